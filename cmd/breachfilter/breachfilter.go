@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/sha1"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bubalync/uni-auth/pkg/breach"
+)
+
+const (
+	_defaultOut               = "breached.bloom"
+	_defaultFalsePositiveRate = 0.001
+)
+
+// The filter is built in two passes over the dump, the first counts the hashes to size it.
+func main() {
+	var dumpPath, outPath string
+	var falsePositiveRate float64
+	var minCount int
+
+	flag.StringVar(&dumpPath, "dump", "", "path to the dump of SHA-1 hashes, e.g. pwned-passwords-sha1-ordered-by-count.txt")
+	flag.StringVar(&outPath, "out", _defaultOut, "path to the filter file")
+	flag.Float64Var(&falsePositiveRate, "fp-rate", _defaultFalsePositiveRate, "false positive rate of the filter")
+	flag.IntVar(&minCount, "min-count", 1, "skip the hashes seen fewer times, to make the filter smaller")
+	flag.Parse()
+
+	if dumpPath == "" {
+		panic("dump is required")
+	}
+
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		log.Fatalf("BreachFilter: fp-rate must be between 0 and 1")
+	}
+
+	var n uint64
+	if err := readDump(dumpPath, minCount, func([sha1.Size]byte) { n++ }); err != nil {
+		log.Fatalf("BreachFilter: count hashes: %s", err)
+	}
+
+	filter := breach.NewFilter(n, falsePositiveRate)
+	if err := readDump(dumpPath, minCount, filter.Add); err != nil {
+		log.Fatalf("BreachFilter: add hashes: %s", err)
+	}
+
+	// the filter is replaced at once, a running app never reads a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(outPath), filepath.Base(outPath)+".*")
+	if err != nil {
+		log.Fatalf("BreachFilter: create filter file: %s", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err = filter.WriteTo(tmp); err != nil {
+		log.Fatalf("BreachFilter: write filter: %s", err)
+	}
+
+	if err = tmp.Chmod(0o644); err != nil {
+		log.Fatalf("BreachFilter: write filter: %s", err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		log.Fatalf("BreachFilter: write filter: %s", err)
+	}
+
+	if err = os.Rename(tmp.Name(), outPath); err != nil {
+		log.Fatalf("BreachFilter: write filter: %s", err)
+	}
+
+	log.Printf("BreachFilter: %d hashes written to %s", n, outPath)
+}
+
+func readDump(path string, minCount int, fn func(hash [sha1.Size]byte)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	return breach.ReadDump(file, minCount, fn)
+}
@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"context"
+	"github.com/bubalync/uni-auth/internal/lib/ratelimit"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net"
+	"strings"
+)
+
+// rateLimitInterceptor throttles the methods that have rules, the route is the full method,
+// e.g. "/auth.v1.AuthService/ValidateToken". The rate limit headers are sent as metadata.
+// Requests are let through when the counters cannot be reached.
+func rateLimitInterceptor(log *slog.Logger, limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		res, ok, err := limiter.Allow(ctx, info.FullMethod, func(kind string) string {
+			return rateLimitKey(ctx, req, kind)
+		})
+		if err != nil {
+			log.Error("failed to check the rate limit", sl.Err(err), slog.String("method", info.FullMethod))
+			return handler(ctx, req)
+		}
+
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		md := metadata.MD{}
+		for name, value := range res.Headers() {
+			md.Set(name, value)
+		}
+		_ = grpc.SetHeader(ctx, md)
+
+		if !res.Allowed {
+			return nil, status.Error(codes.ResourceExhausted, "too many requests, try again later")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitKey returns the value of the request that a rule counts by,
+// the email, user id and client id are the fields of the request message.
+func rateLimitKey(ctx context.Context, req any, kind string) string {
+	switch kind {
+	case ratelimit.KeyIP:
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return ""
+		}
+
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			return p.Addr.String()
+		}
+		return host
+	case ratelimit.KeyEmail:
+		if r, ok := req.(interface{ GetEmail() string }); ok {
+			return strings.ToLower(r.GetEmail())
+		}
+	case ratelimit.KeyUserId:
+		if r, ok := req.(interface{ GetUserId() string }); ok {
+			return r.GetUserId()
+		}
+	case ratelimit.KeyClientId:
+		if r, ok := req.(interface{ GetClientId() string }); ok {
+			return r.GetClientId()
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"context"
+	"github.com/bubalync/uni-auth/internal/lib/ratelimit"
+	authv1 "github.com/bubalync/uni-auth/internal/proto/v1"
+	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRateLimitInterceptor(t *testing.T) {
+	const method = "/auth.v1.AuthService/ValidateToken"
+
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), map[string][]ratelimit.Rule{
+		method: {{Key: ratelimit.KeyIP, Requests: 1, Window: time.Minute}},
+	})
+	interceptor := rateLimitInterceptor(logger.New("local", "info"), limiter)
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		return &authv1.ValidateTokenResponse{IsValid: true}, nil
+	}
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 5000}})
+	req := &authv1.ValidateTokenRequest{AccessToken: "token"}
+
+	res, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	require.NoError(t, err)
+	assert.True(t, res.(*authv1.ValidateTokenResponse).GetIsValid())
+
+	_, err = interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// another client is counted apart
+	other := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("192.0.2.2"), Port: 5000}})
+	_, err = interceptor(other, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	assert.NoError(t, err)
+
+	// methods without rules are not limited
+	_, err = interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/auth.v1.AuthService/GetJWKS"}, handler)
+	assert.NoError(t, err)
+}
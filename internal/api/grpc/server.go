@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	v1 "github.com/bubalync/uni-auth/internal/api/grpc/v1"
+	"github.com/bubalync/uni-auth/internal/lib/ratelimit"
+	authv1 "github.com/bubalync/uni-auth/internal/proto/v1"
 	"github.com/bubalync/uni-auth/internal/service"
 	"net"
+	"strings"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
@@ -21,17 +24,11 @@ type Server struct {
 	port    int
 }
 
-func NewServer(log *slog.Logger, services *service.Services, port int) *Server {
+// NewServer -.
+// The admin service is only registered when adminApiKey is set.
+func NewServer(log *slog.Logger, services *service.Services, limiter *ratelimit.Limiter, port int, adminApiKey string) *Server {
 	// TODO continue server setup: otel, etc...
 
-	loggingOpts := []logging.Option{
-		logging.WithLogOnEvents(
-			logging.StartCall, logging.FinishCall,
-			logging.PayloadReceived, logging.PayloadSent,
-		),
-		// Add any other option (check functions starting with logging.With).
-	}
-
 	recoveryOpts := []recovery.Option{
 		recovery.WithRecoveryHandler(func(p interface{}) (err error) {
 			log.Error("Recovered from panic", slog.Any("panic", p))
@@ -43,12 +40,16 @@ func NewServer(log *slog.Logger, services *service.Services, port int) *Server {
 	grpcSrv := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
 			recovery.UnaryServerInterceptor(recoveryOpts...),
-			logging.UnaryServerInterceptor(interceptorLogger(log), loggingOpts...),
+			loggingInterceptor(log),
+			rateLimitInterceptor(log, limiter),
 		),
 	)
 
 	// handlers
 	v1.NewAuthServer(grpcSrv, services.Auth)
+	if adminApiKey != "" {
+		v1.NewAdminServer(grpcSrv, services.Client, adminApiKey)
+	}
 
 	return &Server{
 		log:     log,
@@ -57,6 +58,31 @@ func NewServer(log *slog.Logger, services *service.Services, port int) *Server {
 	}
 }
 
+// loggingInterceptor logs the calls with their payloads, except for the admin service:
+// its responses carry plaintext client secrets.
+func loggingInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	withPayloads := logging.UnaryServerInterceptor(interceptorLogger(log),
+		logging.WithLogOnEvents(
+			logging.StartCall, logging.FinishCall,
+			logging.PayloadReceived, logging.PayloadSent,
+		),
+		// Add any other option (check functions starting with logging.With).
+	)
+	withoutPayloads := logging.UnaryServerInterceptor(interceptorLogger(log),
+		logging.WithLogOnEvents(logging.StartCall, logging.FinishCall),
+	)
+
+	adminPrefix := "/" + authv1.AdminService_ServiceDesc.ServiceName + "/"
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, adminPrefix) {
+			return withoutPayloads(ctx, req, info, handler)
+		}
+
+		return withPayloads(ctx, req, info, handler)
+	}
+}
+
 // interceptorLogger adapts slog logger to interceptor logger.
 // This code is simple enough to be copied and not imported.
 func interceptorLogger(l *slog.Logger) logging.Logger {
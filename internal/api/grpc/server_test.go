@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	authv1 "github.com/bubalync/uni-auth/internal/proto/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"log/slog"
+	"testing"
+)
+
+func TestLoggingInterceptor(t *testing.T) {
+	testCases := []struct {
+		name         string
+		method       string
+		res          any
+		wantPayloads bool
+	}{
+		{
+			name:         "auth service",
+			method:       authv1.AuthService_ValidateToken_FullMethodName,
+			res:          &authv1.ValidateTokenResponse{IsValid: true},
+			wantPayloads: true,
+		},
+		{
+			name:   "create client",
+			method: authv1.AdminService_CreateClient_FullMethodName,
+			res:    &authv1.CreateClientResponse{ClientSecret: "plaintext-secret"},
+		},
+		{
+			name:   "rotate client secret",
+			method: authv1.AdminService_RotateClientSecret_FullMethodName,
+			res:    &authv1.RotateClientSecretResponse{ClientSecret: "plaintext-secret"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			interceptor := loggingInterceptor(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+			handler := func(ctx context.Context, req any) (any, error) {
+				return tc.res, nil
+			}
+
+			_, err := interceptor(context.Background(), &authv1.ValidateTokenRequest{}, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+			require.NoError(t, err)
+
+			assert.Contains(t, buf.String(), "finished call")
+			assert.NotContains(t, buf.String(), "plaintext-secret")
+			if tc.wantPayloads {
+				assert.Contains(t, buf.String(), "response sent")
+			} else {
+				assert.NotContains(t, buf.String(), "response sent")
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package v1
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	authv1 "github.com/bubalync/uni-auth/internal/proto/v1"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/client"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// AdminApiKeyMetadata is the metadata key that carries the admin api key.
+const AdminApiKeyMetadata = "x-admin-api-key"
+
+type adminServerApi struct {
+	authv1.UnimplementedAdminServiceServer
+	cs     service.Client
+	apiKey []byte
+}
+
+func NewAdminServer(gRPCServer *grpc.Server, cs service.Client, apiKey string) {
+	authv1.RegisterAdminServiceServer(gRPCServer, &adminServerApi{cs: cs, apiKey: []byte(apiKey)})
+}
+
+func (s *adminServerApi) CreateClient(ctx context.Context, req *authv1.CreateClientRequest) (*authv1.CreateClientResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	if req.GetAccessTokenTtl() < 0 || req.GetRefreshTokenTtl() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "token lifetimes must not be negative")
+	}
+
+	out, err := s.cs.Create(ctx, client.CreateClientInput{
+		Id:              req.GetId(),
+		Name:            req.GetName(),
+		Confidential:    req.GetConfidential(),
+		RedirectURIs:    req.GetRedirectUris(),
+		GrantTypes:      req.GetGrantTypes(),
+		Scopes:          req.GetScopes(),
+		Audience:        req.GetAudience(),
+		AccessTokenTTL:  time.Duration(req.GetAccessTokenTtl()) * time.Second,
+		RefreshTokenTTL: time.Duration(req.GetRefreshTokenTtl()) * time.Second,
+	})
+	if err != nil {
+		return nil, clientStatus(err)
+	}
+
+	return &authv1.CreateClientResponse{
+		Client:       toProtoClient(out.Client),
+		ClientSecret: out.Secret,
+	}, nil
+}
+
+func (s *adminServerApi) GetClient(ctx context.Context, req *authv1.GetClientRequest) (*authv1.Client, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	c, err := s.cs.Client(ctx, req.GetId())
+	if err != nil {
+		return nil, clientStatus(err)
+	}
+
+	return toProtoClient(c), nil
+}
+
+func (s *adminServerApi) ListClients(ctx context.Context, req *authv1.ListClientsRequest) (*authv1.ListClientsResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	clients, err := s.cs.Clients(ctx)
+	if err != nil {
+		return nil, clientStatus(err)
+	}
+
+	resp := &authv1.ListClientsResponse{Clients: make([]*authv1.Client, 0, len(clients))}
+	for _, c := range clients {
+		resp.Clients = append(resp.Clients, toProtoClient(c))
+	}
+
+	return resp, nil
+}
+
+func (s *adminServerApi) UpdateClient(ctx context.Context, req *authv1.UpdateClientRequest) (*authv1.Client, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	if req.GetAccessTokenTtl() < 0 || req.GetRefreshTokenTtl() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "token lifetimes must not be negative")
+	}
+
+	c, err := s.cs.Update(ctx, client.UpdateClientInput{
+		Id:              req.GetId(),
+		Name:            req.GetName(),
+		RedirectURIs:    req.GetRedirectUris(),
+		GrantTypes:      req.GetGrantTypes(),
+		Scopes:          req.GetScopes(),
+		Audience:        req.GetAudience(),
+		AccessTokenTTL:  time.Duration(req.GetAccessTokenTtl()) * time.Second,
+		RefreshTokenTTL: time.Duration(req.GetRefreshTokenTtl()) * time.Second,
+	})
+	if err != nil {
+		return nil, clientStatus(err)
+	}
+
+	return toProtoClient(c), nil
+}
+
+func (s *adminServerApi) DeleteClient(ctx context.Context, req *authv1.DeleteClientRequest) (*authv1.DeleteClientResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := s.cs.Delete(ctx, req.GetId()); err != nil {
+		return nil, clientStatus(err)
+	}
+
+	return &authv1.DeleteClientResponse{}, nil
+}
+
+func (s *adminServerApi) RotateClientSecret(ctx context.Context, req *authv1.RotateClientSecretRequest) (*authv1.RotateClientSecretResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	secret, err := s.cs.RotateSecret(ctx, req.GetId())
+	if err != nil {
+		return nil, clientStatus(err)
+	}
+
+	return &authv1.RotateClientSecretResponse{ClientSecret: secret}, nil
+}
+
+// authorize checks the admin api key the same way the HTTP admin middleware does.
+func (s *adminServerApi) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	keys := md.Get(AdminApiKeyMetadata)
+	if len(keys) == 0 || keys[0] == "" || subtle.ConstantTimeCompare([]byte(keys[0]), s.apiKey) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid api key")
+	}
+
+	return nil
+}
+
+func clientStatus(err error) error {
+	switch {
+	case errors.Is(err, svcErrs.ErrClientNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, svcErrs.ErrClientAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, svcErrs.ErrInvalidClientConfig):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func toProtoClient(c entity.Client) *authv1.Client {
+	return &authv1.Client{
+		Id:              c.Id,
+		Name:            c.Name,
+		Confidential:    c.IsConfidential(),
+		RedirectUris:    c.RedirectURIs,
+		GrantTypes:      c.GrantTypes,
+		Scopes:          c.Scopes,
+		Audience:        c.Audience,
+		AccessTokenTtl:  int64(c.AccessTokenTTL.Seconds()),
+		RefreshTokenTtl: int64(c.RefreshTokenTTL.Seconds()),
+		CreatedAt:       c.CreatedAt.Unix(),
+		UpdatedAt:       c.UpdatedAt.Unix(),
+	}
+}
@@ -0,0 +1,206 @@
+package v1
+
+import (
+	"context"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	authv1 "github.com/bubalync/uni-auth/internal/proto/v1"
+	"github.com/bubalync/uni-auth/internal/service/client"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+)
+
+const adminApiKey = "admin-key"
+
+func newAdminGRPCClient(t *testing.T, cs *servicemocks.MockClient) authv1.AdminServiceClient {
+	lis := bufconn.Listen(bufSize)
+	s := grpc.NewServer()
+	NewAdminServer(s, cs, adminApiKey)
+
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+
+	cc, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+
+	return authv1.NewAdminServiceClient(cc)
+}
+
+func adminContext(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), AdminApiKeyMetadata, key)
+}
+
+func TestAdminGRPCRoutes_CreateClient(t *testing.T) {
+	request := &authv1.CreateClientRequest{
+		Id:             "example-backend",
+		Name:           "Example backend",
+		Confidential:   true,
+		RedirectUris:   []string{"https://app.example.com/callback"},
+		GrantTypes:     []string{"authorization_code"},
+		AccessTokenTtl: 300,
+	}
+
+	type MockBehaviour func(m *servicemocks.MockClient)
+
+	testCases := []struct {
+		name          string
+		ctx           context.Context
+		request       *authv1.CreateClientRequest
+		mockBehaviour MockBehaviour
+		wantCode      codes.Code
+	}{
+		{
+			name:    "OK",
+			ctx:     adminContext(adminApiKey),
+			request: request,
+			mockBehaviour: func(m *servicemocks.MockClient) {
+				m.EXPECT().Create(gomock.Any(), client.CreateClientInput{
+					Id:             "example-backend",
+					Name:           "Example backend",
+					Confidential:   true,
+					RedirectURIs:   []string{"https://app.example.com/callback"},
+					GrantTypes:     []string{"authorization_code"},
+					AccessTokenTTL: 5 * time.Minute,
+				}).Return(client.CreateClientOutput{
+					Client: entity.Client{Id: "example-backend", SecretHash: []byte("hash"), AccessTokenTTL: 5 * time.Minute},
+					Secret: "secret",
+				}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:          "no api key",
+			ctx:           context.Background(),
+			request:       request,
+			mockBehaviour: func(m *servicemocks.MockClient) {},
+			wantCode:      codes.Unauthenticated,
+		},
+		{
+			name:          "wrong api key",
+			ctx:           adminContext("wrong"),
+			request:       request,
+			mockBehaviour: func(m *servicemocks.MockClient) {},
+			wantCode:      codes.Unauthenticated,
+		},
+		{
+			name:          "no name",
+			ctx:           adminContext(adminApiKey),
+			request:       &authv1.CreateClientRequest{Id: "example-backend"},
+			mockBehaviour: func(m *servicemocks.MockClient) {},
+			wantCode:      codes.InvalidArgument,
+		},
+		{
+			name:    "client already exists",
+			ctx:     adminContext(adminApiKey),
+			request: request,
+			mockBehaviour: func(m *servicemocks.MockClient) {
+				m.EXPECT().Create(gomock.Any(), gomock.Any()).Return(client.CreateClientOutput{}, svcErrs.ErrClientAlreadyExists)
+			},
+			wantCode: codes.AlreadyExists,
+		},
+		{
+			name:    "invalid settings",
+			ctx:     adminContext(adminApiKey),
+			request: request,
+			mockBehaviour: func(m *servicemocks.MockClient) {
+				m.EXPECT().Create(gomock.Any(), gomock.Any()).Return(client.CreateClientOutput{}, svcErrs.ErrInvalidClientConfig)
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:    "internal error",
+			ctx:     adminContext(adminApiKey),
+			request: request,
+			mockBehaviour: func(m *servicemocks.MockClient) {
+				m.EXPECT().Create(gomock.Any(), gomock.Any()).Return(client.CreateClientOutput{}, svcErrs.ErrCannotCreateClient)
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cs := servicemocks.NewMockClient(ctrl)
+			tc.mockBehaviour(cs)
+
+			resp, err := newAdminGRPCClient(t, cs).CreateClient(tc.ctx, tc.request)
+			if tc.wantCode != codes.OK {
+				assert.Equal(t, tc.wantCode, status.Code(err))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "example-backend", resp.GetClient().GetId())
+			assert.True(t, resp.GetClient().GetConfidential())
+			assert.Equal(t, int64(300), resp.GetClient().GetAccessTokenTtl())
+			assert.Equal(t, "secret", resp.GetClientSecret())
+		})
+	}
+}
+
+func TestAdminGRPCRoutes_GetClient(t *testing.T) {
+	type MockBehaviour func(m *servicemocks.MockClient)
+
+	testCases := []struct {
+		name          string
+		mockBehaviour MockBehaviour
+		wantCode      codes.Code
+	}{
+		{
+			name: "OK",
+			mockBehaviour: func(m *servicemocks.MockClient) {
+				m.EXPECT().Client(gomock.Any(), "example-spa").Return(entity.Client{Id: "example-spa", Name: "Example SPA"}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "client not found",
+			mockBehaviour: func(m *servicemocks.MockClient) {
+				m.EXPECT().Client(gomock.Any(), "example-spa").Return(entity.Client{}, svcErrs.ErrClientNotFound)
+			},
+			wantCode: codes.NotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cs := servicemocks.NewMockClient(ctrl)
+			tc.mockBehaviour(cs)
+
+			resp, err := newAdminGRPCClient(t, cs).GetClient(adminContext(adminApiKey), &authv1.GetClientRequest{Id: "example-spa"})
+			if tc.wantCode != codes.OK {
+				assert.Equal(t, tc.wantCode, status.Code(err))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "Example SPA", resp.GetName())
+			assert.False(t, resp.GetConfidential())
+		})
+	}
+}
@@ -2,11 +2,14 @@ package v1
 
 import (
 	"context"
+	"errors"
 	authv1 "github.com/bubalync/uni-auth/internal/proto/v1"
 	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"strings"
 )
 
 type serverApi struct {
@@ -23,14 +26,54 @@ func (s *serverApi) ValidateToken(ctx context.Context, req *authv1.ValidateToken
 		return nil, status.Error(codes.InvalidArgument, "access token is required")
 	}
 
-	claims, err := s.as.ParseToken(req.GetAccessToken())
+	claims, err := s.as.ParseToken(ctx, req.GetAccessToken())
 	if err != nil {
+		// the denylist could not be checked, the token is neither valid nor invalid
+		if errors.Is(err, svcErrs.ErrAccessToCache) {
+			return nil, status.Error(codes.Internal, "internal error")
+		}
+
 		return &authv1.ValidateTokenResponse{IsValid: false}, nil
 	}
 
+	if claims.IsService() {
+		return &authv1.ValidateTokenResponse{
+			IsValid:       true,
+			PrincipalType: authv1.PrincipalType_PRINCIPAL_TYPE_SERVICE,
+			ClientId:      claims.Subject,
+			Scopes:        strings.Fields(claims.Scope),
+		}, nil
+	}
+
 	return &authv1.ValidateTokenResponse{
-		IsValid: true,
-		UserId:  claims.UserId.String(),
-		Email:   claims.Email,
+		IsValid:       true,
+		UserId:        claims.UserId.String(),
+		Email:         claims.Email,
+		PrincipalType: authv1.PrincipalType_PRINCIPAL_TYPE_USER,
+		ClientId:      claims.AuthorizedParty,
 	}, nil
 }
+
+func (s *serverApi) GetJWKS(ctx context.Context, req *authv1.GetJWKSRequest) (*authv1.GetJWKSResponse, error) {
+	set, err := s.as.JWKS()
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	keys := make([]*authv1.JWK, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		keys = append(keys, &authv1.JWK{
+			Kid: k.Kid,
+			Kty: k.Kty,
+			Alg: k.Alg,
+			Use: k.Use,
+			N:   k.N,
+			E:   k.E,
+			Crv: k.Crv,
+			X:   k.X,
+			Y:   k.Y,
+		})
+	}
+
+	return &authv1.GetJWKSResponse{Keys: keys}, nil
+}
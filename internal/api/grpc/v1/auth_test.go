@@ -6,6 +6,8 @@ import (
 	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
 	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
 	authv1 "github.com/bubalync/uni-auth/internal/proto/v1"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -13,6 +15,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
 	"net"
 	"testing"
 )
@@ -74,12 +77,36 @@ func TestAuthGRPCRoutes_ValidateToken(t *testing.T) {
 					UserId: uuid.MustParse("00000000-0000-0000-0000-000000000001"),
 					Email:  "test@example.com",
 				}
-				m.EXPECT().ParseToken(args.request.AccessToken).Return(claims, nil)
+				m.EXPECT().ParseToken(gomock.Any(), args.request.AccessToken).Return(claims, nil)
 			},
 			wantResponse: &authv1.ValidateTokenResponse{
-				IsValid: true,
-				UserId:  "00000000-0000-0000-0000-000000000001",
-				Email:   "test@example.com",
+				IsValid:       true,
+				UserId:        "00000000-0000-0000-0000-000000000001",
+				Email:         "test@example.com",
+				PrincipalType: authv1.PrincipalType_PRINCIPAL_TYPE_USER,
+			},
+			wantErr: false,
+		},
+		{
+			name: "OK: service principal",
+			args: args{
+				ctx:     context.Background(),
+				request: &authv1.ValidateTokenRequest{AccessToken: "service-token"},
+			},
+			mockBehaviour: func(m *servicemocks.MockAuth, args args) {
+				claims := &jwtgen.Claims{
+					TokenUse:         jwtgen.TokenUseService,
+					AuthorizedParty:  "billing",
+					Scope:            "orders:read orders:write",
+					RegisteredClaims: jwt.RegisteredClaims{Subject: "billing"},
+				}
+				m.EXPECT().ParseToken(gomock.Any(), args.request.AccessToken).Return(claims, nil)
+			},
+			wantResponse: &authv1.ValidateTokenResponse{
+				IsValid:       true,
+				PrincipalType: authv1.PrincipalType_PRINCIPAL_TYPE_SERVICE,
+				ClientId:      "billing",
+				Scopes:        []string{"orders:read", "orders:write"},
 			},
 			wantErr: false,
 		},
@@ -100,15 +127,25 @@ func TestAuthGRPCRoutes_ValidateToken(t *testing.T) {
 				request: &authv1.ValidateTokenRequest{AccessToken: "valid-token"},
 			},
 			mockBehaviour: func(m *servicemocks.MockAuth, args args) {
-				m.EXPECT().ParseToken(args.request.AccessToken).Return(nil, errors.New("some error"))
+				m.EXPECT().ParseToken(gomock.Any(), args.request.AccessToken).Return(nil, errors.New("some error"))
 			},
 			wantResponse: &authv1.ValidateTokenResponse{
 				IsValid: false,
-				UserId:  "",
-				Email:   "",
 			},
 			wantErr: false,
 		},
+		{
+			name: "auth service: denylist unavailable",
+			args: args{
+				ctx:     context.Background(),
+				request: &authv1.ValidateTokenRequest{AccessToken: "valid-token"},
+			},
+			mockBehaviour: func(m *servicemocks.MockAuth, args args) {
+				m.EXPECT().ParseToken(gomock.Any(), args.request.AccessToken).Return(nil, svcErrs.ErrAccessToCache)
+			},
+			wantResponse: nil,
+			wantErr:      true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -139,9 +176,67 @@ func TestAuthGRPCRoutes_ValidateToken(t *testing.T) {
 			}
 
 			require.NoError(t, err)
-			assert.Equal(t, tc.wantResponse.IsValid, resp.IsValid)
-			assert.Equal(t, tc.wantResponse.UserId, resp.UserId)
-			assert.Equal(t, tc.wantResponse.Email, resp.Email)
+			assert.True(t, proto.Equal(tc.wantResponse, resp))
+		})
+	}
+}
+
+func TestAuthGRPCRoutes_GetJWKS(t *testing.T) {
+	type MockBehaviour func(m *servicemocks.MockAuth)
+
+	testCases := []struct {
+		name          string
+		mockBehaviour MockBehaviour
+		wantKeys      []*authv1.JWK
+		wantErr       bool
+	}{
+		{
+			name: "OK",
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().JWKS().Return(jwtgen.JWKS{Keys: []jwtgen.JWK{
+					{Kid: "active", Kty: "EC", Alg: "ES256", Use: "sig", Crv: "P-256", X: "x", Y: "y"},
+					{Kid: "retiring", Kty: "OKP", Alg: "EdDSA", Use: "sig", Crv: "Ed25519", X: "x"},
+				}}, nil)
+			},
+			wantKeys: []*authv1.JWK{
+				{Kid: "active", Kty: "EC", Alg: "ES256", Use: "sig", Crv: "P-256", X: "x", Y: "y"},
+				{Kid: "retiring", Kty: "OKP", Alg: "EdDSA", Use: "sig", Crv: "Ed25519", X: "x"},
+			},
+		},
+		{
+			name: "auth service error",
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().JWKS().Return(jwtgen.JWKS{}, errors.New("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			as := servicemocks.NewMockAuth(ctrl)
+			tc.mockBehaviour(as)
+
+			lis, server := startGRPCServer(t, as)
+			defer server.Stop()
+
+			cc, client := newGRPCClient(t, lis)
+			defer cc.Close()
+
+			resp, err := client.GetJWKS(context.Background(), &authv1.GetJWKSRequest{})
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, resp.Keys, len(tc.wantKeys))
+			for i, k := range tc.wantKeys {
+				assert.True(t, proto.Equal(k, resp.Keys[i]))
+			}
 		})
 	}
 }
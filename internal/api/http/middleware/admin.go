@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const AdminApiKeyHeader = "X-Admin-Api-Key"
+
+type AdminMiddleware struct {
+	apiKey []byte
+}
+
+func NewAdminMiddleware(apiKey string) *AdminMiddleware {
+	return &AdminMiddleware{apiKey: []byte(apiKey)}
+}
+
+// AdminIdentity lets through requests that carry the admin api key.
+func (m *AdminMiddleware) AdminIdentity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(AdminApiKeyHeader)
+		if key == "" || subtle.ConstantTimeCompare([]byte(key), m.apiKey) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response.Error(response.ErrInvalidApiKey.Error()))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"errors"
 	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
 	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
 	"net/http"
 	"strings"
 
@@ -10,7 +13,14 @@ import (
 )
 
 const (
-	UserIdKey = "user_id"
+	UserIdKey    = "user_id"
+	SessionIdKey = "session_id"
+	// ClientIdKey and ScopeKey are set for service principals,
+	// which hold tokens issued with the client_credentials grant.
+	ClientIdKey = "client_id"
+	ScopeKey    = "scope"
+	// ClaimsKey holds the *jwtgen.Claims of the token, e.g. to revoke it.
+	ClaimsKey = "claims"
 )
 
 type AuthMiddleware struct {
@@ -21,22 +31,66 @@ func NewAuthMiddleware(authService service.Auth) *AuthMiddleware {
 	return &AuthMiddleware{authService: authService}
 }
 
-func (m *AuthMiddleware) UserIdentity() gin.HandlerFunc {
+// Identity lets through both users and service principals.
+func (m *AuthMiddleware) Identity() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, response.Error(response.ErrInvalidAuthHeader.Error()))
+		claims, ok := m.claims(c)
+		if !ok {
 			return
 		}
 
-		token := strings.TrimPrefix(authHeader, "Bearer ")
+		if claims.IsService() {
+			c.Set(ClientIdKey, claims.Subject)
+			c.Set(ScopeKey, claims.Scope)
+		} else {
+			c.Set(UserIdKey, claims.UserId)
+			c.Set(SessionIdKey, claims.SessionId)
+		}
+		c.Next()
+	}
+}
 
-		claims, err := m.authService.ParseToken(token)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, response.Error(response.ErrInvalidToken.Error()))
+// UserIdentity lets through users only, the routes behind it act on behalf of the user.
+func (m *AuthMiddleware) UserIdentity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := m.claims(c)
+		if !ok {
 			return
 		}
+
+		if claims.IsService() {
+			c.AbortWithStatusJSON(http.StatusForbidden, response.Error(response.ErrUserTokenRequired.Error()))
+			return
+		}
+
 		c.Set(UserIdKey, claims.UserId)
+		c.Set(SessionIdKey, claims.SessionId)
 		c.Next()
 	}
 }
+
+// claims parses the bearer token of the request and aborts it when the token is missing or invalid.
+func (m *AuthMiddleware) claims(c *gin.Context) (*jwtgen.Claims, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, response.Error(response.ErrInvalidAuthHeader.Error()))
+		return nil, false
+	}
+
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+
+	claims, err := m.authService.ParseToken(c.Request.Context(), token)
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrAccessToCache) {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, response.ErrorInternal())
+			return nil, false
+		}
+
+		c.AbortWithStatusJSON(http.StatusUnauthorized, response.Error(response.ErrInvalidToken.Error()))
+		return nil, false
+	}
+
+	c.Set(ClaimsKey, claims)
+
+	return claims, true
+}
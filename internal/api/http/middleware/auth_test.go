@@ -4,7 +4,9 @@ import (
 	"errors"
 	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
 	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -30,11 +32,21 @@ func TestUserIdentity(t *testing.T) {
 			accessToken: `Bearer valid_access_token`,
 			mockBehaviour: func(a *servicemocks.MockAuth) {
 				claims := &jwtgen.Claims{UserId: uuid.MustParse("0148edcd-e2a0-48b8-a47a-c6de5bbe4ed5")}
-				a.EXPECT().ParseToken("valid_access_token").Return(claims, nil)
+				a.EXPECT().ParseToken(gomock.Any(), "valid_access_token").Return(claims, nil)
 			},
 			wantStatusCode:   200,
 			wantResponseBody: `{"user_id":"0148edcd-e2a0-48b8-a47a-c6de5bbe4ed5"}`,
 		},
+		{
+			name:        "service principal",
+			accessToken: `Bearer service_token`,
+			mockBehaviour: func(a *servicemocks.MockAuth) {
+				claims := &jwtgen.Claims{TokenUse: jwtgen.TokenUseService, RegisteredClaims: jwt.RegisteredClaims{Subject: "billing"}}
+				a.EXPECT().ParseToken(gomock.Any(), "service_token").Return(claims, nil)
+			},
+			wantStatusCode:   403,
+			wantResponseBody: `{"errors":{"message":"the token must be issued to a user"}}`,
+		},
 		{
 			name:             "authorization header empty",
 			accessToken:      "",
@@ -53,11 +65,29 @@ func TestUserIdentity(t *testing.T) {
 			name:        "auth service some error",
 			accessToken: `Bearer valid_access_token`,
 			mockBehaviour: func(a *servicemocks.MockAuth) {
-				a.EXPECT().ParseToken("valid_access_token").Return(nil, errors.New("some error"))
+				a.EXPECT().ParseToken(gomock.Any(), "valid_access_token").Return(nil, errors.New("some error"))
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"invalid token"}}`,
+		},
+		{
+			name:        "revoked token",
+			accessToken: `Bearer revoked_access_token`,
+			mockBehaviour: func(a *servicemocks.MockAuth) {
+				a.EXPECT().ParseToken(gomock.Any(), "revoked_access_token").Return(nil, svcErrs.ErrTokenRevoked)
 			},
 			wantStatusCode:   401,
 			wantResponseBody: `{"errors":{"message":"invalid token"}}`,
 		},
+		{
+			name:        "denylist unavailable",
+			accessToken: `Bearer valid_access_token`,
+			mockBehaviour: func(a *servicemocks.MockAuth) {
+				a.EXPECT().ParseToken(gomock.Any(), "valid_access_token").Return(nil, svcErrs.ErrAccessToCache)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -99,3 +129,76 @@ func TestUserIdentity(t *testing.T) {
 
 	}
 }
+
+func TestIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type MockBehaviour func(m *servicemocks.MockAuth)
+
+	testCases := []struct {
+		name             string
+		accessToken      string
+		mockBehaviour    MockBehaviour
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:        "user",
+			accessToken: `Bearer user_token`,
+			mockBehaviour: func(a *servicemocks.MockAuth) {
+				claims := &jwtgen.Claims{UserId: uuid.MustParse("0148edcd-e2a0-48b8-a47a-c6de5bbe4ed5")}
+				a.EXPECT().ParseToken(gomock.Any(), "user_token").Return(claims, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"client_id":null,"scope":null,"user_id":"0148edcd-e2a0-48b8-a47a-c6de5bbe4ed5"}`,
+		},
+		{
+			name:        "service principal",
+			accessToken: `Bearer service_token`,
+			mockBehaviour: func(a *servicemocks.MockAuth) {
+				claims := &jwtgen.Claims{TokenUse: jwtgen.TokenUseService, Scope: "orders:read", RegisteredClaims: jwt.RegisteredClaims{Subject: "billing"}}
+				a.EXPECT().ParseToken(gomock.Any(), "service_token").Return(claims, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"client_id":"billing","scope":"orders:read","user_id":null}`,
+		},
+		{
+			name:        "invalid token",
+			accessToken: `Bearer invalid_token`,
+			mockBehaviour: func(a *servicemocks.MockAuth) {
+				a.EXPECT().ParseToken(gomock.Any(), "invalid_token").Return(nil, errors.New("some error"))
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"invalid token"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			as := servicemocks.NewMockAuth(ctrl)
+			tc.mockBehaviour(as)
+
+			r := gin.New()
+			r.Use(NewAuthMiddleware(as).Identity())
+			r.GET("/protected", func(c *gin.Context) {
+				userId, _ := c.Get(UserIdKey)
+				clientId, _ := c.Get(ClientIdKey)
+				scope, _ := c.Get(ScopeKey)
+
+				c.JSON(200, gin.H{UserIdKey: userId, ClientIdKey: clientId, ScopeKey: scope})
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", tc.accessToken)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
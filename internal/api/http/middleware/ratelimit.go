@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/lib/ratelimit"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// maxRateLimitBody is the most of a JSON body read for the email key.
+const maxRateLimitBody = 1 << 16
+
+type RateLimitMiddleware struct {
+	log     *slog.Logger
+	limiter *ratelimit.Limiter
+}
+
+func NewRateLimitMiddleware(log *slog.Logger, limiter *ratelimit.Limiter) *RateLimitMiddleware {
+	return &RateLimitMiddleware{log: log, limiter: limiter}
+}
+
+// Limit throttles the routes that have rules, the route is the method and the path pattern, e.g. "POST /auth/sign-up".
+// It goes after the identity middleware of the group, so that rules can count by the user or the client.
+// Requests are let through when the counters cannot be reached.
+func (m *RateLimitMiddleware) Limit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+
+		res, ok, err := m.limiter.Allow(c.Request.Context(), route, func(kind string) string {
+			return rateLimitKey(c, kind)
+		})
+		if err != nil {
+			m.log.Error("failed to check the rate limit", sl.Err(err), slog.String("route", route))
+			c.Next()
+			return
+		}
+
+		if !ok {
+			c.Next()
+			return
+		}
+
+		for name, value := range res.Headers() {
+			c.Header(name, value)
+		}
+
+		if !res.Allowed {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, response.Error(response.ErrTooManyRequests.Error()))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey returns the value of the request that a rule counts by.
+func rateLimitKey(c *gin.Context, kind string) string {
+	switch kind {
+	case ratelimit.KeyIP:
+		return c.ClientIP()
+	case ratelimit.KeyUserId:
+		if userId, ok := c.Get(UserIdKey); ok {
+			return userId.(uuid.UUID).String()
+		}
+	case ratelimit.KeyClientId:
+		if clientId := c.GetString(ClientIdKey); clientId != "" {
+			return clientId
+		}
+		if clientId, _, ok := c.Request.BasicAuth(); ok {
+			return clientId
+		}
+		return c.PostForm("client_id")
+	case ratelimit.KeyEmail:
+		return strings.ToLower(requestEmail(c))
+	}
+
+	return ""
+}
+
+// requestEmail reads the email of a JSON or a form body, the body is left for the handler.
+func requestEmail(c *gin.Context) string {
+	if c.ContentType() != gin.MIMEJSON {
+		return c.PostForm("email")
+	}
+
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRateLimitBody))
+	if err != nil {
+		return ""
+	}
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err = json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+
+	return req.Email
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"bytes"
+	"github.com/bubalync/uni-auth/internal/lib/ratelimit"
+	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), map[string][]ratelimit.Rule{
+		"POST /auth/reset-password": {
+			{Key: ratelimit.KeyIP, Requests: 3, Window: time.Hour},
+			{Key: ratelimit.KeyEmail, Requests: 2, Window: time.Hour},
+		},
+	})
+
+	e := gin.New()
+	g := e.Group("/auth", NewRateLimitMiddleware(logger.New("local", "info"), limiter).Limit())
+	g.POST("/reset-password", func(c *gin.Context) {
+		// the handler still reads the body
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+	g.POST("/sign-in", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("/auth/reset-password", `{"email":"Test@example.com"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"email":"Test@example.com"}`, w.Body.String())
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("RateLimit-Reset"))
+
+	w = send("/auth/reset-password", `{"email":"test@example.com"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	// the email is limited in any case
+	w = send("/auth/reset-password", `{"email":"TEST@example.com"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, `{"errors":{"message":"too many requests, try again later"}}`, w.Body.String())
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// another email is limited by the ip
+	w = send("/auth/reset-password", `{"email":"other@example.com"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = send("/auth/reset-password", `{"email":"another@example.com"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// routes without rules are not limited
+	w = send("/auth/sign-in", `{}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
@@ -5,7 +5,9 @@ import (
 	"github.com/bubalync/uni-auth/internal/api/http/middleware"
 	v1 "github.com/bubalync/uni-auth/internal/api/http/v1"
 	"github.com/bubalync/uni-auth/internal/config"
+	"github.com/bubalync/uni-auth/internal/lib/ratelimit"
 	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/pkg/breach"
 	"github.com/bubalync/uni-auth/pkg/validator"
 	"github.com/gin-gonic/gin"
 	sloggin "github.com/samber/slog-gin"
@@ -25,8 +27,11 @@ import (
 // @bearerFormat                 JWT
 // @in                           header
 // @name                         Authorization
+// @securityDefinitions.apikey   AdminApiKey
+// @in                           header
+// @name                         X-Admin-Api-Key
 // @BasePath                     /
-func NewRouter(handler *gin.Engine, cfg *config.Config, log *slog.Logger, services *service.Services) {
+func NewRouter(handler *gin.Engine, cfg *config.Config, log *slog.Logger, services *service.Services, limiter *ratelimit.Limiter, breaches *breach.Filter) {
 	// Middleware
 	handler.Use(gin.Recovery())
 	handler.Use(sloggin.New(log))
@@ -36,17 +41,87 @@ func NewRouter(handler *gin.Engine, cfg *config.Config, log *slog.Logger, servic
 		handler.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 	}
 
-	cv := validator.NewCustomValidator()
+	validatorOpts := []validator.Option{validator.Policy(validator.PasswordPolicy{
+		MinLength: cfg.PasswordPolicy.MinLength,
+		MaxLength: cfg.PasswordPolicy.MaxLength,
+		MinLower:  cfg.PasswordPolicy.MinLower,
+		MinUpper:  cfg.PasswordPolicy.MinUpper,
+		MinDigit:  cfg.PasswordPolicy.MinDigit,
+		MinSymbol: cfg.PasswordPolicy.MinSymbol,
+		Symbols:   cfg.PasswordPolicy.Symbols,
+		MinScore:  cfg.PasswordPolicy.MinScore,
+	})}
+	if breaches != nil {
+		validatorOpts = append(validatorOpts, validator.Breaches(breaches))
+	}
+	cv := validator.NewCustomValidator(validatorOpts...)
+
+	// the rate limit goes after the identity of a group, the rules can count by the user
+	rateLimit := middleware.NewRateLimitMiddleware(log, limiter).Limit()
 
 	// Routes
-	authGroup := handler.Group("/auth")
+	wellKnownGroup := handler.Group("/.well-known", rateLimit)
+	{
+		v1.NewWellKnownRoutes(wellKnownGroup, services.Auth, cfg.OIDC.Issuer, cfg.JWT.Algorithm)
+	}
+
+	authGroup := handler.Group("/auth", rateLimit)
 	{
 		v1.NewAuthRoutes(authGroup, cv, services.Auth)
+
+		if services.Passkey != nil {
+			v1.NewPasskeyAuthRoutes(authGroup, cv, services.Auth, services.Passkey)
+		}
+
+		if services.Federation != nil {
+			v1.NewFederationRoutes(authGroup, cv, services.Federation)
+		}
+	}
+
+	oauthGroup := handler.Group("/oauth", rateLimit)
+	{
+		v1.NewOAuthRoutes(oauthGroup, services.OAuth)
 	}
 
 	authMiddleware := middleware.NewAuthMiddleware(services.Auth)
-	v1Group := handler.Group("/api/v1", authMiddleware.UserIdentity())
+	v1.NewUserInfoRoutes(handler.Group("/userinfo", authMiddleware.UserIdentity(), rateLimit), services.User)
+
+	// the routes for both users and service principals, e.g. backends holding client_credentials tokens
+	principalGroup := handler.Group("/api/v1", authMiddleware.Identity(), rateLimit)
+	{
+		v1.NewUserLookupRoutes(principalGroup.Group("/users"), services.User)
+	}
+
+	v1Group := handler.Group("/api/v1", authMiddleware.UserIdentity(), rateLimit)
 	{
-		v1.NewUserRoutes(v1Group.Group("/users"), log, cv, services.User)
+		v1.NewUserRoutes(v1Group.Group("/users"), log, cv, services.User, services.Auth)
+		v1.NewSessionRoutes(v1Group.Group("/sessions"), services.Auth)
+		v1.NewPhoneRoutes(v1Group.Group("/users/phone"), cv, services.Phone)
+
+		if services.MFA != nil {
+			v1.NewMFARoutes(v1Group.Group("/users/mfa"), cv, services.MFA)
+		}
+
+		if services.Passkey != nil {
+			v1.NewPasskeyRoutes(v1Group.Group("/users/passkeys"), cv, services.Passkey)
+		}
+
+		if services.Federation != nil {
+			v1.NewIdentityRoutes(v1Group.Group("/users/identities"), services.Federation)
+		}
+	}
+
+	if cfg.Admin.ApiKey != "" {
+		adminMiddleware := middleware.NewAdminMiddleware(cfg.Admin.ApiKey)
+		adminGroup := handler.Group("/admin", adminMiddleware.AdminIdentity())
+
+		if services.Keys != nil {
+			v1.NewKeyRoutes(adminGroup.Group("/keys"), services.Keys)
+		}
+		v1.NewClientRoutes(adminGroup.Group("/clients"), cv, services.Client)
+
+		if services.Lockout != nil {
+			v1.NewLockoutRoutes(adminGroup.Group("/users"), cv, services.Lockout)
+		}
 	}
 }
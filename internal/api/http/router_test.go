@@ -0,0 +1,102 @@
+package http
+
+import (
+	"github.com/bubalync/uni-auth/internal/config"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
+	"github.com/bubalync/uni-auth/internal/lib/ratelimit"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewRouter_Principals(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userId := uuid.MustParse("0148edcd-e2a0-48b8-a47a-c6de5bbe4ed5")
+	userClaims := &jwtgen.Claims{UserId: userId}
+	serviceClaims := &jwtgen.Claims{TokenUse: jwtgen.TokenUseService, Scope: "users:read", RegisteredClaims: jwt.RegisteredClaims{Subject: "billing"}}
+
+	type MockBehaviour func(a *servicemocks.MockAuth, u *servicemocks.MockUser)
+
+	testCases := []struct {
+		name           string
+		path           string
+		mockBehaviour  MockBehaviour
+		wantStatusCode int
+	}{
+		{
+			name: "service principal looks up a user",
+			path: "/api/v1/users/" + userId.String(),
+			mockBehaviour: func(a *servicemocks.MockAuth, u *servicemocks.MockUser) {
+				a.EXPECT().ParseToken(gomock.Any(), "access_token").Return(serviceClaims, nil)
+				u.EXPECT().UserById(gomock.Any(), userId).Return(entity.User{Id: userId}, nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "user looks up a user",
+			path: "/api/v1/users/" + userId.String(),
+			mockBehaviour: func(a *servicemocks.MockAuth, u *servicemocks.MockUser) {
+				a.EXPECT().ParseToken(gomock.Any(), "access_token").Return(userClaims, nil)
+				u.EXPECT().UserById(gomock.Any(), userId).Return(entity.User{Id: userId}, nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "service principal on a route of the user",
+			path: "/api/v1/users/",
+			mockBehaviour: func(a *servicemocks.MockAuth, u *servicemocks.MockUser) {
+				a.EXPECT().ParseToken(gomock.Any(), "access_token").Return(serviceClaims, nil)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name: "service principal on userinfo",
+			path: "/userinfo",
+			mockBehaviour: func(a *servicemocks.MockAuth, u *servicemocks.MockUser) {
+				a.EXPECT().ParseToken(gomock.Any(), "access_token").Return(serviceClaims, nil)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			as := servicemocks.NewMockAuth(ctrl)
+			us := servicemocks.NewMockUser(ctrl)
+			tc.mockBehaviour(as, us)
+
+			swagger := false
+			cfg := &config.Config{}
+			cfg.Swagger.Enabled = &swagger
+			cfg.OIDC.Issuer = "https://auth.example.com"
+
+			log := slog.New(slog.NewTextHandler(io.Discard, nil))
+			limiter := ratelimit.New(ratelimit.NewMemoryStore(), nil)
+
+			handler := gin.New()
+			NewRouter(handler, cfg, log, &service.Services{Auth: as, User: us}, limiter, nil)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer access_token")
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
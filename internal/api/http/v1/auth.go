@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/bubalync/uni-auth/internal/lib/api/response"
 	"github.com/bubalync/uni-auth/internal/service"
@@ -22,14 +23,27 @@ func NewAuthRoutes(g *gin.RouterGroup, cv *validator.CustomValidator, authServic
 
 	g.POST("/sign-up", r.signUp)
 	g.POST("/sign-in", r.signIn)
+	g.POST("/mfa/verify", r.verifyMFA)
+	g.POST("/mfa/sms", r.sendMFACode)
 	g.POST("/refresh", r.refresh)
 	g.POST("/reset-password", r.resetPassword)
 	g.POST("/recovery-password", r.recoveryPassword)
+	g.POST("/reset-password/sms", r.resetPasswordBySMS)
+	g.POST("/recovery-password/sms", r.recoveryPasswordBySMS)
+	g.POST("/verify-email", r.verifyEmail)
+	g.POST("/resend-verification", r.resendVerification)
+	g.POST("/magic-link", r.requestMagicLink)
+	g.POST("/magic-link/verify", r.verifyMagicLink)
+	g.POST("/otp/send", r.sendCode)
+	g.POST("/otp/sign-in", r.signInWithCode)
+	g.POST("/otp/verify-email", r.verifyEmailCode)
+	g.GET("/password-policy", r.passwordPolicy)
+	g.POST("/password-check", r.checkPassword)
 }
 
 type signUpRequest struct {
 	Email    string `json:"email"    validate:"required,email,min=5,max=150" minLength:"5" maxLength:"150" example:"email@example.com"`
-	Password string `json:"password" validate:"required,password"            minLength:"8" maxLength:"32"  example:"YourV@lidPassw0rd!"`
+	Password string `json:"password" validate:"required,password"            minLength:"8" maxLength:"64"  example:"YourV@lidPassw0rd!"`
 }
 
 type signUpResponse struct {
@@ -70,6 +84,11 @@ func (r *authRoutes) signUp(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, svcErrs.ErrBreachedPassword) {
+			c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
 		return
 	}
@@ -81,16 +100,39 @@ func (r *authRoutes) signUp(c *gin.Context) {
 
 type signInRequest struct {
 	Email    string `json:"email"    validate:"required,email,min=5,max=150" minLength:"5" maxLength:"150" example:"email@example.com"`
-	Password string `json:"password" validate:"required"                     minLength:"8" maxLength:"32"  example:"YourV@lidPassw0rd!"`
+	Password string `json:"password" validate:"required"                     minLength:"8" maxLength:"64"  example:"YourV@lidPassw0rd!"`
+	// Device label shown in the list of sessions
+	Device string `json:"device" validate:"max=100" maxLength:"100" example:"iPhone 15"`
+	// Nonce is echoed in the ID token
+	Nonce string `json:"nonce" validate:"max=255" maxLength:"255" example:"n-0S6_WzA2Mj"`
 }
 
 type signInResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IdToken      string `json:"id_token,omitempty"`
+	// MFARequired is set instead of the tokens when the user has to present a one-time code
+	MFARequired bool `json:"mfa_required,omitempty"`
+	// MFAToken is exchanged with the one-time code at /auth/mfa/verify
+	MFAToken string `json:"mfa_token,omitempty"`
+}
+
+func newSignInResponse(tokens auth.GenerateTokenOutput) signInResponse {
+	if tokens.MFAToken != "" {
+		return signInResponse{MFARequired: true, MFAToken: tokens.MFAToken}
+	}
+
+	return signInResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		IdToken:      tokens.IdToken,
+	}
 }
 
 // @Summary     Sign in
-// @Description Sign in
+// @Description Sign in. Users with two-factor authentication get mfa_token instead of the tokens.
+// @Description When email verification is required, users who did not verify the email get 403 with the code "email_not_verified".
+// @Description Too many failed sign-ins lock the account (423) or the IP (429) out for a while, see the Retry-After header.
 // @Tags        auth
 // @Accept      json
 // @Produce     json
@@ -98,6 +140,9 @@ type signInResponse struct {
 // @Success     200 {object} signInResponse
 // @Failure     400 {object} response.ErrResponse
 // @Failure     401 {object} response.ErrResponse
+// @Failure     403 {object} response.ErrResponse
+// @Failure     423 {object} response.ErrResponse
+// @Failure     429 {object} response.ErrResponse
 // @Failure     500 {object} response.ErrResponse
 // @Router      /auth/sign-in [post]
 func (r *authRoutes) signIn(c *gin.Context) {
@@ -114,8 +159,12 @@ func (r *authRoutes) signIn(c *gin.Context) {
 	}
 
 	tokens, err := r.as.GenerateToken(c.Request.Context(), auth.GenerateTokenInput{
-		Email:    req.Email,
-		Password: req.Password,
+		Email:     req.Email,
+		Password:  req.Password,
+		Device:    req.Device,
+		Nonce:     req.Nonce,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
 	})
 	if err != nil {
 		if errors.Is(err, svcErrs.ErrInvalidCredentials) {
@@ -123,14 +172,92 @@ func (r *authRoutes) signIn(c *gin.Context) {
 			return
 		}
 
+		if lockoutError(c, err) {
+			return
+		}
+
+		if errors.Is(err, svcErrs.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, response.ErrorWithCode(response.CodeEmailNotVerified, err.Error()))
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
 		return
 	}
 
-	c.JSON(http.StatusOK, signInResponse{
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
-	})
+	c.JSON(http.StatusOK, newSignInResponse(tokens))
+}
+
+type verifyMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	// One-time code of the authenticator app, a recovery code or the code texted by /auth/mfa/sms
+	Code string `json:"code" validate:"required_without=Credential,max=20" maxLength:"20" example:"123456"`
+	// Method is sms for the texted code
+	Method string `json:"method" validate:"omitempty,oneof=sms" enums:"sms" example:"sms"`
+	// SessionId of /auth/mfa/passkey/begin, sent with the credential instead of the code
+	SessionId string `json:"session_id" validate:"required_with=Credential,max=64" maxLength:"64"`
+	// PublicKeyCredential returned by navigator.credentials.get, binary values base64url encoded
+	Credential json.RawMessage `json:"credential" swaggertype:"object"`
+}
+
+// @Summary     Verify second factor
+// @Description Exchanges the mfa_token returned by sign in and a one-time code for tokens.
+// @Description A recovery code is accepted instead of the one-time code, each of them once.
+// @Description Users with the SMS second factor send the code of /auth/mfa/sms with the method sms.
+// @Description Users with a passkey can send the response of /auth/mfa/passkey/begin instead of the code.
+// @Description Several wrong codes of a user lock the account out and drop the mfa_token.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body verifyMFARequest true "Second factor payload"
+// @Success     200 {object} signInResponse
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     423 {object} response.ErrResponse
+// @Failure     429 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/mfa/verify [post]
+func (r *authRoutes) verifyMFA(c *gin.Context) {
+	var req verifyMFARequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	input := auth.VerifyMFAInput{MFAToken: req.MFAToken, Code: req.Code, SMS: req.Method == "sms"}
+	if len(req.Credential) > 0 {
+		input = auth.VerifyMFAInput{MFAToken: req.MFAToken, PasskeySessionId: req.SessionId, PasskeyResponse: req.Credential}
+	}
+
+	tokens, err := r.as.VerifyMFA(c.Request.Context(), input)
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrInvalidMFAToken) || errors.Is(err, svcErrs.ErrInvalidOTP) ||
+			errors.Is(err, svcErrs.ErrMFANotEnabled) || errors.Is(err, svcErrs.ErrInvalidPasskey) ||
+			errors.Is(err, svcErrs.ErrInvalidPasskeyCeremony) {
+			c.JSON(http.StatusUnauthorized, response.Error(err.Error()))
+			return
+		}
+
+		if errors.Is(err, svcErrs.ErrOTPLocked) {
+			c.JSON(http.StatusTooManyRequests, response.Error(err.Error()))
+			return
+		}
+
+		if lockoutError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.JSON(http.StatusOK, newSignInResponse(tokens))
 }
 
 type refreshRequest struct {
@@ -141,10 +268,12 @@ type refreshRequest struct {
 type refreshResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+	IdToken      string `json:"id_token"`
 }
 
 // @Summary     Refresh tokens
-// @Description Refresh tokens by refresh-token
+// @Description Refresh tokens by refresh-token. The refresh token is rotated on every call.
+// @Description Presenting an already rotated token revokes the session and returns the code "refresh_token_reused".
 // @Tags        auth
 // @Accept      json
 // @Produce     json
@@ -169,7 +298,13 @@ func (r *authRoutes) refresh(c *gin.Context) {
 
 	tokens, err := r.as.Refresh(c.Request.Context(), req.Token)
 	if err != nil {
-		if errors.Is(err, svcErrs.ErrCannotParseToken) || errors.Is(err, svcErrs.ErrTokenIsExpired) {
+		if errors.Is(err, svcErrs.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, response.ErrorWithCode(response.CodeRefreshTokenReused, err.Error()))
+			return
+		}
+
+		if errors.Is(err, svcErrs.ErrCannotParseToken) || errors.Is(err, svcErrs.ErrTokenIsExpired) ||
+			errors.Is(err, svcErrs.ErrTokenNotIssuedToClient) {
 			c.JSON(http.StatusUnauthorized, response.Error(err.Error()))
 			return
 		}
@@ -181,6 +316,7 @@ func (r *authRoutes) refresh(c *gin.Context) {
 	c.JSON(http.StatusOK, refreshResponse{
 		AccessToken:  tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
+		IdToken:      tokens.IdToken,
 	})
 }
 
@@ -227,7 +363,7 @@ func (r *authRoutes) resetPassword(c *gin.Context) {
 
 type recoveryPasswordRequest struct {
 	Token    string `json:"token"    validate:"required"`
-	Password string `json:"password" validate:"required,password"  minLength:"8" maxLength:"32"  example:"YourV@lidPassw0rd!"`
+	Password string `json:"password" validate:"required,password"  minLength:"8" maxLength:"64"  example:"YourV@lidPassw0rd!"`
 }
 
 // @Summary     Recovery password
@@ -262,9 +398,100 @@ func (r *authRoutes) recoveryPassword(c *gin.Context) {
 			c.JSON(http.StatusForbidden, response.Error(err.Error()))
 			return
 		}
+		if errors.Is(err, svcErrs.ErrBreachedPassword) {
+			c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
 		return
 	}
 
 	c.String(http.StatusOK, "password updated successfully")
 }
+
+type verifyEmailRequest struct {
+	// Token from the link of the verification email
+	Token string `json:"token" validate:"required"`
+}
+
+// @Summary     Verify email
+// @Description Confirms the email with the token of the verification email, every token works once
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body verifyEmailRequest true "Verify email payload"
+// @Success     200 {string} string
+// @Failure     400 {object} response.ErrResponse
+// @Failure     403 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/verify-email [post]
+func (r *authRoutes) verifyEmail(c *gin.Context) {
+	var req verifyEmailRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	err := r.as.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrInvalidVerificationToken) {
+			c.JSON(http.StatusForbidden, response.Error(err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.String(http.StatusOK, "email verified successfully")
+}
+
+type resendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email" example:"email@example.com"`
+}
+
+// @Summary     Resend verification email
+// @Description Sends another verification email to an unverified account.
+// @Description The response is the same whether there is such an account or not,
+// @Description another email to the address can be requested after the resend interval.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body resendVerificationRequest true "Resend verification payload"
+// @Success     202 {string} string
+// @Failure     400 {object} response.ErrResponse
+// @Failure     429 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/resend-verification [post]
+func (r *authRoutes) resendVerification(c *gin.Context) {
+	var req resendVerificationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	err := r.as.ResendVerification(c.Request.Context(), req.Email)
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrVerificationEmailThrottled) {
+			c.JSON(http.StatusTooManyRequests, response.Error(err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.String(http.StatusAccepted, "verification email sent if the account is not verified")
+}
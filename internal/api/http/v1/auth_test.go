@@ -14,9 +14,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
-const passwordErrMsg = `{"errors":{"Password":"Password must be between 8 and 32 in length, contain at least 1 lowercase, 1 uppercase, 1 digits, and 1 special characters (!@#$%^\u0026*)"}}`
+const passwordErrMsg = `{"errors":{"Password":"Password must be between 8 and 64 in length, contain at least 1 lowercase, 1 uppercase, 1 digits, and 1 special characters (!@#$%^\u0026*)"}}`
 
 func TestAuthRoutes_SignUp(t *testing.T) {
 	type args struct {
@@ -69,7 +70,7 @@ func TestAuthRoutes_SignUp(t *testing.T) {
 		{
 			name:             "Invalid password: too long",
 			args:             args{},
-			inputBody:        `{"email": "test@example.com","password":"Qwerty!123456789012345678901234567890"}`,
+			inputBody:        `{"email": "test@example.com","password":"Qwerty!123456789012345678901234567890123456789012345678901234567890"}`,
 			mockBehaviour:    func(m *servicemocks.MockAuth, args args) {},
 			wantStatusCode:   400,
 			wantResponseBody: passwordErrMsg,
@@ -140,6 +141,22 @@ func TestAuthRoutes_SignUp(t *testing.T) {
 			wantStatusCode:   422,
 			wantResponseBody: `{"errors":{"message":"user already exists"}}`,
 		},
+		{
+			name: "Breached password",
+			args: args{
+				ctx: context.Background(),
+				input: auth.CreateUserInput{
+					Email:    "test@example.com",
+					Password: "Qwerty!1",
+				},
+			},
+			inputBody: `{"email": "test@example.com","password":"Qwerty!1"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth, args args) {
+				m.EXPECT().CreateUser(args.ctx, args.input).Return(uuid.Nil, svcErrs.ErrBreachedPassword)
+			},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"message":"password has appeared in a data breach, choose another one"}}`,
+		},
 		{
 			name: "Internal server error",
 			args: args{
@@ -206,6 +223,7 @@ func TestAuthRoutes_SignIn(t *testing.T) {
 		mockBehaviour    MockBehaviour
 		wantStatusCode   int
 		wantResponseBody string
+		wantRetryAfter   string
 	}{
 		{
 			name: "OK",
@@ -214,15 +232,54 @@ func TestAuthRoutes_SignIn(t *testing.T) {
 				input: auth.GenerateTokenInput{
 					Email:    "test@example.com",
 					Password: "Qwerty!1",
+					IP:       "192.0.2.1",
 				},
 			},
 			inputBody: `{"email":"test@example.com","password":"Qwerty!1"}`,
 			mockBehaviour: func(m *servicemocks.MockAuth, args args) {
 				m.EXPECT().GenerateToken(args.ctx, args.input).
-					Return(auth.GenerateTokenOutput{AccessToken: "1", RefreshToken: "2"}, nil)
+					Return(auth.GenerateTokenOutput{AccessToken: "1", RefreshToken: "2", IdToken: "3"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","refresh_token":"2","id_token":"3"}`,
+		},
+		{
+			name: "OK with device",
+			args: args{
+				ctx: context.Background(),
+				input: auth.GenerateTokenInput{
+					Email:     "test@example.com",
+					Password:  "Qwerty!1",
+					Device:    "Laptop",
+					IP:        "192.0.2.1",
+					UserAgent: "test-agent",
+					Nonce:     "n-0S6_WzA2Mj",
+				},
+			},
+			inputBody: `{"email":"test@example.com","password":"Qwerty!1","device":"Laptop","nonce":"n-0S6_WzA2Mj"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth, args args) {
+				m.EXPECT().GenerateToken(args.ctx, args.input).
+					Return(auth.GenerateTokenOutput{AccessToken: "1", RefreshToken: "2", IdToken: "3"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","refresh_token":"2","id_token":"3"}`,
+		},
+		{
+			name: "OK: second factor required",
+			args: args{
+				ctx: context.Background(),
+				input: auth.GenerateTokenInput{
+					Email:    "test@example.com",
+					Password: "Qwerty!1",
+					IP:       "192.0.2.1",
+				},
+			},
+			inputBody: `{"email":"test@example.com","password":"Qwerty!1"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth, args args) {
+				m.EXPECT().GenerateToken(args.ctx, args.input).Return(auth.GenerateTokenOutput{MFAToken: "mfa"}, nil)
 			},
 			wantStatusCode:   200,
-			wantResponseBody: `{"access_token":"1","refresh_token":"2"}`,
+			wantResponseBody: `{"mfa_required":true,"mfa_token":"mfa"}`,
 		},
 		{
 			name:             "Invalid password: not provided",
@@ -257,6 +314,7 @@ func TestAuthRoutes_SignIn(t *testing.T) {
 				input: auth.GenerateTokenInput{
 					Email:    "test@example.com",
 					Password: "123",
+					IP:       "192.0.2.1",
 				},
 			},
 			inputBody: `{"email": "test@example.com","password":"123"}`,
@@ -266,6 +324,61 @@ func TestAuthRoutes_SignIn(t *testing.T) {
 			wantStatusCode:   401,
 			wantResponseBody: `{"errors":{"message":"invalid credentials"}}`,
 		},
+		{
+			name: "Auth service error: email not verified",
+			args: args{
+				ctx: context.Background(),
+				input: auth.GenerateTokenInput{
+					Email:    "test@example.com",
+					Password: "Qwerty!1",
+					IP:       "192.0.2.1",
+				},
+			},
+			inputBody: `{"email": "test@example.com","password":"Qwerty!1"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth, args args) {
+				m.EXPECT().GenerateToken(args.ctx, args.input).Return(auth.GenerateTokenOutput{}, svcErrs.ErrEmailNotVerified)
+			},
+			wantStatusCode:   403,
+			wantResponseBody: `{"errors":{"code":"email_not_verified","message":"email is not verified"}}`,
+		},
+		{
+			name: "Auth service error: account locked",
+			args: args{
+				ctx: context.Background(),
+				input: auth.GenerateTokenInput{
+					Email:    "test@example.com",
+					Password: "Qwerty!1",
+					IP:       "192.0.2.1",
+				},
+			},
+			inputBody: `{"email": "test@example.com","password":"Qwerty!1"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth, args args) {
+				m.EXPECT().GenerateToken(args.ctx, args.input).
+					Return(auth.GenerateTokenOutput{}, &svcErrs.RetryAfterError{Err: svcErrs.ErrAccountLocked, RetryAfter: 90500 * time.Millisecond})
+			},
+			wantStatusCode:   423,
+			wantResponseBody: `{"errors":{"message":"account is temporarily locked, try again later"}}`,
+			wantRetryAfter:   "91",
+		},
+		{
+			name: "Auth service error: ip locked",
+			args: args{
+				ctx: context.Background(),
+				input: auth.GenerateTokenInput{
+					Email:    "test@example.com",
+					Password: "Qwerty!1",
+					IP:       "192.0.2.1",
+				},
+			},
+			inputBody: `{"email": "test@example.com","password":"Qwerty!1"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth, args args) {
+				m.EXPECT().GenerateToken(args.ctx, args.input).
+					Return(auth.GenerateTokenOutput{}, &svcErrs.RetryAfterError{Err: svcErrs.ErrTooManySignInAttempts, RetryAfter: time.Minute})
+			},
+			wantStatusCode:   429,
+			wantResponseBody: `{"errors":{"message":"too many sign-in attempts, try again later"}}`,
+			wantRetryAfter:   "60",
+		},
 		{
 			name: "Internal server error",
 			args: args{
@@ -273,6 +386,7 @@ func TestAuthRoutes_SignIn(t *testing.T) {
 				input: auth.GenerateTokenInput{
 					Email:    "test@example.com",
 					Password: "Qwerty!1",
+					IP:       "192.0.2.1",
 				},
 			},
 			inputBody: `{"email": "test@example.com", "password":"Qwerty!1"}`,
@@ -306,6 +420,9 @@ func TestAuthRoutes_SignIn(t *testing.T) {
 			// create request
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest(http.MethodPost, "/auth/sign-in", bytes.NewBufferString(tc.inputBody))
+			if tc.args.input.UserAgent != "" {
+				req.Header.Set("User-Agent", tc.args.input.UserAgent)
+			}
 
 			// execute request
 			e.ServeHTTP(w, req)
@@ -313,6 +430,132 @@ func TestAuthRoutes_SignIn(t *testing.T) {
 			// check response
 			assert.Equal(t, tc.wantStatusCode, w.Code)
 			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+			assert.Equal(t, tc.wantRetryAfter, w.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestAuthRoutes_VerifyMFA(t *testing.T) {
+	input := auth.VerifyMFAInput{MFAToken: "mfa", Code: "123456"}
+
+	type MockBehaviour func(m *servicemocks.MockAuth)
+
+	testCases := []struct {
+		name             string
+		inputBody        string
+		mockBehaviour    MockBehaviour
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"mfa_token":"mfa","code":"123456"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyMFA(gomock.Any(), input).
+					Return(auth.GenerateTokenOutput{AccessToken: "1", RefreshToken: "2", IdToken: "3"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","refresh_token":"2","id_token":"3"}`,
+		},
+		{
+			name:      "OK with recovery code",
+			inputBody: `{"mfa_token":"mfa","code":"abcde-fgh23"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyMFA(gomock.Any(), auth.VerifyMFAInput{MFAToken: "mfa", Code: "abcde-fgh23"}).
+					Return(auth.GenerateTokenOutput{AccessToken: "1", RefreshToken: "2", IdToken: "3"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","refresh_token":"2","id_token":"3"}`,
+		},
+		{
+			name:      "OK with passkey",
+			inputBody: `{"mfa_token":"mfa","session_id":"ceremony","credential":{"id":"x"}}`,
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyMFA(gomock.Any(), auth.VerifyMFAInput{
+					MFAToken:         "mfa",
+					PasskeySessionId: "ceremony",
+					PasskeyResponse:  []byte(`{"id":"x"}`),
+				}).Return(auth.GenerateTokenOutput{AccessToken: "1", RefreshToken: "2", IdToken: "3"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","refresh_token":"2","id_token":"3"}`,
+		},
+		{
+			name:             "Invalid passkey: session id not provided",
+			inputBody:        `{"mfa_token":"mfa","credential":{"id":"x"}}`,
+			mockBehaviour:    func(m *servicemocks.MockAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"SessionId":"Is not valid"}}`,
+		},
+		{
+			name:             "Invalid code: neither code nor credential",
+			inputBody:        `{"mfa_token":"mfa"}`,
+			mockBehaviour:    func(m *servicemocks.MockAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Code":"Is not valid"}}`,
+		},
+		{
+			name:             "Invalid code: too long",
+			inputBody:        `{"mfa_token":"mfa","code":"123456789012345678901"}`,
+			mockBehaviour:    func(m *servicemocks.MockAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Code":"Must be shorter than 20"}}`,
+		},
+		{
+			name:             "Invalid mfa token: not provided",
+			inputBody:        `{"code":"123456"}`,
+			mockBehaviour:    func(m *servicemocks.MockAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"MFAToken":"Is a required"}}`,
+		},
+		{
+			name:      "Auth service error: invalid code",
+			inputBody: `{"mfa_token":"mfa","code":"123456"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyMFA(gomock.Any(), input).Return(auth.GenerateTokenOutput{}, svcErrs.ErrInvalidOTP)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"invalid one-time code"}}`,
+		},
+		{
+			name:      "Auth service error: expired mfa token",
+			inputBody: `{"mfa_token":"mfa","code":"123456"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyMFA(gomock.Any(), input).Return(auth.GenerateTokenOutput{}, svcErrs.ErrInvalidMFAToken)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"mfa token is invalid or expired"}}`,
+		},
+		{
+			name:      "Internal server error",
+			inputBody: `{"mfa_token":"mfa","code":"123456"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyMFA(gomock.Any(), input).Return(auth.GenerateTokenOutput{}, svcErrs.ErrCannotGetMFA)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			as := servicemocks.NewMockAuth(ctrl)
+			tc.mockBehaviour(as)
+
+			gin.SetMode(gin.TestMode)
+			e := gin.New()
+			NewAuthRoutes(e.Group("/auth"), validator.NewCustomValidator(), as)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/auth/mfa/verify", bytes.NewBufferString(tc.inputBody))
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
 		})
 	}
 }
@@ -342,10 +585,10 @@ func TestAuthRoutes_Refresh(t *testing.T) {
 			inputBody: `{"token":"valid_token"}`,
 			mockBehaviour: func(m *servicemocks.MockAuth, args args) {
 				m.EXPECT().Refresh(args.ctx, args.token).
-					Return(auth.GenerateTokenOutput{AccessToken: "111", RefreshToken: "222"}, nil)
+					Return(auth.GenerateTokenOutput{AccessToken: "111", RefreshToken: "222", IdToken: "333"}, nil)
 			},
 			wantStatusCode:   200,
-			wantResponseBody: `{"access_token":"111","refresh_token":"222"}`,
+			wantResponseBody: `{"access_token":"111","refresh_token":"222","id_token":"333"}`,
 		},
 		{
 			name:             "Invalid token: not provided",
@@ -381,6 +624,19 @@ func TestAuthRoutes_Refresh(t *testing.T) {
 			wantStatusCode:   401,
 			wantResponseBody: `{"errors":{"message":"token is expired"}}`,
 		},
+		{
+			name: "Auth service error: refresh token reused",
+			args: args{
+				ctx:   context.Background(),
+				token: "rotated_token",
+			},
+			inputBody: `{"token": "rotated_token"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth, args args) {
+				m.EXPECT().Refresh(args.ctx, args.token).Return(auth.GenerateTokenOutput{}, svcErrs.ErrRefreshTokenReused)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"code":"refresh_token_reused","message":"refresh token reuse detected"}}`,
+		},
 		{
 			name: "Auth service error: Internal server error",
 			args: args{
@@ -634,3 +890,161 @@ func TestAuthRoutes_RecoveryPassword(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthRoutes_VerifyEmail(t *testing.T) {
+	type MockBehaviour func(m *servicemocks.MockAuth)
+
+	testCases := []struct {
+		name             string
+		inputBody        string
+		mockBehaviour    MockBehaviour
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"token":"verify"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyEmail(context.Background(), "verify").Return(nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: "email verified successfully",
+		},
+		{
+			name:             "Invalid token: not provided",
+			inputBody:        `{}`,
+			mockBehaviour:    func(m *servicemocks.MockAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Token":"Is a required"}}`,
+		},
+		{
+			name:      "Auth service error: invalid token",
+			inputBody: `{"token":"verify"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyEmail(context.Background(), "verify").Return(svcErrs.ErrInvalidVerificationToken)
+			},
+			wantStatusCode:   403,
+			wantResponseBody: `{"errors":{"message":"email verification token is invalid or expired"}}`,
+		},
+		{
+			name:      "Auth service error: Internal server error",
+			inputBody: `{"token":"verify"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyEmail(context.Background(), "verify").Return(svcErrs.ErrCannotUpdateUser)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// init deps
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// init service mock
+			as := servicemocks.NewMockAuth(ctrl)
+			tc.mockBehaviour(as)
+
+			// create test server
+			gin.SetMode(gin.TestMode)
+			e := gin.New()
+
+			cv := validator.NewCustomValidator()
+
+			g := e.Group("/auth")
+			NewAuthRoutes(g, cv, as)
+
+			// create request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/auth/verify-email", bytes.NewBufferString(tc.inputBody))
+
+			// execute request
+			e.ServeHTTP(w, req)
+
+			// check response
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestAuthRoutes_ResendVerification(t *testing.T) {
+	type MockBehaviour func(m *servicemocks.MockAuth)
+
+	testCases := []struct {
+		name             string
+		inputBody        string
+		mockBehaviour    MockBehaviour
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"email":"test@example.com"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().ResendVerification(context.Background(), "test@example.com").Return(nil)
+			},
+			wantStatusCode:   202,
+			wantResponseBody: "verification email sent if the account is not verified",
+		},
+		{
+			name:             "Invalid email",
+			inputBody:        `{"email":"email"}`,
+			mockBehaviour:    func(m *servicemocks.MockAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Email":"Invalid email format"}}`,
+		},
+		{
+			name:      "Auth service error: sent recently",
+			inputBody: `{"email":"test@example.com"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().ResendVerification(context.Background(), "test@example.com").Return(svcErrs.ErrVerificationEmailThrottled)
+			},
+			wantStatusCode:   429,
+			wantResponseBody: `{"errors":{"message":"verification email was sent recently, try again later"}}`,
+		},
+		{
+			name:      "Auth service error: Internal server error",
+			inputBody: `{"email":"test@example.com"}`,
+			mockBehaviour: func(m *servicemocks.MockAuth) {
+				m.EXPECT().ResendVerification(context.Background(), "test@example.com").Return(svcErrs.ErrSendVerificationEmail)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// init deps
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// init service mock
+			as := servicemocks.NewMockAuth(ctrl)
+			tc.mockBehaviour(as)
+
+			// create test server
+			gin.SetMode(gin.TestMode)
+			e := gin.New()
+
+			cv := validator.NewCustomValidator()
+
+			g := e.Group("/auth")
+			NewAuthRoutes(g, cv, as)
+
+			// create request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/auth/resend-verification", bytes.NewBufferString(tc.inputBody))
+
+			// execute request
+			e.ServeHTTP(w, req)
+
+			// check response
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package v1
+
+import (
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/client"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type clientRoutes struct {
+	cs service.Client
+	cv *validator.CustomValidator
+}
+
+func NewClientRoutes(g *gin.RouterGroup, cv *validator.CustomValidator, cs service.Client) {
+	r := &clientRoutes{cs, cv}
+
+	g.GET("/", r.clients)
+	g.POST("/", r.create)
+	g.GET("/:client_id", r.client)
+	g.PUT("/:client_id", r.update)
+	g.DELETE("/:client_id", r.delete)
+	g.POST("/:client_id/secret", r.rotateSecret)
+}
+
+// clientSettings are the settings of a client that can be changed after it is registered.
+type clientSettings struct {
+	Name         string   `json:"name"          validate:"required,max=100" maxLength:"100" example:"Example SPA"`
+	RedirectURIs []string `json:"redirect_uris" validate:"dive,required,max=2000"           example:"http://localhost:3000/callback"`
+	GrantTypes   []string `json:"grant_types"   validate:"dive,required"                    example:"authorization_code"`
+	Scopes       []string `json:"scopes"        validate:"dive,required"                    example:"openid,email"`
+	// Audience is stamped as the aud claim of access tokens issued to the client
+	Audience string `json:"audience" validate:"max=255" maxLength:"255" example:"https://api.example.com"`
+	// Token lifetimes in seconds, 0 means the global setting, at most oauth.max_access_token_ttl and oauth.max_refresh_token_ttl
+	AccessTokenTTL  int `json:"access_token_ttl"  validate:"min=0" minimum:"0" example:"900"`
+	RefreshTokenTTL int `json:"refresh_token_ttl" validate:"min=0" minimum:"0" example:"86400"`
+}
+
+type createClientRequest struct {
+	// Id is generated when empty
+	Id string `json:"id" validate:"max=64" maxLength:"64" example:"example-spa"`
+	// Confidential clients get a secret, public clients must use PKCE
+	Confidential bool `json:"confidential" example:"false"`
+	clientSettings
+}
+
+type updateClientRequest struct {
+	clientSettings
+}
+
+type clientResponse struct {
+	Id              string    `json:"id"                example:"example-spa"`
+	Name            string    `json:"name"              example:"Example SPA"`
+	Confidential    bool      `json:"confidential"      example:"false"`
+	RedirectURIs    []string  `json:"redirect_uris"     example:"http://localhost:3000/callback"`
+	GrantTypes      []string  `json:"grant_types"       example:"authorization_code"`
+	Scopes          []string  `json:"scopes"            example:"openid,email"`
+	Audience        string    `json:"audience"          example:"https://api.example.com"`
+	AccessTokenTTL  int       `json:"access_token_ttl"  example:"900"`
+	RefreshTokenTTL int       `json:"refresh_token_ttl" example:"86400"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type createClientResponse struct {
+	clientResponse
+	// Secret of a confidential client, it is not shown again
+	Secret string `json:"client_secret,omitempty"`
+}
+
+type clientSecretResponse struct {
+	Secret string `json:"client_secret"`
+}
+
+func newClientResponse(c entity.Client) clientResponse {
+	return clientResponse{
+		Id:              c.Id,
+		Name:            c.Name,
+		Confidential:    c.IsConfidential(),
+		RedirectURIs:    c.RedirectURIs,
+		GrantTypes:      c.GrantTypes,
+		Scopes:          c.Scopes,
+		Audience:        c.Audience,
+		AccessTokenTTL:  int(c.AccessTokenTTL.Seconds()),
+		RefreshTokenTTL: int(c.RefreshTokenTTL.Seconds()),
+		CreatedAt:       c.CreatedAt,
+		UpdatedAt:       c.UpdatedAt,
+	}
+}
+
+// @Summary     OAuth clients
+// @Description List the registered OAuth clients
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminApiKey
+// @Success     200 {array} clientResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /admin/clients [get]
+func (r *clientRoutes) clients(c *gin.Context) {
+	clients, err := r.cs.Clients(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	resp := make([]clientResponse, 0, len(clients))
+	for _, cl := range clients {
+		resp = append(resp, newClientResponse(cl))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary     Register OAuth client
+// @Description Register an OAuth client, the secret of a confidential client is only returned here
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminApiKey
+// @Param       request body createClientRequest true "Client settings"
+// @Success     201 {object} createClientResponse
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     422 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /admin/clients [post]
+func (r *clientRoutes) create(c *gin.Context) {
+	var req createClientRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	out, err := r.cs.Create(c.Request.Context(), client.CreateClientInput{
+		Id:              req.Id,
+		Name:            req.Name,
+		Confidential:    req.Confidential,
+		RedirectURIs:    req.RedirectURIs,
+		GrantTypes:      req.GrantTypes,
+		Scopes:          req.Scopes,
+		Audience:        req.Audience,
+		AccessTokenTTL:  time.Duration(req.AccessTokenTTL) * time.Second,
+		RefreshTokenTTL: time.Duration(req.RefreshTokenTTL) * time.Second,
+	})
+	if err != nil {
+		clientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, createClientResponse{
+		clientResponse: newClientResponse(out.Client),
+		Secret:         out.Secret,
+	})
+}
+
+// @Summary     OAuth client
+// @Description Get a registered OAuth client
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminApiKey
+// @Param       client_id path string true "Client id"
+// @Success     200 {object} clientResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /admin/clients/{client_id} [get]
+func (r *clientRoutes) client(c *gin.Context) {
+	cl, err := r.cs.Client(c.Request.Context(), c.Param("client_id"))
+	if err != nil {
+		clientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newClientResponse(cl))
+}
+
+// @Summary     Update OAuth client
+// @Description Replace the settings of an OAuth client, the secret is kept
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminApiKey
+// @Param       client_id path string              true "Client id"
+// @Param       request   body updateClientRequest true "Client settings"
+// @Success     200 {object} clientResponse
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /admin/clients/{client_id} [put]
+func (r *clientRoutes) update(c *gin.Context) {
+	var req updateClientRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	cl, err := r.cs.Update(c.Request.Context(), client.UpdateClientInput{
+		Id:              c.Param("client_id"),
+		Name:            req.Name,
+		RedirectURIs:    req.RedirectURIs,
+		GrantTypes:      req.GrantTypes,
+		Scopes:          req.Scopes,
+		Audience:        req.Audience,
+		AccessTokenTTL:  time.Duration(req.AccessTokenTTL) * time.Second,
+		RefreshTokenTTL: time.Duration(req.RefreshTokenTTL) * time.Second,
+	})
+	if err != nil {
+		clientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newClientResponse(cl))
+}
+
+// @Summary     Delete OAuth client
+// @Description Delete an OAuth client, tokens that were already issued stay valid until they expire
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminApiKey
+// @Param       client_id path string true "Client id"
+// @Success     204
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /admin/clients/{client_id} [delete]
+func (r *clientRoutes) delete(c *gin.Context) {
+	if err := r.cs.Delete(c.Request.Context(), c.Param("client_id")); err != nil {
+		clientError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Rotate client secret
+// @Description Replace the secret of an OAuth client, a public client becomes confidential
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminApiKey
+// @Param       client_id path string true "Client id"
+// @Success     200 {object} clientSecretResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /admin/clients/{client_id}/secret [post]
+func (r *clientRoutes) rotateSecret(c *gin.Context) {
+	secret, err := r.cs.RotateSecret(c.Request.Context(), c.Param("client_id"))
+	if err != nil {
+		clientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, clientSecretResponse{Secret: secret})
+}
+
+func clientError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, svcErrs.ErrClientNotFound):
+		c.JSON(http.StatusNotFound, response.Error(err.Error()))
+	case errors.Is(err, svcErrs.ErrClientAlreadyExists):
+		c.JSON(http.StatusUnprocessableEntity, response.Error(err.Error()))
+	case errors.Is(err, svcErrs.ErrInvalidClientConfig):
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+	}
+}
@@ -0,0 +1,239 @@
+package v1
+
+import (
+	"bytes"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/internal/service/client"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var createdAt = time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+
+func newTestClientRouter(cs *servicemocks.MockClient) *gin.Engine {
+	e := gin.New()
+	NewClientRoutes(e.Group("/admin/clients"), validator.NewCustomValidator(), cs)
+
+	return e
+}
+
+func TestClientRoutes_Create(t *testing.T) {
+	type MockBehavior func(m *servicemocks.MockClient)
+
+	testCases := []struct {
+		name             string
+		inputBody        string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name: "OK",
+			inputBody: `{"id":"example-backend","name":"Example backend","confidential":true,` +
+				`"redirect_uris":["https://app.example.com/callback"],"grant_types":["authorization_code"],` +
+				`"scopes":["openid"],"audience":"https://api.example.com","access_token_ttl":300}`,
+			mockBehavior: func(m *servicemocks.MockClient) {
+				m.EXPECT().Create(gomock.Any(), client.CreateClientInput{
+					Id:             "example-backend",
+					Name:           "Example backend",
+					Confidential:   true,
+					RedirectURIs:   []string{"https://app.example.com/callback"},
+					GrantTypes:     []string{"authorization_code"},
+					Scopes:         []string{"openid"},
+					Audience:       "https://api.example.com",
+					AccessTokenTTL: 5 * time.Minute,
+				}).Return(client.CreateClientOutput{
+					Client: entity.Client{
+						Id:             "example-backend",
+						Name:           "Example backend",
+						SecretHash:     []byte("hash"),
+						RedirectURIs:   []string{"https://app.example.com/callback"},
+						GrantTypes:     []string{"authorization_code"},
+						Scopes:         []string{"openid"},
+						Audience:       "https://api.example.com",
+						AccessTokenTTL: 5 * time.Minute,
+						CreatedAt:      createdAt,
+						UpdatedAt:      createdAt,
+					},
+					Secret: "secret",
+				}, nil)
+			},
+			wantStatusCode: 201,
+			wantResponseBody: `{"id":"example-backend","name":"Example backend","confidential":true,` +
+				`"redirect_uris":["https://app.example.com/callback"],"grant_types":["authorization_code"],` +
+				`"scopes":["openid"],"audience":"https://api.example.com","access_token_ttl":300,"refresh_token_ttl":0,` +
+				`"created_at":"2025-01-31T12:00:00Z","updated_at":"2025-01-31T12:00:00Z","client_secret":"secret"}`,
+		},
+		{
+			name:             "no name",
+			inputBody:        `{"id":"example-backend"}`,
+			mockBehavior:     func(m *servicemocks.MockClient) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Name":"Is a required"}}`,
+		},
+		{
+			name:             "negative ttl",
+			inputBody:        `{"name":"Example backend","access_token_ttl":-1}`,
+			mockBehavior:     func(m *servicemocks.MockClient) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"AccessTokenTTL":"Must be longer than 0"}}`,
+		},
+		{
+			name:      "invalid settings",
+			inputBody: `{"name":"Example backend","grant_types":["implicit"]}`,
+			mockBehavior: func(m *servicemocks.MockClient) {
+				m.EXPECT().Create(gomock.Any(), gomock.Any()).Return(client.CreateClientOutput{}, svcErrs.ErrInvalidClientConfig)
+			},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"message":"invalid client settings"}}`,
+		},
+		{
+			name:      "client already exists",
+			inputBody: `{"id":"example-backend","name":"Example backend"}`,
+			mockBehavior: func(m *servicemocks.MockClient) {
+				m.EXPECT().Create(gomock.Any(), gomock.Any()).Return(client.CreateClientOutput{}, svcErrs.ErrClientAlreadyExists)
+			},
+			wantStatusCode:   422,
+			wantResponseBody: `{"errors":{"message":"client already exists"}}`,
+		},
+		{
+			name:      "internal error",
+			inputBody: `{"name":"Example backend"}`,
+			mockBehavior: func(m *servicemocks.MockClient) {
+				m.EXPECT().Create(gomock.Any(), gomock.Any()).Return(client.CreateClientOutput{}, svcErrs.ErrCannotCreateClient)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cs := servicemocks.NewMockClient(ctrl)
+			tc.mockBehavior(cs)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/admin/clients/", bytes.NewBufferString(tc.inputBody))
+
+			newTestClientRouter(cs).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestClientRoutes_Client(t *testing.T) {
+	type MockBehavior func(m *servicemocks.MockClient)
+
+	testCases := []struct {
+		name             string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m *servicemocks.MockClient) {
+				m.EXPECT().Client(gomock.Any(), "example-spa").Return(entity.Client{
+					Id:              "example-spa",
+					Name:            "Example SPA",
+					RedirectURIs:    []string{"http://localhost:3000/callback"},
+					GrantTypes:      []string{"authorization_code"},
+					Scopes:          []string{"openid", "email"},
+					RefreshTokenTTL: 24 * time.Hour,
+					CreatedAt:       createdAt,
+					UpdatedAt:       createdAt,
+				}, nil)
+			},
+			wantStatusCode: 200,
+			wantResponseBody: `{"id":"example-spa","name":"Example SPA","confidential":false,` +
+				`"redirect_uris":["http://localhost:3000/callback"],"grant_types":["authorization_code"],` +
+				`"scopes":["openid","email"],"audience":"","access_token_ttl":0,"refresh_token_ttl":86400,` +
+				`"created_at":"2025-01-31T12:00:00Z","updated_at":"2025-01-31T12:00:00Z"}`,
+		},
+		{
+			name: "client not found",
+			mockBehavior: func(m *servicemocks.MockClient) {
+				m.EXPECT().Client(gomock.Any(), "example-spa").Return(entity.Client{}, svcErrs.ErrClientNotFound)
+			},
+			wantStatusCode:   404,
+			wantResponseBody: `{"errors":{"message":"client not found"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cs := servicemocks.NewMockClient(ctrl)
+			tc.mockBehavior(cs)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/admin/clients/example-spa", nil)
+
+			newTestClientRouter(cs).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestClientRoutes_RotateSecret(t *testing.T) {
+	type MockBehavior func(m *servicemocks.MockClient)
+
+	testCases := []struct {
+		name             string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m *servicemocks.MockClient) {
+				m.EXPECT().RotateSecret(gomock.Any(), "example-spa").Return("secret", nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"client_secret":"secret"}`,
+		},
+		{
+			name: "client not found",
+			mockBehavior: func(m *servicemocks.MockClient) {
+				m.EXPECT().RotateSecret(gomock.Any(), "example-spa").Return("", svcErrs.ErrClientNotFound)
+			},
+			wantStatusCode:   404,
+			wantResponseBody: `{"errors":{"message":"client not found"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cs := servicemocks.NewMockClient(ctrl)
+			tc.mockBehavior(cs)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/admin/clients/example-spa/secret", nil)
+
+			newTestClientRouter(cs).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
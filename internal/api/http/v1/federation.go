@@ -0,0 +1,292 @@
+package v1
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/federation"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+const (
+	federationStateCookie = "federation_state"
+	federationCookiePath  = "/auth/federation/"
+)
+
+type federationRoutes struct {
+	fs service.Federation
+	cv *validator.CustomValidator
+}
+
+// NewFederationRoutes registers the sign-in with external OpenID providers.
+func NewFederationRoutes(g *gin.RouterGroup, cv *validator.CustomValidator, fs service.Federation) {
+	r := &federationRoutes{fs, cv}
+
+	g.GET("/federation", r.providers)
+	g.GET("/federation/:provider", r.begin)
+	g.GET("/federation/:provider/callback", r.callback)
+	// Apple posts the callback
+	g.POST("/federation/:provider/callback", r.callback)
+}
+
+// NewIdentityRoutes registers the identities of the current user.
+func NewIdentityRoutes(g *gin.RouterGroup, fs service.Federation) {
+	r := &federationRoutes{fs: fs}
+
+	g.GET("/", r.identities)
+	g.POST("/:provider", r.link)
+	g.DELETE("/:provider/:subject", r.unlink)
+}
+
+type federationProvidersResponse struct {
+	Providers []string `json:"providers" example:"google"`
+}
+
+// @Summary     Identity providers
+// @Description Lists the external providers users can sign in with
+// @Tags        auth
+// @Produce     json
+// @Success     200 {object} federationProvidersResponse
+// @Router      /auth/federation [get]
+func (r *federationRoutes) providers(c *gin.Context) {
+	c.JSON(http.StatusOK, federationProvidersResponse{Providers: r.fs.Providers()})
+}
+
+type beginFederationRequest struct {
+	// Device label shown in the list of sessions
+	Device string `form:"device" validate:"max=100" maxLength:"100" example:"iPhone 15"`
+	// Nonce is echoed in the ID token
+	Nonce string `form:"nonce" validate:"max=255" maxLength:"255" example:"n-0S6_WzA2Mj"`
+}
+
+// @Summary     Sign in with a provider
+// @Description Redirects the browser to the sign-in page of the provider. The state of the sign-in
+// @Description is bound to the browser with a cookie that the callback checks.
+// @Tags        auth
+// @Param       provider path  string true  "Provider name"
+// @Param       device   query string false "Device label shown in the list of sessions"
+// @Param       nonce    query string false "Nonce echoed in the ID token"
+// @Success     302 "Redirect to the provider"
+// @Failure     400 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/federation/{provider} [get]
+func (r *federationRoutes) begin(c *gin.Context) {
+	var req beginFederationRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	out, err := r.fs.Begin(c.Request.Context(), federation.BeginInput{
+		Provider: c.Param("provider"),
+		Device:   req.Device,
+		Nonce:    req.Nonce,
+	})
+	if err != nil {
+		federationError(c, err)
+		return
+	}
+
+	setFederationState(c, out.State)
+	c.Redirect(http.StatusFound, out.URL)
+}
+
+type federationCallbackRequest struct {
+	State string `form:"state"`
+	Code  string `form:"code"`
+	// Error is returned by the provider instead of the code
+	Error string `form:"error"`
+}
+
+// @Summary     Provider callback
+// @Description Completes the sign-in at the provider and signs in the user the account is linked to.
+// @Description On the first sign-in the account is linked to the user with the email verified by the provider,
+// @Description a new user is created when there is none.
+// @Description Users with two-factor authentication get mfa_token instead of the tokens.
+// @Description A sign-in started by linking an identity responds with the linked identity instead.
+// @Tags        auth
+// @Produce     json
+// @Param       provider path  string true  "Provider name"
+// @Param       state    query string true  "State of the sign-in"
+// @Param       code     query string false "Authorization code"
+// @Param       error    query string false "Error returned by the provider"
+// @Success     200 {object} signInResponse
+// @Success     201 {object} identityResponse
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     409 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/federation/{provider}/callback [get]
+func (r *federationRoutes) callback(c *gin.Context) {
+	var req federationCallbackRequest
+
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	// the sign-in must be completed in the browser that started it
+	cookie, err := c.Cookie(federationStateCookie)
+	if err != nil || req.State == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(req.State)) != 1 {
+		c.JSON(http.StatusBadRequest, response.Error(svcErrs.ErrInvalidFederationState.Error()))
+		return
+	}
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie(federationStateCookie, "", -1, federationCookiePath, "", true, true)
+
+	out, err := r.fs.Callback(c.Request.Context(), federation.CallbackInput{
+		Provider:  c.Param("provider"),
+		State:     req.State,
+		Code:      req.Code,
+		Error:     req.Error,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		federationError(c, err)
+		return
+	}
+
+	if out.Identity != nil {
+		c.JSON(http.StatusCreated, newIdentityResponse(*out.Identity))
+		return
+	}
+
+	c.JSON(http.StatusOK, newSignInResponse(out.Tokens))
+}
+
+type identityResponse struct {
+	Provider string `json:"provider" example:"google"`
+	// Subject is the id of the account at the provider
+	Subject string `json:"subject" example:"110248495921238986420"`
+	// Email the provider asserted when the identity was linked
+	Email      string     `json:"email" example:"email@example.com"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func newIdentityResponse(i entity.Identity) identityResponse {
+	return identityResponse{
+		Provider:   i.Provider,
+		Subject:    i.Subject,
+		Email:      i.Email,
+		LastUsedAt: i.LastUsedAt,
+		CreatedAt:  i.CreatedAt,
+	}
+}
+
+// @Summary     Identities
+// @Description List the accounts at external providers linked to the current user
+// @Tags        identities
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200 {array} identityResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/identities [get]
+func (r *federationRoutes) identities(c *gin.Context) {
+	identities, err := r.fs.Identities(c.Request.Context(), userIdFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	res := make([]identityResponse, 0, len(identities))
+	for _, identity := range identities {
+		res = append(res, newIdentityResponse(identity))
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+type linkIdentityResponse struct {
+	// URL of the provider the browser is sent to, the callback links the identity
+	URL string `json:"url" example:"https://accounts.google.com/o/oauth2/auth?client_id=..."`
+}
+
+// @Summary     Link identity
+// @Description Starts a sign-in at the provider that links the account to the current user.
+// @Description The browser is sent to the returned URL, the callback responds with the linked identity.
+// @Tags        identities
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       provider path string true "Provider name"
+// @Success     200 {object} linkIdentityResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/identities/{provider} [post]
+func (r *federationRoutes) link(c *gin.Context) {
+	out, err := r.fs.BeginLink(c.Request.Context(), userIdFromContext(c), c.Param("provider"))
+	if err != nil {
+		federationError(c, err)
+		return
+	}
+
+	setFederationState(c, out.State)
+	c.JSON(http.StatusOK, linkIdentityResponse{URL: out.URL})
+}
+
+// @Summary     Unlink identity
+// @Description The account at the provider can no longer be used to sign in.
+// @Description The last way to sign in cannot be unlinked, set a password first.
+// @Tags        identities
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       provider path string true "Provider name"
+// @Param       subject  path string true "Id of the account at the provider"
+// @Success     204
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     409 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/identities/{provider}/{subject} [delete]
+func (r *federationRoutes) unlink(c *gin.Context) {
+	err := r.fs.Unlink(c.Request.Context(), userIdFromContext(c), c.Param("provider"), c.Param("subject"))
+	if err != nil {
+		federationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// setFederationState binds the sign-in to the browser, the callback checks the cookie.
+func setFederationState(c *gin.Context, state string) {
+	// SameSite=None, the callback of form_post providers is a cross-site POST
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie(federationStateCookie, state, 0, federationCookiePath, "", true, true)
+}
+
+func federationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, svcErrs.ErrUnknownProvider), errors.Is(err, svcErrs.ErrIdentityNotFound):
+		c.JSON(http.StatusNotFound, response.Error(err.Error()))
+	case errors.Is(err, svcErrs.ErrInvalidFederationState):
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+	case errors.Is(err, svcErrs.ErrFederatedSignInFailed), errors.Is(err, svcErrs.ErrFederatedEmailNotVerified):
+		c.JSON(http.StatusUnauthorized, response.Error(err.Error()))
+	case errors.Is(err, svcErrs.ErrUserAlreadyExists), errors.Is(err, svcErrs.ErrIdentityAlreadyLinked),
+		errors.Is(err, svcErrs.ErrLastLoginMethod), errors.Is(err, svcErrs.ErrFederatedAccountUnverified):
+		c.JSON(http.StatusConflict, response.Error(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+	}
+}
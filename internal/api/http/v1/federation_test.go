@@ -0,0 +1,360 @@
+package v1
+
+import (
+	"bytes"
+	"github.com/bubalync/uni-auth/internal/api/http/middleware"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/federation"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFederationRoutes(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	type MockBehavior func(m *servicemocks.MockFederation)
+
+	testCases := []struct {
+		name             string
+		method           string
+		path             string
+		inputBody        string
+		cookie           string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+		wantLocation     string
+		wantCookie       string
+	}{
+		{
+			name:   "providers: OK",
+			method: http.MethodGet,
+			path:   "/auth/federation",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Providers().Return([]string{"apple", "google"})
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"providers":["apple","google"]}`,
+		},
+		{
+			name:   "begin: OK",
+			method: http.MethodGet,
+			path:   "/auth/federation/google?device=iPhone&nonce=n-1",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Begin(gomock.Any(), federation.BeginInput{Provider: "google", Device: "iPhone", Nonce: "n-1"}).
+					Return(federation.BeginOutput{URL: "https://accounts.google.com/o/oauth2/auth?state=s-1", State: "s-1"}, nil)
+			},
+			wantStatusCode: 302,
+			wantLocation:   "https://accounts.google.com/o/oauth2/auth?state=s-1",
+			wantCookie:     "federation_state=s-1; Path=/auth/federation/; HttpOnly; Secure; SameSite=None",
+		},
+		{
+			name:   "begin: unknown provider",
+			method: http.MethodGet,
+			path:   "/auth/federation/github",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Begin(gomock.Any(), federation.BeginInput{Provider: "github"}).
+					Return(federation.BeginOutput{}, svcErrs.ErrUnknownProvider)
+			},
+			wantStatusCode:   404,
+			wantResponseBody: `{"errors":{"message":"unknown identity provider"}}`,
+		},
+		{
+			name:   "callback: OK",
+			method: http.MethodGet,
+			path:   "/auth/federation/google/callback?state=s-1&code=c-1",
+			cookie: "s-1",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Callback(gomock.Any(), federation.CallbackInput{
+					Provider:  "google",
+					State:     "s-1",
+					Code:      "c-1",
+					IP:        "192.0.2.1",
+					UserAgent: "test",
+				}).Return(federation.CallbackOutput{
+					Tokens: auth.GenerateTokenOutput{AccessToken: "access", RefreshToken: "refresh", IdToken: "id"},
+				}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"access","refresh_token":"refresh","id_token":"id"}`,
+			wantCookie:       "federation_state=; Path=/auth/federation/; Max-Age=0; HttpOnly; Secure; SameSite=None",
+		},
+		{
+			name:      "callback: form post",
+			method:    http.MethodPost,
+			path:      "/auth/federation/apple/callback",
+			inputBody: "state=s-1&code=c-1",
+			cookie:    "s-1",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Callback(gomock.Any(), federation.CallbackInput{
+					Provider:  "apple",
+					State:     "s-1",
+					Code:      "c-1",
+					IP:        "192.0.2.1",
+					UserAgent: "test",
+				}).Return(federation.CallbackOutput{Tokens: auth.GenerateTokenOutput{MFAToken: "mfa"}}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"mfa_required":true,"mfa_token":"mfa"}`,
+			wantCookie:       "federation_state=; Path=/auth/federation/; Max-Age=0; HttpOnly; Secure; SameSite=None",
+		},
+		{
+			name:   "callback: identity linked",
+			method: http.MethodGet,
+			path:   "/auth/federation/google/callback?state=s-1&code=c-1",
+			cookie: "s-1",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Callback(gomock.Any(), gomock.Any()).Return(federation.CallbackOutput{
+					Identity: &entity.Identity{Provider: "google", Subject: "1", Email: "test@example.com", CreatedAt: createdAt},
+				}, nil)
+			},
+			wantStatusCode: 201,
+			wantResponseBody: `{"provider":"google","subject":"1","email":"test@example.com",` +
+				`"last_used_at":null,"created_at":"2025-01-02T03:04:05Z"}`,
+			wantCookie: "federation_state=; Path=/auth/federation/; Max-Age=0; HttpOnly; Secure; SameSite=None",
+		},
+		{
+			name:   "callback: identity linked to another user",
+			method: http.MethodGet,
+			path:   "/auth/federation/google/callback?state=s-1&code=c-1",
+			cookie: "s-1",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Callback(gomock.Any(), gomock.Any()).
+					Return(federation.CallbackOutput{}, svcErrs.ErrIdentityAlreadyLinked)
+			},
+			wantStatusCode:   409,
+			wantResponseBody: `{"errors":{"message":"the identity is linked to another user"}}`,
+			wantCookie:       "federation_state=; Path=/auth/federation/; Max-Age=0; HttpOnly; Secure; SameSite=None",
+		},
+		{
+			name:             "callback: started in another browser",
+			method:           http.MethodGet,
+			path:             "/auth/federation/google/callback?state=s-1&code=c-1",
+			cookie:           "s-2",
+			mockBehavior:     func(m *servicemocks.MockFederation) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"message":"sign-in state is invalid or expired"}}`,
+		},
+		{
+			name:             "callback: no cookie",
+			method:           http.MethodGet,
+			path:             "/auth/federation/google/callback?state=s-1&code=c-1",
+			mockBehavior:     func(m *servicemocks.MockFederation) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"message":"sign-in state is invalid or expired"}}`,
+		},
+		{
+			name:   "callback: denied at the provider",
+			method: http.MethodGet,
+			path:   "/auth/federation/google/callback?state=s-1&error=access_denied",
+			cookie: "s-1",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Callback(gomock.Any(), federation.CallbackInput{
+					Provider:  "google",
+					State:     "s-1",
+					Error:     "access_denied",
+					IP:        "192.0.2.1",
+					UserAgent: "test",
+				}).Return(federation.CallbackOutput{}, svcErrs.ErrFederatedSignInFailed)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"sign in at the identity provider failed"}}`,
+			wantCookie:       "federation_state=; Path=/auth/federation/; Max-Age=0; HttpOnly; Secure; SameSite=None",
+		},
+		{
+			name:   "callback: email not verified",
+			method: http.MethodGet,
+			path:   "/auth/federation/google/callback?state=s-1&code=c-1",
+			cookie: "s-1",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Callback(gomock.Any(), gomock.Any()).
+					Return(federation.CallbackOutput{}, svcErrs.ErrFederatedEmailNotVerified)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"the identity provider did not verify the email"}}`,
+			wantCookie:       "federation_state=; Path=/auth/federation/; Max-Age=0; HttpOnly; Secure; SameSite=None",
+		},
+		{
+			name:   "callback: internal error",
+			method: http.MethodGet,
+			path:   "/auth/federation/google/callback?state=s-1&code=c-1",
+			cookie: "s-1",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Callback(gomock.Any(), gomock.Any()).
+					Return(federation.CallbackOutput{}, svcErrs.ErrCannotCreateUser)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+			wantCookie:       "federation_state=; Path=/auth/federation/; Max-Age=0; HttpOnly; Secure; SameSite=None",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ms := servicemocks.NewMockFederation(ctrl)
+			tc.mockBehavior(ms)
+
+			e := gin.New()
+			NewFederationRoutes(e.Group("/auth"), validator.NewCustomValidator(), ms)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.inputBody))
+			req.Header.Set("User-Agent", "test")
+			if tc.inputBody != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "federation_state", Value: tc.cookie})
+			}
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantLocation, w.Header().Get("Location"))
+			assert.Equal(t, tc.wantCookie, w.Header().Get("Set-Cookie"))
+			if tc.wantResponseBody != "" {
+				assert.Equal(t, tc.wantResponseBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestIdentityRoutes(t *testing.T) {
+	userId := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	type MockBehavior func(m *servicemocks.MockFederation)
+
+	testCases := []struct {
+		name             string
+		method           string
+		path             string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+		wantCookie       string
+	}{
+		{
+			name:   "identities: OK",
+			method: http.MethodGet,
+			path:   "/",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Identities(gomock.Any(), userId).Return([]entity.Identity{
+					{Provider: "google", Subject: "1", UserId: userId, Email: "test@example.com", LastUsedAt: &createdAt, CreatedAt: createdAt},
+				}, nil)
+			},
+			wantStatusCode: 200,
+			wantResponseBody: `[{"provider":"google","subject":"1","email":"test@example.com",` +
+				`"last_used_at":"2025-01-02T03:04:05Z","created_at":"2025-01-02T03:04:05Z"}]`,
+		},
+		{
+			name:   "identities: none",
+			method: http.MethodGet,
+			path:   "/",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Identities(gomock.Any(), userId).Return(nil, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `[]`,
+		},
+		{
+			name:   "identities: internal error",
+			method: http.MethodGet,
+			path:   "/",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Identities(gomock.Any(), userId).Return(nil, svcErrs.ErrCannotGetIdentities)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
+		{
+			name:   "link: OK",
+			method: http.MethodPost,
+			path:   "/google",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().BeginLink(gomock.Any(), userId, "google").
+					Return(federation.BeginOutput{URL: "https://accounts.google.com/o/oauth2/auth?state=s-1", State: "s-1"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"url":"https://accounts.google.com/o/oauth2/auth?state=s-1"}`,
+			wantCookie:       "federation_state=s-1; Path=/auth/federation/; HttpOnly; Secure; SameSite=None",
+		},
+		{
+			name:   "link: unknown provider",
+			method: http.MethodPost,
+			path:   "/github",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().BeginLink(gomock.Any(), userId, "github").Return(federation.BeginOutput{}, svcErrs.ErrUnknownProvider)
+			},
+			wantStatusCode:   404,
+			wantResponseBody: `{"errors":{"message":"unknown identity provider"}}`,
+		},
+		{
+			name:   "unlink: OK",
+			method: http.MethodDelete,
+			path:   "/google/1",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Unlink(gomock.Any(), userId, "google", "1").Return(nil)
+			},
+			wantStatusCode: 204,
+		},
+		{
+			name:   "unlink: not linked",
+			method: http.MethodDelete,
+			path:   "/google/1",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Unlink(gomock.Any(), userId, "google", "1").Return(svcErrs.ErrIdentityNotFound)
+			},
+			wantStatusCode:   404,
+			wantResponseBody: `{"errors":{"message":"identity not found"}}`,
+		},
+		{
+			name:   "unlink: last sign-in method",
+			method: http.MethodDelete,
+			path:   "/google/1",
+			mockBehavior: func(m *servicemocks.MockFederation) {
+				m.EXPECT().Unlink(gomock.Any(), userId, "google", "1").Return(svcErrs.ErrLastLoginMethod)
+			},
+			wantStatusCode:   409,
+			wantResponseBody: `{"errors":{"message":"cannot unlink the last sign-in method"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ms := servicemocks.NewMockFederation(ctrl)
+			tc.mockBehavior(ms)
+
+			e := gin.New()
+			g := e.Group("/identities", func(c *gin.Context) {
+				c.Set(middleware.UserIdKey, userId)
+			})
+			NewIdentityRoutes(g, ms)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, "/identities"+tc.path, nil)
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+			assert.Equal(t, tc.wantCookie, w.Header().Get("Set-Cookie"))
+		})
+	}
+}
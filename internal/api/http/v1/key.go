@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"errors"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type keyRoutes struct {
+	ks service.Keys
+}
+
+func NewKeyRoutes(g *gin.RouterGroup, ks service.Keys) {
+	r := &keyRoutes{ks}
+
+	g.GET("/", r.keys)
+	g.POST("/rotate", r.rotate)
+	g.POST("/:kid/revoke", r.revoke)
+}
+
+// @Summary     Signing keys
+// @Description List the signing keys that are not revoked
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminApiKey
+// @Success     200 {array} entity.SigningKey
+// @Failure     401 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /admin/keys [get]
+func (r *keyRoutes) keys(c *gin.Context) {
+	keys, err := r.ks.Keys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// @Summary     Rotate signing key
+// @Description Activate a new signing key now, the previous one keeps verifying until its tokens expire
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminApiKey
+// @Success     200 {object} entity.SigningKey
+// @Failure     401 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /admin/keys/rotate [post]
+func (r *keyRoutes) rotate(c *gin.Context) {
+	key, err := r.ks.Rotate(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// @Summary     Revoke signing key
+// @Description Stop accepting tokens signed by the key, e.g. when it is compromised
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminApiKey
+// @Param       kid path string true "Key id"
+// @Success     204
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /admin/keys/{kid}/revoke [post]
+func (r *keyRoutes) revoke(c *gin.Context) {
+	err := r.ks.Revoke(c.Request.Context(), c.Param("kid"))
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrKeyNotFound) {
+			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"errors"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+type lockoutRoutes struct {
+	ls service.Lockout
+	cv *validator.CustomValidator
+}
+
+func NewLockoutRoutes(g *gin.RouterGroup, cv *validator.CustomValidator, ls service.Lockout) {
+	r := &lockoutRoutes{ls, cv}
+
+	g.POST("/unlock", r.unlock)
+}
+
+type unlockRequest struct {
+	Email string `json:"email" validate:"required,email,min=5,max=150" minLength:"5" maxLength:"150" example:"email@example.com"`
+}
+
+// @Summary     Unlock account
+// @Description Lift the lockout of an account after too many failed sign-ins and forget its earlier lockouts
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Security    AdminApiKey
+// @Param       request body unlockRequest true "Unlock payload"
+// @Success     200 {string} string
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /admin/users/unlock [post]
+func (r *lockoutRoutes) unlock(c *gin.Context) {
+	var req unlockRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	if err := r.ls.Unlock(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.String(http.StatusOK, "account unlocked")
+}
+
+// lockoutError writes the response of a locked account (423) or IP (429), it reports whether err was a lockout.
+func lockoutError(c *gin.Context, err error) bool {
+	status := lockoutStatus(c, err)
+	if status == 0 {
+		return false
+	}
+
+	c.JSON(status, response.Error(err.Error()))
+
+	return true
+}
+
+// lockoutStatus sets the Retry-After header of a lockout and returns its status, zero when err is not a lockout.
+func lockoutStatus(c *gin.Context, err error) int {
+	var status int
+	switch {
+	case errors.Is(err, svcErrs.ErrAccountLocked):
+		status = http.StatusLocked
+	case errors.Is(err, svcErrs.ErrTooManySignInAttempts):
+		status = http.StatusTooManyRequests
+	default:
+		return 0
+	}
+
+	var retryErr *svcErrs.RetryAfterError
+	if errors.As(err, &retryErr) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryErr.RetryAfter.Seconds()))))
+	}
+
+	return status
+}
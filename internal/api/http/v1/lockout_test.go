@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"bytes"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLockoutRoutes_Unlock(t *testing.T) {
+	type MockBehavior func(m *servicemocks.MockLockout)
+
+	testCases := []struct {
+		name             string
+		inputBody        string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"email":"test@example.com"}`,
+			mockBehavior: func(m *servicemocks.MockLockout) {
+				m.EXPECT().Unlock(gomock.Any(), "test@example.com").Return(nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: "account unlocked",
+		},
+		{
+			name:             "invalid email",
+			inputBody:        `{"email":"test"}`,
+			mockBehavior:     func(m *servicemocks.MockLockout) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Email":"Invalid email format"}}`,
+		},
+		{
+			name:      "internal error",
+			inputBody: `{"email":"test@example.com"}`,
+			mockBehavior: func(m *servicemocks.MockLockout) {
+				m.EXPECT().Unlock(gomock.Any(), "test@example.com").Return(svcErrs.ErrAccessToCache)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ls := servicemocks.NewMockLockout(ctrl)
+			tc.mockBehavior(ls)
+
+			e := gin.New()
+			NewLockoutRoutes(e.Group("/admin/users"), validator.NewCustomValidator(), ls)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/admin/users/unlock", bytes.NewBufferString(tc.inputBody))
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
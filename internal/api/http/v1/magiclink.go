@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"errors"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const (
+	magicLinkCookie     = "magic_link_binding"
+	magicLinkCookiePath = "/auth/magic-link"
+)
+
+type magicLinkRequest struct {
+	Email string `json:"email" validate:"required,email,min=5,max=150" minLength:"5" maxLength:"150" example:"email@example.com"`
+	// Device label shown in the list of sessions
+	Device string `json:"device" validate:"max=100" maxLength:"100" example:"iPhone 15"`
+	// Nonce is echoed in the ID token
+	Nonce string `json:"nonce" validate:"max=255" maxLength:"255" example:"n-0S6_WzA2Mj"`
+}
+
+// @Summary     Request sign-in link
+// @Description Emails a single-use, short-lived sign-in link. The response is the same whether there is
+// @Description such an account or not. The link is bound to the browser with a cookie that /auth/magic-link/verify checks.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body magicLinkRequest true "Sign-in link payload"
+// @Success     202 {string} string
+// @Failure     400 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/magic-link [post]
+func (r *authRoutes) requestMagicLink(c *gin.Context) {
+	var req magicLinkRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	// links asked for earlier from the same browser keep working
+	binding, _ := c.Cookie(magicLinkCookie)
+
+	binding, err := r.as.RequestMagicLink(c.Request.Context(), auth.MagicLinkInput{
+		Email:   req.Email,
+		Binding: binding,
+		Device:  req.Device,
+		Nonce:   req.Nonce,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(magicLinkCookie, binding, 0, magicLinkCookiePath, "", true, true)
+	c.String(http.StatusAccepted, "sign-in link sent if the account exists")
+}
+
+type verifyMagicLinkRequest struct {
+	// Token from the sign-in link
+	Token string `json:"token" validate:"required"`
+}
+
+// @Summary     Sign in with a link
+// @Description Exchanges the token of a sign-in link for tokens, in the browser that asked for the link.
+// @Description Users with two-factor authentication get mfa_token instead of the tokens.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body verifyMagicLinkRequest true "Sign-in link token"
+// @Success     200 {object} signInResponse
+// @Failure     400 {object} response.ErrResponse
+// @Failure     403 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/magic-link/verify [post]
+func (r *authRoutes) verifyMagicLink(c *gin.Context) {
+	var req verifyMagicLinkRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	binding, _ := c.Cookie(magicLinkCookie)
+
+	tokens, err := r.as.VerifyMagicLink(c.Request.Context(), auth.VerifyMagicLinkInput{
+		Token:     req.Token,
+		Binding:   binding,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrInvalidMagicLink) {
+			c.JSON(http.StatusForbidden, response.Error(err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.JSON(http.StatusOK, newSignInResponse(tokens))
+}
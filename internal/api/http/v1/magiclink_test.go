@@ -0,0 +1,166 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthRoutes_MagicLink(t *testing.T) {
+	type MockBehavior func(m *servicemocks.MockAuth)
+
+	testCases := []struct {
+		name             string
+		path             string
+		inputBody        string
+		cookie           string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+		wantCookie       string
+	}{
+		{
+			name:      "request: OK",
+			path:      "/auth/magic-link",
+			inputBody: `{"email":"test@example.com","device":"Laptop","nonce":"nonce"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().RequestMagicLink(context.Background(), auth.MagicLinkInput{
+					Email:  "test@example.com",
+					Device: "Laptop",
+					Nonce:  "nonce",
+				}).Return("b-1", nil)
+			},
+			wantStatusCode:   202,
+			wantResponseBody: "sign-in link sent if the account exists",
+			wantCookie:       "magic_link_binding=b-1; Path=/auth/magic-link; HttpOnly; Secure; SameSite=Lax",
+		},
+		{
+			name:      "request: browser asked before",
+			path:      "/auth/magic-link",
+			inputBody: `{"email":"test@example.com"}`,
+			cookie:    "b-1",
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().RequestMagicLink(context.Background(), auth.MagicLinkInput{Email: "test@example.com", Binding: "b-1"}).
+					Return("b-1", nil)
+			},
+			wantStatusCode:   202,
+			wantResponseBody: "sign-in link sent if the account exists",
+			wantCookie:       "magic_link_binding=b-1; Path=/auth/magic-link; HttpOnly; Secure; SameSite=Lax",
+		},
+		{
+			name:             "request: invalid email",
+			path:             "/auth/magic-link",
+			inputBody:        `{"email":"email"}`,
+			mockBehavior:     func(m *servicemocks.MockAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Email":"Invalid email format"}}`,
+		},
+		{
+			name:      "request: internal server error",
+			path:      "/auth/magic-link",
+			inputBody: `{"email":"test@example.com"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().RequestMagicLink(context.Background(), auth.MagicLinkInput{Email: "test@example.com"}).
+					Return("", svcErrs.ErrSendMagicLinkEmail)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
+		{
+			name:      "verify: OK",
+			path:      "/auth/magic-link/verify",
+			inputBody: `{"token":"link"}`,
+			cookie:    "b-1",
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyMagicLink(context.Background(), auth.VerifyMagicLinkInput{
+					Token:     "link",
+					Binding:   "b-1",
+					IP:        "192.0.2.1",
+					UserAgent: "test",
+				}).Return(auth.GenerateTokenOutput{AccessToken: "1", RefreshToken: "2", IdToken: "3"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","refresh_token":"2","id_token":"3"}`,
+		},
+		{
+			name:      "verify: second factor required",
+			path:      "/auth/magic-link/verify",
+			inputBody: `{"token":"link"}`,
+			cookie:    "b-1",
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyMagicLink(context.Background(), gomock.Any()).Return(auth.GenerateTokenOutput{MFAToken: "mfa"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"mfa_required":true,"mfa_token":"mfa"}`,
+		},
+		{
+			name:             "verify: token not provided",
+			path:             "/auth/magic-link/verify",
+			inputBody:        `{}`,
+			mockBehavior:     func(m *servicemocks.MockAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Token":"Is a required"}}`,
+		},
+		{
+			name:      "verify: another browser",
+			path:      "/auth/magic-link/verify",
+			inputBody: `{"token":"link"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyMagicLink(context.Background(), auth.VerifyMagicLinkInput{
+					Token:     "link",
+					IP:        "192.0.2.1",
+					UserAgent: "test",
+				}).Return(auth.GenerateTokenOutput{}, svcErrs.ErrInvalidMagicLink)
+			},
+			wantStatusCode:   403,
+			wantResponseBody: `{"errors":{"message":"sign-in link is invalid or expired"}}`,
+		},
+		{
+			name:      "verify: internal server error",
+			path:      "/auth/magic-link/verify",
+			inputBody: `{"token":"link"}`,
+			cookie:    "b-1",
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyMagicLink(context.Background(), gomock.Any()).Return(auth.GenerateTokenOutput{}, svcErrs.ErrCannotGetUser)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			as := servicemocks.NewMockAuth(ctrl)
+			tc.mockBehavior(as)
+
+			gin.SetMode(gin.TestMode)
+			e := gin.New()
+			NewAuthRoutes(e.Group("/auth"), validator.NewCustomValidator(), as)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.inputBody))
+			req.Header.Set("User-Agent", "test")
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "magic_link_binding", Value: tc.cookie})
+			}
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+			assert.Equal(t, tc.wantCookie, w.Header().Get("Set-Cookie"))
+		})
+	}
+}
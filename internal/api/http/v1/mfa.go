@@ -0,0 +1,201 @@
+package v1
+
+import (
+	"errors"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type mfaRoutes struct {
+	ms service.MFA
+	cv *validator.CustomValidator
+}
+
+func NewMFARoutes(g *gin.RouterGroup, cv *validator.CustomValidator, ms service.MFA) {
+	r := &mfaRoutes{ms, cv}
+
+	g.POST("/totp", r.enrollTOTP)
+	g.POST("/totp/confirm", r.confirmTOTP)
+	g.DELETE("/totp", r.disableTOTP)
+	g.POST("/recovery-codes", r.regenerateRecoveryCodes)
+}
+
+type enrollTOTPResponse struct {
+	// Secret for manual entry in the authenticator app
+	Secret string `json:"secret"      example:"JBSWY3DPEHPK3PXP"`
+	URI    string `json:"otpauth_uri" example:"otpauth://totp/uni-auth:email@example.com?issuer=uni-auth&secret=JBSWY3DPEHPK3PXP"`
+	// QR code of otpauth_uri, base64 encoded PNG
+	QRCode []byte `json:"qr_png" swaggertype:"string" format:"base64"`
+}
+
+type totpCodeRequest struct {
+	// One-time code of the authenticator app
+	Code string `json:"code" validate:"required,numeric,len=6" minLength:"6" maxLength:"6" example:"123456"`
+}
+
+type mfaCodeRequest struct {
+	// One-time code of the authenticator app or a recovery code
+	Code string `json:"code" validate:"required,max=20" maxLength:"20" example:"123456"`
+}
+
+type recoveryCodesResponse struct {
+	// Each code can be used once instead of a one-time code, they are not shown again
+	RecoveryCodes []string `json:"recovery_codes" example:"abcde-fgh23"`
+}
+
+// @Summary     Enroll TOTP
+// @Description Generates a new authenticator app secret. Two-factor authentication is enabled
+// @Description once the first code is confirmed, until then the enrollment can be started over.
+// @Tags        mfa
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200 {object} enrollTOTPResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     409 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/mfa/totp [post]
+func (r *mfaRoutes) enrollTOTP(c *gin.Context) {
+	out, err := r.ms.EnrollTOTP(c.Request.Context(), userIdFromContext(c))
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrMFAAlreadyEnabled) {
+			c.JSON(http.StatusConflict, response.Error(err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollTOTPResponse{
+		Secret: out.Secret,
+		URI:    out.URI,
+		QRCode: out.QRCode,
+	})
+}
+
+// @Summary     Confirm TOTP
+// @Description Enables two-factor authentication with the first code of the authenticator app
+// @Description and returns the recovery codes.
+// @Tags        mfa
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       request body totpCodeRequest true "One-time code"
+// @Success     200 {object} recoveryCodesResponse
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     409 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/mfa/totp/confirm [post]
+func (r *mfaRoutes) confirmTOTP(c *gin.Context) {
+	var req totpCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	codes, err := r.ms.ConfirmTOTP(c.Request.Context(), userIdFromContext(c), req.Code)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// @Summary     Disable TOTP
+// @Description Turns two-factor authentication off, a current code of the authenticator app
+// @Description or a recovery code is required.
+// @Tags        mfa
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       request body mfaCodeRequest true "One-time code or recovery code"
+// @Success     204
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/mfa/totp [delete]
+func (r *mfaRoutes) disableTOTP(c *gin.Context) {
+	var req mfaCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	err := r.ms.DisableTOTP(c.Request.Context(), userIdFromContext(c), req.Code)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Regenerate recovery codes
+// @Description Replaces the recovery codes, the previous ones stop working.
+// @Description A current code of the authenticator app or a recovery code is required.
+// @Tags        mfa
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       request body mfaCodeRequest true "One-time code or recovery code"
+// @Success     200 {object} recoveryCodesResponse
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/mfa/recovery-codes [post]
+func (r *mfaRoutes) regenerateRecoveryCodes(c *gin.Context) {
+	var req mfaCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	codes, err := r.ms.RegenerateRecoveryCodes(c.Request.Context(), userIdFromContext(c), req.Code)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func mfaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, svcErrs.ErrInvalidOTP):
+		c.JSON(http.StatusUnauthorized, response.Error(err.Error()))
+	case errors.Is(err, svcErrs.ErrMFANotEnrolled), errors.Is(err, svcErrs.ErrMFANotEnabled):
+		c.JSON(http.StatusNotFound, response.Error(err.Error()))
+	case errors.Is(err, svcErrs.ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, response.Error(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+	}
+}
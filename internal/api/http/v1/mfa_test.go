@@ -0,0 +1,176 @@
+package v1
+
+import (
+	"bytes"
+	"github.com/bubalync/uni-auth/internal/api/http/middleware"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/internal/service/mfa"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMFARoutes(t *testing.T) {
+	userId := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+	type MockBehavior func(m *servicemocks.MockMFA)
+
+	testCases := []struct {
+		name             string
+		method           string
+		path             string
+		inputBody        string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:   "enroll: OK",
+			method: http.MethodPost,
+			path:   "/totp",
+			mockBehavior: func(m *servicemocks.MockMFA) {
+				m.EXPECT().EnrollTOTP(gomock.Any(), userId).Return(mfa.EnrollTOTPOutput{
+					Secret: "JBSWY3DPEHPK3PXP",
+					URI:    "otpauth://totp/uni-auth:test@example.com?secret=JBSWY3DPEHPK3PXP",
+					QRCode: []byte("png"),
+				}, nil)
+			},
+			wantStatusCode: 200,
+			wantResponseBody: `{"secret":"JBSWY3DPEHPK3PXP",` +
+				`"otpauth_uri":"otpauth://totp/uni-auth:test@example.com?secret=JBSWY3DPEHPK3PXP","qr_png":"cG5n"}`,
+		},
+		{
+			name:   "enroll: already enabled",
+			method: http.MethodPost,
+			path:   "/totp",
+			mockBehavior: func(m *servicemocks.MockMFA) {
+				m.EXPECT().EnrollTOTP(gomock.Any(), userId).Return(mfa.EnrollTOTPOutput{}, svcErrs.ErrMFAAlreadyEnabled)
+			},
+			wantStatusCode:   409,
+			wantResponseBody: `{"errors":{"message":"two-factor authentication is already enabled"}}`,
+		},
+		{
+			name:      "confirm: OK",
+			method:    http.MethodPost,
+			path:      "/totp/confirm",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockMFA) {
+				m.EXPECT().ConfirmTOTP(gomock.Any(), userId, "123456").Return([]string{"abcde-fgh23", "ijklm-nop45"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"recovery_codes":["abcde-fgh23","ijklm-nop45"]}`,
+		},
+		{
+			name:             "confirm: code too short",
+			method:           http.MethodPost,
+			path:             "/totp/confirm",
+			inputBody:        `{"code":"12345"}`,
+			mockBehavior:     func(m *servicemocks.MockMFA) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Code":"Is not valid"}}`,
+		},
+		{
+			name:      "confirm: not enrolled",
+			method:    http.MethodPost,
+			path:      "/totp/confirm",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockMFA) {
+				m.EXPECT().ConfirmTOTP(gomock.Any(), userId, "123456").Return(nil, svcErrs.ErrMFANotEnrolled)
+			},
+			wantStatusCode:   404,
+			wantResponseBody: `{"errors":{"message":"two-factor authentication is not enrolled"}}`,
+		},
+		{
+			name:      "disable: OK",
+			method:    http.MethodDelete,
+			path:      "/totp",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockMFA) {
+				m.EXPECT().DisableTOTP(gomock.Any(), userId, "123456").Return(nil)
+			},
+			wantStatusCode: 204,
+		},
+		{
+			name:      "disable: invalid code",
+			method:    http.MethodDelete,
+			path:      "/totp",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockMFA) {
+				m.EXPECT().DisableTOTP(gomock.Any(), userId, "123456").Return(svcErrs.ErrInvalidOTP)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"invalid one-time code"}}`,
+		},
+		{
+			name:      "disable: with recovery code",
+			method:    http.MethodDelete,
+			path:      "/totp",
+			inputBody: `{"code":"abcde-fgh23"}`,
+			mockBehavior: func(m *servicemocks.MockMFA) {
+				m.EXPECT().DisableTOTP(gomock.Any(), userId, "abcde-fgh23").Return(nil)
+			},
+			wantStatusCode: 204,
+		},
+		{
+			name:      "regenerate recovery codes: OK",
+			method:    http.MethodPost,
+			path:      "/recovery-codes",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockMFA) {
+				m.EXPECT().RegenerateRecoveryCodes(gomock.Any(), userId, "123456").Return([]string{"abcde-fgh23"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"recovery_codes":["abcde-fgh23"]}`,
+		},
+		{
+			name:      "regenerate recovery codes: not enabled",
+			method:    http.MethodPost,
+			path:      "/recovery-codes",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockMFA) {
+				m.EXPECT().RegenerateRecoveryCodes(gomock.Any(), userId, "123456").Return(nil, svcErrs.ErrMFANotEnabled)
+			},
+			wantStatusCode:   404,
+			wantResponseBody: `{"errors":{"message":"two-factor authentication is not enabled"}}`,
+		},
+		{
+			name:             "regenerate recovery codes: code not provided",
+			method:           http.MethodPost,
+			path:             "/recovery-codes",
+			inputBody:        `{}`,
+			mockBehavior:     func(m *servicemocks.MockMFA) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Code":"Is a required"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ms := servicemocks.NewMockMFA(ctrl)
+			tc.mockBehavior(ms)
+
+			e := gin.New()
+			g := e.Group("/mfa", func(c *gin.Context) {
+				c.Set(middleware.UserIdKey, userId)
+			})
+			NewMFARoutes(g, validator.NewCustomValidator(), ms)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, "/mfa"+tc.path, bytes.NewBufferString(tc.inputBody))
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,519 @@
+package v1
+
+import (
+	"errors"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/oauth"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/gin-gonic/gin"
+	"html/template"
+	"net/http"
+	"net/url"
+)
+
+// OAuth error codes (RFC 6749, sections 4.1.2.1 and 5.2).
+const (
+	oauthErrInvalidRequest          = "invalid_request"
+	oauthErrInvalidClient           = "invalid_client"
+	oauthErrUnauthorizedClient      = "unauthorized_client"
+	oauthErrInvalidGrant            = "invalid_grant"
+	oauthErrInvalidScope            = "invalid_scope"
+	oauthErrUnsupportedResponseType = "unsupported_response_type"
+	oauthErrUnsupportedGrantType    = "unsupported_grant_type"
+	oauthErrServerError             = "server_error"
+)
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Sign in</title></head>
+<body>
+<h1>Sign in to {{.Request.ClientId}}</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="">
+<input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.Request.ClientId}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Request.Scope}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+<label>Email <input type="email" name="email" value="{{.Email}}" required autofocus></label>
+<label>Password <input type="password" name="password" required></label>
+{{if .MFARequired}}<label>One-time code or recovery code <input type="text" name="otp" autocomplete="one-time-code" required></label>{{end}}
+<button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
+
+type oauthRoutes struct {
+	os service.OAuth
+}
+
+func NewOAuthRoutes(g *gin.RouterGroup, oauthService service.OAuth) {
+	r := &oauthRoutes{oauthService}
+
+	g.GET("/authorize", r.authorizePage)
+	g.POST("/authorize", r.authorize)
+	g.POST("/token", r.token)
+	g.POST("/introspect", r.introspect)
+	g.POST("/revoke", r.revoke)
+}
+
+type authorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientId            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+func (r authorizeRequest) toService() oauth.AuthorizationRequest {
+	return oauth.AuthorizationRequest{
+		ResponseType:        r.ResponseType,
+		ClientId:            r.ClientId,
+		RedirectURI:         r.RedirectURI,
+		Scope:               r.Scope,
+		State:               r.State,
+		Nonce:               r.Nonce,
+		CodeChallenge:       r.CodeChallenge,
+		CodeChallengeMethod: r.CodeChallengeMethod,
+	}
+}
+
+type loginForm struct {
+	authorizeRequest
+	Email    string `form:"email"`
+	Password string `form:"password"`
+	OTP      string `form:"otp"`
+}
+
+type loginPageData struct {
+	Request authorizeRequest
+	Email   string
+	Error   string
+	// MFARequired shows the one-time code field.
+	MFARequired bool
+}
+
+// @Summary     Authorization endpoint
+// @Description Validates the authorization request and shows the sign in form.
+// @Description PKCE with code_challenge_method S256 is required.
+// @Tags        oauth
+// @Produce     html
+// @Param       response_type         query string true  "Must be code"
+// @Param       client_id             query string true  "Registered client id"
+// @Param       redirect_uri          query string true  "Registered redirect URI"
+// @Param       scope                 query string false "Space separated scopes: openid, email"
+// @Param       state                 query string false "Opaque value returned to the client"
+// @Param       nonce                 query string false "Echoed in the ID token"
+// @Param       code_challenge        query string true  "BASE64URL(SHA256(code_verifier))"
+// @Param       code_challenge_method query string true  "Must be S256"
+// @Success     200
+// @Failure     302 "Redirect to the client with an error"
+// @Failure     400 {object} response.ErrResponse
+// @Router      /oauth/authorize [get]
+func (r *oauthRoutes) authorizePage(c *gin.Context) {
+	var req authorizeRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if err := r.os.ValidateAuthorizationRequest(c.Request.Context(), req.toService()); err != nil {
+		r.authorizeError(c, req, err)
+		return
+	}
+
+	renderLoginPage(c, http.StatusOK, loginPageData{Request: req})
+}
+
+// @Summary     Authorization endpoint
+// @Description Checks the credentials from the sign in form and redirects to the client with a single-use code.
+// @Tags        oauth
+// @Accept      x-www-form-urlencoded
+// @Produce     html
+// @Param       response_type         formData string true  "Must be code"
+// @Param       client_id             formData string true  "Registered client id"
+// @Param       redirect_uri          formData string true  "Registered redirect URI"
+// @Param       scope                 formData string false "Space separated scopes: openid, email"
+// @Param       state                 formData string false "Opaque value returned to the client"
+// @Param       nonce                 formData string false "Echoed in the ID token"
+// @Param       code_challenge        formData string true  "BASE64URL(SHA256(code_verifier))"
+// @Param       code_challenge_method formData string true  "Must be S256"
+// @Param       email                 formData string true  "Email"
+// @Param       password              formData string true  "Password"
+// @Param       otp                   formData string false "One-time code, required with two-factor authentication"
+// @Success     302 "Redirect to the client with code and state"
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 "Sign in form with an error"
+// @Failure     403 "Sign in form with the error of an unverified email"
+// @Failure     423 "Sign in form with the lockout error, see the Retry-After header"
+// @Failure     429 "Sign in form with the lockout error, see the Retry-After header"
+// @Router      /oauth/authorize [post]
+func (r *oauthRoutes) authorize(c *gin.Context) {
+	var form loginForm
+
+	if err := c.ShouldBind(&form); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	code, err := r.os.Authorize(c.Request.Context(), oauth.AuthorizeInput{
+		Request:   form.toService(),
+		Email:     form.Email,
+		Password:  form.Password,
+		OTP:       form.OTP,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrInvalidCredentials) {
+			renderLoginPage(c, http.StatusUnauthorized, loginPageData{
+				Request:     form.authorizeRequest,
+				Email:       form.Email,
+				Error:       err.Error(),
+				MFARequired: form.OTP != "",
+			})
+			return
+		}
+
+		if errors.Is(err, svcErrs.ErrMFARequired) || errors.Is(err, svcErrs.ErrInvalidOTP) {
+			renderLoginPage(c, http.StatusUnauthorized, loginPageData{
+				Request:     form.authorizeRequest,
+				Email:       form.Email,
+				Error:       err.Error(),
+				MFARequired: true,
+			})
+			return
+		}
+
+		if errors.Is(err, svcErrs.ErrEmailNotVerified) {
+			renderLoginPage(c, http.StatusForbidden, loginPageData{
+				Request: form.authorizeRequest,
+				Email:   form.Email,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if status := lockoutStatus(c, err); status != 0 {
+			renderLoginPage(c, status, loginPageData{
+				Request: form.authorizeRequest,
+				Email:   form.Email,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		r.authorizeError(c, form.authorizeRequest, err)
+		return
+	}
+
+	redirectToClient(c, form.RedirectURI, url.Values{"code": {code}}, form.State)
+}
+
+// authorizeError answers with an error page when the client or the redirect URI
+// cannot be trusted and redirects the error to the client otherwise.
+func (r *oauthRoutes) authorizeError(c *gin.Context, req authorizeRequest, err error) {
+	if errors.Is(err, svcErrs.ErrInvalidClient) || errors.Is(err, svcErrs.ErrInvalidRedirectURI) {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	code, _ := oauthErrorCode(err)
+	params := url.Values{"error": {code}}
+	if code != oauthErrServerError {
+		params.Set("error_description", err.Error())
+	}
+
+	redirectToClient(c, req.RedirectURI, params, req.State)
+}
+
+type tokenRequest struct {
+	GrantType    string `form:"grant_type"    binding:"required"`
+	ClientId     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"    example:"Bearer"`
+	ExpiresIn    int    `json:"expires_in"    example:"1800"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IdToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty" example:"openid email"`
+}
+
+// @Summary     Token endpoint
+// @Description Exchanges an authorization code and its code_verifier for tokens (authorization_code),
+// @Description rotates a refresh token issued to the client (refresh_token),
+// @Description or issues a service token to a confidential client on its own behalf (client_credentials).
+// @Description Refresh tokens are only returned to clients registered for the refresh_token grant.
+// @Description Confidential clients authenticate with HTTP Basic or with client_id and client_secret in the body.
+// @Tags        oauth
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       grant_type    formData string true  "authorization_code, refresh_token or client_credentials"
+// @Param       client_id     formData string false "Client id, unless sent with HTTP Basic"
+// @Param       client_secret formData string false "Secret of a confidential client, unless sent with HTTP Basic"
+// @Param       code          formData string false "Authorization code, for authorization_code"
+// @Param       redirect_uri  formData string false "Redirect URI of the authorization request, for authorization_code"
+// @Param       code_verifier formData string false "PKCE code verifier, for authorization_code"
+// @Param       refresh_token formData string false "Refresh token, for refresh_token"
+// @Param       scope         formData string false "Space separated scopes, for client_credentials. Defaults to all scopes of the client"
+// @Success     200 {object} tokenResponse
+// @Failure     400 {object} response.OAuthErrResponse
+// @Failure     401 {object} response.OAuthErrResponse
+// @Failure     500 {object} response.OAuthErrResponse
+// @Router      /oauth/token [post]
+func (r *oauthRoutes) token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req tokenRequest
+
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.OAuthError(oauthErrInvalidRequest, err.Error()))
+		return
+	}
+
+	req.ClientId, req.ClientSecret = clientAuthentication(c, req.ClientId, req.ClientSecret)
+
+	tokens, err := r.os.Token(c.Request.Context(), oauth.TokenRequest{
+		GrantType:    req.GrantType,
+		ClientId:     req.ClientId,
+		ClientSecret: req.ClientSecret,
+		Code:         req.Code,
+		RedirectURI:  req.RedirectURI,
+		CodeVerifier: req.CodeVerifier,
+		RefreshToken: req.RefreshToken,
+		Scope:        req.Scope,
+		IP:           c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+	if err != nil {
+		endpointError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    tokens.TokenType,
+		ExpiresIn:    int(tokens.ExpiresIn.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		IdToken:      tokens.IdToken,
+		Scope:        tokens.Scope,
+	})
+}
+
+type introspectionRequest struct {
+	Token         string `form:"token"           binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientId      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+type introspectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"      example:"openid email"`
+	ClientId  string `json:"client_id,omitempty"  example:"example-spa"`
+	Sub       string `json:"sub,omitempty"        example:"d13a75e2-3d21-4e57-9dc0-3a7f5bee4c25"`
+	Exp       int64  `json:"exp,omitempty"        example:"1735689600"`
+	Iat       int64  `json:"iat,omitempty"        example:"1735687800"`
+	Jti       string `json:"jti,omitempty"`
+	TokenType string `json:"token_type,omitempty" example:"access_token"`
+}
+
+// @Summary     Introspection endpoint
+// @Description Describes an access or refresh token (RFC 7662). Revoked, expired and unknown tokens are reported as {"active": false}.
+// @Description The caller authenticates as a confidential client with HTTP Basic or with client_id and client_secret in the body.
+// @Tags        oauth
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       token           formData string true  "The token to describe"
+// @Param       token_type_hint formData string false "access_token or refresh_token"
+// @Param       client_id       formData string false "Client id, unless sent with HTTP Basic"
+// @Param       client_secret   formData string false "Client secret, unless sent with HTTP Basic"
+// @Success     200 {object} introspectionResponse
+// @Failure     400 {object} response.OAuthErrResponse
+// @Failure     401 {object} response.OAuthErrResponse
+// @Failure     500 {object} response.OAuthErrResponse
+// @Router      /oauth/introspect [post]
+func (r *oauthRoutes) introspect(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req introspectionRequest
+
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.OAuthError(oauthErrInvalidRequest, err.Error()))
+		return
+	}
+
+	req.ClientId, req.ClientSecret = clientAuthentication(c, req.ClientId, req.ClientSecret)
+
+	info, err := r.os.Introspect(c.Request.Context(), oauth.IntrospectionRequest{
+		ClientId:      req.ClientId,
+		ClientSecret:  req.ClientSecret,
+		Token:         req.Token,
+		TokenTypeHint: req.TokenTypeHint,
+	})
+	if err != nil {
+		endpointError(c, err)
+		return
+	}
+
+	if !info.Active {
+		c.JSON(http.StatusOK, introspectionResponse{Active: false})
+		return
+	}
+
+	c.JSON(http.StatusOK, introspectionResponse{
+		Active:    true,
+		Scope:     info.Scope,
+		ClientId:  info.ClientId,
+		Sub:       info.Subject,
+		Exp:       info.ExpiresAt.Unix(),
+		Iat:       info.IssuedAt.Unix(),
+		Jti:       info.TokenId,
+		TokenType: info.TokenType,
+	})
+}
+
+type revocationRequest struct {
+	Token         string `form:"token"           binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientId      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// @Summary     Revocation endpoint
+// @Description Revokes an access or refresh token (RFC 7009). Revoking a refresh token ends its session and denies its access tokens.
+// @Description The client may only revoke tokens issued to it. Invalid and already revoked tokens are answered with 200 as well.
+// @Tags        oauth
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       token           formData string true  "The token to revoke"
+// @Param       token_type_hint formData string false "access_token or refresh_token"
+// @Param       client_id       formData string false "Client id, unless sent with HTTP Basic"
+// @Param       client_secret   formData string false "Client secret of a confidential client, unless sent with HTTP Basic"
+// @Success     200
+// @Failure     400 {object} response.OAuthErrResponse
+// @Failure     401 {object} response.OAuthErrResponse
+// @Failure     500 {object} response.OAuthErrResponse
+// @Router      /oauth/revoke [post]
+func (r *oauthRoutes) revoke(c *gin.Context) {
+	var req revocationRequest
+
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.OAuthError(oauthErrInvalidRequest, err.Error()))
+		return
+	}
+
+	req.ClientId, req.ClientSecret = clientAuthentication(c, req.ClientId, req.ClientSecret)
+
+	err := r.os.Revoke(c.Request.Context(), oauth.RevocationRequest{
+		ClientId:      req.ClientId,
+		ClientSecret:  req.ClientSecret,
+		Token:         req.Token,
+		TokenTypeHint: req.TokenTypeHint,
+	})
+	if err != nil {
+		endpointError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// clientAuthentication returns the client credentials of the request,
+// client_secret_basic takes precedence over client_secret_post.
+func clientAuthentication(c *gin.Context, clientId, clientSecret string) (string, string) {
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		return id, secret
+	}
+
+	return clientId, clientSecret
+}
+
+// endpointError answers a request to the token, introspection or revocation endpoint with an OAuth error.
+func endpointError(c *gin.Context, err error) {
+	code, status := oauthErrorCode(err)
+	if code == oauthErrServerError {
+		c.JSON(status, response.OAuthError(code, ""))
+		return
+	}
+
+	if status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+
+	c.JSON(status, response.OAuthError(code, err.Error()))
+}
+
+// oauthErrorCode maps a service error to an OAuth error code and an HTTP status.
+func oauthErrorCode(err error) (string, int) {
+	switch {
+	case errors.Is(err, svcErrs.ErrInvalidClient), errors.Is(err, svcErrs.ErrInvalidClientCredentials):
+		return oauthErrInvalidClient, http.StatusUnauthorized
+	case errors.Is(err, svcErrs.ErrUnauthorizedClient), errors.Is(err, svcErrs.ErrTokenNotIssuedToClient):
+		return oauthErrUnauthorizedClient, http.StatusBadRequest
+	case errors.Is(err, svcErrs.ErrInvalidGrant):
+		return oauthErrInvalidGrant, http.StatusBadRequest
+	case errors.Is(err, svcErrs.ErrInvalidScope):
+		return oauthErrInvalidScope, http.StatusBadRequest
+	case errors.Is(err, svcErrs.ErrPKCERequired):
+		return oauthErrInvalidRequest, http.StatusBadRequest
+	case errors.Is(err, svcErrs.ErrUnsupportedResponseType):
+		return oauthErrUnsupportedResponseType, http.StatusBadRequest
+	case errors.Is(err, svcErrs.ErrUnsupportedGrantType):
+		return oauthErrUnsupportedGrantType, http.StatusBadRequest
+	default:
+		return oauthErrServerError, http.StatusInternalServerError
+	}
+}
+
+func renderLoginPage(c *gin.Context, status int, data loginPageData) {
+	// the form takes credentials, it must not be framed by another site
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "frame-ancestors 'none'")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+
+	if err := loginPage.Execute(c.Writer, data); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// redirectToClient appends params and state to the registered redirect URI.
+func redirectToClient(c *gin.Context, redirectURI string, params url.Values, state string) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(svcErrs.ErrInvalidRedirectURI.Error()))
+		return
+	}
+
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	if state != "" {
+		q.Set("state", state)
+	}
+	u.RawQuery = q.Encode()
+
+	c.Redirect(http.StatusFound, u.String())
+}
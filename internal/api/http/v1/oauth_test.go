@@ -0,0 +1,581 @@
+package v1
+
+import (
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/oauth"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var authorizeQuery = url.Values{
+	"response_type":         {"code"},
+	"client_id":             {"example-spa"},
+	"redirect_uri":          {"http://localhost:3000/callback"},
+	"scope":                 {"openid email"},
+	"state":                 {"af0ifjsldkj"},
+	"code_challenge":        {"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+	"code_challenge_method": {"S256"},
+}
+
+func TestOAuthRoutes_AuthorizePage(t *testing.T) {
+	type MockBehavior func(m *servicemocks.MockOAuth)
+
+	testCases := []struct {
+		name           string
+		mockBehavior   MockBehavior
+		wantStatusCode int
+		wantLocation   string
+		wantBody       string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().ValidateAuthorizationRequest(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantStatusCode: 200,
+			wantBody:       `<input type="hidden" name="code_challenge" value="E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM">`,
+		},
+		{
+			name: "unknown client",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().ValidateAuthorizationRequest(gomock.Any(), gomock.Any()).Return(svcErrs.ErrInvalidClient)
+			},
+			wantStatusCode: 400,
+			wantBody:       `{"errors":{"message":"unknown client"}}`,
+		},
+		{
+			name: "redirect uri is not registered",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().ValidateAuthorizationRequest(gomock.Any(), gomock.Any()).Return(svcErrs.ErrInvalidRedirectURI)
+			},
+			wantStatusCode: 400,
+			wantBody:       `{"errors":{"message":"redirect_uri is not registered for the client"}}`,
+		},
+		{
+			name: "pkce required",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().ValidateAuthorizationRequest(gomock.Any(), gomock.Any()).Return(svcErrs.ErrPKCERequired)
+			},
+			wantStatusCode: 302,
+			wantLocation: "http://localhost:3000/callback?error=invalid_request" +
+				"&error_description=code_challenge+with+code_challenge_method+S256+is+required&state=af0ifjsldkj",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			os := servicemocks.NewMockOAuth(ctrl)
+			tc.mockBehavior(os)
+
+			e := gin.New()
+			NewOAuthRoutes(e.Group("/oauth"), os)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+authorizeQuery.Encode(), nil)
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantLocation, w.Header().Get("Location"))
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+			if tc.wantStatusCode == 200 {
+				assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+			}
+		})
+	}
+}
+
+func TestOAuthRoutes_Authorize(t *testing.T) {
+	form := url.Values{"email": {"test@example.com"}, "password": {"Qwerty!1"}}
+	for k, v := range authorizeQuery {
+		form[k] = v
+	}
+
+	type MockBehavior func(m *servicemocks.MockOAuth)
+
+	testCases := []struct {
+		name           string
+		mockBehavior   MockBehavior
+		wantStatusCode int
+		wantLocation   string
+		wantBody       string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Authorize(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, input oauth.AuthorizeInput) (string, error) {
+						assert.Equal(t, "test@example.com", input.Email)
+						assert.Equal(t, "Qwerty!1", input.Password)
+						assert.Equal(t, "example-spa", input.Request.ClientId)
+						return "code", nil
+					})
+			},
+			wantStatusCode: 302,
+			wantLocation:   "http://localhost:3000/callback?code=code&state=af0ifjsldkj",
+		},
+		{
+			name: "invalid credentials",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Authorize(gomock.Any(), gomock.Any()).Return("", svcErrs.ErrInvalidCredentials)
+			},
+			wantStatusCode: 401,
+			wantBody:       "invalid credentials",
+		},
+		{
+			name: "one-time code required",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Authorize(gomock.Any(), gomock.Any()).Return("", svcErrs.ErrMFARequired)
+			},
+			wantStatusCode: 401,
+			wantBody:       `name="otp"`,
+		},
+		{
+			name: "invalid one-time code",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Authorize(gomock.Any(), gomock.Any()).Return("", svcErrs.ErrInvalidOTP)
+			},
+			wantStatusCode: 401,
+			wantBody:       "invalid one-time code",
+		},
+		{
+			name: "email not verified",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Authorize(gomock.Any(), gomock.Any()).Return("", svcErrs.ErrEmailNotVerified)
+			},
+			wantStatusCode: 403,
+			wantBody:       "email is not verified",
+		},
+		{
+			name: "unknown client",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Authorize(gomock.Any(), gomock.Any()).Return("", svcErrs.ErrInvalidClient)
+			},
+			wantStatusCode: 400,
+			wantBody:       `{"errors":{"message":"unknown client"}}`,
+		},
+		{
+			name: "internal error",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Authorize(gomock.Any(), gomock.Any()).Return("", svcErrs.ErrAccessToCache)
+			},
+			wantStatusCode: 302,
+			wantLocation:   "http://localhost:3000/callback?error=server_error&state=af0ifjsldkj",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			os := servicemocks.NewMockOAuth(ctrl)
+			tc.mockBehavior(os)
+
+			e := gin.New()
+			NewOAuthRoutes(e.Group("/oauth"), os)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/oauth/authorize", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantLocation, w.Header().Get("Location"))
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestOAuthRoutes_Token(t *testing.T) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {"example-spa"},
+		"code":          {"code"},
+		"redirect_uri":  {"http://localhost:3000/callback"},
+		"code_verifier": {"dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"},
+	}
+
+	type MockBehavior func(m *servicemocks.MockOAuth)
+
+	testCases := []struct {
+		name             string
+		inputBody        string
+		basicAuth        []string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+		wantAuthenticate string
+	}{
+		{
+			name:      "OK",
+			inputBody: form.Encode(),
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Token(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, req oauth.TokenRequest) (oauth.TokenResponse, error) {
+						assert.Equal(t, "code", req.Code)
+						assert.Equal(t, "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", req.CodeVerifier)
+						return oauth.TokenResponse{
+							AccessToken:  "1",
+							TokenType:    "Bearer",
+							ExpiresIn:    30 * time.Minute,
+							RefreshToken: "2",
+							IdToken:      "3",
+							Scope:        "openid email",
+						}, nil
+					})
+			},
+			wantStatusCode: 200,
+			wantResponseBody: `{"access_token":"1","token_type":"Bearer","expires_in":1800,` +
+				`"refresh_token":"2","id_token":"3","scope":"openid email"}`,
+		},
+		{
+			name:             "no grant type",
+			inputBody:        "code=code",
+			mockBehavior:     func(m *servicemocks.MockOAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"error":"invalid_request","error_description":"Key: 'tokenRequest.GrantType' Error:Field validation for 'GrantType' failed on the 'required' tag"}`,
+		},
+		{
+			name:      "invalid grant",
+			inputBody: form.Encode(),
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Token(gomock.Any(), gomock.Any()).Return(oauth.TokenResponse{}, svcErrs.ErrInvalidGrant)
+			},
+			wantStatusCode:   400,
+			wantResponseBody: `{"error":"invalid_grant","error_description":"authorization code is invalid or expired"}`,
+		},
+		{
+			name:      "invalid client",
+			inputBody: form.Encode(),
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Token(gomock.Any(), gomock.Any()).Return(oauth.TokenResponse{}, svcErrs.ErrInvalidClient)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"error":"invalid_client","error_description":"unknown client"}`,
+			wantAuthenticate: `Basic realm="oauth"`,
+		},
+		{
+			name:      "client secret in the body",
+			inputBody: form.Encode() + "&client_secret=secret",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Token(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, req oauth.TokenRequest) (oauth.TokenResponse, error) {
+						assert.Equal(t, "example-spa", req.ClientId)
+						assert.Equal(t, "secret", req.ClientSecret)
+						return oauth.TokenResponse{AccessToken: "1", TokenType: "Bearer", ExpiresIn: time.Minute}, nil
+					})
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","token_type":"Bearer","expires_in":60}`,
+		},
+		{
+			name:      "client secret with http basic",
+			inputBody: form.Encode(),
+			basicAuth: []string{"example-backend", "secret"},
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Token(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, req oauth.TokenRequest) (oauth.TokenResponse, error) {
+						assert.Equal(t, "example-backend", req.ClientId)
+						assert.Equal(t, "secret", req.ClientSecret)
+						return oauth.TokenResponse{AccessToken: "1", TokenType: "Bearer", ExpiresIn: time.Minute}, nil
+					})
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","token_type":"Bearer","expires_in":60}`,
+		},
+		{
+			name:      "client credentials",
+			inputBody: "grant_type=client_credentials&scope=orders%3Aread",
+			basicAuth: []string{"billing", "secret"},
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Token(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, req oauth.TokenRequest) (oauth.TokenResponse, error) {
+						assert.Equal(t, "client_credentials", req.GrantType)
+						assert.Equal(t, "billing", req.ClientId)
+						assert.Equal(t, "orders:read", req.Scope)
+						return oauth.TokenResponse{AccessToken: "1", TokenType: "Bearer", ExpiresIn: time.Minute, Scope: "orders:read"}, nil
+					})
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","token_type":"Bearer","expires_in":60,"scope":"orders:read"}`,
+		},
+		{
+			name:      "invalid client credentials",
+			inputBody: form.Encode(),
+			basicAuth: []string{"example-backend", "wrong"},
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Token(gomock.Any(), gomock.Any()).Return(oauth.TokenResponse{}, svcErrs.ErrInvalidClientCredentials)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"error":"invalid_client","error_description":"invalid client credentials"}`,
+			wantAuthenticate: `Basic realm="oauth"`,
+		},
+		{
+			name:      "unauthorized client",
+			inputBody: form.Encode(),
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Token(gomock.Any(), gomock.Any()).Return(oauth.TokenResponse{}, svcErrs.ErrUnauthorizedClient)
+			},
+			wantStatusCode:   400,
+			wantResponseBody: `{"error":"unauthorized_client","error_description":"the client is not allowed to use this grant type"}`,
+		},
+		{
+			name:      "unsupported grant type",
+			inputBody: form.Encode(),
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Token(gomock.Any(), gomock.Any()).Return(oauth.TokenResponse{}, svcErrs.ErrUnsupportedGrantType)
+			},
+			wantStatusCode:   400,
+			wantResponseBody: `{"error":"unsupported_grant_type","error_description":"unsupported grant_type"}`,
+		},
+		{
+			name:      "internal error",
+			inputBody: form.Encode(),
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Token(gomock.Any(), gomock.Any()).Return(oauth.TokenResponse{}, svcErrs.ErrAccessToCache)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"error":"server_error"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			os := servicemocks.NewMockOAuth(ctrl)
+			tc.mockBehavior(os)
+
+			e := gin.New()
+			NewOAuthRoutes(e.Group("/oauth"), os)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(tc.inputBody))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.basicAuth != nil {
+				req.SetBasicAuth(tc.basicAuth[0], tc.basicAuth[1])
+			}
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			assert.Equal(t, tc.wantAuthenticate, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestOAuthRoutes_Introspect(t *testing.T) {
+	type MockBehavior func(m *servicemocks.MockOAuth)
+
+	testCases := []struct {
+		name             string
+		inputBody        string
+		basicAuth        []string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+		wantAuthenticate string
+	}{
+		{
+			name:      "active token",
+			inputBody: "token=t&token_type_hint=access_token",
+			basicAuth: []string{"gateway", "secret"},
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Introspect(gomock.Any(), oauth.IntrospectionRequest{
+					ClientId:      "gateway",
+					ClientSecret:  "secret",
+					Token:         "t",
+					TokenTypeHint: "access_token",
+				}).Return(auth.TokenInfo{
+					Active:    true,
+					Scope:     "openid email",
+					ClientId:  "example-spa",
+					Subject:   "d13a75e2-3d21-4e57-9dc0-3a7f5bee4c25",
+					ExpiresAt: time.Unix(1735689600, 0),
+					IssuedAt:  time.Unix(1735687800, 0),
+					TokenId:   "jti",
+					TokenType: "access_token",
+				}, nil)
+			},
+			wantStatusCode: 200,
+			wantResponseBody: `{"active":true,"scope":"openid email","client_id":"example-spa",` +
+				`"sub":"d13a75e2-3d21-4e57-9dc0-3a7f5bee4c25","exp":1735689600,"iat":1735687800,` +
+				`"jti":"jti","token_type":"access_token"}`,
+		},
+		{
+			name:      "inactive token",
+			inputBody: "token=t&client_id=gateway&client_secret=secret",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Introspect(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, req oauth.IntrospectionRequest) (auth.TokenInfo, error) {
+						assert.Equal(t, "gateway", req.ClientId)
+						assert.Equal(t, "secret", req.ClientSecret)
+						return auth.TokenInfo{}, nil
+					})
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"active":false}`,
+		},
+		{
+			name:             "no token",
+			inputBody:        "client_id=gateway",
+			mockBehavior:     func(m *servicemocks.MockOAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"error":"invalid_request","error_description":"Key: 'introspectionRequest.Token' Error:Field validation for 'Token' failed on the 'required' tag"}`,
+		},
+		{
+			name:      "invalid client credentials",
+			inputBody: "token=t",
+			basicAuth: []string{"gateway", "wrong"},
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Introspect(gomock.Any(), gomock.Any()).Return(auth.TokenInfo{}, svcErrs.ErrInvalidClientCredentials)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"error":"invalid_client","error_description":"` + svcErrs.ErrInvalidClientCredentials.Error() + `"}`,
+			wantAuthenticate: `Basic realm="oauth"`,
+		},
+		{
+			name:      "internal error",
+			inputBody: "token=t",
+			basicAuth: []string{"gateway", "secret"},
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Introspect(gomock.Any(), gomock.Any()).Return(auth.TokenInfo{}, svcErrs.ErrCannotGetClient)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"error":"server_error"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			os := servicemocks.NewMockOAuth(ctrl)
+			tc.mockBehavior(os)
+
+			e := gin.New()
+			NewOAuthRoutes(e.Group("/oauth"), os)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/oauth/introspect", strings.NewReader(tc.inputBody))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.basicAuth != nil {
+				req.SetBasicAuth(tc.basicAuth[0], tc.basicAuth[1])
+			}
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			assert.Equal(t, tc.wantAuthenticate, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestOAuthRoutes_Revoke(t *testing.T) {
+	type MockBehavior func(m *servicemocks.MockOAuth)
+
+	testCases := []struct {
+		name             string
+		inputBody        string
+		basicAuth        []string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: "token=t&token_type_hint=refresh_token&client_id=example-spa",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Revoke(gomock.Any(), oauth.RevocationRequest{
+					ClientId:      "example-spa",
+					Token:         "t",
+					TokenTypeHint: "refresh_token",
+				}).Return(nil)
+			},
+			wantStatusCode: 200,
+		},
+		{
+			name:      "client secret with HTTP Basic",
+			inputBody: "token=t",
+			basicAuth: []string{"gateway", "secret"},
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Revoke(gomock.Any(), oauth.RevocationRequest{
+					ClientId:     "gateway",
+					ClientSecret: "secret",
+					Token:        "t",
+				}).Return(nil)
+			},
+			wantStatusCode: 200,
+		},
+		{
+			name:             "no token",
+			inputBody:        "client_id=example-spa",
+			mockBehavior:     func(m *servicemocks.MockOAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"error":"invalid_request","error_description":"Key: 'revocationRequest.Token' Error:Field validation for 'Token' failed on the 'required' tag"}`,
+		},
+		{
+			name:      "token of another client",
+			inputBody: "token=t&client_id=example-spa",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Revoke(gomock.Any(), gomock.Any()).Return(svcErrs.ErrTokenNotIssuedToClient)
+			},
+			wantStatusCode:   400,
+			wantResponseBody: `{"error":"unauthorized_client","error_description":"the token was not issued to the client"}`,
+		},
+		{
+			name:      "internal error",
+			inputBody: "token=t&client_id=example-spa",
+			mockBehavior: func(m *servicemocks.MockOAuth) {
+				m.EXPECT().Revoke(gomock.Any(), gomock.Any()).Return(svcErrs.ErrAccessToCache)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"error":"server_error"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			os := servicemocks.NewMockOAuth(ctrl)
+			tc.mockBehavior(os)
+
+			e := gin.New()
+			NewOAuthRoutes(e.Group("/oauth"), os)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/oauth/revoke", strings.NewReader(tc.inputBody))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.basicAuth != nil {
+				req.SetBasicAuth(tc.basicAuth[0], tc.basicAuth[1])
+			}
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
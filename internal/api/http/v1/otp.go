@@ -0,0 +1,222 @@
+package v1
+
+import (
+	"errors"
+	"github.com/bubalync/uni-auth/internal/api/http/middleware"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/otp"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type sendCodeRequest struct {
+	Email string `json:"email" validate:"required,email,min=5,max=150" minLength:"5" maxLength:"150" example:"email@example.com"`
+	// Purpose of the code, sign_in or verify_email
+	Purpose otp.Purpose `json:"purpose" validate:"required,oneof=sign_in verify_email" enums:"sign_in,verify_email" example:"sign_in"`
+}
+
+// @Summary     Send one-time code
+// @Description Emails a numeric one-time code for the passwordless sign-in or the email verification.
+// @Description The response is the same whether there is such an account or not.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body sendCodeRequest true "One-time code payload"
+// @Success     202 {string} string
+// @Failure     400 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/otp/send [post]
+func (r *authRoutes) sendCode(c *gin.Context) {
+	var req sendCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	var err error
+	if req.Purpose == otp.PurposeVerifyEmail {
+		err = r.as.SendVerificationCode(c.Request.Context(), req.Email)
+	} else {
+		err = r.as.SendSignInCode(c.Request.Context(), req.Email)
+	}
+	if err != nil {
+		codeError(c, err)
+		return
+	}
+
+	c.String(http.StatusAccepted, "one-time code sent if the account exists")
+}
+
+type codeSignInRequest struct {
+	Email string `json:"email" validate:"required,email,min=5,max=150" minLength:"5" maxLength:"150" example:"email@example.com"`
+	Code  string `json:"code"  validate:"required,numeric,max=10"     maxLength:"10" example:"123456"`
+	// Device label shown in the list of sessions
+	Device string `json:"device" validate:"max=100" maxLength:"100" example:"iPhone 15"`
+	// Nonce is echoed in the ID token
+	Nonce string `json:"nonce" validate:"max=255" maxLength:"255" example:"n-0S6_WzA2Mj"`
+}
+
+// @Summary     Sign in with a one-time code
+// @Description Exchanges the code sent for the sign-in for tokens. Users with two-factor authentication get mfa_token instead of the tokens.
+// @Description Too many wrong codes lock the sign-in with codes for a while.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body codeSignInRequest true "Sign in payload"
+// @Success     200 {object} signInResponse
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     429 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/otp/sign-in [post]
+func (r *authRoutes) signInWithCode(c *gin.Context) {
+	var req codeSignInRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	tokens, err := r.as.SignInWithCode(c.Request.Context(), auth.CodeSignInInput{
+		Email:     req.Email,
+		Code:      req.Code,
+		Device:    req.Device,
+		Nonce:     req.Nonce,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		codeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSignInResponse(tokens))
+}
+
+type verifyEmailCodeRequest struct {
+	Email string `json:"email" validate:"required,email,min=5,max=150" minLength:"5" maxLength:"150" example:"email@example.com"`
+	Code  string `json:"code"  validate:"required,numeric,max=10"     maxLength:"10" example:"123456"`
+}
+
+// @Summary     Verify email with a one-time code
+// @Description Confirms the email with the code sent for the email verification
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body verifyEmailCodeRequest true "Verify email payload"
+// @Success     200 {string} string
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     429 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/otp/verify-email [post]
+func (r *authRoutes) verifyEmailCode(c *gin.Context) {
+	var req verifyEmailCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	if err := r.as.VerifyEmailCode(c.Request.Context(), req.Email, req.Code); err != nil {
+		codeError(c, err)
+		return
+	}
+
+	c.String(http.StatusOK, "email verified successfully")
+}
+
+// @Summary     Send step-up code
+// @Description Emails a one-time code that confirms a sensitive action of the current user
+// @Tags        users
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Success     202 {string} string
+// @Failure     401 {object} response.ErrResponse
+// @Failure     429 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/step-up [post]
+func (r *userRoutes) sendStepUpCode(c *gin.Context) {
+	if err := r.as.SendStepUpCode(c.Request.Context(), userIdFromContext(c)); err != nil {
+		codeError(c, err)
+		return
+	}
+
+	c.String(http.StatusAccepted, "one-time code sent")
+}
+
+type stepUpRequest struct {
+	Code string `json:"code" validate:"required,numeric,max=10" maxLength:"10" example:"123456"`
+}
+
+// @Summary     Step up
+// @Description Confirms the step-up code and issues new tokens for the current session.
+// @Description The auth_time of the new ID token is the time of the confirmation.
+// @Tags        users
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       request body stepUpRequest true "Step-up payload"
+// @Success     200 {object} signInResponse
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     429 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/step-up/verify [post]
+func (r *userRoutes) stepUp(c *gin.Context) {
+	var req stepUpRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	claims := c.MustGet(middleware.ClaimsKey).(*jwtgen.Claims)
+
+	tokens, err := r.as.StepUp(c.Request.Context(), claims, req.Code)
+	if err != nil {
+		codeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSignInResponse(tokens))
+}
+
+func codeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, svcErrs.ErrInvalidOTP), errors.Is(err, svcErrs.ErrSessionNotFound),
+		errors.Is(err, svcErrs.ErrUserNotFound):
+		c.JSON(http.StatusUnauthorized, response.Error(err.Error()))
+	case errors.Is(err, svcErrs.ErrOTPLocked), errors.Is(err, svcErrs.ErrOTPThrottled):
+		c.JSON(http.StatusTooManyRequests, response.Error(err.Error()))
+	case errors.Is(err, svcErrs.ErrBreachedPassword):
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+	}
+}
@@ -0,0 +1,246 @@
+package v1
+
+import (
+	"bytes"
+	"github.com/bubalync/uni-auth/internal/api/http/middleware"
+	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthRoutes_OTP(t *testing.T) {
+	type MockBehavior func(m *servicemocks.MockAuth)
+
+	testCases := []struct {
+		name             string
+		path             string
+		inputBody        string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:      "send: sign-in code",
+			path:      "/auth/otp/send",
+			inputBody: `{"email":"test@example.com","purpose":"sign_in"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().SendSignInCode(gomock.Any(), "test@example.com").Return(nil)
+			},
+			wantStatusCode:   202,
+			wantResponseBody: "one-time code sent if the account exists",
+		},
+		{
+			name:      "send: verification code",
+			path:      "/auth/otp/send",
+			inputBody: `{"email":"test@example.com","purpose":"verify_email"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().SendVerificationCode(gomock.Any(), "test@example.com").Return(nil)
+			},
+			wantStatusCode:   202,
+			wantResponseBody: "one-time code sent if the account exists",
+		},
+		{
+			name:             "send: unknown purpose",
+			path:             "/auth/otp/send",
+			inputBody:        `{"email":"test@example.com","purpose":"step_up"}`,
+			mockBehavior:     func(m *servicemocks.MockAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Purpose":"Is not valid"}}`,
+		},
+		{
+			name:      "send: internal server error",
+			path:      "/auth/otp/send",
+			inputBody: `{"email":"test@example.com","purpose":"sign_in"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().SendSignInCode(gomock.Any(), "test@example.com").Return(svcErrs.ErrSendOTP)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
+		{
+			name:      "sign in: OK",
+			path:      "/auth/otp/sign-in",
+			inputBody: `{"email":"test@example.com","code":"123456","device":"Phone"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().SignInWithCode(gomock.Any(), auth.CodeSignInInput{
+					Email:     "test@example.com",
+					Code:      "123456",
+					Device:    "Phone",
+					IP:        "192.0.2.1",
+					UserAgent: "test",
+				}).Return(auth.GenerateTokenOutput{AccessToken: "1", RefreshToken: "2", IdToken: "3"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","refresh_token":"2","id_token":"3"}`,
+		},
+		{
+			name:             "sign in: code is not numeric",
+			path:             "/auth/otp/sign-in",
+			inputBody:        `{"email":"test@example.com","code":"abcdef"}`,
+			mockBehavior:     func(m *servicemocks.MockAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Code":"Is not valid"}}`,
+		},
+		{
+			name:      "sign in: invalid code",
+			path:      "/auth/otp/sign-in",
+			inputBody: `{"email":"test@example.com","code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().SignInWithCode(gomock.Any(), gomock.Any()).Return(auth.GenerateTokenOutput{}, svcErrs.ErrInvalidOTP)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"invalid one-time code"}}`,
+		},
+		{
+			name:      "sign in: locked out",
+			path:      "/auth/otp/sign-in",
+			inputBody: `{"email":"test@example.com","code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().SignInWithCode(gomock.Any(), gomock.Any()).Return(auth.GenerateTokenOutput{}, svcErrs.ErrOTPLocked)
+			},
+			wantStatusCode:   429,
+			wantResponseBody: `{"errors":{"message":"too many wrong one-time codes, try again later"}}`,
+		},
+		{
+			name:      "verify email: OK",
+			path:      "/auth/otp/verify-email",
+			inputBody: `{"email":"test@example.com","code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyEmailCode(gomock.Any(), "test@example.com", "123456").Return(nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: "email verified successfully",
+		},
+		{
+			name:      "verify email: invalid code",
+			path:      "/auth/otp/verify-email",
+			inputBody: `{"email":"test@example.com","code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyEmailCode(gomock.Any(), "test@example.com", "123456").Return(svcErrs.ErrInvalidOTP)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"invalid one-time code"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			as := servicemocks.NewMockAuth(ctrl)
+			tc.mockBehavior(as)
+
+			gin.SetMode(gin.TestMode)
+			e := gin.New()
+			NewAuthRoutes(e.Group("/auth"), validator.NewCustomValidator(), as)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.inputBody))
+			req.Header.Set("User-Agent", "test")
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestUserRoutes_StepUp(t *testing.T) {
+	claims := &jwtgen.Claims{UserId: uuid.MustParse("00000000-0000-0000-0000-000000000001"), SessionId: uuid.New()}
+
+	type MockBehavior func(m *servicemocks.MockAuth)
+
+	testCases := []struct {
+		name             string
+		path             string
+		inputBody        string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name: "send: OK",
+			path: "/users/step-up",
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().SendStepUpCode(gomock.Any(), claims.UserId).Return(nil)
+			},
+			wantStatusCode:   202,
+			wantResponseBody: "one-time code sent",
+		},
+		{
+			name: "send: sent recently",
+			path: "/users/step-up",
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().SendStepUpCode(gomock.Any(), claims.UserId).Return(svcErrs.ErrOTPThrottled)
+			},
+			wantStatusCode:   429,
+			wantResponseBody: `{"errors":{"message":"one-time code was sent recently, try again later"}}`,
+		},
+		{
+			name:      "verify: OK",
+			path:      "/users/step-up/verify",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().StepUp(gomock.Any(), claims, "123456").
+					Return(auth.GenerateTokenOutput{AccessToken: "1", RefreshToken: "2", IdToken: "3"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","refresh_token":"2","id_token":"3"}`,
+		},
+		{
+			name:             "verify: code not provided",
+			path:             "/users/step-up/verify",
+			inputBody:        `{}`,
+			mockBehavior:     func(m *servicemocks.MockAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Code":"Is a required"}}`,
+		},
+		{
+			name:      "verify: session ended",
+			path:      "/users/step-up/verify",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().StepUp(gomock.Any(), claims, "123456").Return(auth.GenerateTokenOutput{}, svcErrs.ErrSessionNotFound)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"session not found"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			as := servicemocks.NewMockAuth(ctrl)
+			tc.mockBehavior(as)
+
+			e := gin.New()
+			g := e.Group("/users", func(c *gin.Context) {
+				c.Set(middleware.UserIdKey, claims.UserId)
+				c.Set(middleware.ClaimsKey, claims)
+			})
+			NewUserRoutes(g, logger.New("local", "info"), validator.NewCustomValidator(), nil, as)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.inputBody))
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package v1
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/passkey"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+type passkeyRoutes struct {
+	ps service.Passkey
+	as service.Auth
+	cv *validator.CustomValidator
+}
+
+// NewPasskeyRoutes registers the passkey management of the current user.
+func NewPasskeyRoutes(g *gin.RouterGroup, cv *validator.CustomValidator, ps service.Passkey) {
+	r := &passkeyRoutes{ps: ps, cv: cv}
+
+	g.GET("/", r.passkeys)
+	g.POST("/register/begin", r.beginRegistration)
+	g.POST("/register/finish", r.finishRegistration)
+	g.DELETE("/:credential_id", r.delete)
+}
+
+// NewPasskeyAuthRoutes registers the passwordless sign-in and the passkey answer to the second factor challenge.
+func NewPasskeyAuthRoutes(g *gin.RouterGroup, cv *validator.CustomValidator, as service.Auth, ps service.Passkey) {
+	r := &passkeyRoutes{ps: ps, as: as, cv: cv}
+
+	g.POST("/passkey/login/begin", r.beginLogin)
+	g.POST("/passkey/login/finish", r.finishLogin)
+	g.POST("/mfa/passkey/begin", r.beginMFA)
+}
+
+type passkeyCeremonyResponse struct {
+	// SessionId is sent back with the response of the authenticator
+	SessionId string `json:"session_id" example:"2kQ1n0yWZsV3qkXb9lQm7hTq0aUeJx3cR8d1oPz6N4A"`
+	// Options for navigator.credentials.create or navigator.credentials.get, binary values are base64url encoded
+	Options json.RawMessage `json:"options" swaggertype:"object"`
+}
+
+func newPasskeyCeremonyResponse(c passkey.Ceremony) passkeyCeremonyResponse {
+	return passkeyCeremonyResponse{SessionId: c.SessionId, Options: c.Options}
+}
+
+type passkeyResponse struct {
+	// Credential id, base64url encoded
+	Id         string   `json:"id"         example:"AQIDBAUGBwgJCgsMDQ4PEA"`
+	Name       string   `json:"name"       example:"MacBook"`
+	Transports []string `json:"transports" example:"internal"`
+	// AAGUID identifies the authenticator model, all zeros when the model is not attested
+	AAGUID         string     `json:"aaguid"          example:"00000000-0000-0000-0000-000000000000"`
+	BackupEligible bool       `json:"backup_eligible"`
+	BackupState    bool       `json:"backup_state"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func newPasskeyResponse(c entity.WebAuthnCredential) passkeyResponse {
+	var aaguid string
+	if id, err := uuid.FromBytes(c.AAGUID); err == nil {
+		aaguid = id.String()
+	}
+
+	return passkeyResponse{
+		Id:             base64.RawURLEncoding.EncodeToString(c.Id),
+		Name:           c.Name,
+		Transports:     c.Transports,
+		AAGUID:         aaguid,
+		BackupEligible: c.BackupEligible,
+		BackupState:    c.BackupState,
+		LastUsedAt:     c.LastUsedAt,
+		CreatedAt:      c.CreatedAt,
+	}
+}
+
+// @Summary     Passkeys
+// @Description List the passkeys and security keys of the current user
+// @Tags        passkeys
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200 {array} passkeyResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/passkeys [get]
+func (r *passkeyRoutes) passkeys(c *gin.Context) {
+	credentials, err := r.ps.Credentials(c.Request.Context(), userIdFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	res := make([]passkeyResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		res = append(res, newPasskeyResponse(credential))
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary     Begin passkey registration
+// @Description Returns the options for navigator.credentials.create, the registered passkeys are excluded.
+// @Tags        passkeys
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200 {object} passkeyCeremonyResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/passkeys/register/begin [post]
+func (r *passkeyRoutes) beginRegistration(c *gin.Context) {
+	ceremony, err := r.ps.BeginRegistration(c.Request.Context(), userIdFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPasskeyCeremonyResponse(ceremony))
+}
+
+type finishPasskeyRegistrationRequest struct {
+	SessionId string `json:"session_id" validate:"required,max=64" maxLength:"64"`
+	// Name helps to tell the passkeys apart
+	Name string `json:"name" validate:"max=100" maxLength:"100" example:"MacBook"`
+	// PublicKeyCredential returned by navigator.credentials.create, binary values base64url encoded
+	Credential json.RawMessage `json:"credential" validate:"required" swaggertype:"object"`
+}
+
+// @Summary     Finish passkey registration
+// @Description Verifies the response of the authenticator and saves the passkey.
+// @Tags        passkeys
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       request body finishPasskeyRegistrationRequest true "Authenticator response"
+// @Success     201 {object} passkeyResponse
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     409 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/passkeys/register/finish [post]
+func (r *passkeyRoutes) finishRegistration(c *gin.Context) {
+	var req finishPasskeyRegistrationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	credential, err := r.ps.FinishRegistration(c.Request.Context(), userIdFromContext(c), passkey.FinishRegistrationInput{
+		SessionId: req.SessionId,
+		Name:      req.Name,
+		Response:  req.Credential,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, svcErrs.ErrInvalidPasskey), errors.Is(err, svcErrs.ErrInvalidPasskeyCeremony):
+			c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		case errors.Is(err, svcErrs.ErrPasskeyAlreadyRegistered):
+			c.JSON(http.StatusConflict, response.Error(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, newPasskeyResponse(credential))
+}
+
+// @Summary     Delete passkey
+// @Description The passkey can no longer be used to sign in
+// @Tags        passkeys
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       credential_id path string true "Credential id, base64url encoded"
+// @Success     204
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/passkeys/{credential_id} [delete]
+func (r *passkeyRoutes) delete(c *gin.Context) {
+	id, err := base64.RawURLEncoding.DecodeString(c.Param("credential_id"))
+	if err != nil || len(id) == 0 {
+		c.JSON(http.StatusBadRequest, response.Error("credential_id is not valid base64url"))
+		return
+	}
+
+	err = r.ps.Delete(c.Request.Context(), userIdFromContext(c), id)
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrPasskeyNotFound) {
+			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Begin passkey sign in
+// @Description Returns the options for navigator.credentials.get, the authenticator offers its passkeys
+// @Description for this site and verifies the user.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} passkeyCeremonyResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/passkey/login/begin [post]
+func (r *passkeyRoutes) beginLogin(c *gin.Context) {
+	ceremony, err := r.ps.BeginLogin(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPasskeyCeremonyResponse(ceremony))
+}
+
+type finishPasskeyLoginRequest struct {
+	SessionId string `json:"session_id" validate:"required,max=64" maxLength:"64"`
+	// PublicKeyCredential returned by navigator.credentials.get, binary values base64url encoded
+	Credential json.RawMessage `json:"credential" validate:"required" swaggertype:"object"`
+	// Device label shown in the list of sessions
+	Device string `json:"device" validate:"max=100" maxLength:"100" example:"iPhone 15"`
+	// Nonce is echoed in the ID token
+	Nonce string `json:"nonce" validate:"max=255" maxLength:"255" example:"n-0S6_WzA2Mj"`
+}
+
+// @Summary     Finish passkey sign in
+// @Description Verifies the response of the authenticator and signs its owner in, no password
+// @Description or second factor is asked for.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body finishPasskeyLoginRequest true "Authenticator response"
+// @Success     200 {object} signInResponse
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/passkey/login/finish [post]
+func (r *passkeyRoutes) finishLogin(c *gin.Context) {
+	var req finishPasskeyLoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	tokens, err := r.as.SignInWithPasskey(c.Request.Context(), auth.PasskeySignInInput{
+		SessionId: req.SessionId,
+		Response:  req.Credential,
+		Device:    req.Device,
+		Nonce:     req.Nonce,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrInvalidPasskey) || errors.Is(err, svcErrs.ErrInvalidPasskeyCeremony) {
+			c.JSON(http.StatusUnauthorized, response.Error(err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.JSON(http.StatusOK, newSignInResponse(tokens))
+}
+
+type beginMFAPasskeyRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+}
+
+// @Summary     Begin passkey second factor
+// @Description Returns the options for navigator.credentials.get with the passkeys of the user who signed in.
+// @Description The response of the authenticator is exchanged for tokens at /auth/mfa/verify.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body beginMFAPasskeyRequest true "MFA token"
+// @Success     200 {object} passkeyCeremonyResponse
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/mfa/passkey/begin [post]
+func (r *passkeyRoutes) beginMFA(c *gin.Context) {
+	var req beginMFAPasskeyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	ceremony, err := r.as.BeginMFAPasskey(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, svcErrs.ErrInvalidMFAToken):
+			c.JSON(http.StatusUnauthorized, response.Error(err.Error()))
+		case errors.Is(err, svcErrs.ErrPasskeyNotFound):
+			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newPasskeyCeremonyResponse(ceremony))
+}
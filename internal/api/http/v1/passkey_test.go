@@ -0,0 +1,295 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/bubalync/uni-auth/internal/api/http/middleware"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/passkey"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPasskeyRoutes(t *testing.T) {
+	userId := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	credential := entity.WebAuthnCredential{
+		Id:         []byte{1, 2, 3, 4},
+		UserId:     userId,
+		Name:       "laptop",
+		Transports: []string{"internal"},
+		AAGUID:     make([]byte, 16),
+		CreatedAt:  createdAt,
+	}
+	credentialJSON := `{"id":"AQIDBA","name":"laptop","transports":["internal"],` +
+		`"aaguid":"00000000-0000-0000-0000-000000000000","backup_eligible":false,"backup_state":false,` +
+		`"last_used_at":null,"created_at":"2025-01-02T03:04:05Z"}`
+
+	type MockBehavior func(m *servicemocks.MockPasskey)
+
+	testCases := []struct {
+		name             string
+		method           string
+		path             string
+		inputBody        string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:   "list: OK",
+			method: http.MethodGet,
+			path:   "/",
+			mockBehavior: func(m *servicemocks.MockPasskey) {
+				m.EXPECT().Credentials(gomock.Any(), userId).Return([]entity.WebAuthnCredential{credential}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `[` + credentialJSON + `]`,
+		},
+		{
+			name:   "begin registration: OK",
+			method: http.MethodPost,
+			path:   "/register/begin",
+			mockBehavior: func(m *servicemocks.MockPasskey) {
+				m.EXPECT().BeginRegistration(gomock.Any(), userId).Return(passkey.Ceremony{
+					SessionId: "ceremony",
+					Options:   json.RawMessage(`{"publicKey":{"challenge":"abc"}}`),
+				}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"session_id":"ceremony","options":{"publicKey":{"challenge":"abc"}}}`,
+		},
+		{
+			name:      "finish registration: OK",
+			method:    http.MethodPost,
+			path:      "/register/finish",
+			inputBody: `{"session_id":"ceremony","name":"laptop","credential":{"id":"AQIDBA"}}`,
+			mockBehavior: func(m *servicemocks.MockPasskey) {
+				m.EXPECT().FinishRegistration(gomock.Any(), userId, passkey.FinishRegistrationInput{
+					SessionId: "ceremony",
+					Name:      "laptop",
+					Response:  []byte(`{"id":"AQIDBA"}`),
+				}).Return(credential, nil)
+			},
+			wantStatusCode:   201,
+			wantResponseBody: credentialJSON,
+		},
+		{
+			name:             "finish registration: credential not provided",
+			method:           http.MethodPost,
+			path:             "/register/finish",
+			inputBody:        `{"session_id":"ceremony"}`,
+			mockBehavior:     func(m *servicemocks.MockPasskey) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Credential":"Is a required"}}`,
+		},
+		{
+			name:      "finish registration: rejected attestation",
+			method:    http.MethodPost,
+			path:      "/register/finish",
+			inputBody: `{"session_id":"ceremony","credential":{"id":"AQIDBA"}}`,
+			mockBehavior: func(m *servicemocks.MockPasskey) {
+				m.EXPECT().FinishRegistration(gomock.Any(), userId, gomock.Any()).
+					Return(entity.WebAuthnCredential{}, svcErrs.ErrInvalidPasskey)
+			},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"message":"passkey verification failed"}}`,
+		},
+		{
+			name:      "finish registration: already registered",
+			method:    http.MethodPost,
+			path:      "/register/finish",
+			inputBody: `{"session_id":"ceremony","credential":{"id":"AQIDBA"}}`,
+			mockBehavior: func(m *servicemocks.MockPasskey) {
+				m.EXPECT().FinishRegistration(gomock.Any(), userId, gomock.Any()).
+					Return(entity.WebAuthnCredential{}, svcErrs.ErrPasskeyAlreadyRegistered)
+			},
+			wantStatusCode:   409,
+			wantResponseBody: `{"errors":{"message":"passkey is already registered"}}`,
+		},
+		{
+			name:   "delete: OK",
+			method: http.MethodDelete,
+			path:   "/AQIDBA",
+			mockBehavior: func(m *servicemocks.MockPasskey) {
+				m.EXPECT().Delete(gomock.Any(), userId, []byte{1, 2, 3, 4}).Return(nil)
+			},
+			wantStatusCode: 204,
+		},
+		{
+			name:   "delete: not found",
+			method: http.MethodDelete,
+			path:   "/AQIDBA",
+			mockBehavior: func(m *servicemocks.MockPasskey) {
+				m.EXPECT().Delete(gomock.Any(), userId, []byte{1, 2, 3, 4}).Return(svcErrs.ErrPasskeyNotFound)
+			},
+			wantStatusCode:   404,
+			wantResponseBody: `{"errors":{"message":"passkey not found"}}`,
+		},
+		{
+			name:             "delete: invalid id",
+			method:           http.MethodDelete,
+			path:             "/not+base64",
+			mockBehavior:     func(m *servicemocks.MockPasskey) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"message":"credential_id is not valid base64url"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ps := servicemocks.NewMockPasskey(ctrl)
+			tc.mockBehavior(ps)
+
+			e := gin.New()
+			g := e.Group("/passkeys", func(c *gin.Context) {
+				c.Set(middleware.UserIdKey, userId)
+			})
+			NewPasskeyRoutes(g, validator.NewCustomValidator(), ps)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, "/passkeys"+tc.path, bytes.NewBufferString(tc.inputBody))
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestPasskeyAuthRoutes(t *testing.T) {
+	ceremony := passkey.Ceremony{SessionId: "ceremony", Options: json.RawMessage(`{"publicKey":{"challenge":"abc"}}`)}
+	ceremonyJSON := `{"session_id":"ceremony","options":{"publicKey":{"challenge":"abc"}}}`
+
+	type MockBehavior func(as *servicemocks.MockAuth, ps *servicemocks.MockPasskey)
+
+	testCases := []struct {
+		name             string
+		path             string
+		inputBody        string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name: "begin login: OK",
+			path: "/passkey/login/begin",
+			mockBehavior: func(as *servicemocks.MockAuth, ps *servicemocks.MockPasskey) {
+				ps.EXPECT().BeginLogin(gomock.Any()).Return(ceremony, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: ceremonyJSON,
+		},
+		{
+			name:      "finish login: OK",
+			path:      "/passkey/login/finish",
+			inputBody: `{"session_id":"ceremony","credential":{"id":"AQIDBA"},"device":"laptop"}`,
+			mockBehavior: func(as *servicemocks.MockAuth, ps *servicemocks.MockPasskey) {
+				as.EXPECT().SignInWithPasskey(gomock.Any(), auth.PasskeySignInInput{
+					SessionId: "ceremony",
+					Response:  []byte(`{"id":"AQIDBA"}`),
+					Device:    "laptop",
+					IP:        "192.0.2.1",
+				}).Return(auth.GenerateTokenOutput{AccessToken: "1", RefreshToken: "2", IdToken: "3"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","refresh_token":"2","id_token":"3"}`,
+		},
+		{
+			name:      "finish login: rejected assertion",
+			path:      "/passkey/login/finish",
+			inputBody: `{"session_id":"ceremony","credential":{"id":"AQIDBA"}}`,
+			mockBehavior: func(as *servicemocks.MockAuth, ps *servicemocks.MockPasskey) {
+				as.EXPECT().SignInWithPasskey(gomock.Any(), gomock.Any()).
+					Return(auth.GenerateTokenOutput{}, svcErrs.ErrInvalidPasskey)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"passkey verification failed"}}`,
+		},
+		{
+			name:      "finish login: expired ceremony",
+			path:      "/passkey/login/finish",
+			inputBody: `{"session_id":"ceremony","credential":{"id":"AQIDBA"}}`,
+			mockBehavior: func(as *servicemocks.MockAuth, ps *servicemocks.MockPasskey) {
+				as.EXPECT().SignInWithPasskey(gomock.Any(), gomock.Any()).
+					Return(auth.GenerateTokenOutput{}, svcErrs.ErrInvalidPasskeyCeremony)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"passkey ceremony is invalid or expired"}}`,
+		},
+		{
+			name:             "finish login: session id not provided",
+			path:             "/passkey/login/finish",
+			inputBody:        `{"credential":{"id":"AQIDBA"}}`,
+			mockBehavior:     func(as *servicemocks.MockAuth, ps *servicemocks.MockPasskey) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"SessionId":"Is a required"}}`,
+		},
+		{
+			name:      "begin mfa: OK",
+			path:      "/mfa/passkey/begin",
+			inputBody: `{"mfa_token":"mfa"}`,
+			mockBehavior: func(as *servicemocks.MockAuth, ps *servicemocks.MockPasskey) {
+				as.EXPECT().BeginMFAPasskey(gomock.Any(), "mfa").Return(ceremony, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: ceremonyJSON,
+		},
+		{
+			name:      "begin mfa: expired mfa token",
+			path:      "/mfa/passkey/begin",
+			inputBody: `{"mfa_token":"mfa"}`,
+			mockBehavior: func(as *servicemocks.MockAuth, ps *servicemocks.MockPasskey) {
+				as.EXPECT().BeginMFAPasskey(gomock.Any(), "mfa").Return(passkey.Ceremony{}, svcErrs.ErrInvalidMFAToken)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"mfa token is invalid or expired"}}`,
+		},
+		{
+			name:      "begin mfa: no passkeys",
+			path:      "/mfa/passkey/begin",
+			inputBody: `{"mfa_token":"mfa"}`,
+			mockBehavior: func(as *servicemocks.MockAuth, ps *servicemocks.MockPasskey) {
+				as.EXPECT().BeginMFAPasskey(gomock.Any(), "mfa").Return(passkey.Ceremony{}, svcErrs.ErrPasskeyNotFound)
+			},
+			wantStatusCode:   404,
+			wantResponseBody: `{"errors":{"message":"passkey not found"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			as := servicemocks.NewMockAuth(ctrl)
+			ps := servicemocks.NewMockPasskey(ctrl)
+			tc.mockBehavior(as, ps)
+
+			e := gin.New()
+			NewPasskeyAuthRoutes(e.Group("/auth"), validator.NewCustomValidator(), as, ps)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/auth"+tc.path, bytes.NewBufferString(tc.inputBody))
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package v1
+
+import (
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type passwordPolicyResponse struct {
+	MinLength int `json:"min_length" example:"8"`
+	MaxLength int `json:"max_length" example:"64"`
+	MinLower  int `json:"min_lowercase" example:"1"`
+	MinUpper  int `json:"min_uppercase" example:"1"`
+	MinDigit  int `json:"min_digits" example:"1"`
+	MinSymbol int `json:"min_symbols" example:"1"`
+	// Symbols are the special characters counted by min_symbols
+	Symbols string `json:"symbols" example:"!@#$%^&*"`
+	// MinScore is the least strength score, from 0 (too guessable) to 4 (very unguessable)
+	MinScore int `json:"min_score" example:"2"`
+}
+
+// @Summary     Password policy
+// @Description The rules new passwords must satisfy, for the UI to show them before the password is sent
+// @Tags        auth
+// @Produce     json
+// @Success     200 {object} passwordPolicyResponse
+// @Router      /auth/password-policy [get]
+func (r *authRoutes) passwordPolicy(c *gin.Context) {
+	p := r.cv.PasswordPolicy()
+
+	c.JSON(http.StatusOK, passwordPolicyResponse{
+		MinLength: p.MinLength,
+		MaxLength: p.MaxLength,
+		MinLower:  p.MinLower,
+		MinUpper:  p.MinUpper,
+		MinDigit:  p.MinDigit,
+		MinSymbol: p.MinSymbol,
+		Symbols:   p.Symbols,
+		MinScore:  p.MinScore,
+	})
+}
+
+type passwordCheckRequest struct {
+	Password string `json:"password" validate:"required,max=128" maxLength:"128" example:"YourV@lidPassw0rd!"`
+	// Email of the account, the password must not be based on it
+	Email string `json:"email" validate:"omitempty,email,max=150" maxLength:"150" example:"email@example.com"`
+}
+
+type passwordRuleResponse struct {
+	// Rule is min_length, max_length, lowercase, uppercase, digits, symbols, strength or not_breached
+	Rule    string `json:"rule"    example:"min_length"`
+	Passed  bool   `json:"passed"  example:"true"`
+	Message string `json:"message" example:"At least 8 characters"`
+}
+
+type passwordCheckResponse struct {
+	// Valid is true when every rule passed
+	Valid bool                   `json:"valid" example:"true"`
+	Rules []passwordRuleResponse `json:"rules"`
+	// Score is the strength from 0 (too guessable) to 4 (very unguessable)
+	Score int `json:"score" example:"3"`
+	// Warnings explain what makes the password easy to guess
+	Warnings []string `json:"warnings" example:"Common words and passwords are easy to guess"`
+}
+
+// @Summary     Check password
+// @Description Checks the password against every rule of the policy and estimates its strength, for the UI to give feedback while the user types.
+// @Description The password is neither stored nor logged, the not_breached rule is reported when breached passwords are screened.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body passwordCheckRequest true "Password check payload"
+// @Success     200 {object} passwordCheckResponse
+// @Failure     400 {object} response.ErrResponse
+// @Router      /auth/password-check [post]
+func (r *authRoutes) checkPassword(c *gin.Context) {
+	var req passwordCheckRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	var inputs []string
+	if req.Email != "" {
+		inputs = append(inputs, req.Email)
+	}
+
+	check := r.cv.CheckPassword(req.Password, inputs...)
+
+	res := passwordCheckResponse{
+		Valid:    check.Valid,
+		Rules:    make([]passwordRuleResponse, 0, len(check.Rules)),
+		Score:    check.Strength.Score,
+		Warnings: make([]string, 0, len(check.Strength.Warnings)),
+	}
+	for _, rule := range check.Rules {
+		res.Rules = append(res.Rules, passwordRuleResponse{Rule: rule.Rule, Passed: rule.Passed, Message: rule.Message})
+	}
+	res.Warnings = append(res.Warnings, check.Strength.Warnings...)
+
+	c.JSON(http.StatusOK, res)
+}
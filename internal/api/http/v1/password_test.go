@@ -0,0 +1,136 @@
+package v1
+
+import (
+	"bytes"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthRoutes_PasswordPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cv := validator.NewCustomValidator(validator.Policy(validator.PasswordPolicy{
+		MinLength: 10,
+		MaxLength: 64,
+		MinDigit:  2,
+		Symbols:   "!?",
+		MinScore:  3,
+	}))
+
+	e := gin.New()
+	NewAuthRoutes(e.Group("/auth"), cv, servicemocks.NewMockAuth(ctrl))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/auth/password-policy", nil)
+
+	e.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"min_length":10,"max_length":64,"min_lowercase":0,"min_uppercase":0,"min_digits":2,"min_symbols":0,"symbols":"!?","min_score":3}`, w.Body.String())
+}
+
+func TestAuthRoutes_CheckPassword(t *testing.T) {
+	policy := validator.DefaultPasswordPolicy()
+	policy.MinScore = validator.ScoreSomewhatGuessable
+
+	testCases := []struct {
+		name             string
+		inputBody        string
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:             "OK",
+			inputBody:        `{"password":"Hx7#kLp2$vQm"}`,
+			wantStatusCode:   200,
+			wantResponseBody: `{"valid":true,"rules":[{"rule":"min_length","passed":true,"message":"At least 8 characters"},{"rule":"max_length","passed":true,"message":"At most 64 characters"},{"rule":"lowercase","passed":true,"message":"At least 1 lowercase letters"},{"rule":"uppercase","passed":true,"message":"At least 1 uppercase letters"},{"rule":"digits","passed":true,"message":"At least 1 digits"},{"rule":"symbols","passed":true,"message":"At least 1 special characters (!@#$%^\u0026*)"},{"rule":"strength","passed":true,"message":"Hard to guess"}],"score":4,"warnings":[]}`,
+		},
+		{
+			name:             "common password",
+			inputBody:        `{"password":"password"}`,
+			wantStatusCode:   200,
+			wantResponseBody: `{"valid":false,"rules":[{"rule":"min_length","passed":true,"message":"At least 8 characters"},{"rule":"max_length","passed":true,"message":"At most 64 characters"},{"rule":"lowercase","passed":true,"message":"At least 1 lowercase letters"},{"rule":"uppercase","passed":false,"message":"At least 1 uppercase letters"},{"rule":"digits","passed":false,"message":"At least 1 digits"},{"rule":"symbols","passed":false,"message":"At least 1 special characters (!@#$%^\u0026*)"},{"rule":"strength","passed":false,"message":"Hard to guess"}],"score":0,"warnings":["Common words and passwords are easy to guess"]}`,
+		},
+		{
+			name:             "based on the email",
+			inputBody:        `{"password":"Johnsmith!1","email":"johnsmith@example.com"}`,
+			wantStatusCode:   200,
+			wantResponseBody: `{"valid":false,"rules":[{"rule":"min_length","passed":true,"message":"At least 8 characters"},{"rule":"max_length","passed":true,"message":"At most 64 characters"},{"rule":"lowercase","passed":true,"message":"At least 1 lowercase letters"},{"rule":"uppercase","passed":true,"message":"At least 1 uppercase letters"},{"rule":"digits","passed":true,"message":"At least 1 digits"},{"rule":"symbols","passed":true,"message":"At least 1 special characters (!@#$%^\u0026*)"},{"rule":"strength","passed":false,"message":"Hard to guess"}],"score":1,"warnings":["Do not use your email or name in the password"]}`,
+		},
+		{
+			name:             "no password",
+			inputBody:        `{"email":"johnsmith@example.com"}`,
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Password":"Is a required"}}`,
+		},
+		{
+			name:             "invalid email",
+			inputBody:        `{"password":"Hx7#kLp2$vQm","email":"johnsmith"}`,
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Email":"Invalid email format"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			e := gin.New()
+			NewAuthRoutes(e.Group("/auth"), validator.NewCustomValidator(validator.Policy(policy)), servicemocks.NewMockAuth(ctrl))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/auth/password-check", bytes.NewBufferString(tc.inputBody))
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
+
+type breachedPasswords map[string]bool
+
+func (b breachedPasswords) Breached(password string) bool {
+	return b[password]
+}
+
+func TestAuthRoutes_BreachedPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cv := validator.NewCustomValidator(validator.Breaches(breachedPasswords{"P@ssw0rd!": true}))
+
+	e := gin.New()
+	NewAuthRoutes(e.Group("/auth"), cv, servicemocks.NewMockAuth(ctrl))
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	// the sign-up is rejected by the validator, the service is not called
+	w := send("/auth/sign-up", `{"email":"test@example.com","password":"P@ssw0rd!"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"errors":{"Password":"Password has appeared in a data breach, choose another one"}}`, w.Body.String())
+
+	w = send("/auth/password-check", `{"password":"P@ssw0rd!"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"valid":false`)
+	assert.Contains(t, w.Body.String(), `{"rule":"not_breached","passed":false,"message":"Not found in known data breaches"}`)
+
+	w = send("/auth/password-check", `{"password":"Hx7#kLp2$vQm"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"valid":true`)
+	assert.Contains(t, w.Body.String(), `{"rule":"not_breached","passed":true,"message":"Not found in known data breaches"}`)
+}
@@ -0,0 +1,296 @@
+package v1
+
+import (
+	"errors"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type phoneRoutes struct {
+	ps service.Phone
+	cv *validator.CustomValidator
+}
+
+func NewPhoneRoutes(g *gin.RouterGroup, cv *validator.CustomValidator, ps service.Phone) {
+	r := &phoneRoutes{ps, cv}
+
+	g.PUT("/", r.setPhone)
+	g.POST("/verify", r.verifyPhone)
+	g.DELETE("/", r.removePhone)
+	g.PUT("/second-factor", r.setSecondFactor)
+}
+
+type setPhoneRequest struct {
+	// Phone in E.164 format
+	Phone string `json:"phone" validate:"required,e164" example:"+15550100"`
+}
+
+// @Summary     Set phone
+// @Description Replaces the phone of the current user and texts a code that verifies it.
+// @Description Setting the unverified phone again sends a new code. A new phone turns the SMS second factor off.
+// @Tags        phone
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       request body setPhoneRequest true "Phone payload"
+// @Success     202 {string} string
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     429 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/phone [put]
+func (r *phoneRoutes) setPhone(c *gin.Context) {
+	var req setPhoneRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	if err := r.ps.SetPhone(c.Request.Context(), userIdFromContext(c), req.Phone); err != nil {
+		phoneError(c, err)
+		return
+	}
+
+	c.String(http.StatusAccepted, "one-time code sent")
+}
+
+type verifyPhoneRequest struct {
+	Code string `json:"code" validate:"required,numeric,max=10" maxLength:"10" example:"123456"`
+}
+
+// @Summary     Verify phone
+// @Description Confirms the phone of the current user with the code texted to it
+// @Tags        phone
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       request body verifyPhoneRequest true "Verify phone payload"
+// @Success     200 {string} string
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     409 {object} response.ErrResponse
+// @Failure     429 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/phone/verify [post]
+func (r *phoneRoutes) verifyPhone(c *gin.Context) {
+	var req verifyPhoneRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	if err := r.ps.VerifyPhone(c.Request.Context(), userIdFromContext(c), req.Code); err != nil {
+		phoneError(c, err)
+		return
+	}
+
+	c.String(http.StatusOK, "phone verified successfully")
+}
+
+// @Summary     Remove phone
+// @Description Removes the phone of the current user and turns the SMS second factor off
+// @Tags        phone
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Success     204
+// @Failure     401 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/phone [delete]
+func (r *phoneRoutes) removePhone(c *gin.Context) {
+	if err := r.ps.RemovePhone(c.Request.Context(), userIdFromContext(c)); err != nil {
+		phoneError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type smsSecondFactorRequest struct {
+	Enabled bool `json:"enabled" example:"true"`
+}
+
+// @Summary     SMS second factor
+// @Description Turns the SMS second factor of the current user on or off, it needs a verified phone
+// @Tags        phone
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       request body smsSecondFactorRequest true "SMS second factor payload"
+// @Success     204
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/phone/second-factor [put]
+func (r *phoneRoutes) setSecondFactor(c *gin.Context) {
+	var req smsSecondFactorRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if err := r.ps.SetSecondFactor(c.Request.Context(), userIdFromContext(c), req.Enabled); err != nil {
+		phoneError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type smsResetPasswordRequest struct {
+	// Verified phone in E.164 format
+	Phone string `json:"phone" validate:"required,e164" example:"+15550100"`
+}
+
+// @Summary     Reset password by SMS
+// @Description Texts a code that resets a forgotten password to a verified phone.
+// @Description The response is the same whether there is such a phone or not.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body smsResetPasswordRequest true "Reset password payload"
+// @Success     202 {string} string
+// @Failure     400 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/reset-password/sms [post]
+func (r *authRoutes) resetPasswordBySMS(c *gin.Context) {
+	var req smsResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	if err := r.as.ResetPasswordBySMS(c.Request.Context(), req.Phone); err != nil {
+		codeError(c, err)
+		return
+	}
+
+	c.String(http.StatusAccepted, "one-time code sent if the phone is verified")
+}
+
+type smsRecoveryPasswordRequest struct {
+	Phone    string `json:"phone"    validate:"required,e164"          example:"+15550100"`
+	Code     string `json:"code"     validate:"required,numeric,max=10" maxLength:"10" example:"123456"`
+	Password string `json:"password" validate:"required,password"       minLength:"8" maxLength:"64" example:"YourV@lidPassw0rd!"`
+}
+
+// @Summary     Recovery password by SMS
+// @Description Sets a new password with the code texted by /auth/reset-password/sms
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body smsRecoveryPasswordRequest true "Recovery password payload"
+// @Success     200 {string} string
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     429 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/recovery-password/sms [post]
+func (r *authRoutes) recoveryPasswordBySMS(c *gin.Context) {
+	var req smsRecoveryPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	err := r.as.RecoveryPasswordBySMS(c.Request.Context(), auth.SMSRecoveryPasswordInput{
+		Phone:    req.Phone,
+		Code:     req.Code,
+		Password: req.Password,
+	})
+	if err != nil {
+		codeError(c, err)
+		return
+	}
+
+	c.String(http.StatusOK, "password updated successfully")
+}
+
+type sendMFACodeRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+}
+
+// @Summary     Send second factor SMS
+// @Description Texts a code that answers the mfa_token to the verified phone of a user with the SMS second factor.
+// @Description The code is sent to /auth/mfa/verify with the method sms.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body sendMFACodeRequest true "Second factor SMS payload"
+// @Success     202 {string} string
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     429 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /auth/mfa/sms [post]
+func (r *authRoutes) sendMFACode(c *gin.Context) {
+	var req sendMFACodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if errs := r.cv.ValidateStruct(req); errs != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorMap(errs))
+		return
+	}
+
+	if err := r.as.SendMFACode(c.Request.Context(), req.MFAToken); err != nil {
+		switch {
+		case errors.Is(err, svcErrs.ErrInvalidMFAToken):
+			c.JSON(http.StatusUnauthorized, response.Error(err.Error()))
+		case errors.Is(err, svcErrs.ErrPhoneNotFound):
+			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+		default:
+			codeError(c, err)
+		}
+		return
+	}
+
+	c.String(http.StatusAccepted, "one-time code sent")
+}
+
+func phoneError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, svcErrs.ErrPhoneNotFound):
+		c.JSON(http.StatusNotFound, response.Error(err.Error()))
+	case errors.Is(err, svcErrs.ErrPhoneAlreadyExists):
+		c.JSON(http.StatusConflict, response.Error(err.Error()))
+	default:
+		codeError(c, err)
+	}
+}
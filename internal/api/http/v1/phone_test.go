@@ -0,0 +1,282 @@
+package v1
+
+import (
+	"bytes"
+	"github.com/bubalync/uni-auth/internal/api/http/middleware"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPhoneRoutes(t *testing.T) {
+	userId := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+	type MockBehavior func(m *servicemocks.MockPhone)
+
+	testCases := []struct {
+		name             string
+		method           string
+		path             string
+		inputBody        string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:      "set: OK",
+			method:    http.MethodPut,
+			path:      "/users/phone/",
+			inputBody: `{"phone":"+15550100"}`,
+			mockBehavior: func(m *servicemocks.MockPhone) {
+				m.EXPECT().SetPhone(gomock.Any(), userId, "+15550100").Return(nil)
+			},
+			wantStatusCode:   202,
+			wantResponseBody: "one-time code sent",
+		},
+		{
+			name:             "set: not E.164",
+			method:           http.MethodPut,
+			path:             "/users/phone/",
+			inputBody:        `{"phone":"555-0100"}`,
+			mockBehavior:     func(m *servicemocks.MockPhone) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Phone":"Is not valid"}}`,
+		},
+		{
+			name:      "set: sent recently",
+			method:    http.MethodPut,
+			path:      "/users/phone/",
+			inputBody: `{"phone":"+15550100"}`,
+			mockBehavior: func(m *servicemocks.MockPhone) {
+				m.EXPECT().SetPhone(gomock.Any(), userId, "+15550100").Return(svcErrs.ErrOTPThrottled)
+			},
+			wantStatusCode:   429,
+			wantResponseBody: `{"errors":{"message":"one-time code was sent recently, try again later"}}`,
+		},
+		{
+			name:      "verify: OK",
+			method:    http.MethodPost,
+			path:      "/users/phone/verify",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockPhone) {
+				m.EXPECT().VerifyPhone(gomock.Any(), userId, "123456").Return(nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: "phone verified successfully",
+		},
+		{
+			name:      "verify: invalid code",
+			method:    http.MethodPost,
+			path:      "/users/phone/verify",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockPhone) {
+				m.EXPECT().VerifyPhone(gomock.Any(), userId, "123456").Return(svcErrs.ErrInvalidOTP)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"invalid one-time code"}}`,
+		},
+		{
+			name:      "verify: verified by another user",
+			method:    http.MethodPost,
+			path:      "/users/phone/verify",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(m *servicemocks.MockPhone) {
+				m.EXPECT().VerifyPhone(gomock.Any(), userId, "123456").Return(svcErrs.ErrPhoneAlreadyExists)
+			},
+			wantStatusCode:   409,
+			wantResponseBody: `{"errors":{"message":"phone is verified by another user"}}`,
+		},
+		{
+			name:   "remove: OK",
+			method: http.MethodDelete,
+			path:   "/users/phone/",
+			mockBehavior: func(m *servicemocks.MockPhone) {
+				m.EXPECT().RemovePhone(gomock.Any(), userId).Return(nil)
+			},
+			wantStatusCode: 204,
+		},
+		{
+			name:      "second factor: OK",
+			method:    http.MethodPut,
+			path:      "/users/phone/second-factor",
+			inputBody: `{"enabled":true}`,
+			mockBehavior: func(m *servicemocks.MockPhone) {
+				m.EXPECT().SetSecondFactor(gomock.Any(), userId, true).Return(nil)
+			},
+			wantStatusCode: 204,
+		},
+		{
+			name:      "second factor: no verified phone",
+			method:    http.MethodPut,
+			path:      "/users/phone/second-factor",
+			inputBody: `{"enabled":true}`,
+			mockBehavior: func(m *servicemocks.MockPhone) {
+				m.EXPECT().SetSecondFactor(gomock.Any(), userId, true).Return(svcErrs.ErrPhoneNotFound)
+			},
+			wantStatusCode:   404,
+			wantResponseBody: `{"errors":{"message":"user has no verified phone"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ps := servicemocks.NewMockPhone(ctrl)
+			tc.mockBehavior(ps)
+
+			gin.SetMode(gin.TestMode)
+			e := gin.New()
+			g := e.Group("/users/phone", func(c *gin.Context) {
+				c.Set(middleware.UserIdKey, userId)
+			})
+			NewPhoneRoutes(g, validator.NewCustomValidator(), ps)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.inputBody))
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestAuthRoutes_SMS(t *testing.T) {
+	type MockBehavior func(m *servicemocks.MockAuth)
+
+	testCases := []struct {
+		name             string
+		path             string
+		inputBody        string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:      "mfa code: OK",
+			path:      "/auth/mfa/sms",
+			inputBody: `{"mfa_token":"token"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().SendMFACode(gomock.Any(), "token").Return(nil)
+			},
+			wantStatusCode:   202,
+			wantResponseBody: "one-time code sent",
+		},
+		{
+			name:      "mfa code: invalid token",
+			path:      "/auth/mfa/sms",
+			inputBody: `{"mfa_token":"token"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().SendMFACode(gomock.Any(), "token").Return(svcErrs.ErrInvalidMFAToken)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"mfa token is invalid or expired"}}`,
+		},
+		{
+			name:      "mfa code: sms second factor disabled",
+			path:      "/auth/mfa/sms",
+			inputBody: `{"mfa_token":"token"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().SendMFACode(gomock.Any(), "token").Return(svcErrs.ErrPhoneNotFound)
+			},
+			wantStatusCode:   404,
+			wantResponseBody: `{"errors":{"message":"user has no verified phone"}}`,
+		},
+		{
+			name:      "verify mfa: sms code",
+			path:      "/auth/mfa/verify",
+			inputBody: `{"mfa_token":"token","code":"123456","method":"sms"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().VerifyMFA(gomock.Any(), auth.VerifyMFAInput{MFAToken: "token", Code: "123456", SMS: true}).
+					Return(auth.GenerateTokenOutput{AccessToken: "1", RefreshToken: "2", IdToken: "3"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"access_token":"1","refresh_token":"2","id_token":"3"}`,
+		},
+		{
+			name:             "verify mfa: unknown method",
+			path:             "/auth/mfa/verify",
+			inputBody:        `{"mfa_token":"token","code":"123456","method":"voice"}`,
+			mockBehavior:     func(m *servicemocks.MockAuth) {},
+			wantStatusCode:   400,
+			wantResponseBody: `{"errors":{"Method":"Is not valid"}}`,
+		},
+		{
+			name:      "reset password: OK",
+			path:      "/auth/reset-password/sms",
+			inputBody: `{"phone":"+15550100"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().ResetPasswordBySMS(gomock.Any(), "+15550100").Return(nil)
+			},
+			wantStatusCode:   202,
+			wantResponseBody: "one-time code sent if the phone is verified",
+		},
+		{
+			name:      "reset password: send error",
+			path:      "/auth/reset-password/sms",
+			inputBody: `{"phone":"+15550100"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().ResetPasswordBySMS(gomock.Any(), "+15550100").Return(svcErrs.ErrSendOTP)
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
+		{
+			name:      "recovery password: OK",
+			path:      "/auth/recovery-password/sms",
+			inputBody: `{"phone":"+15550100","code":"123456","password":"NewPassw0rd!"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().RecoveryPasswordBySMS(gomock.Any(), auth.SMSRecoveryPasswordInput{
+					Phone:    "+15550100",
+					Code:     "123456",
+					Password: "NewPassw0rd!",
+				}).Return(nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: "password updated successfully",
+		},
+		{
+			name:      "recovery password: invalid code",
+			path:      "/auth/recovery-password/sms",
+			inputBody: `{"phone":"+15550100","code":"123456","password":"NewPassw0rd!"}`,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().RecoveryPasswordBySMS(gomock.Any(), gomock.Any()).Return(svcErrs.ErrInvalidOTP)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"invalid one-time code"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			as := servicemocks.NewMockAuth(ctrl)
+			tc.mockBehavior(as)
+
+			gin.SetMode(gin.TestMode)
+			e := gin.New()
+			NewAuthRoutes(e.Group("/auth"), validator.NewCustomValidator(), as)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.inputBody))
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"errors"
+	"github.com/bubalync/uni-auth/internal/api/http/middleware"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type sessionRoutes struct {
+	as service.Auth
+}
+
+func NewSessionRoutes(g *gin.RouterGroup, as service.Auth) {
+	r := &sessionRoutes{as}
+
+	g.GET("/", r.sessions)
+	g.DELETE("/:session_id", r.revoke)
+}
+
+func sessionIdFromContext(c *gin.Context) uuid.UUID {
+	sid, _ := c.Get(middleware.SessionIdKey)
+	id, _ := sid.(uuid.UUID)
+	return id
+}
+
+type sessionResponse struct {
+	entity.Session
+	Current bool `json:"current"`
+}
+
+// @Summary     Active sessions
+// @Description List the active sessions (signed in devices) of the current user
+// @Tags        sessions
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200 {array} sessionResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/sessions [get]
+func (r *sessionRoutes) sessions(c *gin.Context) {
+	currentId := sessionIdFromContext(c)
+
+	sessions, err := r.as.Sessions(c.Request.Context(), userIdFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	res := make([]sessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		res = append(res, sessionResponse{
+			Session: s,
+			Current: s.Id == currentId,
+		})
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary     Revoke session
+// @Description Sign out the device that owns the session, its refresh and access tokens stop working
+// @Tags        sessions
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       session_id path string true "Session id (UUID)"
+// @Success     204
+// @Failure     400 {object} response.ErrResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     404 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/sessions/{session_id} [delete]
+func (r *sessionRoutes) revoke(c *gin.Context) {
+	sid, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error("session_id is invalid uuid"))
+		return
+	}
+
+	err = r.as.RevokeSession(c.Request.Context(), userIdFromContext(c), sid)
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, response.Error(err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/bubalync/uni-auth/internal/api/http/middleware"
 	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
 	"github.com/bubalync/uni-auth/internal/service"
 	"github.com/bubalync/uni-auth/internal/service/svcErrs"
 	"github.com/bubalync/uni-auth/pkg/validator"
@@ -15,18 +16,27 @@ import (
 
 type userRoutes struct {
 	us service.User
+	as service.Auth
 	l  *slog.Logger
 	cv *validator.CustomValidator
 }
 
-func NewUserRoutes(g *gin.RouterGroup, log *slog.Logger, cv *validator.CustomValidator, us service.User) {
-	r := &userRoutes{us, log, cv}
+func NewUserRoutes(g *gin.RouterGroup, log *slog.Logger, cv *validator.CustomValidator, us service.User, as service.Auth) {
+	r := &userRoutes{us, as, log, cv}
 
 	g.GET("/", r.user)
-	g.GET("/:user_id", r.userById)
 	g.PUT("/", r.update)
 	g.DELETE("/", r.delete)
 	g.POST("/logout", r.logout)
+	g.POST("/step-up", r.sendStepUpCode)
+	g.POST("/step-up/verify", r.stepUp)
+}
+
+// NewUserLookupRoutes mounts the lookup of users by id, which services call with client_credentials tokens too.
+func NewUserLookupRoutes(g *gin.RouterGroup, us service.User) {
+	r := &userRoutes{us: us}
+
+	g.GET("/:user_id", r.userById)
 }
 
 func userIdFromContext(c *gin.Context) uuid.UUID {
@@ -61,7 +71,7 @@ func (r *userRoutes) user(c *gin.Context) {
 }
 
 // @Summary     User info by id
-// @Description Get information about user by id
+// @Description Get information about user by id, the token may belong to a user or a service principal
 // @Tags        users
 // @Accept      json
 // @Produce     json
@@ -102,7 +112,23 @@ func (r *userRoutes) delete(c *gin.Context) {
 	panic("implement me")
 }
 
+// @Summary     Logout
+// @Description Revoke the access token of the request and end its session, the other tokens of the session stop working
+// @Tags        users
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Success     204
+// @Failure     401 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /api/v1/users/logout [post]
 func (r *userRoutes) logout(c *gin.Context) {
-	//TODO implement me
-	panic("implement me")
+	claims := c.MustGet(middleware.ClaimsKey).(*jwtgen.Claims)
+
+	if err := r.as.Logout(c.Request.Context(), claims); err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
 }
@@ -0,0 +1,59 @@
+package v1
+
+import (
+	"errors"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type userInfoRoutes struct {
+	us service.User
+}
+
+func NewUserInfoRoutes(g *gin.RouterGroup, us service.User) {
+	r := &userInfoRoutes{us}
+
+	// OpenID Connect allows both methods
+	g.GET("", r.userInfo)
+	g.POST("", r.userInfo)
+}
+
+// userInfoResponse holds the standard claims about the user.
+type userInfoResponse struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// @Summary     UserInfo
+// @Description OpenID Connect claims about the user that owns the access token
+// @Tags        oidc
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200 {object} userInfoResponse
+// @Failure     401 {object} response.ErrResponse
+// @Failure     500 {object} response.ErrResponse
+// @Router      /userinfo [get]
+// @Router      /userinfo [post]
+func (r *userInfoRoutes) userInfo(c *gin.Context) {
+	user, err := r.us.UserById(c.Request.Context(), userIdFromContext(c))
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrUserNotFound) {
+			// the token outlived its user
+			c.JSON(http.StatusUnauthorized, response.Error(response.ErrInvalidToken.Error()))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	c.JSON(http.StatusOK, userInfoResponse{
+		Sub:           user.Id.String(),
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+	})
+}
@@ -0,0 +1,91 @@
+package v1
+
+import (
+	"errors"
+	"github.com/bubalync/uni-auth/internal/api/http/middleware"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUserInfoRoutes_UserInfo(t *testing.T) {
+	userId := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+	type MockBehavior func(m *servicemocks.MockUser)
+
+	testCases := []struct {
+		name             string
+		method           string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+	}{
+		{
+			name:   "OK",
+			method: http.MethodGet,
+			mockBehavior: func(m *servicemocks.MockUser) {
+				m.EXPECT().UserById(gomock.Any(), userId).Return(entity.User{Id: userId, Email: "test@example.com"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"sub":"00000000-0000-0000-0000-000000000001","email":"test@example.com","email_verified":false}`,
+		},
+		{
+			name:   "OK with POST",
+			method: http.MethodPost,
+			mockBehavior: func(m *servicemocks.MockUser) {
+				m.EXPECT().UserById(gomock.Any(), userId).Return(entity.User{Id: userId, Email: "test@example.com"}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"sub":"00000000-0000-0000-0000-000000000001","email":"test@example.com","email_verified":false}`,
+		},
+		{
+			name:   "user not found",
+			method: http.MethodGet,
+			mockBehavior: func(m *servicemocks.MockUser) {
+				m.EXPECT().UserById(gomock.Any(), userId).Return(entity.User{}, svcErrs.ErrUserNotFound)
+			},
+			wantStatusCode:   401,
+			wantResponseBody: `{"errors":{"message":"invalid token"}}`,
+		},
+		{
+			name:   "user service error",
+			method: http.MethodGet,
+			mockBehavior: func(m *servicemocks.MockUser) {
+				m.EXPECT().UserById(gomock.Any(), userId).Return(entity.User{}, errors.New("some error"))
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			us := servicemocks.NewMockUser(ctrl)
+			tc.mockBehavior(us)
+
+			e := gin.New()
+			g := e.Group("/userinfo", func(c *gin.Context) {
+				c.Set(middleware.UserIdKey, userId)
+			})
+			NewUserInfoRoutes(g, us)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, "/userinfo", nil)
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/lib/api/response"
+	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/oauth"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// jwksMaxAge is how long verifiers may cache the key set.
+	// A verifier that meets an unknown kid should fetch the set again.
+	jwksMaxAge = 5 * time.Minute
+	// discoveryMaxAge is how long relying parties may cache the provider configuration.
+	discoveryMaxAge = time.Hour
+)
+
+// providerMetadata is the OpenID Provider configuration document (OpenID Connect Discovery 1.0).
+type providerMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	JwksUri                           string   `json:"jwks_uri"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	IntrospectionAuthMethodsSupported []string `json:"introspection_endpoint_auth_methods_supported"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	RevocationAuthMethodsSupported    []string `json:"revocation_endpoint_auth_methods_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IdTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+type wellKnownRoutes struct {
+	as       service.Auth
+	metadata providerMetadata
+}
+
+// NewWellKnownRoutes registers the discovery documents.
+// The issuer is the public base URL of the service, signingAlg the algorithm of ID tokens.
+func NewWellKnownRoutes(g *gin.RouterGroup, as service.Auth, issuer, signingAlg string) {
+	issuer = strings.TrimSuffix(issuer, "/")
+
+	r := &wellKnownRoutes{
+		as: as,
+		metadata: providerMetadata{
+			Issuer:                            issuer,
+			AuthorizationEndpoint:             issuer + "/oauth/authorize",
+			TokenEndpoint:                     issuer + "/oauth/token",
+			JwksUri:                           issuer + "/.well-known/jwks.json",
+			UserinfoEndpoint:                  issuer + "/userinfo",
+			ResponseTypesSupported:            []string{oauth.ResponseTypeCode},
+			GrantTypesSupported:               oauth.SupportedGrantTypes,
+			CodeChallengeMethodsSupported:     []string{oauth.CodeChallengeMethodS256},
+			TokenEndpointAuthMethodsSupported: []string{"none", "client_secret_basic", "client_secret_post"},
+			IntrospectionEndpoint:             issuer + "/oauth/introspect",
+			IntrospectionAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post"},
+			RevocationEndpoint:                issuer + "/oauth/revoke",
+			RevocationAuthMethodsSupported:    []string{"none", "client_secret_basic", "client_secret_post"},
+			SubjectTypesSupported:             []string{"public"},
+			IdTokenSigningAlgValuesSupported:  []string{signingAlg},
+			ScopesSupported:                   oauth.SupportedScopes,
+			ClaimsSupported: []string{
+				"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "email", "email_verified",
+			},
+		},
+	}
+
+	g.GET("/openid-configuration", r.openidConfiguration)
+	g.GET("/jwks.json", r.jwks)
+}
+
+// @Summary     OpenID Provider configuration
+// @Description OpenID Connect discovery document
+// @Tags        well-known
+// @Produce     json
+// @Success     200 {object} providerMetadata
+// @Router      /.well-known/openid-configuration [get]
+func (r *wellKnownRoutes) openidConfiguration(c *gin.Context) {
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(discoveryMaxAge.Seconds())))
+	c.JSON(http.StatusOK, r.metadata)
+}
+
+// @Summary     JSON Web Key Set
+// @Description Public keys that verify access tokens, empty when tokens are signed with a shared secret
+// @Tags        well-known
+// @Produce     json
+// @Success     200 {object} jwtgen.JWKS
+// @Success     304
+// @Failure     500 {object} response.ErrResponse
+// @Router      /.well-known/jwks.json [get]
+func (r *wellKnownRoutes) jwks(c *gin.Context) {
+	set, err := r.as.JWKS()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	body, err := json.Marshal(set)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorInternal())
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(jwksMaxAge.Seconds())))
+	c.Header("ETag", etag)
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", body)
+}
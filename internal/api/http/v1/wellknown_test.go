@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
+	"github.com/bubalync/uni-auth/internal/mocks/servicemocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWellKnownRoutes_JWKS(t *testing.T) {
+	set := jwtgen.JWKS{Keys: []jwtgen.JWK{
+		{Kid: "active", Kty: "OKP", Alg: "EdDSA", Use: "sig", Crv: "Ed25519", X: "x"},
+	}}
+
+	body, _ := json.Marshal(set)
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	type MockBehavior func(m *servicemocks.MockAuth)
+
+	testCases := []struct {
+		name             string
+		ifNoneMatch      string
+		mockBehavior     MockBehavior
+		wantStatusCode   int
+		wantResponseBody string
+		wantCacheControl string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().JWKS().Return(set, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"keys":[{"kid":"active","kty":"OKP","alg":"EdDSA","use":"sig","crv":"Ed25519","x":"x"}]}`,
+			wantCacheControl: "public, max-age=300",
+		},
+		{
+			name:        "not modified",
+			ifNoneMatch: etag,
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().JWKS().Return(set, nil)
+			},
+			wantStatusCode:   304,
+			wantResponseBody: ``,
+			wantCacheControl: "public, max-age=300",
+		},
+		{
+			name: "shared secret",
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().JWKS().Return(jwtgen.JWKS{Keys: []jwtgen.JWK{}}, nil)
+			},
+			wantStatusCode:   200,
+			wantResponseBody: `{"keys":[]}`,
+			wantCacheControl: "public, max-age=300",
+		},
+		{
+			name: "auth service error",
+			mockBehavior: func(m *servicemocks.MockAuth) {
+				m.EXPECT().JWKS().Return(jwtgen.JWKS{}, errors.New("some error"))
+			},
+			wantStatusCode:   500,
+			wantResponseBody: `{"errors":{"message":"internal server error"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			as := servicemocks.NewMockAuth(ctrl)
+			tc.mockBehavior(as)
+
+			e := gin.New()
+			NewWellKnownRoutes(e.Group("/.well-known"), as, "https://auth.example.com", "ES256")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantResponseBody, w.Body.String())
+			assert.Equal(t, tc.wantCacheControl, w.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestWellKnownRoutes_OpenidConfiguration(t *testing.T) {
+	e := gin.New()
+	NewWellKnownRoutes(e.Group("/.well-known"), nil, "https://auth.example.com/", "ES256")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+
+	e.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+
+	var got map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, "https://auth.example.com", got["issuer"])
+	assert.Equal(t, "https://auth.example.com/.well-known/jwks.json", got["jwks_uri"])
+	assert.Equal(t, "https://auth.example.com/userinfo", got["userinfo_endpoint"])
+	assert.Equal(t, "https://auth.example.com/oauth/authorize", got["authorization_endpoint"])
+	assert.Equal(t, "https://auth.example.com/oauth/token", got["token_endpoint"])
+	assert.Equal(t, "https://auth.example.com/oauth/introspect", got["introspection_endpoint"])
+	assert.Equal(t, "https://auth.example.com/oauth/revoke", got["revocation_endpoint"])
+	assert.Equal(t, []any{"code"}, got["response_types_supported"])
+	assert.Equal(t, []any{"S256"}, got["code_challenge_methods_supported"])
+	assert.Equal(t, []any{"ES256"}, got["id_token_signing_alg_values_supported"])
+	assert.Equal(t, []any{"public"}, got["subject_types_supported"])
+}
@@ -1,14 +1,25 @@
 package app
 
 import (
+	"context"
+	"crypto/ecdsa"
 	"fmt"
 	"github.com/bubalync/uni-auth/internal/api/grpc"
 	"github.com/bubalync/uni-auth/internal/api/http"
 	"github.com/bubalync/uni-auth/internal/config"
 	"github.com/bubalync/uni-auth/internal/lib/email"
 	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
+	"github.com/bubalync/uni-auth/internal/lib/ratelimit"
+	"github.com/bubalync/uni-auth/internal/lib/sms"
 	"github.com/bubalync/uni-auth/internal/repo"
 	"github.com/bubalync/uni-auth/internal/service"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/federation"
+	"github.com/bubalync/uni-auth/internal/service/keys"
+	"github.com/bubalync/uni-auth/internal/service/lockout"
+	"github.com/bubalync/uni-auth/internal/service/otp"
+	"github.com/bubalync/uni-auth/pkg/breach"
+	"github.com/bubalync/uni-auth/pkg/cipher"
 	"github.com/bubalync/uni-auth/pkg/hasher"
 	"github.com/bubalync/uni-auth/pkg/httpserver"
 	"github.com/bubalync/uni-auth/pkg/logger"
@@ -16,6 +27,7 @@ import (
 	"github.com/bubalync/uni-auth/pkg/postgres"
 	"github.com/bubalync/uni-auth/pkg/redis"
 	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -40,19 +52,128 @@ func Run(cfg *config.Config) {
 	log.Info("Initializing repositories...")
 	repositories := repo.NewRepositories(pg)
 
+	// Encryption of secrets at rest
+	var secretCipher cipher.Cipher
+	if cfg.Encryption.Key != "" {
+		secretCipher, err = cipher.NewAESGCMFromBase64(cfg.Encryption.Key)
+		if err != nil {
+			log.Error("app - Run - cipher.NewAESGCMFromBase64", sl.Err(err))
+			return
+		}
+	}
+
+	// Relying party of passkeys
+	var relyingParty *webauthn.WebAuthn
+	if cfg.WebAuthn.RPID != "" {
+		timeout := webauthn.TimeoutConfig{Enforce: true, Timeout: cfg.WebAuthn.Timeout, TimeoutUVD: cfg.WebAuthn.Timeout}
+
+		relyingParty, err = webauthn.New(&webauthn.Config{
+			RPID:          cfg.WebAuthn.RPID,
+			RPDisplayName: cfg.App.Name,
+			RPOrigins:     cfg.WebAuthn.RPOrigins,
+			Timeouts:      webauthn.TimeoutsConfig{Login: timeout, Registration: timeout},
+		})
+		if err != nil {
+			log.Error("app - Run - webauthn.New", sl.Err(err))
+			return
+		}
+	}
+
+	// External identity providers
+	federationProviders, err := newFederationProviders(cfg.Federation, cfg.OIDC.Issuer)
+	if err != nil {
+		log.Error("app - Run - newFederationProviders", sl.Err(err))
+		return
+	}
+
+	// SMS channel
+	smsSender, err := newSMSSender(cfg.SMS)
+	if err != nil {
+		log.Error("app - Run - newSMSSender", sl.Err(err))
+		return
+	}
+
+	// Rate limit
+	limiter, err := newRateLimiter(cfg.RateLimit, redisClient)
+	if err != nil {
+		log.Error("app - Run - newRateLimiter", sl.Err(err))
+		return
+	}
+
+	// Breached passwords
+	var breaches *breach.Filter
+	if cfg.BreachedPasswords.File != "" {
+		breaches, err = breach.Load(cfg.BreachedPasswords.File)
+		if err != nil {
+			log.Error("app - Run - breach.Load", sl.Err(err))
+			return
+		}
+	}
+
+	// Token generator
+	var keyRing *jwtgen.KeyRing
+	if cfg.JWT.KeyRing.Enabled {
+		keyRing = jwtgen.NewKeyRing()
+	}
+
+	tokenGenerator, err := newTokenGenerator(cfg.JWT, cfg.OIDC.Issuer, keyRing)
+	if err != nil {
+		log.Error("app - Run - newTokenGenerator", sl.Err(err))
+		return
+	}
+
+	// Password hasher
+	passwordHasher := hasher.NewDetectingHasher(
+		cfg.Hasher.Algorithm,
+		hasher.NewBcryptHasher(hasher.Cost(cfg.Hasher.BcryptCost)),
+		hasher.NewArgon2idHasher(
+			hasher.Memory(cfg.Hasher.Argon2Memory),
+			hasher.Time(cfg.Hasher.Argon2Time),
+			hasher.Parallelism(cfg.Hasher.Argon2Parallelism),
+		),
+	)
+
+	// the secrets hashed by the password hasher before still match
+	secretHasher := hasher.NewSecretHasher(passwordHasher)
+
 	// services
 	log.Info("Initializing services...")
 	deps := service.ServicesDependencies{
-		Repos:  repositories,
-		Hasher: hasher.NewBcryptHasher(),
-		Cache:  redisClient,
-		TokenGenerator: jwtgen.NewJwtTokenGenerator(
-			cfg.JWT.AccessSignKey,
-			cfg.JWT.RefreshSignKey,
-			cfg.JWT.AccessTokenTTL,
-			cfg.JWT.RefreshTokenTTL,
-		),
+		Repos:           repositories,
+		Hasher:          passwordHasher,
+		SecretHasher:    secretHasher,
+		Cache:           redisClient,
+		TokenGenerator:  tokenGenerator,
+		AccessTokenTTL:  cfg.JWT.AccessTokenTTL,
 		RefreshTokenTTL: cfg.JWT.RefreshTokenTTL,
+		IDTokenAudience: cfg.OIDC.Audience,
+		OAuthCodeTTL:    cfg.OAuth.CodeTTL,
+
+		MaxClientAccessTokenTTL:  cfg.OAuth.MaxAccessTokenTTL,
+		MaxClientRefreshTokenTTL: cfg.OAuth.MaxRefreshTokenTTL,
+		EmailVerification: auth.EmailVerificationConfig{
+			Required:       cfg.EmailVerification.Required,
+			TokenTTL:       cfg.EmailVerification.TokenTTL,
+			ResendInterval: cfg.EmailVerification.ResendInterval,
+		},
+		Passwordless: auth.PasswordlessConfig{
+			MagicLinkTTL: cfg.Passwordless.MagicLinkTTL,
+		},
+		OTP: otp.Config{
+			Digits:         cfg.OTP.Digits,
+			TTL:            cfg.OTP.TTL,
+			MaxAttempts:    cfg.OTP.MaxAttempts,
+			Lockout:        cfg.OTP.Lockout,
+			ResendInterval: cfg.OTP.ResendInterval,
+		},
+		Lockout: lockout.Config{
+			MaxAttempts:      cfg.Lockout.MaxAttempts,
+			MaxAttemptsPerIP: cfg.Lockout.MaxAttemptsPerIP,
+			Window:           cfg.Lockout.Window,
+			BaseDelay:        cfg.Lockout.BaseDelay,
+			MaxDelay:         cfg.Lockout.MaxDelay,
+			Notify:           cfg.Lockout.Notify,
+		},
 		EmailSender: email.NewSmtpSender(
 			cfg.EmailSender.SMTPHost,
 			cfg.EmailSender.SMTPPort,
@@ -60,11 +181,41 @@ func Run(cfg *config.Config) {
 			cfg.EmailSender.Password,
 			cfg.EmailSender.From,
 		),
+		SMSSender: smsSender,
+		KeyRing:   keyRing,
+		Cipher:    secretCipher,
+		KeySchedule: keys.Schedule{
+			Algorithm:      cfg.JWT.Algorithm,
+			RotationPeriod: cfg.JWT.KeyRing.RotationPeriod,
+			PrePublish:     cfg.JWT.KeyRing.PrePublish,
+			RetireAfter:    max(cfg.JWT.AccessTokenTTL, cfg.OAuth.MaxAccessTokenTTL),
+		},
+		MFAIssuer: cfg.App.Name,
+		WebAuthn:  relyingParty,
+
+		BreachedPasswords: breaches,
+
+		FederationProviders: federationProviders,
+		FederationStateTTL:  cfg.Federation.StateTTL,
 	}
 	services := service.NewServices(log, deps)
 
+	// Signing key rotation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if services.Keys != nil {
+		log.Info("Loading signing keys...")
+		if err = services.Keys.ApplySchedule(ctx); err != nil {
+			log.Error("app - Run - services.Keys.ApplySchedule", sl.Err(err))
+			return
+		}
+
+		go services.Keys.Run(ctx, cfg.JWT.KeyRing.CheckInterval)
+	}
+
 	// gRPC server
-	gRPCServer := grpc.NewServer(log, services, cfg.GRPC.Port)
+	gRPCServer := grpc.NewServer(log, services, limiter, cfg.GRPC.Port, cfg.Admin.ApiKey)
 
 	go func() {
 		gRPCServer.MustRun()
@@ -73,7 +224,7 @@ func Run(cfg *config.Config) {
 	// Gin handler
 	log.Info("Initializing handlers and routes...")
 	handler := gin.New()
-	http.NewRouter(handler, cfg, log, services)
+	http.NewRouter(handler, cfg, log, services, limiter, breaches)
 
 	// HTTP server
 	httpServer := httpserver.New(
@@ -102,6 +253,8 @@ func Run(cfg *config.Config) {
 
 	// Graceful shutdown
 	log.Info("Shutting down server...")
+	cancel()
+
 	err = httpServer.Shutdown()
 	if err != nil {
 		log.Error("app - Run - httpServer.Shutdown:", sl.Err(err))
@@ -114,3 +267,124 @@ func Run(cfg *config.Config) {
 
 	gRPCServer.Stop()
 }
+
+func newTokenGenerator(cfg config.JWT, issuer string, keyRing *jwtgen.KeyRing) (jwtgen.TokenGenerator, error) {
+	if keyRing != nil {
+		return jwtgen.NewAsymmetricTokenGenerator(issuer, keyRing, cfg.RefreshSignKey, cfg.AccessTokenTTL, cfg.RefreshTokenTTL), nil
+	}
+
+	private, err := jwtgen.LoadPrivateKey(cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load private key: %w", err)
+	}
+
+	verification := make([]jwtgen.VerificationKey, 0, len(cfg.VerificationKeys))
+	for _, k := range cfg.VerificationKeys {
+		public, err := jwtgen.LoadPublicKey(k.File)
+		if err != nil {
+			return nil, fmt.Errorf("load public key %q: %w", k.Id, err)
+		}
+
+		alg, err := jwtgen.AlgorithmForKey(public)
+		if err != nil {
+			return nil, fmt.Errorf("public key %q: %w", k.Id, err)
+		}
+
+		verification = append(verification, jwtgen.VerificationKey{Id: k.Id, Algorithm: alg, Public: public})
+	}
+
+	keys, err := jwtgen.NewStaticKeySet(
+		jwtgen.SigningKey{Id: cfg.KeyId, Algorithm: cfg.Algorithm, Private: private},
+		verification...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwtgen.NewAsymmetricTokenGenerator(issuer, keys, cfg.RefreshSignKey, cfg.AccessTokenTTL, cfg.RefreshTokenTTL), nil
+}
+
+func newFederationProviders(cfg config.Federation, issuer string) (map[string]federation.Provider, error) {
+	providers := make(map[string]federation.Provider, len(cfg.Providers))
+
+	for _, p := range cfg.Providers {
+		providerCfg := federation.ProviderConfig{
+			Type:         p.Type,
+			Issuer:       p.Issuer,
+			ClientId:     p.ClientId,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  issuer + "/auth/federation/" + p.Name + "/callback",
+			Scopes:       p.Scopes,
+		}
+
+		if p.Type == federation.TypeApple {
+			private, err := jwtgen.LoadPrivateKey(p.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("provider %q: load private key: %w", p.Name, err)
+			}
+
+			key, ok := private.(*ecdsa.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("provider %q: apple keys are ECDSA P-256 keys", p.Name)
+			}
+
+			providerCfg.Apple = &federation.AppleConfig{TeamId: p.TeamId, KeyId: p.KeyId, PrivateKey: key}
+		}
+
+		provider, err := federation.NewProvider(providerCfg)
+		if err != nil {
+			return nil, fmt.Errorf("provider %q: %w", p.Name, err)
+		}
+
+		providers[p.Name] = provider
+	}
+
+	return providers, nil
+}
+
+func newSMSSender(cfg config.SMS) (sms.Sender, error) {
+	switch cfg.Provider {
+	case "console":
+		if cfg.File == "" {
+			return sms.NewConsoleSender(os.Stdout), nil
+		}
+
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("open sms file: %w", err)
+		}
+
+		return sms.NewConsoleSender(f), nil
+	case "http":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("sms url is required for the http provider")
+		}
+
+		return sms.NewHTTPSender(cfg.URL, cfg.Token, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown sms provider %q", cfg.Provider)
+	}
+}
+
+func newRateLimiter(cfg config.RateLimit, cache redis.Cache) (*ratelimit.Limiter, error) {
+	var store ratelimit.Store
+	switch cfg.Store {
+	case "redis":
+		store = cache
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+
+	rules := make(map[string][]ratelimit.Rule, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		rules[r.Route] = append(rules[r.Route], ratelimit.Rule{
+			Key:      r.Key,
+			Requests: r.Requests,
+			Window:   r.Window,
+		})
+	}
+
+	return ratelimit.New(store, rules), nil
+}
@@ -7,21 +7,38 @@ import (
 	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"slices"
+	"strings"
 	"time"
 )
 
 type (
 	Config struct {
-		Env         string      `yaml:"env" env:"ENV" env-default:"local"`
-		App         App         `yaml:"app"`
-		Log         Log         `yaml:"log"`
-		PG          PG          `yaml:"pg"`
-		HTTP        HTTP        `yaml:"http"`
-		Swagger     Swagger     `yaml:"swagger"`
-		JWT         JWT         `yaml:"jwt"`
-		Redis       Redis       `yaml:"redis"`
-		GRPC        GRPC        `yaml:"grpc"`
-		EmailSender EmailSender `yaml:"email_sender"`
+		Env               string            `yaml:"env" env:"ENV" env-default:"local"`
+		App               App               `yaml:"app"`
+		Log               Log               `yaml:"log"`
+		PG                PG                `yaml:"pg"`
+		HTTP              HTTP              `yaml:"http"`
+		Swagger           Swagger           `yaml:"swagger"`
+		JWT               JWT               `yaml:"jwt"`
+		OIDC              OIDC              `yaml:"oidc"`
+		OAuth             OAuth             `yaml:"oauth"`
+		Redis             Redis             `yaml:"redis"`
+		GRPC              GRPC              `yaml:"grpc"`
+		EmailSender       EmailSender       `yaml:"email_sender"`
+		EmailVerification EmailVerification `yaml:"email_verification"`
+		Passwordless      Passwordless      `yaml:"passwordless"`
+		OTP               OTP               `yaml:"otp"`
+		SMS               SMS               `yaml:"sms"`
+		Lockout           Lockout           `yaml:"lockout"`
+		RateLimit         RateLimit         `yaml:"rate_limit"`
+		PasswordPolicy    PasswordPolicy    `yaml:"password_policy"`
+		Hasher            Hasher            `yaml:"hasher"`
+		BreachedPasswords BreachedPasswords `yaml:"breached_passwords"`
+		Encryption        Encryption        `yaml:"encryption"`
+		WebAuthn          WebAuthn          `yaml:"webauthn"`
+		Federation        Federation        `yaml:"federation"`
+		Admin             Admin             `yaml:"admin"`
 	}
 
 	App struct {
@@ -48,10 +65,50 @@ type (
 	}
 
 	JWT struct {
-		AccessSignKey   string        `yaml:"access_sign_key"   env:"JWT_ACCESS_SIGN_KEY"   env-required:"true"`
+		// Algorithm of access and ID tokens: RS256, ES256 or EdDSA. Relying parties verify ID tokens
+		// with the JWKS, so there is no shared secret algorithm.
+		Algorithm       string        `yaml:"algorithm"         env:"JWT_ALGORITHM"         env-default:"ES256"`
 		AccessTokenTTL  time.Duration `yaml:"access_token_ttl"  env:"JWT_ACCESS_TOKEN_TTL"  env-required:"true"`
 		RefreshSignKey  string        `yaml:"refresh_sign_key"  env:"JWT_REFRESH_SIGN_KEY"  env-required:"true"`
 		RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"JWT_REFRESH_TOKEN_TTL" env-required:"true"`
+
+		// Static signing keys, used when KeyRing is disabled.
+		KeyId            string   `yaml:"key_id"            env:"JWT_KEY_ID"`
+		PrivateKeyFile   string   `yaml:"private_key_file"  env:"JWT_PRIVATE_KEY_FILE"`
+		VerificationKeys []JWTKey `yaml:"verification_keys"`
+
+		KeyRing KeyRing `yaml:"key_ring"`
+	}
+
+	// KeyRing keeps the signing keys encrypted in Postgres and rotates them on a schedule.
+	// It replaces key_id, private_key_file and verification_keys.
+	KeyRing struct {
+		Enabled        bool          `yaml:"enabled"         env:"JWT_KEY_RING_ENABLED"         env-default:"false"`
+		RotationPeriod time.Duration `yaml:"rotation_period" env:"JWT_KEY_RING_ROTATION_PERIOD" env-default:"720h"`
+		PrePublish     time.Duration `yaml:"pre_publish"     env:"JWT_KEY_RING_PRE_PUBLISH"     env-default:"24h"`
+		CheckInterval  time.Duration `yaml:"check_interval"  env:"JWT_KEY_RING_CHECK_INTERVAL"  env-default:"1m"`
+	}
+
+	OIDC struct {
+		// Issuer is the public base URL of the service, stamped as the iss claim.
+		Issuer string `yaml:"issuer" env:"OIDC_ISSUER" env-required:"true"`
+		// Audience of ID tokens issued by /auth/sign-in, i.e. the client id of first-party frontends.
+		Audience string `yaml:"audience" env:"OIDC_AUDIENCE" env-default:"uni-auth"`
+	}
+
+	OAuth struct {
+		// CodeTTL is the lifetime of authorization codes.
+		CodeTTL time.Duration `yaml:"code_ttl" env:"OAUTH_CODE_TTL" env-default:"1m"`
+		// MaxAccessTokenTTL and MaxRefreshTokenTTL cap the token lifetimes a client can be registered with.
+		// Retired signing keys are published until the longest access token they signed expires.
+		MaxAccessTokenTTL  time.Duration `yaml:"max_access_token_ttl"  env:"OAUTH_MAX_ACCESS_TOKEN_TTL"  env-default:"1h"`
+		MaxRefreshTokenTTL time.Duration `yaml:"max_refresh_token_ttl" env:"OAUTH_MAX_REFRESH_TOKEN_TTL" env-default:"720h"`
+	}
+
+	// JWTKey is an additional public key (PEM file) accepted for verification.
+	JWTKey struct {
+		Id   string `yaml:"id"`
+		File string `yaml:"file"`
 	}
 
 	Redis struct {
@@ -70,6 +127,154 @@ type (
 		Username string `env:"ES_SMTP_USERNAME" env-required:"true"`
 		Password string `env:"ES_SMTP_PASSWORD" env-required:"true"`
 	}
+
+	// EmailVerification asks users to confirm the email they signed up with.
+	EmailVerification struct {
+		// Required stops users with an unverified email from signing in with the password.
+		Required bool `yaml:"required" env:"EMAIL_VERIFICATION_REQUIRED" env-default:"false"`
+		// TokenTTL is the lifetime of the links sent by email.
+		TokenTTL time.Duration `yaml:"token_ttl" env:"EMAIL_VERIFICATION_TOKEN_TTL" env-default:"24h"`
+		// ResendInterval is the least time between two verification emails to an address.
+		ResendInterval time.Duration `yaml:"resend_interval" env:"EMAIL_VERIFICATION_RESEND_INTERVAL" env-default:"1m"`
+	}
+
+	// Passwordless is the sign-in without a password.
+	Passwordless struct {
+		// MagicLinkTTL is the lifetime of the sign-in links sent by email.
+		MagicLinkTTL time.Duration `yaml:"magic_link_ttl" env:"PASSWORDLESS_MAGIC_LINK_TTL" env-default:"15m"`
+	}
+
+	// OTP is the numeric one-time codes sent for the passwordless sign-in, email verification and step-up.
+	OTP struct {
+		Digits int `yaml:"digits" env:"OTP_DIGITS" env-default:"6"`
+		// TTL is the lifetime of a code.
+		TTL time.Duration `yaml:"ttl" env:"OTP_TTL" env-default:"10m"`
+		// MaxAttempts is the number of wrong codes after which no codes are sent or accepted for the lockout.
+		MaxAttempts int           `yaml:"max_attempts" env:"OTP_MAX_ATTEMPTS" env-default:"5"`
+		Lockout     time.Duration `yaml:"lockout"      env:"OTP_LOCKOUT"      env-default:"15m"`
+		// ResendInterval is the least time between two codes for the same flow and account.
+		ResendInterval time.Duration `yaml:"resend_interval" env:"OTP_RESEND_INTERVAL" env-default:"1m"`
+	}
+
+	// SMS is the channel of the one-time codes sent to phones.
+	SMS struct {
+		// Provider is console, which writes the messages to File or stdout, or http, which posts them to URL.
+		Provider string `yaml:"provider" env:"SMS_PROVIDER" env-default:"console"`
+		File     string `yaml:"file"     env:"SMS_FILE"`
+		URL      string `yaml:"url"      env:"SMS_URL"`
+		// Token is sent as a bearer token to the gateway.
+		Token   string        `env:"SMS_TOKEN"`
+		Timeout time.Duration `yaml:"timeout" env:"SMS_TIMEOUT" env-default:"5s"`
+	}
+
+	// Lockout locks accounts and IPs out after too many failed password sign-ins, it is disabled when MaxAttempts is zero.
+	Lockout struct {
+		MaxAttempts      int           `yaml:"max_attempts"        env:"LOCKOUT_MAX_ATTEMPTS"        env-default:"5"`
+		MaxAttemptsPerIP int           `yaml:"max_attempts_per_ip" env:"LOCKOUT_MAX_ATTEMPTS_PER_IP" env-default:"50"`
+		Window           time.Duration `yaml:"window"              env:"LOCKOUT_WINDOW"              env-default:"15m"`
+		// BaseDelay is the first lockout, every next one within a day is twice as long up to MaxDelay.
+		BaseDelay time.Duration `yaml:"base_delay" env:"LOCKOUT_BASE_DELAY" env-default:"1m"`
+		MaxDelay  time.Duration `yaml:"max_delay"  env:"LOCKOUT_MAX_DELAY"  env-default:"1h"`
+		// Notify emails the owner of a locked account.
+		Notify bool `yaml:"notify" env:"LOCKOUT_NOTIFY" env-default:"false"`
+	}
+
+	// RateLimit throttles the routes that have rules, the other routes are not limited.
+	RateLimit struct {
+		// Store is redis, or memory which counts in the process and suits tests and a single instance.
+		Store string          `yaml:"store" env:"RATE_LIMIT_STORE" env-default:"redis"`
+		Rules []RateLimitRule `yaml:"rules"`
+	}
+
+	// RateLimitRule allows Requests requests to a route per Window for every value of Key.
+	RateLimitRule struct {
+		// Route is the method and the path pattern of an HTTP route, e.g. "POST /auth/sign-up",
+		// or the full method of a gRPC call, e.g. "/auth.v1.AuthService/ValidateToken".
+		Route string `yaml:"route"`
+		// Key is ip, email, user_id or client_id.
+		Key      string        `yaml:"key"`
+		Requests int           `yaml:"requests"`
+		Window   time.Duration `yaml:"window"`
+	}
+
+	// PasswordPolicy is what new passwords must satisfy, a zero minimum turns its rule off.
+	PasswordPolicy struct {
+		MinLength int `yaml:"min_length" env:"PASSWORD_MIN_LENGTH" env-default:"8"`
+		// MaxLength is at most 128, and at most 72 with the bcrypt hasher, bcrypt ignores the bytes beyond.
+		MaxLength int    `yaml:"max_length" env:"PASSWORD_MAX_LENGTH" env-default:"64"`
+		MinLower  int    `yaml:"min_lower"  env:"PASSWORD_MIN_LOWER"  env-default:"1"`
+		MinUpper  int    `yaml:"min_upper"  env:"PASSWORD_MIN_UPPER"  env-default:"1"`
+		MinDigit  int    `yaml:"min_digit"  env:"PASSWORD_MIN_DIGIT"  env-default:"1"`
+		MinSymbol int    `yaml:"min_symbol" env:"PASSWORD_MIN_SYMBOL" env-default:"1"`
+		Symbols   string `yaml:"symbols"    env:"PASSWORD_SYMBOLS"    env-default:"!@#$%^&*"`
+		// MinScore is the least strength score, from 0 (too guessable) to 4 (very unguessable).
+		MinScore int `yaml:"min_score" env:"PASSWORD_MIN_SCORE" env-default:"2"`
+	}
+
+	// Hasher hashes new passwords and recovery codes with Algorithm. The stored hashes of the other
+	// algorithm still match, and password hashes of another algorithm or cost are upgraded on sign-in.
+	Hasher struct {
+		// Algorithm is argon2id or bcrypt.
+		Algorithm  string `yaml:"algorithm"   env:"HASHER_ALGORITHM"   env-default:"argon2id"`
+		BcryptCost int    `yaml:"bcrypt_cost" env:"HASHER_BCRYPT_COST" env-default:"10"`
+		// Argon2Memory is in KiB.
+		Argon2Memory      uint32 `yaml:"argon2_memory"      env:"HASHER_ARGON2_MEMORY"      env-default:"65536"`
+		Argon2Time        uint32 `yaml:"argon2_time"        env:"HASHER_ARGON2_TIME"        env-default:"3"`
+		Argon2Parallelism uint8  `yaml:"argon2_parallelism" env:"HASHER_ARGON2_PARALLELISM" env-default:"4"`
+	}
+
+	// BreachedPasswords rejects new passwords found in known data breaches, it is disabled when File is empty.
+	BreachedPasswords struct {
+		// File is the bloom filter built by cmd/breachfilter from a dump of SHA-1 hashes.
+		File string `yaml:"file" env:"BREACHED_PASSWORDS_FILE"`
+	}
+
+	Encryption struct {
+		// Key is a base64 encoded 32 byte key used to encrypt secrets stored in the database.
+		// Two-factor authentication is disabled when it is empty.
+		Key string `env:"ENCRYPTION_KEY"`
+	}
+
+	// WebAuthn is the relying party of passkeys, passkeys are disabled when RPID is empty.
+	WebAuthn struct {
+		// RPID is the domain the passkeys are bound to, e.g. example.com for login.example.com.
+		RPID string `yaml:"rp_id" env:"WEBAUTHN_RP_ID"`
+		// RPOrigins are the origins of the pages that run the ceremonies.
+		RPOrigins []string `yaml:"rp_origins" env:"WEBAUTHN_RP_ORIGINS" env-separator:","`
+		// Timeout is how long the user has to answer a ceremony.
+		Timeout time.Duration `yaml:"timeout" env:"WEBAUTHN_TIMEOUT" env-default:"5m"`
+	}
+
+	// Federation is the sign-in with external OpenID providers.
+	Federation struct {
+		Providers []Provider `yaml:"providers"`
+		// StateTTL is how long the user has to sign in at the provider.
+		StateTTL time.Duration `yaml:"state_ttl" env:"FEDERATION_STATE_TTL" env-default:"10m"`
+	}
+
+	// Provider is an external OpenID provider, the callback is {oidc.issuer}/auth/federation/{name}/callback.
+	Provider struct {
+		// Name is the path segment of the provider routes, e.g. google.
+		Name string `yaml:"name"`
+		// Type is google, apple or oidc, google and apple preset the issuer and the scopes.
+		Type     string   `yaml:"type"`
+		Issuer   string   `yaml:"issuer"`
+		ClientId string   `yaml:"client_id"`
+		Scopes   []string `yaml:"scopes"`
+		// ClientSecretEnv names the environment variable that holds the client secret.
+		ClientSecretEnv string `yaml:"client_secret_env"`
+		ClientSecret    string `yaml:"-"`
+
+		// Sign in with Apple signs its client secrets with this key, see developer.apple.com.
+		TeamId         string `yaml:"team_id"`
+		KeyId          string `yaml:"key_id"`
+		PrivateKeyFile string `yaml:"private_key_file"`
+	}
+
+	Admin struct {
+		// ApiKey protects the /admin routes, they are disabled when it is empty.
+		ApiKey string `env:"ADMIN_API_KEY"`
+	}
 )
 
 func NewConfig() *Config {
@@ -94,6 +299,81 @@ func NewConfig() *Config {
 		log.Fatalf("failed to read config: " + err.Error())
 	}
 
+	if !slices.Contains([]string{"RS256", "ES256", "EdDSA"}, cfg.JWT.Algorithm) {
+		log.Fatalf("jwt.algorithm must be RS256, ES256 or EdDSA, relying parties verify ID tokens with the JWKS")
+	}
+
+	// the issuer must be identical in tokens and in the discovery document
+	cfg.OIDC.Issuer = strings.TrimSuffix(cfg.OIDC.Issuer, "/")
+
+	if cfg.JWT.KeyRing.Enabled {
+		if cfg.Encryption.Key == "" {
+			log.Fatalf("ENCRYPTION_KEY is required for jwt.key_ring")
+		}
+
+		if cfg.JWT.KeyRing.PrePublish >= cfg.JWT.KeyRing.RotationPeriod {
+			log.Fatalf("jwt.key_ring.pre_publish must be shorter than jwt.key_ring.rotation_period")
+		}
+	} else if cfg.JWT.KeyId == "" || cfg.JWT.PrivateKeyFile == "" {
+		log.Fatalf("jwt.key_id and jwt.private_key_file are required for the %s algorithm", cfg.JWT.Algorithm)
+	}
+
+	if cfg.WebAuthn.RPID != "" && len(cfg.WebAuthn.RPOrigins) == 0 {
+		log.Fatalf("webauthn.rp_origins is required for webauthn.rp_id")
+	}
+
+	for i, r := range cfg.RateLimit.Rules {
+		if r.Route == "" || r.Requests <= 0 || r.Window <= 0 {
+			log.Fatalf("rate_limit.rules[%d]: route, requests and window are required", i)
+		}
+
+		if !slices.Contains([]string{"ip", "email", "user_id", "client_id"}, r.Key) {
+			log.Fatalf("rate_limit.rules[%d]: key must be ip, email, user_id or client_id", i)
+		}
+	}
+
+	if p := cfg.PasswordPolicy; p.MinLength <= 0 || p.MinLength > p.MaxLength || p.MaxLength > 128 {
+		log.Fatalf("password_policy: min_length must be positive and not above max_length, max_length must be at most 128")
+	}
+
+	if !slices.Contains([]string{"argon2id", "bcrypt"}, cfg.Hasher.Algorithm) {
+		log.Fatalf("hasher.algorithm must be argon2id or bcrypt")
+	}
+
+	if cfg.Hasher.Algorithm == "bcrypt" && cfg.PasswordPolicy.MaxLength > 72 {
+		log.Fatalf("password_policy.max_length must be at most 72 with the bcrypt hasher")
+	}
+
+	if cfg.Hasher.BcryptCost < 4 || cfg.Hasher.BcryptCost > 31 {
+		log.Fatalf("hasher.bcrypt_cost must be from 4 to 31")
+	}
+
+	// the hasher rejects the hashes out of these bounds
+	if h := cfg.Hasher; h.Argon2Time == 0 || h.Argon2Time > 32 || h.Argon2Parallelism == 0 ||
+		h.Argon2Memory < 8*uint32(h.Argon2Parallelism) || h.Argon2Memory > 2*1024*1024 {
+		log.Fatalf("hasher: argon2_time must be from 1 to 32, argon2_parallelism positive, argon2_memory at least 8 KiB per thread and at most 2 GiB")
+	}
+
+	if p := cfg.PasswordPolicy; p.MinScore < 0 || p.MinScore > 4 {
+		log.Fatalf("password_policy: min_score must be from 0 to 4")
+	}
+
+	if p := cfg.PasswordPolicy; p.MinSymbol > 0 && p.Symbols == "" {
+		log.Fatalf("password_policy: symbols are required for min_symbol")
+	}
+
+	names := make(map[string]bool, len(cfg.Federation.Providers))
+	for i, p := range cfg.Federation.Providers {
+		if p.Name == "" || names[p.Name] {
+			log.Fatalf("federation.providers[%d]: name is required and must be unique", i)
+		}
+		names[p.Name] = true
+
+		if p.ClientSecretEnv != "" {
+			cfg.Federation.Providers[i].ClientSecret = os.Getenv(p.ClientSecretEnv)
+		}
+	}
+
 	return cfg
 }
 
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"slices"
+	"time"
+)
+
+// Client is an application registered to obtain tokens.
+type Client struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	// SecretHash is empty for public clients, which authenticate with PKCE only.
+	SecretHash   []byte   `json:"-"`
+	RedirectURIs []string `json:"redirect_uris"`
+	GrantTypes   []string `json:"grant_types"`
+	Scopes       []string `json:"scopes"`
+	// Audience is stamped as the aud claim of access tokens issued to the client.
+	Audience string `json:"audience"`
+	// AccessTokenTTL and RefreshTokenTTL fall back to the global settings when zero.
+	AccessTokenTTL  time.Duration `json:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `json:"refresh_token_ttl"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// IsConfidential reports whether the client has to authenticate with a secret.
+func (c Client) IsConfidential() bool {
+	return len(c.SecretHash) > 0
+}
+
+func (c Client) AllowsGrantType(grantType string) bool {
+	return slices.Contains(c.GrantTypes, grantType)
+}
+
+func (c Client) AllowsRedirectURI(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+func (c Client) AllowsScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
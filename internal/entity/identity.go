@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Identity is an account at an external provider linked to a user.
+type Identity struct {
+	// Provider is the name of the provider in the federation config.
+	Provider string `json:"provider"`
+	// Subject is the id of the account at the provider.
+	Subject string    `json:"subject"`
+	UserId  uuid.UUID `json:"user_id"`
+	// Email is the address the provider asserted when the identity was linked.
+	Email      string     `json:"email"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RecoveryCode is a one-time code that replaces the authenticator app when the device is lost.
+type RecoveryCode struct {
+	Id       uuid.UUID `json:"id"`
+	UserId   uuid.UUID `json:"user_id"`
+	CodeHash []byte    `json:"-"`
+	// UsedAt is set once the code was accepted, it is never accepted again.
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Session struct {
+	Id        uuid.UUID `json:"id"`
+	UserId    uuid.UUID `json:"user_id"`
+	Device    string    `json:"device"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	// ClientId is the OAuth client the session was started for, empty for first-party sign-ins.
+	ClientId   string    `json:"client_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+type SigningKeyState string
+
+// Life cycle of a signing key: next -> active -> retiring -> revoked.
+const (
+	// SigningKeyNext is published ahead of time but does not sign yet.
+	SigningKeyNext SigningKeyState = "next"
+	// SigningKeyActive signs new tokens. There is at most one active key.
+	SigningKeyActive SigningKeyState = "active"
+	// SigningKeyRetiring no longer signs, but tokens it signed are still accepted.
+	SigningKeyRetiring SigningKeyState = "retiring"
+	// SigningKeyRevoked is not accepted anymore.
+	SigningKeyRevoked SigningKeyState = "revoked"
+)
+
+type SigningKey struct {
+	Id        string          `json:"id"`
+	Algorithm string          `json:"algorithm"`
+	State     SigningKeyState `json:"state"`
+	// PrivateKey is the encrypted PKCS#8 private key.
+	PrivateKey  []byte     `json:"-"`
+	ActivatesAt time.Time  `json:"activates_at"`
+	RetiredAt   *time.Time `json:"retired_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
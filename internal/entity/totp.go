@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// TOTP is the authenticator app a user enrolled for two-factor authentication (RFC 6238).
+type TOTP struct {
+	UserId uuid.UUID `json:"user_id"`
+	// Secret is encrypted, the user id is bound to the ciphertext.
+	Secret []byte `json:"-"`
+	// ConfirmedAt is set once the user entered a first valid code, 2FA is enabled from then on.
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	// LastUsedStep is the time step of the last accepted code, a code is never accepted twice.
+	LastUsedStep int64     `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// IsConfirmed reports whether two-factor authentication is enabled.
+func (t TOTP) IsConfirmed() bool {
+	return t.ConfirmedAt != nil
+}
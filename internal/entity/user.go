@@ -6,12 +6,29 @@ import (
 )
 
 type User struct {
-	Id               uuid.UUID  `json:"id"`
-	Email            string     `json:"email"`
-	Name             string     `json:"name"`
-	PasswordHash     []byte     `json:"-"`
-	IsActive         bool       `json:"is_active"`
+	Id           uuid.UUID `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	PasswordHash []byte    `json:"-"`
+	IsActive     bool      `json:"is_active"`
+	// EmailVerifiedAt is set once the user proved they own the email.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// Phone is in E.164 format, it receives one-time codes once it is verified.
+	Phone           *string    `json:"phone"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
+	// SMSSecondFactor asks for a code sent to the phone at sign-in.
+	SMSSecondFactor  bool       `json:"sms_second_factor"`
 	LastLoginAttempt *time.Time `json:"-"`
 	CreatedAt        time.Time  `json:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at"`
 }
+
+// IsEmailVerified reports whether the user proved they own the email.
+func (u User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsPhoneVerified reports whether the user proved they own the phone.
+func (u User) IsPhoneVerified() bool {
+	return u.Phone != nil && u.PhoneVerifiedAt != nil
+}
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// WebAuthnCredential is a passkey or security key a user registered (WebAuthn Level 2).
+type WebAuthnCredential struct {
+	// Id is the credential id chosen by the authenticator.
+	Id     []byte    `json:"id"`
+	UserId uuid.UUID `json:"user_id"`
+	Name   string    `json:"name"`
+	// PublicKey is COSE encoded, it verifies the assertions of the authenticator.
+	PublicKey       []byte   `json:"-"`
+	AttestationType string   `json:"attestation_type"`
+	Transports      []string `json:"transports"`
+	// AAGUID identifies the authenticator model, it is all zeros when the attestation is "none".
+	AAGUID []byte `json:"aaguid"`
+	// SignCount is the last signature counter reported by the authenticator,
+	// a counter that does not grow hints at a cloned authenticator.
+	SignCount uint32 `json:"-"`
+	// BackupEligible and BackupState tell whether the credential is synced between devices.
+	BackupEligible bool       `json:"backup_eligible"`
+	BackupState    bool       `json:"backup_state"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
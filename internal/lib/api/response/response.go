@@ -2,10 +2,19 @@ package response
 
 import "errors"
 
+// Machine readable error codes, returned in the "code" field.
+const (
+	CodeRefreshTokenReused = "refresh_token_reused"
+	CodeEmailNotVerified   = "email_not_verified"
+)
+
 var (
 	ErrInternal          = errors.New("internal server error")
 	ErrInvalidAuthHeader = errors.New("invalid auth header")
 	ErrInvalidToken      = errors.New("invalid token")
+	ErrInvalidApiKey     = errors.New("invalid api key")
+	ErrUserTokenRequired = errors.New("the token must be issued to a user")
+	ErrTooManyRequests   = errors.New("too many requests, try again later")
 )
 
 type ErrResponse struct {
@@ -24,8 +33,27 @@ func Error(msg string) ErrResponse {
 	}
 }
 
+func ErrorWithCode(code, msg string) ErrResponse {
+	return ErrResponse{
+		Errors: map[string]string{"message": msg, "code": code},
+	}
+}
+
 func ErrorMap(errs map[string]string) ErrResponse {
 	return ErrResponse{
 		Errors: errs,
 	}
 }
+
+// OAuthErrResponse is the error body of the OAuth endpoints (RFC 6749, section 5.2).
+type OAuthErrResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+func OAuthError(code, description string) OAuthErrResponse {
+	return OAuthErrResponse{
+		Error:            code,
+		ErrorDescription: description,
+	}
+}
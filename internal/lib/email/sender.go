@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/smtp"
 	"strings"
+	"time"
 )
 
 const (
@@ -75,10 +76,262 @@ const (
 	</body>
 	</html>
 	`
+	verifyEmailTemplate = `
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<style>
+			body {
+				font-family: Arial, sans-serif;
+				background: #f4f6f9;
+				padding: 20px;
+			}
+	
+			.container {
+				background-color: #ffffff;
+				max-width: 600px;
+				margin: auto;
+				padding: 30px;
+				border-radius: 8px;
+				box-shadow: 0 4px 12px rgba(0, 0, 0, 0.1);
+			}
+			h2 {
+				color: #333333;
+			}
+			p {
+				color: #555555;
+				font-size: 16px;
+				line-height: 1.5;
+			}
+			.button {
+				display: inline-block;
+				margin-top: 20px;
+				padding: 12px 24px;
+				background-color: #28a745;
+				color: white;
+				text-decoration: none;
+				border-radius: 5px;
+				font-weight: bold;
+				box-shadow: 0 4px 8px rgba(0, 0, 0, 0.15);
+				transition: background-color 0.3s ease, box-shadow 0.3s ease;
+			}
+			.button:hover {
+				background-color: #218838;
+				box-shadow: 0 6px 12px rgba(0, 0, 0, 0.2);
+			}
+			.footer {
+				font-size: 12px;
+				color: #999999;
+				margin-top: 30px;
+				text-align: center;
+			}
+		</style>
+	</head>
+	<body>
+		<div class="container">
+			<h2>Confirm Your Email</h2>
+			<p>Hello,</p>
+			<p>Thanks for signing up. Click the button below to confirm your email address:</p>
+			<a href="%s" class="button">Confirm Email</a>
+			<p>If you didn't create an account, you can safely ignore this email.</p>
+			<div class="footer">
+				&copy; 2025 Your Company. All rights reserved.
+			</div>
+		</div>
+	</body>
+	</html>
+	`
+	magicLinkTemplate = `
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<style>
+			body {
+				font-family: Arial, sans-serif;
+				background: #f4f6f9;
+				padding: 20px;
+			}
+	
+			.container {
+				background-color: #ffffff;
+				max-width: 600px;
+				margin: auto;
+				padding: 30px;
+				border-radius: 8px;
+				box-shadow: 0 4px 12px rgba(0, 0, 0, 0.1);
+			}
+			h2 {
+				color: #333333;
+			}
+			p {
+				color: #555555;
+				font-size: 16px;
+				line-height: 1.5;
+			}
+			.button {
+				display: inline-block;
+				margin-top: 20px;
+				padding: 12px 24px;
+				background-color: #28a745;
+				color: white;
+				text-decoration: none;
+				border-radius: 5px;
+				font-weight: bold;
+				box-shadow: 0 4px 8px rgba(0, 0, 0, 0.15);
+				transition: background-color 0.3s ease, box-shadow 0.3s ease;
+			}
+			.button:hover {
+				background-color: #218838;
+				box-shadow: 0 6px 12px rgba(0, 0, 0, 0.2);
+			}
+			.footer {
+				font-size: 12px;
+				color: #999999;
+				margin-top: 30px;
+				text-align: center;
+			}
+		</style>
+	</head>
+	<body>
+		<div class="container">
+			<h2>Sign In</h2>
+			<p>Hello,</p>
+			<p>Click the button below to sign in. The link works once, for a short time and only in the browser you requested it from:</p>
+			<a href="%s" class="button">Sign In</a>
+			<p>If you didn't request this link, you can safely ignore this email.</p>
+			<div class="footer">
+				&copy; 2025 Your Company. All rights reserved.
+			</div>
+		</div>
+	</body>
+	</html>
+	`
+	otpTemplate = `
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<style>
+			body {
+				font-family: Arial, sans-serif;
+				background: #f4f6f9;
+				padding: 20px;
+			}
+	
+			.container {
+				background-color: #ffffff;
+				max-width: 600px;
+				margin: auto;
+				padding: 30px;
+				border-radius: 8px;
+				box-shadow: 0 4px 12px rgba(0, 0, 0, 0.1);
+			}
+			h2 {
+				color: #333333;
+			}
+			p {
+				color: #555555;
+				font-size: 16px;
+				line-height: 1.5;
+			}
+			.code {
+				font-size: 32px;
+				font-weight: bold;
+				letter-spacing: 8px;
+				color: #333333;
+			}
+			.footer {
+				font-size: 12px;
+				color: #999999;
+				margin-top: 30px;
+				text-align: center;
+			}
+		</style>
+	</head>
+	<body>
+		<div class="container">
+			<h2>Your Code</h2>
+			<p>Hello,</p>
+			<p>Enter this code in the app. It works once and for a short time:</p>
+			<p class="code">%s</p>
+			<p>If you didn't request this code, you can safely ignore this email. Never share it with anyone.</p>
+			<div class="footer">
+				&copy; 2025 Your Company. All rights reserved.
+			</div>
+		</div>
+	</body>
+	</html>
+	`
+	accountLockedTemplate = `
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<style>
+			body {
+				font-family: Arial, sans-serif;
+				background: #f4f6f9;
+				padding: 20px;
+			}
+	
+			.container {
+				background-color: #ffffff;
+				max-width: 600px;
+				margin: auto;
+				padding: 30px;
+				border-radius: 8px;
+				box-shadow: 0 4px 12px rgba(0, 0, 0, 0.1);
+			}
+			h2 {
+				color: #333333;
+			}
+			p {
+				color: #555555;
+				font-size: 16px;
+				line-height: 1.5;
+			}
+			.button {
+				display: inline-block;
+				margin-top: 20px;
+				padding: 12px 24px;
+				background-color: #28a745;
+				color: white;
+				text-decoration: none;
+				border-radius: 5px;
+				font-weight: bold;
+			}
+			.footer {
+				font-size: 12px;
+				color: #999999;
+				margin-top: 30px;
+				text-align: center;
+			}
+		</style>
+	</head>
+	<body>
+		<div class="container">
+			<h2>Sign-In Locked</h2>
+			<p>Hello,</p>
+			<p>We noticed several failed attempts to sign in to your account, so signing in with the password is locked for %d minutes.</p>
+			<p>If this was you, try again later. If it wasn't, we recommend choosing a new password:</p>
+			<a href="%s" class="button">Reset Password</a>
+			<div class="footer">
+				&copy; 2025 Your Company. All rights reserved.
+			</div>
+		</div>
+	</body>
+	</html>
+	`
 )
 
 type Sender interface {
 	SendResetPasswordEmail(toEmail, resetToken string) error
+	SendVerificationEmail(toEmail, verificationToken string) error
+	SendMagicLinkEmail(toEmail, token string) error
+	SendOTPEmail(toEmail, code string) error
+	SendAccountLockedEmail(toEmail string, lockout time.Duration) error
 }
 
 type SmtpSender struct {
@@ -110,6 +363,44 @@ func (s *SmtpSender) SendResetPasswordEmail(toEmail, resetToken string) error {
 	return smtp.SendMail(s.smtpAddr, s.auth, s.username, []string{toEmail}, msg)
 }
 
+func (s *SmtpSender) SendVerificationEmail(toEmail, verificationToken string) error {
+	link := fmt.Sprintf("%s/verify-email?token=%s", uiUrl, verificationToken)
+	subject := "Confirm Your Email"
+
+	body := fmt.Sprintf(verifyEmailTemplate, link)
+
+	msg := s.buildMessage(toEmail, subject, body)
+	return smtp.SendMail(s.smtpAddr, s.auth, s.username, []string{toEmail}, msg)
+}
+
+func (s *SmtpSender) SendMagicLinkEmail(toEmail, token string) error {
+	link := fmt.Sprintf("%s/magic-link?token=%s", uiUrl, token)
+	subject := "Your Sign-In Link"
+
+	body := fmt.Sprintf(magicLinkTemplate, link)
+
+	msg := s.buildMessage(toEmail, subject, body)
+	return smtp.SendMail(s.smtpAddr, s.auth, s.username, []string{toEmail}, msg)
+}
+
+func (s *SmtpSender) SendOTPEmail(toEmail, code string) error {
+	subject := "Your One-Time Code"
+
+	body := fmt.Sprintf(otpTemplate, code)
+
+	msg := s.buildMessage(toEmail, subject, body)
+	return smtp.SendMail(s.smtpAddr, s.auth, s.username, []string{toEmail}, msg)
+}
+
+func (s *SmtpSender) SendAccountLockedEmail(toEmail string, lockout time.Duration) error {
+	link := fmt.Sprintf("%s/reset-password", uiUrl)
+	minutes := int((lockout + time.Minute - 1) / time.Minute)
+	subject := "Sign-In Locked"
+	body := fmt.Sprintf(accountLockedTemplate, minutes, link)
+	msg := s.buildMessage(toEmail, subject, body)
+	return smtp.SendMail(s.smtpAddr, s.auth, s.username, []string{toEmail}, msg)
+}
+
 func (s *SmtpSender) buildMessage(to, subject, htmlBody string) []byte {
 	headers := make(map[string]string)
 	headers["From"] = s.username
@@ -0,0 +1,142 @@
+package jwtgen
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+// AsymmetricTokenGenerator signs access tokens with the private key of a KeySet
+// and stamps the key id into the "kid" header, so that other services can verify
+// them with the public keys only.
+// Refresh tokens are only ever read by this service and keep using HMAC.
+type AsymmetricTokenGenerator struct {
+	issuer         string
+	keys           KeySet
+	verifier       *Verifier
+	accessTokenTTL time.Duration
+
+	refreshSignKey  string
+	refreshTokenTTL time.Duration
+}
+
+func NewAsymmetricTokenGenerator(issuer string, keys KeySet, refreshSignKey string, accessTokenTTL, refreshTokenTTL time.Duration) *AsymmetricTokenGenerator {
+	return &AsymmetricTokenGenerator{
+		issuer:          issuer,
+		keys:            keys,
+		verifier:        NewVerifier(keys),
+		accessTokenTTL:  accessTokenTTL,
+		refreshSignKey:  refreshSignKey,
+		refreshTokenTTL: refreshTokenTTL,
+	}
+}
+
+func (g *AsymmetricTokenGenerator) GenerateAccessToken(user entity.User, params TokenParams) (string, error) {
+	claims := newClaims(TokenUseAccess, user, params, g.accessTokenTTL)
+	claims.Issuer = g.issuer
+
+	return g.sign(claims)
+}
+
+func (g *AsymmetricTokenGenerator) GenerateRefreshToken(user entity.User, params TokenParams) (string, error) {
+	return generateToken(g.issuer, TokenUseRefresh, user, params, g.refreshSignKey, g.refreshTokenTTL)
+}
+
+func (g *AsymmetricTokenGenerator) GenerateIDToken(user entity.User, params IDTokenParams) (string, error) {
+	return g.sign(newIDClaims(g.issuer, user, params, g.accessTokenTTL))
+}
+
+func (g *AsymmetricTokenGenerator) GenerateServiceToken(params ServiceTokenParams) (string, error) {
+	return g.sign(newServiceClaims(g.issuer, params, g.accessTokenTTL))
+}
+
+// sign signs the claims with the current signing key and stamps its kid.
+func (g *AsymmetricTokenGenerator) sign(claims jwt.Claims) (string, error) {
+	key, err := g.keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	method, err := signingMethod(key.Algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.Id
+
+	return token.SignedString(key.Private)
+}
+
+func (g *AsymmetricTokenGenerator) ParseAccessToken(tokenStr string) (*Claims, error) {
+	return g.verifier.Parse(tokenStr)
+}
+
+func (g *AsymmetricTokenGenerator) ParseRefreshToken(tokenStr string) (*Claims, error) {
+	claims, err := parseToken(tokenStr, g.refreshSignKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkRefreshTokenUse(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (g *AsymmetricTokenGenerator) PublicKeys() []VerificationKey {
+	return g.keys.PublishedKeys()
+}
+
+// Verifier checks access tokens against public keys only, ID tokens signed with the same keys are rejected.
+// It is what downstream services need to trust tokens issued by uni-auth.
+type Verifier struct {
+	keys KeySet
+}
+
+func NewVerifier(keys KeySet) *Verifier {
+	return &Verifier{keys: keys}
+}
+
+func (v *Verifier) Parse(tokenStr string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, v.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("token is invalid")
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok {
+		return nil, errors.New("failed to cast token claims")
+	}
+
+	if err = checkAccessTokenUse(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, errors.New("token has no kid header")
+	}
+
+	key, err := v.keys.VerificationKey(kid)
+	if err != nil {
+		return nil, fmt.Errorf("kid %q: %w", kid, err)
+	}
+
+	// the algorithm is bound to the key, never trust the alg header alone
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.Public, nil
+}
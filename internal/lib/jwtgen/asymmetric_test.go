@@ -0,0 +1,283 @@
+package jwtgen
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newSigner(t *testing.T, alg string) crypto.Signer {
+	t.Helper()
+
+	var (
+		key crypto.Signer
+		err error
+	)
+
+	switch alg {
+	case AlgRS256:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	}
+	require.NoError(t, err)
+
+	return key
+}
+
+func TestAsymmetricTokenGenerator(t *testing.T) {
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	for _, alg := range []string{AlgRS256, AlgES256, AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			signing := SigningKey{Id: "key-1", Algorithm: alg, Private: newSigner(t, alg)}
+
+			keys, err := NewStaticKeySet(signing)
+			require.NoError(t, err)
+
+			g := NewAsymmetricTokenGenerator("https://auth.example.com", keys, "refresh", time.Minute, time.Hour)
+
+			token, err := g.GenerateAccessToken(user, TokenParams{})
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, "key-1", parsed.Header["kid"])
+			assert.Equal(t, alg, parsed.Header["alg"])
+
+			claims, err := g.ParseAccessToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, user.Id, claims.UserId)
+			assert.NotEmpty(t, claims.ID, "every token can be revoked by its jti")
+
+			// a consumer that only holds the public key can verify the token
+			public, err := NewStaticKeySet(SigningKey{Id: "unused", Algorithm: alg, Private: newSigner(t, alg)}, signing.Public())
+			require.NoError(t, err)
+
+			claims, err = NewVerifier(public).Parse(token)
+			require.NoError(t, err)
+			assert.Equal(t, user.Email, claims.Email)
+		})
+	}
+}
+
+func TestVerifier_Parse(t *testing.T) {
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	signing := SigningKey{Id: "key-1", Algorithm: AlgES256, Private: newSigner(t, AlgES256)}
+	keys, err := NewStaticKeySet(signing)
+	require.NoError(t, err)
+
+	verifier := NewVerifier(keys)
+
+	t.Run("unknown kid", func(t *testing.T) {
+		other, err := NewStaticKeySet(SigningKey{Id: "key-2", Algorithm: AlgES256, Private: newSigner(t, AlgES256)})
+		require.NoError(t, err)
+
+		token, err := NewAsymmetricTokenGenerator("https://auth.example.com", other, "refresh", time.Minute, time.Hour).GenerateAccessToken(user, TokenParams{})
+		require.NoError(t, err)
+
+		_, err = verifier.Parse(token)
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	})
+
+	t.Run("no kid", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodES256, newClaims(TokenUseAccess, user, TokenParams{}, time.Minute)).
+			SignedString(signing.Private)
+		require.NoError(t, err)
+
+		_, err = verifier.Parse(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("algorithm confusion", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims(TokenUseAccess, user, TokenParams{}, time.Minute))
+		token.Header["kid"] = "key-1"
+
+		signed, err := token.SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = verifier.Parse(signed)
+		assert.Error(t, err)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, newClaims(TokenUseAccess, user, TokenParams{}, -time.Minute))
+		token.Header["kid"] = "key-1"
+
+		signed, err := token.SignedString(signing.Private)
+		require.NoError(t, err)
+
+		_, err = verifier.Parse(signed)
+		assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+	})
+}
+
+func TestNewStaticKeySet_KeyAlgorithmMismatch(t *testing.T) {
+	_, err := NewStaticKeySet(SigningKey{Id: "key-1", Algorithm: AlgRS256, Private: newSigner(t, AlgES256)})
+	assert.ErrorIs(t, err, ErrKeyAlgMismatch)
+}
+
+func TestAsymmetricTokenGenerator_GenerateIDToken(t *testing.T) {
+	keys, err := NewStaticKeySet(SigningKey{Id: "k1", Algorithm: AlgES256, Private: newSigner(t, AlgES256)})
+	require.NoError(t, err)
+
+	g := NewAsymmetricTokenGenerator("https://auth.example.com", keys, "refresh", time.Minute, time.Hour)
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+	authTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	token, err := g.GenerateIDToken(user, IDTokenParams{Audience: "web", Nonce: "n-0S6_WzA2Mj", AuthTime: authTime})
+	require.NoError(t, err)
+
+	claims := &IDClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, NewVerifier(keys).keyFunc,
+		jwt.WithIssuer("https://auth.example.com"),
+		jwt.WithAudience("web"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "k1", parsed.Header["kid"])
+	assert.Equal(t, user.Id.String(), claims.Subject)
+	assert.Equal(t, user.Email, claims.Email)
+	assert.Equal(t, "n-0S6_WzA2Mj", claims.Nonce)
+	assert.Equal(t, authTime, claims.AuthTime.Time)
+	assert.False(t, claims.EmailVerified)
+
+	// access tokens carry the issuer too
+	access, err := g.GenerateAccessToken(user, TokenParams{})
+	require.NoError(t, err)
+
+	accessClaims, err := g.ParseAccessToken(access)
+	require.NoError(t, err)
+	assert.Equal(t, "https://auth.example.com", accessClaims.Issuer)
+}
+
+func TestAsymmetricTokenGenerator_ClientClaims(t *testing.T) {
+	keys, err := NewStaticKeySet(SigningKey{Id: "k1", Algorithm: AlgES256, Private: newSigner(t, AlgES256)})
+	require.NoError(t, err)
+
+	g := NewAsymmetricTokenGenerator("https://auth.example.com", keys, "refresh", time.Minute, time.Hour)
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	access, err := g.GenerateAccessToken(user, TokenParams{
+		Audience:        "https://api.example.com",
+		AuthorizedParty: "example-spa",
+		TTL:             5 * time.Minute,
+	})
+	require.NoError(t, err)
+
+	claims, err := g.ParseAccessToken(access)
+	require.NoError(t, err)
+	assert.Equal(t, jwt.ClaimStrings{"https://api.example.com"}, claims.Audience)
+	assert.Equal(t, "example-spa", claims.AuthorizedParty)
+	assert.Equal(t, 5*time.Minute, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+
+	// without a client the generator's lifetime applies and no audience is stamped
+	access, err = g.GenerateAccessToken(user, TokenParams{})
+	require.NoError(t, err)
+
+	claims, err = g.ParseAccessToken(access)
+	require.NoError(t, err)
+	assert.Empty(t, claims.Audience)
+	assert.Empty(t, claims.AuthorizedParty)
+	assert.Equal(t, time.Minute, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+
+	id, err := g.GenerateIDToken(user, IDTokenParams{Audience: "example-spa", AuthorizedParty: "example-spa", TTL: 5 * time.Minute})
+	require.NoError(t, err)
+
+	idClaims := &IDClaims{}
+	_, err = jwt.ParseWithClaims(id, idClaims, NewVerifier(keys).keyFunc, jwt.WithAudience("example-spa"))
+	require.NoError(t, err)
+	assert.Equal(t, "example-spa", idClaims.AuthorizedParty)
+	assert.Equal(t, 5*time.Minute, idClaims.ExpiresAt.Sub(idClaims.IssuedAt.Time))
+}
+
+func TestAsymmetricTokenGenerator_GenerateServiceToken(t *testing.T) {
+	keys, err := NewStaticKeySet(SigningKey{Id: "k1", Algorithm: AlgES256, Private: newSigner(t, AlgES256)})
+	require.NoError(t, err)
+
+	g := NewAsymmetricTokenGenerator("https://auth.example.com", keys, "refresh", time.Minute, time.Hour)
+
+	token, err := g.GenerateServiceToken(ServiceTokenParams{
+		ClientId: "billing",
+		Audience: "https://api.example.com",
+		Scope:    "orders:read",
+		TokenId:  "jti",
+	})
+	require.NoError(t, err)
+
+	claims, err := g.ParseAccessToken(token)
+	require.NoError(t, err)
+	assert.True(t, claims.IsService())
+	assert.Equal(t, uuid.Nil, claims.UserId)
+	assert.Equal(t, "billing", claims.Subject)
+	assert.Equal(t, "billing", claims.AuthorizedParty)
+	assert.Equal(t, "orders:read", claims.Scope)
+	assert.Equal(t, jwt.ClaimStrings{"https://api.example.com"}, claims.Audience)
+	assert.Equal(t, time.Minute, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+
+	// user tokens are never service principals
+	access, err := g.GenerateAccessToken(entity.User{Id: uuid.New()}, TokenParams{AuthorizedParty: "billing"})
+	require.NoError(t, err)
+
+	claims, err = g.ParseAccessToken(access)
+	require.NoError(t, err)
+	assert.False(t, claims.IsService())
+}
+
+func TestParseAccessToken_TokenUse(t *testing.T) {
+	keys, err := NewStaticKeySet(SigningKey{Id: "k1", Algorithm: AlgES256, Private: newSigner(t, AlgES256)})
+	require.NoError(t, err)
+
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	generators := map[string]TokenGenerator{
+		"hmac":       NewJwtTokenGenerator("https://auth.example.com", "access", "refresh", time.Minute, time.Hour),
+		"asymmetric": NewAsymmetricTokenGenerator("https://auth.example.com", keys, "refresh", time.Minute, time.Hour),
+	}
+
+	for name, g := range generators {
+		t.Run(name, func(t *testing.T) {
+			// ID tokens are signed with the access key, they are never accepted as bearer tokens
+			id, err := g.GenerateIDToken(user, IDTokenParams{Audience: "web"})
+			require.NoError(t, err)
+
+			_, err = g.ParseAccessToken(id)
+			assert.ErrorIs(t, err, ErrUnexpectedTokenUse)
+
+			access, err := g.GenerateAccessToken(user, TokenParams{})
+			require.NoError(t, err)
+
+			claims, err := g.ParseAccessToken(access)
+			require.NoError(t, err)
+			assert.Equal(t, TokenUseAccess, claims.TokenUse)
+			assert.False(t, claims.IsService())
+
+			service, err := g.GenerateServiceToken(ServiceTokenParams{ClientId: "billing"})
+			require.NoError(t, err)
+
+			claims, err = g.ParseAccessToken(service)
+			require.NoError(t, err)
+			assert.True(t, claims.IsService())
+
+			refresh, err := g.GenerateRefreshToken(user, TokenParams{})
+			require.NoError(t, err)
+
+			claims, err = g.ParseRefreshToken(refresh)
+			require.NoError(t, err)
+			assert.Equal(t, TokenUseRefresh, claims.TokenUse)
+		})
+	}
+}
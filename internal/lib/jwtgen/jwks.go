@@ -0,0 +1,79 @@
+package jwtgen
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWK is the public part of a signing key as defined by RFC 7517.
+type JWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK encodes the verification key.
+func NewJWK(k VerificationKey) (JWK, error) {
+	jwk := JWK{Kid: k.Id, Alg: k.Algorithm, Use: "sig"}
+
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeBase64(pub.N.Bytes())
+		jwk.E = encodeBase64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		// coordinates are padded to the size of the curve
+		size := (pub.Curve.Params().BitSize + 7) / 8
+
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = encodeBase64(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = encodeBase64(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encodeBase64(pub)
+	default:
+		return JWK{}, ErrUnsupportedKeyType
+	}
+
+	return jwk, nil
+}
+
+// NewJWKS encodes the verification keys.
+func NewJWKS(keys []VerificationKey) (JWKS, error) {
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+
+	for _, k := range keys {
+		jwk, err := NewJWK(k)
+		if err != nil {
+			return JWKS{}, err
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set, nil
+}
+
+func encodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,83 @@
+package jwtgen
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func decodeBase64(t *testing.T, s string) []byte {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestNewJWK(t *testing.T) {
+	t.Run("RS256", func(t *testing.T) {
+		key := newSigner(t, AlgRS256)
+		jwk, err := NewJWK(SigningKey{Id: "rsa", Algorithm: AlgRS256, Private: key}.Public())
+		require.NoError(t, err)
+
+		assert.Equal(t, "RSA", jwk.Kty)
+		assert.Equal(t, "AQAB", jwk.E)
+
+		pub := key.Public().(*rsa.PublicKey)
+		assert.Equal(t, 0, pub.N.Cmp(new(big.Int).SetBytes(decodeBase64(t, jwk.N))))
+	})
+
+	t.Run("ES256", func(t *testing.T) {
+		key := newSigner(t, AlgES256)
+		jwk, err := NewJWK(SigningKey{Id: "ec", Algorithm: AlgES256, Private: key}.Public())
+		require.NoError(t, err)
+
+		assert.Equal(t, "EC", jwk.Kty)
+		assert.Equal(t, "P-256", jwk.Crv)
+
+		x, y := decodeBase64(t, jwk.X), decodeBase64(t, jwk.Y)
+		assert.Len(t, x, 32)
+		assert.Len(t, y, 32)
+
+		pub := key.Public().(*ecdsa.PublicKey)
+		assert.True(t, pub.Equal(&ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}))
+	})
+
+	t.Run("EdDSA", func(t *testing.T) {
+		key := newSigner(t, AlgEdDSA)
+		jwk, err := NewJWK(SigningKey{Id: "ed", Algorithm: AlgEdDSA, Private: key}.Public())
+		require.NoError(t, err)
+
+		assert.Equal(t, "OKP", jwk.Kty)
+		assert.Equal(t, "Ed25519", jwk.Crv)
+		assert.Equal(t, []byte(key.Public().(ed25519.PublicKey)), decodeBase64(t, jwk.X))
+	})
+}
+
+func TestKeyRing_PublishedKeys(t *testing.T) {
+	ring := NewKeyRing()
+	require.NoError(t, ring.Replace([]RingKey{
+		{SigningKey: SigningKey{Id: "retiring", Algorithm: AlgES256, Private: newSigner(t, AlgES256)}, State: entity.SigningKeyRetiring},
+		{SigningKey: SigningKey{Id: "active", Algorithm: AlgES256, Private: newSigner(t, AlgES256)}, State: entity.SigningKeyActive},
+		{SigningKey: SigningKey{Id: "next", Algorithm: AlgES256, Private: newSigner(t, AlgES256)}, State: entity.SigningKeyNext},
+		{SigningKey: SigningKey{Id: "revoked", Algorithm: AlgES256, Private: newSigner(t, AlgES256)}, State: entity.SigningKeyRevoked},
+	}))
+
+	set, err := NewJWKS(ring.PublishedKeys())
+	require.NoError(t, err)
+
+	kids := make([]string, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		kids = append(kids, k.Kid)
+	}
+	assert.Equal(t, []string{"active", "retiring"}, kids)
+}
@@ -9,21 +9,114 @@ import (
 	"time"
 )
 
+// Token uses, stamped as the token_use claim so that a token signed with a shared key is not taken for another kind.
+const (
+	TokenUseAccess  = "access"
+	TokenUseService = "service"
+	TokenUseRefresh = "refresh"
+	TokenUseID      = "id"
+)
+
+var ErrUnexpectedTokenUse = errors.New("unexpected token use")
+
 type Claims struct {
-	UserId uuid.UUID `json:"uid"`
-	Email  string    `json:"email"`
+	// TokenUse is access or service for the tokens ParseAccessToken accepts, refresh for refresh tokens.
+	TokenUse  string    `json:"token_use"`
+	UserId    uuid.UUID `json:"uid"`
+	Email     string    `json:"email"`
+	SessionId uuid.UUID `json:"sid"`
+	// AuthorizedParty is the client id the token was issued to.
+	AuthorizedParty string `json:"azp,omitempty"`
+	// Scope is the space separated list of scopes granted to a service principal.
+	Scope string `json:"scope,omitempty"`
+	// EmailVerified reports whether the user proved they own the email when the token was issued.
+	EmailVerified bool `json:"email_verified"`
 	jwt.RegisteredClaims
 }
 
+// IsService reports whether the token was issued with the client_credentials grant.
+// The subject of such a token is the client id and there is no user.
+func (c *Claims) IsService() bool {
+	return c.TokenUse == TokenUseService
+}
+
+// serviceClaims are the claims of an access token issued to a client on its own behalf.
+type serviceClaims struct {
+	TokenUse        string `json:"token_use"`
+	AuthorizedParty string `json:"azp"`
+	Scope           string `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ServiceTokenParams holds the values of an access token issued to a client on its own behalf.
+type ServiceTokenParams struct {
+	ClientId string
+	// Audience is stamped as the aud claim when it is not empty.
+	Audience string
+	Scope    string
+	TokenId  string
+	// TTL overrides the lifetime configured for the generator when it is not zero.
+	TTL time.Duration
+}
+
+// TokenParams holds the per-token values that are not taken from the user.
+type TokenParams struct {
+	SessionId uuid.UUID
+	// TokenId is stamped as the jti claim, a random id is used when it is empty.
+	TokenId string
+	// Audience and AuthorizedParty are stamped as the aud and azp claims when they are not empty.
+	Audience        string
+	AuthorizedParty string
+	EmailVerified   bool
+	// TTL overrides the lifetime configured for the generator when it is not zero.
+	TTL time.Duration
+}
+
+// IDClaims are the claims of an OpenID Connect ID token.
+type IDClaims struct {
+	TokenUse        string           `json:"token_use"`
+	Email           string           `json:"email,omitempty"`
+	EmailVerified   bool             `json:"email_verified"`
+	Nonce           string           `json:"nonce,omitempty"`
+	AuthTime        *jwt.NumericDate `json:"auth_time,omitempty"`
+	AuthorizedParty string           `json:"azp,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// IDTokenParams holds the values of an ID token that depend on the authentication.
+type IDTokenParams struct {
+	// Audience is the client id of the relying party.
+	Audience string
+	// Nonce is echoed back from the authentication request.
+	Nonce         string
+	AuthTime      time.Time
+	EmailVerified bool
+	// AuthorizedParty is the client id the ID token was issued to.
+	AuthorizedParty string
+	// TTL overrides the access token lifetime when it is not zero.
+	TTL time.Duration
+}
+
 type TokenGenerator interface {
-	GenerateAccessToken(user entity.User) (string, error)
-	GenerateRefreshToken(user entity.User) (string, error)
+	GenerateAccessToken(user entity.User, params TokenParams) (string, error)
+	GenerateRefreshToken(user entity.User, params TokenParams) (string, error)
+	// GenerateIDToken signs an ID token with the access token key and TTL.
+	GenerateIDToken(user entity.User, params IDTokenParams) (string, error)
+	// GenerateServiceToken signs an access token whose subject is a client instead of a user.
+	GenerateServiceToken(params ServiceTokenParams) (string, error)
 
 	ParseAccessToken(tokenStr string) (*Claims, error)
 	ParseRefreshToken(tokenStr string) (*Claims, error)
+
+	// PublicKeys returns the keys that verify access tokens without the ability to sign them.
+	PublicKeys() []VerificationKey
 }
 
+// JWTTokenGenerator signs every token with HS256 shared secrets. Relying parties cannot verify its ID tokens,
+// so the service signs with AsymmetricTokenGenerator.
 type JWTTokenGenerator struct {
+	issuer string
+
 	accessSignKey  string
 	accessTokenTTL time.Duration
 
@@ -31,8 +124,9 @@ type JWTTokenGenerator struct {
 	refreshTokenTTL time.Duration
 }
 
-func NewJwtTokenGenerator(accessSignKey, refreshSignKey string, accessTokenTTL, refreshTokenTTL time.Duration) *JWTTokenGenerator {
+func NewJwtTokenGenerator(issuer, accessSignKey, refreshSignKey string, accessTokenTTL, refreshTokenTTL time.Duration) *JWTTokenGenerator {
 	return &JWTTokenGenerator{
+		issuer:          issuer,
 		accessSignKey:   accessSignKey,
 		refreshSignKey:  refreshSignKey,
 		accessTokenTTL:  accessTokenTTL,
@@ -40,34 +134,170 @@ func NewJwtTokenGenerator(accessSignKey, refreshSignKey string, accessTokenTTL,
 	}
 }
 
-func (g *JWTTokenGenerator) GenerateAccessToken(user entity.User) (string, error) {
-	return generateToken(user, g.accessSignKey, g.accessTokenTTL)
+func (g *JWTTokenGenerator) GenerateAccessToken(user entity.User, params TokenParams) (string, error) {
+	return generateToken(g.issuer, TokenUseAccess, user, params, g.accessSignKey, g.accessTokenTTL)
+}
+
+func (g *JWTTokenGenerator) GenerateRefreshToken(user entity.User, params TokenParams) (string, error) {
+	return generateToken(g.issuer, TokenUseRefresh, user, params, g.refreshSignKey, g.refreshTokenTTL)
 }
 
-func (g *JWTTokenGenerator) GenerateRefreshToken(user entity.User) (string, error) {
-	return generateToken(user, g.refreshSignKey, g.refreshTokenTTL)
+func (g *JWTTokenGenerator) GenerateIDToken(user entity.User, params IDTokenParams) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, newIDClaims(g.issuer, user, params, g.accessTokenTTL))
+	return token.SignedString([]byte(g.accessSignKey))
 }
 
-func generateToken(user entity.User, secret string, ttl time.Duration) (string, error) {
+func (g *JWTTokenGenerator) GenerateServiceToken(params ServiceTokenParams) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, newServiceClaims(g.issuer, params, g.accessTokenTTL))
+	return token.SignedString([]byte(g.accessSignKey))
+}
+
+func generateToken(issuer, use string, user entity.User, params TokenParams, secret string, ttl time.Duration) (string, error) {
+	claims := newClaims(use, user, params, ttl)
+	claims.Issuer = issuer
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+func newClaims(use string, user entity.User, params TokenParams, ttl time.Duration) Claims {
+	if params.TTL != 0 {
+		ttl = params.TTL
+	}
+
 	claims := Claims{
-		UserId: user.Id,
-		Email:  user.Email,
+		TokenUse:        use,
+		UserId:          user.Id,
+		Email:           user.Email,
+		SessionId:       params.SessionId,
+		AuthorizedParty: params.AuthorizedParty,
+		EmailVerified:   params.EmailVerified,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenId(params.TokenId),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	if params.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{params.Audience}
+	}
+
+	return claims
+}
+
+func newIDClaims(issuer string, user entity.User, params IDTokenParams, ttl time.Duration) IDClaims {
+	now := time.Now()
+	if params.TTL != 0 {
+		ttl = params.TTL
+	}
+
+	claims := IDClaims{
+		TokenUse:        TokenUseID,
+		Email:           user.Email,
+		EmailVerified:   params.EmailVerified,
+		Nonce:           params.Nonce,
+		AuthorizedParty: params.AuthorizedParty,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   user.Id.String(),
+			Audience:  jwt.ClaimStrings{params.Audience},
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	if !params.AuthTime.IsZero() {
+		claims.AuthTime = jwt.NewNumericDate(params.AuthTime)
+	}
+
+	return claims
+}
+
+func newServiceClaims(issuer string, params ServiceTokenParams, ttl time.Duration) serviceClaims {
+	now := time.Now()
+	if params.TTL != 0 {
+		ttl = params.TTL
+	}
+
+	claims := serviceClaims{
+		TokenUse:        TokenUseService,
+		AuthorizedParty: params.ClientId,
+		Scope:           params.Scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   params.ClientId,
+			ID:        tokenId(params.TokenId),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	if params.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{params.Audience}
+	}
+
+	return claims
+}
+
+// tokenId gives every token a jti, so that it can be revoked before it expires.
+func tokenId(id string) string {
+	if id == "" {
+		return uuid.NewString()
+	}
+
+	return id
 }
 
 func (g *JWTTokenGenerator) ParseAccessToken(tokenStr string) (*Claims, error) {
-	return parseToken(tokenStr, g.accessSignKey)
+	claims, err := parseToken(tokenStr, g.accessSignKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkAccessTokenUse(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
 }
 
 func (g *JWTTokenGenerator) ParseRefreshToken(tokenStr string) (*Claims, error) {
-	return parseToken(tokenStr, g.refreshSignKey)
+	claims, err := parseToken(tokenStr, g.refreshSignKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkRefreshTokenUse(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// checkAccessTokenUse rejects the ID tokens, signed with the access key too, and any other kind of token.
+func checkAccessTokenUse(claims *Claims) error {
+	if claims.TokenUse != TokenUseAccess && claims.TokenUse != TokenUseService {
+		return fmt.Errorf("%w: %q", ErrUnexpectedTokenUse, claims.TokenUse)
+	}
+
+	return nil
+}
+
+// checkRefreshTokenUse also accepts the refresh tokens issued before token_use was stamped,
+// nothing else is signed with the refresh key.
+func checkRefreshTokenUse(claims *Claims) error {
+	if claims.TokenUse != TokenUseRefresh && claims.TokenUse != "" {
+		return fmt.Errorf("%w: %q", ErrUnexpectedTokenUse, claims.TokenUse)
+	}
+
+	return nil
+}
+
+// PublicKeys returns nothing, HMAC tokens can only be verified with the shared secret.
+func (g *JWTTokenGenerator) PublicKeys() []VerificationKey {
+	return nil
 }
 
 func parseToken(tokenStr string, secret string) (*Claims, error) {
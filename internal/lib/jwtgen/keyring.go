@@ -0,0 +1,113 @@
+package jwtgen
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"sort"
+	"sync"
+)
+
+var ErrNoActiveKey = errors.New("no active signing key")
+
+// RingKey is a signing key together with its life cycle state.
+type RingKey struct {
+	SigningKey
+	State entity.SigningKeyState
+}
+
+// KeyRing is a KeySet whose keys change over time.
+// The active key signs; any key that is not revoked verifies.
+// It is safe for concurrent use, the content is swapped atomically by Replace.
+type KeyRing struct {
+	mu     sync.RWMutex
+	keys   map[string]RingKey
+	active string
+}
+
+func NewKeyRing() *KeyRing {
+	return &KeyRing{keys: map[string]RingKey{}}
+}
+
+// Replace swaps the content of the ring. Revoked keys are dropped.
+func (r *KeyRing) Replace(keys []RingKey) error {
+	ring := make(map[string]RingKey, len(keys))
+	active := ""
+
+	for _, k := range keys {
+		if k.State == entity.SigningKeyRevoked {
+			continue
+		}
+
+		if err := checkKeyAlgorithm(k.Algorithm, k.Private.Public()); err != nil {
+			return fmt.Errorf("key %q: %w", k.Id, err)
+		}
+
+		if k.State == entity.SigningKeyActive {
+			if active != "" {
+				return fmt.Errorf("keys %q and %q are both active", active, k.Id)
+			}
+			active = k.Id
+		}
+
+		ring[k.Id] = k
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys = ring
+	r.active = active
+
+	return nil
+}
+
+func (r *KeyRing) SigningKey() (SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	k, ok := r.keys[r.active]
+	if !ok {
+		return SigningKey{}, ErrNoActiveKey
+	}
+
+	return k.SigningKey, nil
+}
+
+func (r *KeyRing) VerificationKey(kid string) (VerificationKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	k, ok := r.keys[kid]
+	if !ok {
+		return VerificationKey{}, ErrUnknownKey
+	}
+
+	return k.Public(), nil
+}
+
+// PublishedKeys returns the active and the retiring keys.
+// Next keys are left out until they are promoted.
+func (r *KeyRing) PublishedKeys() []VerificationKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]VerificationKey, 0, len(r.keys))
+	if k, ok := r.keys[r.active]; ok {
+		keys = append(keys, k.Public())
+	}
+
+	retiring := make([]RingKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		if k.State == entity.SigningKeyRetiring {
+			retiring = append(retiring, k)
+		}
+	}
+	sort.Slice(retiring, func(i, j int) bool { return retiring[i].Id < retiring[j].Id })
+
+	for _, k := range retiring {
+		keys = append(keys, k.Public())
+	}
+
+	return keys
+}
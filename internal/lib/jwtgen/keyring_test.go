@@ -0,0 +1,112 @@
+package jwtgen
+
+import (
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestKeyRing_Rotation(t *testing.T) {
+	ring := NewKeyRing()
+	g := NewAsymmetricTokenGenerator("https://auth.example.com", ring, "refresh_sign_key", time.Minute, time.Hour)
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	_, err := g.GenerateAccessToken(user, TokenParams{})
+	assert.ErrorIs(t, err, ErrNoActiveKey)
+
+	first := SigningKey{Id: "first", Algorithm: AlgES256, Private: newSigner(t, AlgES256)}
+	second := SigningKey{Id: "second", Algorithm: AlgEdDSA, Private: newSigner(t, AlgEdDSA)}
+
+	// the next key is published before it signs anything
+	require.NoError(t, ring.Replace([]RingKey{
+		{SigningKey: first, State: entity.SigningKeyActive},
+		{SigningKey: second, State: entity.SigningKeyNext},
+	}))
+
+	old, err := g.GenerateAccessToken(user, TokenParams{})
+	require.NoError(t, err)
+
+	require.NoError(t, ring.Replace([]RingKey{
+		{SigningKey: first, State: entity.SigningKeyRetiring},
+		{SigningKey: second, State: entity.SigningKeyActive},
+	}))
+
+	current, err := g.GenerateAccessToken(user, TokenParams{})
+	require.NoError(t, err)
+
+	key, err := ring.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "second", key.Id)
+
+	// tokens of the retiring key are still accepted
+	_, err = g.ParseAccessToken(old)
+	assert.NoError(t, err)
+	_, err = g.ParseAccessToken(current)
+	assert.NoError(t, err)
+
+	require.NoError(t, ring.Replace([]RingKey{
+		{SigningKey: first, State: entity.SigningKeyRevoked},
+		{SigningKey: second, State: entity.SigningKeyActive},
+	}))
+
+	_, err = g.ParseAccessToken(old)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	_, err = g.ParseAccessToken(current)
+	assert.NoError(t, err)
+}
+
+func TestKeyRing_Replace(t *testing.T) {
+	first := SigningKey{Id: "first", Algorithm: AlgES256, Private: newSigner(t, AlgES256)}
+	second := SigningKey{Id: "second", Algorithm: AlgES256, Private: newSigner(t, AlgES256)}
+
+	testCases := []struct {
+		name    string
+		keys    []RingKey
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			keys: []RingKey{
+				{SigningKey: first, State: entity.SigningKeyActive},
+				{SigningKey: second, State: entity.SigningKeyNext},
+			},
+		},
+		{
+			name: "two active keys",
+			keys: []RingKey{
+				{SigningKey: first, State: entity.SigningKeyActive},
+				{SigningKey: second, State: entity.SigningKeyActive},
+			},
+			wantErr: true,
+		},
+		{
+			name: "key does not match the algorithm",
+			keys: []RingKey{
+				{SigningKey: SigningKey{Id: "rsa", Algorithm: AlgRS256, Private: first.Private}, State: entity.SigningKeyActive},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ring := NewKeyRing()
+			require.NoError(t, ring.Replace([]RingKey{{SigningKey: first, State: entity.SigningKeyActive}}))
+
+			err := ring.Replace(tc.keys)
+			if tc.wantErr {
+				assert.Error(t, err)
+
+				// a failed replace keeps the previous content
+				key, err := ring.SigningKey()
+				assert.NoError(t, err)
+				assert.Equal(t, "first", key.Id)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
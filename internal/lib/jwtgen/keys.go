@@ -0,0 +1,234 @@
+package jwtgen
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+)
+
+// Supported asymmetric signing algorithms.
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnknownKey         = errors.New("unknown key id")
+	ErrUnsupportedAlg     = errors.New("unsupported signing algorithm")
+	ErrKeyAlgMismatch     = errors.New("key does not match the signing algorithm")
+	ErrNoPEMBlock         = errors.New("no PEM block found")
+	ErrUnsupportedKeyType = errors.New("unsupported key type")
+)
+
+// SigningKey is a private key used to sign access tokens.
+type SigningKey struct {
+	Id        string
+	Algorithm string
+	Private   crypto.Signer
+}
+
+// VerificationKey is the public part of a signing key.
+type VerificationKey struct {
+	Id        string
+	Algorithm string
+	Public    crypto.PublicKey
+}
+
+// Public returns the verification key of the signing key.
+func (k SigningKey) Public() VerificationKey {
+	return VerificationKey{
+		Id:        k.Id,
+		Algorithm: k.Algorithm,
+		Public:    k.Private.Public(),
+	}
+}
+
+// KeySet provides the keys used to sign and verify access tokens.
+type KeySet interface {
+	SigningKey() (SigningKey, error)
+	VerificationKey(kid string) (VerificationKey, error)
+	// PublishedKeys returns the keys that verifiers should know about, the signing key first.
+	PublishedKeys() []VerificationKey
+}
+
+// StaticKeySet is a KeySet with one signing key and a fixed list of verification keys.
+type StaticKeySet struct {
+	signing      SigningKey
+	verification map[string]VerificationKey
+	published    []VerificationKey
+}
+
+func NewStaticKeySet(signing SigningKey, verification ...VerificationKey) (*StaticKeySet, error) {
+	if err := checkKeyAlgorithm(signing.Algorithm, signing.Private.Public()); err != nil {
+		return nil, fmt.Errorf("signing key %q: %w", signing.Id, err)
+	}
+
+	ks := &StaticKeySet{
+		signing:      signing,
+		verification: map[string]VerificationKey{signing.Id: signing.Public()},
+		published:    []VerificationKey{signing.Public()},
+	}
+
+	for _, k := range verification {
+		if err := checkKeyAlgorithm(k.Algorithm, k.Public); err != nil {
+			return nil, fmt.Errorf("verification key %q: %w", k.Id, err)
+		}
+		if _, ok := ks.verification[k.Id]; ok {
+			return nil, fmt.Errorf("verification key %q: duplicate key id", k.Id)
+		}
+		ks.verification[k.Id] = k
+		ks.published = append(ks.published, k)
+	}
+
+	return ks, nil
+}
+
+func (ks *StaticKeySet) SigningKey() (SigningKey, error) {
+	return ks.signing, nil
+}
+
+func (ks *StaticKeySet) VerificationKey(kid string) (VerificationKey, error) {
+	k, ok := ks.verification[kid]
+	if !ok {
+		return VerificationKey{}, ErrUnknownKey
+	}
+
+	return k, nil
+}
+
+func (ks *StaticKeySet) PublishedKeys() []VerificationKey {
+	return ks.published
+}
+
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case AlgRS256:
+		return jwt.SigningMethodRS256, nil
+	case AlgES256:
+		return jwt.SigningMethodES256, nil
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, ErrUnsupportedAlg
+	}
+}
+
+func checkKeyAlgorithm(alg string, pub crypto.PublicKey) error {
+	var ok bool
+
+	switch alg {
+	case AlgRS256:
+		_, ok = pub.(*rsa.PublicKey)
+	case AlgES256:
+		var k *ecdsa.PublicKey
+		k, ok = pub.(*ecdsa.PublicKey)
+		ok = ok && k.Curve == elliptic.P256()
+	case AlgEdDSA:
+		_, ok = pub.(ed25519.PublicKey)
+	default:
+		return ErrUnsupportedAlg
+	}
+
+	if !ok {
+		return ErrKeyAlgMismatch
+	}
+
+	return nil
+}
+
+// AlgorithmForKey returns the signing algorithm that matches the key type.
+func AlgorithmForKey(pub crypto.PublicKey) (string, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return AlgRS256, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return "", ErrUnsupportedKeyType
+		}
+		return AlgES256, nil
+	case ed25519.PublicKey:
+		return AlgEdDSA, nil
+	default:
+		return "", ErrUnsupportedKeyType
+	}
+}
+
+// GenerateKey creates a new private key for the signing algorithm.
+func GenerateKey(alg string) (crypto.Signer, error) {
+	switch alg {
+	case AlgRS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case AlgES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, ErrUnsupportedAlg
+	}
+}
+
+// LoadPrivateKey reads a PKCS#8, PKCS#1 (RSA) or SEC 1 (EC) private key from a PEM file.
+func LoadPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePrivateKey(data)
+}
+
+// ParsePrivateKey parses a PEM encoded private key.
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrNoPEMBlock
+	}
+
+	var key any
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKeyType
+	}
+
+	return signer, nil
+}
+
+// LoadPublicKey reads a PKIX public key from a PEM file.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrNoPEMBlock
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
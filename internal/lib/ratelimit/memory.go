@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/bubalync/uni-auth/pkg/redis"
+	"sync"
+	"time"
+)
+
+const evictInterval = time.Minute
+
+// MemoryStore keeps the counters in the process, for tests and a single instance.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]counter
+	evictedAt time.Time
+	now       func() time.Time
+}
+
+type counter struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// NewMemoryStore -.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]counter),
+		now:      time.Now,
+	}
+}
+
+// Hit checks and increments the counters under one lock, like the script of redis.Cache.
+func (s *MemoryStore) Hit(_ context.Context, windows []redis.Window) ([]redis.WindowCount, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.evict(now)
+
+	allowed := true
+	counts := make([]redis.WindowCount, len(windows))
+	for i, w := range windows {
+		counts[i] = redis.WindowCount{Previous: s.count(w.Previous, now), Current: s.count(w.Current, now)}
+		if !w.Allows(counts[i]) {
+			allowed = false
+		}
+	}
+
+	if !allowed {
+		return counts, false, nil
+	}
+
+	for _, w := range windows {
+		c, ok := s.counters[w.Current]
+		if !ok || !now.Before(c.expiresAt) {
+			c = counter{expiresAt: now.Add(w.TTL)}
+		}
+		c.value++
+		s.counters[w.Current] = c
+	}
+
+	return counts, true, nil
+}
+
+// count returns the value of a counter, it is zero for a missing key.
+func (s *MemoryStore) count(key string, now time.Time) int64 {
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expiresAt) {
+		return 0
+	}
+
+	return c.value
+}
+
+// evict drops the expired counters, at most once per evictInterval.
+func (s *MemoryStore) evict(now time.Time) {
+	if now.Sub(s.evictedAt) < evictInterval {
+		return
+	}
+	s.evictedAt = now
+
+	for key, c := range s.counters {
+		if !now.Before(c.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+}
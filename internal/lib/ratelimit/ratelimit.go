@@ -0,0 +1,184 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/bubalync/uni-auth/pkg/redis"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Keys the requests are counted by.
+const (
+	KeyIP       = "ip"
+	KeyEmail    = "email"
+	KeyUserId   = "user_id"
+	KeyClientId = "client_id"
+)
+
+// Names of the response headers, see draft-ietf-httpapi-ratelimit-headers.
+const (
+	HeaderLimit      = "RateLimit-Limit"
+	HeaderRemaining  = "RateLimit-Remaining"
+	HeaderReset      = "RateLimit-Reset"
+	HeaderRetryAfter = "Retry-After"
+)
+
+const keyTemplate = "ratelimit:%s:%s:%s:%d"
+
+type (
+	// Rule allows Requests requests to a route per Window for every value of Key.
+	Rule struct {
+		// Key is ip, email, user_id or client_id.
+		Key      string
+		Requests int
+		Window   time.Duration
+	}
+
+	// Result is the state of the most restrictive rule of a route after a request.
+	Result struct {
+		Allowed   bool
+		Limit     int
+		Remaining int
+		// Reset is the time until the window has room for the next request.
+		Reset time.Duration
+	}
+
+	// Store keeps the counters of the windows, redis.Cache is one.
+	Store interface {
+		// Hit counts a hit in the current counters of the windows if every window has room for it,
+		// the check and the increments are atomic. It returns the counts of the windows before the hit.
+		Hit(ctx context.Context, windows []redis.Window) (counts []redis.WindowCount, allowed bool, err error)
+	}
+)
+
+// Limiter throttles requests with a sliding window: the count of the previous fixed window
+// is weighted by the part of it that still overlaps the sliding one.
+type Limiter struct {
+	store Store
+	// rules by route, e.g. "POST /auth/sign-up" or "/auth.v1.AuthService/ValidateToken"
+	rules map[string][]Rule
+	now   func() time.Time
+}
+
+// New -.
+func New(store Store, rules map[string][]Rule) *Limiter {
+	return &Limiter{
+		store: store,
+		rules: rules,
+		now:   time.Now,
+	}
+}
+
+// Allow counts a request to the route against every rule of the route. key returns the value a rule counts by,
+// e.g. the IP of the client, rules with an empty value are skipped. A request rejected by a rule is not counted.
+// ok is false when no rule applied to the request.
+func (l *Limiter) Allow(ctx context.Context, route string, key func(kind string) string) (res Result, ok bool, err error) {
+	now := l.now()
+
+	var (
+		rules   []Rule
+		elapsed []time.Duration
+		windows []redis.Window
+	)
+	for _, rule := range l.rules[route] {
+		value := key(rule.Key)
+		if value == "" {
+			continue
+		}
+
+		index := now.UnixNano() / int64(rule.Window)
+		e := time.Duration(now.UnixNano() % int64(rule.Window))
+
+		rules = append(rules, rule)
+		elapsed = append(elapsed, e)
+		windows = append(windows, redis.Window{
+			Previous: fmt.Sprintf(keyTemplate, route, rule.Key, value, index-1),
+			Current:  fmt.Sprintf(keyTemplate, route, rule.Key, value, index),
+			Weight:   weight(rule, e),
+			Limit:    int64(rule.Requests),
+			// the counter outlives its window, it is the previous window of the next one
+			TTL: 2 * rule.Window,
+		})
+	}
+
+	if len(windows) == 0 {
+		return Result{}, false, nil
+	}
+
+	// the counts are checked and incremented at once, so concurrent requests cannot get past a limit
+	counts, allowed, err := l.store.Hit(ctx, windows)
+	if err != nil {
+		return Result{}, false, err
+	}
+
+	for i, rule := range rules {
+		state := state(rule, counts[i].Previous, counts[i].Current, elapsed[i])
+		if !allowed {
+			if !windows[i].Allows(counts[i]) {
+				return state, true, nil
+			}
+			continue
+		}
+
+		if i == 0 || state.Remaining < res.Remaining {
+			res = state
+		}
+	}
+
+	return res, true, nil
+}
+
+// weight is the part of the previous window that still overlaps the sliding one.
+func weight(rule Rule, elapsed time.Duration) float64 {
+	return 1 - float64(elapsed)/float64(rule.Window)
+}
+
+// state is the result of a request to the window that already counts current requests
+// after previous ones in the window before.
+func state(rule Rule, previous, current int64, elapsed time.Duration) Result {
+	// the counts so far and this request
+	estimated := float64(previous)*weight(rule, elapsed) + float64(current) + 1
+	limit := float64(rule.Requests)
+
+	if estimated <= limit {
+		return Result{
+			Allowed:   true,
+			Limit:     rule.Requests,
+			Remaining: int(math.Floor(limit - estimated)),
+			Reset:     rule.Window - elapsed,
+		}
+	}
+
+	// wait until the weight of the previous window drops enough, or for the next window
+	// if the current one alone is full
+	var reset time.Duration
+	if room := limit - 1 - float64(current); room >= 0 && previous > 0 {
+		reset = time.Duration(float64(rule.Window)*(1-room/float64(previous))) - elapsed
+	} else {
+		reset = rule.Window - elapsed + time.Duration(float64(rule.Window)*max(0, 1-(limit-1)/float64(current)))
+	}
+
+	return Result{
+		Allowed: false,
+		Limit:   rule.Requests,
+		Reset:   max(reset, 0),
+	}
+}
+
+// Headers returns the rate limit headers of the result, in seconds.
+func (r Result) Headers() map[string]string {
+	reset := strconv.Itoa(int(math.Ceil(r.Reset.Seconds())))
+
+	headers := map[string]string{
+		HeaderLimit:     strconv.Itoa(r.Limit),
+		HeaderRemaining: strconv.Itoa(r.Remaining),
+		HeaderReset:     reset,
+	}
+	if !r.Allowed {
+		headers[HeaderRetryAfter] = reset
+	}
+
+	return headers
+}
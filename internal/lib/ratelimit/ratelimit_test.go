@@ -0,0 +1,221 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"github.com/bubalync/uni-auth/pkg/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const route = "POST /auth/sign-up"
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func newTestLimiter(rules ...Rule) (*Limiter, *clock) {
+	// the start of a window of every rule in the tests
+	c := &clock{t: time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)}
+
+	store := NewMemoryStore()
+	store.now = c.now
+
+	l := New(store, map[string][]Rule{route: rules})
+	l.now = c.now
+
+	return l, c
+}
+
+func keys(values map[string]string) func(string) string {
+	return func(kind string) string {
+		return values[kind]
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+	ip := keys(map[string]string{KeyIP: "192.0.2.1"})
+
+	t.Run("limit within a window", func(t *testing.T) {
+		l, c := newTestLimiter(Rule{Key: KeyIP, Requests: 3, Window: time.Minute})
+
+		for i := 2; i >= 0; i-- {
+			res, ok, err := l.Allow(ctx, route, ip)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.True(t, res.Allowed)
+			assert.Equal(t, 3, res.Limit)
+			assert.Equal(t, i, res.Remaining)
+			assert.Equal(t, time.Minute, res.Reset)
+		}
+
+		c.t = c.t.Add(15 * time.Second)
+
+		res, ok, err := l.Allow(ctx, route, ip)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.False(t, res.Allowed)
+		assert.Zero(t, res.Remaining)
+		// the next window starts in 45s and then counts 3 previous requests at the full weight,
+		// the weight drops to 2/3 in another 20s
+		assert.Equal(t, 65*time.Second, res.Reset)
+	})
+
+	t.Run("previous window slides out", func(t *testing.T) {
+		l, c := newTestLimiter(Rule{Key: KeyIP, Requests: 4, Window: time.Minute})
+
+		for range 4 {
+			res, _, err := l.Allow(ctx, route, ip)
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+		}
+
+		// a quarter of the next window, the previous 4 requests weigh 3
+		c.t = c.t.Add(75 * time.Second)
+
+		res, _, err := l.Allow(ctx, route, ip)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Zero(t, res.Remaining)
+
+		res, _, err = l.Allow(ctx, route, ip)
+		require.NoError(t, err)
+		assert.False(t, res.Allowed)
+		// 4 * (1 - e/60) + 1 + 1 <= 4 at e = 30s
+		assert.Equal(t, 15*time.Second, res.Reset)
+
+		c.t = c.t.Add(15 * time.Second)
+
+		res, _, err = l.Allow(ctx, route, ip)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	})
+
+	t.Run("keys are counted apart", func(t *testing.T) {
+		l, _ := newTestLimiter(Rule{Key: KeyIP, Requests: 1, Window: time.Minute})
+
+		res, _, err := l.Allow(ctx, route, ip)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+
+		res, _, err = l.Allow(ctx, route, keys(map[string]string{KeyIP: "192.0.2.2"}))
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+
+		res, _, err = l.Allow(ctx, route, ip)
+		require.NoError(t, err)
+		assert.False(t, res.Allowed)
+	})
+
+	t.Run("most restrictive rule", func(t *testing.T) {
+		l, _ := newTestLimiter(
+			Rule{Key: KeyIP, Requests: 10, Window: time.Minute},
+			Rule{Key: KeyEmail, Requests: 2, Window: time.Hour},
+		)
+		key := keys(map[string]string{KeyIP: "192.0.2.1", KeyEmail: "test@example.com"})
+
+		res, _, err := l.Allow(ctx, route, key)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 2, res.Limit)
+		assert.Equal(t, 1, res.Remaining)
+
+		_, _, err = l.Allow(ctx, route, key)
+		require.NoError(t, err)
+
+		res, _, err = l.Allow(ctx, route, key)
+		require.NoError(t, err)
+		assert.False(t, res.Allowed)
+		assert.Equal(t, 2, res.Limit)
+
+		// the rejected request is not counted by the ip rule
+		res, _, err = l.Allow(ctx, route, ip)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 7, res.Remaining)
+	})
+
+	t.Run("concurrent requests", func(t *testing.T) {
+		l, _ := newTestLimiter(
+			Rule{Key: KeyIP, Requests: 10, Window: time.Minute},
+			Rule{Key: KeyEmail, Requests: 20, Window: time.Hour},
+		)
+		key := keys(map[string]string{KeyIP: "192.0.2.1", KeyEmail: "test@example.com"})
+
+		var (
+			wg      sync.WaitGroup
+			allowed atomic.Int64
+		)
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, _, err := l.Allow(ctx, route, key)
+				assert.NoError(t, err)
+				if res.Allowed {
+					allowed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(10), allowed.Load())
+	})
+
+	t.Run("route without rules", func(t *testing.T) {
+		l, _ := newTestLimiter(Rule{Key: KeyIP, Requests: 1, Window: time.Minute})
+
+		_, ok, err := l.Allow(ctx, "POST /auth/sign-in", ip)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("rule without a value", func(t *testing.T) {
+		l, _ := newTestLimiter(Rule{Key: KeyEmail, Requests: 1, Window: time.Minute})
+
+		_, ok, err := l.Allow(ctx, route, ip)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		l := New(failingStore{}, map[string][]Rule{route: {{Key: KeyIP, Requests: 1, Window: time.Minute}}})
+
+		_, _, err := l.Allow(ctx, route, ip)
+		assert.Error(t, err)
+	})
+}
+
+func TestResult_Headers(t *testing.T) {
+	res := Result{Allowed: false, Limit: 5, Reset: 1500 * time.Millisecond}
+
+	assert.Equal(t, map[string]string{
+		HeaderLimit:      "5",
+		HeaderRemaining:  "0",
+		HeaderReset:      "2",
+		HeaderRetryAfter: "2",
+	}, res.Headers())
+
+	res = Result{Allowed: true, Limit: 5, Remaining: 4, Reset: time.Minute}
+
+	assert.Equal(t, map[string]string{
+		HeaderLimit:     "5",
+		HeaderRemaining: "4",
+		HeaderReset:     "60",
+	}, res.Headers())
+}
+
+type failingStore struct{}
+
+func (failingStore) Hit(context.Context, []redis.Window) ([]redis.WindowCount, bool, error) {
+	return nil, false, errors.New("some error")
+}
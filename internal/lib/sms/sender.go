@@ -0,0 +1,89 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const otpTemplate = "Your code is %s. Never share it with anyone."
+
+type Sender interface {
+	SendOTP(toPhone, code string) error
+}
+
+// ConsoleSender writes the messages instead of sending them, e.g. to stdout or a file in development.
+type ConsoleSender struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewConsoleSender(w io.Writer) *ConsoleSender {
+	return &ConsoleSender{w: w}
+}
+
+func (s *ConsoleSender) SendOTP(toPhone, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := fmt.Fprintf(s.w, "sms to %s: %s\n", toPhone, fmt.Sprintf(otpTemplate, code))
+	return err
+}
+
+// HTTPSender posts the messages to an SMS gateway as JSON {"to": "...", "message": "..."}.
+// Most providers are reached through a small adapter that accepts this request.
+type HTTPSender struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewHTTPSender(url, token string, timeout time.Duration) *HTTPSender {
+	return &HTTPSender{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+type httpMessage struct {
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
+
+func (s *HTTPSender) SendOTP(toPhone, code string) error {
+	return s.send(httpMessage{To: toPhone, Message: fmt.Sprintf(otpTemplate, code)})
+}
+
+func (s *HTTPSender) send(msg httpMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sms gateway responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConsoleSender_SendOTP(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := NewConsoleSender(&buf).SendOTP("+15550100", "123456")
+	require.NoError(t, err)
+
+	assert.Equal(t, "sms to +15550100: Your code is 123456. Never share it with anyone.\n", buf.String())
+}
+
+func TestHTTPSender_SendOTP(t *testing.T) {
+	testCases := []struct {
+		name    string
+		token   string
+		status  int
+		wantErr bool
+	}{
+		{
+			name:   "OK",
+			token:  "secret",
+			status: http.StatusOK,
+		},
+		{
+			name:   "without token",
+			status: http.StatusAccepted,
+		},
+		{
+			name:    "gateway error",
+			token:   "secret",
+			status:  http.StatusBadGateway,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				if tc.token != "" {
+					assert.Equal(t, "Bearer "+tc.token, r.Header.Get("Authorization"))
+				} else {
+					assert.Empty(t, r.Header.Get("Authorization"))
+				}
+
+				var msg httpMessage
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+				assert.Equal(t, "+15550100", msg.To)
+				assert.Contains(t, msg.Message, "123456")
+
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			err := NewHTTPSender(server.URL, tc.token, time.Second).SendOTP("+15550100", "123456")
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,324 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/auth/auth.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/auth/auth.go -destination=internal/mocks/authmocks/auth.go -package=authmocks
+//
+
+// Package authmocks is a generated GoMock package.
+package authmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entity "github.com/bubalync/uni-auth/internal/entity"
+	otp "github.com/bubalync/uni-auth/internal/service/otp"
+	passkey "github.com/bubalync/uni-auth/internal/service/passkey"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSecondFactor is a mock of SecondFactor interface.
+type MockSecondFactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockSecondFactorMockRecorder
+	isgomock struct{}
+}
+
+// MockSecondFactorMockRecorder is the mock recorder for MockSecondFactor.
+type MockSecondFactorMockRecorder struct {
+	mock *MockSecondFactor
+}
+
+// NewMockSecondFactor creates a new mock instance.
+func NewMockSecondFactor(ctrl *gomock.Controller) *MockSecondFactor {
+	mock := &MockSecondFactor{ctrl: ctrl}
+	mock.recorder = &MockSecondFactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSecondFactor) EXPECT() *MockSecondFactorMockRecorder {
+	return m.recorder
+}
+
+// Enabled mocks base method.
+func (m *MockSecondFactor) Enabled(ctx context.Context, userId uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enabled", ctx, userId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Enabled indicates an expected call of Enabled.
+func (mr *MockSecondFactorMockRecorder) Enabled(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enabled", reflect.TypeOf((*MockSecondFactor)(nil).Enabled), ctx, userId)
+}
+
+// Verify mocks base method.
+func (m *MockSecondFactor) Verify(ctx context.Context, userId uuid.UUID, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, userId, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockSecondFactorMockRecorder) Verify(ctx, userId, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockSecondFactor)(nil).Verify), ctx, userId, code)
+}
+
+// MockOneTimeCodes is a mock of OneTimeCodes interface.
+type MockOneTimeCodes struct {
+	ctrl     *gomock.Controller
+	recorder *MockOneTimeCodesMockRecorder
+	isgomock struct{}
+}
+
+// MockOneTimeCodesMockRecorder is the mock recorder for MockOneTimeCodes.
+type MockOneTimeCodesMockRecorder struct {
+	mock *MockOneTimeCodes
+}
+
+// NewMockOneTimeCodes creates a new mock instance.
+func NewMockOneTimeCodes(ctrl *gomock.Controller) *MockOneTimeCodes {
+	mock := &MockOneTimeCodes{ctrl: ctrl}
+	mock.recorder = &MockOneTimeCodesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOneTimeCodes) EXPECT() *MockOneTimeCodesMockRecorder {
+	return m.recorder
+}
+
+// SendEmail mocks base method.
+func (m *MockOneTimeCodes) SendEmail(ctx context.Context, purpose otp.Purpose, subject, to string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendEmail", ctx, purpose, subject, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendEmail indicates an expected call of SendEmail.
+func (mr *MockOneTimeCodesMockRecorder) SendEmail(ctx, purpose, subject, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendEmail", reflect.TypeOf((*MockOneTimeCodes)(nil).SendEmail), ctx, purpose, subject, to)
+}
+
+// SendSMS mocks base method.
+func (m *MockOneTimeCodes) SendSMS(ctx context.Context, purpose otp.Purpose, subject, to string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendSMS", ctx, purpose, subject, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendSMS indicates an expected call of SendSMS.
+func (mr *MockOneTimeCodesMockRecorder) SendSMS(ctx, purpose, subject, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendSMS", reflect.TypeOf((*MockOneTimeCodes)(nil).SendSMS), ctx, purpose, subject, to)
+}
+
+// Verify mocks base method.
+func (m *MockOneTimeCodes) Verify(ctx context.Context, purpose otp.Purpose, subject, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, purpose, subject, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockOneTimeCodesMockRecorder) Verify(ctx, purpose, subject, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockOneTimeCodes)(nil).Verify), ctx, purpose, subject, code)
+}
+
+// MockPasskeys is a mock of Passkeys interface.
+type MockPasskeys struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasskeysMockRecorder
+	isgomock struct{}
+}
+
+// MockPasskeysMockRecorder is the mock recorder for MockPasskeys.
+type MockPasskeysMockRecorder struct {
+	mock *MockPasskeys
+}
+
+// NewMockPasskeys creates a new mock instance.
+func NewMockPasskeys(ctrl *gomock.Controller) *MockPasskeys {
+	mock := &MockPasskeys{ctrl: ctrl}
+	mock.recorder = &MockPasskeysMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasskeys) EXPECT() *MockPasskeysMockRecorder {
+	return m.recorder
+}
+
+// BeginAssertion mocks base method.
+func (m *MockPasskeys) BeginAssertion(ctx context.Context, userId uuid.UUID) (passkey.Ceremony, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginAssertion", ctx, userId)
+	ret0, _ := ret[0].(passkey.Ceremony)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginAssertion indicates an expected call of BeginAssertion.
+func (mr *MockPasskeysMockRecorder) BeginAssertion(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginAssertion", reflect.TypeOf((*MockPasskeys)(nil).BeginAssertion), ctx, userId)
+}
+
+// FinishAssertion mocks base method.
+func (m *MockPasskeys) FinishAssertion(ctx context.Context, userId uuid.UUID, sessionId string, response []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishAssertion", ctx, userId, sessionId, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishAssertion indicates an expected call of FinishAssertion.
+func (mr *MockPasskeysMockRecorder) FinishAssertion(ctx, userId, sessionId, response any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishAssertion", reflect.TypeOf((*MockPasskeys)(nil).FinishAssertion), ctx, userId, sessionId, response)
+}
+
+// FinishLogin mocks base method.
+func (m *MockPasskeys) FinishLogin(ctx context.Context, sessionId string, response []byte) (entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishLogin", ctx, sessionId, response)
+	ret0, _ := ret[0].(entity.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishLogin indicates an expected call of FinishLogin.
+func (mr *MockPasskeysMockRecorder) FinishLogin(ctx, sessionId, response any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishLogin", reflect.TypeOf((*MockPasskeys)(nil).FinishLogin), ctx, sessionId, response)
+}
+
+// MockLockout is a mock of Lockout interface.
+type MockLockout struct {
+	ctrl     *gomock.Controller
+	recorder *MockLockoutMockRecorder
+	isgomock struct{}
+}
+
+// MockLockoutMockRecorder is the mock recorder for MockLockout.
+type MockLockoutMockRecorder struct {
+	mock *MockLockout
+}
+
+// NewMockLockout creates a new mock instance.
+func NewMockLockout(ctrl *gomock.Controller) *MockLockout {
+	mock := &MockLockout{ctrl: ctrl}
+	mock.recorder = &MockLockoutMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLockout) EXPECT() *MockLockoutMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockLockout) Check(ctx context.Context, email, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, email, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockLockoutMockRecorder) Check(ctx, email, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockLockout)(nil).Check), ctx, email, ip)
+}
+
+// Fail mocks base method.
+func (m *MockLockout) Fail(ctx context.Context, email, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", ctx, email, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fail indicates an expected call of Fail.
+func (mr *MockLockoutMockRecorder) Fail(ctx, email, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*MockLockout)(nil).Fail), ctx, email, ip)
+}
+
+// LockAccount mocks base method.
+func (m *MockLockout) LockAccount(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockAccount", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockAccount indicates an expected call of LockAccount.
+func (mr *MockLockoutMockRecorder) LockAccount(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockAccount", reflect.TypeOf((*MockLockout)(nil).LockAccount), ctx, email)
+}
+
+// Reset mocks base method.
+func (m *MockLockout) Reset(ctx context.Context, email string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Reset", ctx, email)
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockLockoutMockRecorder) Reset(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockLockout)(nil).Reset), ctx, email)
+}
+
+// MockBreachedPasswords is a mock of BreachedPasswords interface.
+type MockBreachedPasswords struct {
+	ctrl     *gomock.Controller
+	recorder *MockBreachedPasswordsMockRecorder
+	isgomock struct{}
+}
+
+// MockBreachedPasswordsMockRecorder is the mock recorder for MockBreachedPasswords.
+type MockBreachedPasswordsMockRecorder struct {
+	mock *MockBreachedPasswords
+}
+
+// NewMockBreachedPasswords creates a new mock instance.
+func NewMockBreachedPasswords(ctrl *gomock.Controller) *MockBreachedPasswords {
+	mock := &MockBreachedPasswords{ctrl: ctrl}
+	mock.recorder = &MockBreachedPasswordsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBreachedPasswords) EXPECT() *MockBreachedPasswordsMockRecorder {
+	return m.recorder
+}
+
+// Breached mocks base method.
+func (m *MockBreachedPasswords) Breached(password string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Breached", password)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Breached indicates an expected call of Breached.
+func (mr *MockBreachedPasswordsMockRecorder) Breached(password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Breached", reflect.TypeOf((*MockBreachedPasswords)(nil).Breached), password)
+}
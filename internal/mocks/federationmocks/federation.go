@@ -0,0 +1,58 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/federation/federation.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/federation/federation.go -destination=internal/mocks/federationmocks/federation.go -package=federationmocks
+//
+
+// Package federationmocks is a generated GoMock package.
+package federationmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entity "github.com/bubalync/uni-auth/internal/entity"
+	auth "github.com/bubalync/uni-auth/internal/service/auth"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuthenticator is a mock of Authenticator interface.
+type MockAuthenticator struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthenticatorMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthenticatorMockRecorder is the mock recorder for MockAuthenticator.
+type MockAuthenticatorMockRecorder struct {
+	mock *MockAuthenticator
+}
+
+// NewMockAuthenticator creates a new mock instance.
+func NewMockAuthenticator(ctrl *gomock.Controller) *MockAuthenticator {
+	mock := &MockAuthenticator{ctrl: ctrl}
+	mock.recorder = &MockAuthenticatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthenticator) EXPECT() *MockAuthenticatorMockRecorder {
+	return m.recorder
+}
+
+// SignIn mocks base method.
+func (m *MockAuthenticator) SignIn(ctx context.Context, user entity.User, input auth.IssueTokensInput) (auth.GenerateTokenOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignIn", ctx, user, input)
+	ret0, _ := ret[0].(auth.GenerateTokenOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignIn indicates an expected call of SignIn.
+func (mr *MockAuthenticatorMockRecorder) SignIn(ctx, user, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockAuthenticator)(nil).SignIn), ctx, user, input)
+}
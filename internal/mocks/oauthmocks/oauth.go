@@ -0,0 +1,146 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/oauth/oauth.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/oauth/oauth.go -destination=internal/mocks/oauthmocks/oauth.go -package=oauthmocks
+//
+
+// Package oauthmocks is a generated GoMock package.
+package oauthmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entity "github.com/bubalync/uni-auth/internal/entity"
+	auth "github.com/bubalync/uni-auth/internal/service/auth"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuthenticator is a mock of Authenticator interface.
+type MockAuthenticator struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthenticatorMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthenticatorMockRecorder is the mock recorder for MockAuthenticator.
+type MockAuthenticatorMockRecorder struct {
+	mock *MockAuthenticator
+}
+
+// NewMockAuthenticator creates a new mock instance.
+func NewMockAuthenticator(ctrl *gomock.Controller) *MockAuthenticator {
+	mock := &MockAuthenticator{ctrl: ctrl}
+	mock.recorder = &MockAuthenticatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthenticator) EXPECT() *MockAuthenticatorMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAuthenticator) Authenticate(ctx context.Context, email, password, ip string) (entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, email, password, ip)
+	ret0, _ := ret[0].(entity.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAuthenticatorMockRecorder) Authenticate(ctx, email, password, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAuthenticator)(nil).Authenticate), ctx, email, password, ip)
+}
+
+// IntrospectToken mocks base method.
+func (m *MockAuthenticator) IntrospectToken(ctx context.Context, token, hint string) (auth.TokenInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IntrospectToken", ctx, token, hint)
+	ret0, _ := ret[0].(auth.TokenInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IntrospectToken indicates an expected call of IntrospectToken.
+func (mr *MockAuthenticatorMockRecorder) IntrospectToken(ctx, token, hint any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IntrospectToken", reflect.TypeOf((*MockAuthenticator)(nil).IntrospectToken), ctx, token, hint)
+}
+
+// IssueServiceToken mocks base method.
+func (m *MockAuthenticator) IssueServiceToken(ctx context.Context, client entity.Client, scope string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueServiceToken", ctx, client, scope)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueServiceToken indicates an expected call of IssueServiceToken.
+func (mr *MockAuthenticatorMockRecorder) IssueServiceToken(ctx, client, scope any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueServiceToken", reflect.TypeOf((*MockAuthenticator)(nil).IssueServiceToken), ctx, client, scope)
+}
+
+// IssueTokens mocks base method.
+func (m *MockAuthenticator) IssueTokens(ctx context.Context, user entity.User, input auth.IssueTokensInput) (auth.GenerateTokenOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueTokens", ctx, user, input)
+	ret0, _ := ret[0].(auth.GenerateTokenOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueTokens indicates an expected call of IssueTokens.
+func (mr *MockAuthenticatorMockRecorder) IssueTokens(ctx, user, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueTokens", reflect.TypeOf((*MockAuthenticator)(nil).IssueTokens), ctx, user, input)
+}
+
+// RefreshClient mocks base method.
+func (m *MockAuthenticator) RefreshClient(ctx context.Context, token, clientId string) (auth.GenerateTokenOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshClient", ctx, token, clientId)
+	ret0, _ := ret[0].(auth.GenerateTokenOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshClient indicates an expected call of RefreshClient.
+func (mr *MockAuthenticatorMockRecorder) RefreshClient(ctx, token, clientId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshClient", reflect.TypeOf((*MockAuthenticator)(nil).RefreshClient), ctx, token, clientId)
+}
+
+// RevokeToken mocks base method.
+func (m *MockAuthenticator) RevokeToken(ctx context.Context, token, hint, clientId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, token, hint, clientId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockAuthenticatorMockRecorder) RevokeToken(ctx, token, hint, clientId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockAuthenticator)(nil).RevokeToken), ctx, token, hint, clientId)
+}
+
+// VerifySecondFactor mocks base method.
+func (m *MockAuthenticator) VerifySecondFactor(ctx context.Context, user entity.User, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifySecondFactor", ctx, user, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifySecondFactor indicates an expected call of VerifySecondFactor.
+func (mr *MockAuthenticatorMockRecorder) VerifySecondFactor(ctx, user, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySecondFactor", reflect.TypeOf((*MockAuthenticator)(nil).VerifySecondFactor), ctx, user, code)
+}
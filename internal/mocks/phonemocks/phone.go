@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/phone/phone.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/phone/phone.go -destination=internal/mocks/phonemocks/phone.go -package=phonemocks
+//
+
+// Package phonemocks is a generated GoMock package.
+package phonemocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	otp "github.com/bubalync/uni-auth/internal/service/otp"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCodes is a mock of Codes interface.
+type MockCodes struct {
+	ctrl     *gomock.Controller
+	recorder *MockCodesMockRecorder
+	isgomock struct{}
+}
+
+// MockCodesMockRecorder is the mock recorder for MockCodes.
+type MockCodesMockRecorder struct {
+	mock *MockCodes
+}
+
+// NewMockCodes creates a new mock instance.
+func NewMockCodes(ctrl *gomock.Controller) *MockCodes {
+	mock := &MockCodes{ctrl: ctrl}
+	mock.recorder = &MockCodesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCodes) EXPECT() *MockCodesMockRecorder {
+	return m.recorder
+}
+
+// SendSMS mocks base method.
+func (m *MockCodes) SendSMS(ctx context.Context, purpose otp.Purpose, subject, to string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendSMS", ctx, purpose, subject, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendSMS indicates an expected call of SendSMS.
+func (mr *MockCodesMockRecorder) SendSMS(ctx, purpose, subject, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendSMS", reflect.TypeOf((*MockCodes)(nil).SendSMS), ctx, purpose, subject, to)
+}
+
+// Verify mocks base method.
+func (m *MockCodes) Verify(ctx context.Context, purpose otp.Purpose, subject, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, purpose, subject, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockCodesMockRecorder) Verify(ctx, purpose, subject, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockCodes)(nil).Verify), ctx, purpose, subject, code)
+}
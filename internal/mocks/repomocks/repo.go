@@ -12,6 +12,7 @@ package repomocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entity "github.com/bubalync/uni-auth/internal/entity"
 	uuid "github.com/google/uuid"
@@ -70,6 +71,34 @@ func (mr *MockUserMockRecorder) Delete(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUser)(nil).Delete), ctx, id)
 }
 
+// SetPhone mocks base method.
+func (m *MockUser) SetPhone(ctx context.Context, id uuid.UUID, phone *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPhone", ctx, id, phone)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPhone indicates an expected call of SetPhone.
+func (mr *MockUserMockRecorder) SetPhone(ctx, id, phone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPhone", reflect.TypeOf((*MockUser)(nil).SetPhone), ctx, id, phone)
+}
+
+// SetSMSSecondFactor mocks base method.
+func (m *MockUser) SetSMSSecondFactor(ctx context.Context, id uuid.UUID, enabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSMSSecondFactor", ctx, id, enabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSMSSecondFactor indicates an expected call of SetSMSSecondFactor.
+func (mr *MockUserMockRecorder) SetSMSSecondFactor(ctx, id, enabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSMSSecondFactor", reflect.TypeOf((*MockUser)(nil).SetSMSSecondFactor), ctx, id, enabled)
+}
+
 // Update mocks base method.
 func (m *MockUser) Update(ctx context.Context, u entity.User) error {
 	m.ctrl.T.Helper()
@@ -156,3 +185,576 @@ func (mr *MockUserMockRecorder) UserById(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserById", reflect.TypeOf((*MockUser)(nil).UserById), ctx, id)
 }
+
+// UserByPhone mocks base method.
+func (m *MockUser) UserByPhone(ctx context.Context, phone string) (entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserByPhone", ctx, phone)
+	ret0, _ := ret[0].(entity.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserByPhone indicates an expected call of UserByPhone.
+func (mr *MockUserMockRecorder) UserByPhone(ctx, phone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserByPhone", reflect.TypeOf((*MockUser)(nil).UserByPhone), ctx, phone)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockUser) VerifyEmail(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUserMockRecorder) VerifyEmail(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUser)(nil).VerifyEmail), ctx, id)
+}
+
+// VerifyPhone mocks base method.
+func (m *MockUser) VerifyPhone(ctx context.Context, id uuid.UUID, phone string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyPhone", ctx, id, phone)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyPhone indicates an expected call of VerifyPhone.
+func (mr *MockUserMockRecorder) VerifyPhone(ctx, id, phone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyPhone", reflect.TypeOf((*MockUser)(nil).VerifyPhone), ctx, id, phone)
+}
+
+// MockSigningKey is a mock of SigningKey interface.
+type MockSigningKey struct {
+	ctrl     *gomock.Controller
+	recorder *MockSigningKeyMockRecorder
+	isgomock struct{}
+}
+
+// MockSigningKeyMockRecorder is the mock recorder for MockSigningKey.
+type MockSigningKeyMockRecorder struct {
+	mock *MockSigningKey
+}
+
+// NewMockSigningKey creates a new mock instance.
+func NewMockSigningKey(ctrl *gomock.Controller) *MockSigningKey {
+	mock := &MockSigningKey{ctrl: ctrl}
+	mock.recorder = &MockSigningKeyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSigningKey) EXPECT() *MockSigningKeyMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSigningKey) Create(ctx context.Context, k entity.SigningKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, k)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSigningKeyMockRecorder) Create(ctx, k any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSigningKey)(nil).Create), ctx, k)
+}
+
+// Keys mocks base method.
+func (m *MockSigningKey) Keys(ctx context.Context) ([]entity.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Keys", ctx)
+	ret0, _ := ret[0].([]entity.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Keys indicates an expected call of Keys.
+func (mr *MockSigningKeyMockRecorder) Keys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keys", reflect.TypeOf((*MockSigningKey)(nil).Keys), ctx)
+}
+
+// Promote mocks base method.
+func (m *MockSigningKey) Promote(ctx context.Context, id string, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Promote", ctx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Promote indicates an expected call of Promote.
+func (mr *MockSigningKeyMockRecorder) Promote(ctx, id, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Promote", reflect.TypeOf((*MockSigningKey)(nil).Promote), ctx, id, at)
+}
+
+// Revoke mocks base method.
+func (m *MockSigningKey) Revoke(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockSigningKeyMockRecorder) Revoke(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockSigningKey)(nil).Revoke), ctx, id)
+}
+
+// MockClient is a mock of Client interface.
+type MockClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientMockRecorder
+	isgomock struct{}
+}
+
+// MockClientMockRecorder is the mock recorder for MockClient.
+type MockClientMockRecorder struct {
+	mock *MockClient
+}
+
+// NewMockClient creates a new mock instance.
+func NewMockClient(ctrl *gomock.Controller) *MockClient {
+	mock := &MockClient{ctrl: ctrl}
+	mock.recorder = &MockClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClient) EXPECT() *MockClientMockRecorder {
+	return m.recorder
+}
+
+// ClientById mocks base method.
+func (m *MockClient) ClientById(ctx context.Context, id string) (entity.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientById", ctx, id)
+	ret0, _ := ret[0].(entity.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientById indicates an expected call of ClientById.
+func (mr *MockClientMockRecorder) ClientById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientById", reflect.TypeOf((*MockClient)(nil).ClientById), ctx, id)
+}
+
+// Clients mocks base method.
+func (m *MockClient) Clients(ctx context.Context) ([]entity.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clients", ctx)
+	ret0, _ := ret[0].([]entity.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Clients indicates an expected call of Clients.
+func (mr *MockClientMockRecorder) Clients(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clients", reflect.TypeOf((*MockClient)(nil).Clients), ctx)
+}
+
+// Create mocks base method.
+func (m *MockClient) Create(ctx context.Context, c entity.Client) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockClientMockRecorder) Create(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClient)(nil).Create), ctx, c)
+}
+
+// Delete mocks base method.
+func (m *MockClient) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockClientMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), ctx, id)
+}
+
+// Update mocks base method.
+func (m *MockClient) Update(ctx context.Context, c entity.Client) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockClientMockRecorder) Update(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockClient)(nil).Update), ctx, c)
+}
+
+// UpdateSecret mocks base method.
+func (m *MockClient) UpdateSecret(ctx context.Context, id string, secretHash []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSecret", ctx, id, secretHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSecret indicates an expected call of UpdateSecret.
+func (mr *MockClientMockRecorder) UpdateSecret(ctx, id, secretHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSecret", reflect.TypeOf((*MockClient)(nil).UpdateSecret), ctx, id, secretHash)
+}
+
+// MockTOTP is a mock of TOTP interface.
+type MockTOTP struct {
+	ctrl     *gomock.Controller
+	recorder *MockTOTPMockRecorder
+	isgomock struct{}
+}
+
+// MockTOTPMockRecorder is the mock recorder for MockTOTP.
+type MockTOTPMockRecorder struct {
+	mock *MockTOTP
+}
+
+// NewMockTOTP creates a new mock instance.
+func NewMockTOTP(ctrl *gomock.Controller) *MockTOTP {
+	mock := &MockTOTP{ctrl: ctrl}
+	mock.recorder = &MockTOTPMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTOTP) EXPECT() *MockTOTPMockRecorder {
+	return m.recorder
+}
+
+// Confirm mocks base method.
+func (m *MockTOTP) Confirm(ctx context.Context, userId uuid.UUID, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Confirm", ctx, userId, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Confirm indicates an expected call of Confirm.
+func (mr *MockTOTPMockRecorder) Confirm(ctx, userId, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirm", reflect.TypeOf((*MockTOTP)(nil).Confirm), ctx, userId, step)
+}
+
+// Delete mocks base method.
+func (m *MockTOTP) Delete(ctx context.Context, userId uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockTOTPMockRecorder) Delete(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTOTP)(nil).Delete), ctx, userId)
+}
+
+// TOTPByUserId mocks base method.
+func (m *MockTOTP) TOTPByUserId(ctx context.Context, userId uuid.UUID) (entity.TOTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TOTPByUserId", ctx, userId)
+	ret0, _ := ret[0].(entity.TOTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TOTPByUserId indicates an expected call of TOTPByUserId.
+func (mr *MockTOTPMockRecorder) TOTPByUserId(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TOTPByUserId", reflect.TypeOf((*MockTOTP)(nil).TOTPByUserId), ctx, userId)
+}
+
+// Upsert mocks base method.
+func (m *MockTOTP) Upsert(ctx context.Context, t entity.TOTP) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockTOTPMockRecorder) Upsert(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockTOTP)(nil).Upsert), ctx, t)
+}
+
+// UseStep mocks base method.
+func (m *MockTOTP) UseStep(ctx context.Context, userId uuid.UUID, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseStep", ctx, userId, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseStep indicates an expected call of UseStep.
+func (mr *MockTOTPMockRecorder) UseStep(ctx, userId, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseStep", reflect.TypeOf((*MockTOTP)(nil).UseStep), ctx, userId, step)
+}
+
+// MockRecoveryCode is a mock of RecoveryCode interface.
+type MockRecoveryCode struct {
+	ctrl     *gomock.Controller
+	recorder *MockRecoveryCodeMockRecorder
+	isgomock struct{}
+}
+
+// MockRecoveryCodeMockRecorder is the mock recorder for MockRecoveryCode.
+type MockRecoveryCodeMockRecorder struct {
+	mock *MockRecoveryCode
+}
+
+// NewMockRecoveryCode creates a new mock instance.
+func NewMockRecoveryCode(ctrl *gomock.Controller) *MockRecoveryCode {
+	mock := &MockRecoveryCode{ctrl: ctrl}
+	mock.recorder = &MockRecoveryCodeMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRecoveryCode) EXPECT() *MockRecoveryCodeMockRecorder {
+	return m.recorder
+}
+
+// Replace mocks base method.
+func (m *MockRecoveryCode) Replace(ctx context.Context, userId uuid.UUID, hashes [][]byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replace", ctx, userId, hashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Replace indicates an expected call of Replace.
+func (mr *MockRecoveryCodeMockRecorder) Replace(ctx, userId, hashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replace", reflect.TypeOf((*MockRecoveryCode)(nil).Replace), ctx, userId, hashes)
+}
+
+// Unused mocks base method.
+func (m *MockRecoveryCode) Unused(ctx context.Context, userId uuid.UUID) ([]entity.RecoveryCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unused", ctx, userId)
+	ret0, _ := ret[0].([]entity.RecoveryCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Unused indicates an expected call of Unused.
+func (mr *MockRecoveryCodeMockRecorder) Unused(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unused", reflect.TypeOf((*MockRecoveryCode)(nil).Unused), ctx, userId)
+}
+
+// Use mocks base method.
+func (m *MockRecoveryCode) Use(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Use", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockRecoveryCodeMockRecorder) Use(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockRecoveryCode)(nil).Use), ctx, id)
+}
+
+// MockWebAuthnCredential is a mock of WebAuthnCredential interface.
+type MockWebAuthnCredential struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebAuthnCredentialMockRecorder
+	isgomock struct{}
+}
+
+// MockWebAuthnCredentialMockRecorder is the mock recorder for MockWebAuthnCredential.
+type MockWebAuthnCredentialMockRecorder struct {
+	mock *MockWebAuthnCredential
+}
+
+// NewMockWebAuthnCredential creates a new mock instance.
+func NewMockWebAuthnCredential(ctrl *gomock.Controller) *MockWebAuthnCredential {
+	mock := &MockWebAuthnCredential{ctrl: ctrl}
+	mock.recorder = &MockWebAuthnCredentialMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebAuthnCredential) EXPECT() *MockWebAuthnCredentialMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWebAuthnCredential) Create(ctx context.Context, c entity.WebAuthnCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWebAuthnCredentialMockRecorder) Create(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWebAuthnCredential)(nil).Create), ctx, c)
+}
+
+// CredentialsByUserId mocks base method.
+func (m *MockWebAuthnCredential) CredentialsByUserId(ctx context.Context, userId uuid.UUID) ([]entity.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CredentialsByUserId", ctx, userId)
+	ret0, _ := ret[0].([]entity.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CredentialsByUserId indicates an expected call of CredentialsByUserId.
+func (mr *MockWebAuthnCredentialMockRecorder) CredentialsByUserId(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CredentialsByUserId", reflect.TypeOf((*MockWebAuthnCredential)(nil).CredentialsByUserId), ctx, userId)
+}
+
+// Delete mocks base method.
+func (m *MockWebAuthnCredential) Delete(ctx context.Context, userId uuid.UUID, id []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockWebAuthnCredentialMockRecorder) Delete(ctx, userId, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockWebAuthnCredential)(nil).Delete), ctx, userId, id)
+}
+
+// UpdateUsage mocks base method.
+func (m *MockWebAuthnCredential) UpdateUsage(ctx context.Context, id []byte, signCount uint32, backupState bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUsage", ctx, id, signCount, backupState)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUsage indicates an expected call of UpdateUsage.
+func (mr *MockWebAuthnCredentialMockRecorder) UpdateUsage(ctx, id, signCount, backupState any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUsage", reflect.TypeOf((*MockWebAuthnCredential)(nil).UpdateUsage), ctx, id, signCount, backupState)
+}
+
+// MockIdentity is a mock of Identity interface.
+type MockIdentity struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityMockRecorder
+	isgomock struct{}
+}
+
+// MockIdentityMockRecorder is the mock recorder for MockIdentity.
+type MockIdentityMockRecorder struct {
+	mock *MockIdentity
+}
+
+// NewMockIdentity creates a new mock instance.
+func NewMockIdentity(ctrl *gomock.Controller) *MockIdentity {
+	mock := &MockIdentity{ctrl: ctrl}
+	mock.recorder = &MockIdentityMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentity) EXPECT() *MockIdentityMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIdentity) Create(ctx context.Context, i entity.Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, i)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIdentityMockRecorder) Create(ctx, i any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIdentity)(nil).Create), ctx, i)
+}
+
+// Delete mocks base method.
+func (m *MockIdentity) Delete(ctx context.Context, userId uuid.UUID, provider, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userId, provider, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIdentityMockRecorder) Delete(ctx, userId, provider, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIdentity)(nil).Delete), ctx, userId, provider, subject)
+}
+
+// IdentitiesByUserId mocks base method.
+func (m *MockIdentity) IdentitiesByUserId(ctx context.Context, userId uuid.UUID) ([]entity.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdentitiesByUserId", ctx, userId)
+	ret0, _ := ret[0].([]entity.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IdentitiesByUserId indicates an expected call of IdentitiesByUserId.
+func (mr *MockIdentityMockRecorder) IdentitiesByUserId(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentitiesByUserId", reflect.TypeOf((*MockIdentity)(nil).IdentitiesByUserId), ctx, userId)
+}
+
+// IdentityBySubject mocks base method.
+func (m *MockIdentity) IdentityBySubject(ctx context.Context, provider, subject string) (entity.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdentityBySubject", ctx, provider, subject)
+	ret0, _ := ret[0].(entity.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IdentityBySubject indicates an expected call of IdentityBySubject.
+func (mr *MockIdentityMockRecorder) IdentityBySubject(ctx, provider, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentityBySubject", reflect.TypeOf((*MockIdentity)(nil).IdentityBySubject), ctx, provider, subject)
+}
+
+// UpdateLastUsed mocks base method.
+func (m *MockIdentity) UpdateLastUsed(ctx context.Context, provider, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastUsed", ctx, provider, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastUsed indicates an expected call of UpdateLastUsed.
+func (mr *MockIdentityMockRecorder) UpdateLastUsed(ctx, provider, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastUsed", reflect.TypeOf((*MockIdentity)(nil).UpdateLastUsed), ctx, provider, subject)
+}
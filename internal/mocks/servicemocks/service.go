@@ -12,10 +12,16 @@ package servicemocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entity "github.com/bubalync/uni-auth/internal/entity"
 	jwtgen "github.com/bubalync/uni-auth/internal/lib/jwtgen"
 	auth "github.com/bubalync/uni-auth/internal/service/auth"
+	client "github.com/bubalync/uni-auth/internal/service/client"
+	federation "github.com/bubalync/uni-auth/internal/service/federation"
+	mfa "github.com/bubalync/uni-auth/internal/service/mfa"
+	oauth "github.com/bubalync/uni-auth/internal/service/oauth"
+	passkey "github.com/bubalync/uni-auth/internal/service/passkey"
 	uuid "github.com/google/uuid"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -44,6 +50,21 @@ func (m *MockAuth) EXPECT() *MockAuthMockRecorder {
 	return m.recorder
 }
 
+// BeginMFAPasskey mocks base method.
+func (m *MockAuth) BeginMFAPasskey(ctx context.Context, mfaToken string) (passkey.Ceremony, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginMFAPasskey", ctx, mfaToken)
+	ret0, _ := ret[0].(passkey.Ceremony)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginMFAPasskey indicates an expected call of BeginMFAPasskey.
+func (mr *MockAuthMockRecorder) BeginMFAPasskey(ctx, mfaToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginMFAPasskey", reflect.TypeOf((*MockAuth)(nil).BeginMFAPasskey), ctx, mfaToken)
+}
+
 // CreateUser mocks base method.
 func (m *MockAuth) CreateUser(ctx context.Context, input auth.CreateUserInput) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -74,19 +95,48 @@ func (mr *MockAuthMockRecorder) GenerateToken(ctx, input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockAuth)(nil).GenerateToken), ctx, input)
 }
 
+// JWKS mocks base method.
+func (m *MockAuth) JWKS() (jwtgen.JWKS, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(jwtgen.JWKS)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockAuthMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockAuth)(nil).JWKS))
+}
+
+// Logout mocks base method.
+func (m *MockAuth) Logout(ctx context.Context, claims *jwtgen.Claims) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, claims)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAuthMockRecorder) Logout(ctx, claims any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuth)(nil).Logout), ctx, claims)
+}
+
 // ParseToken mocks base method.
-func (m *MockAuth) ParseToken(token string) (*jwtgen.Claims, error) {
+func (m *MockAuth) ParseToken(ctx context.Context, token string) (*jwtgen.Claims, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ParseToken", token)
+	ret := m.ctrl.Call(m, "ParseToken", ctx, token)
 	ret0, _ := ret[0].(*jwtgen.Claims)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ParseToken indicates an expected call of ParseToken.
-func (mr *MockAuthMockRecorder) ParseToken(token any) *gomock.Call {
+func (mr *MockAuthMockRecorder) ParseToken(ctx, token any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockAuth)(nil).ParseToken), token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockAuth)(nil).ParseToken), ctx, token)
 }
 
 // RecoveryPassword mocks base method.
@@ -103,6 +153,20 @@ func (mr *MockAuthMockRecorder) RecoveryPassword(ctx, input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoveryPassword", reflect.TypeOf((*MockAuth)(nil).RecoveryPassword), ctx, input)
 }
 
+// RecoveryPasswordBySMS mocks base method.
+func (m *MockAuth) RecoveryPasswordBySMS(ctx context.Context, input auth.SMSRecoveryPasswordInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoveryPasswordBySMS", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecoveryPasswordBySMS indicates an expected call of RecoveryPasswordBySMS.
+func (mr *MockAuthMockRecorder) RecoveryPasswordBySMS(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoveryPasswordBySMS", reflect.TypeOf((*MockAuth)(nil).RecoveryPasswordBySMS), ctx, input)
+}
+
 // Refresh mocks base method.
 func (m *MockAuth) Refresh(ctx context.Context, token string) (auth.GenerateTokenOutput, error) {
 	m.ctrl.T.Helper()
@@ -118,6 +182,35 @@ func (mr *MockAuthMockRecorder) Refresh(ctx, token any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockAuth)(nil).Refresh), ctx, token)
 }
 
+// RequestMagicLink mocks base method.
+func (m *MockAuth) RequestMagicLink(ctx context.Context, input auth.MagicLinkInput) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestMagicLink", ctx, input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestMagicLink indicates an expected call of RequestMagicLink.
+func (mr *MockAuthMockRecorder) RequestMagicLink(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestMagicLink", reflect.TypeOf((*MockAuth)(nil).RequestMagicLink), ctx, input)
+}
+
+// ResendVerification mocks base method.
+func (m *MockAuth) ResendVerification(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendVerification", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResendVerification indicates an expected call of ResendVerification.
+func (mr *MockAuthMockRecorder) ResendVerification(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerification", reflect.TypeOf((*MockAuth)(nil).ResendVerification), ctx, email)
+}
+
 // ResetPassword mocks base method.
 func (m *MockAuth) ResetPassword(ctx context.Context, input auth.ResetPasswordInput) error {
 	m.ctrl.T.Helper()
@@ -132,6 +225,208 @@ func (mr *MockAuthMockRecorder) ResetPassword(ctx, input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAuth)(nil).ResetPassword), ctx, input)
 }
 
+// ResetPasswordBySMS mocks base method.
+func (m *MockAuth) ResetPasswordBySMS(ctx context.Context, phone string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPasswordBySMS", ctx, phone)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPasswordBySMS indicates an expected call of ResetPasswordBySMS.
+func (mr *MockAuthMockRecorder) ResetPasswordBySMS(ctx, phone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPasswordBySMS", reflect.TypeOf((*MockAuth)(nil).ResetPasswordBySMS), ctx, phone)
+}
+
+// RevokeSession mocks base method.
+func (m *MockAuth) RevokeSession(ctx context.Context, userId, sessionId uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, userId, sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockAuthMockRecorder) RevokeSession(ctx, userId, sessionId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockAuth)(nil).RevokeSession), ctx, userId, sessionId)
+}
+
+// SendMFACode mocks base method.
+func (m *MockAuth) SendMFACode(ctx context.Context, mfaToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMFACode", ctx, mfaToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMFACode indicates an expected call of SendMFACode.
+func (mr *MockAuthMockRecorder) SendMFACode(ctx, mfaToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMFACode", reflect.TypeOf((*MockAuth)(nil).SendMFACode), ctx, mfaToken)
+}
+
+// SendSignInCode mocks base method.
+func (m *MockAuth) SendSignInCode(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendSignInCode", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendSignInCode indicates an expected call of SendSignInCode.
+func (mr *MockAuthMockRecorder) SendSignInCode(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendSignInCode", reflect.TypeOf((*MockAuth)(nil).SendSignInCode), ctx, email)
+}
+
+// SendStepUpCode mocks base method.
+func (m *MockAuth) SendStepUpCode(ctx context.Context, userId uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendStepUpCode", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendStepUpCode indicates an expected call of SendStepUpCode.
+func (mr *MockAuthMockRecorder) SendStepUpCode(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendStepUpCode", reflect.TypeOf((*MockAuth)(nil).SendStepUpCode), ctx, userId)
+}
+
+// SendVerificationCode mocks base method.
+func (m *MockAuth) SendVerificationCode(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerificationCode", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerificationCode indicates an expected call of SendVerificationCode.
+func (mr *MockAuthMockRecorder) SendVerificationCode(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerificationCode", reflect.TypeOf((*MockAuth)(nil).SendVerificationCode), ctx, email)
+}
+
+// Sessions mocks base method.
+func (m *MockAuth) Sessions(ctx context.Context, userId uuid.UUID) ([]entity.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sessions", ctx, userId)
+	ret0, _ := ret[0].([]entity.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sessions indicates an expected call of Sessions.
+func (mr *MockAuthMockRecorder) Sessions(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sessions", reflect.TypeOf((*MockAuth)(nil).Sessions), ctx, userId)
+}
+
+// SignInWithCode mocks base method.
+func (m *MockAuth) SignInWithCode(ctx context.Context, input auth.CodeSignInInput) (auth.GenerateTokenOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignInWithCode", ctx, input)
+	ret0, _ := ret[0].(auth.GenerateTokenOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignInWithCode indicates an expected call of SignInWithCode.
+func (mr *MockAuthMockRecorder) SignInWithCode(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignInWithCode", reflect.TypeOf((*MockAuth)(nil).SignInWithCode), ctx, input)
+}
+
+// SignInWithPasskey mocks base method.
+func (m *MockAuth) SignInWithPasskey(ctx context.Context, input auth.PasskeySignInInput) (auth.GenerateTokenOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignInWithPasskey", ctx, input)
+	ret0, _ := ret[0].(auth.GenerateTokenOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignInWithPasskey indicates an expected call of SignInWithPasskey.
+func (mr *MockAuthMockRecorder) SignInWithPasskey(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignInWithPasskey", reflect.TypeOf((*MockAuth)(nil).SignInWithPasskey), ctx, input)
+}
+
+// StepUp mocks base method.
+func (m *MockAuth) StepUp(ctx context.Context, claims *jwtgen.Claims, code string) (auth.GenerateTokenOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StepUp", ctx, claims, code)
+	ret0, _ := ret[0].(auth.GenerateTokenOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StepUp indicates an expected call of StepUp.
+func (mr *MockAuthMockRecorder) StepUp(ctx, claims, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StepUp", reflect.TypeOf((*MockAuth)(nil).StepUp), ctx, claims, code)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockAuth) VerifyEmail(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockAuthMockRecorder) VerifyEmail(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockAuth)(nil).VerifyEmail), ctx, token)
+}
+
+// VerifyEmailCode mocks base method.
+func (m *MockAuth) VerifyEmailCode(ctx context.Context, email, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmailCode", ctx, email, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmailCode indicates an expected call of VerifyEmailCode.
+func (mr *MockAuthMockRecorder) VerifyEmailCode(ctx, email, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmailCode", reflect.TypeOf((*MockAuth)(nil).VerifyEmailCode), ctx, email, code)
+}
+
+// VerifyMFA mocks base method.
+func (m *MockAuth) VerifyMFA(ctx context.Context, input auth.VerifyMFAInput) (auth.GenerateTokenOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMFA", ctx, input)
+	ret0, _ := ret[0].(auth.GenerateTokenOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFA indicates an expected call of VerifyMFA.
+func (mr *MockAuthMockRecorder) VerifyMFA(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFA", reflect.TypeOf((*MockAuth)(nil).VerifyMFA), ctx, input)
+}
+
+// VerifyMagicLink mocks base method.
+func (m *MockAuth) VerifyMagicLink(ctx context.Context, input auth.VerifyMagicLinkInput) (auth.GenerateTokenOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMagicLink", ctx, input)
+	ret0, _ := ret[0].(auth.GenerateTokenOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMagicLink indicates an expected call of VerifyMagicLink.
+func (mr *MockAuthMockRecorder) VerifyMagicLink(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMagicLink", reflect.TypeOf((*MockAuth)(nil).VerifyMagicLink), ctx, input)
+}
+
 // MockUser is a mock of User interface.
 type MockUser struct {
 	ctrl     *gomock.Controller
@@ -170,20 +465,6 @@ func (mr *MockUserMockRecorder) Delete(ctx, u any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUser)(nil).Delete), ctx, u)
 }
 
-// Logout mocks base method.
-func (m *MockUser) Logout(ctx context.Context, u entity.User) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Logout", ctx, u)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// Logout indicates an expected call of Logout.
-func (mr *MockUserMockRecorder) Logout(ctx, u any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockUser)(nil).Logout), ctx, u)
-}
-
 // Update mocks base method.
 func (m *MockUser) Update(ctx context.Context, u entity.User) error {
 	m.ctrl.T.Helper()
@@ -227,3 +508,718 @@ func (mr *MockUserMockRecorder) UserById(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserById", reflect.TypeOf((*MockUser)(nil).UserById), ctx, id)
 }
+
+// MockMFA is a mock of MFA interface.
+type MockMFA struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFAMockRecorder
+	isgomock struct{}
+}
+
+// MockMFAMockRecorder is the mock recorder for MockMFA.
+type MockMFAMockRecorder struct {
+	mock *MockMFA
+}
+
+// NewMockMFA creates a new mock instance.
+func NewMockMFA(ctrl *gomock.Controller) *MockMFA {
+	mock := &MockMFA{ctrl: ctrl}
+	mock.recorder = &MockMFAMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFA) EXPECT() *MockMFAMockRecorder {
+	return m.recorder
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockMFA) ConfirmTOTP(ctx context.Context, userId uuid.UUID, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", ctx, userId, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockMFAMockRecorder) ConfirmTOTP(ctx, userId, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockMFA)(nil).ConfirmTOTP), ctx, userId, code)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockMFA) DisableTOTP(ctx context.Context, userId uuid.UUID, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", ctx, userId, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockMFAMockRecorder) DisableTOTP(ctx, userId, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockMFA)(nil).DisableTOTP), ctx, userId, code)
+}
+
+// EnrollTOTP mocks base method.
+func (m *MockMFA) EnrollTOTP(ctx context.Context, userId uuid.UUID) (mfa.EnrollTOTPOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTOTP", ctx, userId)
+	ret0, _ := ret[0].(mfa.EnrollTOTPOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTOTP indicates an expected call of EnrollTOTP.
+func (mr *MockMFAMockRecorder) EnrollTOTP(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTOTP", reflect.TypeOf((*MockMFA)(nil).EnrollTOTP), ctx, userId)
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockMFA) RegenerateRecoveryCodes(ctx context.Context, userId uuid.UUID, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", ctx, userId, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockMFAMockRecorder) RegenerateRecoveryCodes(ctx, userId, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockMFA)(nil).RegenerateRecoveryCodes), ctx, userId, code)
+}
+
+// MockPhone is a mock of Phone interface.
+type MockPhone struct {
+	ctrl     *gomock.Controller
+	recorder *MockPhoneMockRecorder
+	isgomock struct{}
+}
+
+// MockPhoneMockRecorder is the mock recorder for MockPhone.
+type MockPhoneMockRecorder struct {
+	mock *MockPhone
+}
+
+// NewMockPhone creates a new mock instance.
+func NewMockPhone(ctrl *gomock.Controller) *MockPhone {
+	mock := &MockPhone{ctrl: ctrl}
+	mock.recorder = &MockPhoneMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPhone) EXPECT() *MockPhoneMockRecorder {
+	return m.recorder
+}
+
+// RemovePhone mocks base method.
+func (m *MockPhone) RemovePhone(ctx context.Context, userId uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemovePhone", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePhone indicates an expected call of RemovePhone.
+func (mr *MockPhoneMockRecorder) RemovePhone(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePhone", reflect.TypeOf((*MockPhone)(nil).RemovePhone), ctx, userId)
+}
+
+// SetPhone mocks base method.
+func (m *MockPhone) SetPhone(ctx context.Context, userId uuid.UUID, phone string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPhone", ctx, userId, phone)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPhone indicates an expected call of SetPhone.
+func (mr *MockPhoneMockRecorder) SetPhone(ctx, userId, phone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPhone", reflect.TypeOf((*MockPhone)(nil).SetPhone), ctx, userId, phone)
+}
+
+// SetSecondFactor mocks base method.
+func (m *MockPhone) SetSecondFactor(ctx context.Context, userId uuid.UUID, enabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSecondFactor", ctx, userId, enabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSecondFactor indicates an expected call of SetSecondFactor.
+func (mr *MockPhoneMockRecorder) SetSecondFactor(ctx, userId, enabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSecondFactor", reflect.TypeOf((*MockPhone)(nil).SetSecondFactor), ctx, userId, enabled)
+}
+
+// VerifyPhone mocks base method.
+func (m *MockPhone) VerifyPhone(ctx context.Context, userId uuid.UUID, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyPhone", ctx, userId, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyPhone indicates an expected call of VerifyPhone.
+func (mr *MockPhoneMockRecorder) VerifyPhone(ctx, userId, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyPhone", reflect.TypeOf((*MockPhone)(nil).VerifyPhone), ctx, userId, code)
+}
+
+// MockPasskey is a mock of Passkey interface.
+type MockPasskey struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasskeyMockRecorder
+	isgomock struct{}
+}
+
+// MockPasskeyMockRecorder is the mock recorder for MockPasskey.
+type MockPasskeyMockRecorder struct {
+	mock *MockPasskey
+}
+
+// NewMockPasskey creates a new mock instance.
+func NewMockPasskey(ctrl *gomock.Controller) *MockPasskey {
+	mock := &MockPasskey{ctrl: ctrl}
+	mock.recorder = &MockPasskeyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasskey) EXPECT() *MockPasskeyMockRecorder {
+	return m.recorder
+}
+
+// BeginLogin mocks base method.
+func (m *MockPasskey) BeginLogin(ctx context.Context) (passkey.Ceremony, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginLogin", ctx)
+	ret0, _ := ret[0].(passkey.Ceremony)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginLogin indicates an expected call of BeginLogin.
+func (mr *MockPasskeyMockRecorder) BeginLogin(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginLogin", reflect.TypeOf((*MockPasskey)(nil).BeginLogin), ctx)
+}
+
+// BeginRegistration mocks base method.
+func (m *MockPasskey) BeginRegistration(ctx context.Context, userId uuid.UUID) (passkey.Ceremony, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginRegistration", ctx, userId)
+	ret0, _ := ret[0].(passkey.Ceremony)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginRegistration indicates an expected call of BeginRegistration.
+func (mr *MockPasskeyMockRecorder) BeginRegistration(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginRegistration", reflect.TypeOf((*MockPasskey)(nil).BeginRegistration), ctx, userId)
+}
+
+// Credentials mocks base method.
+func (m *MockPasskey) Credentials(ctx context.Context, userId uuid.UUID) ([]entity.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Credentials", ctx, userId)
+	ret0, _ := ret[0].([]entity.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Credentials indicates an expected call of Credentials.
+func (mr *MockPasskeyMockRecorder) Credentials(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Credentials", reflect.TypeOf((*MockPasskey)(nil).Credentials), ctx, userId)
+}
+
+// Delete mocks base method.
+func (m *MockPasskey) Delete(ctx context.Context, userId uuid.UUID, id []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPasskeyMockRecorder) Delete(ctx, userId, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPasskey)(nil).Delete), ctx, userId, id)
+}
+
+// FinishRegistration mocks base method.
+func (m *MockPasskey) FinishRegistration(ctx context.Context, userId uuid.UUID, input passkey.FinishRegistrationInput) (entity.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishRegistration", ctx, userId, input)
+	ret0, _ := ret[0].(entity.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishRegistration indicates an expected call of FinishRegistration.
+func (mr *MockPasskeyMockRecorder) FinishRegistration(ctx, userId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishRegistration", reflect.TypeOf((*MockPasskey)(nil).FinishRegistration), ctx, userId, input)
+}
+
+// MockFederation is a mock of Federation interface.
+type MockFederation struct {
+	ctrl     *gomock.Controller
+	recorder *MockFederationMockRecorder
+	isgomock struct{}
+}
+
+// MockFederationMockRecorder is the mock recorder for MockFederation.
+type MockFederationMockRecorder struct {
+	mock *MockFederation
+}
+
+// NewMockFederation creates a new mock instance.
+func NewMockFederation(ctrl *gomock.Controller) *MockFederation {
+	mock := &MockFederation{ctrl: ctrl}
+	mock.recorder = &MockFederationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFederation) EXPECT() *MockFederationMockRecorder {
+	return m.recorder
+}
+
+// Begin mocks base method.
+func (m *MockFederation) Begin(ctx context.Context, input federation.BeginInput) (federation.BeginOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin", ctx, input)
+	ret0, _ := ret[0].(federation.BeginOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Begin indicates an expected call of Begin.
+func (mr *MockFederationMockRecorder) Begin(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockFederation)(nil).Begin), ctx, input)
+}
+
+// BeginLink mocks base method.
+func (m *MockFederation) BeginLink(ctx context.Context, userId uuid.UUID, provider string) (federation.BeginOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginLink", ctx, userId, provider)
+	ret0, _ := ret[0].(federation.BeginOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginLink indicates an expected call of BeginLink.
+func (mr *MockFederationMockRecorder) BeginLink(ctx, userId, provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginLink", reflect.TypeOf((*MockFederation)(nil).BeginLink), ctx, userId, provider)
+}
+
+// Callback mocks base method.
+func (m *MockFederation) Callback(ctx context.Context, input federation.CallbackInput) (federation.CallbackOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Callback", ctx, input)
+	ret0, _ := ret[0].(federation.CallbackOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Callback indicates an expected call of Callback.
+func (mr *MockFederationMockRecorder) Callback(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Callback", reflect.TypeOf((*MockFederation)(nil).Callback), ctx, input)
+}
+
+// Identities mocks base method.
+func (m *MockFederation) Identities(ctx context.Context, userId uuid.UUID) ([]entity.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Identities", ctx, userId)
+	ret0, _ := ret[0].([]entity.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Identities indicates an expected call of Identities.
+func (mr *MockFederationMockRecorder) Identities(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Identities", reflect.TypeOf((*MockFederation)(nil).Identities), ctx, userId)
+}
+
+// Providers mocks base method.
+func (m *MockFederation) Providers() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Providers")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Providers indicates an expected call of Providers.
+func (mr *MockFederationMockRecorder) Providers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Providers", reflect.TypeOf((*MockFederation)(nil).Providers))
+}
+
+// Unlink mocks base method.
+func (m *MockFederation) Unlink(ctx context.Context, userId uuid.UUID, provider, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlink", ctx, userId, provider, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlink indicates an expected call of Unlink.
+func (mr *MockFederationMockRecorder) Unlink(ctx, userId, provider, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlink", reflect.TypeOf((*MockFederation)(nil).Unlink), ctx, userId, provider, subject)
+}
+
+// MockLockout is a mock of Lockout interface.
+type MockLockout struct {
+	ctrl     *gomock.Controller
+	recorder *MockLockoutMockRecorder
+	isgomock struct{}
+}
+
+// MockLockoutMockRecorder is the mock recorder for MockLockout.
+type MockLockoutMockRecorder struct {
+	mock *MockLockout
+}
+
+// NewMockLockout creates a new mock instance.
+func NewMockLockout(ctrl *gomock.Controller) *MockLockout {
+	mock := &MockLockout{ctrl: ctrl}
+	mock.recorder = &MockLockoutMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLockout) EXPECT() *MockLockoutMockRecorder {
+	return m.recorder
+}
+
+// Unlock mocks base method.
+func (m *MockLockout) Unlock(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockLockoutMockRecorder) Unlock(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockLockout)(nil).Unlock), ctx, email)
+}
+
+// MockKeys is a mock of Keys interface.
+type MockKeys struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeysMockRecorder
+	isgomock struct{}
+}
+
+// MockKeysMockRecorder is the mock recorder for MockKeys.
+type MockKeysMockRecorder struct {
+	mock *MockKeys
+}
+
+// NewMockKeys creates a new mock instance.
+func NewMockKeys(ctrl *gomock.Controller) *MockKeys {
+	mock := &MockKeys{ctrl: ctrl}
+	mock.recorder = &MockKeysMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeys) EXPECT() *MockKeysMockRecorder {
+	return m.recorder
+}
+
+// ApplySchedule mocks base method.
+func (m *MockKeys) ApplySchedule(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplySchedule", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplySchedule indicates an expected call of ApplySchedule.
+func (mr *MockKeysMockRecorder) ApplySchedule(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplySchedule", reflect.TypeOf((*MockKeys)(nil).ApplySchedule), ctx)
+}
+
+// Keys mocks base method.
+func (m *MockKeys) Keys(ctx context.Context) ([]entity.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Keys", ctx)
+	ret0, _ := ret[0].([]entity.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Keys indicates an expected call of Keys.
+func (mr *MockKeysMockRecorder) Keys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keys", reflect.TypeOf((*MockKeys)(nil).Keys), ctx)
+}
+
+// Revoke mocks base method.
+func (m *MockKeys) Revoke(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockKeysMockRecorder) Revoke(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockKeys)(nil).Revoke), ctx, id)
+}
+
+// Rotate mocks base method.
+func (m *MockKeys) Rotate(ctx context.Context) (entity.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate", ctx)
+	ret0, _ := ret[0].(entity.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rotate indicates an expected call of Rotate.
+func (mr *MockKeysMockRecorder) Rotate(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockKeys)(nil).Rotate), ctx)
+}
+
+// Run mocks base method.
+func (m *MockKeys) Run(ctx context.Context, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx, interval)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockKeysMockRecorder) Run(ctx, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockKeys)(nil).Run), ctx, interval)
+}
+
+// MockOAuth is a mock of OAuth interface.
+type MockOAuth struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthMockRecorder
+	isgomock struct{}
+}
+
+// MockOAuthMockRecorder is the mock recorder for MockOAuth.
+type MockOAuthMockRecorder struct {
+	mock *MockOAuth
+}
+
+// NewMockOAuth creates a new mock instance.
+func NewMockOAuth(ctrl *gomock.Controller) *MockOAuth {
+	mock := &MockOAuth{ctrl: ctrl}
+	mock.recorder = &MockOAuthMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuth) EXPECT() *MockOAuthMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method.
+func (m *MockOAuth) Authorize(ctx context.Context, input oauth.AuthorizeInput) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", ctx, input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockOAuthMockRecorder) Authorize(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockOAuth)(nil).Authorize), ctx, input)
+}
+
+// Introspect mocks base method.
+func (m *MockOAuth) Introspect(ctx context.Context, req oauth.IntrospectionRequest) (auth.TokenInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Introspect", ctx, req)
+	ret0, _ := ret[0].(auth.TokenInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Introspect indicates an expected call of Introspect.
+func (mr *MockOAuthMockRecorder) Introspect(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Introspect", reflect.TypeOf((*MockOAuth)(nil).Introspect), ctx, req)
+}
+
+// Revoke mocks base method.
+func (m *MockOAuth) Revoke(ctx context.Context, req oauth.RevocationRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockOAuthMockRecorder) Revoke(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockOAuth)(nil).Revoke), ctx, req)
+}
+
+// Token mocks base method.
+func (m *MockOAuth) Token(ctx context.Context, req oauth.TokenRequest) (oauth.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Token", ctx, req)
+	ret0, _ := ret[0].(oauth.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Token indicates an expected call of Token.
+func (mr *MockOAuthMockRecorder) Token(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Token", reflect.TypeOf((*MockOAuth)(nil).Token), ctx, req)
+}
+
+// ValidateAuthorizationRequest mocks base method.
+func (m *MockOAuth) ValidateAuthorizationRequest(ctx context.Context, req oauth.AuthorizationRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateAuthorizationRequest", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateAuthorizationRequest indicates an expected call of ValidateAuthorizationRequest.
+func (mr *MockOAuthMockRecorder) ValidateAuthorizationRequest(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateAuthorizationRequest", reflect.TypeOf((*MockOAuth)(nil).ValidateAuthorizationRequest), ctx, req)
+}
+
+// MockClient is a mock of Client interface.
+type MockClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientMockRecorder
+	isgomock struct{}
+}
+
+// MockClientMockRecorder is the mock recorder for MockClient.
+type MockClientMockRecorder struct {
+	mock *MockClient
+}
+
+// NewMockClient creates a new mock instance.
+func NewMockClient(ctrl *gomock.Controller) *MockClient {
+	mock := &MockClient{ctrl: ctrl}
+	mock.recorder = &MockClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClient) EXPECT() *MockClientMockRecorder {
+	return m.recorder
+}
+
+// Client mocks base method.
+func (m *MockClient) Client(ctx context.Context, id string) (entity.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Client", ctx, id)
+	ret0, _ := ret[0].(entity.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Client indicates an expected call of Client.
+func (mr *MockClientMockRecorder) Client(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Client", reflect.TypeOf((*MockClient)(nil).Client), ctx, id)
+}
+
+// Clients mocks base method.
+func (m *MockClient) Clients(ctx context.Context) ([]entity.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clients", ctx)
+	ret0, _ := ret[0].([]entity.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Clients indicates an expected call of Clients.
+func (mr *MockClientMockRecorder) Clients(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clients", reflect.TypeOf((*MockClient)(nil).Clients), ctx)
+}
+
+// Create mocks base method.
+func (m *MockClient) Create(ctx context.Context, input client.CreateClientInput) (client.CreateClientOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, input)
+	ret0, _ := ret[0].(client.CreateClientOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockClientMockRecorder) Create(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClient)(nil).Create), ctx, input)
+}
+
+// Delete mocks base method.
+func (m *MockClient) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockClientMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), ctx, id)
+}
+
+// RotateSecret mocks base method.
+func (m *MockClient) RotateSecret(ctx context.Context, id string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSecret", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSecret indicates an expected call of RotateSecret.
+func (mr *MockClientMockRecorder) RotateSecret(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSecret", reflect.TypeOf((*MockClient)(nil).RotateSecret), ctx, id)
+}
+
+// Update mocks base method.
+func (m *MockClient) Update(ctx context.Context, input client.UpdateClientInput) (entity.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, input)
+	ret0, _ := ret[0].(entity.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockClientMockRecorder) Update(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockClient)(nil).Update), ctx, input)
+}
@@ -42,33 +42,63 @@ func (m *MockTokenGenerator) EXPECT() *MockTokenGeneratorMockRecorder {
 }
 
 // GenerateAccessToken mocks base method.
-func (m *MockTokenGenerator) GenerateAccessToken(user entity.User) (string, error) {
+func (m *MockTokenGenerator) GenerateAccessToken(user entity.User, params jwtgen.TokenParams) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateAccessToken", user)
+	ret := m.ctrl.Call(m, "GenerateAccessToken", user, params)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateAccessToken indicates an expected call of GenerateAccessToken.
-func (mr *MockTokenGeneratorMockRecorder) GenerateAccessToken(user any) *gomock.Call {
+func (mr *MockTokenGeneratorMockRecorder) GenerateAccessToken(user, params any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAccessToken", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateAccessToken), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAccessToken", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateAccessToken), user, params)
+}
+
+// GenerateIDToken mocks base method.
+func (m *MockTokenGenerator) GenerateIDToken(user entity.User, params jwtgen.IDTokenParams) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateIDToken", user, params)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateIDToken indicates an expected call of GenerateIDToken.
+func (mr *MockTokenGeneratorMockRecorder) GenerateIDToken(user, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateIDToken", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateIDToken), user, params)
 }
 
 // GenerateRefreshToken mocks base method.
-func (m *MockTokenGenerator) GenerateRefreshToken(user entity.User) (string, error) {
+func (m *MockTokenGenerator) GenerateRefreshToken(user entity.User, params jwtgen.TokenParams) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateRefreshToken", user)
+	ret := m.ctrl.Call(m, "GenerateRefreshToken", user, params)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateRefreshToken indicates an expected call of GenerateRefreshToken.
-func (mr *MockTokenGeneratorMockRecorder) GenerateRefreshToken(user any) *gomock.Call {
+func (mr *MockTokenGeneratorMockRecorder) GenerateRefreshToken(user, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRefreshToken", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateRefreshToken), user, params)
+}
+
+// GenerateServiceToken mocks base method.
+func (m *MockTokenGenerator) GenerateServiceToken(params jwtgen.ServiceTokenParams) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateServiceToken", params)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateServiceToken indicates an expected call of GenerateServiceToken.
+func (mr *MockTokenGeneratorMockRecorder) GenerateServiceToken(params any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRefreshToken", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateRefreshToken), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateServiceToken", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateServiceToken), params)
 }
 
 // ParseAccessToken mocks base method.
@@ -100,3 +130,17 @@ func (mr *MockTokenGeneratorMockRecorder) ParseRefreshToken(tokenStr any) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseRefreshToken", reflect.TypeOf((*MockTokenGenerator)(nil).ParseRefreshToken), tokenStr)
 }
+
+// PublicKeys mocks base method.
+func (m *MockTokenGenerator) PublicKeys() []jwtgen.VerificationKey {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublicKeys")
+	ret0, _ := ret[0].([]jwtgen.VerificationKey)
+	return ret0
+}
+
+// PublicKeys indicates an expected call of PublicKeys.
+func (mr *MockTokenGeneratorMockRecorder) PublicKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublicKeys", reflect.TypeOf((*MockTokenGenerator)(nil).PublicKeys))
+}
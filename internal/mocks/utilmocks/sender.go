@@ -11,6 +11,7 @@ package utilmocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -39,6 +40,48 @@ func (m *MockSender) EXPECT() *MockSenderMockRecorder {
 	return m.recorder
 }
 
+// SendAccountLockedEmail mocks base method.
+func (m *MockSender) SendAccountLockedEmail(toEmail string, lockout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendAccountLockedEmail", toEmail, lockout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendAccountLockedEmail indicates an expected call of SendAccountLockedEmail.
+func (mr *MockSenderMockRecorder) SendAccountLockedEmail(toEmail, lockout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendAccountLockedEmail", reflect.TypeOf((*MockSender)(nil).SendAccountLockedEmail), toEmail, lockout)
+}
+
+// SendMagicLinkEmail mocks base method.
+func (m *MockSender) SendMagicLinkEmail(toEmail, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMagicLinkEmail", toEmail, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMagicLinkEmail indicates an expected call of SendMagicLinkEmail.
+func (mr *MockSenderMockRecorder) SendMagicLinkEmail(toEmail, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMagicLinkEmail", reflect.TypeOf((*MockSender)(nil).SendMagicLinkEmail), toEmail, token)
+}
+
+// SendOTPEmail mocks base method.
+func (m *MockSender) SendOTPEmail(toEmail, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendOTPEmail", toEmail, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendOTPEmail indicates an expected call of SendOTPEmail.
+func (mr *MockSenderMockRecorder) SendOTPEmail(toEmail, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendOTPEmail", reflect.TypeOf((*MockSender)(nil).SendOTPEmail), toEmail, code)
+}
+
 // SendResetPasswordEmail mocks base method.
 func (m *MockSender) SendResetPasswordEmail(toEmail, resetToken string) error {
 	m.ctrl.T.Helper()
@@ -52,3 +95,17 @@ func (mr *MockSenderMockRecorder) SendResetPasswordEmail(toEmail, resetToken any
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendResetPasswordEmail", reflect.TypeOf((*MockSender)(nil).SendResetPasswordEmail), toEmail, resetToken)
 }
+
+// SendVerificationEmail mocks base method.
+func (m *MockSender) SendVerificationEmail(toEmail, verificationToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerificationEmail", toEmail, verificationToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerificationEmail indicates an expected call of SendVerificationEmail.
+func (mr *MockSenderMockRecorder) SendVerificationEmail(toEmail, verificationToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerificationEmail", reflect.TypeOf((*MockSender)(nil).SendVerificationEmail), toEmail, verificationToken)
+}
@@ -0,0 +1,54 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/lib/sms/sender.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/lib/sms/sender.go -destination=internal/mocks/utilmocks/sms.go -package=utilmocks -mock_names=Sender=MockSMSSender
+//
+
+// Package utilmocks is a generated GoMock package.
+package utilmocks
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSMSSender is a mock of Sender interface.
+type MockSMSSender struct {
+	ctrl     *gomock.Controller
+	recorder *MockSMSSenderMockRecorder
+	isgomock struct{}
+}
+
+// MockSMSSenderMockRecorder is the mock recorder for MockSMSSender.
+type MockSMSSenderMockRecorder struct {
+	mock *MockSMSSender
+}
+
+// NewMockSMSSender creates a new mock instance.
+func NewMockSMSSender(ctrl *gomock.Controller) *MockSMSSender {
+	mock := &MockSMSSender{ctrl: ctrl}
+	mock.recorder = &MockSMSSenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSMSSender) EXPECT() *MockSMSSenderMockRecorder {
+	return m.recorder
+}
+
+// SendOTP mocks base method.
+func (m *MockSMSSender) SendOTP(toPhone, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendOTP", toPhone, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendOTP indicates an expected call of SendOTP.
+func (mr *MockSMSSenderMockRecorder) SendOTP(toPhone, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendOTP", reflect.TypeOf((*MockSMSSender)(nil).SendOTP), toPhone, code)
+}
@@ -0,0 +1,224 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+)
+
+const clientColumns = "id, name, secret_hash, redirect_uris, grant_types, scopes, audience, " +
+	"access_token_ttl, refresh_token_ttl, created_at, updated_at"
+
+type ClientRepo struct {
+	*postgres.Postgres
+}
+
+func NewClientRepo(pg *postgres.Postgres) *ClientRepo {
+	return &ClientRepo{pg}
+}
+
+func (r *ClientRepo) Create(ctx context.Context, c entity.Client) error {
+	const op = "repo.persistent.client.Create"
+
+	sql, args, _ := r.Builder.
+		Insert("clients").
+		Columns("id, name, secret_hash, redirect_uris, grant_types, scopes, audience, access_token_ttl, refresh_token_ttl").
+		Values(
+			c.Id,
+			c.Name,
+			c.SecretHash,
+			orEmpty(c.RedirectURIs),
+			orEmpty(c.GrantTypes),
+			orEmpty(c.Scopes),
+			c.Audience,
+			seconds(c.AccessTokenTTL),
+			seconds(c.RefreshTokenTTL),
+		).
+		ToSql()
+
+	_, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if ok := errors.As(err, &pgErr); ok {
+			if pgErr.ConstraintName == "clients_pkey" {
+				return repoErrs.ErrAlreadyExists
+			}
+		}
+
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	return nil
+}
+
+// Update replaces the settings of the client, the secret is kept.
+func (r *ClientRepo) Update(ctx context.Context, c entity.Client) error {
+	const op = "repo.persistent.client.Update"
+
+	sql, args, _ := r.Builder.
+		Update("clients").
+		Set("name", c.Name).
+		Set("redirect_uris", orEmpty(c.RedirectURIs)).
+		Set("grant_types", orEmpty(c.GrantTypes)).
+		Set("scopes", orEmpty(c.Scopes)).
+		Set("audience", c.Audience).
+		Set("access_token_ttl", seconds(c.AccessTokenTTL)).
+		Set("refresh_token_ttl", seconds(c.RefreshTokenTTL)).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where("id = ?", c.Id).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ClientRepo) UpdateSecret(ctx context.Context, id string, secretHash []byte) error {
+	const op = "repo.persistent.client.UpdateSecret"
+
+	sql, args, _ := r.Builder.
+		Update("clients").
+		Set("secret_hash", secretHash).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where("id = ?", id).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ClientRepo) Delete(ctx context.Context, id string) error {
+	const op = "repo.persistent.client.Delete"
+
+	sql, args, _ := r.Builder.
+		Delete("clients").
+		Where("id = ?", id).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ClientRepo) ClientById(ctx context.Context, id string) (entity.Client, error) {
+	const op = "repo.persistent.client.ClientById"
+
+	sql, args, _ := r.Builder.
+		Select(clientColumns).
+		From("clients").
+		Where("id = ?", id).
+		ToSql()
+
+	c, err := scanClient(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Client{}, repoErrs.ErrNotFound
+		}
+		return entity.Client{}, fmt.Errorf("%s: r.Pool.QueryRow: %w", op, err)
+	}
+
+	return c, nil
+}
+
+func (r *ClientRepo) Clients(ctx context.Context) ([]entity.Client, error) {
+	const op = "repo.persistent.client.Clients"
+
+	sql, args, _ := r.Builder.
+		Select(clientColumns).
+		From("clients").
+		OrderBy("created_at").
+		ToSql()
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: r.Pool.Query: %w", op, err)
+	}
+	defer rows.Close()
+
+	var clients []entity.Client
+	for rows.Next() {
+		c, err := scanClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: rows.Scan: %w", op, err)
+		}
+
+		clients = append(clients, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows.Err: %w", op, err)
+	}
+
+	return clients, nil
+}
+
+func scanClient(row pgx.Row) (entity.Client, error) {
+	var (
+		c                               entity.Client
+		accessTokenTTL, refreshTokenTTL int64
+	)
+
+	err := row.Scan(
+		&c.Id,
+		&c.Name,
+		&c.SecretHash,
+		&c.RedirectURIs,
+		&c.GrantTypes,
+		&c.Scopes,
+		&c.Audience,
+		&accessTokenTTL,
+		&refreshTokenTTL,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return entity.Client{}, err
+	}
+
+	c.AccessTokenTTL = time.Duration(accessTokenTTL) * time.Second
+	c.RefreshTokenTTL = time.Duration(refreshTokenTTL) * time.Second
+
+	return c, nil
+}
+
+func seconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
+
+// orEmpty keeps NOT NULL array columns from receiving a nil slice, which pgx encodes as NULL.
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
@@ -0,0 +1,259 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/pkg/postgres"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newClientRepoMock(poolMock pgxmock.PgxPoolIface) *ClientRepo {
+	return NewClientRepo(&postgres.Postgres{
+		Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		Pool:    poolMock,
+	})
+}
+
+var testClient = entity.Client{
+	Id:              "example-spa",
+	Name:            "Example SPA",
+	SecretHash:      []byte("hash"),
+	RedirectURIs:    []string{"http://localhost:3000/callback"},
+	GrantTypes:      []string{"authorization_code"},
+	Scopes:          []string{"openid", "email"},
+	Audience:        "https://api.example.com",
+	AccessTokenTTL:  15 * time.Minute,
+	RefreshTokenTTL: 24 * time.Hour,
+}
+
+func TestClientRepo_Create(t *testing.T) {
+	c := testClient
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO clients").
+					WithArgs(c.Id, c.Name, c.SecretHash, c.RedirectURIs, c.GrantTypes, c.Scopes, c.Audience, int64(900), int64(86400)).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+		},
+		{
+			name: "client already exists",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO clients").
+					WithArgs(c.Id, c.Name, c.SecretHash, c.RedirectURIs, c.GrantTypes, c.Scopes, c.Audience, int64(900), int64(86400)).
+					WillReturnError(&pgconn.PgError{ConstraintName: "clients_pkey"})
+			},
+			wantErr: repoErrs.ErrAlreadyExists,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO clients").
+					WithArgs(c.Id, c.Name, c.SecretHash, c.RedirectURIs, c.GrantTypes, c.Scopes, c.Audience, int64(900), int64(86400)).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newClientRepoMock(poolMock).Create(context.Background(), c)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestClientRepo_Update(t *testing.T) {
+	c := testClient
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE clients SET name = \\$1, redirect_uris = \\$2, grant_types = \\$3, scopes = \\$4, "+
+					"audience = \\$5, access_token_ttl = \\$6, refresh_token_ttl = \\$7, updated_at = NOW\\(\\) WHERE id = \\$8").
+					WithArgs(c.Name, c.RedirectURIs, c.GrantTypes, c.Scopes, c.Audience, int64(900), int64(86400), c.Id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "client not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE clients").
+					WithArgs(c.Name, c.RedirectURIs, c.GrantTypes, c.Scopes, c.Audience, int64(900), int64(86400), c.Id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newClientRepoMock(poolMock).Update(context.Background(), c)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestClientRepo_Delete(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM clients WHERE id = \\$1").
+					WithArgs("example-spa").
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+		},
+		{
+			name: "client not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM clients").
+					WithArgs("example-spa").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newClientRepoMock(poolMock).Delete(context.Background(), "example-spa")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestClientRepo_ClientById(t *testing.T) {
+	createdAt := time.Now()
+	columns := []string{
+		"id", "name", "secret_hash", "redirect_uris", "grant_types", "scopes", "audience",
+		"access_token_ttl", "refresh_token_ttl", "created_at", "updated_at",
+	}
+
+	want := testClient
+	want.CreatedAt = createdAt
+	want.UpdatedAt = createdAt
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         entity.Client
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows(columns).AddRow(
+					want.Id, want.Name, want.SecretHash, want.RedirectURIs, want.GrantTypes, want.Scopes, want.Audience,
+					int64(900), int64(86400), createdAt, createdAt,
+				)
+
+				m.ExpectQuery("SELECT (.+) FROM clients WHERE id = \\$1").
+					WithArgs(want.Id).
+					WillReturnRows(rows)
+			},
+			want: want,
+		},
+		{
+			name: "client not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM clients").
+					WithArgs(want.Id).
+					WillReturnRows(pgxmock.NewRows(columns))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM clients").
+					WithArgs(want.Id).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			got, err := newClientRepoMock(poolMock).ClientById(context.Background(), want.Id)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
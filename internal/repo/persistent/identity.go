@@ -0,0 +1,156 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type IdentityRepo struct {
+	*postgres.Postgres
+}
+
+func NewIdentityRepo(pg *postgres.Postgres) *IdentityRepo {
+	return &IdentityRepo{pg}
+}
+
+func (r *IdentityRepo) Create(ctx context.Context, i entity.Identity) error {
+	const op = "repo.persistent.identity.Create"
+
+	sql, args, _ := r.Builder.
+		Insert("identities").
+		Columns("provider, subject, user_id, email").
+		Values(i.Provider, i.Subject, i.UserId, i.Email).
+		ToSql()
+
+	_, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if ok := errors.As(err, &pgErr); ok {
+			if pgErr.ConstraintName == "identities_pkey" {
+				return repoErrs.ErrAlreadyExists
+			}
+		}
+
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *IdentityRepo) IdentityBySubject(ctx context.Context, provider, subject string) (entity.Identity, error) {
+	const op = "repo.persistent.identity.IdentityBySubject"
+
+	sql, args, _ := r.Builder.
+		Select("provider, subject, user_id, email, last_used_at, created_at").
+		From("identities").
+		Where("provider = ? AND subject = ?", provider, subject).
+		ToSql()
+
+	var i entity.Identity
+	err := r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&i.Provider,
+		&i.Subject,
+		&i.UserId,
+		&i.Email,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Identity{}, repoErrs.ErrNotFound
+		}
+		return entity.Identity{}, fmt.Errorf("%s: r.Pool.QueryRow: %w", op, err)
+	}
+
+	return i, nil
+}
+
+func (r *IdentityRepo) IdentitiesByUserId(ctx context.Context, userId uuid.UUID) ([]entity.Identity, error) {
+	const op = "repo.persistent.identity.IdentitiesByUserId"
+
+	sql, args, _ := r.Builder.
+		Select("provider, subject, user_id, email, last_used_at, created_at").
+		From("identities").
+		Where("user_id = ?", userId).
+		OrderBy("created_at").
+		ToSql()
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: r.Pool.Query: %w", op, err)
+	}
+	defer rows.Close()
+
+	var identities []entity.Identity
+	for rows.Next() {
+		var i entity.Identity
+		err = rows.Scan(
+			&i.Provider,
+			&i.Subject,
+			&i.UserId,
+			&i.Email,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: rows.Scan: %w", op, err)
+		}
+		identities = append(identities, i)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows.Err: %w", op, err)
+	}
+
+	return identities, nil
+}
+
+// UpdateLastUsed records a sign-in with the identity.
+func (r *IdentityRepo) UpdateLastUsed(ctx context.Context, provider, subject string) error {
+	const op = "repo.persistent.identity.UpdateLastUsed"
+
+	sql, args, _ := r.Builder.
+		Update("identities").
+		Set("last_used_at", squirrel.Expr("NOW()")).
+		Where("provider = ? AND subject = ?", provider, subject).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *IdentityRepo) Delete(ctx context.Context, userId uuid.UUID, provider, subject string) error {
+	const op = "repo.persistent.identity.Delete"
+
+	sql, args, _ := r.Builder.
+		Delete("identities").
+		Where("provider = ? AND subject = ? AND user_id = ?", provider, subject, userId).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,321 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newIdentityRepoMock(poolMock pgxmock.PgxPoolIface) *IdentityRepo {
+	return NewIdentityRepo(&postgres.Postgres{
+		Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		Pool:    poolMock,
+	})
+}
+
+func TestIdentityRepo_Create(t *testing.T) {
+	identity := entity.Identity{
+		Provider: "google",
+		Subject:  "1234567890",
+		UserId:   uuid.New(),
+		Email:    "test@example.com",
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO identities \\(provider, subject, user_id, email\\) VALUES \\(\\$1,\\$2,\\$3,\\$4\\)").
+					WithArgs(identity.Provider, identity.Subject, identity.UserId, identity.Email).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+		},
+		{
+			name: "already linked",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO identities").
+					WithArgs(identity.Provider, identity.Subject, identity.UserId, identity.Email).
+					WillReturnError(&pgconn.PgError{ConstraintName: "identities_pkey"})
+			},
+			wantErr: repoErrs.ErrAlreadyExists,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO identities").
+					WithArgs(identity.Provider, identity.Subject, identity.UserId, identity.Email).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newIdentityRepoMock(poolMock).Create(context.Background(), identity)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestIdentityRepo_IdentityBySubject(t *testing.T) {
+	identity := entity.Identity{
+		Provider:  "google",
+		Subject:   "1234567890",
+		UserId:    uuid.New(),
+		Email:     "test@example.com",
+		CreatedAt: time.Now(),
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         entity.Identity
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"provider", "subject", "user_id", "email", "last_used_at", "created_at"}).
+					AddRow(identity.Provider, identity.Subject, identity.UserId, identity.Email, identity.LastUsedAt, identity.CreatedAt)
+
+				m.ExpectQuery("SELECT (.+) FROM identities WHERE provider = \\$1 AND subject = \\$2").
+					WithArgs(identity.Provider, identity.Subject).
+					WillReturnRows(rows)
+			},
+			want: identity,
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM identities").
+					WithArgs(identity.Provider, identity.Subject).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM identities").
+					WithArgs(identity.Provider, identity.Subject).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			got, err := newIdentityRepoMock(poolMock).IdentityBySubject(context.Background(), identity.Provider, identity.Subject)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestIdentityRepo_IdentitiesByUserId(t *testing.T) {
+	userId := uuid.New()
+	identity := entity.Identity{
+		Provider:  "google",
+		Subject:   "1234567890",
+		UserId:    userId,
+		Email:     "test@example.com",
+		CreatedAt: time.Now(),
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         []entity.Identity
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"provider", "subject", "user_id", "email", "last_used_at", "created_at"}).
+					AddRow(identity.Provider, identity.Subject, identity.UserId, identity.Email, identity.LastUsedAt, identity.CreatedAt)
+
+				m.ExpectQuery("SELECT (.+) FROM identities WHERE user_id = \\$1 ORDER BY created_at").
+					WithArgs(userId).
+					WillReturnRows(rows)
+			},
+			want: []entity.Identity{identity},
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM identities").
+					WithArgs(userId).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			got, err := newIdentityRepoMock(poolMock).IdentitiesByUserId(context.Background(), userId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestIdentityRepo_UpdateLastUsed(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE identities SET last_used_at = NOW\\(\\) WHERE provider = \\$1 AND subject = \\$2").
+					WithArgs("google", "1234567890").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE identities").
+					WithArgs("google", "1234567890").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newIdentityRepoMock(poolMock).UpdateLastUsed(context.Background(), "google", "1234567890")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestIdentityRepo_Delete(t *testing.T) {
+	userId := uuid.New()
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM identities WHERE provider = \\$1 AND subject = \\$2 AND user_id = \\$3").
+					WithArgs("google", "1234567890", userId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM identities").
+					WithArgs("google", "1234567890", userId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM identities").
+					WithArgs("google", "1234567890", userId).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newIdentityRepoMock(poolMock).Delete(context.Background(), userId, "google", "1234567890")
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
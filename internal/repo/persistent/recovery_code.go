@@ -0,0 +1,116 @@
+package persistent
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/pkg/postgres"
+	"github.com/google/uuid"
+)
+
+type RecoveryCodeRepo struct {
+	*postgres.Postgres
+}
+
+func NewRecoveryCodeRepo(pg *postgres.Postgres) *RecoveryCodeRepo {
+	return &RecoveryCodeRepo{pg}
+}
+
+// Replace drops all codes of the user and saves the new set in one transaction.
+func (r *RecoveryCodeRepo) Replace(ctx context.Context, userId uuid.UUID, hashes [][]byte) error {
+	const op = "repo.persistent.recovery_code.Replace"
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Begin: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ := r.Builder.
+		Delete("user_recovery_codes").
+		Where("user_id = ?", userId).
+		ToSql()
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: tx.Exec: %w", op, err)
+	}
+
+	if len(hashes) > 0 {
+		insert := r.Builder.
+			Insert("user_recovery_codes").
+			Columns("user_id, code_hash")
+		for _, hash := range hashes {
+			insert = insert.Values(userId, hash)
+		}
+
+		sql, args, _ = insert.ToSql()
+
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("%s: tx.Exec: %w", op, err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: tx.Commit: %w", op, err)
+	}
+
+	return nil
+}
+
+// Unused returns the codes of the user that can still be used.
+func (r *RecoveryCodeRepo) Unused(ctx context.Context, userId uuid.UUID) ([]entity.RecoveryCode, error) {
+	const op = "repo.persistent.recovery_code.Unused"
+
+	sql, args, _ := r.Builder.
+		Select("id, user_id, code_hash, used_at, created_at").
+		From("user_recovery_codes").
+		Where("user_id = ? AND used_at IS NULL", userId).
+		OrderBy("created_at").
+		ToSql()
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: r.Pool.Query: %w", op, err)
+	}
+	defer rows.Close()
+
+	var codes []entity.RecoveryCode
+	for rows.Next() {
+		var c entity.RecoveryCode
+		if err = rows.Scan(&c.Id, &c.UserId, &c.CodeHash, &c.UsedAt, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: rows.Scan: %w", op, err)
+		}
+		codes = append(codes, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows.Err: %w", op, err)
+	}
+
+	return codes, nil
+}
+
+// Use marks the code as used. The condition makes the check and the update atomic,
+// so a code presented twice at the same time is accepted once.
+func (r *RecoveryCodeRepo) Use(ctx context.Context, id uuid.UUID) error {
+	const op = "repo.persistent.recovery_code.Use"
+
+	sql, args, _ := r.Builder.
+		Update("user_recovery_codes").
+		Set("used_at", squirrel.Expr("NOW()")).
+		Where("id = ? AND used_at IS NULL", id).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
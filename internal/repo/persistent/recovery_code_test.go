@@ -0,0 +1,207 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newRecoveryCodeRepoMock(poolMock pgxmock.PgxPoolIface) *RecoveryCodeRepo {
+	return NewRecoveryCodeRepo(&postgres.Postgres{
+		Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		Pool:    poolMock,
+	})
+}
+
+func TestRecoveryCodeRepo_Replace(t *testing.T) {
+	userId := uuid.New()
+	hashes := [][]byte{[]byte("hash1"), []byte("hash2")}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		hashes       [][]byte
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name:   "OK",
+			hashes: hashes,
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectExec("DELETE FROM user_recovery_codes WHERE user_id = \\$1").
+					WithArgs(userId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 10))
+				m.ExpectExec("INSERT INTO user_recovery_codes \\(user_id, code_hash\\) VALUES \\(\\$1,\\$2\\),\\(\\$3,\\$4\\)").
+					WithArgs(userId, hashes[0], userId, hashes[1]).
+					WillReturnResult(pgxmock.NewResult("INSERT", 2))
+				m.ExpectCommit()
+			},
+		},
+		{
+			name: "empty set",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectExec("DELETE FROM user_recovery_codes").
+					WithArgs(userId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 10))
+				m.ExpectCommit()
+			},
+		},
+		{
+			name:   "unexpected error",
+			hashes: hashes,
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectExec("DELETE FROM user_recovery_codes").
+					WithArgs(userId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				m.ExpectExec("INSERT INTO user_recovery_codes").
+					WithArgs(userId, hashes[0], userId, hashes[1]).
+					WillReturnError(errors.New("some error"))
+				m.ExpectRollback()
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newRecoveryCodeRepoMock(poolMock).Replace(context.Background(), userId, tc.hashes)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRecoveryCodeRepo_Unused(t *testing.T) {
+	userId := uuid.New()
+	code := entity.RecoveryCode{Id: uuid.New(), UserId: userId, CodeHash: []byte("hash"), CreatedAt: time.Now()}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         []entity.RecoveryCode
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id", "user_id", "code_hash", "used_at", "created_at"}).
+					AddRow(code.Id, code.UserId, code.CodeHash, code.UsedAt, code.CreatedAt)
+
+				m.ExpectQuery("SELECT (.+) FROM user_recovery_codes WHERE user_id = \\$1 AND used_at IS NULL ORDER BY created_at").
+					WithArgs(userId).
+					WillReturnRows(rows)
+			},
+			want: []entity.RecoveryCode{code},
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM user_recovery_codes").
+					WithArgs(userId).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			got, err := newRecoveryCodeRepoMock(poolMock).Unused(context.Background(), userId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRecoveryCodeRepo_Use(t *testing.T) {
+	id := uuid.New()
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE user_recovery_codes SET used_at = NOW\\(\\) WHERE id = \\$1 AND used_at IS NULL").
+					WithArgs(id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "already used",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE user_recovery_codes").
+					WithArgs(id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE user_recovery_codes").
+					WithArgs(id).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newRecoveryCodeRepoMock(poolMock).Use(context.Background(), id)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
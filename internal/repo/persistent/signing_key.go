@@ -0,0 +1,155 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/pkg/postgres"
+	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+)
+
+type SigningKeyRepo struct {
+	*postgres.Postgres
+}
+
+func NewSigningKeyRepo(pg *postgres.Postgres) *SigningKeyRepo {
+	return &SigningKeyRepo{pg}
+}
+
+func (r *SigningKeyRepo) Create(ctx context.Context, k entity.SigningKey) error {
+	const op = "repo.persistent.signing_key.Create"
+
+	sql, args, _ := r.Builder.
+		Insert("signing_keys").
+		Columns("id, algorithm, state, private_key, activates_at").
+		Values(k.Id, k.Algorithm, k.State, k.PrivateKey, k.ActivatesAt).
+		ToSql()
+
+	_, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if ok := errors.As(err, &pgErr); ok {
+			switch pgErr.ConstraintName {
+			case "signing_keys_pkey", "signing_keys_single_active", "signing_keys_single_next":
+				return repoErrs.ErrAlreadyExists
+			}
+		}
+
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	return nil
+}
+
+// Keys returns every key that is not revoked.
+func (r *SigningKeyRepo) Keys(ctx context.Context) ([]entity.SigningKey, error) {
+	const op = "repo.persistent.signing_key.Keys"
+
+	sql, args, _ := r.Builder.
+		Select("id, algorithm, state, private_key, activates_at, retired_at, created_at").
+		From("signing_keys").
+		Where("state <> ?", entity.SigningKeyRevoked).
+		OrderBy("activates_at").
+		ToSql()
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: r.Pool.Query: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []entity.SigningKey
+	for rows.Next() {
+		var k entity.SigningKey
+		err = rows.Scan(
+			&k.Id,
+			&k.Algorithm,
+			&k.State,
+			&k.PrivateKey,
+			&k.ActivatesAt,
+			&k.RetiredAt,
+			&k.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: rows.Scan: %w", op, err)
+		}
+
+		keys = append(keys, k)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows.Err: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// Promote makes the next key active and moves the current active key to retiring.
+// It returns repoErrs.ErrNotFound when the key is no longer in the next state,
+// e.g. because another replica promoted it first.
+func (r *SigningKeyRepo) Promote(ctx context.Context, id string, at time.Time) error {
+	const op = "repo.persistent.signing_key.Promote"
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Begin: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ := r.Builder.
+		Update("signing_keys").
+		Set("state", entity.SigningKeyRetiring).
+		Set("retired_at", at).
+		Where("state = ?", entity.SigningKeyActive).
+		ToSql()
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: tx.Exec: %w", op, err)
+	}
+
+	sql, args, _ = r.Builder.
+		Update("signing_keys").
+		Set("state", entity.SigningKeyActive).
+		Set("activates_at", at).
+		Where("id = ? AND state = ?", id, entity.SigningKeyNext).
+		ToSql()
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: tx.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: tx.Commit: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SigningKeyRepo) Revoke(ctx context.Context, id string) error {
+	const op = "repo.persistent.signing_key.Revoke"
+
+	sql, args, _ := r.Builder.
+		Update("signing_keys").
+		Set("state", entity.SigningKeyRevoked).
+		Where("id = ? AND state <> ?", id, entity.SigningKeyRevoked).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
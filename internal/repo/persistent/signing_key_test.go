@@ -0,0 +1,272 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/pkg/postgres"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newSigningKeyRepoMock(poolMock pgxmock.PgxPoolIface) *SigningKeyRepo {
+	return NewSigningKeyRepo(&postgres.Postgres{
+		Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		Pool:    poolMock,
+	})
+}
+
+func TestSigningKeyRepo_Create(t *testing.T) {
+	key := entity.SigningKey{
+		Id:          "20250101-0a1b2c3d",
+		Algorithm:   "ES256",
+		State:       entity.SigningKeyNext,
+		PrivateKey:  []byte{1, 2, 3},
+		ActivatesAt: time.Now(),
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO signing_keys").
+					WithArgs(key.Id, key.Algorithm, key.State, key.PrivateKey, key.ActivatesAt).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+		},
+		{
+			name: "next key already exists",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO signing_keys").
+					WithArgs(key.Id, key.Algorithm, key.State, key.PrivateKey, key.ActivatesAt).
+					WillReturnError(&pgconn.PgError{ConstraintName: "signing_keys_single_next"})
+			},
+			wantErr: repoErrs.ErrAlreadyExists,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO signing_keys").
+					WithArgs(key.Id, key.Algorithm, key.State, key.PrivateKey, key.ActivatesAt).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newSigningKeyRepoMock(poolMock).Create(context.Background(), key)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSigningKeyRepo_Keys(t *testing.T) {
+	activatesAt := time.Now()
+	retiredAt := activatesAt.Add(time.Hour)
+
+	columns := []string{"id", "algorithm", "state", "private_key", "activates_at", "retired_at", "created_at"}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         []entity.SigningKey
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows(columns).
+					AddRow("old", "ES256", entity.SigningKeyRetiring, []byte{1}, activatesAt, &retiredAt, activatesAt).
+					AddRow("cur", "ES256", entity.SigningKeyActive, []byte{2}, retiredAt, nil, activatesAt)
+
+				m.ExpectQuery("SELECT (.+) FROM signing_keys WHERE state <> \\$1 ORDER BY activates_at").
+					WithArgs(entity.SigningKeyRevoked).
+					WillReturnRows(rows)
+			},
+			want: []entity.SigningKey{
+				{
+					Id:          "old",
+					Algorithm:   "ES256",
+					State:       entity.SigningKeyRetiring,
+					PrivateKey:  []byte{1},
+					ActivatesAt: activatesAt,
+					RetiredAt:   &retiredAt,
+					CreatedAt:   activatesAt,
+				},
+				{
+					Id:          "cur",
+					Algorithm:   "ES256",
+					State:       entity.SigningKeyActive,
+					PrivateKey:  []byte{2},
+					ActivatesAt: retiredAt,
+					CreatedAt:   activatesAt,
+				},
+			},
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM signing_keys").
+					WithArgs(entity.SigningKeyRevoked).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			got, err := newSigningKeyRepoMock(poolMock).Keys(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSigningKeyRepo_Promote(t *testing.T) {
+	at := time.Now()
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE signing_keys SET state = \\$1, retired_at = \\$2 WHERE state = \\$3").
+					WithArgs(entity.SigningKeyRetiring, at, entity.SigningKeyActive).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				m.ExpectExec("UPDATE signing_keys SET state = \\$1, activates_at = \\$2 WHERE id = \\$3 AND state = \\$4").
+					WithArgs(entity.SigningKeyActive, at, "next", entity.SigningKeyNext).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				m.ExpectCommit()
+			},
+		},
+		{
+			name: "already promoted",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE signing_keys").
+					WithArgs(entity.SigningKeyRetiring, at, entity.SigningKeyActive).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				m.ExpectExec("UPDATE signing_keys").
+					WithArgs(entity.SigningKeyActive, at, "next", entity.SigningKeyNext).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+				m.ExpectRollback()
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE signing_keys").
+					WithArgs(entity.SigningKeyRetiring, at, entity.SigningKeyActive).
+					WillReturnError(errors.New("some error"))
+				m.ExpectRollback()
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newSigningKeyRepoMock(poolMock).Promote(context.Background(), "next", at)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSigningKeyRepo_Revoke(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE signing_keys SET state = \\$1 WHERE id = \\$2 AND state <> \\$3").
+					WithArgs(entity.SigningKeyRevoked, "kid", entity.SigningKeyRevoked).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE signing_keys").
+					WithArgs(entity.SigningKeyRevoked, "kid", entity.SigningKeyRevoked).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newSigningKeyRepoMock(poolMock).Revoke(context.Background(), "kid")
+			assert.ErrorIs(t, err, tc.wantErr)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type TOTPRepo struct {
+	*postgres.Postgres
+}
+
+func NewTOTPRepo(pg *postgres.Postgres) *TOTPRepo {
+	return &TOTPRepo{pg}
+}
+
+// Upsert starts a new enrollment. An unconfirmed enrollment is replaced,
+// a confirmed one is kept and repoErrs.ErrAlreadyExists is returned.
+func (r *TOTPRepo) Upsert(ctx context.Context, t entity.TOTP) error {
+	const op = "repo.persistent.totp.Upsert"
+
+	sql, args, _ := r.Builder.
+		Insert("user_totp").
+		Columns("user_id, secret").
+		Values(t.UserId, t.Secret).
+		Suffix("ON CONFLICT (user_id) DO UPDATE " +
+			"SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW() " +
+			"WHERE user_totp.confirmed_at IS NULL").
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrAlreadyExists
+	}
+
+	return nil
+}
+
+// Confirm enables two-factor authentication, step is the time step of the first code.
+func (r *TOTPRepo) Confirm(ctx context.Context, userId uuid.UUID, step int64) error {
+	const op = "repo.persistent.totp.Confirm"
+
+	sql, args, _ := r.Builder.
+		Update("user_totp").
+		Set("confirmed_at", squirrel.Expr("NOW()")).
+		Set("last_used_step", step).
+		Where("user_id = ? AND confirmed_at IS NULL", userId).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
+
+// UseStep moves the last used step forward. The condition makes the check and the update atomic,
+// so a code presented twice at the same time is accepted once.
+func (r *TOTPRepo) UseStep(ctx context.Context, userId uuid.UUID, step int64) error {
+	const op = "repo.persistent.totp.UseStep"
+
+	sql, args, _ := r.Builder.
+		Update("user_totp").
+		Set("last_used_step", step).
+		Where("user_id = ? AND last_used_step < ?", userId, step).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TOTPRepo) Delete(ctx context.Context, userId uuid.UUID) error {
+	const op = "repo.persistent.totp.Delete"
+
+	sql, args, _ := r.Builder.
+		Delete("user_totp").
+		Where("user_id = ?", userId).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TOTPRepo) TOTPByUserId(ctx context.Context, userId uuid.UUID) (entity.TOTP, error) {
+	const op = "repo.persistent.totp.TOTPByUserId"
+
+	sql, args, _ := r.Builder.
+		Select("user_id, secret, confirmed_at, last_used_step, created_at").
+		From("user_totp").
+		Where("user_id = ?", userId).
+		ToSql()
+
+	var t entity.TOTP
+	err := r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&t.UserId,
+		&t.Secret,
+		&t.ConfirmedAt,
+		&t.LastUsedStep,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.TOTP{}, repoErrs.ErrNotFound
+		}
+		return entity.TOTP{}, fmt.Errorf("%s: r.Pool.QueryRow: %w", op, err)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,190 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newTOTPRepoMock(poolMock pgxmock.PgxPoolIface) *TOTPRepo {
+	return NewTOTPRepo(&postgres.Postgres{
+		Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		Pool:    poolMock,
+	})
+}
+
+func TestTOTPRepo_Upsert(t *testing.T) {
+	totp := entity.TOTP{UserId: uuid.New(), Secret: []byte("encrypted")}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO user_totp (.+) ON CONFLICT \\(user_id\\) DO UPDATE (.+) WHERE user_totp.confirmed_at IS NULL").
+					WithArgs(totp.UserId, totp.Secret).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+		},
+		{
+			name: "already confirmed",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO user_totp").
+					WithArgs(totp.UserId, totp.Secret).
+					WillReturnResult(pgxmock.NewResult("INSERT", 0))
+			},
+			wantErr: repoErrs.ErrAlreadyExists,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO user_totp").
+					WithArgs(totp.UserId, totp.Secret).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newTOTPRepoMock(poolMock).Upsert(context.Background(), totp)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTOTPRepo_UseStep(t *testing.T) {
+	userId := uuid.New()
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE user_totp SET last_used_step = \\$1 WHERE user_id = \\$2 AND last_used_step < \\$3").
+					WithArgs(int64(42), userId, int64(42)).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "step already used",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE user_totp").
+					WithArgs(int64(42), userId, int64(42)).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newTOTPRepoMock(poolMock).UseStep(context.Background(), userId, 42)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTOTPRepo_TOTPByUserId(t *testing.T) {
+	createdAt := time.Now()
+	columns := []string{"user_id", "secret", "confirmed_at", "last_used_step", "created_at"}
+
+	want := entity.TOTP{
+		UserId:       uuid.New(),
+		Secret:       []byte("encrypted"),
+		ConfirmedAt:  &createdAt,
+		LastUsedStep: 42,
+		CreatedAt:    createdAt,
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         entity.TOTP
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows(columns).
+					AddRow(want.UserId, want.Secret, want.ConfirmedAt, want.LastUsedStep, want.CreatedAt)
+
+				m.ExpectQuery("SELECT (.+) FROM user_totp WHERE user_id = \\$1").
+					WithArgs(want.UserId).
+					WillReturnRows(rows)
+			},
+			want: want,
+		},
+		{
+			name: "not enrolled",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM user_totp").
+					WithArgs(want.UserId).
+					WillReturnRows(pgxmock.NewRows(columns))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			got, err := newTOTPRepoMock(poolMock).TOTPByUserId(context.Background(), want.UserId)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -26,8 +26,8 @@ func (r *UserRepo) Create(ctx context.Context, u entity.User) error {
 
 	sql, args, _ := r.Builder.
 		Insert("users").
-		Columns("id, email, password_hash").
-		Values(u.Id, u.Email, u.PasswordHash).
+		Columns("id, email, password_hash, email_verified_at").
+		Values(u.Id, u.Email, u.PasswordHash, u.EmailVerifiedAt).
 		ToSql()
 
 	_, err := r.Pool.Exec(ctx, sql, args...)
@@ -89,11 +89,109 @@ func (r *UserRepo) UpdateLastLoginAttempt(ctx context.Context, id uuid.UUID) err
 	return nil
 }
 
+// VerifyEmail marks the email of the user as verified, a verified email keeps its original time.
+func (r *UserRepo) VerifyEmail(ctx context.Context, id uuid.UUID) error {
+	const op = "repo.persistent.user.VerifyEmail"
+
+	sql, args, _ := r.Builder.
+		Update("users").
+		Set("email_verified_at", squirrel.Expr("COALESCE(email_verified_at, NOW())")).
+		Where("id = ?", id).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetPhone replaces the phone of the user, nil removes it. A new phone is not verified and
+// does not receive the second factor codes until it is.
+func (r *UserRepo) SetPhone(ctx context.Context, id uuid.UUID, phone *string) error {
+	const op = "repo.persistent.user.SetPhone"
+
+	sql, args, _ := r.Builder.
+		Update("users").
+		Set("phone", phone).
+		Set("phone_verified_at", nil).
+		Set("sms_second_factor", false).
+		Where("id = ?", id).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
+
+// VerifyPhone marks the phone of the user as verified if it is still the given one.
+func (r *UserRepo) VerifyPhone(ctx context.Context, id uuid.UUID, phone string) error {
+	const op = "repo.persistent.user.VerifyPhone"
+
+	sql, args, _ := r.Builder.
+		Update("users").
+		Set("phone_verified_at", squirrel.Expr("COALESCE(phone_verified_at, NOW())")).
+		Where("id = ? AND phone = ?", id, phone).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if ok := errors.As(err, &pgErr); ok {
+			if pgErr.ConstraintName == "users_phone_verified_unique" {
+				return repoErrs.ErrAlreadyExists
+			}
+		}
+
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetSMSSecondFactor turns the SMS second factor on or off, only a verified phone can have it.
+func (r *UserRepo) SetSMSSecondFactor(ctx context.Context, id uuid.UUID, enabled bool) error {
+	const op = "repo.persistent.user.SetSMSSecondFactor"
+
+	sql, args, _ := r.Builder.
+		Update("users").
+		Set("sms_second_factor", enabled).
+		Where("id = ? AND phone_verified_at IS NOT NULL", id).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
+
 func (r *UserRepo) UserByEmail(ctx context.Context, email string) (entity.User, error) {
 	const op = "repo.persistent.user.UserByEmail"
 
 	sql, args, _ := r.Builder.
-		Select("id, email, password_hash, name, is_active, last_login_attempt, created_at, updated_at").
+		Select("id, email, password_hash, name, is_active, email_verified_at, phone, phone_verified_at, sms_second_factor, last_login_attempt, created_at, updated_at").
 		From("users").
 		Where("LOWER(email) = LOWER(?)", email).
 		ToSql()
@@ -105,6 +203,10 @@ func (r *UserRepo) UserByEmail(ctx context.Context, email string) (entity.User,
 		&user.PasswordHash,
 		&user.Name,
 		&user.IsActive,
+		&user.EmailVerifiedAt,
+		&user.Phone,
+		&user.PhoneVerifiedAt,
+		&user.SMSSecondFactor,
 		&user.LastLoginAttempt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
@@ -144,7 +246,7 @@ func (r *UserRepo) UserById(ctx context.Context, id uuid.UUID) (entity.User, err
 	const op = "repo.persistent.user.UserById"
 
 	sql, args, _ := r.Builder.
-		Select("id, email, password_hash, name, is_active, last_login_attempt, created_at, updated_at").
+		Select("id, email, password_hash, name, is_active, email_verified_at, phone, phone_verified_at, sms_second_factor, last_login_attempt, created_at, updated_at").
 		From("users").
 		Where("id = ?", id).
 		ToSql()
@@ -156,6 +258,45 @@ func (r *UserRepo) UserById(ctx context.Context, id uuid.UUID) (entity.User, err
 		&user.PasswordHash,
 		&user.Name,
 		&user.IsActive,
+		&user.EmailVerifiedAt,
+		&user.Phone,
+		&user.PhoneVerifiedAt,
+		&user.SMSSecondFactor,
+		&user.LastLoginAttempt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.User{}, repoErrs.ErrNotFound
+		}
+		return entity.User{}, fmt.Errorf("%s: r.Pool.QueryRow: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// UserByPhone looks the user up by a verified phone.
+func (r *UserRepo) UserByPhone(ctx context.Context, phone string) (entity.User, error) {
+	const op = "repo.persistent.user.UserByPhone"
+
+	sql, args, _ := r.Builder.
+		Select("id, email, password_hash, name, is_active, email_verified_at, phone, phone_verified_at, sms_second_factor, last_login_attempt, created_at, updated_at").
+		From("users").
+		Where("phone = ? AND phone_verified_at IS NOT NULL", phone).
+		ToSql()
+
+	var user entity.User
+	err := r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&user.Id,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Name,
+		&user.IsActive,
+		&user.EmailVerifiedAt,
+		&user.Phone,
+		&user.PhoneVerifiedAt,
+		&user.SMSSecondFactor,
 		&user.LastLoginAttempt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
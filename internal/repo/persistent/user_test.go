@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/Masterminds/squirrel"
 	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
 	"github.com/bubalync/uni-auth/pkg/postgres"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -45,7 +46,7 @@ func TestUserRepo_Create(t *testing.T) {
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
 				m.ExpectExec("INSERT INTO users").
-					WithArgs(args.user.Id, args.user.Email, args.user.PasswordHash).
+					WithArgs(args.user.Id, args.user.Email, args.user.PasswordHash, args.user.EmailVerifiedAt).
 					WillReturnResult(pgxmock.NewResult("INSERT", 1))
 			},
 			wantErr: false,
@@ -61,7 +62,7 @@ func TestUserRepo_Create(t *testing.T) {
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
 				m.ExpectExec("INSERT INTO users").
-					WithArgs(args.user.Id, args.user.Email, args.user.PasswordHash).
+					WithArgs(args.user.Id, args.user.Email, args.user.PasswordHash, args.user.EmailVerifiedAt).
 					WillReturnError(&pgconn.PgError{
 						ConstraintName: "users_email_lower_unique",
 					})
@@ -79,7 +80,7 @@ func TestUserRepo_Create(t *testing.T) {
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
 				m.ExpectQuery("INSERT INTO users").
-					WithArgs(args.user.Id, args.user.Email, args.user.PasswordHash).
+					WithArgs(args.user.Id, args.user.Email, args.user.PasswordHash, args.user.EmailVerifiedAt).
 					WillReturnError(errors.New("some error"))
 			},
 			wantErr: true,
@@ -225,10 +226,10 @@ func TestUserRepo_UserByEmail(t *testing.T) {
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
 				rows := pgxmock.
-					NewRows([]string{"id", "email", "password_hash", "name", "is_active", "last_login_attempt", "created_at", "updated_at"}).
-					AddRow(uuid.MustParse("25101e2d-b9ec-4c1d-a2c2-7180c6b5410a"), args.email, []byte("Qwerty1!"), "", true, nil, time.UnixMilli(123456), time.UnixMilli(123456))
+					NewRows([]string{"id", "email", "password_hash", "name", "is_active", "email_verified_at", "phone", "phone_verified_at", "sms_second_factor", "last_login_attempt", "created_at", "updated_at"}).
+					AddRow(uuid.MustParse("25101e2d-b9ec-4c1d-a2c2-7180c6b5410a"), args.email, []byte("Qwerty1!"), "", true, nil, nil, nil, false, nil, time.UnixMilli(123456), time.UnixMilli(123456))
 
-				m.ExpectQuery("SELECT id, email, password_hash, name, is_active, last_login_attempt, created_at, updated_at FROM users").
+				m.ExpectQuery("SELECT id, email, password_hash, name, is_active, email_verified_at, phone, phone_verified_at, sms_second_factor, last_login_attempt, created_at, updated_at FROM users").
 					WithArgs(args.email).
 					WillReturnRows(rows)
 			},
@@ -251,7 +252,7 @@ func TestUserRepo_UserByEmail(t *testing.T) {
 				email: "test@example.com",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				m.ExpectQuery("SELECT id, email, password_hash, name, is_active, last_login_attempt, created_at, updated_at FROM users").
+				m.ExpectQuery("SELECT id, email, password_hash, name, is_active, email_verified_at, phone, phone_verified_at, sms_second_factor, last_login_attempt, created_at, updated_at FROM users").
 					WithArgs(args.email).
 					WillReturnError(pgx.ErrNoRows)
 			},
@@ -265,7 +266,7 @@ func TestUserRepo_UserByEmail(t *testing.T) {
 				email: "test@example.com",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				m.ExpectQuery("SELECT id, email, password_hash, name, is_active, last_login_attempt, created_at, updated_at FROM users").
+				m.ExpectQuery("SELECT id, email, password_hash, name, is_active, email_verified_at, phone, phone_verified_at, sms_second_factor, last_login_attempt, created_at, updated_at FROM users").
 					WithArgs(args.email).
 					WillReturnError(errors.New("some error"))
 			},
@@ -367,6 +368,68 @@ func TestUserRepo_UpdateLastLoginAttempt(t *testing.T) {
 	}
 }
 
+func TestUserRepo_VerifyEmail(t *testing.T) {
+	id := uuid.New()
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users SET email_verified_at = COALESCE\\(email_verified_at, NOW\\(\\)\\) WHERE id = \\$1").
+					WithArgs(id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users").
+					WithArgs(id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users").
+					WithArgs(id).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			err := NewUserRepo(postgresMock).VerifyEmail(context.Background(), id)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
 func TestUserRepo_UpdatePassword(t *testing.T) {
 	type args struct {
 		ctx      context.Context
@@ -436,3 +499,287 @@ func TestUserRepo_UpdatePassword(t *testing.T) {
 		})
 	}
 }
+
+func TestUserRepo_SetPhone(t *testing.T) {
+	id := uuid.New()
+	phone := "+15550100"
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		phone        *string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name:  "OK",
+			phone: &phone,
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users SET phone = \\$1, phone_verified_at = \\$2, sms_second_factor = \\$3 WHERE id = \\$4").
+					WithArgs(&phone, nil, false, id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name:  "not found",
+			phone: nil,
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users").
+					WithArgs((*string)(nil), nil, false, id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+		{
+			name:  "unexpected error",
+			phone: &phone,
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users").
+					WithArgs(&phone, nil, false, id).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			err := NewUserRepo(postgresMock).SetPhone(context.Background(), id, tc.phone)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserRepo_VerifyPhone(t *testing.T) {
+	id := uuid.New()
+	phone := "+15550100"
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users SET phone_verified_at = COALESCE\\(phone_verified_at, NOW\\(\\)\\) WHERE id = \\$1 AND phone = \\$2").
+					WithArgs(id, phone).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "phone changed",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users").
+					WithArgs(id, phone).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+		{
+			name: "verified by another user",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users").
+					WithArgs(id, phone).
+					WillReturnError(&pgconn.PgError{
+						ConstraintName: "users_phone_verified_unique",
+					})
+			},
+			wantErr: repoErrs.ErrAlreadyExists,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users").
+					WithArgs(id, phone).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			err := NewUserRepo(postgresMock).VerifyPhone(context.Background(), id, phone)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserRepo_SetSMSSecondFactor(t *testing.T) {
+	id := uuid.New()
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users SET sms_second_factor = \\$1 WHERE id = \\$2 AND phone_verified_at IS NOT NULL").
+					WithArgs(true, id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "no verified phone",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users").
+					WithArgs(true, id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users").
+					WithArgs(true, id).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			err := NewUserRepo(postgresMock).SetSMSSecondFactor(context.Background(), id, true)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserRepo_UserByPhone(t *testing.T) {
+	id := uuid.New()
+	phone := "+15550100"
+	verifiedAt := time.UnixMilli(123456)
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         entity.User
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				rows := pgxmock.
+					NewRows([]string{"id", "email", "password_hash", "name", "is_active", "email_verified_at", "phone", "phone_verified_at", "sms_second_factor", "last_login_attempt", "created_at", "updated_at"}).
+					AddRow(id, "test@example.com", []byte("hash"), "", true, nil, &phone, &verifiedAt, true, nil, verifiedAt, verifiedAt)
+
+				m.ExpectQuery("SELECT (.+) FROM users WHERE phone = \\$1 AND phone_verified_at IS NOT NULL").
+					WithArgs(phone).
+					WillReturnRows(rows)
+			},
+			want: entity.User{
+				Id:              id,
+				Email:           "test@example.com",
+				PasswordHash:    []byte("hash"),
+				IsActive:        true,
+				Phone:           &phone,
+				PhoneVerifiedAt: &verifiedAt,
+				SMSSecondFactor: true,
+				CreatedAt:       verifiedAt,
+				UpdatedAt:       verifiedAt,
+			},
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs(phone).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs(phone).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			got, err := NewUserRepo(postgresMock).UserByPhone(context.Background(), phone)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type WebAuthnCredentialRepo struct {
+	*postgres.Postgres
+}
+
+func NewWebAuthnCredentialRepo(pg *postgres.Postgres) *WebAuthnCredentialRepo {
+	return &WebAuthnCredentialRepo{pg}
+}
+
+func (r *WebAuthnCredentialRepo) Create(ctx context.Context, c entity.WebAuthnCredential) error {
+	const op = "repo.persistent.webauthn_credential.Create"
+
+	sql, args, _ := r.Builder.
+		Insert("webauthn_credentials").
+		Columns("id, user_id, name, public_key, attestation_type, transports, aaguid, sign_count, backup_eligible, backup_state").
+		Values(
+			c.Id,
+			c.UserId,
+			c.Name,
+			c.PublicKey,
+			c.AttestationType,
+			orEmpty(c.Transports),
+			c.AAGUID,
+			c.SignCount,
+			c.BackupEligible,
+			c.BackupState,
+		).
+		ToSql()
+
+	_, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if ok := errors.As(err, &pgErr); ok {
+			if pgErr.ConstraintName == "webauthn_credentials_pkey" {
+				return repoErrs.ErrAlreadyExists
+			}
+		}
+
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *WebAuthnCredentialRepo) CredentialsByUserId(ctx context.Context, userId uuid.UUID) ([]entity.WebAuthnCredential, error) {
+	const op = "repo.persistent.webauthn_credential.CredentialsByUserId"
+
+	sql, args, _ := r.Builder.
+		Select("id, user_id, name, public_key, attestation_type, transports, aaguid, sign_count, "+
+			"backup_eligible, backup_state, last_used_at, created_at").
+		From("webauthn_credentials").
+		Where("user_id = ?", userId).
+		OrderBy("created_at").
+		ToSql()
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: r.Pool.Query: %w", op, err)
+	}
+	defer rows.Close()
+
+	var credentials []entity.WebAuthnCredential
+	for rows.Next() {
+		var c entity.WebAuthnCredential
+		err = rows.Scan(
+			&c.Id,
+			&c.UserId,
+			&c.Name,
+			&c.PublicKey,
+			&c.AttestationType,
+			&c.Transports,
+			&c.AAGUID,
+			&c.SignCount,
+			&c.BackupEligible,
+			&c.BackupState,
+			&c.LastUsedAt,
+			&c.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: rows.Scan: %w", op, err)
+		}
+		credentials = append(credentials, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows.Err: %w", op, err)
+	}
+
+	return credentials, nil
+}
+
+// UpdateUsage saves the state the authenticator reported in an accepted assertion.
+func (r *WebAuthnCredentialRepo) UpdateUsage(ctx context.Context, id []byte, signCount uint32, backupState bool) error {
+	const op = "repo.persistent.webauthn_credential.UpdateUsage"
+
+	sql, args, _ := r.Builder.
+		Update("webauthn_credentials").
+		Set("sign_count", signCount).
+		Set("backup_state", backupState).
+		Set("last_used_at", squirrel.Expr("NOW()")).
+		Where("id = ?", id).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *WebAuthnCredentialRepo) Delete(ctx context.Context, userId uuid.UUID, id []byte) error {
+	const op = "repo.persistent.webauthn_credential.Delete"
+
+	sql, args, _ := r.Builder.
+		Delete("webauthn_credentials").
+		Where("id = ? AND user_id = ?", id, userId).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: r.Pool.Exec: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoErrs.ErrNotFound
+	}
+
+	return nil
+}
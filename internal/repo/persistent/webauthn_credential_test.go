@@ -0,0 +1,280 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newWebAuthnCredentialRepoMock(poolMock pgxmock.PgxPoolIface) *WebAuthnCredentialRepo {
+	return NewWebAuthnCredentialRepo(&postgres.Postgres{
+		Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		Pool:    poolMock,
+	})
+}
+
+func TestWebAuthnCredentialRepo_Create(t *testing.T) {
+	credential := entity.WebAuthnCredential{
+		Id:              []byte("credential"),
+		UserId:          uuid.New(),
+		Name:            "laptop",
+		PublicKey:       []byte("public key"),
+		AttestationType: "none",
+		Transports:      []string{"internal", "hybrid"},
+		AAGUID:          make([]byte, 16),
+		SignCount:       1,
+		BackupEligible:  true,
+		BackupState:     true,
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO webauthn_credentials").
+					WithArgs(
+						credential.Id,
+						credential.UserId,
+						credential.Name,
+						credential.PublicKey,
+						credential.AttestationType,
+						credential.Transports,
+						credential.AAGUID,
+						credential.SignCount,
+						credential.BackupEligible,
+						credential.BackupState,
+					).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+		},
+		{
+			name: "already exists",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO webauthn_credentials").
+					WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+					WillReturnError(&pgconn.PgError{ConstraintName: "webauthn_credentials_pkey"})
+			},
+			wantErr: repoErrs.ErrAlreadyExists,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO webauthn_credentials").
+					WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newWebAuthnCredentialRepoMock(poolMock).Create(context.Background(), credential)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWebAuthnCredentialRepo_CredentialsByUserId(t *testing.T) {
+	userId := uuid.New()
+	credential := entity.WebAuthnCredential{
+		Id:         []byte("credential"),
+		UserId:     userId,
+		PublicKey:  []byte("public key"),
+		Transports: []string{"usb"},
+		AAGUID:     make([]byte, 16),
+		SignCount:  7,
+		CreatedAt:  time.Now(),
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         []entity.WebAuthnCredential
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{
+					"id", "user_id", "name", "public_key", "attestation_type", "transports", "aaguid", "sign_count",
+					"backup_eligible", "backup_state", "last_used_at", "created_at",
+				}).AddRow(
+					credential.Id, credential.UserId, credential.Name, credential.PublicKey, credential.AttestationType,
+					credential.Transports, credential.AAGUID, credential.SignCount, credential.BackupEligible,
+					credential.BackupState, credential.LastUsedAt, credential.CreatedAt,
+				)
+
+				m.ExpectQuery("SELECT (.+) FROM webauthn_credentials WHERE user_id = \\$1 ORDER BY created_at").
+					WithArgs(userId).
+					WillReturnRows(rows)
+			},
+			want: []entity.WebAuthnCredential{credential},
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM webauthn_credentials").
+					WithArgs(userId).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			got, err := newWebAuthnCredentialRepoMock(poolMock).CredentialsByUserId(context.Background(), userId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWebAuthnCredentialRepo_UpdateUsage(t *testing.T) {
+	id := []byte("credential")
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE webauthn_credentials SET sign_count = \\$1, backup_state = \\$2, last_used_at = NOW\\(\\) WHERE id = \\$3").
+					WithArgs(uint32(8), true, id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE webauthn_credentials").
+					WithArgs(uint32(8), true, id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newWebAuthnCredentialRepoMock(poolMock).UpdateUsage(context.Background(), id, 8, true)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWebAuthnCredentialRepo_Delete(t *testing.T) {
+	userId := uuid.New()
+	id := []byte("credential")
+
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM webauthn_credentials WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(id, userId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM webauthn_credentials").
+					WithArgs(id, userId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			wantErr: repoErrs.ErrNotFound,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("DELETE FROM webauthn_credentials").
+					WithArgs(id, userId).
+					WillReturnError(errors.New("some error"))
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := newWebAuthnCredentialRepoMock(poolMock).Delete(context.Background(), userId, id)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
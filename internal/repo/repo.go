@@ -6,6 +6,7 @@ import (
 	"github.com/bubalync/uni-auth/internal/repo/persistent"
 	"github.com/bubalync/uni-auth/pkg/postgres"
 	"github.com/google/uuid"
+	"time"
 )
 
 type (
@@ -15,18 +16,94 @@ type (
 		UpdatePassword(ctx context.Context, email string, password []byte) error
 		Update(ctx context.Context, u entity.User) error
 		UpdateLastLoginAttempt(ctx context.Context, id uuid.UUID) error
+		// VerifyEmail returns repoErrs.ErrNotFound when there is no such user.
+		VerifyEmail(ctx context.Context, id uuid.UUID) error
+		// SetPhone returns repoErrs.ErrNotFound when there is no such user.
+		SetPhone(ctx context.Context, id uuid.UUID, phone *string) error
+		// VerifyPhone returns repoErrs.ErrNotFound when the user has another phone now
+		// and repoErrs.ErrAlreadyExists when the phone is verified by another user.
+		VerifyPhone(ctx context.Context, id uuid.UUID, phone string) error
+		// SetSMSSecondFactor returns repoErrs.ErrNotFound when the user has no verified phone.
+		SetSMSSecondFactor(ctx context.Context, id uuid.UUID, enabled bool) error
 		UserByEmail(ctx context.Context, email string) (entity.User, error)
 		UserByEmailIsExists(ctx context.Context, email string) (*bool, error)
 		UserById(ctx context.Context, id uuid.UUID) (entity.User, error)
+		UserByPhone(ctx context.Context, phone string) (entity.User, error)
+	}
+
+	SigningKey interface {
+		Create(ctx context.Context, k entity.SigningKey) error
+		Keys(ctx context.Context) ([]entity.SigningKey, error)
+		Promote(ctx context.Context, id string, at time.Time) error
+		Revoke(ctx context.Context, id string) error
+	}
+
+	Client interface {
+		Create(ctx context.Context, c entity.Client) error
+		Update(ctx context.Context, c entity.Client) error
+		UpdateSecret(ctx context.Context, id string, secretHash []byte) error
+		Delete(ctx context.Context, id string) error
+		ClientById(ctx context.Context, id string) (entity.Client, error)
+		Clients(ctx context.Context) ([]entity.Client, error)
+	}
+
+	TOTP interface {
+		// Upsert replaces an unconfirmed enrollment, it returns repoErrs.ErrAlreadyExists when 2FA is enabled.
+		Upsert(ctx context.Context, t entity.TOTP) error
+		Confirm(ctx context.Context, userId uuid.UUID, step int64) error
+		// UseStep records the time step of an accepted code,
+		// it returns repoErrs.ErrNotFound when the step is not newer than the last one.
+		UseStep(ctx context.Context, userId uuid.UUID, step int64) error
+		Delete(ctx context.Context, userId uuid.UUID) error
+		TOTPByUserId(ctx context.Context, userId uuid.UUID) (entity.TOTP, error)
+	}
+
+	RecoveryCode interface {
+		// Replace drops the codes of the user and saves the new set, an empty set only drops them.
+		Replace(ctx context.Context, userId uuid.UUID, hashes [][]byte) error
+		Unused(ctx context.Context, userId uuid.UUID) ([]entity.RecoveryCode, error)
+		// Use returns repoErrs.ErrNotFound when the code was already used.
+		Use(ctx context.Context, id uuid.UUID) error
+	}
+
+	WebAuthnCredential interface {
+		// Create returns repoErrs.ErrAlreadyExists when the credential is registered already.
+		Create(ctx context.Context, c entity.WebAuthnCredential) error
+		CredentialsByUserId(ctx context.Context, userId uuid.UUID) ([]entity.WebAuthnCredential, error)
+		UpdateUsage(ctx context.Context, id []byte, signCount uint32, backupState bool) error
+		// Delete returns repoErrs.ErrNotFound when the user has no such credential.
+		Delete(ctx context.Context, userId uuid.UUID, id []byte) error
+	}
+
+	Identity interface {
+		// Create returns repoErrs.ErrAlreadyExists when the identity is linked already.
+		Create(ctx context.Context, i entity.Identity) error
+		IdentityBySubject(ctx context.Context, provider, subject string) (entity.Identity, error)
+		IdentitiesByUserId(ctx context.Context, userId uuid.UUID) ([]entity.Identity, error)
+		UpdateLastUsed(ctx context.Context, provider, subject string) error
+		// Delete returns repoErrs.ErrNotFound when the user has no such identity.
+		Delete(ctx context.Context, userId uuid.UUID, provider, subject string) error
 	}
 )
 
 type Repositories struct {
 	User
+	SigningKey
+	Client
+	TOTP
+	RecoveryCode
+	WebAuthnCredential
+	Identity
 }
 
 func NewRepositories(pg *postgres.Postgres) *Repositories {
 	return &Repositories{
-		User: persistent.NewUserRepo(pg),
+		User:               persistent.NewUserRepo(pg),
+		SigningKey:         persistent.NewSigningKeyRepo(pg),
+		Client:             persistent.NewClientRepo(pg),
+		TOTP:               persistent.NewTOTPRepo(pg),
+		RecoveryCode:       persistent.NewRecoveryCodeRepo(pg),
+		WebAuthnCredential: persistent.NewWebAuthnCredentialRepo(pg),
+		Identity:           persistent.NewIdentityRepo(pg),
 	}
 }
@@ -9,6 +9,8 @@ import (
 	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
 	"github.com/bubalync/uni-auth/internal/repo"
 	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/otp"
+	"github.com/bubalync/uni-auth/internal/service/passkey"
 	"github.com/bubalync/uni-auth/internal/service/svcErrs"
 	"github.com/bubalync/uni-auth/pkg/hasher"
 	"github.com/bubalync/uni-auth/pkg/logger/sl"
@@ -20,8 +22,7 @@ import (
 )
 
 const (
-	refreshKeyTemplate = "refresh:%s"
-	resetKeyTemplate   = "reset:%s"
+	resetKeyTemplate = "reset:%s"
 )
 
 type Service struct {
@@ -32,6 +33,53 @@ type Service struct {
 	tokenGenerator  jwtgen.TokenGenerator
 	refreshTokenTTL time.Duration
 	emailSender     email.Sender
+	idTokenAudience string
+	verification    EmailVerificationConfig
+	passwordless    PasswordlessConfig
+	codes           OneTimeCodes
+	// secondFactor is nil when two-factor authentication is not configured.
+	secondFactor SecondFactor
+	// passkeys is nil when WebAuthn is not configured.
+	passkeys Passkeys
+	// lockout is nil when the lockout of failed sign-ins is disabled.
+	lockout Lockout
+	// breaches is nil when breached passwords are not screened.
+	breaches BreachedPasswords
+}
+
+// SecondFactor verifies the second factor of users who enabled two-factor authentication.
+type SecondFactor interface {
+	Enabled(ctx context.Context, userId uuid.UUID) (bool, error)
+	Verify(ctx context.Context, userId uuid.UUID, code string) error
+}
+
+// OneTimeCodes sends the numeric codes of the passwordless sign-in, email verification, step-up,
+// SMS second factor and account recovery, and checks them.
+type OneTimeCodes interface {
+	SendEmail(ctx context.Context, purpose otp.Purpose, subject, to string) error
+	SendSMS(ctx context.Context, purpose otp.Purpose, subject, to string) error
+	Verify(ctx context.Context, purpose otp.Purpose, subject, code string) error
+}
+
+// Passkeys verifies WebAuthn assertions, for passwordless sign-in and as an answer to the second factor challenge.
+type Passkeys interface {
+	FinishLogin(ctx context.Context, sessionId string, response []byte) (entity.User, error)
+	BeginAssertion(ctx context.Context, userId uuid.UUID) (passkey.Ceremony, error)
+	FinishAssertion(ctx context.Context, userId uuid.UUID, sessionId string, response []byte) error
+}
+
+// Lockout locks accounts and IPs out after too many failed password sign-ins.
+type Lockout interface {
+	Check(ctx context.Context, email, ip string) error
+	Fail(ctx context.Context, email, ip string) error
+	Reset(ctx context.Context, email string)
+	// LockAccount locks the account out after too many wrong second factors.
+	LockAccount(ctx context.Context, email string) error
+}
+
+// BreachedPasswords tells whether a password appeared in a known data breach.
+type BreachedPasswords interface {
+	Breached(password string) bool
 }
 
 // New -.
@@ -43,6 +91,14 @@ func New(
 	tokenGenerator jwtgen.TokenGenerator,
 	emailSender email.Sender,
 	refreshTokenTTL time.Duration,
+	idTokenAudience string,
+	verification EmailVerificationConfig,
+	passwordless PasswordlessConfig,
+	codes OneTimeCodes,
+	secondFactor SecondFactor,
+	passkeys Passkeys,
+	lockout Lockout,
+	breaches BreachedPasswords,
 ) *Service {
 	return &Service{
 		log:             log,
@@ -52,6 +108,14 @@ func New(
 		tokenGenerator:  tokenGenerator,
 		emailSender:     emailSender,
 		refreshTokenTTL: refreshTokenTTL,
+		idTokenAudience: idTokenAudience,
+		verification:    verification,
+		passwordless:    passwordless,
+		codes:           codes,
+		secondFactor:    secondFactor,
+		passkeys:        passkeys,
+		lockout:         lockout,
+		breaches:        breaches,
 	}
 }
 
@@ -59,6 +123,10 @@ func (s *Service) CreateUser(ctx context.Context, input CreateUserInput) (uuid.U
 	const op = "service.auth.CreateUser"
 	log := s.log.With(slog.String("op", op))
 
+	if s.breached(log, input.Password) {
+		return uuid.Nil, svcErrs.ErrBreachedPassword
+	}
+
 	hashedPassword, err := s.hasher.Hash(input.Password)
 	if err != nil {
 		log.Error("failed to generate hashed password", sl.Err(err))
@@ -81,88 +149,405 @@ func (s *Service) CreateUser(ctx context.Context, input CreateUserInput) (uuid.U
 		log.Error("failed to create new user", sl.Err(err))
 		return uuid.Nil, svcErrs.ErrCannotCreateUser
 	}
+
+	// the account exists either way, the user can ask for another email
+	_ = s.sendVerification(ctx, log, user)
+
 	return user.Id, nil
 }
 
+// GenerateToken signs the user in. Users with two-factor authentication get only an MFA token
+// that VerifyMFA exchanges for tokens.
 func (s *Service) GenerateToken(ctx context.Context, input GenerateTokenInput) (GenerateTokenOutput, error) {
-	const op = "service.auth.GenerateToken"
+	user, err := s.Authenticate(ctx, input.Email, input.Password, input.IP)
+	if err != nil {
+		return GenerateTokenOutput{}, err
+	}
+
+	output, err := s.SignIn(ctx, user, IssueTokensInput{
+		Device:    input.Device,
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Nonce:     input.Nonce,
+	})
+	if err != nil {
+		return GenerateTokenOutput{}, err
+	}
+
+	s.rehash(ctx, user, input.Password)
+
+	return output, nil
+}
+
+// rehash replaces an outdated hash of the password, of an old algorithm or cost, while the password is known.
+// The sign-in goes on when it fails, the old hash still matches.
+func (s *Service) rehash(ctx context.Context, user entity.User, password string) {
+	const op = "service.auth.rehash"
+	log := s.log.With(slog.String("op", op))
+
+	if !s.hasher.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Error("failed to generate hashed password", sl.Err(err))
+		return
+	}
+
+	if err = s.userRepo.UpdatePassword(ctx, user.Email, hash); err != nil {
+		log.Error("failed to update password", sl.Err(err))
+		return
+	}
+
+	log.Info("password hash upgraded", slog.String("user_id", user.Id.String()))
+}
+
+// SignIn starts a session for a user who proved the first factor, e.g. at an external provider.
+// Users with two-factor authentication get only an MFA token that VerifyMFA exchanges for tokens.
+func (s *Service) SignIn(ctx context.Context, user entity.User, input IssueTokensInput) (GenerateTokenOutput, error) {
+	const op = "service.auth.SignIn"
 	log := s.log.With(slog.String("op", op))
 
-	user, err := s.userRepo.UserByEmail(ctx, input.Email)
+	required, err := s.mfaRequired(ctx, user)
+	if err != nil {
+		return GenerateTokenOutput{}, err
+	}
+
+	if required {
+		token, err := s.newMFAChallenge(ctx, user, input)
+		if err != nil {
+			log.Error("failed to save mfa challenge", sl.Err(err))
+			return GenerateTokenOutput{}, svcErrs.ErrAccessToCache
+		}
+
+		return GenerateTokenOutput{MFAToken: token}, nil
+	}
+
+	return s.IssueTokens(ctx, user, input)
+}
+
+// Authenticate checks the credentials of the user signing in from the IP. Too many failures lock the account
+// or the IP out for a while, the error is then a svcErrs.RetryAfterError. Users with an unverified email
+// are rejected when verification is required, whichever flow they sign in with.
+func (s *Service) Authenticate(ctx context.Context, email, password, ip string) (entity.User, error) {
+	const op = "service.auth.Authenticate"
+	log := s.log.With(slog.String("op", op))
+
+	if s.lockout != nil {
+		if err := s.lockout.Check(ctx, email, ip); err != nil {
+			return entity.User{}, err
+		}
+	}
+
+	user, err := s.userRepo.UserByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, repoErrs.ErrNotFound) {
 			log.Error("Cannot get user", sl.Err(err))
-			return GenerateTokenOutput{}, svcErrs.ErrInvalidCredentials
+			return entity.User{}, s.failSignIn(ctx, email, ip)
 		}
 
 		log.Error("Cannot get user", sl.Err(err))
-		return GenerateTokenOutput{}, svcErrs.ErrCannotGetUser
+		return entity.User{}, svcErrs.ErrCannotGetUser
 	}
 
-	if err = s.hasher.Compare(user.PasswordHash, []byte(input.Password)); err != nil {
+	if err = s.hasher.Compare(user.PasswordHash, []byte(password)); err != nil {
 		log.Error("failed to compare password", sl.Err(err))
-		return GenerateTokenOutput{}, svcErrs.ErrInvalidCredentials
+		return entity.User{}, s.failSignIn(ctx, email, ip)
+	}
+
+	if s.lockout != nil {
+		s.lockout.Reset(ctx, email)
+	}
+
+	// checked after the password, so that it does not tell which emails are registered
+	if s.verification.Required && !user.IsEmailVerified() {
+		return entity.User{}, svcErrs.ErrEmailNotVerified
 	}
 
-	return s.generateTokens(ctx, log, user)
+	return user, nil
 }
 
+// failSignIn counts a failed sign-in and returns the error for it, the lockout error when it locked the account.
+func (s *Service) failSignIn(ctx context.Context, email, ip string) error {
+	if s.lockout == nil {
+		return svcErrs.ErrInvalidCredentials
+	}
+
+	if err := s.lockout.Fail(ctx, email, ip); err != nil {
+		return err
+	}
+
+	return svcErrs.ErrInvalidCredentials
+}
+
+// IssueTokens starts a new session for an authenticated user.
+func (s *Service) IssueTokens(ctx context.Context, user entity.User, input IssueTokensInput) (GenerateTokenOutput, error) {
+	const op = "service.auth.IssueTokens"
+	log := s.log.With(slog.String("op", op))
+
+	session := newSessionRecord(user.Id, input.Device, input.IP, input.UserAgent)
+	session.EmailVerified = user.IsEmailVerified()
+	session.Scope = input.Scope
+	if input.Client != nil {
+		session.ClientId = input.Client.Id
+		session.Audience = input.Client.Audience
+		session.AccessTokenTTL = input.Client.AccessTokenTTL
+		session.RefreshTokenTTL = input.Client.RefreshTokenTTL
+	}
+	if !input.AuthTime.IsZero() {
+		session.AuthTime = input.AuthTime
+	}
+
+	return s.generateTokens(ctx, log, user, session, input.Nonce)
+}
+
+// Refresh rotates the refresh token of a first-party session, the tokens of OAuth clients are refused.
 func (s *Service) Refresh(ctx context.Context, token string) (GenerateTokenOutput, error) {
 	const op = "service.auth.Refresh"
 	log := s.log.With(slog.String("op", op))
 
+	return s.refresh(ctx, log, token, "")
+}
+
+// RefreshClient rotates a refresh token issued to the OAuth client, for the refresh_token grant.
+func (s *Service) RefreshClient(ctx context.Context, token, clientId string) (GenerateTokenOutput, error) {
+	const op = "service.auth.RefreshClient"
+	log := s.log.With(slog.String("op", op))
+
+	return s.refresh(ctx, log, token, clientId)
+}
+
+// refresh rotates the refresh token, which must have been issued to clientId (empty for first-party sessions).
+func (s *Service) refresh(ctx context.Context, log *slog.Logger, token, clientId string) (GenerateTokenOutput, error) {
 	claims, err := s.tokenGenerator.ParseRefreshToken(token)
 	if err != nil {
 		log.Error("failed to parse refresh token", sl.Err(err))
 		return GenerateTokenOutput{}, svcErrs.ErrCannotParseToken
 	}
 
-	stored, err := s.cache.Get(ctx, fmt.Sprintf(refreshKeyTemplate, claims.UserId))
+	if claims.AuthorizedParty != clientId {
+		log.Warn("refresh token presented by another client",
+			sl.SecurityEvent("refresh_token_client_mismatch"),
+			slog.String("client_id", clientId),
+			slog.String("azp", claims.AuthorizedParty),
+		)
+		return GenerateTokenOutput{}, svcErrs.ErrTokenNotIssuedToClient
+	}
+
+	session, err := s.session(ctx, claims.SessionId)
 	if err != nil {
-		log.Error("failed to refresh token", sl.Err(err))
+		if s.isSessionFamilyRevoked(ctx, claims.SessionId) {
+			log.Warn("refresh token of a revoked family was presented",
+				sl.SecurityEvent("refresh_token_reuse"),
+				slog.String("user_id", claims.UserId.String()),
+				slog.String("session_id", claims.SessionId.String()),
+			)
+			return GenerateTokenOutput{}, svcErrs.ErrRefreshTokenReused
+		}
+
+		log.Error("failed to get session", sl.Err(err))
 		return GenerateTokenOutput{}, svcErrs.ErrTokenIsExpired
 	}
 
-	if token != stored {
-		log.Error("stored and input token is not equal")
+	if session.UserId != claims.UserId {
+		log.Error("session belongs to another user")
 		return GenerateTokenOutput{}, svcErrs.ErrTokenIsExpired
 	}
 
-	return s.generateTokens(ctx, log, entity.User{Id: claims.UserId, Email: claims.Email})
+	if session.RefreshTokenId != claims.ID {
+		// The token is correctly signed but was already rotated:
+		// either the client or an attacker holds a stolen copy, so the whole family goes.
+		return GenerateTokenOutput{}, s.refreshTokenReused(ctx, log, session)
+	}
+
+	// concurrent refreshes with the same token all pass the check above, only the first one claims it
+	claimed, err := s.claimRefreshToken(ctx, claims)
+	if err != nil {
+		log.Error("failed to claim the refresh token", sl.Err(err))
+		return GenerateTokenOutput{}, svcErrs.ErrAccessToCache
+	}
+
+	if !claimed {
+		return GenerateTokenOutput{}, s.refreshTokenReused(ctx, log, session)
+	}
+
+	if !session.EmailVerified {
+		// the email may have been verified since the sign-in
+		user, err := s.userRepo.UserById(ctx, session.UserId)
+		if err != nil {
+			log.Error("failed to get user", sl.Err(err))
+		} else {
+			session.EmailVerified = user.IsEmailVerified()
+		}
+	}
+
+	return s.generateTokens(ctx, log, entity.User{Id: claims.UserId, Email: claims.Email}, session, "")
 }
 
-func (s *Service) generateTokens(ctx context.Context, log *slog.Logger, user entity.User) (GenerateTokenOutput, error) {
-	accessToken, err := s.tokenGenerator.GenerateAccessToken(user)
+// refreshTokenReused revokes the token family of the session a rotated refresh token was presented for.
+func (s *Service) refreshTokenReused(ctx context.Context, log *slog.Logger, session sessionRecord) error {
+	log.Warn("refresh token reuse detected, revoking the token family",
+		sl.SecurityEvent("refresh_token_reuse"),
+		slog.String("user_id", session.UserId.String()),
+		slog.String("session_id", session.Id.String()),
+		slog.String("ip", session.IP),
+	)
+
+	if err := s.revokeSessionFamily(ctx, session); err != nil {
+		log.Error("failed to revoke the token family", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	return svcErrs.ErrRefreshTokenReused
+}
+
+func (s *Service) generateTokens(ctx context.Context, log *slog.Logger, user entity.User, session sessionRecord, nonce string) (GenerateTokenOutput, error) {
+	accessToken, err := s.tokenGenerator.GenerateAccessToken(user, jwtgen.TokenParams{
+		SessionId:       session.Id,
+		TokenId:         uuid.NewString(),
+		Audience:        session.Audience,
+		AuthorizedParty: session.ClientId,
+		EmailVerified:   session.EmailVerified,
+		TTL:             session.AccessTokenTTL,
+	})
 	if err != nil {
 		log.Error("failed to generate access token", sl.Err(err))
 		return GenerateTokenOutput{}, svcErrs.ErrCannotSignToken
 	}
 
-	refreshToken, err := s.tokenGenerator.GenerateRefreshToken(user)
+	// every refresh token gets a new id, rotating the session's token family
+	refreshTokenId := uuid.NewString()
+
+	refreshToken, err := s.tokenGenerator.GenerateRefreshToken(user, jwtgen.TokenParams{
+		SessionId:       session.Id,
+		TokenId:         refreshTokenId,
+		AuthorizedParty: session.ClientId,
+		EmailVerified:   session.EmailVerified,
+		TTL:             session.RefreshTokenTTL,
+	})
 	if err != nil {
 		log.Error("failed to generate refresh token", sl.Err(err))
 		return GenerateTokenOutput{}, svcErrs.ErrCannotSignToken
 	}
 
+	var idToken string
+	if session.ClientId == "" || hasScope(session.Scope, ScopeOpenId) {
+		audience := session.ClientId
+		if audience == "" {
+			audience = s.idTokenAudience
+		}
+
+		idToken, err = s.tokenGenerator.GenerateIDToken(user, jwtgen.IDTokenParams{
+			Audience:        audience,
+			Nonce:           nonce,
+			AuthTime:        session.AuthTime,
+			EmailVerified:   session.EmailVerified,
+			AuthorizedParty: session.ClientId,
+			TTL:             session.AccessTokenTTL,
+		})
+		if err != nil {
+			log.Error("failed to generate id token", sl.Err(err))
+			return GenerateTokenOutput{}, svcErrs.ErrCannotSignToken
+		}
+	}
+
 	//TODO delete field and func`s
 	if err = s.userRepo.UpdateLastLoginAttempt(ctx, user.Id); err != nil {
 		log.Error("failed to update last_login_attempt", sl.Err(err))
 	}
 
-	// TODO solve the problem with authentication from two different realms.
-	//  storing token as a key?
-	err = s.cache.Set(ctx, fmt.Sprintf(refreshKeyTemplate, user.Id), refreshToken, s.refreshTokenTTL)
-	if err != nil {
-		log.Error("failed to save refresh token to cache", sl.Err(err))
+	session.RefreshTokenId = refreshTokenId
+	session.LastUsedAt = time.Now()
+
+	if err = s.saveSession(ctx, session); err != nil {
+		log.Error("failed to save session to cache", sl.Err(err))
 		return GenerateTokenOutput{}, svcErrs.ErrAccessToCache
 	}
 
 	return GenerateTokenOutput{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
+		IdToken:      idToken,
+		Scope:        session.Scope,
 	}, nil
 }
 
+// IssueServiceToken issues an access token whose subject is the client, for the client_credentials grant.
+// No refresh token or session is created, the client authenticates again when the token expires.
+func (s *Service) IssueServiceToken(ctx context.Context, client entity.Client, scope string) (string, error) {
+	const op = "service.auth.IssueServiceToken"
+	log := s.log.With(slog.String("op", op))
+
+	token, err := s.tokenGenerator.GenerateServiceToken(jwtgen.ServiceTokenParams{
+		ClientId: client.Id,
+		Audience: client.Audience,
+		Scope:    scope,
+		TokenId:  uuid.NewString(),
+		TTL:      client.AccessTokenTTL,
+	})
+	if err != nil {
+		log.Error("failed to generate service token", sl.Err(err))
+		return "", svcErrs.ErrCannotSignToken
+	}
+
+	log.Info("service token issued", slog.String("client_id", client.Id))
+
+	return token, nil
+}
+
+func (s *Service) Sessions(ctx context.Context, userId uuid.UUID) ([]entity.Session, error) {
+	const op = "service.auth.Sessions"
+	log := s.log.With(slog.String("op", op))
+
+	userKey := fmt.Sprintf(userSessionsKeyTemplate, userId)
+
+	ids, err := s.cache.SMembers(ctx, userKey)
+	if err != nil {
+		log.Error("failed to get user sessions", sl.Err(err))
+		return nil, svcErrs.ErrAccessToCache
+	}
+
+	sessions := make([]entity.Session, 0, len(ids))
+	for _, id := range ids {
+		sessionId, err := uuid.Parse(id)
+		if err != nil {
+			log.Error("invalid session id in index", slog.String("session_id", id))
+			continue
+		}
+
+		session, err := s.session(ctx, sessionId)
+		if err != nil {
+			// the session has expired, drop it from the index
+			if err = s.cache.SRem(ctx, userKey, id); err != nil {
+				log.Error("failed to remove expired session from index", sl.Err(err))
+			}
+			continue
+		}
+
+		sessions = append(sessions, session.Session)
+	}
+
+	return sessions, nil
+}
+
+func (s *Service) RevokeSession(ctx context.Context, userId, sessionId uuid.UUID) error {
+	const op = "service.auth.RevokeSession"
+	log := s.log.With(slog.String("op", op))
+
+	session, err := s.session(ctx, sessionId)
+	if err != nil || session.UserId != userId {
+		return svcErrs.ErrSessionNotFound
+	}
+
+	if err = s.endSession(ctx, session); err != nil {
+		log.Error("failed to end session", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	return nil
+}
+
 func (s *Service) ResetPassword(ctx context.Context, input ResetPasswordInput) error {
 	const op = "service.auth.ResetPassword"
 	log := s.log.With(slog.String("op", op))
@@ -197,6 +582,11 @@ func (s *Service) RecoveryPassword(ctx context.Context, input RecoveryPasswordIn
 	const op = "service.auth.RecoveryPassword"
 	log := s.log.With(slog.String("op", op))
 
+	// checked first, the reset token stays valid for another password
+	if s.breached(log, input.Password) {
+		return svcErrs.ErrBreachedPassword
+	}
+
 	userEmail, err := s.cache.Get(ctx, fmt.Sprintf(resetKeyTemplate, input.Token))
 	if err != nil {
 		log.Error("failed to get the reset token", sl.Err(err))
@@ -223,7 +613,17 @@ func (s *Service) RecoveryPassword(ctx context.Context, input RecoveryPasswordIn
 	return nil
 }
 
-func (s *Service) ParseToken(token string) (*jwtgen.Claims, error) {
+// breached reports whether the new password appeared in a known data breach.
+func (s *Service) breached(log *slog.Logger, password string) bool {
+	if s.breaches == nil || !s.breaches.Breached(password) {
+		return false
+	}
+
+	log.Info("breached password rejected", sl.SecurityEvent("breached_password_rejected"))
+	return true
+}
+
+func (s *Service) ParseToken(ctx context.Context, token string) (*jwtgen.Claims, error) {
 	const op = "service.auth.ParseToken"
 	log := s.log.With(slog.String("op", op))
 
@@ -233,5 +633,188 @@ func (s *Service) ParseToken(token string) (*jwtgen.Claims, error) {
 		return nil, svcErrs.ErrCannotParseToken
 	}
 
+	denied, err := s.isTokenDenied(ctx, claims)
+	if err != nil {
+		log.Error("failed to check the token denylist", sl.Err(err))
+		return nil, svcErrs.ErrAccessToCache
+	}
+
+	if denied {
+		log.Warn("revoked access token was presented",
+			sl.SecurityEvent("revoked_token_used"),
+			slog.String("jti", claims.ID),
+		)
+		return nil, svcErrs.ErrTokenRevoked
+	}
+
 	return claims, nil
 }
+
+// IntrospectToken reports whether the token is active: correctly signed, not expired and not revoked.
+// The hint only decides which kind of token is tried first.
+// Tokens of a user are active while their session is, a rotated refresh token is not active.
+func (s *Service) IntrospectToken(ctx context.Context, token, hint string) (TokenInfo, error) {
+	claims, tokenType := s.parseAnyToken(token, hint)
+	if claims == nil {
+		return TokenInfo{}, nil
+	}
+
+	return s.tokenInfo(ctx, claims, tokenType), nil
+}
+
+// RevokeToken revokes a token on request of the client it was issued to (RFC 7009).
+// A revoked access token goes on the denylist, a revoked refresh token ends its session.
+// Invalid, expired and already revoked tokens are ignored.
+func (s *Service) RevokeToken(ctx context.Context, token, hint, clientId string) error {
+	const op = "service.auth.RevokeToken"
+	log := s.log.With(slog.String("op", op))
+
+	claims, tokenType := s.parseAnyToken(token, hint)
+	if claims == nil {
+		return nil
+	}
+
+	if claims.AuthorizedParty != clientId {
+		log.Warn("client tried to revoke a token issued to another party",
+			sl.SecurityEvent("foreign_token_revocation"),
+			slog.String("client_id", clientId),
+		)
+		return svcErrs.ErrTokenNotIssuedToClient
+	}
+
+	if tokenType == TokenTypeAccessToken {
+		if err := s.denyToken(ctx, claims); err != nil {
+			log.Error("failed to put the token on the denylist", sl.Err(err))
+			return svcErrs.ErrAccessToCache
+		}
+
+		return nil
+	}
+
+	session, err := s.session(ctx, claims.SessionId)
+	if err != nil || session.UserId != claims.UserId || session.RefreshTokenId != claims.ID {
+		// the session is already gone or the token was rotated, it is not usable anyway
+		return nil
+	}
+
+	if err = s.endSession(ctx, session); err != nil {
+		log.Error("failed to end session", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	return nil
+}
+
+// Logout revokes the access token the user presented and ends its session,
+// the refresh token and the other access tokens of the session can no longer be used.
+func (s *Service) Logout(ctx context.Context, claims *jwtgen.Claims) error {
+	const op = "service.auth.Logout"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.denyToken(ctx, claims); err != nil {
+		log.Error("failed to put the token on the denylist", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	session, err := s.session(ctx, claims.SessionId)
+	if err != nil || session.UserId != claims.UserId {
+		// the session already ended, the token presented is denied above
+		return nil
+	}
+
+	if err = s.endSession(ctx, session); err != nil {
+		log.Error("failed to end session", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	return nil
+}
+
+// parseAnyToken parses an access or a refresh token, the hint decides which kind is tried first.
+// The claims are nil when the token is neither.
+func (s *Service) parseAnyToken(token, hint string) (*jwtgen.Claims, string) {
+	parsers := []struct {
+		tokenType string
+		parse     func(string) (*jwtgen.Claims, error)
+	}{
+		{TokenTypeAccessToken, s.tokenGenerator.ParseAccessToken},
+		{TokenTypeRefreshToken, s.tokenGenerator.ParseRefreshToken},
+	}
+	if hint == TokenTypeRefreshToken {
+		parsers[0], parsers[1] = parsers[1], parsers[0]
+	}
+
+	for _, p := range parsers {
+		if claims, err := p.parse(token); err == nil {
+			return claims, p.tokenType
+		}
+	}
+
+	return nil, ""
+}
+
+func (s *Service) tokenInfo(ctx context.Context, claims *jwtgen.Claims, tokenType string) TokenInfo {
+	info := TokenInfo{
+		Active:    true,
+		ClientId:  claims.AuthorizedParty,
+		TokenId:   claims.ID,
+		TokenType: tokenType,
+	}
+	if claims.ExpiresAt != nil {
+		info.ExpiresAt = claims.ExpiresAt.Time
+	}
+	if claims.IssuedAt != nil {
+		info.IssuedAt = claims.IssuedAt.Time
+	}
+
+	if tokenType == TokenTypeAccessToken {
+		if denied, err := s.isTokenDenied(ctx, claims); err != nil || denied {
+			return TokenInfo{}
+		}
+	}
+
+	if claims.IsService() {
+		info.Subject = claims.Subject
+		info.Scope = claims.Scope
+		return info
+	}
+
+	session, err := s.session(ctx, claims.SessionId)
+	if err != nil || session.UserId != claims.UserId {
+		return TokenInfo{}
+	}
+
+	if tokenType == TokenTypeRefreshToken && session.RefreshTokenId != claims.ID {
+		return TokenInfo{}
+	}
+
+	info.Subject = claims.UserId.String()
+	info.Scope = session.Scope
+
+	return info
+}
+
+// JWKS returns the public keys that verify access tokens.
+// The set is empty when tokens are signed with a shared secret.
+func (s *Service) JWKS() (jwtgen.JWKS, error) {
+	const op = "service.auth.JWKS"
+	log := s.log.With(slog.String("op", op))
+
+	set, err := jwtgen.NewJWKS(s.tokenGenerator.PublicKeys())
+	if err != nil {
+		log.Error("failed to encode public keys", sl.Err(err))
+		return jwtgen.JWKS{}, svcErrs.ErrCannotGetKeys
+	}
+
+	return set, nil
+}
+
+func hasScope(scope, want string) bool {
+	for _, sc := range strings.Fields(scope) {
+		if sc == want {
+			return true
+		}
+	}
+
+	return false
+}
@@ -3,27 +3,37 @@ package auth
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/bubalync/uni-auth/internal/entity"
 	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
+	"github.com/bubalync/uni-auth/internal/mocks/authmocks"
 	"github.com/bubalync/uni-auth/internal/mocks/redismocks"
 	"github.com/bubalync/uni-auth/internal/mocks/repomocks"
 	"github.com/bubalync/uni-auth/internal/mocks/utilmocks"
 	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/otp"
+	"github.com/bubalync/uni-auth/internal/service/passkey"
 	"github.com/bubalync/uni-auth/internal/service/svcErrs"
 	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"sync"
 	"testing"
 	"time"
 )
 
 const (
 	refreshTokenTTL = time.Minute
+	idTokenAudience = "uni-auth"
 )
 
+var emailVerification = EmailVerificationConfig{TokenTTL: 24 * time.Hour, ResendInterval: time.Minute}
+
 func boolPointer(b bool) *bool {
 	return &b
 }
@@ -42,13 +52,28 @@ func (m partialUserMatcher) String() string {
 	return fmt.Sprintf("matches User with Email=%s", m.Email)
 }
 
+func sessionJSON(t *testing.T, sessionId, userId uuid.UUID, refreshTokenId string) string {
+	rec := sessionRecord{
+		Session:        entity.Session{Id: sessionId, UserId: userId},
+		RefreshTokenId: refreshTokenId,
+		EmailVerified:  true,
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
 func TestAuthService_CreateUser(t *testing.T) {
 	type args struct {
 		ctx   context.Context
 		input CreateUserInput
 	}
 
-	type MockBehavior func(o *repomocks.MockUser, h *utilmocks.MockPasswordHasher, args args)
+	type MockBehavior func(o *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, e *utilmocks.MockSender, args args)
 
 	testCases := []struct {
 		name         string
@@ -66,7 +91,7 @@ func TestAuthService_CreateUser(t *testing.T) {
 					Password: "Qwerty!1",
 				},
 			},
-			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, args args) {
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, e *utilmocks.MockSender, args args) {
 				hash := []byte{1, 2, 3}
 				h.EXPECT().Hash(args.input.Password).Return(hash, nil)
 
@@ -76,6 +101,28 @@ func TestAuthService_CreateUser(t *testing.T) {
 				}
 				r.EXPECT().Create(args.ctx, user).
 					Return(nil)
+				c.EXPECT().Set(args.ctx, gomock.Any(), gomock.Any(), 24*time.Hour).Return(nil)
+				c.EXPECT().Set(args.ctx, "verification_sent:test@example.com", "1", time.Minute).Return(nil)
+				e.EXPECT().SendVerificationEmail(args.input.Email, gomock.Any()).Return(nil)
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "verification email error",
+			args: args{
+				ctx: context.Background(),
+				input: CreateUserInput{
+					Email:    "test@example.com",
+					Password: "Qwerty!1",
+				},
+			},
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, e *utilmocks.MockSender, args args) {
+				hash := []byte{1, 2, 3}
+				h.EXPECT().Hash(args.input.Password).Return(hash, nil)
+				r.EXPECT().Create(args.ctx, gomock.Any()).Return(nil)
+				c.EXPECT().Set(args.ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+				e.EXPECT().SendVerificationEmail(args.input.Email, gomock.Any()).Return(errors.New("some error"))
 			},
 			wantErr: false,
 			err:     nil,
@@ -89,7 +136,7 @@ func TestAuthService_CreateUser(t *testing.T) {
 					Password: "Qwerty!1",
 				},
 			},
-			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, args args) {
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, e *utilmocks.MockSender, args args) {
 				h.EXPECT().Hash(args.input.Password).Return(nil, errors.New("some error"))
 			},
 			wantErr: true,
@@ -104,7 +151,7 @@ func TestAuthService_CreateUser(t *testing.T) {
 					Password: "Qwerty!1",
 				},
 			},
-			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, args args) {
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, e *utilmocks.MockSender, args args) {
 				hash := []byte{1, 2, 3}
 				h.EXPECT().Hash(args.input.Password).Return(hash, nil)
 
@@ -127,7 +174,7 @@ func TestAuthService_CreateUser(t *testing.T) {
 					Password: "Qwerty!1",
 				},
 			},
-			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, args args) {
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, e *utilmocks.MockSender, args args) {
 				hash := []byte{1, 2, 3}
 				h.EXPECT().Hash(args.input.Password).Return(hash, nil)
 
@@ -152,13 +199,15 @@ func TestAuthService_CreateUser(t *testing.T) {
 			// init repo mock
 			repo := repomocks.NewMockUser(ctrl)
 			hasher := utilmocks.NewMockPasswordHasher(ctrl)
-			tc.mockBehavior(repo, hasher, tc.args)
+			cache := redismocks.NewMockCache(ctrl)
+			sender := utilmocks.NewMockSender(ctrl)
+			tc.mockBehavior(repo, hasher, cache, sender, tc.args)
 
 			// Log
 			log := logger.New("local", "info")
 
 			// init service
-			s := New(log, nil, repo, hasher, nil, nil, refreshTokenTTL)
+			s := New(log, cache, repo, hasher, nil, sender, refreshTokenTTL, idTokenAudience, emailVerification, PasswordlessConfig{}, nil, nil, nil, nil, nil)
 
 			// run test
 			got, err := s.CreateUser(tc.args.ctx, tc.args.input)
@@ -192,6 +241,69 @@ func TestAuthService_GenerateToken(t *testing.T) {
 	}{
 		{
 			name: "OK",
+			args: args{
+				ctx: context.Background(),
+				input: GenerateTokenInput{
+					Email:    "test@example.com",
+					Password: "Qwerty!1",
+					Nonce:    "n-0S6_WzA2Mj",
+				},
+			},
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				hash := []byte(args.input.Password)
+				user := entity.User{Id: uuid.New(), PasswordHash: hash, Email: args.input.Email}
+
+				r.EXPECT().UserByEmail(args.ctx, args.input.Email).Return(user, nil)
+				h.EXPECT().Compare(hash, hash).Return(nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("refresh_token", nil)
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.IDTokenParams) (string, error) {
+						assert.Equal(t, idTokenAudience, params.Audience)
+						assert.Equal(t, args.input.Nonce, params.Nonce)
+						assert.False(t, params.AuthTime.IsZero())
+						return "id_token", nil
+					})
+				r.EXPECT().UpdateLastLoginAttempt(args.ctx, user.Id).Return(nil)
+				c.EXPECT().Set(args.ctx, gomock.Any(), gomock.Any(), refreshTokenTTL).Return(nil)
+				c.EXPECT().SAdd(args.ctx, "sessions:"+user.Id.String(), gomock.Any()).Return(nil)
+				c.EXPECT().Expire(args.ctx, "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
+				h.EXPECT().NeedsRehash(hash).Return(false)
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "OK: outdated hash is upgraded",
+			args: args{
+				ctx: context.Background(),
+				input: GenerateTokenInput{
+					Email:    "test@example.com",
+					Password: "Qwerty!1",
+				},
+			},
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				hash := []byte(args.input.Password)
+				user := entity.User{Id: uuid.New(), PasswordHash: hash, Email: args.input.Email}
+
+				r.EXPECT().UserByEmail(args.ctx, args.input.Email).Return(user, nil)
+				h.EXPECT().Compare(hash, hash).Return(nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("refresh_token", nil)
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).Return("id_token", nil)
+				r.EXPECT().UpdateLastLoginAttempt(args.ctx, user.Id).Return(nil)
+				c.EXPECT().Set(args.ctx, gomock.Any(), gomock.Any(), refreshTokenTTL).Return(nil)
+				c.EXPECT().SAdd(args.ctx, "sessions:"+user.Id.String(), gomock.Any()).Return(nil)
+				c.EXPECT().Expire(args.ctx, "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
+				h.EXPECT().NeedsRehash(hash).Return(true)
+				h.EXPECT().Hash(args.input.Password).Return([]byte("$argon2id$new"), nil)
+				r.EXPECT().UpdatePassword(args.ctx, user.Email, []byte("$argon2id$new")).Return(nil)
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "OK: upgrade of the hash fails",
 			args: args{
 				ctx: context.Background(),
 				input: GenerateTokenInput{
@@ -205,10 +317,16 @@ func TestAuthService_GenerateToken(t *testing.T) {
 
 				r.EXPECT().UserByEmail(args.ctx, args.input.Email).Return(user, nil)
 				h.EXPECT().Compare(hash, hash).Return(nil)
-				g.EXPECT().GenerateAccessToken(user).Return("access_token", nil)
-				g.EXPECT().GenerateRefreshToken(user).Return("refresh_token", nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("refresh_token", nil)
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).Return("id_token", nil)
 				r.EXPECT().UpdateLastLoginAttempt(args.ctx, user.Id).Return(nil)
-				c.EXPECT().Set(args.ctx, "refresh:"+user.Id.String(), "refresh_token", refreshTokenTTL).Return(nil)
+				c.EXPECT().Set(args.ctx, gomock.Any(), gomock.Any(), refreshTokenTTL).Return(nil)
+				c.EXPECT().SAdd(args.ctx, "sessions:"+user.Id.String(), gomock.Any()).Return(nil)
+				c.EXPECT().Expire(args.ctx, "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
+				h.EXPECT().NeedsRehash(hash).Return(true)
+				h.EXPECT().Hash(args.input.Password).Return([]byte("$argon2id$new"), nil)
+				r.EXPECT().UpdatePassword(args.ctx, user.Email, []byte("$argon2id$new")).Return(errors.New("some error"))
 			},
 			wantErr: false,
 			err:     nil,
@@ -278,7 +396,7 @@ func TestAuthService_GenerateToken(t *testing.T) {
 				r.EXPECT().UserByEmail(args.ctx, args.input.Email).Return(user, nil)
 
 				h.EXPECT().Compare(hash, hash).Return(nil)
-				g.EXPECT().GenerateAccessToken(user).Return("", errors.New("some error"))
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("", errors.New("some error"))
 			},
 			wantErr: true,
 			err:     svcErrs.ErrCannotSignToken,
@@ -299,8 +417,31 @@ func TestAuthService_GenerateToken(t *testing.T) {
 				r.EXPECT().UserByEmail(args.ctx, args.input.Email).Return(user, nil)
 
 				h.EXPECT().Compare(hash, hash).Return(nil)
-				g.EXPECT().GenerateAccessToken(user).Return("access_token", nil)
-				g.EXPECT().GenerateRefreshToken(user).Return("", errors.New("some error"))
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("", errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrCannotSignToken,
+		},
+		{
+			name: "generate id token error",
+			args: args{
+				ctx: context.Background(),
+				input: GenerateTokenInput{
+					Email:    "test@example.com",
+					Password: "Qwerty!1",
+				},
+			},
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				hash := []byte(args.input.Password)
+				user := entity.User{Id: uuid.New(), PasswordHash: hash, Email: args.input.Email}
+
+				r.EXPECT().UserByEmail(args.ctx, args.input.Email).Return(user, nil)
+
+				h.EXPECT().Compare(hash, hash).Return(nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("refresh_token", nil)
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).Return("", errors.New("some error"))
 			},
 			wantErr: true,
 			err:     svcErrs.ErrCannotSignToken,
@@ -321,10 +462,11 @@ func TestAuthService_GenerateToken(t *testing.T) {
 				r.EXPECT().UserByEmail(args.ctx, args.input.Email).Return(user, nil)
 
 				h.EXPECT().Compare(hash, hash).Return(nil)
-				g.EXPECT().GenerateAccessToken(user).Return("access_token", nil)
-				g.EXPECT().GenerateRefreshToken(user).Return("refresh_token", nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("refresh_token", nil)
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).Return("id_token", nil)
 				r.EXPECT().UpdateLastLoginAttempt(args.ctx, user.Id).Return(errors.New("some update error"))
-				c.EXPECT().Set(args.ctx, "refresh:"+user.Id.String(), "refresh_token", refreshTokenTTL).Return(errors.New("some error"))
+				c.EXPECT().Set(args.ctx, gomock.Any(), gomock.Any(), refreshTokenTTL).Return(errors.New("some error"))
 			},
 			wantErr: true,
 			err:     svcErrs.ErrAccessToCache,
@@ -349,7 +491,7 @@ func TestAuthService_GenerateToken(t *testing.T) {
 			log := logger.New("local", "info")
 
 			// init service
-			s := New(log, cache, repo, hasher, tokenGenerator, nil, refreshTokenTTL)
+			s := New(log, cache, repo, hasher, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
 
 			// run test
 			got, err := s.GenerateToken(tc.args.ctx, tc.args.input)
@@ -363,377 +505,663 @@ func TestAuthService_GenerateToken(t *testing.T) {
 			assert.NotNil(t, got)
 			assert.NotEqual(t, "", got.AccessToken)
 			assert.NotEqual(t, "", got.RefreshToken)
+			assert.NotEqual(t, "", got.IdToken)
 		})
 	}
 }
 
-func TestAuthService_ParseToken(t *testing.T) {
-	type args struct {
-		ctx   context.Context
-		token string
-	}
-
-	type MockBehavior func(g *utilmocks.MockTokenGenerator, args args)
+func TestAuthService_BreachedPassword(t *testing.T) {
+	ctx := context.Background()
 
+	// the breached password is rejected before the token or the code are used
 	testCases := []struct {
-		name         string
-		args         args
-		mockBehavior MockBehavior
-		wantErr      bool
-		err          error
+		name string
+		call func(s *Service) error
 	}{
 		{
-			name: "OK",
-			args: args{
-				ctx:   context.Background(),
-				token: "valid_access_token",
-			},
-			mockBehavior: func(g *utilmocks.MockTokenGenerator, args args) {
-				g.EXPECT().ParseAccessToken(args.token).Return(&jwtgen.Claims{}, nil)
+			name: "sign up",
+			call: func(s *Service) error {
+				_, err := s.CreateUser(ctx, CreateUserInput{Email: "test@example.com", Password: "P@ssw0rd!"})
+				return err
 			},
-			wantErr: false,
-			err:     nil,
 		},
 		{
-			name: "some error",
-			args: args{
-				ctx:   context.Background(),
-				token: "invalid_access_token",
+			name: "recovery",
+			call: func(s *Service) error {
+				return s.RecoveryPassword(ctx, RecoveryPasswordInput{Token: "token", Password: "P@ssw0rd!"})
 			},
-			mockBehavior: func(g *utilmocks.MockTokenGenerator, args args) {
-				g.EXPECT().ParseAccessToken(args.token).Return(nil, errors.New("some error"))
+		},
+		{
+			name: "recovery by sms",
+			call: func(s *Service) error {
+				return s.RecoveryPasswordBySMS(ctx, SMSRecoveryPasswordInput{Phone: "+15550100", Code: "123456", Password: "P@ssw0rd!"})
 			},
-			wantErr: true,
-			err:     svcErrs.ErrCannotParseToken,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// init deps
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			// init repo mock
+			cache := redismocks.NewMockCache(ctrl)
 			repo := repomocks.NewMockUser(ctrl)
 			hasher := utilmocks.NewMockPasswordHasher(ctrl)
-			cache := redismocks.NewMockCache(ctrl)
-			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			codes := authmocks.NewMockOneTimeCodes(ctrl)
+			breaches := authmocks.NewMockBreachedPasswords(ctrl)
+			breaches.EXPECT().Breached("P@ssw0rd!").Return(true)
 
-			tc.mockBehavior(tokenGenerator, tc.args)
+			s := New(logger.New("local", "info"), cache, repo, hasher, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, codes, nil, nil, nil, breaches)
 
-			// Log
-			log := logger.New("local", "info")
-
-			// init service
-			s := New(log, cache, repo, hasher, tokenGenerator, nil, refreshTokenTTL)
-
-			// run test
-			got, err := s.ParseToken(tc.args.token)
-			if tc.wantErr {
-				assert.Error(t, err)
-				assert.ErrorIs(t, err, tc.err)
-				return
-			}
-
-			assert.NoError(t, err)
-			assert.NotNil(t, got)
+			assert.ErrorIs(t, tc.call(s), svcErrs.ErrBreachedPassword)
 		})
 	}
 }
 
-func TestAuthService_Refresh(t *testing.T) {
-	type args struct {
-		ctx   context.Context
-		token string
-	}
+func TestAuthService_Authenticate_Lockout(t *testing.T) {
+	ctx := context.Background()
 
-	type MockBehavior func(o *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args)
+	hash := []byte("Qwerty!1")
+	user := entity.User{Id: uuid.New(), PasswordHash: hash, Email: "test@example.com"}
+	locked := &svcErrs.RetryAfterError{Err: svcErrs.ErrAccountLocked, RetryAfter: time.Minute}
+
+	type MockBehavior func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, l *authmocks.MockLockout)
 
 	testCases := []struct {
 		name         string
-		args         args
 		mockBehavior MockBehavior
-		wantErr      bool
-		err          error
+		wantErr      error
 	}{
 		{
 			name: "OK",
-			args: args{
-				ctx:   context.Background(),
-				token: "valid_token",
-			},
-			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
-				claims := &jwtgen.Claims{UserId: uuid.New(), Email: "test@example.com"}
-				user := entity.User{Id: claims.UserId, Email: claims.Email}
-
-				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
-				c.EXPECT().Get(args.ctx, "refresh:"+user.Id.String()).Return(args.token, nil)
-				g.EXPECT().GenerateAccessToken(user).Return("access_token", nil)
-				g.EXPECT().GenerateRefreshToken(user).Return("refresh_token", nil)
-				r.EXPECT().UpdateLastLoginAttempt(args.ctx, user.Id).Return(nil)
-				c.EXPECT().Set(args.ctx, "refresh:"+user.Id.String(), "refresh_token", refreshTokenTTL).Return(nil)
-			},
-			wantErr: false,
-			err:     nil,
-		},
-		{
-			name: "cannot parse refresh token error",
-			args: args{
-				ctx:   context.Background(),
-				token: "invalid_token",
-			},
-			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
-				g.EXPECT().ParseRefreshToken(args.token).Return(nil, errors.New("some error"))
-			},
-			wantErr: true,
-			err:     svcErrs.ErrCannotParseToken,
-		},
-		{
-			name: "get from cache: some error",
-			args: args{
-				ctx:   context.Background(),
-				token: "valid_token",
-			},
-			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
-				claims := &jwtgen.Claims{UserId: uuid.New(), Email: "test@example.com"}
-				user := entity.User{Id: claims.UserId, Email: claims.Email}
-
-				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
-				c.EXPECT().Get(args.ctx, "refresh:"+user.Id.String()).Return("another valid token", nil)
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, l *authmocks.MockLockout) {
+				l.EXPECT().Check(ctx, user.Email, "10.0.0.1").Return(nil)
+				r.EXPECT().UserByEmail(ctx, user.Email).Return(user, nil)
+				h.EXPECT().Compare(hash, hash).Return(nil)
+				l.EXPECT().Reset(ctx, user.Email)
 			},
-			wantErr: true,
-			err:     svcErrs.ErrTokenIsExpired,
 		},
 		{
-			name: "input and stored token is not equal",
-			args: args{
-				ctx:   context.Background(),
-				token: "valid_token",
+			name: "locked",
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, l *authmocks.MockLockout) {
+				l.EXPECT().Check(ctx, user.Email, "10.0.0.1").Return(locked)
 			},
-			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
-				claims := &jwtgen.Claims{UserId: uuid.New(), Email: "test@example.com"}
-				user := entity.User{Id: claims.UserId, Email: claims.Email}
-
-				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
-				c.EXPECT().Get(args.ctx, "refresh:"+user.Id.String()).Return("", errors.New("some error"))
-			},
-			wantErr: true,
-			err:     svcErrs.ErrTokenIsExpired,
+			wantErr: svcErrs.ErrAccountLocked,
 		},
 		{
-			name: "generate access token error",
-			args: args{
-				ctx:   context.Background(),
-				token: "valid_token",
-			},
-			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
-				claims := &jwtgen.Claims{UserId: uuid.New(), Email: "test@example.com"}
-				user := entity.User{Id: claims.UserId, Email: claims.Email}
-
-				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
-				c.EXPECT().Get(args.ctx, "refresh:"+user.Id.String()).Return(args.token, nil)
-				g.EXPECT().GenerateAccessToken(user).Return("", errors.New("some error"))
+			name: "wrong password",
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, l *authmocks.MockLockout) {
+				l.EXPECT().Check(ctx, user.Email, "10.0.0.1").Return(nil)
+				r.EXPECT().UserByEmail(ctx, user.Email).Return(user, nil)
+				h.EXPECT().Compare(hash, hash).Return(errors.New("some error"))
+				l.EXPECT().Fail(ctx, user.Email, "10.0.0.1").Return(nil)
 			},
-			wantErr: true,
-			err:     svcErrs.ErrCannotSignToken,
+			wantErr: svcErrs.ErrInvalidCredentials,
 		},
 		{
-			name: "generate refresh token error",
-			args: args{
-				ctx:   context.Background(),
-				token: "valid_token",
-			},
-			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
-				claims := &jwtgen.Claims{UserId: uuid.New(), Email: "test@example.com"}
-				user := entity.User{Id: claims.UserId, Email: claims.Email}
-
-				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
-				c.EXPECT().Get(args.ctx, "refresh:"+user.Id.String()).Return(args.token, nil)
-				g.EXPECT().GenerateAccessToken(user).Return("access_token", nil)
-				g.EXPECT().GenerateRefreshToken(user).Return("", errors.New("some error"))
+			name: "wrong password locks the account",
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, l *authmocks.MockLockout) {
+				l.EXPECT().Check(ctx, user.Email, "10.0.0.1").Return(nil)
+				r.EXPECT().UserByEmail(ctx, user.Email).Return(user, nil)
+				h.EXPECT().Compare(hash, hash).Return(errors.New("some error"))
+				l.EXPECT().Fail(ctx, user.Email, "10.0.0.1").Return(locked)
 			},
-			wantErr: true,
-			err:     svcErrs.ErrCannotSignToken,
+			wantErr: svcErrs.ErrAccountLocked,
 		},
 		{
-			name: "save to cache: refresh token error",
-			args: args{
-				ctx:   context.Background(),
-				token: "valid_token",
-			},
-			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
-				claims := &jwtgen.Claims{UserId: uuid.New(), Email: "test@example.com"}
-				user := entity.User{Id: claims.UserId, Email: claims.Email}
-
-				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
-				c.EXPECT().Get(args.ctx, "refresh:"+user.Id.String()).Return(args.token, nil)
-				g.EXPECT().GenerateAccessToken(user).Return("access_token", nil)
-				g.EXPECT().GenerateRefreshToken(user).Return("refresh_token", nil)
-				r.EXPECT().UpdateLastLoginAttempt(args.ctx, user.Id).Return(errors.New("some update error"))
-				c.EXPECT().Set(args.ctx, "refresh:"+user.Id.String(), "refresh_token", refreshTokenTTL).Return(errors.New("some error"))
+			name: "unknown email",
+			mockBehavior: func(r *repomocks.MockUser, h *utilmocks.MockPasswordHasher, l *authmocks.MockLockout) {
+				l.EXPECT().Check(ctx, user.Email, "10.0.0.1").Return(nil)
+				r.EXPECT().UserByEmail(ctx, user.Email).Return(entity.User{}, repoErrs.ErrNotFound)
+				l.EXPECT().Fail(ctx, user.Email, "10.0.0.1").Return(nil)
 			},
-			wantErr: true,
-			err:     svcErrs.ErrAccessToCache,
+			wantErr: svcErrs.ErrInvalidCredentials,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// init deps
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			// init repo mock
 			repo := repomocks.NewMockUser(ctrl)
 			hasher := utilmocks.NewMockPasswordHasher(ctrl)
-			cache := redismocks.NewMockCache(ctrl)
-			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
-
-			tc.mockBehavior(repo, hasher, cache, tokenGenerator, tc.args)
-
-			// Log
-			log := logger.New("local", "info")
+			lockout := authmocks.NewMockLockout(ctrl)
+			tc.mockBehavior(repo, hasher, lockout)
 
-			// init service
-			s := New(log, cache, repo, hasher, tokenGenerator, nil, refreshTokenTTL)
+			s := New(logger.New("local", "info"), nil, repo, hasher, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, lockout, nil)
 
-			// run test
-			got, err := s.Refresh(tc.args.ctx, tc.args.token)
-			if tc.wantErr {
-				assert.Error(t, err)
-				assert.ErrorIs(t, err, tc.err)
+			got, err := s.Authenticate(ctx, user.Email, "Qwerty!1", "10.0.0.1")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
 				return
 			}
 
 			assert.NoError(t, err)
-			assert.NotNil(t, got)
-			assert.NotEqual(t, "", got.AccessToken)
-			assert.NotEqual(t, "", got.RefreshToken)
+			assert.Equal(t, user.Id, got.Id)
 		})
 	}
 }
 
-func TestAuthService_ResetPassword(t *testing.T) {
-	type args struct {
-		ctx   context.Context
-		input ResetPasswordInput
+func TestAuthService_IssueTokens(t *testing.T) {
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	client := &entity.Client{
+		Id:              "example-spa",
+		Audience:        "https://api.example.com",
+		AccessTokenTTL:  5 * time.Minute,
+		RefreshTokenTTL: 2 * time.Minute,
 	}
 
-	type MockBehavior func(r *repomocks.MockUser, c *redismocks.MockCache, s *utilmocks.MockSender, args args)
+	type MockBehavior func(r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, input IssueTokensInput)
 
 	testCases := []struct {
 		name         string
-		args         args
+		input        IssueTokensInput
 		mockBehavior MockBehavior
-		wantErr      bool
-		err          error
+		wantIdToken  bool
 	}{
 		{
-			name: "OK",
-			args: args{
-				ctx:   context.Background(),
-				input: ResetPasswordInput{Email: "test@example.com"},
+			name: "OK: openid scope",
+			input: IssueTokensInput{
+				Client:   client,
+				Scope:    "openid email",
+				Nonce:    "n-0S6_WzA2Mj",
+				AuthTime: authTime,
 			},
-			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, s *utilmocks.MockSender, args args) {
-				r.EXPECT().UserByEmailIsExists(args.ctx, args.input.Email).Return(boolPointer(true), nil)
-				c.EXPECT().Set(args.ctx, gomock.Any(), args.input.Email, 15*time.Minute).Return(nil)
-				s.EXPECT().SendResetPasswordEmail(args.input.Email, gomock.Any()).Return(nil)
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, input IssueTokensInput) {
+				r.EXPECT().UpdateLastLoginAttempt(gomock.Any(), user.Id).Return(nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.TokenParams) (string, error) {
+						assert.Equal(t, client.Audience, params.Audience)
+						assert.Equal(t, client.Id, params.AuthorizedParty)
+						assert.Equal(t, client.AccessTokenTTL, params.TTL)
+						return "access_token", nil
+					})
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.TokenParams) (string, error) {
+						assert.Equal(t, client.RefreshTokenTTL, params.TTL)
+						return "refresh_token", nil
+					})
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.IDTokenParams) (string, error) {
+						assert.Equal(t, client.Id, params.Audience)
+						assert.Equal(t, client.Id, params.AuthorizedParty)
+						assert.Equal(t, input.Nonce, params.Nonce)
+						assert.True(t, input.AuthTime.Equal(params.AuthTime))
+						return "id_token", nil
+					})
+				c.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), client.RefreshTokenTTL).
+					DoAndReturn(func(_ context.Context, _ string, value interface{}, _ time.Duration) error {
+						var rec sessionRecord
+						assert.NoError(t, json.Unmarshal([]byte(value.(string)), &rec))
+						assert.Equal(t, client.Id, rec.ClientId)
+						assert.Equal(t, client.Audience, rec.Audience)
+						assert.Equal(t, client.AccessTokenTTL, rec.AccessTokenTTL)
+						assert.Equal(t, input.Scope, rec.Scope)
+						return nil
+					})
+				c.EXPECT().SAdd(gomock.Any(), "sessions:"+user.Id.String(), gomock.Any()).Return(nil)
+				c.EXPECT().Expire(gomock.Any(), "sessions:"+user.Id.String(), client.RefreshTokenTTL).Return(nil)
 			},
-			wantErr: false,
-			err:     nil,
+			wantIdToken: true,
 		},
 		{
-			name: "user not found error",
-			args: args{
-				ctx:   context.Background(),
-				input: ResetPasswordInput{Email: "test@example.com"},
+			name: "OK: no openid scope",
+			input: IssueTokensInput{
+				Client: &entity.Client{Id: "example-spa"},
+				Scope:  "email",
 			},
-			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, s *utilmocks.MockSender, args args) {
-				r.EXPECT().UserByEmailIsExists(args.ctx, args.input.Email).Return(boolPointer(false), nil)
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, input IssueTokensInput) {
+				r.EXPECT().UpdateLastLoginAttempt(gomock.Any(), user.Id).Return(nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("refresh_token", nil)
+				c.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), refreshTokenTTL).Return(nil)
+				c.EXPECT().SAdd(gomock.Any(), "sessions:"+user.Id.String(), gomock.Any()).Return(nil)
+				c.EXPECT().Expire(gomock.Any(), "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
 			},
-			wantErr: true,
-			err:     svcErrs.ErrUserNotFound,
+			wantIdToken: false,
 		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockUser(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			tc.mockBehavior(repo, cache, tokenGenerator, tc.input)
+
+			s := New(logger.New("local", "info"), cache, repo, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			got, err := s.IssueTokens(context.Background(), user, tc.input)
+			assert.NoError(t, err)
+			assert.Equal(t, "access_token", got.AccessToken)
+			assert.Equal(t, tc.input.Scope, got.Scope)
+			assert.Equal(t, tc.wantIdToken, got.IdToken != "")
+		})
+	}
+}
+
+func TestAuthService_IssueServiceToken(t *testing.T) {
+	client := entity.Client{
+		Id:             "billing",
+		Audience:       "https://api.example.com",
+		AccessTokenTTL: 5 * time.Minute,
+	}
+
+	testCases := []struct {
+		name     string
+		tokenErr error
+		err      error
+	}{
 		{
-			name: "repo some error",
-			args: args{
-				ctx:   context.Background(),
-				input: ResetPasswordInput{Email: "test@example.com"},
+			name: "OK",
+		},
+		{
+			name:     "token generator error",
+			tokenErr: errors.New("some error"),
+			err:      svcErrs.ErrCannotSignToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			tokenGenerator.EXPECT().GenerateServiceToken(gomock.Any()).
+				DoAndReturn(func(params jwtgen.ServiceTokenParams) (string, error) {
+					assert.Equal(t, client.Id, params.ClientId)
+					assert.Equal(t, client.Audience, params.Audience)
+					assert.Equal(t, client.AccessTokenTTL, params.TTL)
+					assert.Equal(t, "orders:read", params.Scope)
+					assert.NotEmpty(t, params.TokenId)
+					return "service_token", tc.tokenErr
+				})
+
+			s := New(logger.New("local", "info"), nil, nil, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			got, err := s.IssueServiceToken(context.Background(), client, "orders:read")
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "service_token", got)
+		})
+	}
+}
+
+func TestAuthService_IntrospectToken(t *testing.T) {
+	userId := uuid.New()
+	sessionId := uuid.New()
+	now := time.Now().Truncate(time.Second)
+
+	userClaims := func(tokenId string) *jwtgen.Claims {
+		return &jwtgen.Claims{
+			UserId:          userId,
+			SessionId:       sessionId,
+			AuthorizedParty: "example-spa",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        tokenId,
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
 			},
-			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, s *utilmocks.MockSender, args args) {
-				r.EXPECT().UserByEmailIsExists(args.ctx, args.input.Email).Return(nil, errors.New("some error"))
+		}
+	}
+
+	session := func(t *testing.T, refreshTokenId string) string {
+		data, err := json.Marshal(sessionRecord{
+			Session:        entity.Session{Id: sessionId, UserId: userId, ClientId: "example-spa"},
+			RefreshTokenId: refreshTokenId,
+			Scope:          "openid email",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	type MockBehavior func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator)
+
+	testCases := []struct {
+		name         string
+		hint         string
+		mockBehavior MockBehavior
+		want         TokenInfo
+	}{
+		{
+			name: "access token",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseAccessToken("token").Return(userClaims("access_id"), nil)
+				c.EXPECT().Exists(gomock.Any(), "revoked_token:access_id").Return(false, nil)
+				c.EXPECT().Exists(gomock.Any(), "revoked_session:"+sessionId.String()).Return(false, nil)
+				c.EXPECT().Exists(gomock.Any(), "ended_session:"+sessionId.String()).Return(false, nil)
+				c.EXPECT().Get(gomock.Any(), "session:"+sessionId.String()).Return(session(t, "refresh_id"), nil)
+			},
+			want: TokenInfo{
+				Active:    true,
+				Scope:     "openid email",
+				ClientId:  "example-spa",
+				Subject:   userId.String(),
+				ExpiresAt: now.Add(time.Minute),
+				IssuedAt:  now,
+				TokenId:   "access_id",
+				TokenType: TokenTypeAccessToken,
 			},
-			wantErr: true,
-			err:     svcErrs.ErrCannotGetUser,
 		},
 		{
-			name: "save to cache error",
-			args: args{
-				ctx:   context.Background(),
-				input: ResetPasswordInput{Email: "test@example.com"},
+			name: "refresh token with a hint",
+			hint: TokenTypeRefreshToken,
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseRefreshToken("token").Return(userClaims("refresh_id"), nil)
+				c.EXPECT().Get(gomock.Any(), "session:"+sessionId.String()).Return(session(t, "refresh_id"), nil)
 			},
-			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, s *utilmocks.MockSender, args args) {
-				r.EXPECT().UserByEmailIsExists(args.ctx, args.input.Email).Return(boolPointer(true), nil)
-				c.EXPECT().Set(args.ctx, gomock.Any(), args.input.Email, 15*time.Minute).Return(errors.New("some error"))
+			want: TokenInfo{
+				Active:    true,
+				Scope:     "openid email",
+				ClientId:  "example-spa",
+				Subject:   userId.String(),
+				ExpiresAt: now.Add(time.Minute),
+				IssuedAt:  now,
+				TokenId:   "refresh_id",
+				TokenType: TokenTypeRefreshToken,
 			},
-			wantErr: true,
-			err:     svcErrs.ErrAccessToCache,
 		},
 		{
-			name: "send email some error",
-			args: args{
-				ctx:   context.Background(),
-				input: ResetPasswordInput{Email: "test@example.com"},
+			name: "rotated refresh token",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseAccessToken("token").Return(nil, errors.New("some error"))
+				g.EXPECT().ParseRefreshToken("token").Return(userClaims("refresh_id"), nil)
+				c.EXPECT().Get(gomock.Any(), "session:"+sessionId.String()).Return(session(t, "newer_refresh_id"), nil)
 			},
-			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, s *utilmocks.MockSender, args args) {
-				r.EXPECT().UserByEmailIsExists(args.ctx, args.input.Email).Return(boolPointer(true), nil)
-				c.EXPECT().Set(args.ctx, gomock.Any(), args.input.Email, 15*time.Minute).Return(nil)
-				s.EXPECT().SendResetPasswordEmail(args.input.Email, gomock.Any()).Return(errors.New("some error"))
+		},
+		{
+			name: "revoked session",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseAccessToken("token").Return(userClaims("access_id"), nil)
+				c.EXPECT().Exists(gomock.Any(), "revoked_token:access_id").Return(false, nil)
+				c.EXPECT().Exists(gomock.Any(), "revoked_session:"+sessionId.String()).Return(false, nil)
+				c.EXPECT().Exists(gomock.Any(), "ended_session:"+sessionId.String()).Return(false, nil)
+				c.EXPECT().Get(gomock.Any(), "session:"+sessionId.String()).Return("", errors.New("some error"))
+			},
+		},
+		{
+			name: "access token of a revoked token family",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseAccessToken("token").Return(userClaims("access_id"), nil)
+				c.EXPECT().Exists(gomock.Any(), "revoked_token:access_id").Return(false, nil)
+				c.EXPECT().Exists(gomock.Any(), "revoked_session:"+sessionId.String()).Return(true, nil)
+			},
+		},
+		{
+			name: "access token of an ended session",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseAccessToken("token").Return(userClaims("access_id"), nil)
+				c.EXPECT().Exists(gomock.Any(), "revoked_token:access_id").Return(false, nil)
+				c.EXPECT().Exists(gomock.Any(), "revoked_session:"+sessionId.String()).Return(false, nil)
+				c.EXPECT().Exists(gomock.Any(), "ended_session:"+sessionId.String()).Return(true, nil)
+			},
+		},
+		{
+			name: "revoked access token",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseAccessToken("token").Return(userClaims("access_id"), nil)
+				c.EXPECT().Exists(gomock.Any(), "revoked_token:access_id").Return(true, nil)
+			},
+		},
+		{
+			name: "service token",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseAccessToken("token").Return(&jwtgen.Claims{
+					TokenUse:        jwtgen.TokenUseService,
+					Scope:           "orders:read",
+					AuthorizedParty: "billing",
+					RegisteredClaims: jwt.RegisteredClaims{
+						Subject:   "billing",
+						ID:        "service_id",
+						IssuedAt:  jwt.NewNumericDate(now),
+						ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+					},
+				}, nil)
+				c.EXPECT().Exists(gomock.Any(), "revoked_token:service_id").Return(false, nil)
+			},
+			want: TokenInfo{
+				Active:    true,
+				Scope:     "orders:read",
+				ClientId:  "billing",
+				Subject:   "billing",
+				ExpiresAt: now.Add(time.Minute),
+				IssuedAt:  now,
+				TokenId:   "service_id",
+				TokenType: TokenTypeAccessToken,
+			},
+		},
+		{
+			name: "invalid token",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseAccessToken("token").Return(nil, errors.New("some error"))
+				g.EXPECT().ParseRefreshToken("token").Return(nil, errors.New("some error"))
 			},
-			wantErr: true,
-			err:     svcErrs.ErrSendResetPasswordEmail,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// init deps
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			// init repo mock
-			repo := repomocks.NewMockUser(ctrl)
 			cache := redismocks.NewMockCache(ctrl)
-			sender := utilmocks.NewMockSender(ctrl)
-			tc.mockBehavior(repo, cache, sender, tc.args)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			tc.mockBehavior(t, cache, tokenGenerator)
 
-			// Log
-			log := logger.New("local", "info")
+			s := New(logger.New("local", "info"), cache, nil, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
 
-			// init service
-			s := New(log, cache, repo, nil, nil, sender, refreshTokenTTL)
+			got, err := s.IntrospectToken(context.Background(), "token", tc.hint)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.Active, got.Active)
+			assert.True(t, tc.want.ExpiresAt.Equal(got.ExpiresAt))
+			assert.True(t, tc.want.IssuedAt.Equal(got.IssuedAt))
 
-			// run test
-			err := s.ResetPassword(tc.args.ctx, tc.args.input)
-			if tc.wantErr {
-				assert.Error(t, err)
+			got.ExpiresAt, got.IssuedAt = tc.want.ExpiresAt, tc.want.IssuedAt
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestAuthService_RevokeToken(t *testing.T) {
+	userId := uuid.New()
+	sessionId := uuid.New()
+
+	claims := func(tokenId string) *jwtgen.Claims {
+		return &jwtgen.Claims{
+			UserId:          userId,
+			SessionId:       sessionId,
+			AuthorizedParty: "example-spa",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        tokenId,
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		}
+	}
+
+	type MockBehavior func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator)
+
+	testCases := []struct {
+		name         string
+		hint         string
+		clientId     string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name:     "access token goes on the denylist",
+			clientId: "example-spa",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseAccessToken("token").Return(claims("access_id"), nil)
+				c.EXPECT().Set(gomock.Any(), "revoked_token:access_id", userId.String(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, _ string, ttl time.Duration) error {
+						assert.True(t, ttl > 0 && ttl <= time.Minute)
+						return nil
+					})
+			},
+		},
+		{
+			name:     "refresh token ends the session",
+			hint:     TokenTypeRefreshToken,
+			clientId: "example-spa",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseRefreshToken("token").Return(claims("refresh_id"), nil)
+				c.EXPECT().Get(gomock.Any(), "session:"+sessionId.String()).Return(sessionJSON(t, sessionId, userId, "refresh_id"), nil)
+				c.EXPECT().Set(gomock.Any(), "ended_session:"+sessionId.String(), userId.String(), refreshTokenTTL).Return(nil)
+				c.EXPECT().Delete(gomock.Any(), "session:"+sessionId.String()).Return(nil)
+				c.EXPECT().SRem(gomock.Any(), "sessions:"+userId.String(), sessionId.String()).Return(nil)
+			},
+		},
+		{
+			name:     "rotated refresh token is ignored",
+			hint:     TokenTypeRefreshToken,
+			clientId: "example-spa",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseRefreshToken("token").Return(claims("refresh_id"), nil)
+				c.EXPECT().Get(gomock.Any(), "session:"+sessionId.String()).Return(sessionJSON(t, sessionId, userId, "newer_refresh_id"), nil)
+			},
+		},
+		{
+			name:     "invalid token is ignored",
+			clientId: "example-spa",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseAccessToken("token").Return(nil, errors.New("some error"))
+				g.EXPECT().ParseRefreshToken("token").Return(nil, errors.New("some error"))
+			},
+		},
+		{
+			name:     "token of another client",
+			clientId: "billing",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseAccessToken("token").Return(claims("access_id"), nil)
+			},
+			err: svcErrs.ErrTokenNotIssuedToClient,
+		},
+		{
+			name:     "cache error",
+			clientId: "example-spa",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseAccessToken("token").Return(claims("access_id"), nil)
+				c.EXPECT().Set(gomock.Any(), "revoked_token:access_id", gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+			},
+			err: svcErrs.ErrAccessToCache,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			tc.mockBehavior(t, cache, tokenGenerator)
+
+			s := New(logger.New("local", "info"), cache, nil, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			err := s.RevokeToken(context.Background(), "token", tc.hint, tc.clientId)
+			if tc.err != nil {
 				assert.ErrorIs(t, err, tc.err)
 				return
 			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	claims := &jwtgen.Claims{
+		UserId:    uuid.New(),
+		SessionId: uuid.New(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "access_id",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+
+	type MockBehavior func(t *testing.T, c *redismocks.MockCache)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache) {
+				c.EXPECT().Set(gomock.Any(), "revoked_token:access_id", claims.UserId.String(), gomock.Any()).Return(nil)
+				c.EXPECT().Get(gomock.Any(), "session:"+claims.SessionId.String()).Return(sessionJSON(t, claims.SessionId, claims.UserId, "refresh_id"), nil)
+				c.EXPECT().Set(gomock.Any(), "ended_session:"+claims.SessionId.String(), claims.UserId.String(), refreshTokenTTL).Return(nil)
+				c.EXPECT().Delete(gomock.Any(), "session:"+claims.SessionId.String()).Return(nil)
+				c.EXPECT().SRem(gomock.Any(), "sessions:"+claims.UserId.String(), claims.SessionId.String()).Return(nil)
+			},
+		},
+		{
+			name: "session already ended",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache) {
+				c.EXPECT().Set(gomock.Any(), "revoked_token:access_id", claims.UserId.String(), gomock.Any()).Return(nil)
+				c.EXPECT().Get(gomock.Any(), "session:"+claims.SessionId.String()).Return("", errors.New("redis: nil"))
+			},
+		},
+		{
+			name: "denylist error",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache) {
+				c.EXPECT().Set(gomock.Any(), "revoked_token:access_id", gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+			},
+			err: svcErrs.ErrAccessToCache,
+		},
+		{
+			name: "delete session error",
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache) {
+				c.EXPECT().Set(gomock.Any(), "revoked_token:access_id", gomock.Any(), gomock.Any()).Return(nil)
+				c.EXPECT().Get(gomock.Any(), "session:"+claims.SessionId.String()).Return(sessionJSON(t, claims.SessionId, claims.UserId, "refresh_id"), nil)
+				c.EXPECT().Set(gomock.Any(), "ended_session:"+claims.SessionId.String(), gomock.Any(), gomock.Any()).Return(nil)
+				c.EXPECT().Delete(gomock.Any(), "session:"+claims.SessionId.String()).Return(errors.New("some error"))
+			},
+			err: svcErrs.ErrAccessToCache,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			tc.mockBehavior(t, cache)
+
+			s := New(logger.New("local", "info"), cache, nil, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
 
+			err := s.Logout(context.Background(), claims)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
 			assert.NoError(t, err)
 		})
 	}
 }
 
-func TestAuthService_RecoveryPassword(t *testing.T) {
+func TestAuthService_ParseToken(t *testing.T) {
+	sessionId := uuid.New()
+
 	type args struct {
 		ctx   context.Context
-		input RecoveryPasswordInput
+		token string
 	}
 
-	type MockBehavior func(r *repomocks.MockUser, c *redismocks.MockCache, h *utilmocks.MockPasswordHasher, args args)
+	type MockBehavior func(c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args)
 
 	testCases := []struct {
 		name         string
@@ -746,96 +1174,413 @@ func TestAuthService_RecoveryPassword(t *testing.T) {
 			name: "OK",
 			args: args{
 				ctx:   context.Background(),
-				input: RecoveryPasswordInput{Token: "recovery-token", Password: "new-password"},
+				token: "valid_access_token",
 			},
-			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, h *utilmocks.MockPasswordHasher, args args) {
-				hash := []byte{1, 2, 3}
-				c.EXPECT().Get(args.ctx, "reset:"+args.input.Token).Return("test@example.com", nil)
-				h.EXPECT().Hash(args.input.Password).Return(hash, nil)
-				r.EXPECT().UpdatePassword(args.ctx, "test@example.com", hash).Return(nil)
-				c.EXPECT().Delete(args.ctx, "reset:"+args.input.Token).Return(nil)
+			mockBehavior: func(c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				g.EXPECT().ParseAccessToken(args.token).Return(&jwtgen.Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "jti"}}, nil)
+				c.EXPECT().Exists(args.ctx, "revoked_token:jti").Return(false, nil)
 			},
 			wantErr: false,
 			err:     nil,
 		},
 		{
-			name: "token expired error",
+			name: "revoked token",
 			args: args{
 				ctx:   context.Background(),
-				input: RecoveryPasswordInput{Token: "recovery-token", Password: "new-password"},
+				token: "revoked_access_token",
 			},
-			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, h *utilmocks.MockPasswordHasher, args args) {
-				c.EXPECT().Get(args.ctx, "reset:"+args.input.Token).Return("", errors.New("token expired"))
+			mockBehavior: func(c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				g.EXPECT().ParseAccessToken(args.token).Return(&jwtgen.Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "jti"}}, nil)
+				c.EXPECT().Exists(args.ctx, "revoked_token:jti").Return(true, nil)
 			},
 			wantErr: true,
-			err:     svcErrs.ErrTokenIsExpired,
+			err:     svcErrs.ErrTokenRevoked,
 		},
 		{
-			name: "token expired error",
+			name: "token of a revoked token family",
 			args: args{
 				ctx:   context.Background(),
-				input: RecoveryPasswordInput{Token: "recovery-token", Password: "new-password"},
+				token: "valid_access_token",
 			},
-			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, h *utilmocks.MockPasswordHasher, args args) {
-				c.EXPECT().Get(args.ctx, "reset:"+args.input.Token).Return("test@example.com", nil)
-				h.EXPECT().Hash(args.input.Password).Return(nil, errors.New("some error"))
+			mockBehavior: func(c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				g.EXPECT().ParseAccessToken(args.token).Return(&jwtgen.Claims{SessionId: sessionId, RegisteredClaims: jwt.RegisteredClaims{ID: "jti"}}, nil)
+				c.EXPECT().Exists(args.ctx, "revoked_token:jti").Return(false, nil)
+				c.EXPECT().Exists(args.ctx, "revoked_session:"+sessionId.String()).Return(true, nil)
 			},
 			wantErr: true,
-			err:     svcErrs.ErrCannotUpdateUser,
+			err:     svcErrs.ErrTokenRevoked,
 		},
 		{
-			name: "repo update password error",
+			name: "token of an ended session",
 			args: args{
 				ctx:   context.Background(),
-				input: RecoveryPasswordInput{Token: "recovery-token", Password: "new-password"},
+				token: "valid_access_token",
 			},
-			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, h *utilmocks.MockPasswordHasher, args args) {
-				hash := []byte{1, 2, 3}
-				c.EXPECT().Get(args.ctx, "reset:"+args.input.Token).Return("test@example.com", nil)
-				h.EXPECT().Hash(args.input.Password).Return(hash, nil)
-				r.EXPECT().UpdatePassword(args.ctx, "test@example.com", hash).Return(errors.New("some error"))
+			mockBehavior: func(c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				g.EXPECT().ParseAccessToken(args.token).Return(&jwtgen.Claims{SessionId: sessionId, RegisteredClaims: jwt.RegisteredClaims{ID: "jti"}}, nil)
+				c.EXPECT().Exists(args.ctx, "revoked_token:jti").Return(false, nil)
+				c.EXPECT().Exists(args.ctx, "revoked_session:"+sessionId.String()).Return(false, nil)
+				c.EXPECT().Exists(args.ctx, "ended_session:"+sessionId.String()).Return(true, nil)
 			},
 			wantErr: true,
-			err:     svcErrs.ErrCannotUpdateUser,
+			err:     svcErrs.ErrTokenRevoked,
 		},
 		{
-			name: "ok with error delete from cache",
+			name: "denylist error",
 			args: args{
 				ctx:   context.Background(),
-				input: RecoveryPasswordInput{Token: "recovery-token", Password: "new-password"},
+				token: "valid_access_token",
 			},
-			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, h *utilmocks.MockPasswordHasher, args args) {
-				hash := []byte{1, 2, 3}
-				c.EXPECT().Get(args.ctx, "reset:"+args.input.Token).Return("test@example.com", nil)
-				h.EXPECT().Hash(args.input.Password).Return(hash, nil)
-				r.EXPECT().UpdatePassword(args.ctx, "test@example.com", hash).Return(nil)
-				c.EXPECT().Delete(args.ctx, "reset:"+args.input.Token).Return(errors.New("some error"))
+			mockBehavior: func(c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				g.EXPECT().ParseAccessToken(args.token).Return(&jwtgen.Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "jti"}}, nil)
+				c.EXPECT().Exists(args.ctx, "revoked_token:jti").Return(false, errors.New("some error"))
 			},
-			wantErr: false,
-			err:     nil,
+			wantErr: true,
+			err:     svcErrs.ErrAccessToCache,
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			// init deps
-			ctrl := gomock.NewController(t)
-			defer ctrl.Finish()
+		{
+			name: "some error",
+			args: args{
+				ctx:   context.Background(),
+				token: "invalid_access_token",
+			},
+			mockBehavior: func(c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				g.EXPECT().ParseAccessToken(args.token).Return(nil, errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrCannotParseToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// init deps
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
 			// init repo mock
 			repo := repomocks.NewMockUser(ctrl)
-			cache := redismocks.NewMockCache(ctrl)
 			hasher := utilmocks.NewMockPasswordHasher(ctrl)
-			tc.mockBehavior(repo, cache, hasher, tc.args)
+			cache := redismocks.NewMockCache(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+
+			tc.mockBehavior(cache, tokenGenerator, tc.args)
 
 			// Log
 			log := logger.New("local", "info")
 
 			// init service
-			s := New(log, cache, repo, hasher, nil, nil, refreshTokenTTL)
+			s := New(log, cache, repo, hasher, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
 
 			// run test
-			err := s.RecoveryPassword(tc.args.ctx, tc.args.input)
+			got, err := s.ParseToken(tc.args.ctx, tc.args.token)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, got)
+		})
+	}
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		token string
+	}
+
+	type MockBehavior func(t *testing.T, o *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args)
+
+	newClaims := func() *jwtgen.Claims {
+		return &jwtgen.Claims{
+			UserId:           uuid.New(),
+			Email:            "test@example.com",
+			SessionId:        uuid.New(),
+			RegisteredClaims: jwt.RegisteredClaims{ID: "current_token_id"},
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+		err          error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:   context.Background(),
+				token: "valid_token",
+			},
+			mockBehavior: func(t *testing.T, r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				claims := newClaims()
+				user := entity.User{Id: claims.UserId, Email: claims.Email}
+
+				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
+				c.EXPECT().Get(args.ctx, "session:"+claims.SessionId.String()).Return(sessionJSON(t, claims.SessionId, user.Id, claims.ID), nil)
+				c.EXPECT().Incr(args.ctx, "refresh_rotated:"+claims.ID, refreshTokenTTL).Return(int64(1), nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.TokenParams) (string, error) {
+						assert.Equal(t, claims.SessionId, params.SessionId)
+						assert.NotEmpty(t, params.TokenId)
+						return "access_token", nil
+					})
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.TokenParams) (string, error) {
+						assert.Equal(t, claims.SessionId, params.SessionId)
+						assert.NotEqual(t, claims.ID, params.TokenId)
+						return "refresh_token", nil
+					})
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.IDTokenParams) (string, error) {
+						assert.Equal(t, idTokenAudience, params.Audience)
+						assert.Empty(t, params.Nonce)
+						return "id_token", nil
+					})
+				r.EXPECT().UpdateLastLoginAttempt(args.ctx, user.Id).Return(nil)
+				c.EXPECT().Set(args.ctx, "session:"+claims.SessionId.String(), gomock.Any(), refreshTokenTTL).Return(nil)
+				c.EXPECT().SAdd(args.ctx, "sessions:"+user.Id.String(), claims.SessionId.String()).Return(nil)
+				c.EXPECT().Expire(args.ctx, "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "email verified since the sign-in",
+			args: args{
+				ctx:   context.Background(),
+				token: "valid_token",
+			},
+			mockBehavior: func(t *testing.T, r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				claims := newClaims()
+				user := entity.User{Id: claims.UserId, Email: claims.Email}
+				session, _ := json.Marshal(sessionRecord{
+					Session:        entity.Session{Id: claims.SessionId, UserId: user.Id},
+					RefreshTokenId: claims.ID,
+				})
+				verifiedAt := time.Now()
+
+				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
+				c.EXPECT().Get(args.ctx, "session:"+claims.SessionId.String()).Return(string(session), nil)
+				c.EXPECT().Incr(args.ctx, "refresh_rotated:"+claims.ID, refreshTokenTTL).Return(int64(1), nil)
+				r.EXPECT().UserById(args.ctx, user.Id).Return(entity.User{Id: user.Id, Email: user.Email, EmailVerifiedAt: &verifiedAt}, nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.TokenParams) (string, error) {
+						assert.True(t, params.EmailVerified)
+						return "access_token", nil
+					})
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("refresh_token", nil)
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.IDTokenParams) (string, error) {
+						assert.True(t, params.EmailVerified)
+						return "id_token", nil
+					})
+				r.EXPECT().UpdateLastLoginAttempt(args.ctx, user.Id).Return(nil)
+				c.EXPECT().Set(args.ctx, "session:"+claims.SessionId.String(), gomock.Any(), refreshTokenTTL).
+					DoAndReturn(func(_ context.Context, _ string, value string, _ time.Duration) error {
+						assert.Contains(t, value, `"email_verified":true`)
+						return nil
+					})
+				c.EXPECT().SAdd(args.ctx, "sessions:"+user.Id.String(), claims.SessionId.String()).Return(nil)
+				c.EXPECT().Expire(args.ctx, "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "refresh token of an oauth client",
+			args: args{
+				ctx:   context.Background(),
+				token: "valid_token",
+			},
+			mockBehavior: func(t *testing.T, r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				claims := newClaims()
+				claims.AuthorizedParty = "example-spa"
+
+				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
+			},
+			wantErr: true,
+			err:     svcErrs.ErrTokenNotIssuedToClient,
+		},
+		{
+			name: "cannot parse refresh token error",
+			args: args{
+				ctx:   context.Background(),
+				token: "invalid_token",
+			},
+			mockBehavior: func(t *testing.T, r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				g.EXPECT().ParseRefreshToken(args.token).Return(nil, errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrCannotParseToken,
+		},
+		{
+			name: "session not found",
+			args: args{
+				ctx:   context.Background(),
+				token: "valid_token",
+			},
+			mockBehavior: func(t *testing.T, r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				claims := newClaims()
+
+				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
+				c.EXPECT().Get(args.ctx, "session:"+claims.SessionId.String()).Return("", errors.New("some error"))
+				c.EXPECT().Get(args.ctx, "revoked_session:"+claims.SessionId.String()).Return("", errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrTokenIsExpired,
+		},
+		{
+			name: "session of another user",
+			args: args{
+				ctx:   context.Background(),
+				token: "valid_token",
+			},
+			mockBehavior: func(t *testing.T, r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				claims := newClaims()
+
+				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
+				c.EXPECT().Get(args.ctx, "session:"+claims.SessionId.String()).Return(sessionJSON(t, claims.SessionId, uuid.New(), claims.ID), nil)
+			},
+			wantErr: true,
+			err:     svcErrs.ErrTokenIsExpired,
+		},
+		{
+			name: "reuse of rotated token revokes the family",
+			args: args{
+				ctx:   context.Background(),
+				token: "rotated_token",
+			},
+			mockBehavior: func(t *testing.T, r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				claims := newClaims()
+
+				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
+				c.EXPECT().Get(args.ctx, "session:"+claims.SessionId.String()).Return(sessionJSON(t, claims.SessionId, claims.UserId, "newer_token_id"), nil)
+				c.EXPECT().Set(args.ctx, "revoked_session:"+claims.SessionId.String(), claims.UserId.String(), refreshTokenTTL).Return(nil)
+				c.EXPECT().Delete(args.ctx, "session:"+claims.SessionId.String()).Return(nil)
+				c.EXPECT().SRem(args.ctx, "sessions:"+claims.UserId.String(), claims.SessionId.String()).Return(nil)
+			},
+			wantErr: true,
+			err:     svcErrs.ErrRefreshTokenReused,
+		},
+		{
+			name: "reuse after the family was revoked",
+			args: args{
+				ctx:   context.Background(),
+				token: "rotated_token",
+			},
+			mockBehavior: func(t *testing.T, r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				claims := newClaims()
+
+				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
+				c.EXPECT().Get(args.ctx, "session:"+claims.SessionId.String()).Return("", errors.New("some error"))
+				c.EXPECT().Get(args.ctx, "revoked_session:"+claims.SessionId.String()).Return(claims.UserId.String(), nil)
+			},
+			wantErr: true,
+			err:     svcErrs.ErrRefreshTokenReused,
+		},
+		{
+			name: "revoke family: cache error",
+			args: args{
+				ctx:   context.Background(),
+				token: "rotated_token",
+			},
+			mockBehavior: func(t *testing.T, r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				claims := newClaims()
+
+				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
+				c.EXPECT().Get(args.ctx, "session:"+claims.SessionId.String()).Return(sessionJSON(t, claims.SessionId, claims.UserId, "newer_token_id"), nil)
+				c.EXPECT().Set(args.ctx, "revoked_session:"+claims.SessionId.String(), claims.UserId.String(), refreshTokenTTL).Return(errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrAccessToCache,
+		},
+		{
+			name: "generate access token error",
+			args: args{
+				ctx:   context.Background(),
+				token: "valid_token",
+			},
+			mockBehavior: func(t *testing.T, r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				claims := newClaims()
+				user := entity.User{Id: claims.UserId, Email: claims.Email}
+
+				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
+				c.EXPECT().Get(args.ctx, "session:"+claims.SessionId.String()).Return(sessionJSON(t, claims.SessionId, user.Id, claims.ID), nil)
+				c.EXPECT().Incr(args.ctx, "refresh_rotated:"+claims.ID, refreshTokenTTL).Return(int64(1), nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("", errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrCannotSignToken,
+		},
+		{
+			name: "generate refresh token error",
+			args: args{
+				ctx:   context.Background(),
+				token: "valid_token",
+			},
+			mockBehavior: func(t *testing.T, r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				claims := newClaims()
+				user := entity.User{Id: claims.UserId, Email: claims.Email}
+
+				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
+				c.EXPECT().Get(args.ctx, "session:"+claims.SessionId.String()).Return(sessionJSON(t, claims.SessionId, user.Id, claims.ID), nil)
+				c.EXPECT().Incr(args.ctx, "refresh_rotated:"+claims.ID, refreshTokenTTL).Return(int64(1), nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("", errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrCannotSignToken,
+		},
+		{
+			name: "save to cache: refresh token error",
+			args: args{
+				ctx:   context.Background(),
+				token: "valid_token",
+			},
+			mockBehavior: func(t *testing.T, r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator, args args) {
+				claims := newClaims()
+				user := entity.User{Id: claims.UserId, Email: claims.Email}
+
+				g.EXPECT().ParseRefreshToken(args.token).Return(claims, nil)
+				c.EXPECT().Get(args.ctx, "session:"+claims.SessionId.String()).Return(sessionJSON(t, claims.SessionId, user.Id, claims.ID), nil)
+				c.EXPECT().Incr(args.ctx, "refresh_rotated:"+claims.ID, refreshTokenTTL).Return(int64(1), nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("refresh_token", nil)
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).Return("id_token", nil)
+				r.EXPECT().UpdateLastLoginAttempt(args.ctx, user.Id).Return(errors.New("some update error"))
+				c.EXPECT().Set(args.ctx, "session:"+claims.SessionId.String(), gomock.Any(), refreshTokenTTL).Return(errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrAccessToCache,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// init deps
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// init repo mock
+			repo := repomocks.NewMockUser(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+
+			tc.mockBehavior(t, repo, cache, tokenGenerator, tc.args)
+
+			// Log
+			log := logger.New("local", "info")
+
+			// init service
+			s := New(log, cache, repo, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			// run test
+			got, err := s.Refresh(tc.args.ctx, tc.args.token)
 			if tc.wantErr {
 				assert.Error(t, err)
 				assert.ErrorIs(t, err, tc.err)
@@ -843,6 +1588,2345 @@ func TestAuthService_RecoveryPassword(t *testing.T) {
 			}
 
 			assert.NoError(t, err)
+			assert.NotNil(t, got)
+			assert.NotEqual(t, "", got.AccessToken)
+			assert.NotEqual(t, "", got.RefreshToken)
+			assert.NotEqual(t, "", got.IdToken)
+		})
+	}
+}
+
+func TestAuthService_RefreshClient(t *testing.T) {
+	ctx := context.Background()
+	claims := &jwtgen.Claims{
+		UserId:           uuid.New(),
+		Email:            "test@example.com",
+		SessionId:        uuid.New(),
+		AuthorizedParty:  "example-spa",
+		RegisteredClaims: jwt.RegisteredClaims{ID: "current_token_id"},
+	}
+	user := entity.User{Id: claims.UserId, Email: claims.Email}
+
+	type MockBehavior func(r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator)
+
+	testCases := []struct {
+		name         string
+		clientId     string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name:     "OK",
+			clientId: "example-spa",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseRefreshToken("refresh_token").Return(claims, nil)
+				c.EXPECT().Get(ctx, "session:"+claims.SessionId.String()).Return(sessionJSON(t, claims.SessionId, user.Id, claims.ID), nil)
+				c.EXPECT().Incr(ctx, "refresh_rotated:"+claims.ID, refreshTokenTTL).Return(int64(1), nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("next_refresh_token", nil)
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).Return("id_token", nil)
+				r.EXPECT().UpdateLastLoginAttempt(ctx, user.Id).Return(nil)
+				c.EXPECT().Set(ctx, "session:"+claims.SessionId.String(), gomock.Any(), refreshTokenTTL).Return(nil)
+				c.EXPECT().SAdd(ctx, "sessions:"+user.Id.String(), claims.SessionId.String()).Return(nil)
+				c.EXPECT().Expire(ctx, "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
+			},
+		},
+		{
+			name:     "token of another client",
+			clientId: "other-client",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, g *utilmocks.MockTokenGenerator) {
+				g.EXPECT().ParseRefreshToken("refresh_token").Return(claims, nil)
+			},
+			err: svcErrs.ErrTokenNotIssuedToClient,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockUser(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			tc.mockBehavior(repo, cache, tokenGenerator)
+
+			s := New(logger.New("local", "info"), cache, repo, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			got, err := s.RefreshClient(ctx, "refresh_token", tc.clientId)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "next_refresh_token", got.RefreshToken)
+		})
+	}
+}
+
+func TestAuthService_Refresh_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	claims := &jwtgen.Claims{
+		UserId:           uuid.New(),
+		Email:            "test@example.com",
+		SessionId:        uuid.New(),
+		RegisteredClaims: jwt.RegisteredClaims{ID: "current_token_id"},
+	}
+	session := sessionJSON(t, claims.SessionId, claims.UserId, claims.ID)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// every refresh reads the session before any of them saves the rotated one
+	var mu sync.Mutex
+	counters := map[string]int64{}
+	cache := redismocks.NewMockCache(ctrl)
+	cache.EXPECT().Get(ctx, "session:"+claims.SessionId.String()).Return(session, nil).AnyTimes()
+	cache.EXPECT().Incr(ctx, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, key string, _ time.Duration) (int64, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			counters[key]++
+			return counters[key], nil
+		}).AnyTimes()
+	cache.EXPECT().Set(ctx, "session:"+claims.SessionId.String(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	cache.EXPECT().SAdd(ctx, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	cache.EXPECT().Expire(ctx, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	cache.EXPECT().Set(ctx, "revoked_session:"+claims.SessionId.String(), gomock.Any(), gomock.Any()).Return(nil).MinTimes(1)
+	cache.EXPECT().Delete(ctx, "session:"+claims.SessionId.String()).Return(nil).MinTimes(1)
+	cache.EXPECT().SRem(ctx, gomock.Any(), gomock.Any()).Return(nil).MinTimes(1)
+
+	tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+	tokenGenerator.EXPECT().ParseRefreshToken("refresh_token").Return(claims, nil).AnyTimes()
+	tokenGenerator.EXPECT().GenerateAccessToken(gomock.Any(), gomock.Any()).Return("access_token", nil).Times(1)
+	tokenGenerator.EXPECT().GenerateRefreshToken(gomock.Any(), gomock.Any()).Return("next_refresh_token", nil).Times(1)
+	tokenGenerator.EXPECT().GenerateIDToken(gomock.Any(), gomock.Any()).Return("id_token", nil).Times(1)
+
+	repo := repomocks.NewMockUser(ctrl)
+	repo.EXPECT().UpdateLastLoginAttempt(ctx, claims.UserId).Return(nil).Times(1)
+
+	s := New(logger.New("local", "info"), cache, repo, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+	const refreshes = 10
+
+	var wg sync.WaitGroup
+	errs := make([]error, refreshes)
+	for i := range refreshes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = s.Refresh(ctx, "refresh_token")
+		}()
+	}
+	wg.Wait()
+
+	var rotated, reused int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			rotated++
+		case errors.Is(err, svcErrs.ErrRefreshTokenReused):
+			reused++
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	assert.Equal(t, 1, rotated)
+	assert.Equal(t, refreshes-1, reused)
+}
+
+func TestAuthService_ResetPassword(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		input ResetPasswordInput
+	}
+
+	type MockBehavior func(r *repomocks.MockUser, c *redismocks.MockCache, s *utilmocks.MockSender, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+		err          error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:   context.Background(),
+				input: ResetPasswordInput{Email: "test@example.com"},
+			},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, s *utilmocks.MockSender, args args) {
+				r.EXPECT().UserByEmailIsExists(args.ctx, args.input.Email).Return(boolPointer(true), nil)
+				c.EXPECT().Set(args.ctx, gomock.Any(), args.input.Email, 15*time.Minute).Return(nil)
+				s.EXPECT().SendResetPasswordEmail(args.input.Email, gomock.Any()).Return(nil)
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "user not found error",
+			args: args{
+				ctx:   context.Background(),
+				input: ResetPasswordInput{Email: "test@example.com"},
+			},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, s *utilmocks.MockSender, args args) {
+				r.EXPECT().UserByEmailIsExists(args.ctx, args.input.Email).Return(boolPointer(false), nil)
+			},
+			wantErr: true,
+			err:     svcErrs.ErrUserNotFound,
+		},
+		{
+			name: "repo some error",
+			args: args{
+				ctx:   context.Background(),
+				input: ResetPasswordInput{Email: "test@example.com"},
+			},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, s *utilmocks.MockSender, args args) {
+				r.EXPECT().UserByEmailIsExists(args.ctx, args.input.Email).Return(nil, errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrCannotGetUser,
+		},
+		{
+			name: "save to cache error",
+			args: args{
+				ctx:   context.Background(),
+				input: ResetPasswordInput{Email: "test@example.com"},
+			},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, s *utilmocks.MockSender, args args) {
+				r.EXPECT().UserByEmailIsExists(args.ctx, args.input.Email).Return(boolPointer(true), nil)
+				c.EXPECT().Set(args.ctx, gomock.Any(), args.input.Email, 15*time.Minute).Return(errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrAccessToCache,
+		},
+		{
+			name: "send email some error",
+			args: args{
+				ctx:   context.Background(),
+				input: ResetPasswordInput{Email: "test@example.com"},
+			},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, s *utilmocks.MockSender, args args) {
+				r.EXPECT().UserByEmailIsExists(args.ctx, args.input.Email).Return(boolPointer(true), nil)
+				c.EXPECT().Set(args.ctx, gomock.Any(), args.input.Email, 15*time.Minute).Return(nil)
+				s.EXPECT().SendResetPasswordEmail(args.input.Email, gomock.Any()).Return(errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrSendResetPasswordEmail,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// init deps
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// init repo mock
+			repo := repomocks.NewMockUser(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			sender := utilmocks.NewMockSender(ctrl)
+			tc.mockBehavior(repo, cache, sender, tc.args)
+
+			// Log
+			log := logger.New("local", "info")
+
+			// init service
+			s := New(log, cache, repo, nil, nil, sender, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			// run test
+			err := s.ResetPassword(tc.args.ctx, tc.args.input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_RecoveryPassword(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		input RecoveryPasswordInput
+	}
+
+	type MockBehavior func(r *repomocks.MockUser, c *redismocks.MockCache, h *utilmocks.MockPasswordHasher, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+		err          error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:   context.Background(),
+				input: RecoveryPasswordInput{Token: "recovery-token", Password: "new-password"},
+			},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, h *utilmocks.MockPasswordHasher, args args) {
+				hash := []byte{1, 2, 3}
+				c.EXPECT().Get(args.ctx, "reset:"+args.input.Token).Return("test@example.com", nil)
+				h.EXPECT().Hash(args.input.Password).Return(hash, nil)
+				r.EXPECT().UpdatePassword(args.ctx, "test@example.com", hash).Return(nil)
+				c.EXPECT().Delete(args.ctx, "reset:"+args.input.Token).Return(nil)
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "token expired error",
+			args: args{
+				ctx:   context.Background(),
+				input: RecoveryPasswordInput{Token: "recovery-token", Password: "new-password"},
+			},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, h *utilmocks.MockPasswordHasher, args args) {
+				c.EXPECT().Get(args.ctx, "reset:"+args.input.Token).Return("", errors.New("token expired"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrTokenIsExpired,
+		},
+		{
+			name: "token expired error",
+			args: args{
+				ctx:   context.Background(),
+				input: RecoveryPasswordInput{Token: "recovery-token", Password: "new-password"},
+			},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, h *utilmocks.MockPasswordHasher, args args) {
+				c.EXPECT().Get(args.ctx, "reset:"+args.input.Token).Return("test@example.com", nil)
+				h.EXPECT().Hash(args.input.Password).Return(nil, errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrCannotUpdateUser,
+		},
+		{
+			name: "repo update password error",
+			args: args{
+				ctx:   context.Background(),
+				input: RecoveryPasswordInput{Token: "recovery-token", Password: "new-password"},
+			},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, h *utilmocks.MockPasswordHasher, args args) {
+				hash := []byte{1, 2, 3}
+				c.EXPECT().Get(args.ctx, "reset:"+args.input.Token).Return("test@example.com", nil)
+				h.EXPECT().Hash(args.input.Password).Return(hash, nil)
+				r.EXPECT().UpdatePassword(args.ctx, "test@example.com", hash).Return(errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrCannotUpdateUser,
+		},
+		{
+			name: "ok with error delete from cache",
+			args: args{
+				ctx:   context.Background(),
+				input: RecoveryPasswordInput{Token: "recovery-token", Password: "new-password"},
+			},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, h *utilmocks.MockPasswordHasher, args args) {
+				hash := []byte{1, 2, 3}
+				c.EXPECT().Get(args.ctx, "reset:"+args.input.Token).Return("test@example.com", nil)
+				h.EXPECT().Hash(args.input.Password).Return(hash, nil)
+				r.EXPECT().UpdatePassword(args.ctx, "test@example.com", hash).Return(nil)
+				c.EXPECT().Delete(args.ctx, "reset:"+args.input.Token).Return(errors.New("some error"))
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// init deps
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// init repo mock
+			repo := repomocks.NewMockUser(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			hasher := utilmocks.NewMockPasswordHasher(ctrl)
+			tc.mockBehavior(repo, cache, hasher, tc.args)
+
+			// Log
+			log := logger.New("local", "info")
+
+			// init service
+			s := New(log, cache, repo, hasher, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			// run test
+			err := s.RecoveryPassword(tc.args.ctx, tc.args.input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_Sessions(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userId uuid.UUID
+	}
+
+	type MockBehavior func(t *testing.T, c *redismocks.MockCache, args args)
+
+	sessionId := uuid.New()
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []uuid.UUID
+		wantErr      bool
+		err          error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:    context.Background(),
+				userId: uuid.New(),
+			},
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, args args) {
+				c.EXPECT().SMembers(args.ctx, "sessions:"+args.userId.String()).Return([]string{sessionId.String()}, nil)
+				c.EXPECT().Get(args.ctx, "session:"+sessionId.String()).Return(sessionJSON(t, sessionId, args.userId, "token_id"), nil)
+			},
+			want:    []uuid.UUID{sessionId},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "expired session is removed from index",
+			args: args{
+				ctx:    context.Background(),
+				userId: uuid.New(),
+			},
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, args args) {
+				c.EXPECT().SMembers(args.ctx, "sessions:"+args.userId.String()).Return([]string{sessionId.String()}, nil)
+				c.EXPECT().Get(args.ctx, "session:"+sessionId.String()).Return("", errors.New("redis: nil"))
+				c.EXPECT().SRem(args.ctx, "sessions:"+args.userId.String(), sessionId.String()).Return(nil)
+			},
+			want:    []uuid.UUID{},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "cache error",
+			args: args{
+				ctx:    context.Background(),
+				userId: uuid.New(),
+			},
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, args args) {
+				c.EXPECT().SMembers(args.ctx, "sessions:"+args.userId.String()).Return(nil, errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrAccessToCache,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// init deps
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			tc.mockBehavior(t, cache, tc.args)
+
+			// Log
+			log := logger.New("local", "info")
+
+			// init service
+			s := New(log, cache, nil, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			// run test
+			got, err := s.Sessions(tc.args.ctx, tc.args.userId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			ids := make([]uuid.UUID, 0, len(got))
+			for _, session := range got {
+				ids = append(ids, session.Id)
+			}
+			assert.Equal(t, tc.want, ids)
+		})
+	}
+}
+
+func TestAuthService_RevokeSession(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		userId    uuid.UUID
+		sessionId uuid.UUID
+	}
+
+	type MockBehavior func(t *testing.T, c *redismocks.MockCache, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+		err          error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:       context.Background(),
+				userId:    uuid.New(),
+				sessionId: uuid.New(),
+			},
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, args args) {
+				c.EXPECT().Get(args.ctx, "session:"+args.sessionId.String()).Return(sessionJSON(t, args.sessionId, args.userId, "token_id"), nil)
+				c.EXPECT().Set(args.ctx, "ended_session:"+args.sessionId.String(), args.userId.String(), refreshTokenTTL).Return(nil)
+				c.EXPECT().Delete(args.ctx, "session:"+args.sessionId.String()).Return(nil)
+				c.EXPECT().SRem(args.ctx, "sessions:"+args.userId.String(), args.sessionId.String()).Return(nil)
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "session not found",
+			args: args{
+				ctx:       context.Background(),
+				userId:    uuid.New(),
+				sessionId: uuid.New(),
+			},
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, args args) {
+				c.EXPECT().Get(args.ctx, "session:"+args.sessionId.String()).Return("", errors.New("redis: nil"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrSessionNotFound,
+		},
+		{
+			name: "session of another user",
+			args: args{
+				ctx:       context.Background(),
+				userId:    uuid.New(),
+				sessionId: uuid.New(),
+			},
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, args args) {
+				c.EXPECT().Get(args.ctx, "session:"+args.sessionId.String()).Return(sessionJSON(t, args.sessionId, uuid.New(), "token_id"), nil)
+			},
+			wantErr: true,
+			err:     svcErrs.ErrSessionNotFound,
+		},
+		{
+			name: "delete from cache error",
+			args: args{
+				ctx:       context.Background(),
+				userId:    uuid.New(),
+				sessionId: uuid.New(),
+			},
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, args args) {
+				c.EXPECT().Get(args.ctx, "session:"+args.sessionId.String()).Return(sessionJSON(t, args.sessionId, args.userId, "token_id"), nil)
+				c.EXPECT().Set(args.ctx, "ended_session:"+args.sessionId.String(), gomock.Any(), gomock.Any()).Return(nil)
+				c.EXPECT().Delete(args.ctx, "session:"+args.sessionId.String()).Return(errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrAccessToCache,
+		},
+		{
+			name: "end session error",
+			args: args{
+				ctx:       context.Background(),
+				userId:    uuid.New(),
+				sessionId: uuid.New(),
+			},
+			mockBehavior: func(t *testing.T, c *redismocks.MockCache, args args) {
+				c.EXPECT().Get(args.ctx, "session:"+args.sessionId.String()).Return(sessionJSON(t, args.sessionId, args.userId, "token_id"), nil)
+				c.EXPECT().Set(args.ctx, "ended_session:"+args.sessionId.String(), gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+			},
+			wantErr: true,
+			err:     svcErrs.ErrAccessToCache,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// init deps
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			tc.mockBehavior(t, cache, tc.args)
+
+			// Log
+			log := logger.New("local", "info")
+
+			// init service
+			s := New(log, cache, nil, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			// run test
+			err := s.RevokeSession(tc.args.ctx, tc.args.userId, tc.args.sessionId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_EndedSession_DeniesAccessTokens(t *testing.T) {
+	userId := uuid.New()
+
+	testCases := []struct {
+		name string
+		end  func(s *Service, claims *jwtgen.Claims) error
+	}{
+		{
+			name: "revoked session",
+			end: func(s *Service, claims *jwtgen.Claims) error {
+				return s.RevokeSession(context.Background(), userId, claims.SessionId)
+			},
+		},
+		{
+			name: "logout with another token of the session",
+			end: func(s *Service, claims *jwtgen.Claims) error {
+				other := *claims
+				other.ID = "other_access_id"
+				return s.Logout(context.Background(), &other)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			claims := &jwtgen.Claims{
+				UserId:    userId,
+				SessionId: uuid.New(),
+				RegisteredClaims: jwt.RegisteredClaims{
+					ID:        "access_id",
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+				},
+			}
+
+			entries := map[string]string{"session:" + claims.SessionId.String(): sessionJSON(t, claims.SessionId, userId, "refresh_id")}
+			cache := redismocks.NewMockCache(ctrl)
+			cache.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key string) (string, error) {
+				v, ok := entries[key]
+				if !ok {
+					return "", errors.New("redis: nil")
+				}
+				return v, nil
+			}).AnyTimes()
+			cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, key, value string, _ time.Duration) error {
+					entries[key] = value
+					return nil
+				}).AnyTimes()
+			cache.EXPECT().Delete(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key string) error {
+				delete(entries, key)
+				return nil
+			}).AnyTimes()
+			cache.EXPECT().SRem(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			cache.EXPECT().Exists(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key string) (bool, error) {
+				_, ok := entries[key]
+				return ok, nil
+			}).AnyTimes()
+
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			tokenGenerator.EXPECT().ParseAccessToken("access_token").Return(claims, nil).AnyTimes()
+
+			s := New(logger.New("local", "info"), cache, nil, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			_, err := s.ParseToken(context.Background(), "access_token")
+			assert.NoError(t, err)
+
+			assert.NoError(t, tc.end(s, claims))
+
+			_, err = s.ParseToken(context.Background(), "access_token")
+			assert.ErrorIs(t, err, svcErrs.ErrTokenRevoked)
+		})
+	}
+}
+
+func TestAuthService_GenerateToken_MFA(t *testing.T) {
+	ctx := context.Background()
+	hash := []byte("Qwerty!1")
+	user := entity.User{Id: uuid.New(), PasswordHash: hash, Email: "test@example.com"}
+
+	type MockBehavior func(c *redismocks.MockCache, f *authmocks.MockSecondFactor)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(c *redismocks.MockCache, f *authmocks.MockSecondFactor) {
+				f.EXPECT().Enabled(ctx, user.Id).Return(true, nil)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), mfaChallengeTTL).
+					DoAndReturn(func(_ context.Context, key string, value string, _ time.Duration) error {
+						var challenge mfaChallenge
+						assert.NoError(t, json.Unmarshal([]byte(value), &challenge))
+						assert.Equal(t, user.Id, challenge.UserId)
+						assert.Equal(t, "n-0S6_WzA2Mj", challenge.Nonce)
+						return nil
+					})
+			},
+		},
+		{
+			name: "second factor error",
+			mockBehavior: func(c *redismocks.MockCache, f *authmocks.MockSecondFactor) {
+				f.EXPECT().Enabled(ctx, user.Id).Return(false, svcErrs.ErrCannotGetMFA)
+			},
+			err: svcErrs.ErrCannotGetMFA,
+		},
+		{
+			name: "cache error",
+			mockBehavior: func(c *redismocks.MockCache, f *authmocks.MockSecondFactor) {
+				f.EXPECT().Enabled(ctx, user.Id).Return(true, nil)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), mfaChallengeTTL).Return(errors.New("some error"))
+			},
+			err: svcErrs.ErrAccessToCache,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockUser(ctrl)
+			hasher := utilmocks.NewMockPasswordHasher(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			secondFactor := authmocks.NewMockSecondFactor(ctrl)
+
+			repo.EXPECT().UserByEmail(ctx, user.Email).Return(user, nil)
+			hasher.EXPECT().Compare(hash, hash).Return(nil)
+			tc.mockBehavior(cache, secondFactor)
+			if tc.err == nil {
+				hasher.EXPECT().NeedsRehash(hash).Return(false)
+			}
+
+			// no tokens are issued before the second factor
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+
+			s := New(logger.New("local", "info"), cache, repo, hasher, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, secondFactor, nil, nil, nil)
+
+			got, err := s.GenerateToken(ctx, GenerateTokenInput{Email: user.Email, Password: "Qwerty!1", Nonce: "n-0S6_WzA2Mj"})
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, got.MFAToken, 43)
+			assert.Empty(t, got.AccessToken)
+			assert.Empty(t, got.RefreshToken)
+		})
+	}
+}
+
+func TestAuthService_VerifyMFA(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+	key := "mfa_challenge:token"
+	attemptsKey := "mfa_attempts:" + user.Id.String()
+
+	data, err := json.Marshal(mfaChallenge{
+		UserId:    user.Id,
+		Email:     user.Email,
+		AuthTime:  time.Now(),
+		ExpiresAt: time.Now().Add(mfaChallengeTTL),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge := string(data)
+
+	type MockBehavior func(c *redismocks.MockCache, f *authmocks.MockSecondFactor, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser, l *authmocks.MockLockout)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(c *redismocks.MockCache, f *authmocks.MockSecondFactor, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser, l *authmocks.MockLockout) {
+				c.EXPECT().Get(ctx, key).Return(challenge, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).Return(int64(1), nil)
+				f.EXPECT().Verify(ctx, user.Id, "123456").Return(nil)
+				c.EXPECT().Delete(ctx, attemptsKey).Return(nil)
+				c.EXPECT().GetDel(ctx, key).Return(challenge, nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("refresh_token", nil)
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).Return("id_token", nil)
+				r.EXPECT().UpdateLastLoginAttempt(ctx, user.Id).Return(nil)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), refreshTokenTTL).Return(nil)
+				c.EXPECT().SAdd(ctx, "sessions:"+user.Id.String(), gomock.Any()).Return(nil)
+				c.EXPECT().Expire(ctx, "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
+			},
+		},
+		{
+			name: "unknown token",
+			mockBehavior: func(c *redismocks.MockCache, f *authmocks.MockSecondFactor, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser, l *authmocks.MockLockout) {
+				c.EXPECT().Get(ctx, key).Return("", errors.New("redis: nil"))
+			},
+			err: svcErrs.ErrInvalidMFAToken,
+		},
+		{
+			name: "invalid code",
+			mockBehavior: func(c *redismocks.MockCache, f *authmocks.MockSecondFactor, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser, l *authmocks.MockLockout) {
+				c.EXPECT().Get(ctx, key).Return(challenge, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).Return(int64(1), nil)
+				f.EXPECT().Verify(ctx, user.Id, "123456").Return(svcErrs.ErrInvalidOTP)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "too many attempts",
+			mockBehavior: func(c *redismocks.MockCache, f *authmocks.MockSecondFactor, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser, l *authmocks.MockLockout) {
+				c.EXPECT().Get(ctx, key).Return(challenge, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).Return(int64(mfaMaxAttempts), nil)
+				f.EXPECT().Verify(ctx, user.Id, "123456").Return(svcErrs.ErrInvalidOTP)
+				l.EXPECT().LockAccount(ctx, user.Email).
+					Return(&svcErrs.RetryAfterError{Err: svcErrs.ErrAccountLocked, RetryAfter: time.Minute})
+				c.EXPECT().Delete(ctx, key).Return(nil)
+			},
+			err: svcErrs.ErrAccountLocked,
+		},
+		{
+			name: "attempts used concurrently",
+			mockBehavior: func(c *redismocks.MockCache, f *authmocks.MockSecondFactor, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser, l *authmocks.MockLockout) {
+				c.EXPECT().Get(ctx, key).Return(challenge, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, mfaAttemptsWindow).Return(int64(mfaMaxAttempts+1), nil)
+				c.EXPECT().TTL(ctx, attemptsKey).Return(10*time.Minute, nil)
+				c.EXPECT().Delete(ctx, key).Return(nil)
+			},
+			err: svcErrs.ErrTooManySignInAttempts,
+		},
+		{
+			name: "cache error",
+			mockBehavior: func(c *redismocks.MockCache, f *authmocks.MockSecondFactor, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser, l *authmocks.MockLockout) {
+				c.EXPECT().Get(ctx, key).Return(challenge, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).Return(int64(0), errors.New("some error"))
+			},
+			err: svcErrs.ErrAccessToCache,
+		},
+		{
+			name: "challenge already used",
+			mockBehavior: func(c *redismocks.MockCache, f *authmocks.MockSecondFactor, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser, l *authmocks.MockLockout) {
+				c.EXPECT().Get(ctx, key).Return(challenge, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).Return(int64(1), nil)
+				f.EXPECT().Verify(ctx, user.Id, "123456").Return(nil)
+				c.EXPECT().Delete(ctx, attemptsKey).Return(nil)
+				c.EXPECT().GetDel(ctx, key).Return("", errors.New("redis: nil"))
+			},
+			err: svcErrs.ErrInvalidMFAToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			secondFactor := authmocks.NewMockSecondFactor(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			repo := repomocks.NewMockUser(ctrl)
+			lockout := authmocks.NewMockLockout(ctrl)
+			tc.mockBehavior(cache, secondFactor, tokenGenerator, repo, lockout)
+
+			s := New(logger.New("local", "info"), cache, repo, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, secondFactor, nil, lockout, nil)
+
+			got, err := s.VerifyMFA(ctx, VerifyMFAInput{MFAToken: "token", Code: "123456"})
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "access_token", got.AccessToken)
+			assert.Equal(t, "refresh_token", got.RefreshToken)
+		})
+	}
+}
+
+func TestAuthService_VerifyMFA_SMS(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+	key := "mfa_challenge:token"
+
+	data, err := json.Marshal(mfaChallenge{
+		UserId:    user.Id,
+		Email:     user.Email,
+		AuthTime:  time.Now(),
+		ExpiresAt: time.Now().Add(mfaChallengeTTL),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge := string(data)
+
+	type MockBehavior func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser) {
+				c.EXPECT().Get(ctx, key).Return(challenge, nil)
+				c.EXPECT().Incr(ctx, "mfa_attempts:"+user.Id.String(), mfaAttemptsWindow).Return(int64(1), nil)
+				o.EXPECT().Verify(ctx, otp.PurposeSecondFactor, user.Id.String(), "123456").Return(nil)
+				c.EXPECT().Delete(ctx, "mfa_attempts:"+user.Id.String()).Return(nil)
+				c.EXPECT().GetDel(ctx, key).Return(challenge, nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("refresh_token", nil)
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).Return("id_token", nil)
+				r.EXPECT().UpdateLastLoginAttempt(ctx, user.Id).Return(nil)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), refreshTokenTTL).Return(nil)
+				c.EXPECT().SAdd(ctx, "sessions:"+user.Id.String(), gomock.Any()).Return(nil)
+				c.EXPECT().Expire(ctx, "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
+			},
+		},
+		{
+			name: "invalid code",
+			mockBehavior: func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser) {
+				c.EXPECT().Get(ctx, key).Return(challenge, nil)
+				c.EXPECT().Incr(ctx, "mfa_attempts:"+user.Id.String(), mfaAttemptsWindow).Return(int64(1), nil)
+				o.EXPECT().Verify(ctx, otp.PurposeSecondFactor, user.Id.String(), "123456").Return(svcErrs.ErrInvalidOTP)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "locked out",
+			mockBehavior: func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser) {
+				c.EXPECT().Get(ctx, key).Return(challenge, nil)
+				c.EXPECT().Incr(ctx, "mfa_attempts:"+user.Id.String(), mfaAttemptsWindow).Return(int64(1), nil)
+				o.EXPECT().Verify(ctx, otp.PurposeSecondFactor, user.Id.String(), "123456").Return(svcErrs.ErrOTPLocked)
+			},
+			err: svcErrs.ErrOTPLocked,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			codes := authmocks.NewMockOneTimeCodes(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			repo := repomocks.NewMockUser(ctrl)
+			tc.mockBehavior(cache, codes, tokenGenerator, repo)
+
+			// two-factor authentication with authenticator apps is not configured
+			s := New(logger.New("local", "info"), cache, repo, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, codes, nil, nil, nil, nil)
+
+			got, err := s.VerifyMFA(ctx, VerifyMFAInput{MFAToken: "token", Code: "123456", SMS: true})
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "access_token", got.AccessToken)
+		})
+	}
+}
+
+func TestAuthService_SendMFACode(t *testing.T) {
+	ctx := context.Background()
+	phone := "+15550100"
+	verifiedAt := time.Now()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com", Phone: &phone, PhoneVerifiedAt: &verifiedAt, SMSSecondFactor: true}
+	key := "mfa_challenge:token"
+
+	data, err := json.Marshal(mfaChallenge{UserId: user.Id, Email: user.Email, ExpiresAt: time.Now().Add(mfaChallengeTTL)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge := string(data)
+
+	type MockBehavior func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, r *repomocks.MockUser)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, r *repomocks.MockUser) {
+				c.EXPECT().Get(ctx, key).Return(challenge, nil)
+				r.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+				o.EXPECT().SendSMS(ctx, otp.PurposeSecondFactor, user.Id.String(), phone).Return(nil)
+			},
+		},
+		{
+			name: "unknown token",
+			mockBehavior: func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, r *repomocks.MockUser) {
+				c.EXPECT().Get(ctx, key).Return("", errors.New("redis: nil"))
+			},
+			err: svcErrs.ErrInvalidMFAToken,
+		},
+		{
+			name: "sms second factor disabled",
+			mockBehavior: func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, r *repomocks.MockUser) {
+				c.EXPECT().Get(ctx, key).Return(challenge, nil)
+				r.EXPECT().UserById(ctx, user.Id).Return(entity.User{Id: user.Id, Phone: &phone, PhoneVerifiedAt: &verifiedAt}, nil)
+			},
+			err: svcErrs.ErrPhoneNotFound,
+		},
+		{
+			name: "sent recently",
+			mockBehavior: func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, r *repomocks.MockUser) {
+				c.EXPECT().Get(ctx, key).Return(challenge, nil)
+				r.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+				o.EXPECT().SendSMS(ctx, otp.PurposeSecondFactor, user.Id.String(), phone).Return(svcErrs.ErrOTPThrottled)
+			},
+			err: svcErrs.ErrOTPThrottled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			codes := authmocks.NewMockOneTimeCodes(ctrl)
+			repo := repomocks.NewMockUser(ctrl)
+			tc.mockBehavior(cache, codes, repo)
+
+			s := New(logger.New("local", "info"), cache, repo, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, codes, nil, nil, nil, nil)
+
+			err := s.SendMFACode(ctx, "token")
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_ResetPasswordBySMS(t *testing.T) {
+	ctx := context.Background()
+	phone := "+15550100"
+
+	type MockBehavior func(o *authmocks.MockOneTimeCodes, r *repomocks.MockUser)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(o *authmocks.MockOneTimeCodes, r *repomocks.MockUser) {
+				r.EXPECT().UserByPhone(ctx, phone).Return(entity.User{Id: uuid.New()}, nil)
+				o.EXPECT().SendSMS(ctx, otp.PurposeRecovery, phone, phone).Return(nil)
+			},
+		},
+		{
+			name: "unknown phone",
+			mockBehavior: func(o *authmocks.MockOneTimeCodes, r *repomocks.MockUser) {
+				r.EXPECT().UserByPhone(ctx, phone).Return(entity.User{}, repoErrs.ErrNotFound)
+			},
+		},
+		{
+			name: "sent recently",
+			mockBehavior: func(o *authmocks.MockOneTimeCodes, r *repomocks.MockUser) {
+				r.EXPECT().UserByPhone(ctx, phone).Return(entity.User{Id: uuid.New()}, nil)
+				o.EXPECT().SendSMS(ctx, otp.PurposeRecovery, phone, phone).Return(svcErrs.ErrOTPThrottled)
+			},
+		},
+		{
+			name: "send error",
+			mockBehavior: func(o *authmocks.MockOneTimeCodes, r *repomocks.MockUser) {
+				r.EXPECT().UserByPhone(ctx, phone).Return(entity.User{Id: uuid.New()}, nil)
+				o.EXPECT().SendSMS(ctx, otp.PurposeRecovery, phone, phone).Return(svcErrs.ErrSendOTP)
+			},
+			err: svcErrs.ErrSendOTP,
+		},
+		{
+			name: "repo error",
+			mockBehavior: func(o *authmocks.MockOneTimeCodes, r *repomocks.MockUser) {
+				r.EXPECT().UserByPhone(ctx, phone).Return(entity.User{}, errors.New("some error"))
+			},
+			err: svcErrs.ErrCannotGetUser,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			codes := authmocks.NewMockOneTimeCodes(ctrl)
+			repo := repomocks.NewMockUser(ctrl)
+			tc.mockBehavior(codes, repo)
+
+			s := New(logger.New("local", "info"), nil, repo, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, codes, nil, nil, nil, nil)
+
+			err := s.ResetPasswordBySMS(ctx, phone)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_RecoveryPasswordBySMS(t *testing.T) {
+	ctx := context.Background()
+	input := SMSRecoveryPasswordInput{Phone: "+15550100", Code: "123456", Password: "NewPassw0rd!"}
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	type MockBehavior func(o *authmocks.MockOneTimeCodes, r *repomocks.MockUser, h *utilmocks.MockPasswordHasher)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(o *authmocks.MockOneTimeCodes, r *repomocks.MockUser, h *utilmocks.MockPasswordHasher) {
+				o.EXPECT().Verify(ctx, otp.PurposeRecovery, input.Phone, input.Code).Return(nil)
+				r.EXPECT().UserByPhone(ctx, input.Phone).Return(user, nil)
+				h.EXPECT().Hash(input.Password).Return([]byte("hash"), nil)
+				r.EXPECT().UpdatePassword(ctx, user.Email, []byte("hash")).Return(nil)
+			},
+		},
+		{
+			name: "invalid code",
+			mockBehavior: func(o *authmocks.MockOneTimeCodes, r *repomocks.MockUser, h *utilmocks.MockPasswordHasher) {
+				o.EXPECT().Verify(ctx, otp.PurposeRecovery, input.Phone, input.Code).Return(svcErrs.ErrInvalidOTP)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "phone removed",
+			mockBehavior: func(o *authmocks.MockOneTimeCodes, r *repomocks.MockUser, h *utilmocks.MockPasswordHasher) {
+				o.EXPECT().Verify(ctx, otp.PurposeRecovery, input.Phone, input.Code).Return(nil)
+				r.EXPECT().UserByPhone(ctx, input.Phone).Return(entity.User{}, repoErrs.ErrNotFound)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "update error",
+			mockBehavior: func(o *authmocks.MockOneTimeCodes, r *repomocks.MockUser, h *utilmocks.MockPasswordHasher) {
+				o.EXPECT().Verify(ctx, otp.PurposeRecovery, input.Phone, input.Code).Return(nil)
+				r.EXPECT().UserByPhone(ctx, input.Phone).Return(user, nil)
+				h.EXPECT().Hash(input.Password).Return([]byte("hash"), nil)
+				r.EXPECT().UpdatePassword(ctx, user.Email, []byte("hash")).Return(errors.New("some error"))
+			},
+			err: svcErrs.ErrCannotUpdateUser,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			codes := authmocks.NewMockOneTimeCodes(ctrl)
+			repo := repomocks.NewMockUser(ctrl)
+			hasher := utilmocks.NewMockPasswordHasher(ctrl)
+			tc.mockBehavior(codes, repo, hasher)
+
+			s := New(logger.New("local", "info"), nil, repo, hasher, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, codes, nil, nil, nil, nil)
+
+			err := s.RecoveryPasswordBySMS(ctx, input)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_VerifySecondFactor(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+	phone := "+15550100"
+	verifiedAt := time.Now()
+	user := entity.User{Id: userId}
+	attemptsKey := "mfa_attempts:" + userId.String()
+	smsUser := entity.User{Id: userId, Phone: &phone, PhoneVerifiedAt: &verifiedAt, SMSSecondFactor: true}
+
+	type MockBehavior func(cache *redismocks.MockCache, f *authmocks.MockSecondFactor, c *authmocks.MockOneTimeCodes, l *authmocks.MockLockout)
+
+	testCases := []struct {
+		name         string
+		user         entity.User
+		code         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "not enabled",
+			user: user,
+			mockBehavior: func(cache *redismocks.MockCache, f *authmocks.MockSecondFactor, c *authmocks.MockOneTimeCodes, l *authmocks.MockLockout) {
+				f.EXPECT().Enabled(ctx, userId).Return(false, nil)
+			},
+		},
+		{
+			name: "code required",
+			user: user,
+			mockBehavior: func(cache *redismocks.MockCache, f *authmocks.MockSecondFactor, c *authmocks.MockOneTimeCodes, l *authmocks.MockLockout) {
+				f.EXPECT().Enabled(ctx, userId).Return(true, nil)
+			},
+			err: svcErrs.ErrMFARequired,
+		},
+		{
+			name: "OK",
+			user: user,
+			code: "123456",
+			mockBehavior: func(cache *redismocks.MockCache, f *authmocks.MockSecondFactor, c *authmocks.MockOneTimeCodes, l *authmocks.MockLockout) {
+				f.EXPECT().Enabled(ctx, userId).Return(true, nil)
+				cache.EXPECT().Incr(ctx, attemptsKey, mfaAttemptsWindow).Return(int64(1), nil)
+				f.EXPECT().Verify(ctx, userId, "123456").Return(nil)
+				cache.EXPECT().Delete(ctx, attemptsKey).Return(nil)
+			},
+		},
+		{
+			name: "invalid code",
+			user: user,
+			code: "123456",
+			mockBehavior: func(cache *redismocks.MockCache, f *authmocks.MockSecondFactor, c *authmocks.MockOneTimeCodes, l *authmocks.MockLockout) {
+				f.EXPECT().Enabled(ctx, userId).Return(true, nil)
+				cache.EXPECT().Incr(ctx, attemptsKey, mfaAttemptsWindow).Return(int64(1), nil)
+				f.EXPECT().Verify(ctx, userId, "123456").Return(svcErrs.ErrInvalidOTP)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "sms code sent",
+			user: smsUser,
+			mockBehavior: func(cache *redismocks.MockCache, f *authmocks.MockSecondFactor, c *authmocks.MockOneTimeCodes, l *authmocks.MockLockout) {
+				f.EXPECT().Enabled(ctx, userId).Return(false, nil)
+				c.EXPECT().SendSMS(ctx, otp.PurposeSecondFactor, userId.String(), phone).Return(nil)
+			},
+			err: svcErrs.ErrMFARequired,
+		},
+		{
+			name: "sms code",
+			user: smsUser,
+			code: "654321",
+			mockBehavior: func(cache *redismocks.MockCache, f *authmocks.MockSecondFactor, c *authmocks.MockOneTimeCodes, l *authmocks.MockLockout) {
+				f.EXPECT().Enabled(ctx, userId).Return(false, nil)
+				cache.EXPECT().Incr(ctx, attemptsKey, mfaAttemptsWindow).Return(int64(1), nil)
+				c.EXPECT().Verify(ctx, otp.PurposeSecondFactor, userId.String(), "654321").Return(nil)
+				cache.EXPECT().Delete(ctx, attemptsKey).Return(nil)
+			},
+		},
+		{
+			name: "sms code with totp enabled",
+			user: smsUser,
+			code: "654321",
+			mockBehavior: func(cache *redismocks.MockCache, f *authmocks.MockSecondFactor, c *authmocks.MockOneTimeCodes, l *authmocks.MockLockout) {
+				f.EXPECT().Enabled(ctx, userId).Return(true, nil)
+				cache.EXPECT().Incr(ctx, attemptsKey, mfaAttemptsWindow).Return(int64(1), nil)
+				f.EXPECT().Verify(ctx, userId, "654321").Return(svcErrs.ErrInvalidOTP)
+				c.EXPECT().Verify(ctx, otp.PurposeSecondFactor, userId.String(), "654321").Return(nil)
+				cache.EXPECT().Delete(ctx, attemptsKey).Return(nil)
+			},
+		},
+		{
+			name: "invalid sms code",
+			user: smsUser,
+			code: "654321",
+			mockBehavior: func(cache *redismocks.MockCache, f *authmocks.MockSecondFactor, c *authmocks.MockOneTimeCodes, l *authmocks.MockLockout) {
+				f.EXPECT().Enabled(ctx, userId).Return(false, nil)
+				cache.EXPECT().Incr(ctx, attemptsKey, mfaAttemptsWindow).Return(int64(1), nil)
+				c.EXPECT().Verify(ctx, otp.PurposeSecondFactor, userId.String(), "654321").Return(svcErrs.ErrInvalidOTP)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "too many attempts",
+			user: user,
+			code: "123456",
+			mockBehavior: func(cache *redismocks.MockCache, f *authmocks.MockSecondFactor, c *authmocks.MockOneTimeCodes, l *authmocks.MockLockout) {
+				f.EXPECT().Enabled(ctx, userId).Return(true, nil)
+				cache.EXPECT().Incr(ctx, attemptsKey, mfaAttemptsWindow).Return(int64(mfaMaxAttempts+1), nil)
+				cache.EXPECT().TTL(ctx, attemptsKey).Return(10*time.Minute, nil)
+			},
+			err: svcErrs.ErrTooManySignInAttempts,
+		},
+		{
+			name: "last attempt locks the account out",
+			user: user,
+			code: "123456",
+			mockBehavior: func(cache *redismocks.MockCache, f *authmocks.MockSecondFactor, c *authmocks.MockOneTimeCodes, l *authmocks.MockLockout) {
+				f.EXPECT().Enabled(ctx, userId).Return(true, nil)
+				cache.EXPECT().Incr(ctx, attemptsKey, mfaAttemptsWindow).Return(int64(mfaMaxAttempts), nil)
+				f.EXPECT().Verify(ctx, userId, "123456").Return(svcErrs.ErrInvalidOTP)
+				l.EXPECT().LockAccount(ctx, user.Email).
+					Return(&svcErrs.RetryAfterError{Err: svcErrs.ErrAccountLocked, RetryAfter: time.Minute})
+			},
+			err: svcErrs.ErrAccountLocked,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			secondFactor := authmocks.NewMockSecondFactor(ctrl)
+			codes := authmocks.NewMockOneTimeCodes(ctrl)
+			lockout := authmocks.NewMockLockout(ctrl)
+			tc.mockBehavior(cache, secondFactor, codes, lockout)
+
+			s := New(logger.New("local", "info"), cache, nil, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, codes, secondFactor, nil, lockout, nil)
+
+			err := s.VerifySecondFactor(ctx, tc.user, tc.code)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_VerifyMFA_Passkey(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+	key := "mfa_challenge:token"
+	response := []byte(`{"id":"credential"}`)
+
+	challengeJSON, err := json.Marshal(mfaChallenge{
+		UserId:    user.Id,
+		Email:     user.Email,
+		AuthTime:  time.Now(),
+		ExpiresAt: time.Now().Add(mfaChallengeTTL),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type MockBehavior func(c *redismocks.MockCache, p *authmocks.MockPasskeys, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(c *redismocks.MockCache, p *authmocks.MockPasskeys, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser) {
+				c.EXPECT().Get(ctx, key).Return(string(challengeJSON), nil)
+				c.EXPECT().Incr(ctx, "mfa_attempts:"+user.Id.String(), mfaAttemptsWindow).Return(int64(1), nil)
+				p.EXPECT().FinishAssertion(ctx, user.Id, "session", response).Return(nil)
+				c.EXPECT().Delete(ctx, "mfa_attempts:"+user.Id.String()).Return(nil)
+				c.EXPECT().GetDel(ctx, key).Return(string(challengeJSON), nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("refresh_token", nil)
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).Return("id_token", nil)
+				r.EXPECT().UpdateLastLoginAttempt(ctx, user.Id).Return(nil)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), refreshTokenTTL).Return(nil)
+				c.EXPECT().SAdd(ctx, "sessions:"+user.Id.String(), gomock.Any()).Return(nil)
+				c.EXPECT().Expire(ctx, "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
+			},
+		},
+		{
+			name: "rejected assertion",
+			mockBehavior: func(c *redismocks.MockCache, p *authmocks.MockPasskeys, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser) {
+				c.EXPECT().Get(ctx, key).Return(string(challengeJSON), nil)
+				c.EXPECT().Incr(ctx, "mfa_attempts:"+user.Id.String(), mfaAttemptsWindow).Return(int64(1), nil)
+				p.EXPECT().FinishAssertion(ctx, user.Id, "session", response).Return(svcErrs.ErrInvalidPasskey)
+			},
+			err: svcErrs.ErrInvalidPasskey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			secondFactor := authmocks.NewMockSecondFactor(ctrl)
+			passkeys := authmocks.NewMockPasskeys(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			repo := repomocks.NewMockUser(ctrl)
+			tc.mockBehavior(cache, passkeys, tokenGenerator, repo)
+
+			s := New(logger.New("local", "info"), cache, repo, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, secondFactor, passkeys, nil, nil)
+
+			got, err := s.VerifyMFA(ctx, VerifyMFAInput{MFAToken: "token", PasskeySessionId: "session", PasskeyResponse: response})
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "access_token", got.AccessToken)
+		})
+	}
+}
+
+func TestAuthService_BeginMFAPasskey(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+	key := "mfa_challenge:token"
+	ceremony := passkey.Ceremony{SessionId: "session", Options: json.RawMessage(`{"publicKey":{}}`)}
+
+	challengeJSON, err := json.Marshal(mfaChallenge{UserId: userId, ExpiresAt: time.Now().Add(mfaChallengeTTL)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type MockBehavior func(c *redismocks.MockCache, p *authmocks.MockPasskeys)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         passkey.Ceremony
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(c *redismocks.MockCache, p *authmocks.MockPasskeys) {
+				c.EXPECT().Get(ctx, key).Return(string(challengeJSON), nil)
+				p.EXPECT().BeginAssertion(ctx, userId).Return(ceremony, nil)
+			},
+			want: ceremony,
+		},
+		{
+			name: "unknown token",
+			mockBehavior: func(c *redismocks.MockCache, p *authmocks.MockPasskeys) {
+				c.EXPECT().Get(ctx, key).Return("", errors.New("redis: nil"))
+			},
+			err: svcErrs.ErrInvalidMFAToken,
+		},
+		{
+			name: "no passkeys",
+			mockBehavior: func(c *redismocks.MockCache, p *authmocks.MockPasskeys) {
+				c.EXPECT().Get(ctx, key).Return(string(challengeJSON), nil)
+				p.EXPECT().BeginAssertion(ctx, userId).Return(passkey.Ceremony{}, svcErrs.ErrPasskeyNotFound)
+			},
+			err: svcErrs.ErrPasskeyNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			passkeys := authmocks.NewMockPasskeys(ctrl)
+			tc.mockBehavior(cache, passkeys)
+
+			s := New(logger.New("local", "info"), cache, nil, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, passkeys, nil, nil)
+
+			got, err := s.BeginMFAPasskey(ctx, "token")
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestAuthService_SignInWithPasskey(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+	input := PasskeySignInInput{SessionId: "session", Response: []byte(`{"id":"credential"}`), Device: "laptop"}
+
+	type MockBehavior func(c *redismocks.MockCache, p *authmocks.MockPasskeys, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(c *redismocks.MockCache, p *authmocks.MockPasskeys, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser) {
+				p.EXPECT().FinishLogin(ctx, input.SessionId, input.Response).Return(user, nil)
+				g.EXPECT().GenerateAccessToken(user, gomock.Any()).Return("access_token", nil)
+				g.EXPECT().GenerateRefreshToken(user, gomock.Any()).Return("refresh_token", nil)
+				g.EXPECT().GenerateIDToken(user, gomock.Any()).Return("id_token", nil)
+				r.EXPECT().UpdateLastLoginAttempt(ctx, user.Id).Return(nil)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), refreshTokenTTL).Return(nil)
+				c.EXPECT().SAdd(ctx, "sessions:"+user.Id.String(), gomock.Any()).Return(nil)
+				c.EXPECT().Expire(ctx, "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
+			},
+		},
+		{
+			name: "rejected assertion",
+			mockBehavior: func(c *redismocks.MockCache, p *authmocks.MockPasskeys, g *utilmocks.MockTokenGenerator, r *repomocks.MockUser) {
+				p.EXPECT().FinishLogin(ctx, input.SessionId, input.Response).Return(entity.User{}, svcErrs.ErrInvalidPasskey)
+			},
+			err: svcErrs.ErrInvalidPasskey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			passkeys := authmocks.NewMockPasskeys(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			repo := repomocks.NewMockUser(ctrl)
+			tc.mockBehavior(cache, passkeys, tokenGenerator, repo)
+
+			s := New(logger.New("local", "info"), cache, repo, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, passkeys, nil, nil)
+
+			got, err := s.SignInWithPasskey(ctx, input)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "access_token", got.AccessToken)
+			assert.Equal(t, "refresh_token", got.RefreshToken)
+			assert.Empty(t, got.MFAToken)
+		})
+	}
+}
+
+func TestAuthService_GenerateToken_EmailVerification(t *testing.T) {
+	ctx := context.Background()
+	verifiedAt := time.Now()
+	input := GenerateTokenInput{Email: "test@example.com", Password: "Qwerty!1"}
+
+	testCases := []struct {
+		name     string
+		user     entity.User
+		required bool
+		wantErr  error
+	}{
+		{
+			name:     "unverified",
+			user:     entity.User{Id: uuid.New(), Email: "test@example.com"},
+			required: true,
+			wantErr:  svcErrs.ErrEmailNotVerified,
+		},
+		{
+			name:     "verified",
+			user:     entity.User{Id: uuid.New(), Email: "test@example.com", EmailVerifiedAt: &verifiedAt},
+			required: true,
+		},
+		{
+			name: "unverified, verification not required",
+			user: entity.User{Id: uuid.New(), Email: "test@example.com"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockUser(ctrl)
+			hasher := utilmocks.NewMockPasswordHasher(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+
+			repo.EXPECT().UserByEmail(ctx, input.Email).Return(tc.user, nil)
+			hasher.EXPECT().Compare(tc.user.PasswordHash, []byte(input.Password)).Return(nil)
+			if tc.wantErr == nil {
+				hasher.EXPECT().NeedsRehash(tc.user.PasswordHash).Return(false)
+				tokenGenerator.EXPECT().GenerateAccessToken(tc.user, gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.TokenParams) (string, error) {
+						assert.Equal(t, tc.user.IsEmailVerified(), params.EmailVerified)
+						return "access_token", nil
+					})
+				tokenGenerator.EXPECT().GenerateRefreshToken(tc.user, gomock.Any()).Return("refresh_token", nil)
+				tokenGenerator.EXPECT().GenerateIDToken(tc.user, gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.IDTokenParams) (string, error) {
+						assert.Equal(t, tc.user.IsEmailVerified(), params.EmailVerified)
+						return "id_token", nil
+					})
+				repo.EXPECT().UpdateLastLoginAttempt(ctx, tc.user.Id).Return(nil)
+				cache.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), refreshTokenTTL).Return(nil)
+				cache.EXPECT().SAdd(ctx, "sessions:"+tc.user.Id.String(), gomock.Any()).Return(nil)
+				cache.EXPECT().Expire(ctx, "sessions:"+tc.user.Id.String(), refreshTokenTTL).Return(nil)
+			}
+
+			verification := emailVerification
+			verification.Required = tc.required
+			s := New(logger.New("local", "info"), cache, repo, hasher, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, verification, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			got, err := s.GenerateToken(ctx, input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "access_token", got.AccessToken)
+		})
+	}
+}
+
+func TestAuthService_VerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+
+	type MockBehavior func(r *repomocks.MockUser, c *redismocks.MockCache)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache) {
+				c.EXPECT().GetDel(ctx, "verify_email:token").Return(userId.String(), nil)
+				r.EXPECT().VerifyEmail(ctx, userId).Return(nil)
+			},
+		},
+		{
+			name: "unknown or used token",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache) {
+				c.EXPECT().GetDel(ctx, "verify_email:token").Return("", errors.New("redis: nil"))
+			},
+			wantErr: svcErrs.ErrInvalidVerificationToken,
+		},
+		{
+			name: "user deleted",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache) {
+				c.EXPECT().GetDel(ctx, "verify_email:token").Return(userId.String(), nil)
+				r.EXPECT().VerifyEmail(ctx, userId).Return(repoErrs.ErrNotFound)
+			},
+			wantErr: svcErrs.ErrInvalidVerificationToken,
+		},
+		{
+			name: "repo error",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache) {
+				c.EXPECT().GetDel(ctx, "verify_email:token").Return(userId.String(), nil)
+				r.EXPECT().VerifyEmail(ctx, userId).Return(errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrCannotUpdateUser,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockUser(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			tc.mockBehavior(repo, cache)
+
+			s := New(logger.New("local", "info"), cache, repo, nil, nil, nil, refreshTokenTTL, idTokenAudience, emailVerification, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			err := s.VerifyEmail(ctx, "token")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_ResendVerification(t *testing.T) {
+	ctx := context.Background()
+	verifiedAt := time.Now()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	type MockBehavior func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender)
+
+	testCases := []struct {
+		name         string
+		email        string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name:  "OK",
+			email: "Test@example.com",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender) {
+				c.EXPECT().Exists(ctx, "verification_sent:test@example.com").Return(false, nil)
+				r.EXPECT().UserByEmail(ctx, "Test@example.com").Return(user, nil)
+				c.EXPECT().Set(ctx, gomock.Any(), user.Id.String(), 24*time.Hour).
+					DoAndReturn(func(_ context.Context, key string, _ string, _ time.Duration) error {
+						assert.Regexp(t, "^verify_email:[A-Za-z0-9_-]{43}$", key)
+						return nil
+					})
+				c.EXPECT().Set(ctx, "verification_sent:test@example.com", "1", time.Minute).Return(nil)
+				e.EXPECT().SendVerificationEmail(user.Email, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:  "sent recently",
+			email: "test@example.com",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender) {
+				c.EXPECT().Exists(ctx, "verification_sent:test@example.com").Return(true, nil)
+			},
+			wantErr: svcErrs.ErrVerificationEmailThrottled,
+		},
+		{
+			name:  "unknown email",
+			email: "unknown@example.com",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender) {
+				c.EXPECT().Exists(ctx, "verification_sent:unknown@example.com").Return(false, nil)
+				r.EXPECT().UserByEmail(ctx, "unknown@example.com").Return(entity.User{}, repoErrs.ErrNotFound)
+				c.EXPECT().Set(ctx, "verification_sent:unknown@example.com", "1", time.Minute).Return(nil)
+			},
+		},
+		{
+			name:  "already verified",
+			email: "test@example.com",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender) {
+				c.EXPECT().Exists(ctx, "verification_sent:test@example.com").Return(false, nil)
+				r.EXPECT().UserByEmail(ctx, "test@example.com").
+					Return(entity.User{Id: user.Id, Email: user.Email, EmailVerifiedAt: &verifiedAt}, nil)
+				c.EXPECT().Set(ctx, "verification_sent:test@example.com", "1", time.Minute).Return(nil)
+			},
+		},
+		{
+			name:  "send error",
+			email: "test@example.com",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender) {
+				c.EXPECT().Exists(ctx, "verification_sent:test@example.com").Return(false, nil)
+				r.EXPECT().UserByEmail(ctx, "test@example.com").Return(user, nil)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+				e.EXPECT().SendVerificationEmail(user.Email, gomock.Any()).Return(errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrSendVerificationEmail,
+		},
+		{
+			name:  "cache error",
+			email: "test@example.com",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender) {
+				c.EXPECT().Exists(ctx, "verification_sent:test@example.com").Return(false, errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrAccessToCache,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockUser(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			sender := utilmocks.NewMockSender(ctrl)
+			tc.mockBehavior(repo, cache, sender)
+
+			s := New(logger.New("local", "info"), cache, repo, nil, nil, sender, refreshTokenTTL, idTokenAudience, emailVerification, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			err := s.ResendVerification(ctx, tc.email)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_RequestMagicLink(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+	passwordless := PasswordlessConfig{MagicLinkTTL: 15 * time.Minute}
+
+	type MockBehavior func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender)
+
+	testCases := []struct {
+		name         string
+		input        MagicLinkInput
+		mockBehavior MockBehavior
+		wantBinding  string
+		wantErr      error
+	}{
+		{
+			name:  "OK",
+			input: MagicLinkInput{Email: "test@example.com", Binding: "binding", Device: "Laptop", Nonce: "nonce"},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender) {
+				r.EXPECT().UserByEmail(ctx, "test@example.com").Return(user, nil)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), 15*time.Minute).
+					DoAndReturn(func(_ context.Context, key string, value string, _ time.Duration) error {
+						assert.Regexp(t, "^magic_link:[A-Za-z0-9_-]{43}$", key)
+
+						var record magicLinkRecord
+						assert.NoError(t, json.Unmarshal([]byte(value), &record))
+						hash := sha256.Sum256([]byte("binding"))
+						assert.Equal(t, magicLinkRecord{UserId: user.Id, BindingHash: hash[:], Device: "Laptop", Nonce: "nonce"}, record)
+						return nil
+					})
+				e.EXPECT().SendMagicLinkEmail(user.Email, gomock.Any()).Return(nil)
+			},
+			wantBinding: "binding",
+		},
+		{
+			name:  "unknown email",
+			input: MagicLinkInput{Email: "unknown@example.com", Binding: "binding"},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender) {
+				r.EXPECT().UserByEmail(ctx, "unknown@example.com").Return(entity.User{}, repoErrs.ErrNotFound)
+			},
+			wantBinding: "binding",
+		},
+		{
+			name:  "repo error",
+			input: MagicLinkInput{Email: "test@example.com"},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender) {
+				r.EXPECT().UserByEmail(ctx, "test@example.com").Return(entity.User{}, errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrCannotGetUser,
+		},
+		{
+			name:  "cache error",
+			input: MagicLinkInput{Email: "test@example.com"},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender) {
+				r.EXPECT().UserByEmail(ctx, "test@example.com").Return(user, nil)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), 15*time.Minute).Return(errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrAccessToCache,
+		},
+		{
+			name:  "send error",
+			input: MagicLinkInput{Email: "test@example.com"},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, e *utilmocks.MockSender) {
+				r.EXPECT().UserByEmail(ctx, "test@example.com").Return(user, nil)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), 15*time.Minute).Return(nil)
+				e.EXPECT().SendMagicLinkEmail(user.Email, gomock.Any()).Return(errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrSendMagicLinkEmail,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockUser(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			sender := utilmocks.NewMockSender(ctrl)
+			tc.mockBehavior(repo, cache, sender)
+
+			s := New(logger.New("local", "info"), cache, repo, nil, nil, sender, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, passwordless, nil, nil, nil, nil, nil)
+
+			binding, err := s.RequestMagicLink(ctx, tc.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantBinding, binding)
+		})
+	}
+}
+
+func TestAuthService_RequestMagicLink_NewBinding(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockUser(ctrl)
+	repo.EXPECT().UserByEmail(gomock.Any(), "unknown@example.com").Return(entity.User{}, repoErrs.ErrNotFound).Times(2)
+
+	s := New(logger.New("local", "info"), nil, repo, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+	first, err := s.RequestMagicLink(context.Background(), MagicLinkInput{Email: "unknown@example.com"})
+	assert.NoError(t, err)
+	assert.Regexp(t, "^[A-Za-z0-9_-]{43}$", first)
+
+	second, err := s.RequestMagicLink(context.Background(), MagicLinkInput{Email: "unknown@example.com"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+}
+
+func TestAuthService_VerifyMagicLink(t *testing.T) {
+	ctx := context.Background()
+	verifiedAt := time.Now()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com", EmailVerifiedAt: &verifiedAt}
+	unverifiedUser := entity.User{Id: user.Id, Email: user.Email}
+
+	hash := sha256.Sum256([]byte("binding"))
+	record, _ := json.Marshal(magicLinkRecord{UserId: user.Id, BindingHash: hash[:], Device: "Laptop", Nonce: "nonce"})
+
+	input := VerifyMagicLinkInput{Token: "token", Binding: "binding", IP: "192.0.2.1", UserAgent: "test-agent"}
+
+	type MockBehavior func(r *repomocks.MockUser, c *redismocks.MockCache, tg *utilmocks.MockTokenGenerator)
+
+	issueTokens := func(r *repomocks.MockUser, c *redismocks.MockCache, tg *utilmocks.MockTokenGenerator) {
+		tg.EXPECT().GenerateAccessToken(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(u entity.User, params jwtgen.TokenParams) (string, error) {
+				assert.True(t, params.EmailVerified)
+				return "access_token", nil
+			})
+		tg.EXPECT().GenerateRefreshToken(gomock.Any(), gomock.Any()).Return("refresh_token", nil)
+		tg.EXPECT().GenerateIDToken(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ entity.User, params jwtgen.IDTokenParams) (string, error) {
+				assert.Equal(t, "nonce", params.Nonce)
+				return "id_token", nil
+			})
+		r.EXPECT().UpdateLastLoginAttempt(ctx, user.Id).Return(nil)
+		c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), refreshTokenTTL).
+			DoAndReturn(func(_ context.Context, _ string, value string, _ time.Duration) error {
+				var session sessionRecord
+				assert.NoError(t, json.Unmarshal([]byte(value), &session))
+				assert.Equal(t, "Laptop", session.Device)
+				assert.Equal(t, "192.0.2.1", session.IP)
+				assert.Equal(t, "test-agent", session.UserAgent)
+				return nil
+			})
+		c.EXPECT().SAdd(ctx, "sessions:"+user.Id.String(), gomock.Any()).Return(nil)
+		c.EXPECT().Expire(ctx, "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
+	}
+
+	testCases := []struct {
+		name         string
+		input        VerifyMagicLinkInput
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name:  "OK",
+			input: input,
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, tg *utilmocks.MockTokenGenerator) {
+				c.EXPECT().GetDel(ctx, "magic_link:token").Return(string(record), nil)
+				r.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+				issueTokens(r, c, tg)
+			},
+		},
+		{
+			name:  "OK: email becomes verified",
+			input: input,
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, tg *utilmocks.MockTokenGenerator) {
+				c.EXPECT().GetDel(ctx, "magic_link:token").Return(string(record), nil)
+				r.EXPECT().UserById(ctx, user.Id).Return(unverifiedUser, nil)
+				r.EXPECT().VerifyEmail(ctx, user.Id).Return(nil)
+				issueTokens(r, c, tg)
+			},
+		},
+		{
+			name:  "unknown or used token",
+			input: input,
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, tg *utilmocks.MockTokenGenerator) {
+				c.EXPECT().GetDel(ctx, "magic_link:token").Return("", errors.New("redis: nil"))
+			},
+			wantErr: svcErrs.ErrInvalidMagicLink,
+		},
+		{
+			name:  "another browser",
+			input: VerifyMagicLinkInput{Token: "token", Binding: "other", IP: "192.0.2.1"},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, tg *utilmocks.MockTokenGenerator) {
+				c.EXPECT().GetDel(ctx, "magic_link:token").Return(string(record), nil)
+			},
+			wantErr: svcErrs.ErrInvalidMagicLink,
+		},
+		{
+			name:  "no binding",
+			input: VerifyMagicLinkInput{Token: "token", IP: "192.0.2.1"},
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, tg *utilmocks.MockTokenGenerator) {
+				c.EXPECT().GetDel(ctx, "magic_link:token").Return(string(record), nil)
+			},
+			wantErr: svcErrs.ErrInvalidMagicLink,
+		},
+		{
+			name:  "user deleted",
+			input: input,
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, tg *utilmocks.MockTokenGenerator) {
+				c.EXPECT().GetDel(ctx, "magic_link:token").Return(string(record), nil)
+				r.EXPECT().UserById(ctx, user.Id).Return(entity.User{}, repoErrs.ErrNotFound)
+			},
+			wantErr: svcErrs.ErrInvalidMagicLink,
+		},
+		{
+			name:  "repo error",
+			input: input,
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, tg *utilmocks.MockTokenGenerator) {
+				c.EXPECT().GetDel(ctx, "magic_link:token").Return(string(record), nil)
+				r.EXPECT().UserById(ctx, user.Id).Return(entity.User{}, errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrCannotGetUser,
+		},
+		{
+			name:  "verify email error",
+			input: input,
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, tg *utilmocks.MockTokenGenerator) {
+				c.EXPECT().GetDel(ctx, "magic_link:token").Return(string(record), nil)
+				r.EXPECT().UserById(ctx, user.Id).Return(unverifiedUser, nil)
+				r.EXPECT().VerifyEmail(ctx, user.Id).Return(errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrCannotUpdateUser,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockUser(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			tc.mockBehavior(repo, cache, tokenGenerator)
+
+			s := New(logger.New("local", "info"), cache, repo, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+			got, err := s.VerifyMagicLink(ctx, tc.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, GenerateTokenOutput{AccessToken: "access_token", RefreshToken: "refresh_token", IdToken: "id_token"}, got)
+		})
+	}
+}
+
+func TestAuthService_SendSignInCode(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	type MockBehavior func(r *repomocks.MockUser, o *authmocks.MockOneTimeCodes)
+
+	testCases := []struct {
+		name         string
+		email        string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name:  "OK",
+			email: "Test@example.com",
+			mockBehavior: func(r *repomocks.MockUser, o *authmocks.MockOneTimeCodes) {
+				r.EXPECT().UserByEmail(ctx, "Test@example.com").Return(user, nil)
+				o.EXPECT().SendEmail(ctx, otp.PurposeSignIn, "test@example.com", user.Email).Return(nil)
+			},
+		},
+		{
+			name:  "unknown email",
+			email: "unknown@example.com",
+			mockBehavior: func(r *repomocks.MockUser, o *authmocks.MockOneTimeCodes) {
+				r.EXPECT().UserByEmail(ctx, "unknown@example.com").Return(entity.User{}, repoErrs.ErrNotFound)
+			},
+		},
+		{
+			name:  "sent recently",
+			email: "test@example.com",
+			mockBehavior: func(r *repomocks.MockUser, o *authmocks.MockOneTimeCodes) {
+				r.EXPECT().UserByEmail(ctx, "test@example.com").Return(user, nil)
+				o.EXPECT().SendEmail(ctx, otp.PurposeSignIn, "test@example.com", user.Email).Return(svcErrs.ErrOTPThrottled)
+			},
+		},
+		{
+			name:  "locked out",
+			email: "test@example.com",
+			mockBehavior: func(r *repomocks.MockUser, o *authmocks.MockOneTimeCodes) {
+				r.EXPECT().UserByEmail(ctx, "test@example.com").Return(user, nil)
+				o.EXPECT().SendEmail(ctx, otp.PurposeSignIn, "test@example.com", user.Email).Return(svcErrs.ErrOTPLocked)
+			},
+		},
+		{
+			name:  "send error",
+			email: "test@example.com",
+			mockBehavior: func(r *repomocks.MockUser, o *authmocks.MockOneTimeCodes) {
+				r.EXPECT().UserByEmail(ctx, "test@example.com").Return(user, nil)
+				o.EXPECT().SendEmail(ctx, otp.PurposeSignIn, "test@example.com", user.Email).Return(svcErrs.ErrSendOTP)
+			},
+			wantErr: svcErrs.ErrSendOTP,
+		},
+		{
+			name:  "repo error",
+			email: "test@example.com",
+			mockBehavior: func(r *repomocks.MockUser, o *authmocks.MockOneTimeCodes) {
+				r.EXPECT().UserByEmail(ctx, "test@example.com").Return(entity.User{}, errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrCannotGetUser,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockUser(ctrl)
+			codes := authmocks.NewMockOneTimeCodes(ctrl)
+			tc.mockBehavior(repo, codes)
+
+			s := New(logger.New("local", "info"), nil, repo, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, codes, nil, nil, nil, nil)
+
+			err := s.SendSignInCode(ctx, tc.email)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_SignInWithCode(t *testing.T) {
+	ctx := context.Background()
+	verifiedAt := time.Now()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com", EmailVerifiedAt: &verifiedAt}
+	input := CodeSignInInput{Email: "Test@example.com", Code: "123456", Device: "Phone", IP: "192.0.2.1", UserAgent: "test-agent"}
+
+	type MockBehavior func(r *repomocks.MockUser, c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, tg *utilmocks.MockTokenGenerator)
+
+	issueTokens := func(r *repomocks.MockUser, c *redismocks.MockCache, tg *utilmocks.MockTokenGenerator) {
+		tg.EXPECT().GenerateAccessToken(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ entity.User, params jwtgen.TokenParams) (string, error) {
+				assert.True(t, params.EmailVerified)
+				return "access_token", nil
+			})
+		tg.EXPECT().GenerateRefreshToken(gomock.Any(), gomock.Any()).Return("refresh_token", nil)
+		tg.EXPECT().GenerateIDToken(gomock.Any(), gomock.Any()).Return("id_token", nil)
+		r.EXPECT().UpdateLastLoginAttempt(ctx, user.Id).Return(nil)
+		c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), refreshTokenTTL).Return(nil)
+		c.EXPECT().SAdd(ctx, "sessions:"+user.Id.String(), gomock.Any()).Return(nil)
+		c.EXPECT().Expire(ctx, "sessions:"+user.Id.String(), refreshTokenTTL).Return(nil)
+	}
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, tg *utilmocks.MockTokenGenerator) {
+				o.EXPECT().Verify(ctx, otp.PurposeSignIn, "test@example.com", "123456").Return(nil)
+				r.EXPECT().UserByEmail(ctx, input.Email).Return(user, nil)
+				issueTokens(r, c, tg)
+			},
+		},
+		{
+			name: "OK: email becomes verified",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, tg *utilmocks.MockTokenGenerator) {
+				o.EXPECT().Verify(ctx, otp.PurposeSignIn, "test@example.com", "123456").Return(nil)
+				r.EXPECT().UserByEmail(ctx, input.Email).Return(entity.User{Id: user.Id, Email: user.Email}, nil)
+				r.EXPECT().VerifyEmail(ctx, user.Id).Return(nil)
+				issueTokens(r, c, tg)
+			},
+		},
+		{
+			name: "invalid code",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, tg *utilmocks.MockTokenGenerator) {
+				o.EXPECT().Verify(ctx, otp.PurposeSignIn, "test@example.com", "123456").Return(svcErrs.ErrInvalidOTP)
+			},
+			wantErr: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "locked out",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, tg *utilmocks.MockTokenGenerator) {
+				o.EXPECT().Verify(ctx, otp.PurposeSignIn, "test@example.com", "123456").Return(svcErrs.ErrOTPLocked)
+			},
+			wantErr: svcErrs.ErrOTPLocked,
+		},
+		{
+			name: "user deleted",
+			mockBehavior: func(r *repomocks.MockUser, c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, tg *utilmocks.MockTokenGenerator) {
+				o.EXPECT().Verify(ctx, otp.PurposeSignIn, "test@example.com", "123456").Return(nil)
+				r.EXPECT().UserByEmail(ctx, input.Email).Return(entity.User{}, repoErrs.ErrNotFound)
+			},
+			wantErr: svcErrs.ErrInvalidOTP,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockUser(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			codes := authmocks.NewMockOneTimeCodes(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			tc.mockBehavior(repo, cache, codes, tokenGenerator)
+
+			s := New(logger.New("local", "info"), cache, repo, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, codes, nil, nil, nil, nil)
+
+			got, err := s.SignInWithCode(ctx, input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, GenerateTokenOutput{AccessToken: "access_token", RefreshToken: "refresh_token", IdToken: "id_token"}, got)
+		})
+	}
+}
+
+func TestAuthService_VerificationCode(t *testing.T) {
+	ctx := context.Background()
+	verifiedAt := time.Now()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	t.Run("send", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repomocks.NewMockUser(ctrl)
+		codes := authmocks.NewMockOneTimeCodes(ctrl)
+		repo.EXPECT().UserByEmail(ctx, "test@example.com").Return(user, nil)
+		codes.EXPECT().SendEmail(ctx, otp.PurposeVerifyEmail, "test@example.com", user.Email).Return(nil)
+
+		s := New(logger.New("local", "info"), nil, repo, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, codes, nil, nil, nil, nil)
+
+		assert.NoError(t, s.SendVerificationCode(ctx, "test@example.com"))
+	})
+
+	t.Run("send: already verified", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repomocks.NewMockUser(ctrl)
+		repo.EXPECT().UserByEmail(ctx, "test@example.com").
+			Return(entity.User{Id: user.Id, Email: user.Email, EmailVerifiedAt: &verifiedAt}, nil)
+
+		s := New(logger.New("local", "info"), nil, repo, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, authmocks.NewMockOneTimeCodes(ctrl), nil, nil, nil, nil)
+
+		assert.NoError(t, s.SendVerificationCode(ctx, "test@example.com"))
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repomocks.NewMockUser(ctrl)
+		codes := authmocks.NewMockOneTimeCodes(ctrl)
+		codes.EXPECT().Verify(ctx, otp.PurposeVerifyEmail, "test@example.com", "123456").Return(nil)
+		repo.EXPECT().UserByEmail(ctx, "Test@example.com").Return(user, nil)
+		repo.EXPECT().VerifyEmail(ctx, user.Id).Return(nil)
+
+		s := New(logger.New("local", "info"), nil, repo, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, codes, nil, nil, nil, nil)
+
+		assert.NoError(t, s.VerifyEmailCode(ctx, "Test@example.com", "123456"))
+	})
+
+	t.Run("verify: invalid code", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		codes := authmocks.NewMockOneTimeCodes(ctrl)
+		codes.EXPECT().Verify(ctx, otp.PurposeVerifyEmail, "test@example.com", "123456").Return(svcErrs.ErrInvalidOTP)
+
+		s := New(logger.New("local", "info"), nil, nil, nil, nil, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, codes, nil, nil, nil, nil)
+
+		assert.ErrorIs(t, s.VerifyEmailCode(ctx, "test@example.com", "123456"), svcErrs.ErrInvalidOTP)
+	})
+}
+
+func TestAuthService_StepUp(t *testing.T) {
+	ctx := context.Background()
+	claims := &jwtgen.Claims{UserId: uuid.New(), Email: "test@example.com", SessionId: uuid.New()}
+
+	type MockBehavior func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, r *repomocks.MockUser, tg *utilmocks.MockTokenGenerator)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, r *repomocks.MockUser, tg *utilmocks.MockTokenGenerator) {
+				o.EXPECT().Verify(ctx, otp.PurposeStepUp, claims.UserId.String(), "123456").Return(nil)
+				c.EXPECT().Get(ctx, "session:"+claims.SessionId.String()).Return(sessionJSON(t, claims.SessionId, claims.UserId, "refresh_id"), nil)
+				tg.EXPECT().GenerateAccessToken(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.TokenParams) (string, error) {
+						assert.Equal(t, claims.SessionId, params.SessionId)
+						return "access_token", nil
+					})
+				tg.EXPECT().GenerateRefreshToken(gomock.Any(), gomock.Any()).Return("refresh_token", nil)
+				tg.EXPECT().GenerateIDToken(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ entity.User, params jwtgen.IDTokenParams) (string, error) {
+						assert.WithinDuration(t, time.Now(), params.AuthTime, time.Second)
+						return "id_token", nil
+					})
+				r.EXPECT().UpdateLastLoginAttempt(ctx, claims.UserId).Return(nil)
+				c.EXPECT().Set(ctx, "session:"+claims.SessionId.String(), gomock.Any(), refreshTokenTTL).Return(nil)
+				c.EXPECT().SAdd(ctx, "sessions:"+claims.UserId.String(), claims.SessionId.String()).Return(nil)
+				c.EXPECT().Expire(ctx, "sessions:"+claims.UserId.String(), refreshTokenTTL).Return(nil)
+			},
+		},
+		{
+			name: "invalid code",
+			mockBehavior: func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, r *repomocks.MockUser, tg *utilmocks.MockTokenGenerator) {
+				o.EXPECT().Verify(ctx, otp.PurposeStepUp, claims.UserId.String(), "123456").Return(svcErrs.ErrInvalidOTP)
+			},
+			wantErr: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "session ended",
+			mockBehavior: func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, r *repomocks.MockUser, tg *utilmocks.MockTokenGenerator) {
+				o.EXPECT().Verify(ctx, otp.PurposeStepUp, claims.UserId.String(), "123456").Return(nil)
+				c.EXPECT().Get(ctx, "session:"+claims.SessionId.String()).Return("", errors.New("redis: nil"))
+			},
+			wantErr: svcErrs.ErrSessionNotFound,
+		},
+		{
+			name: "session of another user",
+			mockBehavior: func(c *redismocks.MockCache, o *authmocks.MockOneTimeCodes, r *repomocks.MockUser, tg *utilmocks.MockTokenGenerator) {
+				o.EXPECT().Verify(ctx, otp.PurposeStepUp, claims.UserId.String(), "123456").Return(nil)
+				c.EXPECT().Get(ctx, "session:"+claims.SessionId.String()).Return(sessionJSON(t, claims.SessionId, uuid.New(), "refresh_id"), nil)
+			},
+			wantErr: svcErrs.ErrSessionNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockUser(ctrl)
+			cache := redismocks.NewMockCache(ctrl)
+			codes := authmocks.NewMockOneTimeCodes(ctrl)
+			tokenGenerator := utilmocks.NewMockTokenGenerator(ctrl)
+			tc.mockBehavior(cache, codes, repo, tokenGenerator)
+
+			s := New(logger.New("local", "info"), cache, repo, nil, tokenGenerator, nil, refreshTokenTTL, idTokenAudience, EmailVerificationConfig{}, PasswordlessConfig{}, codes, nil, nil, nil, nil)
+
+			got, err := s.StepUp(ctx, claims, "123456")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "access_token", got.AccessToken)
 		})
 	}
 }
@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/otp"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/google/uuid"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// SendSignInCode emails a one-time code that signs the user in without the password.
+// The result is the same for unknown emails, they get no email.
+func (s *Service) SendSignInCode(ctx context.Context, email string) error {
+	const op = "service.auth.SendSignInCode"
+	log := s.log.With(slog.String("op", op))
+
+	user, err := s.userRepo.UserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return nil
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return svcErrs.ErrCannotGetUser
+	}
+
+	return s.sendCode(ctx, otp.PurposeSignIn, user)
+}
+
+// SignInWithCode exchanges the code sent by SendSignInCode for tokens. Users with two-factor authentication
+// get only an MFA token. The code proves the user owns the email, so an unverified email becomes verified.
+func (s *Service) SignInWithCode(ctx context.Context, input CodeSignInInput) (GenerateTokenOutput, error) {
+	const op = "service.auth.SignInWithCode"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.codes.Verify(ctx, otp.PurposeSignIn, strings.ToLower(input.Email), input.Code); err != nil {
+		return GenerateTokenOutput{}, err
+	}
+
+	user, err := s.userRepo.UserByEmail(ctx, input.Email)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return GenerateTokenOutput{}, svcErrs.ErrInvalidOTP
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return GenerateTokenOutput{}, svcErrs.ErrCannotGetUser
+	}
+
+	if err = s.markEmailVerified(ctx, log, &user); err != nil {
+		return GenerateTokenOutput{}, err
+	}
+
+	return s.SignIn(ctx, user, IssueTokensInput{
+		Device:    input.Device,
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Nonce:     input.Nonce,
+	})
+}
+
+// SendVerificationCode emails a one-time code that verifies the email, the code replaces the link for mobile apps.
+// Unknown and already verified addresses get no email, so that the result does not tell whether there is an account.
+func (s *Service) SendVerificationCode(ctx context.Context, email string) error {
+	const op = "service.auth.SendVerificationCode"
+	log := s.log.With(slog.String("op", op))
+
+	user, err := s.userRepo.UserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return nil
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return svcErrs.ErrCannotGetUser
+	}
+
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	return s.sendCode(ctx, otp.PurposeVerifyEmail, user)
+}
+
+// VerifyEmailCode marks the email as verified with the code sent by SendVerificationCode.
+func (s *Service) VerifyEmailCode(ctx context.Context, email, code string) error {
+	const op = "service.auth.VerifyEmailCode"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.codes.Verify(ctx, otp.PurposeVerifyEmail, strings.ToLower(email), code); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.UserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrInvalidOTP
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return svcErrs.ErrCannotGetUser
+	}
+
+	return s.markEmailVerified(ctx, log, &user)
+}
+
+// SendStepUpCode emails a one-time code that the signed-in user confirms a sensitive action with.
+func (s *Service) SendStepUpCode(ctx context.Context, userId uuid.UUID) error {
+	const op = "service.auth.SendStepUpCode"
+	log := s.log.With(slog.String("op", op))
+
+	user, err := s.userRepo.UserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrUserNotFound
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return svcErrs.ErrCannotGetUser
+	}
+
+	return s.codes.SendEmail(ctx, otp.PurposeStepUp, user.Id.String(), user.Email)
+}
+
+// StepUp checks the code sent by SendStepUpCode and issues new tokens for the session of the access token.
+// The auth_time of the new ID token is now, so that relying parties can require a recent authentication.
+func (s *Service) StepUp(ctx context.Context, claims *jwtgen.Claims, code string) (GenerateTokenOutput, error) {
+	const op = "service.auth.StepUp"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.codes.Verify(ctx, otp.PurposeStepUp, claims.UserId.String(), code); err != nil {
+		return GenerateTokenOutput{}, err
+	}
+
+	session, err := s.session(ctx, claims.SessionId)
+	if err != nil || session.UserId != claims.UserId {
+		return GenerateTokenOutput{}, svcErrs.ErrSessionNotFound
+	}
+
+	session.AuthTime = time.Now()
+
+	log.Info("session stepped up",
+		sl.SecurityEvent("step_up"),
+		slog.String("user_id", claims.UserId.String()),
+		slog.String("session_id", session.Id.String()),
+	)
+
+	return s.generateTokens(ctx, log, entity.User{Id: claims.UserId, Email: claims.Email}, session, "")
+}
+
+// sendCode emails a code for the flow keyed by the email. A code sent recently, or a lockout,
+// is not reported, so that the result is the same as for unknown emails.
+func (s *Service) sendCode(ctx context.Context, purpose otp.Purpose, user entity.User) error {
+	err := s.codes.SendEmail(ctx, purpose, strings.ToLower(user.Email), user.Email)
+	if errors.Is(err, svcErrs.ErrOTPThrottled) || errors.Is(err, svcErrs.ErrOTPLocked) {
+		return nil
+	}
+
+	return err
+}
+
+// markEmailVerified verifies the email of a user who proved they own it by using a code or a link sent to it.
+func (s *Service) markEmailVerified(ctx context.Context, log *slog.Logger, user *entity.User) error {
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	if err := s.userRepo.VerifyEmail(ctx, user.Id); err != nil {
+		log.Error("failed to verify email", sl.Err(err))
+		return svcErrs.ErrCannotUpdateUser
+	}
+
+	log.Info("email verified",
+		sl.SecurityEvent("email_verified"),
+		slog.String("user_id", user.Id.String()),
+	)
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
+	"github.com/google/uuid"
+	"time"
+)
+
+const revokedTokenKeyTemplate = "revoked_token:%s"
+
+// denyToken puts the jti of an access token on the denylist.
+// The entry lives until the token expires, an expired token is rejected anyway.
+func (s *Service) denyToken(ctx context.Context, claims *jwtgen.Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+
+	// the holder of the token is kept for troubleshooting
+	holder := claims.Subject
+	if !claims.IsService() {
+		holder = claims.UserId.String()
+	}
+
+	return s.cache.Set(ctx, fmt.Sprintf(revokedTokenKeyTemplate, claims.ID), holder, ttl)
+}
+
+// isTokenDenied reports whether the access token was revoked, or its session was ended or its token family revoked.
+// Tokens without a jti were issued before the denylist existed and cannot be revoked on their own.
+func (s *Service) isTokenDenied(ctx context.Context, claims *jwtgen.Claims) (bool, error) {
+	if claims.ID != "" {
+		denied, err := s.cache.Exists(ctx, fmt.Sprintf(revokedTokenKeyTemplate, claims.ID))
+		if err != nil || denied {
+			return denied, err
+		}
+	}
+
+	if claims.SessionId == uuid.Nil {
+		return false, nil
+	}
+
+	for _, template := range []string{revokedSessionKeyTemplate, endedSessionKeyTemplate} {
+		denied, err := s.cache.Exists(ctx, fmt.Sprintf(template, claims.SessionId))
+		if err != nil || denied {
+			return denied, err
+		}
+	}
+
+	return false, nil
+}
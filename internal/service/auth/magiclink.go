@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/google/uuid"
+	"log/slog"
+)
+
+const magicLinkKeyTemplate = "magic_link:%s"
+
+// magicLinkRecord is the cached state of a sign-in link.
+type magicLinkRecord struct {
+	UserId uuid.UUID `json:"user_id"`
+	// BindingHash is the SHA-256 of the secret of the browser that asked for the link.
+	BindingHash []byte `json:"binding_hash"`
+	Device      string `json:"device"`
+	Nonce       string `json:"nonce"`
+}
+
+// RequestMagicLink emails a single-use sign-in link to the user and returns the secret of the browser
+// the link is bound to. The result is the same for unknown emails, they get no email.
+func (s *Service) RequestMagicLink(ctx context.Context, input MagicLinkInput) (string, error) {
+	const op = "service.auth.RequestMagicLink"
+	log := s.log.With(slog.String("op", op))
+
+	binding := input.Binding
+	if binding == "" {
+		var err error
+		if binding, err = randomToken(); err != nil {
+			log.Error("failed to generate the browser binding", sl.Err(err))
+			return "", svcErrs.ErrSendMagicLinkEmail
+		}
+	}
+
+	user, err := s.userRepo.UserByEmail(ctx, input.Email)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return binding, nil
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return "", svcErrs.ErrCannotGetUser
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		log.Error("failed to generate sign-in link token", sl.Err(err))
+		return "", svcErrs.ErrSendMagicLinkEmail
+	}
+
+	bindingHash := sha256.Sum256([]byte(binding))
+	data, err := json.Marshal(magicLinkRecord{
+		UserId:      user.Id,
+		BindingHash: bindingHash[:],
+		Device:      input.Device,
+		Nonce:       input.Nonce,
+	})
+	if err != nil {
+		log.Error("failed to marshal sign-in link", sl.Err(err))
+		return "", svcErrs.ErrSendMagicLinkEmail
+	}
+
+	if err = s.cache.Set(ctx, fmt.Sprintf(magicLinkKeyTemplate, token), string(data), s.passwordless.MagicLinkTTL); err != nil {
+		log.Error("failed to save the sign-in link to cache", sl.Err(err))
+		return "", svcErrs.ErrAccessToCache
+	}
+
+	if err = s.emailSender.SendMagicLinkEmail(user.Email, token); err != nil {
+		log.Error("failed to send the sign-in link email", sl.Err(err))
+		return "", svcErrs.ErrSendMagicLinkEmail
+	}
+
+	return binding, nil
+}
+
+// VerifyMagicLink exchanges the token of a sign-in link for tokens, every link works once and only
+// in the browser that asked for it. Users with two-factor authentication get only an MFA token.
+// The link proves the user owns the email, so an unverified email becomes verified.
+func (s *Service) VerifyMagicLink(ctx context.Context, input VerifyMagicLinkInput) (GenerateTokenOutput, error) {
+	const op = "service.auth.VerifyMagicLink"
+	log := s.log.With(slog.String("op", op))
+
+	data, err := s.cache.GetDel(ctx, fmt.Sprintf(magicLinkKeyTemplate, input.Token))
+	if err != nil {
+		return GenerateTokenOutput{}, svcErrs.ErrInvalidMagicLink
+	}
+
+	var record magicLinkRecord
+	if err = json.Unmarshal([]byte(data), &record); err != nil {
+		log.Error("failed to unmarshal sign-in link", sl.Err(err))
+		return GenerateTokenOutput{}, svcErrs.ErrInvalidMagicLink
+	}
+
+	bindingHash := sha256.Sum256([]byte(input.Binding))
+	if subtle.ConstantTimeCompare(bindingHash[:], record.BindingHash) != 1 {
+		log.Warn("sign-in link used in another browser",
+			sl.SecurityEvent("magic_link_binding_mismatch"),
+			slog.String("user_id", record.UserId.String()),
+			slog.String("ip", input.IP),
+		)
+		return GenerateTokenOutput{}, svcErrs.ErrInvalidMagicLink
+	}
+
+	user, err := s.userRepo.UserById(ctx, record.UserId)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return GenerateTokenOutput{}, svcErrs.ErrInvalidMagicLink
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return GenerateTokenOutput{}, svcErrs.ErrCannotGetUser
+	}
+
+	if err = s.markEmailVerified(ctx, log, &user); err != nil {
+		return GenerateTokenOutput{}, err
+	}
+
+	return s.SignIn(ctx, user, IssueTokensInput{
+		Device:    record.Device,
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Nonce:     record.Nonce,
+	})
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
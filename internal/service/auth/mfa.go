@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/otp"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+const (
+	mfaChallengeKeyTemplate = "mfa_challenge:%s"
+	mfaAttemptsKeyTemplate  = "mfa_attempts:%s"
+	mfaChallengeTTL         = 5 * time.Minute
+	// mfaMaxAttempts is the number of wrong second factors of a user after which the account is locked out
+	// and no second factor is checked until mfaAttemptsWindow ends.
+	mfaMaxAttempts    = 5
+	mfaAttemptsWindow = 15 * time.Minute
+)
+
+// mfaChallenge is the cached state of a sign-in that waits for the second factor.
+type mfaChallenge struct {
+	UserId    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	Device    string    `json:"device"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Nonce     string    `json:"nonce"`
+	AuthTime  time.Time `json:"auth_time"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// EmailVerifiedAt is carried over to the tokens.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+}
+
+// VerifyMFA exchanges the MFA token returned by GenerateToken and a one-time code, a code texted by SendMFACode
+// or a passkey assertion for tokens.
+func (s *Service) VerifyMFA(ctx context.Context, input VerifyMFAInput) (GenerateTokenOutput, error) {
+	const op = "service.auth.VerifyMFA"
+	log := s.log.With(slog.String("op", op))
+
+	key, challenge, err := s.mfaChallenge(ctx, log, input.MFAToken)
+	if err != nil {
+		return GenerateTokenOutput{}, err
+	}
+
+	user := entity.User{Id: challenge.UserId, Email: challenge.Email, EmailVerifiedAt: challenge.EmailVerifiedAt}
+
+	attempts, err := s.countSecondFactorAttempt(ctx, log, user)
+	if err != nil {
+		if errors.As(err, new(*svcErrs.RetryAfterError)) {
+			s.dropMFAChallenge(ctx, log, key)
+		}
+		return GenerateTokenOutput{}, err
+	}
+
+	switch {
+	case input.PasskeySessionId != "":
+		err = s.verifyPasskey(ctx, challenge.UserId, input.PasskeySessionId, input.PasskeyResponse)
+	case input.SMS:
+		err = s.codes.Verify(ctx, otp.PurposeSecondFactor, challenge.UserId.String(), input.Code)
+	default:
+		err = s.verifyTOTP(ctx, challenge.UserId, input.Code)
+	}
+	if err != nil {
+		if err = s.failSecondFactor(ctx, log, user, attempts, err); errors.As(err, new(*svcErrs.RetryAfterError)) {
+			s.dropMFAChallenge(ctx, log, key)
+		}
+		return GenerateTokenOutput{}, err
+	}
+
+	s.resetSecondFactorAttempts(ctx, log, user)
+
+	// the challenge is single-use, a concurrent exchange that deleted it first wins
+	if _, err = s.cache.GetDel(ctx, key); err != nil {
+		return GenerateTokenOutput{}, svcErrs.ErrInvalidMFAToken
+	}
+
+	return s.IssueTokens(ctx, user, IssueTokensInput{
+		Device:    challenge.Device,
+		IP:        challenge.IP,
+		UserAgent: challenge.UserAgent,
+		Nonce:     challenge.Nonce,
+		AuthTime:  challenge.AuthTime,
+	})
+}
+
+// SendMFACode texts a code that answers the second factor challenge of the MFA token
+// to the verified phone of a user who enabled the SMS second factor.
+func (s *Service) SendMFACode(ctx context.Context, mfaToken string) error {
+	const op = "service.auth.SendMFACode"
+	log := s.log.With(slog.String("op", op))
+
+	_, challenge, err := s.mfaChallenge(ctx, log, mfaToken)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.UserById(ctx, challenge.UserId)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrInvalidMFAToken
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return svcErrs.ErrCannotGetUser
+	}
+
+	if !smsSecondFactor(user) {
+		return svcErrs.ErrPhoneNotFound
+	}
+
+	return s.codes.SendSMS(ctx, otp.PurposeSecondFactor, user.Id.String(), *user.Phone)
+}
+
+// VerifySecondFactor checks the one-time code of a user who signs in with a form that asks for both factors.
+// It does nothing for users without two-factor authentication. Users with only the SMS second factor
+// get a code texted when they send the form without one. The wrong codes count towards the same limit as VerifyMFA.
+func (s *Service) VerifySecondFactor(ctx context.Context, user entity.User, code string) error {
+	const op = "service.auth.VerifySecondFactor"
+	log := s.log.With(slog.String("op", op))
+
+	totp, err := s.totpEnabled(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+
+	sms := smsSecondFactor(user)
+	if !totp && !sms {
+		return nil
+	}
+
+	if code == "" {
+		if !totp {
+			if err = s.codes.SendSMS(ctx, otp.PurposeSecondFactor, user.Id.String(), *user.Phone); err != nil &&
+				!errors.Is(err, svcErrs.ErrOTPThrottled) {
+				log.Error("failed to send the second factor code", sl.Err(err))
+			}
+		}
+
+		return svcErrs.ErrMFARequired
+	}
+
+	attempts, err := s.countSecondFactorAttempt(ctx, log, user)
+	if err != nil {
+		return err
+	}
+
+	if totp {
+		err = s.secondFactor.Verify(ctx, user.Id, code)
+	}
+	if !totp || sms && errors.Is(err, svcErrs.ErrInvalidOTP) {
+		err = s.codes.Verify(ctx, otp.PurposeSecondFactor, user.Id.String(), code)
+	}
+	if err != nil {
+		return s.failSecondFactor(ctx, log, user, attempts, err)
+	}
+
+	s.resetSecondFactorAttempts(ctx, log, user)
+
+	return nil
+}
+
+func (s *Service) mfaRequired(ctx context.Context, user entity.User) (bool, error) {
+	if smsSecondFactor(user) {
+		return true, nil
+	}
+
+	return s.totpEnabled(ctx, user.Id)
+}
+
+func (s *Service) totpEnabled(ctx context.Context, userId uuid.UUID) (bool, error) {
+	if s.secondFactor == nil {
+		return false, nil
+	}
+
+	return s.secondFactor.Enabled(ctx, userId)
+}
+
+// verifyTOTP checks a code of the authenticator app or a recovery code.
+func (s *Service) verifyTOTP(ctx context.Context, userId uuid.UUID, code string) error {
+	if s.secondFactor == nil {
+		return svcErrs.ErrMFANotEnabled
+	}
+
+	return s.secondFactor.Verify(ctx, userId, code)
+}
+
+func smsSecondFactor(user entity.User) bool {
+	return user.SMSSecondFactor && user.IsPhoneVerified()
+}
+
+// mfaChallenge returns the sign-in the MFA token refers to and its cache key.
+func (s *Service) mfaChallenge(ctx context.Context, log *slog.Logger, token string) (string, mfaChallenge, error) {
+	key := fmt.Sprintf(mfaChallengeKeyTemplate, token)
+
+	data, err := s.cache.Get(ctx, key)
+	if err != nil {
+		return "", mfaChallenge{}, svcErrs.ErrInvalidMFAToken
+	}
+
+	var challenge mfaChallenge
+	if err = json.Unmarshal([]byte(data), &challenge); err != nil {
+		log.Error("failed to decode mfa challenge", sl.Err(err))
+		return "", mfaChallenge{}, svcErrs.ErrInvalidMFAToken
+	}
+
+	return key, challenge, nil
+}
+
+// newMFAChallenge saves the sign-in and returns the token that refers to it.
+func (s *Service) newMFAChallenge(ctx context.Context, user entity.User, input IssueTokensInput) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+	data, err := json.Marshal(mfaChallenge{
+		UserId:    user.Id,
+		Email:     user.Email,
+		Device:    input.Device,
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Nonce:     input.Nonce,
+		AuthTime:  now,
+		ExpiresAt: now.Add(mfaChallengeTTL),
+
+		EmailVerifiedAt: user.EmailVerifiedAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err = s.cache.Set(ctx, fmt.Sprintf(mfaChallengeKeyTemplate, token), string(data), mfaChallengeTTL); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// countSecondFactorAttempt counts an attempt at the second factor of the user before it is checked,
+// so concurrent guesses cannot get past mfaMaxAttempts. The counter is per user and outlives the sign-in,
+// signing in again with the password brings no new attempts.
+func (s *Service) countSecondFactorAttempt(ctx context.Context, log *slog.Logger, user entity.User) (int64, error) {
+	key := fmt.Sprintf(mfaAttemptsKeyTemplate, user.Id)
+
+	attempts, err := s.cache.Incr(ctx, key, mfaAttemptsWindow)
+	if err != nil {
+		log.Error("failed to count second factor attempts", sl.Err(err))
+		return 0, svcErrs.ErrAccessToCache
+	}
+
+	if attempts <= mfaMaxAttempts {
+		return attempts, nil
+	}
+
+	ttl, err := s.cache.TTL(ctx, key)
+	if err != nil || ttl <= 0 {
+		ttl = mfaAttemptsWindow
+	}
+
+	return 0, &svcErrs.RetryAfterError{Err: svcErrs.ErrTooManySignInAttempts, RetryAfter: ttl}
+}
+
+// failSecondFactor returns the error of a rejected second factor, the last wrong one locks the account out.
+func (s *Service) failSecondFactor(ctx context.Context, log *slog.Logger, user entity.User, attempts int64, err error) error {
+	if attempts < mfaMaxAttempts || !errors.Is(err, svcErrs.ErrInvalidOTP) && !errors.Is(err, svcErrs.ErrInvalidPasskey) {
+		return err
+	}
+
+	log.Warn("too many failed second factor attempts, locking out",
+		sl.SecurityEvent("mfa_attempts_exceeded"),
+		slog.String("user_id", user.Id.String()),
+	)
+
+	if s.lockout == nil {
+		return &svcErrs.RetryAfterError{Err: svcErrs.ErrTooManySignInAttempts, RetryAfter: mfaAttemptsWindow}
+	}
+
+	return s.lockout.LockAccount(ctx, user.Email)
+}
+
+// resetSecondFactorAttempts forgets the wrong second factors of the user after a right one.
+func (s *Service) resetSecondFactorAttempts(ctx context.Context, log *slog.Logger, user entity.User) {
+	if err := s.cache.Delete(ctx, fmt.Sprintf(mfaAttemptsKeyTemplate, user.Id)); err != nil {
+		log.Error("failed to reset second factor attempts", sl.Err(err))
+	}
+}
+
+// dropMFAChallenge deletes the challenge once the second factor attempts of the user are used up.
+func (s *Service) dropMFAChallenge(ctx context.Context, log *slog.Logger, key string) {
+	if err := s.cache.Delete(ctx, key); err != nil {
+		log.Error("failed to delete mfa challenge", sl.Err(err))
+	}
+}
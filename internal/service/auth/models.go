@@ -1,19 +1,135 @@
 package auth
 
+import (
+	"github.com/bubalync/uni-auth/internal/entity"
+	"time"
+)
+
+// ScopeOpenId asks for an ID token in the OAuth flows.
+const ScopeOpenId = "openid"
+
+// Token types reported by introspection, they match the token_type_hint values of RFC 7009.
+const (
+	TokenTypeAccessToken  = "access_token"
+	TokenTypeRefreshToken = "refresh_token"
+)
+
 type (
+	// EmailVerificationConfig describes how users prove they own the email they signed up with.
+	EmailVerificationConfig struct {
+		// Required stops users with an unverified email from signing in with the password.
+		Required bool
+		// TokenTTL is the lifetime of the links sent by email.
+		TokenTTL time.Duration
+		// ResendInterval is the least time between two verification emails to an address.
+		ResendInterval time.Duration
+	}
+
+	// PasswordlessConfig describes the sign-in without a password.
+	PasswordlessConfig struct {
+		// MagicLinkTTL is the lifetime of the sign-in links sent by email.
+		MagicLinkTTL time.Duration
+	}
+
 	CreateUserInput struct {
 		Email    string
 		Password string
 	}
 
 	GenerateTokenInput struct {
-		Email    string
-		Password string
+		Email     string
+		Password  string
+		Device    string
+		IP        string
+		UserAgent string
+		// Nonce is echoed in the ID token to bind it to the client's request.
+		Nonce string
+	}
+
+	// IssueTokensInput describes the session started for an authenticated user.
+	IssueTokensInput struct {
+		Device    string
+		IP        string
+		UserAgent string
+		Nonce     string
+		// Client and Scope are set when the tokens are issued to an OAuth client.
+		Client *entity.Client
+		Scope  string
+		// AuthTime defaults to now.
+		AuthTime time.Time
 	}
 
 	GenerateTokenOutput struct {
 		AccessToken  string
 		RefreshToken string
+		// IdToken is empty when an OAuth client did not ask for the openid scope.
+		IdToken string
+		Scope   string
+		// MFAToken is the only field set when the user has to present a second factor.
+		MFAToken string
+	}
+
+	VerifyMFAInput struct {
+		MFAToken string
+		// Code is a one-time code or a recovery code.
+		Code string
+		// SMS marks Code as the code texted by SendMFACode.
+		SMS bool
+		// PasskeySessionId and PasskeyResponse answer the challenge with a passkey instead of the code.
+		PasskeySessionId string
+		PasskeyResponse  []byte
+	}
+
+	// PasskeySignInInput is the passwordless sign-in with a discoverable credential.
+	PasskeySignInInput struct {
+		SessionId string
+		// Response is the JSON encoded PublicKeyCredential returned by navigator.credentials.get.
+		Response  []byte
+		Device    string
+		IP        string
+		UserAgent string
+		Nonce     string
+	}
+
+	// TokenInfo describes a token for introspection (RFC 7662).
+	// Only Active is set when the token is not active.
+	TokenInfo struct {
+		Active bool
+		Scope  string
+		// ClientId is the client the token was issued to, empty for first-party sign-ins.
+		ClientId string
+		// Subject is the user id, or the client id of a service principal.
+		Subject   string
+		ExpiresAt time.Time
+		IssuedAt  time.Time
+		TokenId   string
+		TokenType string
+	}
+
+	MagicLinkInput struct {
+		Email string
+		// Binding is the secret of the browser that asked for an earlier link, a new one is generated when empty.
+		Binding string
+		Device  string
+		Nonce   string
+	}
+
+	VerifyMagicLinkInput struct {
+		Token string
+		// Binding is the secret of the browser the link is used in, it must be the one the link was asked for in.
+		Binding   string
+		IP        string
+		UserAgent string
+	}
+
+	// CodeSignInInput is the passwordless sign-in with a one-time code sent by email.
+	CodeSignInInput struct {
+		Email     string
+		Code      string
+		Device    string
+		IP        string
+		UserAgent string
+		Nonce     string
 	}
 
 	ResetPasswordInput struct {
@@ -24,4 +140,11 @@ type (
 		Token    string
 		Password string
 	}
+
+	// SMSRecoveryPasswordInput resets the password with a code texted to a verified phone.
+	SMSRecoveryPasswordInput struct {
+		Phone    string
+		Code     string
+		Password string
+	}
 )
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"github.com/bubalync/uni-auth/internal/service/passkey"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/google/uuid"
+	"log/slog"
+)
+
+// SignInWithPasskey starts a session for the owner of a discoverable credential.
+// The authenticator verified the user, so no second factor is asked for.
+func (s *Service) SignInWithPasskey(ctx context.Context, input PasskeySignInInput) (GenerateTokenOutput, error) {
+	if s.passkeys == nil {
+		return GenerateTokenOutput{}, svcErrs.ErrInvalidPasskey
+	}
+
+	user, err := s.passkeys.FinishLogin(ctx, input.SessionId, input.Response)
+	if err != nil {
+		return GenerateTokenOutput{}, err
+	}
+
+	return s.IssueTokens(ctx, user, IssueTokensInput{
+		Device:    input.Device,
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Nonce:     input.Nonce,
+	})
+}
+
+// BeginMFAPasskey starts the assertion that answers the second factor challenge of the MFA token
+// with a passkey of the user.
+func (s *Service) BeginMFAPasskey(ctx context.Context, mfaToken string) (passkey.Ceremony, error) {
+	const op = "service.auth.BeginMFAPasskey"
+	log := s.log.With(slog.String("op", op))
+
+	_, challenge, err := s.mfaChallenge(ctx, log, mfaToken)
+	if err != nil {
+		return passkey.Ceremony{}, err
+	}
+
+	if s.passkeys == nil {
+		return passkey.Ceremony{}, svcErrs.ErrPasskeyNotFound
+	}
+
+	return s.passkeys.BeginAssertion(ctx, challenge.UserId)
+}
+
+func (s *Service) verifyPasskey(ctx context.Context, userId uuid.UUID, sessionId string, response []byte) error {
+	if s.passkeys == nil {
+		return svcErrs.ErrInvalidPasskey
+	}
+
+	return s.passkeys.FinishAssertion(ctx, userId, sessionId, response)
+}
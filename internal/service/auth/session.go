@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	sessionKeyTemplate        = "session:%s"
+	userSessionsKeyTemplate   = "sessions:%s"
+	revokedSessionKeyTemplate = "revoked_session:%s"
+	// endedSessionKeyTemplate marks a session ended by the user or a client, its access tokens are denied.
+	endedSessionKeyTemplate = "ended_session:%s"
+	// rotatedRefreshTokenKeyTemplate counts the refreshes with a refresh token, only the first one rotates it.
+	rotatedRefreshTokenKeyTemplate = "refresh_rotated:%s"
+)
+
+// sessionRecord is the cached representation of a session.
+// Every refresh issued for the session belongs to one token family, and only
+// the jti of the latest token in the family is accepted.
+type sessionRecord struct {
+	entity.Session
+	RefreshTokenId string `json:"refresh_token_id"`
+	// Scope granted to the OAuth client, space separated.
+	Scope string `json:"scope,omitempty"`
+	// AuthTime is when the user entered the credentials.
+	AuthTime time.Time `json:"auth_time"`
+	// EmailVerified is stamped in the tokens of the session.
+	EmailVerified bool `json:"email_verified,omitempty"`
+	// Token settings of the OAuth client, kept for the refreshes of the session.
+	Audience        string        `json:"audience,omitempty"`
+	AccessTokenTTL  time.Duration `json:"access_token_ttl,omitempty"`
+	RefreshTokenTTL time.Duration `json:"refresh_token_ttl,omitempty"`
+}
+
+func newSessionRecord(userId uuid.UUID, device, ip, userAgent string) sessionRecord {
+	now := time.Now()
+
+	return sessionRecord{
+		Session: entity.Session{
+			Id:         uuid.New(),
+			UserId:     userId,
+			Device:     device,
+			IP:         ip,
+			UserAgent:  userAgent,
+			CreatedAt:  now,
+			LastUsedAt: now,
+		},
+		AuthTime: now,
+	}
+}
+
+func (s *Service) saveSession(ctx context.Context, rec sessionRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	ttl := s.refreshTokenTTL
+	if rec.RefreshTokenTTL != 0 {
+		ttl = rec.RefreshTokenTTL
+	}
+
+	if err = s.cache.Set(ctx, fmt.Sprintf(sessionKeyTemplate, rec.Id), string(data), ttl); err != nil {
+		return err
+	}
+
+	userKey := fmt.Sprintf(userSessionsKeyTemplate, rec.UserId)
+	if err = s.cache.SAdd(ctx, userKey, rec.Id.String()); err != nil {
+		return err
+	}
+
+	// the index must outlive the longest session it lists
+	return s.cache.Expire(ctx, userKey, max(ttl, s.refreshTokenTTL))
+}
+
+func (s *Service) session(ctx context.Context, id uuid.UUID) (sessionRecord, error) {
+	data, err := s.cache.Get(ctx, fmt.Sprintf(sessionKeyTemplate, id))
+	if err != nil {
+		return sessionRecord{}, err
+	}
+
+	var rec sessionRecord
+	if err = json.Unmarshal([]byte(data), &rec); err != nil {
+		return sessionRecord{}, err
+	}
+
+	return rec, nil
+}
+
+func (s *Service) deleteSession(ctx context.Context, userId, sessionId uuid.UUID) error {
+	if err := s.cache.Delete(ctx, fmt.Sprintf(sessionKeyTemplate, sessionId)); err != nil {
+		return err
+	}
+
+	return s.cache.SRem(ctx, fmt.Sprintf(userSessionsKeyTemplate, userId), sessionId.String())
+}
+
+// revokeSessionFamily deletes the session and leaves a marker behind,
+// so that any later attempt to use a token of the family is reported as reuse
+// and the access tokens of the family are denied.
+func (s *Service) revokeSessionFamily(ctx context.Context, rec sessionRecord) error {
+	if err := s.cache.Set(ctx, fmt.Sprintf(revokedSessionKeyTemplate, rec.Id), rec.UserId.String(), s.familyTTL(rec)); err != nil {
+		return err
+	}
+
+	return s.deleteSession(ctx, rec.UserId, rec.Id)
+}
+
+// endSession deletes the session and leaves a marker behind, so that the access tokens
+// of the session are denied too. Unlike a revoked family, its refresh token is not reported as reuse.
+func (s *Service) endSession(ctx context.Context, rec sessionRecord) error {
+	if err := s.cache.Set(ctx, fmt.Sprintf(endedSessionKeyTemplate, rec.Id), rec.UserId.String(), s.familyTTL(rec)); err != nil {
+		return err
+	}
+
+	return s.deleteSession(ctx, rec.UserId, rec.Id)
+}
+
+// familyTTL is the lifetime of the markers of the session, they outlive every token of the session.
+func (s *Service) familyTTL(rec sessionRecord) time.Duration {
+	return max(s.refreshTokenTTL, rec.RefreshTokenTTL, rec.AccessTokenTTL)
+}
+
+// claimRefreshToken reports whether this is the first refresh with the token. The counter is atomic,
+// so of concurrent refreshes with one token exactly one claims it.
+func (s *Service) claimRefreshToken(ctx context.Context, claims *jwtgen.Claims) (bool, error) {
+	ttl := s.refreshTokenTTL
+	if claims.ExpiresAt != nil && time.Until(claims.ExpiresAt.Time) > 0 {
+		ttl = time.Until(claims.ExpiresAt.Time)
+	}
+
+	n, err := s.cache.Incr(ctx, fmt.Sprintf(rotatedRefreshTokenKeyTemplate, claims.ID), ttl)
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+func (s *Service) isSessionFamilyRevoked(ctx context.Context, sessionId uuid.UUID) bool {
+	_, err := s.cache.Get(ctx, fmt.Sprintf(revokedSessionKeyTemplate, sessionId))
+	return err == nil
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/otp"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"log/slog"
+)
+
+// ResetPasswordBySMS texts a code that resets a forgotten password to a verified phone.
+// The result is the same for unknown phones, they get no message.
+func (s *Service) ResetPasswordBySMS(ctx context.Context, phone string) error {
+	const op = "service.auth.ResetPasswordBySMS"
+	log := s.log.With(slog.String("op", op))
+
+	if _, err := s.userRepo.UserByPhone(ctx, phone); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return nil
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return svcErrs.ErrCannotGetUser
+	}
+
+	err := s.codes.SendSMS(ctx, otp.PurposeRecovery, phone, phone)
+	if errors.Is(err, svcErrs.ErrOTPThrottled) || errors.Is(err, svcErrs.ErrOTPLocked) {
+		return nil
+	}
+
+	return err
+}
+
+// RecoveryPasswordBySMS sets a new password with the code sent by ResetPasswordBySMS.
+func (s *Service) RecoveryPasswordBySMS(ctx context.Context, input SMSRecoveryPasswordInput) error {
+	const op = "service.auth.RecoveryPasswordBySMS"
+	log := s.log.With(slog.String("op", op))
+
+	// checked first, the code stays valid for another password
+	if s.breached(log, input.Password) {
+		return svcErrs.ErrBreachedPassword
+	}
+
+	if err := s.codes.Verify(ctx, otp.PurposeRecovery, input.Phone, input.Code); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.UserByPhone(ctx, input.Phone)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrInvalidOTP
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return svcErrs.ErrCannotGetUser
+	}
+
+	pwd, err := s.hasher.Hash(input.Password)
+	if err != nil {
+		log.Error("failed to generate hashed password", sl.Err(err))
+		return svcErrs.ErrCannotUpdateUser
+	}
+
+	if err = s.userRepo.UpdatePassword(ctx, user.Email, pwd); err != nil {
+		log.Error("failed to update password", sl.Err(err))
+		return svcErrs.ErrCannotUpdateUser
+	}
+
+	log.Info("password recovered by sms",
+		sl.SecurityEvent("password_recovered_by_sms"),
+		slog.String("user_id", user.Id.String()),
+	)
+
+	return nil
+}
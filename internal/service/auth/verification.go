@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/google/uuid"
+	"log/slog"
+	"strings"
+)
+
+const (
+	verifyEmailKeyTemplate = "verify_email:%s"
+	// verificationSentKeyTemplate throttles the verification emails sent to an address.
+	verificationSentKeyTemplate = "verification_sent:%s"
+)
+
+// VerifyEmail marks the email the token was sent to as verified, every token works once.
+func (s *Service) VerifyEmail(ctx context.Context, token string) error {
+	const op = "service.auth.VerifyEmail"
+	log := s.log.With(slog.String("op", op))
+
+	id, err := s.cache.GetDel(ctx, fmt.Sprintf(verifyEmailKeyTemplate, token))
+	if err != nil {
+		return svcErrs.ErrInvalidVerificationToken
+	}
+
+	userId, err := uuid.Parse(id)
+	if err != nil {
+		log.Error("invalid user id in verification token", slog.String("user_id", id))
+		return svcErrs.ErrInvalidVerificationToken
+	}
+
+	if err = s.userRepo.VerifyEmail(ctx, userId); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			// the user was deleted after the email was sent
+			return svcErrs.ErrInvalidVerificationToken
+		}
+
+		log.Error("failed to verify email", sl.Err(err))
+		return svcErrs.ErrCannotUpdateUser
+	}
+
+	log.Info("email verified",
+		sl.SecurityEvent("email_verified"),
+		slog.String("user_id", userId.String()),
+	)
+
+	return nil
+}
+
+// ResendVerification sends another verification email, at most one per resend interval and address.
+// Unknown and already verified addresses are throttled alike but get no email,
+// so that the response does not tell whether there is an account.
+func (s *Service) ResendVerification(ctx context.Context, email string) error {
+	const op = "service.auth.ResendVerification"
+	log := s.log.With(slog.String("op", op))
+
+	sent, err := s.cache.Exists(ctx, verificationSentKey(email))
+	if err != nil {
+		log.Error("failed to check the verification throttle", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	if sent {
+		return svcErrs.ErrVerificationEmailThrottled
+	}
+
+	user, err := s.userRepo.UserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repoErrs.ErrNotFound) {
+		log.Error("failed to get user", sl.Err(err))
+		return svcErrs.ErrCannotGetUser
+	}
+
+	if err != nil || user.IsEmailVerified() {
+		if err = s.cache.Set(ctx, verificationSentKey(email), "1", s.verification.ResendInterval); err != nil {
+			log.Error("failed to save the verification throttle", sl.Err(err))
+			return svcErrs.ErrAccessToCache
+		}
+
+		return nil
+	}
+
+	return s.sendVerification(ctx, log, user)
+}
+
+// sendVerification emails a new verification link to the user and starts the resend interval.
+func (s *Service) sendVerification(ctx context.Context, log *slog.Logger, user entity.User) error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Error("failed to generate verification token", sl.Err(err))
+		return svcErrs.ErrSendVerificationEmail
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	if err := s.cache.Set(ctx, fmt.Sprintf(verifyEmailKeyTemplate, token), user.Id.String(), s.verification.TokenTTL); err != nil {
+		log.Error("failed to save the verification token to cache", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	if err := s.cache.Set(ctx, verificationSentKey(user.Email), "1", s.verification.ResendInterval); err != nil {
+		log.Error("failed to save the verification throttle", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	if err := s.emailSender.SendVerificationEmail(user.Email, token); err != nil {
+		log.Error("failed to send the verification email", sl.Err(err))
+		return svcErrs.ErrSendVerificationEmail
+	}
+
+	return nil
+}
+
+func verificationSentKey(email string) string {
+	return fmt.Sprintf(verificationSentKeyTemplate, strings.ToLower(email))
+}
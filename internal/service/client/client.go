@@ -0,0 +1,273 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/oauth"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/hasher"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Service manages the registry of OAuth clients.
+type Service struct {
+	log    *slog.Logger
+	repo   repo.Client
+	hasher hasher.PasswordHasher
+
+	maxAccessTokenTTL  time.Duration
+	maxRefreshTokenTTL time.Duration
+}
+
+// New -. The token lifetimes of clients are capped by maxAccessTokenTTL and maxRefreshTokenTTL.
+func New(log *slog.Logger, repo repo.Client, hasher hasher.PasswordHasher, maxAccessTokenTTL, maxRefreshTokenTTL time.Duration) *Service {
+	return &Service{
+		log:                log,
+		repo:               repo,
+		hasher:             hasher,
+		maxAccessTokenTTL:  maxAccessTokenTTL,
+		maxRefreshTokenTTL: maxRefreshTokenTTL,
+	}
+}
+
+func (s *Service) Create(ctx context.Context, input CreateClientInput) (CreateClientOutput, error) {
+	const op = "service.client.Create"
+	log := s.log.With(slog.String("op", op))
+
+	c := entity.Client{
+		Id:              input.Id,
+		Name:            input.Name,
+		RedirectURIs:    input.RedirectURIs,
+		GrantTypes:      input.GrantTypes,
+		Scopes:          input.Scopes,
+		Audience:        input.Audience,
+		AccessTokenTTL:  input.AccessTokenTTL,
+		RefreshTokenTTL: input.RefreshTokenTTL,
+	}
+	if c.Id == "" {
+		c.Id = uuid.NewString()
+	}
+
+	var secret string
+	if input.Confidential {
+		var err error
+		secret, c.SecretHash, err = s.newSecret()
+		if err != nil {
+			log.Error("failed to generate client secret", sl.Err(err))
+			return CreateClientOutput{}, svcErrs.ErrCannotCreateClient
+		}
+	}
+
+	if err := s.validate(c); err != nil {
+		return CreateClientOutput{}, err
+	}
+
+	if err := s.repo.Create(ctx, c); err != nil {
+		if errors.Is(err, repoErrs.ErrAlreadyExists) {
+			return CreateClientOutput{}, svcErrs.ErrClientAlreadyExists
+		}
+
+		log.Error("failed to create client", sl.Err(err))
+		return CreateClientOutput{}, svcErrs.ErrCannotCreateClient
+	}
+
+	log.Info("client registered", slog.String("client_id", c.Id))
+
+	return CreateClientOutput{Client: c, Secret: secret}, nil
+}
+
+// Update replaces the settings of the client. Tokens that were already issued keep their audience and lifetimes.
+func (s *Service) Update(ctx context.Context, input UpdateClientInput) (entity.Client, error) {
+	const op = "service.client.Update"
+	log := s.log.With(slog.String("op", op))
+
+	c, err := s.Client(ctx, input.Id)
+	if err != nil {
+		return entity.Client{}, err
+	}
+
+	c.Name = input.Name
+	c.RedirectURIs = input.RedirectURIs
+	c.GrantTypes = input.GrantTypes
+	c.Scopes = input.Scopes
+	c.Audience = input.Audience
+	c.AccessTokenTTL = input.AccessTokenTTL
+	c.RefreshTokenTTL = input.RefreshTokenTTL
+
+	if err = s.validate(c); err != nil {
+		return entity.Client{}, err
+	}
+
+	if err = s.repo.Update(ctx, c); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return entity.Client{}, svcErrs.ErrClientNotFound
+		}
+
+		log.Error("failed to update client", sl.Err(err))
+		return entity.Client{}, svcErrs.ErrCannotUpdateClient
+	}
+
+	return c, nil
+}
+
+// RotateSecret replaces the secret of the client and returns the new one.
+// A public client becomes confidential.
+func (s *Service) RotateSecret(ctx context.Context, id string) (string, error) {
+	const op = "service.client.RotateSecret"
+	log := s.log.With(slog.String("op", op))
+
+	secret, hash, err := s.newSecret()
+	if err != nil {
+		log.Error("failed to generate client secret", sl.Err(err))
+		return "", svcErrs.ErrCannotUpdateClient
+	}
+
+	if err = s.repo.UpdateSecret(ctx, id, hash); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return "", svcErrs.ErrClientNotFound
+		}
+
+		log.Error("failed to update client secret", sl.Err(err))
+		return "", svcErrs.ErrCannotUpdateClient
+	}
+
+	log.Info("client secret rotated", sl.SecurityEvent("client_secret_rotated"), slog.String("client_id", id))
+
+	return secret, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id string) error {
+	const op = "service.client.Delete"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrClientNotFound
+		}
+
+		log.Error("failed to delete client", sl.Err(err))
+		return svcErrs.ErrCannotUpdateClient
+	}
+
+	log.Info("client deleted", slog.String("client_id", id))
+
+	return nil
+}
+
+func (s *Service) Client(ctx context.Context, id string) (entity.Client, error) {
+	const op = "service.client.Client"
+	log := s.log.With(slog.String("op", op))
+
+	c, err := s.repo.ClientById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return entity.Client{}, svcErrs.ErrClientNotFound
+		}
+
+		log.Error("failed to get client", sl.Err(err))
+		return entity.Client{}, svcErrs.ErrCannotGetClient
+	}
+
+	return c, nil
+}
+
+func (s *Service) Clients(ctx context.Context) ([]entity.Client, error) {
+	const op = "service.client.Clients"
+	log := s.log.With(slog.String("op", op))
+
+	clients, err := s.repo.Clients(ctx)
+	if err != nil {
+		log.Error("failed to get clients", sl.Err(err))
+		return nil, svcErrs.ErrCannotGetClient
+	}
+
+	return clients, nil
+}
+
+func (s *Service) newSecret() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	secret := base64.RawURLEncoding.EncodeToString(b)
+
+	hash, err := s.hasher.Hash(secret)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return secret, hash, nil
+}
+
+// validate checks the settings against what the OAuth endpoints support.
+func (s *Service) validate(c entity.Client) error {
+	for _, gt := range c.GrantTypes {
+		if !slices.Contains(oauth.SupportedGrantTypes, gt) {
+			return fmt.Errorf("%w: unsupported grant type %q", svcErrs.ErrInvalidClientConfig, gt)
+		}
+	}
+
+	for _, sc := range c.Scopes {
+		if !validScope(sc) {
+			return fmt.Errorf("%w: invalid scope %q", svcErrs.ErrInvalidClientConfig, sc)
+		}
+	}
+
+	if c.AllowsGrantType(oauth.GrantTypeAuthorizationCode) && len(c.RedirectURIs) == 0 {
+		return fmt.Errorf("%w: %s requires a redirect uri", svcErrs.ErrInvalidClientConfig, oauth.GrantTypeAuthorizationCode)
+	}
+
+	if c.AllowsGrantType(oauth.GrantTypeClientCredentials) && !c.IsConfidential() {
+		return fmt.Errorf("%w: %s requires a confidential client", svcErrs.ErrInvalidClientConfig, oauth.GrantTypeClientCredentials)
+	}
+
+	for _, uri := range c.RedirectURIs {
+		// RFC 6749, section 3.1.2: absolute and without a fragment
+		u, err := url.Parse(uri)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			return fmt.Errorf("%w: invalid redirect uri %q", svcErrs.ErrInvalidClientConfig, uri)
+		}
+	}
+
+	if c.AccessTokenTTL < 0 || c.RefreshTokenTTL < 0 {
+		return fmt.Errorf("%w: token lifetimes must not be negative", svcErrs.ErrInvalidClientConfig)
+	}
+
+	// the signing keys are retired after the longest access token lifetime
+	if c.AccessTokenTTL > s.maxAccessTokenTTL {
+		return fmt.Errorf("%w: access token lifetime is longer than %s", svcErrs.ErrInvalidClientConfig, s.maxAccessTokenTTL)
+	}
+
+	if c.RefreshTokenTTL > s.maxRefreshTokenTTL {
+		return fmt.Errorf("%w: refresh token lifetime is longer than %s", svcErrs.ErrInvalidClientConfig, s.maxRefreshTokenTTL)
+	}
+
+	return nil
+}
+
+// validScope checks the scope-token syntax of RFC 6749, section 3.3.
+func validScope(scope string) bool {
+	if scope == "" {
+		return false
+	}
+
+	for _, r := range scope {
+		if r < 0x21 || r > 0x7e || r == '"' || r == '\\' {
+			return false
+		}
+	}
+
+	return true
+}
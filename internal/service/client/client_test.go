@@ -0,0 +1,305 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/mocks/repomocks"
+	"github.com/bubalync/uni-auth/internal/mocks/utilmocks"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+const (
+	maxAccessTokenTTL  = time.Hour
+	maxRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+func validInput() CreateClientInput {
+	return CreateClientInput{
+		Id:              "example-spa",
+		Name:            "Example SPA",
+		RedirectURIs:    []string{"http://localhost:3000/callback"},
+		GrantTypes:      []string{"authorization_code"},
+		Scopes:          []string{"openid", "email"},
+		Audience:        "https://api.example.com",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+	}
+}
+
+func TestClientService_Create(t *testing.T) {
+	type MockBehavior func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher)
+
+	testCases := []struct {
+		name         string
+		modify       func(i *CreateClientInput)
+		mockBehavior MockBehavior
+		wantSecret   bool
+		err          error
+	}{
+		{
+			name:   "public client",
+			modify: func(i *CreateClientInput) {},
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, c entity.Client) error {
+						assert.Equal(t, "example-spa", c.Id)
+						assert.Empty(t, c.SecretHash)
+						return nil
+					})
+			},
+		},
+		{
+			name:   "confidential client with a generated id",
+			modify: func(i *CreateClientInput) { i.Id = ""; i.Confidential = true },
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {
+				h.EXPECT().Hash(gomock.Any()).Return([]byte("hash"), nil)
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, c entity.Client) error {
+						assert.Len(t, c.Id, 36)
+						assert.Equal(t, []byte("hash"), c.SecretHash)
+						return nil
+					})
+			},
+			wantSecret: true,
+		},
+		{
+			name:         "unsupported grant type",
+			modify:       func(i *CreateClientInput) { i.GrantTypes = []string{"implicit"} },
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {},
+			err:          svcErrs.ErrInvalidClientConfig,
+		},
+		{
+			name: "confidential client with api scopes",
+			modify: func(i *CreateClientInput) {
+				i.Confidential = true
+				i.GrantTypes = []string{"client_credentials"}
+				i.Scopes = []string{"orders:read"}
+			},
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {
+				h.EXPECT().Hash(gomock.Any()).Return([]byte("hash"), nil)
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantSecret: true,
+		},
+		{
+			name:         "invalid scope",
+			modify:       func(i *CreateClientInput) { i.Scopes = []string{`orders"read`} },
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {},
+			err:          svcErrs.ErrInvalidClientConfig,
+		},
+		{
+			name:         "client credentials for a public client",
+			modify:       func(i *CreateClientInput) { i.GrantTypes = []string{"client_credentials"} },
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {},
+			err:          svcErrs.ErrInvalidClientConfig,
+		},
+		{
+			name:         "authorization code without a redirect uri",
+			modify:       func(i *CreateClientInput) { i.RedirectURIs = nil },
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {},
+			err:          svcErrs.ErrInvalidClientConfig,
+		},
+		{
+			name:         "redirect uri with a fragment",
+			modify:       func(i *CreateClientInput) { i.RedirectURIs = []string{"http://localhost:3000/callback#x"} },
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {},
+			err:          svcErrs.ErrInvalidClientConfig,
+		},
+		{
+			name:         "relative redirect uri",
+			modify:       func(i *CreateClientInput) { i.RedirectURIs = []string{"/callback"} },
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {},
+			err:          svcErrs.ErrInvalidClientConfig,
+		},
+		{
+			name:         "negative ttl",
+			modify:       func(i *CreateClientInput) { i.AccessTokenTTL = -time.Second },
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {},
+			err:          svcErrs.ErrInvalidClientConfig,
+		},
+		{
+			name:         "access token ttl above the maximum",
+			modify:       func(i *CreateClientInput) { i.AccessTokenTTL = maxAccessTokenTTL + time.Second },
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {},
+			err:          svcErrs.ErrInvalidClientConfig,
+		},
+		{
+			name:         "refresh token ttl above the maximum",
+			modify:       func(i *CreateClientInput) { i.RefreshTokenTTL = maxRefreshTokenTTL + time.Second },
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {},
+			err:          svcErrs.ErrInvalidClientConfig,
+		},
+		{
+			name:   "client already exists",
+			modify: func(i *CreateClientInput) {},
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).Return(repoErrs.ErrAlreadyExists)
+			},
+			err: svcErrs.ErrClientAlreadyExists,
+		},
+		{
+			name:   "repo error",
+			modify: func(i *CreateClientInput) {},
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+			},
+			err: svcErrs.ErrCannotCreateClient,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockClient(ctrl)
+			h := utilmocks.NewMockPasswordHasher(ctrl)
+			tc.mockBehavior(repo, h)
+
+			input := validInput()
+			tc.modify(&input)
+
+			got, err := New(logger.New("local", "info"), repo, h, maxAccessTokenTTL, maxRefreshTokenTTL).Create(context.Background(), input)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantSecret, got.Secret != "")
+		})
+	}
+}
+
+func TestClientService_Update(t *testing.T) {
+	stored := entity.Client{
+		Id:           "example-spa",
+		Name:         "Example SPA",
+		SecretHash:   []byte("hash"),
+		RedirectURIs: []string{"http://localhost:3000/callback"},
+		GrantTypes:   []string{"authorization_code"},
+	}
+
+	input := UpdateClientInput{
+		Id:             "example-spa",
+		Name:           "Renamed",
+		RedirectURIs:   []string{"https://app.example.com/callback"},
+		GrantTypes:     []string{"authorization_code"},
+		Scopes:         []string{"openid"},
+		AccessTokenTTL: 5 * time.Minute,
+	}
+
+	type MockBehavior func(r *repomocks.MockClient)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *repomocks.MockClient) {
+				r.EXPECT().ClientById(gomock.Any(), "example-spa").Return(stored, nil)
+				r.EXPECT().Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, c entity.Client) error {
+						assert.Equal(t, "Renamed", c.Name)
+						assert.Equal(t, []byte("hash"), c.SecretHash, "secret is kept")
+						assert.Equal(t, 5*time.Minute, c.AccessTokenTTL)
+						return nil
+					})
+			},
+		},
+		{
+			name: "client not found",
+			mockBehavior: func(r *repomocks.MockClient) {
+				r.EXPECT().ClientById(gomock.Any(), "example-spa").Return(entity.Client{}, repoErrs.ErrNotFound)
+			},
+			err: svcErrs.ErrClientNotFound,
+		},
+		{
+			name: "repo error",
+			mockBehavior: func(r *repomocks.MockClient) {
+				r.EXPECT().ClientById(gomock.Any(), "example-spa").Return(stored, nil)
+				r.EXPECT().Update(gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+			},
+			err: svcErrs.ErrCannotUpdateClient,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockClient(ctrl)
+			tc.mockBehavior(repo)
+
+			_, err := New(logger.New("local", "info"), repo, nil, maxAccessTokenTTL, maxRefreshTokenTTL).Update(context.Background(), input)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestClientService_RotateSecret(t *testing.T) {
+	type MockBehavior func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {
+				h.EXPECT().Hash(gomock.Any()).Return([]byte("hash"), nil)
+				r.EXPECT().UpdateSecret(gomock.Any(), "example-spa", []byte("hash")).Return(nil)
+			},
+		},
+		{
+			name: "client not found",
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {
+				h.EXPECT().Hash(gomock.Any()).Return([]byte("hash"), nil)
+				r.EXPECT().UpdateSecret(gomock.Any(), "example-spa", []byte("hash")).Return(repoErrs.ErrNotFound)
+			},
+			err: svcErrs.ErrClientNotFound,
+		},
+		{
+			name: "hasher error",
+			mockBehavior: func(r *repomocks.MockClient, h *utilmocks.MockPasswordHasher) {
+				h.EXPECT().Hash(gomock.Any()).Return(nil, errors.New("some error"))
+			},
+			err: svcErrs.ErrCannotUpdateClient,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockClient(ctrl)
+			h := utilmocks.NewMockPasswordHasher(ctrl)
+			tc.mockBehavior(repo, h)
+
+			secret, err := New(logger.New("local", "info"), repo, h, maxAccessTokenTTL, maxRefreshTokenTTL).RotateSecret(context.Background(), "example-spa")
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, secret, 43)
+		})
+	}
+}
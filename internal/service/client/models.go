@@ -0,0 +1,39 @@
+package client
+
+import (
+	"github.com/bubalync/uni-auth/internal/entity"
+	"time"
+)
+
+type (
+	CreateClientInput struct {
+		// Id is generated when empty.
+		Id   string
+		Name string
+		// Confidential clients get a secret, public clients rely on PKCE.
+		Confidential    bool
+		RedirectURIs    []string
+		GrantTypes      []string
+		Scopes          []string
+		Audience        string
+		AccessTokenTTL  time.Duration
+		RefreshTokenTTL time.Duration
+	}
+
+	CreateClientOutput struct {
+		Client entity.Client
+		// Secret is only returned once, the registry keeps its hash.
+		Secret string
+	}
+
+	UpdateClientInput struct {
+		Id              string
+		Name            string
+		RedirectURIs    []string
+		GrantTypes      []string
+		Scopes          []string
+		Audience        string
+		AccessTokenTTL  time.Duration
+		RefreshTokenTTL time.Duration
+	}
+)
@@ -0,0 +1,425 @@
+package federation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/bubalync/uni-auth/pkg/redis"
+	"github.com/google/uuid"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+const stateKeyTemplate = "federation_state:%s"
+
+// Authenticator is the part of the auth service that starts the session of a federated user.
+type Authenticator interface {
+	SignIn(ctx context.Context, user entity.User, input auth.IssueTokensInput) (auth.GenerateTokenOutput, error)
+}
+
+// stateRecord is the cached state of a sign-in redirected to a provider.
+type stateRecord struct {
+	Provider string `json:"provider"`
+	// LinkUserId is the signed-in user the identity is linked to, it is empty for a sign-in.
+	LinkUserId   uuid.UUID `json:"link_user_id"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	Device       string    `json:"device"`
+	// ClientNonce is echoed in the ID token issued after the callback.
+	ClientNonce string `json:"client_nonce"`
+}
+
+type Service struct {
+	log        *slog.Logger
+	cache      redis.Cache
+	auth       Authenticator
+	users      repo.User
+	identities repo.Identity
+	// passkeys is nil when passkeys are disabled, they do not count as a sign-in method then.
+	passkeys  repo.WebAuthnCredential
+	providers map[string]Provider
+	stateTTL  time.Duration
+}
+
+// New -.
+func New(
+	log *slog.Logger,
+	cache redis.Cache,
+	auth Authenticator,
+	users repo.User,
+	identities repo.Identity,
+	passkeys repo.WebAuthnCredential,
+	providers map[string]Provider,
+	stateTTL time.Duration,
+) *Service {
+	return &Service{
+		log:        log,
+		cache:      cache,
+		auth:       auth,
+		users:      users,
+		identities: identities,
+		passkeys:   passkeys,
+		providers:  providers,
+		stateTTL:   stateTTL,
+	}
+}
+
+// Providers returns the names of the configured providers.
+func (s *Service) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// Begin saves the state of a sign-in and returns the URL of the provider the user is redirected to.
+func (s *Service) Begin(ctx context.Context, input BeginInput) (BeginOutput, error) {
+	return s.begin(ctx, stateRecord{Provider: input.Provider, Device: input.Device, ClientNonce: input.Nonce})
+}
+
+// BeginLink starts a sign-in at the provider that links the identity to the user instead of signing in.
+func (s *Service) BeginLink(ctx context.Context, userId uuid.UUID, provider string) (BeginOutput, error) {
+	return s.begin(ctx, stateRecord{Provider: provider, LinkUserId: userId})
+}
+
+func (s *Service) begin(ctx context.Context, state stateRecord) (BeginOutput, error) {
+	const op = "service.federation.Begin"
+	log := s.log.With(slog.String("op", op), slog.String("provider", state.Provider))
+
+	provider, ok := s.providers[state.Provider]
+	if !ok {
+		return BeginOutput{}, svcErrs.ErrUnknownProvider
+	}
+
+	id, err := randomString()
+	if err != nil {
+		log.Error("failed to generate state", sl.Err(err))
+		return BeginOutput{}, svcErrs.ErrFederatedSignInFailed
+	}
+	if state.Nonce, err = randomString(); err != nil {
+		log.Error("failed to generate nonce", sl.Err(err))
+		return BeginOutput{}, svcErrs.ErrFederatedSignInFailed
+	}
+	if state.CodeVerifier, err = randomString(); err != nil {
+		log.Error("failed to generate code verifier", sl.Err(err))
+		return BeginOutput{}, svcErrs.ErrFederatedSignInFailed
+	}
+
+	url, err := provider.AuthCodeURL(ctx, id, state.Nonce, state.CodeVerifier)
+	if err != nil {
+		log.Error("failed to build authorization url", sl.Err(err))
+		return BeginOutput{}, svcErrs.ErrFederatedSignInFailed
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.Error("failed to encode state", sl.Err(err))
+		return BeginOutput{}, svcErrs.ErrFederatedSignInFailed
+	}
+
+	if err = s.cache.Set(ctx, fmt.Sprintf(stateKeyTemplate, id), string(data), s.stateTTL); err != nil {
+		log.Error("failed to save state", sl.Err(err))
+		return BeginOutput{}, svcErrs.ErrAccessToCache
+	}
+
+	return BeginOutput{URL: url, State: id}, nil
+}
+
+// Callback completes the sign-in at the provider. The user the identity is linked to is signed in,
+// users with two-factor authentication get only an MFA token. A sign-in started by BeginLink links
+// the identity to its user instead.
+func (s *Service) Callback(ctx context.Context, input CallbackInput) (CallbackOutput, error) {
+	const op = "service.federation.Callback"
+	log := s.log.With(slog.String("op", op), slog.String("provider", input.Provider))
+
+	provider, ok := s.providers[input.Provider]
+	if !ok {
+		return CallbackOutput{}, svcErrs.ErrUnknownProvider
+	}
+
+	// the state is single-use, a replayed callback finds nothing
+	data, err := s.cache.GetDel(ctx, fmt.Sprintf(stateKeyTemplate, input.State))
+	if err != nil {
+		return CallbackOutput{}, svcErrs.ErrInvalidFederationState
+	}
+
+	var state stateRecord
+	if err = json.Unmarshal([]byte(data), &state); err != nil || state.Provider != input.Provider {
+		return CallbackOutput{}, svcErrs.ErrInvalidFederationState
+	}
+
+	if input.Error != "" || input.Code == "" {
+		log.Info("sign in at the provider failed", slog.String("error", input.Error))
+		return CallbackOutput{}, svcErrs.ErrFederatedSignInFailed
+	}
+
+	claims, err := provider.Exchange(ctx, input.Code, state.CodeVerifier)
+	if err != nil {
+		log.Warn("failed to complete sign in at the provider", sl.Err(err))
+		return CallbackOutput{}, svcErrs.ErrFederatedSignInFailed
+	}
+
+	if claims.Nonce != state.Nonce {
+		log.Warn("id token nonce mismatch", sl.SecurityEvent("federation_nonce_mismatch"))
+		return CallbackOutput{}, svcErrs.ErrFederatedSignInFailed
+	}
+
+	if state.LinkUserId != uuid.Nil {
+		identity, err := s.link(ctx, log, state.LinkUserId, input.Provider, claims)
+		if err != nil {
+			return CallbackOutput{}, err
+		}
+
+		return CallbackOutput{Identity: &identity}, nil
+	}
+
+	user, err := s.user(ctx, log, input.Provider, claims)
+	if err != nil {
+		return CallbackOutput{}, err
+	}
+
+	log.Info("user signed in with an identity provider",
+		sl.SecurityEvent("federated_sign_in"),
+		slog.String("user_id", user.Id.String()),
+	)
+
+	tokens, err := s.auth.SignIn(ctx, user, auth.IssueTokensInput{
+		Device:    state.Device,
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Nonce:     state.ClientNonce,
+	})
+	if err != nil {
+		return CallbackOutput{}, err
+	}
+
+	return CallbackOutput{Tokens: tokens}, nil
+}
+
+// Identities returns the identities linked to the user.
+func (s *Service) Identities(ctx context.Context, userId uuid.UUID) ([]entity.Identity, error) {
+	const op = "service.federation.Identities"
+	log := s.log.With(slog.String("op", op))
+
+	identities, err := s.identities.IdentitiesByUserId(ctx, userId)
+	if err != nil {
+		log.Error("failed to get identities", sl.Err(err))
+		return nil, svcErrs.ErrCannotGetIdentities
+	}
+
+	return identities, nil
+}
+
+// Unlink removes an identity of the user, unless it is the only way left to sign in.
+func (s *Service) Unlink(ctx context.Context, userId uuid.UUID, provider, subject string) error {
+	const op = "service.federation.Unlink"
+	log := s.log.With(slog.String("op", op))
+
+	identities, err := s.identities.IdentitiesByUserId(ctx, userId)
+	if err != nil {
+		log.Error("failed to get identities", sl.Err(err))
+		return svcErrs.ErrCannotGetIdentities
+	}
+
+	if !slices.ContainsFunc(identities, func(i entity.Identity) bool {
+		return i.Provider == provider && i.Subject == subject
+	}) {
+		return svcErrs.ErrIdentityNotFound
+	}
+
+	other, err := s.hasOtherLoginMethod(ctx, userId, len(identities)-1)
+	if err != nil {
+		log.Error("failed to get sign-in methods", sl.Err(err))
+		return svcErrs.ErrCannotGetIdentities
+	}
+	if !other {
+		return svcErrs.ErrLastLoginMethod
+	}
+
+	if err = s.identities.Delete(ctx, userId, provider, subject); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrIdentityNotFound
+		}
+
+		log.Error("failed to delete identity", sl.Err(err))
+		return svcErrs.ErrCannotUpdateIdentities
+	}
+
+	log.Info("identity unlinked",
+		sl.SecurityEvent("identity_unlinked"),
+		slog.String("user_id", userId.String()),
+		slog.String("provider", provider),
+	)
+
+	return nil
+}
+
+// hasOtherLoginMethod tells whether the user can still sign in with a password, a passkey or one of the other identities.
+func (s *Service) hasOtherLoginMethod(ctx context.Context, userId uuid.UUID, otherIdentities int) (bool, error) {
+	if otherIdentities > 0 {
+		return true, nil
+	}
+
+	user, err := s.users.UserById(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	if len(user.PasswordHash) > 0 {
+		return true, nil
+	}
+
+	if s.passkeys == nil {
+		return false, nil
+	}
+
+	credentials, err := s.passkeys.CredentialsByUserId(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+
+	return len(credentials) > 0, nil
+}
+
+// user returns the user the identity is linked to. On the first sign-in with the identity it is linked
+// to the user with the same email, a user is created when there is none. Only emails verified by
+// the provider are trusted, otherwise anyone could take over an account.
+func (s *Service) user(ctx context.Context, log *slog.Logger, provider string, claims Claims) (entity.User, error) {
+	identity, err := s.identities.IdentityBySubject(ctx, provider, claims.Subject)
+	if err == nil {
+		user, err := s.users.UserById(ctx, identity.UserId)
+		if err != nil {
+			log.Error("failed to get user", sl.Err(err))
+			return entity.User{}, svcErrs.ErrCannotGetUser
+		}
+
+		if err = s.identities.UpdateLastUsed(ctx, provider, claims.Subject); err != nil {
+			log.Error("failed to update identity", sl.Err(err))
+		}
+
+		return user, nil
+	}
+	if !errors.Is(err, repoErrs.ErrNotFound) {
+		log.Error("failed to get identity", sl.Err(err))
+		return entity.User{}, svcErrs.ErrCannotGetIdentities
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return entity.User{}, svcErrs.ErrFederatedEmailNotVerified
+	}
+
+	user, err := s.users.UserByEmail(ctx, claims.Email)
+	if err != nil {
+		if !errors.Is(err, repoErrs.ErrNotFound) {
+			log.Error("failed to get user", sl.Err(err))
+			return entity.User{}, svcErrs.ErrCannotGetUser
+		}
+
+		if user, err = s.createUser(ctx, log, claims.Email); err != nil {
+			return entity.User{}, err
+		}
+	} else if !user.IsEmailVerified() {
+		// anyone could have signed up with the email and set the password, linking would hand them the account
+		log.Warn("federated sign-in matches an account with an unverified email",
+			sl.SecurityEvent("federated_link_refused"),
+			slog.String("user_id", user.Id.String()),
+			slog.String("provider", provider),
+		)
+		return entity.User{}, svcErrs.ErrFederatedAccountUnverified
+	}
+
+	if _, err = s.createIdentity(ctx, log, user.Id, provider, claims); err != nil {
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
+
+// createUser creates the user of a first federated sign-in. Such users have no password until they
+// set one through the password recovery, their email is verified by the provider.
+func (s *Service) createUser(ctx context.Context, log *slog.Logger, email string) (entity.User, error) {
+	now := time.Now()
+	user := entity.User{
+		Id:              uuid.New(),
+		Email:           strings.ToLower(email),
+		IsActive:        true,
+		EmailVerifiedAt: &now,
+	}
+
+	if err := s.users.Create(ctx, user); err != nil {
+		if errors.Is(err, repoErrs.ErrAlreadyExists) {
+			return entity.User{}, svcErrs.ErrUserAlreadyExists
+		}
+
+		log.Error("failed to create user", sl.Err(err))
+		return entity.User{}, svcErrs.ErrCannotCreateUser
+	}
+
+	return user, nil
+}
+
+// link links the identity to the user, linking it again to the same user changes nothing.
+func (s *Service) link(ctx context.Context, log *slog.Logger, userId uuid.UUID, provider string, claims Claims) (entity.Identity, error) {
+	identity, err := s.identities.IdentityBySubject(ctx, provider, claims.Subject)
+	if err == nil {
+		if identity.UserId != userId {
+			return entity.Identity{}, svcErrs.ErrIdentityAlreadyLinked
+		}
+		return identity, nil
+	}
+	if !errors.Is(err, repoErrs.ErrNotFound) {
+		log.Error("failed to get identity", sl.Err(err))
+		return entity.Identity{}, svcErrs.ErrCannotGetIdentities
+	}
+
+	return s.createIdentity(ctx, log, userId, provider, claims)
+}
+
+func (s *Service) createIdentity(ctx context.Context, log *slog.Logger, userId uuid.UUID, provider string, claims Claims) (entity.Identity, error) {
+	identity := entity.Identity{
+		Provider:  provider,
+		Subject:   claims.Subject,
+		UserId:    userId,
+		Email:     claims.Email,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.identities.Create(ctx, identity); err != nil {
+		if errors.Is(err, repoErrs.ErrAlreadyExists) {
+			return entity.Identity{}, svcErrs.ErrIdentityAlreadyLinked
+		}
+
+		log.Error("failed to create identity", sl.Err(err))
+		return entity.Identity{}, svcErrs.ErrCannotUpdateIdentities
+	}
+
+	log.Info("identity linked",
+		sl.SecurityEvent("identity_linked"),
+		slog.String("user_id", userId.String()),
+		slog.String("provider", provider),
+	)
+
+	return identity, nil
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
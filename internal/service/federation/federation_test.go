@@ -0,0 +1,488 @@
+package federation
+
+import (
+	"context"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/mocks/federationmocks"
+	"github.com/bubalync/uni-auth/internal/mocks/redismocks"
+	"github.com/bubalync/uni-auth/internal/mocks/repomocks"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type testMocks struct {
+	auth       *federationmocks.MockAuthenticator
+	users      *repomocks.MockUser
+	identities *repomocks.MockIdentity
+	passkeys   *repomocks.MockWebAuthnCredential
+}
+
+func newTestService(t *testing.T, ctrl *gomock.Controller, server *oidcServer) (*Service, testMocks) {
+	t.Helper()
+
+	providers := make(map[string]Provider)
+	for _, name := range []string{"test", "other"} {
+		provider, err := NewProvider(ProviderConfig{
+			Type:         TypeOIDC,
+			Issuer:       server.URL,
+			ClientId:     testClientId,
+			ClientSecret: testClientSecret,
+			RedirectURL:  "http://localhost:8080/auth/federation/" + name + "/callback",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		providers[name] = provider
+	}
+
+	m := testMocks{
+		auth:       federationmocks.NewMockAuthenticator(ctrl),
+		users:      repomocks.NewMockUser(ctrl),
+		identities: repomocks.NewMockIdentity(ctrl),
+		passkeys:   repomocks.NewMockWebAuthnCredential(ctrl),
+	}
+
+	s := New(logger.New("local", "info"), newCacheMock(ctrl), m.auth, m.users, m.identities, m.passkeys, providers, time.Minute)
+
+	return s, m
+}
+
+// newCacheMock keeps the states in memory.
+func newCacheMock(ctrl *gomock.Controller) *redismocks.MockCache {
+	data := make(map[string]string)
+
+	cache := redismocks.NewMockCache(ctrl)
+	cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, key, value string, _ time.Duration) error {
+			data[key] = value
+			return nil
+		}).AnyTimes()
+	cache.EXPECT().GetDel(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, key string) (string, error) {
+			value, ok := data[key]
+			if !ok {
+				return "", errors.New("redis: nil")
+			}
+			delete(data, key)
+			return value, nil
+		}).AnyTimes()
+
+	return cache
+}
+
+func TestFederationService_Begin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newOIDCServer(t)
+	s, _ := newTestService(t, ctrl, server)
+
+	_, err := s.Begin(context.Background(), BeginInput{Provider: "unknown"})
+	assert.ErrorIs(t, err, svcErrs.ErrUnknownProvider)
+
+	out, err := s.Begin(context.Background(), BeginInput{Provider: "test"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	u, err := url.Parse(out.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	q := u.Query()
+
+	assert.Equal(t, server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, out.State, q.Get("state"))
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "openid email profile", q.Get("scope"))
+	assert.Equal(t, "http://localhost:8080/auth/federation/test/callback", q.Get("redirect_uri"))
+	assert.NotEmpty(t, q.Get("nonce"))
+	assert.NotEmpty(t, q.Get("code_challenge"))
+
+	assert.Equal(t, []string{"other", "test"}, s.Providers())
+}
+
+func TestFederationService_Callback(t *testing.T) {
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+	verifiedAt := time.Now()
+	verifiedUser := entity.User{Id: user.Id, Email: user.Email, EmailVerifiedAt: &verifiedAt}
+	tokens := auth.GenerateTokenOutput{AccessToken: "access", RefreshToken: "refresh"}
+	identity := entity.Identity{Provider: "test", Subject: "subject-1", UserId: user.Id, Email: "test@example.com"}
+
+	type MockBehavior func(m testMocks)
+
+	testCases := []struct {
+		name         string
+		claims       jwt.MapClaims
+		mockBehavior MockBehavior
+		// linkUserId starts the sign-in with BeginLink
+		linkUserId uuid.UUID
+		// callback changes the input of the callback
+		callback     func(input CallbackInput) CallbackInput
+		want         auth.GenerateTokenOutput
+		wantIdentity *entity.Identity
+		wantErr      error
+	}{
+		{
+			name:   "linked identity",
+			claims: jwt.MapClaims{"email": "test@example.com", "email_verified": true},
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentityBySubject(gomock.Any(), "test", "subject-1").Return(identity, nil)
+				m.users.EXPECT().UserById(gomock.Any(), user.Id).Return(user, nil)
+				m.identities.EXPECT().UpdateLastUsed(gomock.Any(), "test", "subject-1").Return(nil)
+				m.auth.EXPECT().SignIn(gomock.Any(), user, auth.IssueTokensInput{
+					Device:    "iPhone 15",
+					IP:        "127.0.0.1",
+					UserAgent: "test",
+					Nonce:     "client-nonce",
+				}).Return(tokens, nil)
+			},
+			want: tokens,
+		},
+		{
+			name:   "linked identity without a verified email",
+			claims: jwt.MapClaims{"email": "changed@example.com", "email_verified": false},
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentityBySubject(gomock.Any(), "test", "subject-1").Return(identity, nil)
+				m.users.EXPECT().UserById(gomock.Any(), user.Id).Return(user, nil)
+				m.identities.EXPECT().UpdateLastUsed(gomock.Any(), "test", "subject-1").Return(nil)
+				m.auth.EXPECT().SignIn(gomock.Any(), user, gomock.Any()).Return(tokens, nil)
+			},
+			want: tokens,
+		},
+		{
+			name:   "first sign-in of an existing user",
+			claims: jwt.MapClaims{"email": "Test@example.com", "email_verified": true},
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentityBySubject(gomock.Any(), "test", "subject-1").Return(entity.Identity{}, repoErrs.ErrNotFound)
+				m.users.EXPECT().UserByEmail(gomock.Any(), "Test@example.com").Return(verifiedUser, nil)
+				m.identities.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, created entity.Identity) error {
+					assert.Equal(t, "test", created.Provider)
+					assert.Equal(t, "subject-1", created.Subject)
+					assert.Equal(t, user.Id, created.UserId)
+					return nil
+				})
+				m.auth.EXPECT().SignIn(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, signedIn entity.User, _ auth.IssueTokensInput) (auth.GenerateTokenOutput, error) {
+						assert.Equal(t, user.Id, signedIn.Id)
+						assert.True(t, signedIn.IsEmailVerified())
+						return tokens, nil
+					})
+			},
+			want: tokens,
+		},
+		{
+			name:   "first sign-in of an existing user with an unverified email",
+			claims: jwt.MapClaims{"email": "Test@example.com", "email_verified": true},
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentityBySubject(gomock.Any(), "test", "subject-1").Return(entity.Identity{}, repoErrs.ErrNotFound)
+				m.users.EXPECT().UserByEmail(gomock.Any(), "Test@example.com").Return(user, nil)
+			},
+			wantErr: svcErrs.ErrFederatedAccountUnverified,
+		},
+		{
+			name:   "first sign-in of a new user",
+			claims: jwt.MapClaims{"email": "New@example.com", "email_verified": true},
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentityBySubject(gomock.Any(), "test", "subject-1").Return(entity.Identity{}, repoErrs.ErrNotFound)
+				m.users.EXPECT().UserByEmail(gomock.Any(), "New@example.com").Return(entity.User{}, repoErrs.ErrNotFound)
+				m.users.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, created entity.User) error {
+					assert.Equal(t, "new@example.com", created.Email)
+					assert.Nil(t, created.PasswordHash)
+					assert.True(t, created.IsEmailVerified())
+					return nil
+				})
+				m.identities.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				m.auth.EXPECT().SignIn(gomock.Any(), gomock.Any(), gomock.Any()).Return(tokens, nil)
+			},
+			want: tokens,
+		},
+		{
+			name:   "email_verified as a string",
+			claims: jwt.MapClaims{"email": "test@example.com", "email_verified": "true"},
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentityBySubject(gomock.Any(), "test", "subject-1").Return(entity.Identity{}, repoErrs.ErrNotFound)
+				m.users.EXPECT().UserByEmail(gomock.Any(), "test@example.com").Return(verifiedUser, nil)
+				m.identities.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				m.auth.EXPECT().SignIn(gomock.Any(), verifiedUser, gomock.Any()).Return(tokens, nil)
+			},
+			want: tokens,
+		},
+		{
+			name:   "second factor required",
+			claims: jwt.MapClaims{"email": "test@example.com", "email_verified": true},
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentityBySubject(gomock.Any(), "test", "subject-1").Return(identity, nil)
+				m.users.EXPECT().UserById(gomock.Any(), user.Id).Return(user, nil)
+				m.identities.EXPECT().UpdateLastUsed(gomock.Any(), "test", "subject-1").Return(nil)
+				m.auth.EXPECT().SignIn(gomock.Any(), user, gomock.Any()).Return(auth.GenerateTokenOutput{MFAToken: "mfa"}, nil)
+			},
+			want: auth.GenerateTokenOutput{MFAToken: "mfa"},
+		},
+		{
+			name:   "email not verified",
+			claims: jwt.MapClaims{"email": "test@example.com", "email_verified": false},
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentityBySubject(gomock.Any(), "test", "subject-1").Return(entity.Identity{}, repoErrs.ErrNotFound)
+			},
+			wantErr: svcErrs.ErrFederatedEmailNotVerified,
+		},
+		{
+			name:   "no email",
+			claims: jwt.MapClaims{},
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentityBySubject(gomock.Any(), "test", "subject-1").Return(entity.Identity{}, repoErrs.ErrNotFound)
+			},
+			wantErr: svcErrs.ErrFederatedEmailNotVerified,
+		},
+		{
+			name:   "link",
+			claims: jwt.MapClaims{"email": "other@example.com"},
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentityBySubject(gomock.Any(), "test", "subject-1").Return(entity.Identity{}, repoErrs.ErrNotFound)
+				m.identities.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			linkUserId:   user.Id,
+			wantIdentity: &entity.Identity{Provider: "test", Subject: "subject-1", UserId: user.Id, Email: "other@example.com"},
+		},
+		{
+			name:   "link: already linked to the user",
+			claims: jwt.MapClaims{"email": "test@example.com"},
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentityBySubject(gomock.Any(), "test", "subject-1").Return(identity, nil)
+			},
+			linkUserId:   user.Id,
+			wantIdentity: &identity,
+		},
+		{
+			name:   "link: linked to another user",
+			claims: jwt.MapClaims{"email": "test@example.com"},
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentityBySubject(gomock.Any(), "test", "subject-1").Return(identity, nil)
+			},
+			linkUserId: uuid.New(),
+			wantErr:    svcErrs.ErrIdentityAlreadyLinked,
+		},
+		{
+			name:         "nonce mismatch",
+			claims:       jwt.MapClaims{"email": "test@example.com", "email_verified": true, "nonce": "replayed"},
+			mockBehavior: func(m testMocks) {},
+			wantErr:      svcErrs.ErrFederatedSignInFailed,
+		},
+		{
+			name:         "token of another client",
+			claims:       jwt.MapClaims{"email": "test@example.com", "email_verified": true, "aud": "another-client"},
+			mockBehavior: func(m testMocks) {},
+			wantErr:      svcErrs.ErrFederatedSignInFailed,
+		},
+		{
+			name:         "expired token",
+			claims:       jwt.MapClaims{"email": "test@example.com", "email_verified": true, "exp": time.Now().Add(-time.Minute).Unix()},
+			mockBehavior: func(m testMocks) {},
+			wantErr:      svcErrs.ErrFederatedSignInFailed,
+		},
+		{
+			name:         "invalid code",
+			mockBehavior: func(m testMocks) {},
+			callback: func(input CallbackInput) CallbackInput {
+				input.Code = "invalid"
+				return input
+			},
+			wantErr: svcErrs.ErrFederatedSignInFailed,
+		},
+		{
+			name:         "error of the provider",
+			mockBehavior: func(m testMocks) {},
+			callback: func(input CallbackInput) CallbackInput {
+				input.Code = ""
+				input.Error = "access_denied"
+				return input
+			},
+			wantErr: svcErrs.ErrFederatedSignInFailed,
+		},
+		{
+			name:         "unknown state",
+			mockBehavior: func(m testMocks) {},
+			callback: func(input CallbackInput) CallbackInput {
+				input.State = "unknown"
+				return input
+			},
+			wantErr: svcErrs.ErrInvalidFederationState,
+		},
+		{
+			name:         "state of another provider",
+			mockBehavior: func(m testMocks) {},
+			callback: func(input CallbackInput) CallbackInput {
+				input.Provider = "other"
+				return input
+			},
+			wantErr: svcErrs.ErrInvalidFederationState,
+		},
+		{
+			name:         "unknown provider",
+			mockBehavior: func(m testMocks) {},
+			callback: func(input CallbackInput) CallbackInput {
+				input.Provider = "unknown"
+				return input
+			},
+			wantErr: svcErrs.ErrUnknownProvider,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			server := newOIDCServer(t)
+			s, m := newTestService(t, ctrl, server)
+			tc.mockBehavior(m)
+
+			var (
+				out BeginOutput
+				err error
+			)
+			if tc.linkUserId != uuid.Nil {
+				out, err = s.BeginLink(ctx, tc.linkUserId, "test")
+			} else {
+				out, err = s.Begin(ctx, BeginInput{Provider: "test", Device: "iPhone 15", Nonce: "client-nonce"})
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			input := CallbackInput{
+				Provider:  "test",
+				State:     out.State,
+				Code:      server.authorize(t, out.URL, tc.claims),
+				IP:        "127.0.0.1",
+				UserAgent: "test",
+			}
+			if tc.callback != nil {
+				input = tc.callback(input)
+			}
+
+			got, err := s.Callback(ctx, input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got.Tokens)
+			if tc.wantIdentity != nil && assert.NotNil(t, got.Identity) {
+				got.Identity.CreatedAt = tc.wantIdentity.CreatedAt
+				assert.Equal(t, *tc.wantIdentity, *got.Identity)
+			}
+
+			// the state is single-use
+			_, err = s.Callback(ctx, input)
+			assert.ErrorIs(t, err, svcErrs.ErrInvalidFederationState)
+		})
+	}
+}
+
+func TestFederationService_Unlink(t *testing.T) {
+	userId := uuid.New()
+	google := entity.Identity{Provider: "google", Subject: "1", UserId: userId}
+	apple := entity.Identity{Provider: "apple", Subject: "2", UserId: userId}
+
+	type MockBehavior func(m testMocks)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		// passkeysDisabled leaves the service without the passkeys repository
+		passkeysDisabled bool
+		wantErr          error
+	}{
+		{
+			name: "another identity left",
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentitiesByUserId(gomock.Any(), userId).Return([]entity.Identity{google, apple}, nil)
+				m.identities.EXPECT().Delete(gomock.Any(), userId, "google", "1").Return(nil)
+			},
+		},
+		{
+			name: "password left",
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentitiesByUserId(gomock.Any(), userId).Return([]entity.Identity{google}, nil)
+				m.users.EXPECT().UserById(gomock.Any(), userId).Return(entity.User{Id: userId, PasswordHash: []byte("hash")}, nil)
+				m.identities.EXPECT().Delete(gomock.Any(), userId, "google", "1").Return(nil)
+			},
+		},
+		{
+			name: "passkey left",
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentitiesByUserId(gomock.Any(), userId).Return([]entity.Identity{google}, nil)
+				m.users.EXPECT().UserById(gomock.Any(), userId).Return(entity.User{Id: userId}, nil)
+				m.passkeys.EXPECT().CredentialsByUserId(gomock.Any(), userId).Return([]entity.WebAuthnCredential{{UserId: userId}}, nil)
+				m.identities.EXPECT().Delete(gomock.Any(), userId, "google", "1").Return(nil)
+			},
+		},
+		{
+			name: "last sign-in method",
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentitiesByUserId(gomock.Any(), userId).Return([]entity.Identity{google}, nil)
+				m.users.EXPECT().UserById(gomock.Any(), userId).Return(entity.User{Id: userId}, nil)
+				m.passkeys.EXPECT().CredentialsByUserId(gomock.Any(), userId).Return(nil, nil)
+			},
+			wantErr: svcErrs.ErrLastLoginMethod,
+		},
+		{
+			name: "last sign-in method, passkeys disabled",
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentitiesByUserId(gomock.Any(), userId).Return([]entity.Identity{google}, nil)
+				m.users.EXPECT().UserById(gomock.Any(), userId).Return(entity.User{Id: userId}, nil)
+			},
+			passkeysDisabled: true,
+			wantErr:          svcErrs.ErrLastLoginMethod,
+		},
+		{
+			name: "not linked",
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentitiesByUserId(gomock.Any(), userId).Return([]entity.Identity{apple}, nil)
+			},
+			wantErr: svcErrs.ErrIdentityNotFound,
+		},
+		{
+			name: "cannot get identities",
+			mockBehavior: func(m testMocks) {
+				m.identities.EXPECT().IdentitiesByUserId(gomock.Any(), userId).Return(nil, errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrCannotGetIdentities,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s, m := newTestService(t, ctrl, newOIDCServer(t))
+			if tc.passkeysDisabled {
+				s.passkeys = nil
+			}
+			tc.mockBehavior(m)
+
+			err := s.Unlink(context.Background(), userId, "google", "1")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
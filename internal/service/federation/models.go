@@ -0,0 +1,49 @@
+package federation
+
+import (
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+)
+
+type (
+	// Claims describe the user as asserted by the ID token of a provider.
+	Claims struct {
+		// Subject is the id of the user at the provider.
+		Subject       string
+		Email         string
+		EmailVerified bool
+		Name          string
+		Nonce         string
+	}
+
+	BeginInput struct {
+		Provider string
+		// Device and Nonce describe the session started after the callback.
+		Device string
+		Nonce  string
+	}
+
+	BeginOutput struct {
+		// URL is the authorization endpoint of the provider the user is redirected to.
+		URL string
+		// State binds the callback to the browser that started the sign-in.
+		State string
+	}
+
+	CallbackOutput struct {
+		// Tokens of the user who signed in, only the MFA token when a second factor is required.
+		Tokens auth.GenerateTokenOutput
+		// Identity is set instead of the tokens when the sign-in linked an identity to the current user.
+		Identity *entity.Identity
+	}
+
+	CallbackInput struct {
+		Provider string
+		State    string
+		Code     string
+		// Error is set instead of the code when the sign-in at the provider failed.
+		Error     string
+		IP        string
+		UserAgent string
+	}
+)
@@ -0,0 +1,229 @@
+package federation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Provider types, google and apple preset the issuer and the scopes of a generic OpenID provider.
+const (
+	TypeOIDC   = "oidc"
+	TypeGoogle = "google"
+	TypeApple  = "apple"
+)
+
+const (
+	googleIssuer = "https://accounts.google.com"
+	appleIssuer  = "https://appleid.apple.com"
+	// appleClientSecretTTL is the lifetime of the client secrets signed for Apple, at most 6 months are accepted.
+	appleClientSecretTTL = 5 * time.Minute
+)
+
+// Provider is an external OpenID provider users sign in with.
+type Provider interface {
+	// AuthCodeURL returns the authorization endpoint URL the user is redirected to.
+	AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error)
+	// Exchange redeems the authorization code and returns the claims of the verified ID token.
+	Exchange(ctx context.Context, code, codeVerifier string) (Claims, error)
+}
+
+// ProviderConfig describes the client registered at an OpenID provider.
+type ProviderConfig struct {
+	Type         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	// RedirectURL is the callback route of the provider.
+	RedirectURL string
+	Scopes      []string
+	// Apple is required for the apple type, its client secret is a JWT signed by the service.
+	Apple *AppleConfig
+}
+
+// AppleConfig is the key of Sign in with Apple the client secrets are signed with.
+type AppleConfig struct {
+	TeamId     string
+	KeyId      string
+	PrivateKey *ecdsa.PrivateKey
+}
+
+// oidcProvider is a provider that implements OpenID Connect discovery, the discovery document is
+// fetched on first use so that a provider outage does not stop the service.
+type oidcProvider struct {
+	cfg ProviderConfig
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+// NewProvider -.
+func NewProvider(cfg ProviderConfig) (Provider, error) {
+	switch cfg.Type {
+	case TypeGoogle:
+		cfg.Issuer = googleIssuer
+		if len(cfg.Scopes) == 0 {
+			cfg.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+		}
+	case TypeApple:
+		cfg.Issuer = appleIssuer
+		if len(cfg.Scopes) == 0 {
+			cfg.Scopes = []string{oidc.ScopeOpenID, "email", "name"}
+		}
+		if cfg.Apple == nil || cfg.Apple.PrivateKey == nil {
+			return nil, errors.New("apple provider requires a team id, a key id and a private key")
+		}
+	case TypeOIDC, "":
+		if cfg.Issuer == "" {
+			return nil, errors.New("oidc provider requires an issuer")
+		}
+		if len(cfg.Scopes) == 0 {
+			cfg.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+		}
+	default:
+		return nil, fmt.Errorf("unknown provider type %q", cfg.Type)
+	}
+
+	if cfg.ClientId == "" {
+		return nil, errors.New("provider requires a client id")
+	}
+
+	return &oidcProvider{cfg: cfg}, nil
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	opts := []oauth2.AuthCodeOption{oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier)}
+	if p.cfg.Type == TypeApple {
+		// Apple posts the callback when the name or the email is requested
+		opts = append(opts, oauth2.SetAuthURLParam("response_mode", "form_post"))
+	}
+
+	return p.oauth2Config(provider, p.cfg.ClientSecret).AuthCodeURL(state, opts...), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier string) (Claims, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	secret := p.cfg.ClientSecret
+	if p.cfg.Type == TypeApple {
+		if secret, err = p.appleClientSecret(); err != nil {
+			return Claims{}, fmt.Errorf("sign client secret: %w", err)
+		}
+	}
+
+	token, err := p.oauth2Config(provider, secret).Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return Claims{}, fmt.Errorf("exchange code: %w", err)
+	}
+
+	raw, ok := token.Extra("id_token").(string)
+	if !ok || raw == "" {
+		return Claims{}, errors.New("token response has no id_token")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientId}).Verify(ctx, raw)
+	if err != nil {
+		return Claims{}, fmt.Errorf("verify id_token: %w", err)
+	}
+
+	var claims struct {
+		Email         string    `json:"email"`
+		EmailVerified boolClaim `json:"email_verified"`
+		Name          string    `json:"name"`
+	}
+	if err = idToken.Claims(&claims); err != nil {
+		return Claims{}, fmt.Errorf("decode id_token claims: %w", err)
+	}
+
+	return Claims{
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		Nonce:         idToken.Nonce,
+	}, nil
+}
+
+// discover fetches the discovery document once it is available.
+func (p *oidcProvider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider != nil {
+		return p.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discover %s: %w", p.cfg.Issuer, err)
+	}
+	p.provider = provider
+
+	return provider, nil
+}
+
+func (p *oidcProvider) oauth2Config(provider *oidc.Provider, secret string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientId,
+		ClientSecret: secret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       p.cfg.Scopes,
+	}
+}
+
+// appleClientSecret signs the short-lived client secret Apple expects instead of a static one.
+func (p *oidcProvider) appleClientSecret() (string, error) {
+	now := time.Now()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Issuer:    p.cfg.Apple.TeamId,
+		Subject:   p.cfg.ClientId,
+		Audience:  jwt.ClaimStrings{appleIssuer},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(appleClientSecretTTL)),
+	})
+	token.Header["kid"] = p.cfg.Apple.KeyId
+
+	return token.SignedString(p.cfg.Apple.PrivateKey)
+}
+
+// boolClaim accepts the email_verified claim as a boolean or, like Apple sends it, as a string.
+type boolClaim bool
+
+func (b *boolClaim) UnmarshalJSON(data []byte) error {
+	var v bool
+	if err := json.Unmarshal(data, &v); err == nil {
+		*b = boolClaim(v)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*b = boolClaim(v)
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package federation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewProvider(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name       string
+		cfg        ProviderConfig
+		wantIssuer string
+		wantErr    bool
+	}{
+		{
+			name:       "google",
+			cfg:        ProviderConfig{Type: TypeGoogle, ClientId: "client"},
+			wantIssuer: googleIssuer,
+		},
+		{
+			name:       "apple",
+			cfg:        ProviderConfig{Type: TypeApple, ClientId: "client", Apple: &AppleConfig{PrivateKey: key}},
+			wantIssuer: appleIssuer,
+		},
+		{
+			name:    "apple without a key",
+			cfg:     ProviderConfig{Type: TypeApple, ClientId: "client"},
+			wantErr: true,
+		},
+		{
+			name:       "generic",
+			cfg:        ProviderConfig{Issuer: "https://id.example.com", ClientId: "client"},
+			wantIssuer: "https://id.example.com",
+		},
+		{
+			name:    "generic without an issuer",
+			cfg:     ProviderConfig{Type: TypeOIDC, ClientId: "client"},
+			wantErr: true,
+		},
+		{
+			name:    "without a client id",
+			cfg:     ProviderConfig{Type: TypeGoogle},
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			cfg:     ProviderConfig{Type: "saml", ClientId: "client"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider, err := NewProvider(tc.cfg)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.wantIssuer, provider.(*oidcProvider).cfg.Issuer)
+			}
+		})
+	}
+}
+
+func TestProvider_AppleClientSecret(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := NewProvider(ProviderConfig{
+		Type:     TypeApple,
+		ClientId: "com.example.uni-auth",
+		Apple:    &AppleConfig{TeamId: "ABCDE12345", KeyId: "FGHIJ67890", PrivateKey: key},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := provider.(*oidcProvider).appleClientSecret()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var claims jwt.RegisteredClaims
+	token, err := jwt.ParseWithClaims(secret, &claims, func(*jwt.Token) (any, error) {
+		return &key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience(appleIssuer), jwt.WithIssuer("ABCDE12345"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "FGHIJ67890", token.Header["kid"])
+	assert.Equal(t, "com.example.uni-auth", claims.Subject)
+}
@@ -0,0 +1,365 @@
+package keys
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
+	"github.com/bubalync/uni-auth/internal/repo"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/cipher"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"log/slog"
+	"time"
+)
+
+// Schedule describes when keys change state.
+type Schedule struct {
+	// Algorithm of newly generated keys.
+	Algorithm string
+	// RotationPeriod is how long a key stays active.
+	RotationPeriod time.Duration
+	// PrePublish is how long before its activation the next key is created,
+	// so that every replica has loaded it before any replica signs with it.
+	PrePublish time.Duration
+	// RetireAfter is how long a retired key keeps verifying,
+	// it must not be shorter than the lifetime of access tokens.
+	RetireAfter time.Duration
+}
+
+// Service keeps the signing key ring of all replicas in sync with the database.
+type Service struct {
+	log      *slog.Logger
+	repo     repo.SigningKey
+	cipher   cipher.Cipher
+	ring     *jwtgen.KeyRing
+	schedule Schedule
+	now      func() time.Time
+}
+
+// New -.
+func New(log *slog.Logger, repo repo.SigningKey, cipher cipher.Cipher, ring *jwtgen.KeyRing, schedule Schedule) *Service {
+	return &Service{
+		log:      log,
+		repo:     repo,
+		cipher:   cipher,
+		ring:     ring,
+		schedule: schedule,
+		now:      time.Now,
+	}
+}
+
+// Run applies the schedule every interval until the context is cancelled.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = s.ApplySchedule(ctx)
+		}
+	}
+}
+
+// ApplySchedule creates, promotes and revokes keys that are due and reloads the ring.
+// Every replica runs it, concurrent changes are resolved by the database constraints.
+func (s *Service) ApplySchedule(ctx context.Context) error {
+	const op = "service.keys.ApplySchedule"
+	log := s.log.With(slog.String("op", op))
+
+	keys, err := s.repo.Keys(ctx)
+	if err != nil {
+		log.Error("failed to get signing keys", sl.Err(err))
+		return svcErrs.ErrCannotGetKeys
+	}
+
+	now := s.now()
+	active, next := findKey(keys, entity.SigningKeyActive), findKey(keys, entity.SigningKeyNext)
+
+	switch {
+	case active == nil:
+		// bootstrap, or the active key was revoked: a key is needed right now
+		if err = s.promoteNow(ctx, log, next); err != nil {
+			return err
+		}
+	case next == nil && !now.Before(active.ActivatesAt.Add(s.schedule.RotationPeriod-s.schedule.PrePublish)):
+		activatesAt := active.ActivatesAt.Add(s.schedule.RotationPeriod)
+		if activatesAt.Before(now) {
+			activatesAt = now
+		}
+
+		if _, err = s.createKey(ctx, log, activatesAt); err != nil {
+			return err
+		}
+	case next != nil && !now.Before(next.ActivatesAt):
+		if err = s.promote(ctx, log, next.Id, now); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range keys {
+		if k.State == entity.SigningKeyRetiring && k.RetiredAt != nil && !now.Before(k.RetiredAt.Add(s.schedule.RetireAfter)) {
+			if err = s.repo.Revoke(ctx, k.Id); err != nil && !errors.Is(err, repoErrs.ErrNotFound) {
+				log.Error("failed to revoke retired key", slog.String("kid", k.Id), sl.Err(err))
+				return svcErrs.ErrCannotUpdateKeys
+			}
+
+			log.Info("retired signing key revoked", slog.String("kid", k.Id))
+		}
+	}
+
+	return s.Reload(ctx)
+}
+
+// Rotate immediately replaces the active key: the next key is promoted,
+// or a new key is generated if there is none.
+func (s *Service) Rotate(ctx context.Context) (entity.SigningKey, error) {
+	const op = "service.keys.Rotate"
+	log := s.log.With(slog.String("op", op))
+
+	keys, err := s.repo.Keys(ctx)
+	if err != nil {
+		log.Error("failed to get signing keys", sl.Err(err))
+		return entity.SigningKey{}, svcErrs.ErrCannotGetKeys
+	}
+
+	next := findKey(keys, entity.SigningKeyNext)
+	if err = s.promoteNow(ctx, log, next); err != nil {
+		return entity.SigningKey{}, err
+	}
+
+	if err = s.Reload(ctx); err != nil {
+		return entity.SigningKey{}, err
+	}
+
+	key, err := s.ring.SigningKey()
+	if err != nil {
+		log.Error("no active key after rotation", sl.Err(err))
+		return entity.SigningKey{}, svcErrs.ErrCannotUpdateKeys
+	}
+
+	return s.Key(ctx, key.Id)
+}
+
+// Revoke stops accepting tokens signed by the key. Revoking the active key rotates first.
+func (s *Service) Revoke(ctx context.Context, id string) error {
+	const op = "service.keys.Revoke"
+	log := s.log.With(slog.String("op", op))
+
+	key, err := s.Key(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if key.State == entity.SigningKeyActive {
+		if _, err = s.Rotate(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err = s.repo.Revoke(ctx, id); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrKeyNotFound
+		}
+
+		log.Error("failed to revoke key", sl.Err(err))
+		return svcErrs.ErrCannotUpdateKeys
+	}
+
+	log.Warn("signing key revoked", slog.String("kid", id), sl.SecurityEvent("signing_key_revoked"))
+
+	return s.Reload(ctx)
+}
+
+// Keys returns the keys that are not revoked, without private parts.
+func (s *Service) Keys(ctx context.Context) ([]entity.SigningKey, error) {
+	const op = "service.keys.Keys"
+	log := s.log.With(slog.String("op", op))
+
+	keys, err := s.repo.Keys(ctx)
+	if err != nil {
+		log.Error("failed to get signing keys", sl.Err(err))
+		return nil, svcErrs.ErrCannotGetKeys
+	}
+
+	for i := range keys {
+		keys[i].PrivateKey = nil
+	}
+
+	return keys, nil
+}
+
+// Key returns a key that is not revoked, without the private part.
+func (s *Service) Key(ctx context.Context, id string) (entity.SigningKey, error) {
+	keys, err := s.Keys(ctx)
+	if err != nil {
+		return entity.SigningKey{}, err
+	}
+
+	for _, k := range keys {
+		if k.Id == id {
+			return k, nil
+		}
+	}
+
+	return entity.SigningKey{}, svcErrs.ErrKeyNotFound
+}
+
+// Reload loads the keys from the database into the ring.
+func (s *Service) Reload(ctx context.Context) error {
+	const op = "service.keys.Reload"
+	log := s.log.With(slog.String("op", op))
+
+	keys, err := s.repo.Keys(ctx)
+	if err != nil {
+		log.Error("failed to get signing keys", sl.Err(err))
+		return svcErrs.ErrCannotGetKeys
+	}
+
+	ring := make([]jwtgen.RingKey, 0, len(keys))
+	for _, k := range keys {
+		private, err := s.decryptKey(k)
+		if err != nil {
+			log.Error("failed to decrypt signing key", slog.String("kid", k.Id), sl.Err(err))
+			return svcErrs.ErrCannotGetKeys
+		}
+
+		ring = append(ring, jwtgen.RingKey{
+			SigningKey: jwtgen.SigningKey{Id: k.Id, Algorithm: k.Algorithm, Private: private},
+			State:      k.State,
+		})
+	}
+
+	if err = s.ring.Replace(ring); err != nil {
+		log.Error("failed to load the key ring", sl.Err(err))
+		return svcErrs.ErrCannotGetKeys
+	}
+
+	return nil
+}
+
+// promoteNow activates the next key, creating one first if needed.
+func (s *Service) promoteNow(ctx context.Context, log *slog.Logger, next *entity.SigningKey) error {
+	now := s.now()
+
+	if next == nil {
+		key, err := s.createKey(ctx, log, now)
+		if err != nil {
+			return err
+		}
+		next = &key
+	}
+
+	return s.promote(ctx, log, next.Id, now)
+}
+
+func (s *Service) promote(ctx context.Context, log *slog.Logger, id string, at time.Time) error {
+	err := s.repo.Promote(ctx, id, at)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			// another replica was faster
+			return nil
+		}
+
+		log.Error("failed to promote key", slog.String("kid", id), sl.Err(err))
+		return svcErrs.ErrCannotUpdateKeys
+	}
+
+	log.Info("signing key activated", slog.String("kid", id))
+
+	return nil
+}
+
+func (s *Service) createKey(ctx context.Context, log *slog.Logger, activatesAt time.Time) (entity.SigningKey, error) {
+	private, err := jwtgen.GenerateKey(s.schedule.Algorithm)
+	if err != nil {
+		log.Error("failed to generate key", sl.Err(err))
+		return entity.SigningKey{}, svcErrs.ErrCannotUpdateKeys
+	}
+
+	key := entity.SigningKey{
+		Id:          newKeyId(activatesAt),
+		Algorithm:   s.schedule.Algorithm,
+		State:       entity.SigningKeyNext,
+		ActivatesAt: activatesAt,
+	}
+
+	if key.PrivateKey, err = s.encryptKey(key.Id, private); err != nil {
+		log.Error("failed to encrypt key", sl.Err(err))
+		return entity.SigningKey{}, svcErrs.ErrCannotUpdateKeys
+	}
+
+	if err = s.repo.Create(ctx, key); err != nil {
+		if errors.Is(err, repoErrs.ErrAlreadyExists) {
+			// another replica created the next key, use that one
+			keys, err := s.repo.Keys(ctx)
+			if err == nil {
+				if next := findKey(keys, entity.SigningKeyNext); next != nil {
+					return *next, nil
+				}
+			}
+		}
+
+		log.Error("failed to save key", sl.Err(err))
+		return entity.SigningKey{}, svcErrs.ErrCannotUpdateKeys
+	}
+
+	log.Info("signing key created", slog.String("kid", key.Id), slog.Time("activates_at", activatesAt))
+
+	return key, nil
+}
+
+// the key id is bound to the ciphertext, so encrypted keys cannot be swapped between rows
+func (s *Service) encryptKey(id string, private crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.cipher.Encrypt(der, []byte(id))
+}
+
+func (s *Service) decryptKey(k entity.SigningKey) (crypto.Signer, error) {
+	der, err := s.cipher.Decrypt(k.PrivateKey, []byte(k.Id))
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+
+	return signer, nil
+}
+
+func findKey(keys []entity.SigningKey, state entity.SigningKeyState) *entity.SigningKey {
+	for i := range keys {
+		if keys[i].State == state {
+			return &keys[i]
+		}
+	}
+
+	return nil
+}
+
+func newKeyId(activatesAt time.Time) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+
+	return activatesAt.UTC().Format("20060102") + "-" + hex.EncodeToString(b)
+}
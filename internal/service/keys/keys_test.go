@@ -0,0 +1,349 @@
+package keys
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
+	"github.com/bubalync/uni-auth/internal/mocks/repomocks"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/cipher"
+	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+var (
+	now      = time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	schedule = Schedule{
+		Algorithm:      jwtgen.AlgES256,
+		RotationPeriod: 30 * 24 * time.Hour,
+		PrePublish:     24 * time.Hour,
+		RetireAfter:    30 * time.Minute,
+	}
+)
+
+func newCipher(t *testing.T) cipher.Cipher {
+	c, err := cipher.NewAESGCM(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func newKey(t *testing.T, c cipher.Cipher, id string, state entity.SigningKeyState, activatesAt time.Time) entity.SigningKey {
+	private, err := jwtgen.GenerateKey(jwtgen.AlgES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := c.Encrypt(der, []byte(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return entity.SigningKey{
+		Id:          id,
+		Algorithm:   jwtgen.AlgES256,
+		State:       state,
+		PrivateKey:  encrypted,
+		ActivatesAt: activatesAt,
+	}
+}
+
+func newService(t *testing.T, repo *repomocks.MockSigningKey, c cipher.Cipher, ring *jwtgen.KeyRing) *Service {
+	s := New(logger.New("local", "info"), repo, c, ring, schedule)
+	s.now = func() time.Time { return now }
+
+	return s
+}
+
+func TestKeysService_ApplySchedule(t *testing.T) {
+	c := newCipher(t)
+
+	active := newKey(t, c, "active", entity.SigningKeyActive, now.Add(-10*24*time.Hour))
+	expiring := newKey(t, c, "expiring", entity.SigningKeyActive, now.Add(-schedule.RotationPeriod+time.Hour))
+	next := newKey(t, c, "next", entity.SigningKeyNext, now.Add(-time.Minute))
+	promoted := newKey(t, c, "next", entity.SigningKeyActive, now)
+
+	retiredAt := now.Add(-time.Hour)
+	retiring := newKey(t, c, "retiring", entity.SigningKeyRetiring, now.Add(-schedule.RotationPeriod))
+	retiring.RetiredAt = &retiredAt
+
+	type MockBehavior func(r *repomocks.MockSigningKey)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantActive   string
+		wantErr      error
+	}{
+		{
+			name: "nothing due",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{active}, nil).Times(2)
+			},
+			wantActive: "active",
+		},
+		{
+			name: "bootstrap",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return(nil, nil)
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, k entity.SigningKey) error {
+						assert.Equal(t, entity.SigningKeyNext, k.State)
+						assert.Equal(t, now, k.ActivatesAt)
+						return nil
+					})
+				r.EXPECT().Promote(gomock.Any(), gomock.Any(), now).Return(nil)
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{promoted}, nil)
+			},
+			wantActive: "next",
+		},
+		{
+			name: "next key is pre-published",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{expiring}, nil)
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, k entity.SigningKey) error {
+						assert.Equal(t, entity.SigningKeyNext, k.State)
+						assert.Equal(t, expiring.ActivatesAt.Add(schedule.RotationPeriod), k.ActivatesAt)
+						return nil
+					})
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{expiring, next}, nil)
+			},
+			wantActive: "expiring",
+		},
+		{
+			name: "next key is promoted",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{active, next}, nil)
+				r.EXPECT().Promote(gomock.Any(), "next", now).Return(nil)
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{promoted}, nil)
+			},
+			wantActive: "next",
+		},
+		{
+			name: "promoted by another replica",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{active, next}, nil)
+				r.EXPECT().Promote(gomock.Any(), "next", now).Return(repoErrs.ErrNotFound)
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{promoted}, nil)
+			},
+			wantActive: "next",
+		},
+		{
+			name: "retired key is revoked",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{retiring, active}, nil)
+				r.EXPECT().Revoke(gomock.Any(), "retiring").Return(nil)
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{active}, nil)
+			},
+			wantActive: "active",
+		},
+		{
+			name: "cannot get keys",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return(nil, errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrCannotGetKeys,
+		},
+		{
+			name: "cannot create key",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return(nil, nil)
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrCannotUpdateKeys,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockSigningKey(ctrl)
+			tc.mockBehavior(repo)
+
+			ring := jwtgen.NewKeyRing()
+			s := newService(t, repo, c, ring)
+
+			err := s.ApplySchedule(context.Background())
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			key, err := ring.SigningKey()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantActive, key.Id)
+		})
+	}
+}
+
+func TestKeysService_Reload(t *testing.T) {
+	c := newCipher(t)
+
+	active := newKey(t, c, "active", entity.SigningKeyActive, now)
+	retiring := newKey(t, c, "retiring", entity.SigningKeyRetiring, now.Add(-time.Hour))
+
+	// a ciphertext copied from another row must not decrypt
+	swapped := retiring
+	swapped.PrivateKey = active.PrivateKey
+
+	type MockBehavior func(r *repomocks.MockSigningKey)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{retiring, active}, nil)
+			},
+		},
+		{
+			name: "key encrypted for another id",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{swapped, active}, nil)
+			},
+			wantErr: svcErrs.ErrCannotGetKeys,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockSigningKey(ctrl)
+			tc.mockBehavior(repo)
+
+			ring := jwtgen.NewKeyRing()
+			s := newService(t, repo, c, ring)
+
+			err := s.Reload(context.Background())
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			_, err = ring.VerificationKey("retiring")
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestKeysService_Rotate(t *testing.T) {
+	c := newCipher(t)
+
+	active := newKey(t, c, "active", entity.SigningKeyActive, now.Add(-time.Hour))
+	next := newKey(t, c, "next", entity.SigningKeyNext, now.Add(time.Hour))
+	promoted := newKey(t, c, "next", entity.SigningKeyActive, now)
+
+	retiredAt := now
+	retiring := active
+	retiring.State = entity.SigningKeyRetiring
+	retiring.RetiredAt = &retiredAt
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockSigningKey(ctrl)
+	repo.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{active, next}, nil)
+	repo.EXPECT().Promote(gomock.Any(), "next", now).Return(nil)
+	repo.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{retiring, promoted}, nil).Times(2)
+
+	ring := jwtgen.NewKeyRing()
+	s := newService(t, repo, c, ring)
+
+	key, err := s.Rotate(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "next", key.Id)
+	assert.Equal(t, entity.SigningKeyActive, key.State)
+	assert.Nil(t, key.PrivateKey)
+
+	_, err = ring.VerificationKey("active")
+	assert.NoError(t, err)
+}
+
+func TestKeysService_Revoke(t *testing.T) {
+	c := newCipher(t)
+
+	active := newKey(t, c, "active", entity.SigningKeyActive, now)
+	retiring := newKey(t, c, "retiring", entity.SigningKeyRetiring, now.Add(-time.Hour))
+
+	type MockBehavior func(r *repomocks.MockSigningKey)
+
+	testCases := []struct {
+		name         string
+		id           string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			id:   "retiring",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{retiring, active}, nil)
+				r.EXPECT().Revoke(gomock.Any(), "retiring").Return(nil)
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{active}, nil)
+			},
+		},
+		{
+			name: "key not found",
+			id:   "unknown",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{retiring, active}, nil)
+			},
+			wantErr: svcErrs.ErrKeyNotFound,
+		},
+		{
+			name: "cannot revoke",
+			id:   "retiring",
+			mockBehavior: func(r *repomocks.MockSigningKey) {
+				r.EXPECT().Keys(gomock.Any()).Return([]entity.SigningKey{retiring, active}, nil)
+				r.EXPECT().Revoke(gomock.Any(), "retiring").Return(errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrCannotUpdateKeys,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockSigningKey(ctrl)
+			tc.mockBehavior(repo)
+
+			ring := jwtgen.NewKeyRing()
+			s := newService(t, repo, c, ring)
+
+			err := s.Revoke(context.Background(), tc.id)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			_, err = ring.VerificationKey("retiring")
+			assert.ErrorIs(t, err, jwtgen.ErrUnknownKey)
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/lib/email"
+	"github.com/bubalync/uni-auth/internal/repo"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/bubalync/uni-auth/pkg/redis"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	failuresKeyTemplate = "login_failures:%s:%s"
+	lockKeyTemplate     = "login_lockout:%s:%s"
+	strikesKeyTemplate  = "login_lockouts:%s:%s"
+
+	kindAccount = "account"
+	kindIP      = "ip"
+
+	// strikesTTL is how long a lockout makes the next one longer.
+	strikesTTL = 24 * time.Hour
+)
+
+// Service counts failed password sign-ins per account and per IP and locks them out for a while,
+// every next lockout is longer.
+type Service struct {
+	log         *slog.Logger
+	cache       redis.Cache
+	users       repo.User
+	emailSender email.Sender
+	cfg         Config
+}
+
+// New -.
+func New(log *slog.Logger, cache redis.Cache, users repo.User, emailSender email.Sender, cfg Config) *Service {
+	return &Service{
+		log:         log,
+		cache:       cache,
+		users:       users,
+		emailSender: emailSender,
+		cfg:         cfg,
+	}
+}
+
+// Check returns a svcErrs.RetryAfterError when the account or the IP is locked out.
+func (s *Service) Check(ctx context.Context, email, ip string) error {
+	const op = "service.lockout.Check"
+	log := s.log.With(slog.String("op", op))
+
+	ttl, err := s.cache.TTL(ctx, fmt.Sprintf(lockKeyTemplate, kindAccount, strings.ToLower(email)))
+	if err != nil {
+		log.Error("failed to check the account lockout", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	if ttl > 0 {
+		return &svcErrs.RetryAfterError{Err: svcErrs.ErrAccountLocked, RetryAfter: ttl}
+	}
+
+	if ip == "" || s.cfg.MaxAttemptsPerIP <= 0 {
+		return nil
+	}
+
+	ttl, err = s.cache.TTL(ctx, fmt.Sprintf(lockKeyTemplate, kindIP, ip))
+	if err != nil {
+		log.Error("failed to check the ip lockout", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	if ttl > 0 {
+		return &svcErrs.RetryAfterError{Err: svcErrs.ErrTooManySignInAttempts, RetryAfter: ttl}
+	}
+
+	return nil
+}
+
+// Fail counts a failed sign-in, it returns a svcErrs.RetryAfterError when the failure locked the account or the IP.
+// Unknown emails are counted too, so that a lockout does not tell whether there is an account.
+func (s *Service) Fail(ctx context.Context, email, ip string) error {
+	const op = "service.lockout.Fail"
+	log := s.log.With(slog.String("op", op))
+
+	email = strings.ToLower(email)
+
+	// the IP is counted first, a failure that locks the account still counts against the IP
+	var ipErr error
+	if ip != "" && s.cfg.MaxAttemptsPerIP > 0 {
+		failures, err := s.cache.Incr(ctx, fmt.Sprintf(failuresKeyTemplate, kindIP, ip), s.cfg.Window)
+		if err != nil {
+			log.Error("failed to count the failed sign-in", sl.Err(err))
+			return svcErrs.ErrAccessToCache
+		}
+
+		if failures >= int64(s.cfg.MaxAttemptsPerIP) {
+			delay, err := s.lock(ctx, log, kindIP, ip)
+			if err != nil {
+				return err
+			}
+
+			ipErr = &svcErrs.RetryAfterError{Err: svcErrs.ErrTooManySignInAttempts, RetryAfter: delay}
+		}
+	}
+
+	failures, err := s.cache.Incr(ctx, fmt.Sprintf(failuresKeyTemplate, kindAccount, email), s.cfg.Window)
+	if err != nil {
+		log.Error("failed to count the failed sign-in", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	if failures >= int64(s.cfg.MaxAttempts) {
+		return s.lockAccount(ctx, log, email)
+	}
+
+	return ipErr
+}
+
+// LockAccount locks the account out at once, e.g. after too many wrong second factors, and notifies its owner.
+// It returns the svcErrs.RetryAfterError of the lockout.
+func (s *Service) LockAccount(ctx context.Context, email string) error {
+	const op = "service.lockout.LockAccount"
+	log := s.log.With(slog.String("op", op))
+
+	return s.lockAccount(ctx, log, strings.ToLower(email))
+}
+
+func (s *Service) lockAccount(ctx context.Context, log *slog.Logger, email string) error {
+	delay, err := s.lock(ctx, log, kindAccount, email)
+	if err != nil {
+		return err
+	}
+
+	s.notify(ctx, log, email, delay)
+
+	return &svcErrs.RetryAfterError{Err: svcErrs.ErrAccountLocked, RetryAfter: delay}
+}
+
+// Reset forgets the failed sign-ins of the account after a successful one.
+func (s *Service) Reset(ctx context.Context, email string) {
+	const op = "service.lockout.Reset"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.cache.Delete(ctx, fmt.Sprintf(failuresKeyTemplate, kindAccount, strings.ToLower(email))); err != nil {
+		log.Error("failed to reset the failed sign-ins", sl.Err(err))
+	}
+}
+
+// Unlock lifts the lockout of the account and forgets its failed sign-ins and earlier lockouts.
+func (s *Service) Unlock(ctx context.Context, email string) error {
+	const op = "service.lockout.Unlock"
+	log := s.log.With(slog.String("op", op))
+
+	email = strings.ToLower(email)
+
+	for _, template := range []string{lockKeyTemplate, failuresKeyTemplate, strikesKeyTemplate} {
+		if err := s.cache.Delete(ctx, fmt.Sprintf(template, kindAccount, email)); err != nil {
+			log.Error("failed to unlock the account", sl.Err(err))
+			return svcErrs.ErrAccessToCache
+		}
+	}
+
+	log.Info("account unlocked", sl.SecurityEvent("account_unlocked"))
+
+	return nil
+}
+
+// lock locks the account or the IP out and returns for how long.
+func (s *Service) lock(ctx context.Context, log *slog.Logger, kind, id string) (time.Duration, error) {
+	strikes, err := s.cache.Incr(ctx, fmt.Sprintf(strikesKeyTemplate, kind, id), strikesTTL)
+	if err != nil {
+		log.Error("failed to count the lockout", sl.Err(err))
+		return 0, svcErrs.ErrAccessToCache
+	}
+
+	delay := s.delay(strikes)
+
+	if err = s.cache.Set(ctx, fmt.Sprintf(lockKeyTemplate, kind, id), "1", delay); err != nil {
+		log.Error("failed to save the lockout", sl.Err(err))
+		return 0, svcErrs.ErrAccessToCache
+	}
+
+	if err = s.cache.Delete(ctx, fmt.Sprintf(failuresKeyTemplate, kind, id)); err != nil {
+		log.Error("failed to reset the failed sign-ins", sl.Err(err))
+	}
+
+	log.Warn("too many failed sign-ins, locking out",
+		sl.SecurityEvent(kind+"_locked"),
+		slog.Int64("lockouts", strikes),
+		slog.Duration("delay", delay),
+	)
+
+	return delay, nil
+}
+
+// delay doubles BaseDelay for every earlier lockout, up to MaxDelay.
+func (s *Service) delay(strikes int64) time.Duration {
+	delay := s.cfg.BaseDelay
+	for i := int64(1); i < strikes && delay < s.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, s.cfg.MaxDelay)
+}
+
+// notify emails the owner of the locked account, unknown emails get nothing.
+func (s *Service) notify(ctx context.Context, log *slog.Logger, email string, delay time.Duration) {
+	if !s.cfg.Notify {
+		return
+	}
+
+	user, err := s.users.UserByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, repoErrs.ErrNotFound) {
+			log.Error("failed to get user", sl.Err(err))
+		}
+		return
+	}
+
+	if err = s.emailSender.SendAccountLockedEmail(user.Email, delay); err != nil {
+		log.Error("failed to send the lockout email", sl.Err(err))
+	}
+}
@@ -0,0 +1,320 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/mocks/redismocks"
+	"github.com/bubalync/uni-auth/internal/mocks/repomocks"
+	"github.com/bubalync/uni-auth/internal/mocks/utilmocks"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+var cfg = Config{
+	MaxAttempts:      3,
+	MaxAttemptsPerIP: 10,
+	Window:           15 * time.Minute,
+	BaseDelay:        time.Minute,
+	MaxDelay:         time.Hour,
+	Notify:           true,
+}
+
+func TestService_Check(t *testing.T) {
+	ctx := context.Background()
+
+	type MockBehavior func(c *redismocks.MockCache)
+
+	testCases := []struct {
+		name           string
+		ip             string
+		mockBehavior   MockBehavior
+		wantErr        error
+		wantRetryAfter time.Duration
+	}{
+		{
+			name: "OK",
+			ip:   "10.0.0.1",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().TTL(ctx, "login_lockout:account:test@example.com").Return(time.Duration(0), nil)
+				c.EXPECT().TTL(ctx, "login_lockout:ip:10.0.0.1").Return(time.Duration(0), nil)
+			},
+		},
+		{
+			name: "no ip",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().TTL(ctx, "login_lockout:account:test@example.com").Return(time.Duration(0), nil)
+			},
+		},
+		{
+			name: "account locked",
+			ip:   "10.0.0.1",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().TTL(ctx, "login_lockout:account:test@example.com").Return(30*time.Second, nil)
+			},
+			wantErr:        svcErrs.ErrAccountLocked,
+			wantRetryAfter: 30 * time.Second,
+		},
+		{
+			name: "ip locked",
+			ip:   "10.0.0.1",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().TTL(ctx, "login_lockout:account:test@example.com").Return(time.Duration(0), nil)
+				c.EXPECT().TTL(ctx, "login_lockout:ip:10.0.0.1").Return(time.Minute, nil)
+			},
+			wantErr:        svcErrs.ErrTooManySignInAttempts,
+			wantRetryAfter: time.Minute,
+		},
+		{
+			name: "cache error",
+			ip:   "10.0.0.1",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().TTL(ctx, gomock.Any()).Return(time.Duration(0), errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrAccessToCache,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			tc.mockBehavior(cache)
+
+			s := New(logger.New("local", "info"), cache, nil, nil, cfg)
+
+			err := s.Check(ctx, "Test@example.com", tc.ip)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				if tc.wantRetryAfter != 0 {
+					var retryErr *svcErrs.RetryAfterError
+					assert.ErrorAs(t, err, &retryErr)
+					assert.Equal(t, tc.wantRetryAfter, retryErr.RetryAfter)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_Fail(t *testing.T) {
+	ctx := context.Background()
+
+	user := entity.User{Email: "test@example.com"}
+
+	type MockBehavior func(c *redismocks.MockCache, u *repomocks.MockUser, e *utilmocks.MockSender)
+
+	testCases := []struct {
+		name           string
+		mockBehavior   MockBehavior
+		wantErr        error
+		wantRetryAfter time.Duration
+	}{
+		{
+			name: "below the threshold",
+			mockBehavior: func(c *redismocks.MockCache, u *repomocks.MockUser, e *utilmocks.MockSender) {
+				c.EXPECT().Incr(ctx, "login_failures:ip:10.0.0.1", 15*time.Minute).Return(int64(1), nil)
+				c.EXPECT().Incr(ctx, "login_failures:account:test@example.com", 15*time.Minute).Return(int64(1), nil)
+			},
+		},
+		{
+			name: "account locked",
+			mockBehavior: func(c *redismocks.MockCache, u *repomocks.MockUser, e *utilmocks.MockSender) {
+				c.EXPECT().Incr(ctx, "login_failures:ip:10.0.0.1", 15*time.Minute).Return(int64(1), nil)
+				c.EXPECT().Incr(ctx, "login_failures:account:test@example.com", 15*time.Minute).Return(int64(3), nil)
+				c.EXPECT().Incr(ctx, "login_lockouts:account:test@example.com", 24*time.Hour).Return(int64(1), nil)
+				c.EXPECT().Set(ctx, "login_lockout:account:test@example.com", "1", time.Minute).Return(nil)
+				c.EXPECT().Delete(ctx, "login_failures:account:test@example.com").Return(nil)
+				u.EXPECT().UserByEmail(ctx, "test@example.com").Return(user, nil)
+				e.EXPECT().SendAccountLockedEmail("test@example.com", time.Minute).Return(nil)
+			},
+			wantErr:        svcErrs.ErrAccountLocked,
+			wantRetryAfter: time.Minute,
+		},
+		{
+			name: "account locked again",
+			mockBehavior: func(c *redismocks.MockCache, u *repomocks.MockUser, e *utilmocks.MockSender) {
+				c.EXPECT().Incr(ctx, "login_failures:ip:10.0.0.1", 15*time.Minute).Return(int64(1), nil)
+				c.EXPECT().Incr(ctx, "login_failures:account:test@example.com", 15*time.Minute).Return(int64(3), nil)
+				c.EXPECT().Incr(ctx, "login_lockouts:account:test@example.com", 24*time.Hour).Return(int64(3), nil)
+				c.EXPECT().Set(ctx, "login_lockout:account:test@example.com", "1", 4*time.Minute).Return(nil)
+				c.EXPECT().Delete(ctx, "login_failures:account:test@example.com").Return(nil)
+				u.EXPECT().UserByEmail(ctx, "test@example.com").Return(user, nil)
+				e.EXPECT().SendAccountLockedEmail("test@example.com", 4*time.Minute).Return(nil)
+			},
+			wantErr:        svcErrs.ErrAccountLocked,
+			wantRetryAfter: 4 * time.Minute,
+		},
+		{
+			name: "delay capped",
+			mockBehavior: func(c *redismocks.MockCache, u *repomocks.MockUser, e *utilmocks.MockSender) {
+				c.EXPECT().Incr(ctx, "login_failures:ip:10.0.0.1", 15*time.Minute).Return(int64(1), nil)
+				c.EXPECT().Incr(ctx, "login_failures:account:test@example.com", 15*time.Minute).Return(int64(3), nil)
+				c.EXPECT().Incr(ctx, "login_lockouts:account:test@example.com", 24*time.Hour).Return(int64(20), nil)
+				c.EXPECT().Set(ctx, "login_lockout:account:test@example.com", "1", time.Hour).Return(nil)
+				c.EXPECT().Delete(ctx, "login_failures:account:test@example.com").Return(nil)
+				u.EXPECT().UserByEmail(ctx, "test@example.com").Return(user, nil)
+				e.EXPECT().SendAccountLockedEmail("test@example.com", time.Hour).Return(nil)
+			},
+			wantErr:        svcErrs.ErrAccountLocked,
+			wantRetryAfter: time.Hour,
+		},
+		{
+			name: "unknown email locked without email",
+			mockBehavior: func(c *redismocks.MockCache, u *repomocks.MockUser, e *utilmocks.MockSender) {
+				c.EXPECT().Incr(ctx, "login_failures:ip:10.0.0.1", 15*time.Minute).Return(int64(1), nil)
+				c.EXPECT().Incr(ctx, "login_failures:account:test@example.com", 15*time.Minute).Return(int64(3), nil)
+				c.EXPECT().Incr(ctx, "login_lockouts:account:test@example.com", 24*time.Hour).Return(int64(1), nil)
+				c.EXPECT().Set(ctx, "login_lockout:account:test@example.com", "1", time.Minute).Return(nil)
+				c.EXPECT().Delete(ctx, "login_failures:account:test@example.com").Return(nil)
+				u.EXPECT().UserByEmail(ctx, "test@example.com").Return(entity.User{}, repoErrs.ErrNotFound)
+			},
+			wantErr:        svcErrs.ErrAccountLocked,
+			wantRetryAfter: time.Minute,
+		},
+		{
+			name: "ip locked",
+			mockBehavior: func(c *redismocks.MockCache, u *repomocks.MockUser, e *utilmocks.MockSender) {
+				c.EXPECT().Incr(ctx, "login_failures:ip:10.0.0.1", 15*time.Minute).Return(int64(10), nil)
+				c.EXPECT().Incr(ctx, "login_lockouts:ip:10.0.0.1", 24*time.Hour).Return(int64(2), nil)
+				c.EXPECT().Set(ctx, "login_lockout:ip:10.0.0.1", "1", 2*time.Minute).Return(nil)
+				c.EXPECT().Delete(ctx, "login_failures:ip:10.0.0.1").Return(nil)
+				c.EXPECT().Incr(ctx, "login_failures:account:test@example.com", 15*time.Minute).Return(int64(1), nil)
+			},
+			wantErr:        svcErrs.ErrTooManySignInAttempts,
+			wantRetryAfter: 2 * time.Minute,
+		},
+		{
+			name: "account and ip locked",
+			mockBehavior: func(c *redismocks.MockCache, u *repomocks.MockUser, e *utilmocks.MockSender) {
+				c.EXPECT().Incr(ctx, "login_failures:ip:10.0.0.1", 15*time.Minute).Return(int64(10), nil)
+				c.EXPECT().Incr(ctx, "login_lockouts:ip:10.0.0.1", 24*time.Hour).Return(int64(1), nil)
+				c.EXPECT().Set(ctx, "login_lockout:ip:10.0.0.1", "1", time.Minute).Return(nil)
+				c.EXPECT().Delete(ctx, "login_failures:ip:10.0.0.1").Return(nil)
+				c.EXPECT().Incr(ctx, "login_failures:account:test@example.com", 15*time.Minute).Return(int64(3), nil)
+				c.EXPECT().Incr(ctx, "login_lockouts:account:test@example.com", 24*time.Hour).Return(int64(1), nil)
+				c.EXPECT().Set(ctx, "login_lockout:account:test@example.com", "1", time.Minute).Return(nil)
+				c.EXPECT().Delete(ctx, "login_failures:account:test@example.com").Return(nil)
+				u.EXPECT().UserByEmail(ctx, "test@example.com").Return(user, nil)
+				e.EXPECT().SendAccountLockedEmail("test@example.com", time.Minute).Return(nil)
+			},
+			wantErr:        svcErrs.ErrAccountLocked,
+			wantRetryAfter: time.Minute,
+		},
+		{
+			name: "cache error",
+			mockBehavior: func(c *redismocks.MockCache, u *repomocks.MockUser, e *utilmocks.MockSender) {
+				c.EXPECT().Incr(ctx, gomock.Any(), gomock.Any()).Return(int64(0), errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrAccessToCache,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			users := repomocks.NewMockUser(ctrl)
+			sender := utilmocks.NewMockSender(ctrl)
+			tc.mockBehavior(cache, users, sender)
+
+			s := New(logger.New("local", "info"), cache, users, sender, cfg)
+
+			err := s.Fail(ctx, "Test@example.com", "10.0.0.1")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				if tc.wantRetryAfter != 0 {
+					var retryErr *svcErrs.RetryAfterError
+					assert.ErrorAs(t, err, &retryErr)
+					assert.Equal(t, tc.wantRetryAfter, retryErr.RetryAfter)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_LockAccount(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache := redismocks.NewMockCache(ctrl)
+	cache.EXPECT().Incr(ctx, "login_lockouts:account:test@example.com", 24*time.Hour).Return(int64(2), nil)
+	cache.EXPECT().Set(ctx, "login_lockout:account:test@example.com", "1", 2*time.Minute).Return(nil)
+	cache.EXPECT().Delete(ctx, "login_failures:account:test@example.com").Return(nil)
+	users := repomocks.NewMockUser(ctrl)
+	users.EXPECT().UserByEmail(ctx, "test@example.com").Return(entity.User{Email: "test@example.com"}, nil)
+	sender := utilmocks.NewMockSender(ctrl)
+	sender.EXPECT().SendAccountLockedEmail("test@example.com", 2*time.Minute).Return(nil)
+
+	s := New(logger.New("local", "info"), cache, users, sender, cfg)
+
+	err := s.LockAccount(ctx, "Test@example.com")
+	var retryErr *svcErrs.RetryAfterError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.ErrorIs(t, err, svcErrs.ErrAccountLocked)
+	assert.Equal(t, 2*time.Minute, retryErr.RetryAfter)
+}
+
+func TestService_Unlock(t *testing.T) {
+	ctx := context.Background()
+
+	type MockBehavior func(c *redismocks.MockCache)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().Delete(ctx, "login_lockout:account:test@example.com").Return(nil)
+				c.EXPECT().Delete(ctx, "login_failures:account:test@example.com").Return(nil)
+				c.EXPECT().Delete(ctx, "login_lockouts:account:test@example.com").Return(nil)
+			},
+		},
+		{
+			name: "cache error",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().Delete(ctx, gomock.Any()).Return(errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrAccessToCache,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			tc.mockBehavior(cache)
+
+			s := New(logger.New("local", "info"), cache, nil, nil, cfg)
+
+			err := s.Unlock(ctx, "Test@example.com")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
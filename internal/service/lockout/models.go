@@ -0,0 +1,19 @@
+package lockout
+
+import "time"
+
+type (
+	Config struct {
+		// MaxAttempts is the number of failed sign-ins within Window that lock the account.
+		MaxAttempts int
+		// MaxAttemptsPerIP is the number of failed sign-ins from an IP within Window that lock the IP,
+		// zero disables the lockout of IPs.
+		MaxAttemptsPerIP int
+		Window           time.Duration
+		// BaseDelay is the first lockout, every next lockout within a day is twice as long up to MaxDelay.
+		BaseDelay time.Duration
+		MaxDelay  time.Duration
+		// Notify emails the owner of a locked account.
+		Notify bool
+	}
+)
@@ -0,0 +1,374 @@
+package mfa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/cipher"
+	"github.com/bubalync/uni-auth/pkg/hasher"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+	"image/png"
+	"log/slog"
+	"time"
+)
+
+// Service manages the second factor of users: TOTP authenticator apps (RFC 6238)
+// and the recovery codes that replace them when the device is lost.
+type Service struct {
+	log    *slog.Logger
+	repo   repo.TOTP
+	codes  repo.RecoveryCode
+	users  repo.User
+	hasher hasher.PasswordHasher
+	cipher cipher.Cipher
+	// issuer is the account label shown in authenticator apps.
+	issuer string
+}
+
+// New -.
+func New(
+	log *slog.Logger,
+	repo repo.TOTP,
+	codes repo.RecoveryCode,
+	users repo.User,
+	hasher hasher.PasswordHasher,
+	cipher cipher.Cipher,
+	issuer string,
+) *Service {
+	return &Service{
+		log:    log,
+		repo:   repo,
+		codes:  codes,
+		users:  users,
+		hasher: hasher,
+		cipher: cipher,
+		issuer: issuer,
+	}
+}
+
+// EnrollTOTP generates a new secret for the user. Two-factor authentication is enabled
+// by ConfirmTOTP, until then the enrollment can be started over.
+func (s *Service) EnrollTOTP(ctx context.Context, userId uuid.UUID) (EnrollTOTPOutput, error) {
+	const op = "service.mfa.EnrollTOTP"
+	log := s.log.With(slog.String("op", op))
+
+	user, err := s.users.UserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return EnrollTOTPOutput{}, svcErrs.ErrUserNotFound
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return EnrollTOTPOutput{}, svcErrs.ErrCannotGetUser
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.issuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+	if err != nil {
+		log.Error("failed to generate totp secret", sl.Err(err))
+		return EnrollTOTPOutput{}, svcErrs.ErrCannotUpdateMFA
+	}
+
+	secret, err := s.cipher.Encrypt([]byte(key.Secret()), []byte(userId.String()))
+	if err != nil {
+		log.Error("failed to encrypt totp secret", sl.Err(err))
+		return EnrollTOTPOutput{}, svcErrs.ErrCannotUpdateMFA
+	}
+
+	if err = s.repo.Upsert(ctx, entity.TOTP{UserId: userId, Secret: secret}); err != nil {
+		if errors.Is(err, repoErrs.ErrAlreadyExists) {
+			return EnrollTOTPOutput{}, svcErrs.ErrMFAAlreadyEnabled
+		}
+
+		log.Error("failed to save totp secret", sl.Err(err))
+		return EnrollTOTPOutput{}, svcErrs.ErrCannotUpdateMFA
+	}
+
+	img, err := key.Image(qrCodeSize, qrCodeSize)
+	if err != nil {
+		log.Error("failed to render qr code", sl.Err(err))
+		return EnrollTOTPOutput{}, svcErrs.ErrCannotUpdateMFA
+	}
+
+	var qr bytes.Buffer
+	if err = png.Encode(&qr, img); err != nil {
+		log.Error("failed to encode qr code", sl.Err(err))
+		return EnrollTOTPOutput{}, svcErrs.ErrCannotUpdateMFA
+	}
+
+	return EnrollTOTPOutput{
+		Secret: key.Secret(),
+		URI:    key.URL(),
+		QRCode: qr.Bytes(),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once the user proves the app is set up.
+// It returns the recovery codes, they are not shown again.
+func (s *Service) ConfirmTOTP(ctx context.Context, userId uuid.UUID, code string) ([]string, error) {
+	const op = "service.mfa.ConfirmTOTP"
+	log := s.log.With(slog.String("op", op))
+
+	t, err := s.totp(ctx, userId)
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrMFANotEnabled) {
+			return nil, svcErrs.ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+
+	if t.IsConfirmed() {
+		return nil, svcErrs.ErrMFAAlreadyEnabled
+	}
+
+	step, err := s.match(t, code)
+	if err != nil {
+		return nil, err
+	}
+
+	// the codes are saved first, so 2FA is never enabled without them
+	codes, err := s.newRecoveryCodes(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.Confirm(ctx, userId, step); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return nil, svcErrs.ErrMFAAlreadyEnabled
+		}
+
+		log.Error("failed to confirm totp", sl.Err(err))
+		return nil, svcErrs.ErrCannotUpdateMFA
+	}
+
+	log.Info("two-factor authentication enabled",
+		sl.SecurityEvent("mfa_enabled"),
+		slog.String("user_id", userId.String()),
+	)
+
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, it takes a valid code.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userId uuid.UUID, code string) ([]string, error) {
+	const op = "service.mfa.RegenerateRecoveryCodes"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.Verify(ctx, userId, code); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.newRecoveryCodes(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("recovery codes regenerated",
+		sl.SecurityEvent("recovery_codes_regenerated"),
+		slog.String("user_id", userId.String()),
+	)
+
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off, it takes a valid code.
+func (s *Service) DisableTOTP(ctx context.Context, userId uuid.UUID, code string) error {
+	const op = "service.mfa.DisableTOTP"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.Verify(ctx, userId, code); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, userId); err != nil && !errors.Is(err, repoErrs.ErrNotFound) {
+		log.Error("failed to delete totp", sl.Err(err))
+		return svcErrs.ErrCannotUpdateMFA
+	}
+
+	if err := s.codes.Replace(ctx, userId, nil); err != nil {
+		log.Error("failed to delete recovery codes", sl.Err(err))
+		return svcErrs.ErrCannotUpdateMFA
+	}
+
+	log.Info("two-factor authentication disabled",
+		sl.SecurityEvent("mfa_disabled"),
+		slog.String("user_id", userId.String()),
+	)
+
+	return nil
+}
+
+// Enabled reports whether the user has to present a second factor to sign in.
+func (s *Service) Enabled(ctx context.Context, userId uuid.UUID) (bool, error) {
+	t, err := s.totp(ctx, userId)
+	if err != nil {
+		if errors.Is(err, svcErrs.ErrMFANotEnabled) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return t.IsConfirmed(), nil
+}
+
+// Verify checks a code of the user's authenticator app or a recovery code. Every code is accepted once.
+func (s *Service) Verify(ctx context.Context, userId uuid.UUID, code string) error {
+	t, err := s.totp(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if !t.IsConfirmed() {
+		return svcErrs.ErrMFANotEnabled
+	}
+
+	if !isTOTPCode(code) {
+		return s.useRecoveryCode(ctx, userId, code)
+	}
+
+	return s.useTOTPCode(ctx, t, code)
+}
+
+func (s *Service) useTOTPCode(ctx context.Context, t entity.TOTP, code string) error {
+	const op = "service.mfa.useTOTPCode"
+	log := s.log.With(slog.String("op", op))
+
+	step, err := s.match(t, code)
+	if err != nil {
+		return err
+	}
+
+	if step <= t.LastUsedStep {
+		log.Warn("totp code replayed",
+			sl.SecurityEvent("totp_replay"),
+			slog.String("user_id", t.UserId.String()),
+		)
+		return svcErrs.ErrInvalidOTP
+	}
+
+	if err = s.repo.UseStep(ctx, t.UserId, step); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			// another request used the same code first
+			return svcErrs.ErrInvalidOTP
+		}
+
+		log.Error("failed to save the used time step", sl.Err(err))
+		return svcErrs.ErrCannotUpdateMFA
+	}
+
+	return nil
+}
+
+// totp returns svcErrs.ErrMFANotEnabled when the user has not enrolled.
+func (s *Service) totp(ctx context.Context, userId uuid.UUID) (entity.TOTP, error) {
+	const op = "service.mfa.totp"
+	log := s.log.With(slog.String("op", op))
+
+	t, err := s.repo.TOTPByUserId(ctx, userId)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return entity.TOTP{}, svcErrs.ErrMFANotEnabled
+		}
+
+		log.Error("failed to get totp", sl.Err(err))
+		return entity.TOTP{}, svcErrs.ErrCannotGetMFA
+	}
+
+	return t, nil
+}
+
+func (s *Service) match(t entity.TOTP, code string) (int64, error) {
+	const op = "service.mfa.match"
+	log := s.log.With(slog.String("op", op))
+
+	secret, err := s.cipher.Decrypt(t.Secret, []byte(t.UserId.String()))
+	if err != nil {
+		log.Error("failed to decrypt totp secret", sl.Err(err))
+		return 0, svcErrs.ErrCannotGetMFA
+	}
+
+	step, ok := matchStep(string(secret), code, time.Now())
+	if !ok {
+		return 0, svcErrs.ErrInvalidOTP
+	}
+
+	return step, nil
+}
+
+func (s *Service) useRecoveryCode(ctx context.Context, userId uuid.UUID, code string) error {
+	const op = "service.mfa.useRecoveryCode"
+	log := s.log.With(slog.String("op", op))
+
+	codes, err := s.codes.Unused(ctx, userId)
+	if err != nil {
+		log.Error("failed to get recovery codes", sl.Err(err))
+		return svcErrs.ErrCannotGetMFA
+	}
+
+	normalized := []byte(normalizeRecoveryCode(code))
+	for _, c := range codes {
+		if s.hasher.Compare(c.CodeHash, normalized) != nil {
+			continue
+		}
+
+		if err = s.codes.Use(ctx, c.Id); err != nil {
+			if errors.Is(err, repoErrs.ErrNotFound) {
+				// another request used the same code first
+				return svcErrs.ErrInvalidOTP
+			}
+
+			log.Error("failed to use recovery code", sl.Err(err))
+			return svcErrs.ErrCannotUpdateMFA
+		}
+
+		log.Warn("recovery code used",
+			sl.SecurityEvent("recovery_code_used"),
+			slog.String("user_id", userId.String()),
+			slog.Int("remaining", len(codes)-1),
+		)
+
+		return nil
+	}
+
+	return svcErrs.ErrInvalidOTP
+}
+
+// newRecoveryCodes replaces the recovery codes of the user and returns the new ones.
+func (s *Service) newRecoveryCodes(ctx context.Context, userId uuid.UUID) ([]string, error) {
+	const op = "service.mfa.newRecoveryCodes"
+	log := s.log.With(slog.String("op", op))
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		log.Error("failed to generate recovery codes", sl.Err(err))
+		return nil, svcErrs.ErrCannotUpdateMFA
+	}
+
+	hashes := make([][]byte, 0, len(codes))
+	for _, code := range codes {
+		hash, err := s.hasher.Hash(normalizeRecoveryCode(code))
+		if err != nil {
+			log.Error("failed to hash recovery code", sl.Err(err))
+			return nil, svcErrs.ErrCannotUpdateMFA
+		}
+		hashes = append(hashes, hash)
+	}
+
+	if err = s.codes.Replace(ctx, userId, hashes); err != nil {
+		log.Error("failed to save recovery codes", sl.Err(err))
+		return nil, svcErrs.ErrCannotUpdateMFA
+	}
+
+	return codes, nil
+}
@@ -0,0 +1,442 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/mocks/repomocks"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/cipher"
+	"github.com/bubalync/uni-auth/pkg/hasher"
+	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "JBSWY3DPEHPK3PXP"
+
+func newCipher(t *testing.T) cipher.Cipher {
+	c, err := cipher.NewAESGCM(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func newTOTP(t *testing.T, c cipher.Cipher, userId uuid.UUID, confirmed bool, lastUsedStep int64) entity.TOTP {
+	secret, err := c.Encrypt([]byte(testSecret), []byte(userId.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := entity.TOTP{UserId: userId, Secret: secret, LastUsedStep: lastUsedStep}
+	if confirmed {
+		now := time.Now()
+		tt.ConfirmedAt = &now
+	}
+
+	return tt
+}
+
+func newHasher() hasher.PasswordHasher {
+	return hasher.NewBcryptHasher(hasher.Cost(bcrypt.MinCost))
+}
+
+func currentCode(t *testing.T) (string, int64) {
+	now := time.Now()
+
+	code, err := totp.GenerateCodeCustom(testSecret, now, totpOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return code, now.Unix() / totpPeriod
+}
+
+func TestMFAService_EnrollTOTP(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	type MockBehavior func(r *repomocks.MockTOTP, u *repomocks.MockUser)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *repomocks.MockTOTP, u *repomocks.MockUser) {
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+				r.EXPECT().Upsert(ctx, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "user not found",
+			mockBehavior: func(r *repomocks.MockTOTP, u *repomocks.MockUser) {
+				u.EXPECT().UserById(ctx, user.Id).Return(entity.User{}, repoErrs.ErrNotFound)
+			},
+			err: svcErrs.ErrUserNotFound,
+		},
+		{
+			name: "already enabled",
+			mockBehavior: func(r *repomocks.MockTOTP, u *repomocks.MockUser) {
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+				r.EXPECT().Upsert(ctx, gomock.Any()).Return(repoErrs.ErrAlreadyExists)
+			},
+			err: svcErrs.ErrMFAAlreadyEnabled,
+		},
+		{
+			name: "repo error",
+			mockBehavior: func(r *repomocks.MockTOTP, u *repomocks.MockUser) {
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+				r.EXPECT().Upsert(ctx, gomock.Any()).Return(errors.New("some error"))
+			},
+			err: svcErrs.ErrCannotUpdateMFA,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockTOTP(ctrl)
+			users := repomocks.NewMockUser(ctrl)
+			tc.mockBehavior(repo, users)
+
+			c := newCipher(t)
+			s := New(logger.New("local", "info"), repo, nil, users, nil, c, "uni-auth")
+
+			got, err := s.EnrollTOTP(ctx, user.Id)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, got.Secret)
+			assert.True(t, strings.HasPrefix(got.URI, "otpauth://totp/uni-auth:test@example.com?"))
+			assert.Equal(t, []byte("\x89PNG"), got.QRCode[:4])
+		})
+	}
+}
+
+func TestMFAService_EnrollTOTP_EncryptsSecret(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := newCipher(t)
+	users := repomocks.NewMockUser(ctrl)
+	users.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+
+	var saved entity.TOTP
+	repo := repomocks.NewMockTOTP(ctrl)
+	repo.EXPECT().Upsert(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, t entity.TOTP) error {
+		saved = t
+		return nil
+	})
+
+	s := New(logger.New("local", "info"), repo, nil, users, nil, c, "uni-auth")
+
+	got, err := s.EnrollTOTP(ctx, user.Id)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(saved.Secret), got.Secret)
+
+	secret, err := c.Decrypt(saved.Secret, []byte(user.Id.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, got.Secret, string(secret))
+}
+
+func TestMFAService_ConfirmTOTP(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+	c := newCipher(t)
+	code, step := currentCode(t)
+
+	type MockBehavior func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode)
+
+	testCases := []struct {
+		name         string
+		code         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			code: code,
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, false, 0), nil)
+				rc.EXPECT().Replace(ctx, userId, gomock.Len(recoveryCodeCount)).Return(nil)
+				r.EXPECT().Confirm(ctx, userId, step).Return(nil)
+			},
+		},
+		{
+			name: "not enrolled",
+			code: code,
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(entity.TOTP{}, repoErrs.ErrNotFound)
+			},
+			err: svcErrs.ErrMFANotEnrolled,
+		},
+		{
+			name: "already enabled",
+			code: code,
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, true, 0), nil)
+			},
+			err: svcErrs.ErrMFAAlreadyEnabled,
+		},
+		{
+			name: "invalid code",
+			code: "000000",
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, false, 0), nil)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockTOTP(ctrl)
+			codes := repomocks.NewMockRecoveryCode(ctrl)
+			tc.mockBehavior(repo, codes)
+
+			s := New(logger.New("local", "info"), repo, codes, nil, newHasher(), c, "uni-auth")
+
+			got, err := s.ConfirmTOTP(ctx, userId, tc.code)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, got, recoveryCodeCount)
+		})
+	}
+}
+
+func TestMFAService_Verify(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+	c := newCipher(t)
+	code, step := currentCode(t)
+	h := newHasher()
+
+	recoveryHash, err := h.Hash("abcdefgh23")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryCodes := []entity.RecoveryCode{
+		{Id: uuid.New(), UserId: userId, CodeHash: []byte("$2a$04$otherotherotherotherotherotherotherotherotherotherothe")},
+		{Id: uuid.New(), UserId: userId, CodeHash: recoveryHash},
+	}
+
+	type MockBehavior func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode)
+
+	testCases := []struct {
+		name         string
+		code         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			code: code,
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, true, 0), nil)
+				r.EXPECT().UseStep(ctx, userId, step).Return(nil)
+			},
+		},
+		{
+			name: "not enabled",
+			code: code,
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(entity.TOTP{}, repoErrs.ErrNotFound)
+			},
+			err: svcErrs.ErrMFANotEnabled,
+		},
+		{
+			name: "not confirmed",
+			code: code,
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, false, 0), nil)
+			},
+			err: svcErrs.ErrMFANotEnabled,
+		},
+		{
+			name: "invalid code",
+			code: "000000",
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, true, 0), nil)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "replayed code",
+			code: code,
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, true, step), nil)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "code used concurrently",
+			code: code,
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, true, 0), nil)
+				r.EXPECT().UseStep(ctx, userId, step).Return(repoErrs.ErrNotFound)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "recovery code",
+			code: "ABCDE-FGH23",
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, true, 0), nil)
+				rc.EXPECT().Unused(ctx, userId).Return(recoveryCodes, nil)
+				rc.EXPECT().Use(ctx, recoveryCodes[1].Id).Return(nil)
+			},
+		},
+		{
+			name: "unknown recovery code",
+			code: "abcde-fgh24",
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, true, 0), nil)
+				rc.EXPECT().Unused(ctx, userId).Return(recoveryCodes, nil)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "recovery code used concurrently",
+			code: "abcde-fgh23",
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, true, 0), nil)
+				rc.EXPECT().Unused(ctx, userId).Return(recoveryCodes, nil)
+				rc.EXPECT().Use(ctx, recoveryCodes[1].Id).Return(repoErrs.ErrNotFound)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "repo error",
+			code: code,
+			mockBehavior: func(r *repomocks.MockTOTP, rc *repomocks.MockRecoveryCode) {
+				r.EXPECT().TOTPByUserId(ctx, userId).Return(entity.TOTP{}, errors.New("some error"))
+			},
+			err: svcErrs.ErrCannotGetMFA,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockTOTP(ctrl)
+			codes := repomocks.NewMockRecoveryCode(ctrl)
+			tc.mockBehavior(repo, codes)
+
+			s := New(logger.New("local", "info"), repo, codes, nil, h, c, "uni-auth")
+
+			err := s.Verify(ctx, userId, tc.code)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMFAService_DisableTOTP(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+	c := newCipher(t)
+	code, step := currentCode(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockTOTP(ctrl)
+	repo.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, true, 0), nil)
+	repo.EXPECT().UseStep(ctx, userId, step).Return(nil)
+	repo.EXPECT().Delete(ctx, userId).Return(nil)
+
+	codes := repomocks.NewMockRecoveryCode(ctrl)
+	codes.EXPECT().Replace(ctx, userId, nil).Return(nil)
+
+	s := New(logger.New("local", "info"), repo, codes, nil, nil, c, "uni-auth")
+
+	assert.NoError(t, s.DisableTOTP(ctx, userId, code))
+}
+
+func TestMFAService_RegenerateRecoveryCodes(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+	c := newCipher(t)
+	h := newHasher()
+	code, step := currentCode(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockTOTP(ctrl)
+	repo.EXPECT().TOTPByUserId(ctx, userId).Return(newTOTP(t, c, userId, true, 0), nil)
+	repo.EXPECT().UseStep(ctx, userId, step).Return(nil)
+
+	var hashes [][]byte
+	codes := repomocks.NewMockRecoveryCode(ctrl)
+	codes.EXPECT().Replace(ctx, userId, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, h [][]byte) error {
+			hashes = h
+			return nil
+		})
+
+	s := New(logger.New("local", "info"), repo, codes, nil, h, c, "uni-auth")
+
+	got, err := s.RegenerateRecoveryCodes(ctx, userId, code)
+	assert.NoError(t, err)
+	assert.Len(t, got, recoveryCodeCount)
+	assert.Len(t, hashes, recoveryCodeCount)
+
+	for i, rc := range got {
+		assert.Regexp(t, "^[a-z2-7]{5}-[a-z2-7]{5}$", rc)
+		assert.NoError(t, h.Compare(hashes[i], []byte(normalizeRecoveryCode(rc))))
+	}
+}
+
+func TestMatchStep(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	current := now.Unix() / totpPeriod
+
+	codeAt := func(step int64) string {
+		code, err := totp.GenerateCodeCustom(testSecret, time.Unix(step*totpPeriod, 0), totpOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	for _, step := range []int64{current - 1, current, current + 1} {
+		got, ok := matchStep(testSecret, codeAt(step), now)
+		assert.True(t, ok)
+		assert.Equal(t, step, got)
+	}
+
+	_, ok := matchStep(testSecret, codeAt(current-2), now)
+	assert.False(t, ok)
+}
@@ -0,0 +1,13 @@
+package mfa
+
+type (
+	// EnrollTOTPOutput is shown to the user once, to set up the authenticator app.
+	EnrollTOTPOutput struct {
+		// Secret is the base32 key for manual entry.
+		Secret string
+		// URI is the otpauth:// URI encoded in the QR code.
+		URI string
+		// QRCode is a PNG image of the URI.
+		QRCode []byte
+	}
+)
@@ -0,0 +1,52 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+)
+
+const (
+	recoveryCodeCount = 10
+	// recoveryCodeLength is the number of characters of a code without the separator, 50 random bits.
+	recoveryCodeLength = 10
+)
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// generateRecoveryCodes returns codes formatted for display, like "abcde-fgh23".
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+
+	b := make([]byte, 7)
+	for range recoveryCodeCount {
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		code := recoveryCodeEncoding.EncodeToString(b)[:recoveryCodeLength]
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+	}
+
+	return codes, nil
+}
+
+// normalizeRecoveryCode accepts the code as typed: in any case, with or without the separator.
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+}
+
+// isTOTPCode tells an authenticator app code from a recovery code.
+func isTOTPCode(code string) bool {
+	if len(code) != totpOpts.Digits.Length() {
+		return false
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,40 @@
+package mfa
+
+import (
+	"crypto/subtle"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"time"
+)
+
+const (
+	totpPeriod = 30
+	// totpSkew is the number of steps accepted before and after the current one, for clock drift.
+	totpSkew = 1
+	// qrCodeSize is the width and height of the QR code in pixels.
+	qrCodeSize = 256
+)
+
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// matchStep returns the time step the code was generated for.
+func matchStep(secret, code string, now time.Time) (int64, bool) {
+	current := now.Unix() / totpPeriod
+
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		want, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totpOpts)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
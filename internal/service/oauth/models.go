@@ -0,0 +1,81 @@
+package oauth
+
+import "time"
+
+const (
+	ResponseTypeCode = "code"
+
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeRefreshToken      = "refresh_token"
+
+	ScopeEmail = "email"
+
+	CodeChallengeMethodS256 = "S256"
+)
+
+type (
+	// AuthorizationRequest holds the parameters of /oauth/authorize.
+	AuthorizationRequest struct {
+		ResponseType        string
+		ClientId            string
+		RedirectURI         string
+		Scope               string
+		State               string
+		Nonce               string
+		CodeChallenge       string
+		CodeChallengeMethod string
+	}
+
+	AuthorizeInput struct {
+		Request  AuthorizationRequest
+		Email    string
+		Password string
+		// OTP is the one-time code of users with two-factor authentication.
+		OTP       string
+		IP        string
+		UserAgent string
+	}
+
+	// TokenRequest holds the parameters of /oauth/token.
+	TokenRequest struct {
+		GrantType    string
+		ClientId     string
+		ClientSecret string
+		Code         string
+		RedirectURI  string
+		CodeVerifier string
+		// RefreshToken is read by the refresh_token grant.
+		RefreshToken string
+		// Scope is only read by the client_credentials grant,
+		// the authorization code carries the scope of the authorization request.
+		Scope     string
+		IP        string
+		UserAgent string
+	}
+
+	// IntrospectionRequest holds the parameters of /oauth/introspect.
+	IntrospectionRequest struct {
+		ClientId      string
+		ClientSecret  string
+		Token         string
+		TokenTypeHint string
+	}
+
+	// RevocationRequest holds the parameters of /oauth/revoke.
+	RevocationRequest struct {
+		ClientId      string
+		ClientSecret  string
+		Token         string
+		TokenTypeHint string
+	}
+
+	TokenResponse struct {
+		AccessToken  string
+		TokenType    string
+		ExpiresIn    time.Duration
+		RefreshToken string
+		IdToken      string
+		Scope        string
+	}
+)
@@ -0,0 +1,401 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/hasher"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/bubalync/uni-auth/pkg/redis"
+	"github.com/google/uuid"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+const codeKeyTemplate = "oauth_code:%s"
+
+// SupportedScopes are the scopes users can grant in an authorization request.
+// Clients may also be registered for their own API scopes, which are only granted with client_credentials.
+var SupportedScopes = []string{auth.ScopeOpenId, ScopeEmail}
+
+// SupportedGrantTypes are the grant types a client may be registered for.
+var SupportedGrantTypes = []string{GrantTypeAuthorizationCode, GrantTypeClientCredentials, GrantTypeRefreshToken}
+
+// Authenticator is the part of the auth service the OAuth flows are built on.
+type Authenticator interface {
+	Authenticate(ctx context.Context, email, password, ip string) (entity.User, error)
+	VerifySecondFactor(ctx context.Context, user entity.User, code string) error
+	IssueTokens(ctx context.Context, user entity.User, input auth.IssueTokensInput) (auth.GenerateTokenOutput, error)
+	RefreshClient(ctx context.Context, token, clientId string) (auth.GenerateTokenOutput, error)
+	IssueServiceToken(ctx context.Context, client entity.Client, scope string) (string, error)
+	IntrospectToken(ctx context.Context, token, hint string) (auth.TokenInfo, error)
+	RevokeToken(ctx context.Context, token, hint, clientId string) error
+}
+
+// codeRecord is the cached state of an authorization code.
+type codeRecord struct {
+	ClientId      string    `json:"client_id"`
+	RedirectURI   string    `json:"redirect_uri"`
+	UserId        uuid.UUID `json:"user_id"`
+	Email         string    `json:"email"`
+	Scope         string    `json:"scope"`
+	Nonce         string    `json:"nonce"`
+	CodeChallenge string    `json:"code_challenge"`
+	AuthTime      time.Time `json:"auth_time"`
+	// EmailVerifiedAt is carried over to the tokens.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+}
+
+type Service struct {
+	log            *slog.Logger
+	cache          redis.Cache
+	auth           Authenticator
+	clients        repo.Client
+	hasher         hasher.PasswordHasher
+	codeTTL        time.Duration
+	accessTokenTTL time.Duration
+}
+
+// New -.
+func New(
+	log *slog.Logger,
+	cache redis.Cache,
+	auth Authenticator,
+	clients repo.Client,
+	hasher hasher.PasswordHasher,
+	codeTTL time.Duration,
+	accessTokenTTL time.Duration,
+) *Service {
+	return &Service{
+		log:            log,
+		cache:          cache,
+		auth:           auth,
+		clients:        clients,
+		hasher:         hasher,
+		codeTTL:        codeTTL,
+		accessTokenTTL: accessTokenTTL,
+	}
+}
+
+// ValidateAuthorizationRequest checks the request before the user is asked for credentials.
+// svcErrs.ErrInvalidClient and svcErrs.ErrInvalidRedirectURI mean that the error
+// must not be redirected to the client.
+func (s *Service) ValidateAuthorizationRequest(ctx context.Context, req AuthorizationRequest) error {
+	client, err := s.client(ctx, req.ClientId)
+	if err != nil {
+		return err
+	}
+
+	if !client.AllowsRedirectURI(req.RedirectURI) {
+		return svcErrs.ErrInvalidRedirectURI
+	}
+
+	if req.ResponseType != ResponseTypeCode {
+		return svcErrs.ErrUnsupportedResponseType
+	}
+
+	if !client.AllowsGrantType(GrantTypeAuthorizationCode) {
+		return svcErrs.ErrUnauthorizedClient
+	}
+
+	if req.CodeChallengeMethod != CodeChallengeMethodS256 || !validCodeChallenge(req.CodeChallenge) {
+		return svcErrs.ErrPKCERequired
+	}
+
+	for _, sc := range strings.Fields(req.Scope) {
+		if !slices.Contains(SupportedScopes, sc) || !client.AllowsScope(sc) {
+			return svcErrs.ErrInvalidScope
+		}
+	}
+
+	return nil
+}
+
+// Authorize checks the credentials of the user and returns a single-use authorization code.
+func (s *Service) Authorize(ctx context.Context, input AuthorizeInput) (string, error) {
+	const op = "service.oauth.Authorize"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.ValidateAuthorizationRequest(ctx, input.Request); err != nil {
+		return "", err
+	}
+
+	user, err := s.auth.Authenticate(ctx, input.Email, input.Password, input.IP)
+	if err != nil {
+		return "", err
+	}
+
+	if err = s.auth.VerifySecondFactor(ctx, user, input.OTP); err != nil {
+		return "", err
+	}
+
+	code, err := newCode()
+	if err != nil {
+		log.Error("failed to generate authorization code", sl.Err(err))
+		return "", svcErrs.ErrCannotSignToken
+	}
+
+	data, err := json.Marshal(codeRecord{
+		ClientId:      input.Request.ClientId,
+		RedirectURI:   input.Request.RedirectURI,
+		UserId:        user.Id,
+		Email:         user.Email,
+		Scope:         strings.Join(strings.Fields(input.Request.Scope), " "),
+		Nonce:         input.Request.Nonce,
+		CodeChallenge: input.Request.CodeChallenge,
+		AuthTime:      time.Now(),
+
+		EmailVerifiedAt: user.EmailVerifiedAt,
+	})
+	if err != nil {
+		log.Error("failed to encode authorization code", sl.Err(err))
+		return "", svcErrs.ErrAccessToCache
+	}
+
+	if err = s.cache.Set(ctx, fmt.Sprintf(codeKeyTemplate, code), string(data), s.codeTTL); err != nil {
+		log.Error("failed to save authorization code", sl.Err(err))
+		return "", svcErrs.ErrAccessToCache
+	}
+
+	return code, nil
+}
+
+// Token issues tokens for the authorization_code, client_credentials and refresh_token grants.
+func (s *Service) Token(ctx context.Context, req TokenRequest) (TokenResponse, error) {
+	if !slices.Contains(SupportedGrantTypes, req.GrantType) {
+		return TokenResponse{}, svcErrs.ErrUnsupportedGrantType
+	}
+
+	client, err := s.authenticateClient(ctx, req.ClientId, req.ClientSecret)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	if !client.AllowsGrantType(req.GrantType) {
+		return TokenResponse{}, svcErrs.ErrUnauthorizedClient
+	}
+
+	switch req.GrantType {
+	case GrantTypeClientCredentials:
+		return s.clientCredentials(ctx, client, req.Scope)
+	case GrantTypeRefreshToken:
+		return s.refreshToken(ctx, client, req.RefreshToken)
+	default:
+		return s.authorizationCode(ctx, client, req)
+	}
+}
+
+// authorizationCode exchanges an authorization code for tokens.
+func (s *Service) authorizationCode(ctx context.Context, client entity.Client, req TokenRequest) (TokenResponse, error) {
+	const op = "service.oauth.authorizationCode"
+	log := s.log.With(slog.String("op", op))
+
+	// the code is deleted on first use, even when the exchange fails below
+	data, err := s.cache.GetDel(ctx, fmt.Sprintf(codeKeyTemplate, req.Code))
+	if err != nil {
+		log.Info("authorization code not found", sl.Err(err))
+		return TokenResponse{}, svcErrs.ErrInvalidGrant
+	}
+
+	var rec codeRecord
+	if err = json.Unmarshal([]byte(data), &rec); err != nil {
+		log.Error("failed to decode authorization code", sl.Err(err))
+		return TokenResponse{}, svcErrs.ErrInvalidGrant
+	}
+
+	if rec.ClientId != req.ClientId || rec.RedirectURI != req.RedirectURI {
+		log.Warn("authorization code presented by another client or with another redirect_uri",
+			sl.SecurityEvent("authorization_code_misuse"),
+			slog.String("client_id", req.ClientId),
+		)
+		return TokenResponse{}, svcErrs.ErrInvalidGrant
+	}
+
+	if !verifyCodeChallenge(req.CodeVerifier, rec.CodeChallenge) {
+		log.Warn("code_verifier does not match the code_challenge",
+			sl.SecurityEvent("pkce_verification_failed"),
+			slog.String("client_id", req.ClientId),
+		)
+		return TokenResponse{}, svcErrs.ErrInvalidGrant
+	}
+
+	user := entity.User{Id: rec.UserId, Email: rec.Email, EmailVerifiedAt: rec.EmailVerifiedAt}
+
+	tokens, err := s.auth.IssueTokens(ctx, user, auth.IssueTokensInput{
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+		Nonce:     rec.Nonce,
+		Client:    &client,
+		Scope:     rec.Scope,
+		AuthTime:  rec.AuthTime,
+	})
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return s.tokenResponse(client, tokens), nil
+}
+
+// refreshToken rotates a refresh token issued to the client (RFC 6749, section 6).
+func (s *Service) refreshToken(ctx context.Context, client entity.Client, token string) (TokenResponse, error) {
+	tokens, err := s.auth.RefreshClient(ctx, token, client.Id)
+	if err != nil {
+		// invalid, expired, reused and other clients' tokens are all an invalid grant to the client
+		if errors.Is(err, svcErrs.ErrCannotParseToken) || errors.Is(err, svcErrs.ErrTokenIsExpired) ||
+			errors.Is(err, svcErrs.ErrRefreshTokenReused) || errors.Is(err, svcErrs.ErrTokenNotIssuedToClient) {
+			return TokenResponse{}, svcErrs.ErrInvalidGrant
+		}
+
+		return TokenResponse{}, err
+	}
+
+	return s.tokenResponse(client, tokens), nil
+}
+
+// tokenResponse returns the tokens of a user session, the refresh token only to clients allowed to use it.
+func (s *Service) tokenResponse(client entity.Client, tokens auth.GenerateTokenOutput) TokenResponse {
+	res := TokenResponse{
+		AccessToken: tokens.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   s.expiresIn(client),
+		IdToken:     tokens.IdToken,
+		Scope:       tokens.Scope,
+	}
+
+	if client.AllowsGrantType(GrantTypeRefreshToken) {
+		res.RefreshToken = tokens.RefreshToken
+	}
+
+	return res
+}
+
+// clientCredentials issues an access token to the client on its own behalf (RFC 6749, section 4.4).
+// Without a requested scope the client gets all the scopes it is registered for.
+func (s *Service) clientCredentials(ctx context.Context, client entity.Client, scope string) (TokenResponse, error) {
+	// only a client that proved its identity can act as itself
+	if !client.IsConfidential() {
+		return TokenResponse{}, svcErrs.ErrUnauthorizedClient
+	}
+
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+
+	for _, sc := range scopes {
+		if !client.AllowsScope(sc) {
+			return TokenResponse{}, svcErrs.ErrInvalidScope
+		}
+	}
+
+	granted := strings.Join(scopes, " ")
+
+	token, err := s.auth.IssueServiceToken(ctx, client, granted)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   s.expiresIn(client),
+		Scope:       granted,
+	}, nil
+}
+
+// Introspect describes a token to a confidential client, e.g. an API gateway (RFC 7662).
+func (s *Service) Introspect(ctx context.Context, req IntrospectionRequest) (auth.TokenInfo, error) {
+	client, err := s.authenticateClient(ctx, req.ClientId, req.ClientSecret)
+	if err != nil {
+		return auth.TokenInfo{}, err
+	}
+
+	// a public client could probe any token it got hold of
+	if !client.IsConfidential() {
+		return auth.TokenInfo{}, svcErrs.ErrInvalidClientCredentials
+	}
+
+	return s.auth.IntrospectToken(ctx, req.Token, req.TokenTypeHint)
+}
+
+// Revoke revokes a token on request of the client it was issued to (RFC 7009).
+// Public clients may revoke their tokens too, they are identified by client_id alone.
+func (s *Service) Revoke(ctx context.Context, req RevocationRequest) error {
+	client, err := s.authenticateClient(ctx, req.ClientId, req.ClientSecret)
+	if err != nil {
+		return err
+	}
+
+	return s.auth.RevokeToken(ctx, req.Token, req.TokenTypeHint, client.Id)
+}
+
+// expiresIn is the lifetime of the access tokens issued to the client.
+func (s *Service) expiresIn(client entity.Client) time.Duration {
+	if client.AccessTokenTTL != 0 {
+		return client.AccessTokenTTL
+	}
+
+	return s.accessTokenTTL
+}
+
+func (s *Service) client(ctx context.Context, id string) (entity.Client, error) {
+	const op = "service.oauth.client"
+	log := s.log.With(slog.String("op", op))
+
+	client, err := s.clients.ClientById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return entity.Client{}, svcErrs.ErrInvalidClient
+		}
+
+		log.Error("failed to get client", sl.Err(err))
+		return entity.Client{}, svcErrs.ErrCannotGetClient
+	}
+
+	return client, nil
+}
+
+// authenticateClient checks the secret of a confidential client.
+// Public clients have no secret, the code_verifier binds the code to them instead.
+func (s *Service) authenticateClient(ctx context.Context, id, secret string) (entity.Client, error) {
+	const op = "service.oauth.authenticateClient"
+	log := s.log.With(slog.String("op", op))
+
+	client, err := s.client(ctx, id)
+	if err != nil {
+		return entity.Client{}, err
+	}
+
+	if !client.IsConfidential() {
+		return client, nil
+	}
+
+	if secret == "" || s.hasher.Compare(client.SecretHash, []byte(secret)) != nil {
+		log.Warn("client authentication failed",
+			sl.SecurityEvent("client_authentication_failed"),
+			slog.String("client_id", id),
+		)
+		return entity.Client{}, svcErrs.ErrInvalidClientCredentials
+	}
+
+	return client, nil
+}
+
+func newCode() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
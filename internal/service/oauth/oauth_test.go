@@ -0,0 +1,865 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/mocks/authmocks"
+	"github.com/bubalync/uni-auth/internal/mocks/oauthmocks"
+	"github.com/bubalync/uni-auth/internal/mocks/redismocks"
+	"github.com/bubalync/uni-auth/internal/mocks/repomocks"
+	"github.com/bubalync/uni-auth/internal/mocks/utilmocks"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"slices"
+	"testing"
+	"time"
+)
+
+const (
+	clientId     = "example-spa"
+	redirectURI  = "http://localhost:3000/callback"
+	codeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	codeTTL      = time.Minute
+	accessTTL    = 30 * time.Minute
+)
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func validRequest() AuthorizationRequest {
+	return AuthorizationRequest{
+		ResponseType:        ResponseTypeCode,
+		ClientId:            clientId,
+		RedirectURI:         redirectURI,
+		Scope:               "openid email",
+		State:               "af0ifjsldkj",
+		Nonce:               "n-0S6_WzA2Mj",
+		CodeChallenge:       codeChallenge(codeVerifier),
+		CodeChallengeMethod: CodeChallengeMethodS256,
+	}
+}
+
+func testClient() entity.Client {
+	return entity.Client{
+		Id:           clientId,
+		RedirectURIs: []string{redirectURI},
+		GrantTypes:   []string{GrantTypeAuthorizationCode},
+		Scopes:       []string{"openid", "email"},
+	}
+}
+
+func newService(
+	cache *redismocks.MockCache,
+	a *oauthmocks.MockAuthenticator,
+	clients *repomocks.MockClient,
+	h *utilmocks.MockPasswordHasher,
+) *Service {
+	return New(logger.New("local", "info"), cache, a, clients, h, codeTTL, accessTTL)
+}
+
+func TestOAuthService_ValidateAuthorizationRequest(t *testing.T) {
+	testCases := []struct {
+		name         string
+		modify       func(r *AuthorizationRequest)
+		modifyClient func(c *entity.Client)
+		clientErr    error
+		err          error
+	}{
+		{
+			name:   "OK",
+			modify: func(r *AuthorizationRequest) {},
+		},
+		{
+			name:      "unknown client",
+			modify:    func(r *AuthorizationRequest) {},
+			clientErr: repoErrs.ErrNotFound,
+			err:       svcErrs.ErrInvalidClient,
+		},
+		{
+			name:      "cannot get client",
+			modify:    func(r *AuthorizationRequest) {},
+			clientErr: errors.New("some error"),
+			err:       svcErrs.ErrCannotGetClient,
+		},
+		{
+			name:   "redirect uri is not registered",
+			modify: func(r *AuthorizationRequest) { r.RedirectURI = "http://localhost:3000/callback/other" },
+			err:    svcErrs.ErrInvalidRedirectURI,
+		},
+		{
+			name:   "unsupported response type",
+			modify: func(r *AuthorizationRequest) { r.ResponseType = "token" },
+			err:    svcErrs.ErrUnsupportedResponseType,
+		},
+		{
+			name:   "no code challenge",
+			modify: func(r *AuthorizationRequest) { r.CodeChallenge = "" },
+			err:    svcErrs.ErrPKCERequired,
+		},
+		{
+			name:   "plain code challenge method",
+			modify: func(r *AuthorizationRequest) { r.CodeChallengeMethod = "plain" },
+			err:    svcErrs.ErrPKCERequired,
+		},
+		{
+			name:   "unknown scope",
+			modify: func(r *AuthorizationRequest) { r.Scope = "openid admin" },
+			err:    svcErrs.ErrInvalidScope,
+		},
+		{
+			name:         "scope not allowed for the client",
+			modify:       func(r *AuthorizationRequest) {},
+			modifyClient: func(c *entity.Client) { c.Scopes = []string{"openid"} },
+			err:          svcErrs.ErrInvalidScope,
+		},
+		{
+			name:         "grant type not allowed for the client",
+			modify:       func(r *AuthorizationRequest) {},
+			modifyClient: func(c *entity.Client) { c.GrantTypes = nil },
+			err:          svcErrs.ErrUnauthorizedClient,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			client := testClient()
+			if tc.modifyClient != nil {
+				tc.modifyClient(&client)
+			}
+
+			clients := repomocks.NewMockClient(ctrl)
+			clients.EXPECT().ClientById(gomock.Any(), clientId).Return(client, tc.clientErr)
+
+			s := newService(redismocks.NewMockCache(ctrl), oauthmocks.NewMockAuthenticator(ctrl), clients, nil)
+
+			req := validRequest()
+			tc.modify(&req)
+
+			err := s.ValidateAuthorizationRequest(context.Background(), req)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestOAuthService_Authorize(t *testing.T) {
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	type MockBehavior func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				a.EXPECT().Authenticate(gomock.Any(), user.Email, "Qwerty!1", gomock.Any()).Return(user, nil)
+				a.EXPECT().VerifySecondFactor(gomock.Any(), user, "").Return(nil)
+				c.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), codeTTL).Return(nil)
+			},
+		},
+		{
+			name: "one-time code required",
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				a.EXPECT().Authenticate(gomock.Any(), user.Email, "Qwerty!1", gomock.Any()).Return(user, nil)
+				a.EXPECT().VerifySecondFactor(gomock.Any(), user, "").Return(svcErrs.ErrMFARequired)
+			},
+			err: svcErrs.ErrMFARequired,
+		},
+		{
+			name: "invalid credentials",
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				a.EXPECT().Authenticate(gomock.Any(), user.Email, "Qwerty!1", gomock.Any()).Return(entity.User{}, svcErrs.ErrInvalidCredentials)
+			},
+			err: svcErrs.ErrInvalidCredentials,
+		},
+		{
+			name: "cache error",
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				a.EXPECT().Authenticate(gomock.Any(), user.Email, "Qwerty!1", gomock.Any()).Return(user, nil)
+				a.EXPECT().VerifySecondFactor(gomock.Any(), user, "").Return(nil)
+				c.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), codeTTL).Return(errors.New("some error"))
+			},
+			err: svcErrs.ErrAccessToCache,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			authenticator := oauthmocks.NewMockAuthenticator(ctrl)
+			tc.mockBehavior(cache, authenticator)
+
+			clients := repomocks.NewMockClient(ctrl)
+			clients.EXPECT().ClientById(gomock.Any(), clientId).Return(testClient(), nil)
+
+			s := newService(cache, authenticator, clients, nil)
+
+			code, err := s.Authorize(context.Background(), AuthorizeInput{
+				Request:  validRequest(),
+				Email:    user.Email,
+				Password: "Qwerty!1",
+			})
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, code, 43)
+		})
+	}
+}
+
+// TestOAuthService_Authorize_WrongCodes guesses the second factor through Authorize with the real auth service, the
+// wrong codes must lock the account out like on the sign-in path.
+func TestOAuthService_Authorize_WrongCodes(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com", PasswordHash: []byte("hash")}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	counters := map[string]int64{}
+	cache := redismocks.NewMockCache(ctrl)
+	cache.EXPECT().Incr(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, key string, _ time.Duration) (int64, error) {
+			counters[key]++
+			return counters[key], nil
+		}).AnyTimes()
+	cache.EXPECT().TTL(gomock.Any(), gomock.Any()).Return(10*time.Minute, nil).AnyTimes()
+
+	users := repomocks.NewMockUser(ctrl)
+	users.EXPECT().UserByEmail(gomock.Any(), user.Email).Return(user, nil).AnyTimes()
+
+	h := utilmocks.NewMockPasswordHasher(ctrl)
+	h.EXPECT().Compare(user.PasswordHash, []byte("Qwerty!1")).Return(nil).AnyTimes()
+
+	secondFactor := authmocks.NewMockSecondFactor(ctrl)
+	secondFactor.EXPECT().Enabled(gomock.Any(), user.Id).Return(true, nil).AnyTimes()
+	secondFactor.EXPECT().Verify(gomock.Any(), user.Id, "000000").Return(svcErrs.ErrInvalidOTP).Times(5)
+
+	var locked bool
+	lockout := authmocks.NewMockLockout(ctrl)
+	lockout.EXPECT().Check(gomock.Any(), user.Email, gomock.Any()).DoAndReturn(func(context.Context, string, string) error {
+		if locked {
+			return &svcErrs.RetryAfterError{Err: svcErrs.ErrAccountLocked, RetryAfter: time.Minute}
+		}
+		return nil
+	}).AnyTimes()
+	lockout.EXPECT().Reset(gomock.Any(), user.Email).AnyTimes()
+	lockout.EXPECT().LockAccount(gomock.Any(), user.Email).DoAndReturn(func(context.Context, string) error {
+		locked = true
+		return &svcErrs.RetryAfterError{Err: svcErrs.ErrAccountLocked, RetryAfter: time.Minute}
+	})
+
+	a := auth.New(logger.New("local", "info"), cache, users, h, nil, nil, time.Hour, "", auth.EmailVerificationConfig{}, auth.PasswordlessConfig{}, nil, secondFactor, nil, lockout, nil)
+
+	clients := repomocks.NewMockClient(ctrl)
+	clients.EXPECT().ClientById(gomock.Any(), clientId).Return(testClient(), nil).AnyTimes()
+
+	s := New(logger.New("local", "info"), cache, a, clients, nil, codeTTL, accessTTL)
+
+	for i := range 20 {
+		_, err := s.Authorize(ctx, AuthorizeInput{
+			Request:  validRequest(),
+			Email:    user.Email,
+			Password: "Qwerty!1",
+			OTP:      "000000",
+		})
+		switch {
+		case i < 4:
+			assert.ErrorIs(t, err, svcErrs.ErrInvalidOTP)
+		default:
+			assert.ErrorIs(t, err, svcErrs.ErrAccountLocked)
+		}
+	}
+
+	// the counter outlives the account lockout
+	locked = false
+	_, err := s.Authorize(ctx, AuthorizeInput{
+		Request:  validRequest(),
+		Email:    user.Email,
+		Password: "Qwerty!1",
+		OTP:      "000000",
+	})
+	assert.ErrorIs(t, err, svcErrs.ErrTooManySignInAttempts)
+}
+
+func TestOAuthService_Authorize_EmailNotVerified(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com", PasswordHash: []byte("hash")}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// no code is saved
+	cache := redismocks.NewMockCache(ctrl)
+
+	users := repomocks.NewMockUser(ctrl)
+	users.EXPECT().UserByEmail(gomock.Any(), user.Email).Return(user, nil)
+
+	h := utilmocks.NewMockPasswordHasher(ctrl)
+	h.EXPECT().Compare(user.PasswordHash, []byte("Qwerty!1")).Return(nil)
+
+	verification := auth.EmailVerificationConfig{Required: true}
+	a := auth.New(logger.New("local", "info"), cache, users, h, nil, nil, time.Hour, "", verification, auth.PasswordlessConfig{}, nil, nil, nil, nil, nil)
+
+	clients := repomocks.NewMockClient(ctrl)
+	clients.EXPECT().ClientById(gomock.Any(), clientId).Return(testClient(), nil)
+
+	s := New(logger.New("local", "info"), cache, a, clients, nil, codeTTL, accessTTL)
+
+	code, err := s.Authorize(ctx, AuthorizeInput{
+		Request:  validRequest(),
+		Email:    user.Email,
+		Password: "Qwerty!1",
+	})
+	assert.ErrorIs(t, err, svcErrs.ErrEmailNotVerified)
+	assert.Empty(t, code)
+}
+
+func TestOAuthService_Token(t *testing.T) {
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	// authorize once to get a code and its cached state
+	ctrl := gomock.NewController(t)
+	cache := redismocks.NewMockCache(ctrl)
+	authenticator := oauthmocks.NewMockAuthenticator(ctrl)
+	clients := repomocks.NewMockClient(ctrl)
+
+	var key, record string
+	clients.EXPECT().ClientById(gomock.Any(), clientId).Return(testClient(), nil)
+	authenticator.EXPECT().Authenticate(gomock.Any(), user.Email, "Qwerty!1", gomock.Any()).Return(user, nil)
+	authenticator.EXPECT().VerifySecondFactor(gomock.Any(), user, "").Return(nil)
+	cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), codeTTL).
+		DoAndReturn(func(_ context.Context, k string, v string, _ time.Duration) error {
+			key, record = k, v
+			return nil
+		})
+
+	code, err := newService(cache, authenticator, clients, nil).Authorize(context.Background(), AuthorizeInput{
+		Request:  validRequest(),
+		Email:    user.Email,
+		Password: "Qwerty!1",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "oauth_code:"+code, key)
+	ctrl.Finish()
+
+	validToken := func() TokenRequest {
+		return TokenRequest{
+			GrantType:    GrantTypeAuthorizationCode,
+			ClientId:     clientId,
+			Code:         code,
+			RedirectURI:  redirectURI,
+			CodeVerifier: codeVerifier,
+		}
+	}
+
+	type MockBehavior func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator)
+
+	refreshing := testClient()
+	refreshing.GrantTypes = []string{GrantTypeAuthorizationCode, GrantTypeRefreshToken}
+
+	confidential := refreshing
+	confidential.SecretHash = []byte("hash")
+	confidential.AccessTokenTTL = 5 * time.Minute
+
+	refreshToken := func(r *TokenRequest) {
+		*r = TokenRequest{GrantType: GrantTypeRefreshToken, ClientId: clientId, RefreshToken: "refresh_token"}
+	}
+
+	testCases := []struct {
+		name         string
+		modify       func(r *TokenRequest)
+		client       entity.Client
+		clientErr    error
+		mockBehavior MockBehavior
+		want         TokenResponse
+		err          error
+	}{
+		{
+			name:   "OK",
+			modify: func(r *TokenRequest) {},
+			client: refreshing,
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				c.EXPECT().GetDel(gomock.Any(), key).Return(record, nil)
+				a.EXPECT().IssueTokens(gomock.Any(), user, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ entity.User, input auth.IssueTokensInput) (auth.GenerateTokenOutput, error) {
+						assert.Equal(t, clientId, input.Client.Id)
+						assert.Equal(t, "openid email", input.Scope)
+						assert.Equal(t, "n-0S6_WzA2Mj", input.Nonce)
+						assert.False(t, input.AuthTime.IsZero())
+						return auth.GenerateTokenOutput{
+							AccessToken:  "access_token",
+							RefreshToken: "refresh_token",
+							IdToken:      "id_token",
+							Scope:        input.Scope,
+						}, nil
+					})
+			},
+			want: TokenResponse{
+				AccessToken:  "access_token",
+				TokenType:    "Bearer",
+				ExpiresIn:    accessTTL,
+				RefreshToken: "refresh_token",
+				IdToken:      "id_token",
+				Scope:        "openid email",
+			},
+		},
+		{
+			name:         "unsupported grant type",
+			modify:       func(r *TokenRequest) { r.GrantType = "password" },
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {},
+			err:          svcErrs.ErrUnsupportedGrantType,
+		},
+		{
+			name:   "confidential client",
+			modify: func(r *TokenRequest) { r.ClientSecret = "secret" },
+			client: confidential,
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				c.EXPECT().GetDel(gomock.Any(), key).Return(record, nil)
+				a.EXPECT().IssueTokens(gomock.Any(), user, gomock.Any()).
+					Return(auth.GenerateTokenOutput{AccessToken: "access_token", RefreshToken: "refresh_token"}, nil)
+			},
+			want: TokenResponse{
+				AccessToken:  "access_token",
+				TokenType:    "Bearer",
+				ExpiresIn:    5 * time.Minute,
+				RefreshToken: "refresh_token",
+			},
+		},
+		{
+			name:         "confidential client without a secret",
+			modify:       func(r *TokenRequest) {},
+			client:       confidential,
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {},
+			err:          svcErrs.ErrInvalidClientCredentials,
+		},
+		{
+			name:         "confidential client with a wrong secret",
+			modify:       func(r *TokenRequest) { r.ClientSecret = "wrong" },
+			client:       confidential,
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {},
+			err:          svcErrs.ErrInvalidClientCredentials,
+		},
+		{
+			name:         "unknown client",
+			modify:       func(r *TokenRequest) {},
+			clientErr:    repoErrs.ErrNotFound,
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {},
+			err:          svcErrs.ErrInvalidClient,
+		},
+		{
+			name:         "grant type not allowed for the client",
+			modify:       func(r *TokenRequest) {},
+			client:       entity.Client{Id: clientId, RedirectURIs: []string{redirectURI}},
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {},
+			err:          svcErrs.ErrUnauthorizedClient,
+		},
+		{
+			name:   "code already used or expired",
+			modify: func(r *TokenRequest) {},
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				c.EXPECT().GetDel(gomock.Any(), key).Return("", errors.New("redis: nil"))
+			},
+			err: svcErrs.ErrInvalidGrant,
+		},
+		{
+			name:   "another redirect uri",
+			modify: func(r *TokenRequest) { r.RedirectURI = "http://localhost:3000/other" },
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				c.EXPECT().GetDel(gomock.Any(), key).Return(record, nil)
+			},
+			err: svcErrs.ErrInvalidGrant,
+		},
+		{
+			name:   "wrong code verifier",
+			modify: func(r *TokenRequest) { r.CodeVerifier = "Ow8GU6s6GDlUfQhyWaQ2M7mdv2zNdbCTi8yXH9Ja2mY" },
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				c.EXPECT().GetDel(gomock.Any(), key).Return(record, nil)
+			},
+			err: svcErrs.ErrInvalidGrant,
+		},
+		{
+			name:   "no code verifier",
+			modify: func(r *TokenRequest) { r.CodeVerifier = "" },
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				c.EXPECT().GetDel(gomock.Any(), key).Return(record, nil)
+			},
+			err: svcErrs.ErrInvalidGrant,
+		},
+		{
+			name:   "no refresh token without the refresh_token grant",
+			modify: func(r *TokenRequest) {},
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				c.EXPECT().GetDel(gomock.Any(), key).Return(record, nil)
+				a.EXPECT().IssueTokens(gomock.Any(), user, gomock.Any()).
+					Return(auth.GenerateTokenOutput{AccessToken: "access_token", RefreshToken: "refresh_token", Scope: "openid"}, nil)
+			},
+			want: TokenResponse{
+				AccessToken: "access_token",
+				TokenType:   "Bearer",
+				ExpiresIn:   accessTTL,
+				Scope:       "openid",
+			},
+		},
+		{
+			name:   "refresh token",
+			modify: refreshToken,
+			client: refreshing,
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				a.EXPECT().RefreshClient(gomock.Any(), "refresh_token", clientId).
+					Return(auth.GenerateTokenOutput{AccessToken: "access_token", RefreshToken: "next_refresh_token", IdToken: "id_token", Scope: "openid email"}, nil)
+			},
+			want: TokenResponse{
+				AccessToken:  "access_token",
+				TokenType:    "Bearer",
+				ExpiresIn:    accessTTL,
+				RefreshToken: "next_refresh_token",
+				IdToken:      "id_token",
+				Scope:        "openid email",
+			},
+		},
+		{
+			name:   "refresh token of another client",
+			modify: refreshToken,
+			client: refreshing,
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				a.EXPECT().RefreshClient(gomock.Any(), "refresh_token", clientId).Return(auth.GenerateTokenOutput{}, svcErrs.ErrTokenNotIssuedToClient)
+			},
+			err: svcErrs.ErrInvalidGrant,
+		},
+		{
+			name:   "reused refresh token",
+			modify: refreshToken,
+			client: refreshing,
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				a.EXPECT().RefreshClient(gomock.Any(), "refresh_token", clientId).Return(auth.GenerateTokenOutput{}, svcErrs.ErrRefreshTokenReused)
+			},
+			err: svcErrs.ErrInvalidGrant,
+		},
+		{
+			name:         "refresh_token grant not allowed for the client",
+			modify:       refreshToken,
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {},
+			err:          svcErrs.ErrUnauthorizedClient,
+		},
+		{
+			name:   "issue tokens error",
+			modify: func(r *TokenRequest) {},
+			mockBehavior: func(c *redismocks.MockCache, a *oauthmocks.MockAuthenticator) {
+				c.EXPECT().GetDel(gomock.Any(), key).Return(record, nil)
+				a.EXPECT().IssueTokens(gomock.Any(), user, gomock.Any()).Return(auth.GenerateTokenOutput{}, svcErrs.ErrAccessToCache)
+			},
+			err: svcErrs.ErrAccessToCache,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			authenticator := oauthmocks.NewMockAuthenticator(ctrl)
+			tc.mockBehavior(cache, authenticator)
+
+			req := validToken()
+			tc.modify(&req)
+
+			client := tc.client
+			if client.Id == "" {
+				client = testClient()
+			}
+
+			clients := repomocks.NewMockClient(ctrl)
+			if slices.Contains(SupportedGrantTypes, req.GrantType) {
+				clients.EXPECT().ClientById(gomock.Any(), clientId).Return(client, tc.clientErr)
+			}
+
+			h := utilmocks.NewMockPasswordHasher(ctrl)
+			if client.IsConfidential() && req.ClientSecret != "" {
+				var compareErr error
+				if req.ClientSecret != "secret" {
+					compareErr = errors.New("mismatch")
+				}
+				h.EXPECT().Compare(client.SecretHash, []byte(req.ClientSecret)).Return(compareErr)
+			}
+
+			got, err := newService(cache, authenticator, clients, h).Token(context.Background(), req)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// RFC 7636, appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", codeChallenge(codeVerifier))
+	assert.True(t, verifyCodeChallenge(codeVerifier, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"))
+
+	assert.False(t, verifyCodeChallenge(codeVerifier[:42], codeChallenge(codeVerifier[:42])), "verifier too short")
+	assert.False(t, verifyCodeChallenge(codeVerifier+"+", codeChallenge(codeVerifier+"+")), "invalid character")
+	assert.False(t, validCodeChallenge("E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw"), "challenge too short")
+}
+
+func TestOAuthService_ClientCredentials(t *testing.T) {
+	backend := entity.Client{
+		Id:             "billing",
+		SecretHash:     []byte("hash"),
+		GrantTypes:     []string{GrantTypeClientCredentials},
+		Scopes:         []string{"orders:read", "orders:write"},
+		Audience:       "https://api.example.com",
+		AccessTokenTTL: 5 * time.Minute,
+	}
+
+	type MockBehavior func(a *oauthmocks.MockAuthenticator)
+
+	testCases := []struct {
+		name         string
+		client       entity.Client
+		scope        string
+		mockBehavior MockBehavior
+		want         TokenResponse
+		err          error
+	}{
+		{
+			name:   "OK",
+			client: backend,
+			scope:  "orders:read",
+			mockBehavior: func(a *oauthmocks.MockAuthenticator) {
+				a.EXPECT().IssueServiceToken(gomock.Any(), backend, "orders:read").Return("service_token", nil)
+			},
+			want: TokenResponse{
+				AccessToken: "service_token",
+				TokenType:   "Bearer",
+				ExpiresIn:   5 * time.Minute,
+				Scope:       "orders:read",
+			},
+		},
+		{
+			name:   "all scopes of the client by default",
+			client: backend,
+			mockBehavior: func(a *oauthmocks.MockAuthenticator) {
+				a.EXPECT().IssueServiceToken(gomock.Any(), backend, "orders:read orders:write").Return("service_token", nil)
+			},
+			want: TokenResponse{
+				AccessToken: "service_token",
+				TokenType:   "Bearer",
+				ExpiresIn:   5 * time.Minute,
+				Scope:       "orders:read orders:write",
+			},
+		},
+		{
+			name:         "scope not allowed for the client",
+			client:       backend,
+			scope:        "orders:read users:write",
+			mockBehavior: func(a *oauthmocks.MockAuthenticator) {},
+			err:          svcErrs.ErrInvalidScope,
+		},
+		{
+			name:         "grant type not allowed for the client",
+			client:       testClient(),
+			mockBehavior: func(a *oauthmocks.MockAuthenticator) {},
+			err:          svcErrs.ErrUnauthorizedClient,
+		},
+		{
+			name: "public client",
+			client: entity.Client{
+				Id:         "billing",
+				GrantTypes: []string{GrantTypeClientCredentials},
+			},
+			mockBehavior: func(a *oauthmocks.MockAuthenticator) {},
+			err:          svcErrs.ErrUnauthorizedClient,
+		},
+		{
+			name:   "issue token error",
+			client: backend,
+			mockBehavior: func(a *oauthmocks.MockAuthenticator) {
+				a.EXPECT().IssueServiceToken(gomock.Any(), backend, gomock.Any()).Return("", svcErrs.ErrCannotSignToken)
+			},
+			err: svcErrs.ErrCannotSignToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			authenticator := oauthmocks.NewMockAuthenticator(ctrl)
+			tc.mockBehavior(authenticator)
+
+			clients := repomocks.NewMockClient(ctrl)
+			clients.EXPECT().ClientById(gomock.Any(), tc.client.Id).Return(tc.client, nil)
+
+			h := utilmocks.NewMockPasswordHasher(ctrl)
+			if tc.client.IsConfidential() {
+				h.EXPECT().Compare(tc.client.SecretHash, []byte("secret")).Return(nil)
+			}
+
+			got, err := newService(redismocks.NewMockCache(ctrl), authenticator, clients, h).Token(context.Background(), TokenRequest{
+				GrantType:    GrantTypeClientCredentials,
+				ClientId:     tc.client.Id,
+				ClientSecret: "secret",
+				Scope:        tc.scope,
+			})
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestOAuthService_Introspect(t *testing.T) {
+	gateway := entity.Client{
+		Id:         "gateway",
+		SecretHash: []byte("hash"),
+		GrantTypes: []string{GrantTypeClientCredentials},
+	}
+
+	type MockBehavior func(a *oauthmocks.MockAuthenticator, h *utilmocks.MockPasswordHasher)
+
+	testCases := []struct {
+		name         string
+		client       entity.Client
+		mockBehavior MockBehavior
+		want         auth.TokenInfo
+		err          error
+	}{
+		{
+			name:   "OK",
+			client: gateway,
+			mockBehavior: func(a *oauthmocks.MockAuthenticator, h *utilmocks.MockPasswordHasher) {
+				h.EXPECT().Compare(gateway.SecretHash, []byte("secret")).Return(nil)
+				a.EXPECT().IntrospectToken(gomock.Any(), "token", auth.TokenTypeAccessToken).
+					Return(auth.TokenInfo{Active: true, Subject: "billing"}, nil)
+			},
+			want: auth.TokenInfo{Active: true, Subject: "billing"},
+		},
+		{
+			name:   "wrong secret",
+			client: gateway,
+			mockBehavior: func(a *oauthmocks.MockAuthenticator, h *utilmocks.MockPasswordHasher) {
+				h.EXPECT().Compare(gateway.SecretHash, []byte("secret")).Return(errors.New("mismatch"))
+			},
+			err: svcErrs.ErrInvalidClientCredentials,
+		},
+		{
+			name:         "public client",
+			client:       testClient(),
+			mockBehavior: func(a *oauthmocks.MockAuthenticator, h *utilmocks.MockPasswordHasher) {},
+			err:          svcErrs.ErrInvalidClientCredentials,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			authenticator := oauthmocks.NewMockAuthenticator(ctrl)
+			h := utilmocks.NewMockPasswordHasher(ctrl)
+			tc.mockBehavior(authenticator, h)
+
+			clients := repomocks.NewMockClient(ctrl)
+			clients.EXPECT().ClientById(gomock.Any(), tc.client.Id).Return(tc.client, nil)
+
+			got, err := newService(redismocks.NewMockCache(ctrl), authenticator, clients, h).Introspect(context.Background(), IntrospectionRequest{
+				ClientId:      tc.client.Id,
+				ClientSecret:  "secret",
+				Token:         "token",
+				TokenTypeHint: auth.TokenTypeAccessToken,
+			})
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestOAuthService_Revoke(t *testing.T) {
+	type MockBehavior func(a *oauthmocks.MockAuthenticator)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "public client",
+			mockBehavior: func(a *oauthmocks.MockAuthenticator) {
+				a.EXPECT().RevokeToken(gomock.Any(), "token", auth.TokenTypeRefreshToken, testClient().Id).Return(nil)
+			},
+		},
+		{
+			name: "token of another client",
+			mockBehavior: func(a *oauthmocks.MockAuthenticator) {
+				a.EXPECT().RevokeToken(gomock.Any(), "token", auth.TokenTypeRefreshToken, testClient().Id).
+					Return(svcErrs.ErrTokenNotIssuedToClient)
+			},
+			err: svcErrs.ErrTokenNotIssuedToClient,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			authenticator := oauthmocks.NewMockAuthenticator(ctrl)
+			tc.mockBehavior(authenticator)
+
+			clients := repomocks.NewMockClient(ctrl)
+			clients.EXPECT().ClientById(gomock.Any(), testClient().Id).Return(testClient(), nil)
+
+			err := newService(redismocks.NewMockCache(ctrl), authenticator, clients, nil).Revoke(context.Background(), RevocationRequest{
+				ClientId:      testClient().Id,
+				Token:         "token",
+				TokenTypeHint: auth.TokenTypeRefreshToken,
+			})
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package otp
+
+import "time"
+
+// Purpose separates the codes of different flows, a code is accepted only for the purpose it was sent for.
+type Purpose string
+
+const (
+	PurposeSignIn      Purpose = "sign_in"
+	PurposeVerifyEmail Purpose = "verify_email"
+	PurposeStepUp      Purpose = "step_up"
+	PurposeVerifyPhone Purpose = "verify_phone"
+	// PurposeSecondFactor is the code texted to the phone of a user who signs in with the SMS second factor.
+	PurposeSecondFactor Purpose = "second_factor"
+	// PurposeRecovery is the code texted to a verified phone to reset a forgotten password.
+	PurposeRecovery Purpose = "recovery"
+)
+
+type (
+	Config struct {
+		// Digits is the length of the codes.
+		Digits int
+		// TTL is the lifetime of a code.
+		TTL time.Duration
+		// MaxAttempts is the number of wrong codes after which the subject is locked out.
+		MaxAttempts int
+		// Lockout is how long no codes are sent to or accepted from a locked out subject.
+		Lockout time.Duration
+		// ResendInterval is the least time between two codes for a subject.
+		ResendInterval time.Duration
+	}
+)
@@ -0,0 +1,247 @@
+package otp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/lib/email"
+	"github.com/bubalync/uni-auth/internal/lib/sms"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/bubalync/uni-auth/pkg/redis"
+	"log/slog"
+	"math/big"
+	"time"
+)
+
+const (
+	codeKeyTemplate     = "otp:%s:%s"
+	sentKeyTemplate     = "otp_sent:%s:%s"
+	lockoutKeyTemplate  = "otp_lockout:%s:%s"
+	attemptsKeyTemplate = "otp_attempts:%s:%s"
+)
+
+// codeRecord is the cached state of a code, only the hash of the code is kept.
+type codeRecord struct {
+	Hash      []byte    `json:"hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Service sends short numeric one-time codes and checks them. A code belongs to a purpose and a subject,
+// e.g. the email of a passwordless sign-in or the id of a user, and the last code sent is the only one accepted.
+type Service struct {
+	log         *slog.Logger
+	cache       redis.Cache
+	emailSender email.Sender
+	smsSender   sms.Sender
+	cfg         Config
+}
+
+// New -.
+func New(log *slog.Logger, cache redis.Cache, emailSender email.Sender, smsSender sms.Sender, cfg Config) *Service {
+	return &Service{
+		log:         log,
+		cache:       cache,
+		emailSender: emailSender,
+		smsSender:   smsSender,
+		cfg:         cfg,
+	}
+}
+
+// SendEmail emails a new code for the purpose and subject to the address.
+func (s *Service) SendEmail(ctx context.Context, purpose Purpose, subject, to string) error {
+	const op = "service.otp.SendEmail"
+	log := s.log.With(slog.String("op", op))
+
+	code, err := s.issue(ctx, log, purpose, subject)
+	if err != nil {
+		return err
+	}
+
+	if err = s.emailSender.SendOTPEmail(to, code); err != nil {
+		log.Error("failed to send the one-time code email", sl.Err(err))
+		return svcErrs.ErrSendOTP
+	}
+
+	return nil
+}
+
+// SendSMS texts a new code for the purpose and subject to the phone.
+func (s *Service) SendSMS(ctx context.Context, purpose Purpose, subject, to string) error {
+	const op = "service.otp.SendSMS"
+	log := s.log.With(slog.String("op", op))
+
+	code, err := s.issue(ctx, log, purpose, subject)
+	if err != nil {
+		return err
+	}
+
+	if err = s.smsSender.SendOTP(to, code); err != nil {
+		log.Error("failed to send the one-time code sms", sl.Err(err))
+		return svcErrs.ErrSendOTP
+	}
+
+	return nil
+}
+
+// Verify checks the code of the purpose and subject, every code works once.
+// After MaxAttempts wrong codes the code is dropped and the subject is locked out. The wrong codes
+// are counted across the codes sent within TTL, requesting a new code does not bring new attempts.
+func (s *Service) Verify(ctx context.Context, purpose Purpose, subject, code string) error {
+	const op = "service.otp.Verify"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.checkLockout(ctx, log, purpose, subject); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf(codeKeyTemplate, purpose, subject)
+
+	data, err := s.cache.Get(ctx, key)
+	if err != nil {
+		return svcErrs.ErrInvalidOTP
+	}
+
+	var record codeRecord
+	if err = json.Unmarshal([]byte(data), &record); err != nil {
+		log.Error("failed to unmarshal one-time code", sl.Err(err))
+		return svcErrs.ErrInvalidOTP
+	}
+
+	if time.Until(record.ExpiresAt) <= 0 {
+		return svcErrs.ErrInvalidOTP
+	}
+
+	// the attempt is counted before the code is compared, so concurrent guesses cannot get past MaxAttempts.
+	// The counter belongs to the subject, not to the code, and only a right code resets it.
+	attemptsKey := fmt.Sprintf(attemptsKeyTemplate, purpose, subject)
+
+	attempts, err := s.cache.Incr(ctx, attemptsKey, s.cfg.TTL)
+	if err != nil {
+		log.Error("failed to count one-time code attempts", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	if attempts > int64(s.cfg.MaxAttempts) {
+		return s.lock(ctx, log, purpose, subject)
+	}
+
+	if subtle.ConstantTimeCompare(hashCode(purpose, subject, code), record.Hash) != 1 {
+		if attempts == int64(s.cfg.MaxAttempts) {
+			return s.lock(ctx, log, purpose, subject)
+		}
+
+		return svcErrs.ErrInvalidOTP
+	}
+
+	// a concurrent check that deleted the code first wins
+	if _, err = s.cache.GetDel(ctx, key); err != nil {
+		return svcErrs.ErrInvalidOTP
+	}
+
+	if err = s.cache.Delete(ctx, attemptsKey); err != nil {
+		log.Error("failed to delete one-time code attempts", sl.Err(err))
+	}
+
+	return nil
+}
+
+// issue generates and saves a new code, it replaces the previous code of the subject.
+func (s *Service) issue(ctx context.Context, log *slog.Logger, purpose Purpose, subject string) (string, error) {
+	if err := s.checkLockout(ctx, log, purpose, subject); err != nil {
+		return "", err
+	}
+
+	sentKey := fmt.Sprintf(sentKeyTemplate, purpose, subject)
+
+	sent, err := s.cache.Exists(ctx, sentKey)
+	if err != nil {
+		log.Error("failed to check the one-time code throttle", sl.Err(err))
+		return "", svcErrs.ErrAccessToCache
+	}
+
+	if sent {
+		return "", svcErrs.ErrOTPThrottled
+	}
+
+	code, err := s.generate()
+	if err != nil {
+		log.Error("failed to generate one-time code", sl.Err(err))
+		return "", svcErrs.ErrSendOTP
+	}
+
+	data, err := json.Marshal(codeRecord{
+		Hash:      hashCode(purpose, subject, code),
+		ExpiresAt: time.Now().Add(s.cfg.TTL),
+	})
+	if err != nil {
+		log.Error("failed to marshal one-time code", sl.Err(err))
+		return "", svcErrs.ErrSendOTP
+	}
+
+	// the attempts are left as they are, a new code must not bring new guesses
+	if err = s.cache.Set(ctx, fmt.Sprintf(codeKeyTemplate, purpose, subject), string(data), s.cfg.TTL); err != nil {
+		log.Error("failed to save one-time code to cache", sl.Err(err))
+		return "", svcErrs.ErrAccessToCache
+	}
+
+	if err = s.cache.Set(ctx, sentKey, "1", s.cfg.ResendInterval); err != nil {
+		log.Error("failed to save the one-time code throttle", sl.Err(err))
+		return "", svcErrs.ErrAccessToCache
+	}
+
+	return code, nil
+}
+
+// lock drops the code and locks the subject out once MaxAttempts codes were tried.
+func (s *Service) lock(ctx context.Context, log *slog.Logger, purpose Purpose, subject string) error {
+	if err := s.cache.Delete(ctx, fmt.Sprintf(codeKeyTemplate, purpose, subject)); err != nil {
+		log.Error("failed to delete one-time code", sl.Err(err))
+	}
+
+	log.Warn("too many wrong one-time codes, locking out",
+		sl.SecurityEvent("otp_attempts_exceeded"),
+		slog.String("purpose", string(purpose)),
+	)
+
+	if err := s.cache.Set(ctx, fmt.Sprintf(lockoutKeyTemplate, purpose, subject), "1", s.cfg.Lockout); err != nil {
+		log.Error("failed to save one-time code lockout", sl.Err(err))
+	}
+
+	return svcErrs.ErrOTPLocked
+}
+
+func (s *Service) checkLockout(ctx context.Context, log *slog.Logger, purpose Purpose, subject string) error {
+	locked, err := s.cache.Exists(ctx, fmt.Sprintf(lockoutKeyTemplate, purpose, subject))
+	if err != nil {
+		log.Error("failed to check one-time code lockout", sl.Err(err))
+		return svcErrs.ErrAccessToCache
+	}
+
+	if locked {
+		return svcErrs.ErrOTPLocked
+	}
+
+	return nil
+}
+
+// generate returns a uniformly random code of cfg.Digits digits.
+func (s *Service) generate() (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(s.cfg.Digits)), nil)
+
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", s.cfg.Digits, n), nil
+}
+
+// hashCode binds the code to its purpose and subject, so a cached hash cannot be replayed for another one.
+func hashCode(purpose Purpose, subject, code string) []byte {
+	sum := sha256.Sum256([]byte(string(purpose) + ":" + subject + ":" + code))
+	return sum[:]
+}
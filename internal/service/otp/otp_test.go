@@ -0,0 +1,417 @@
+package otp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/mocks/redismocks"
+	"github.com/bubalync/uni-auth/internal/mocks/utilmocks"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+var cfg = Config{Digits: 6, TTL: 10 * time.Minute, MaxAttempts: 3, Lockout: 15 * time.Minute, ResendInterval: time.Minute}
+
+func TestService_SendEmail(t *testing.T) {
+	ctx := context.Background()
+
+	type MockBehavior func(c *redismocks.MockCache, e *utilmocks.MockSender)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(c *redismocks.MockCache, e *utilmocks.MockSender) {
+				var hash []byte
+				c.EXPECT().Exists(ctx, "otp_lockout:sign_in:test@example.com").Return(false, nil)
+				c.EXPECT().Exists(ctx, "otp_sent:sign_in:test@example.com").Return(false, nil)
+				c.EXPECT().Set(ctx, "otp:sign_in:test@example.com", gomock.Any(), 10*time.Minute).
+					DoAndReturn(func(_ context.Context, _ string, value string, _ time.Duration) error {
+						var record codeRecord
+						assert.NoError(t, json.Unmarshal([]byte(value), &record))
+						assert.WithinDuration(t, time.Now().Add(10*time.Minute), record.ExpiresAt, time.Second)
+						hash = record.Hash
+						return nil
+					})
+				c.EXPECT().Set(ctx, "otp_sent:sign_in:test@example.com", "1", time.Minute).Return(nil)
+				e.EXPECT().SendOTPEmail("Test@example.com", gomock.Any()).
+					DoAndReturn(func(_ string, code string) error {
+						assert.Regexp(t, "^[0-9]{6}$", code)
+						assert.Equal(t, hashCode(PurposeSignIn, "test@example.com", code), hash)
+						return nil
+					})
+			},
+		},
+		{
+			name: "sent recently",
+			mockBehavior: func(c *redismocks.MockCache, e *utilmocks.MockSender) {
+				c.EXPECT().Exists(ctx, "otp_lockout:sign_in:test@example.com").Return(false, nil)
+				c.EXPECT().Exists(ctx, "otp_sent:sign_in:test@example.com").Return(true, nil)
+			},
+			wantErr: svcErrs.ErrOTPThrottled,
+		},
+		{
+			name: "locked out",
+			mockBehavior: func(c *redismocks.MockCache, e *utilmocks.MockSender) {
+				c.EXPECT().Exists(ctx, "otp_lockout:sign_in:test@example.com").Return(true, nil)
+			},
+			wantErr: svcErrs.ErrOTPLocked,
+		},
+		{
+			name: "cache error",
+			mockBehavior: func(c *redismocks.MockCache, e *utilmocks.MockSender) {
+				c.EXPECT().Exists(ctx, "otp_lockout:sign_in:test@example.com").Return(false, errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrAccessToCache,
+		},
+		{
+			name: "send error",
+			mockBehavior: func(c *redismocks.MockCache, e *utilmocks.MockSender) {
+				c.EXPECT().Exists(ctx, gomock.Any()).Return(false, nil).Times(2)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+				e.EXPECT().SendOTPEmail("Test@example.com", gomock.Any()).Return(errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrSendOTP,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			sender := utilmocks.NewMockSender(ctrl)
+			tc.mockBehavior(cache, sender)
+
+			s := New(logger.New("local", "info"), cache, sender, nil, cfg)
+
+			err := s.SendEmail(ctx, PurposeSignIn, "test@example.com", "Test@example.com")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_SendSMS(t *testing.T) {
+	ctx := context.Background()
+
+	type MockBehavior func(c *redismocks.MockCache, s *utilmocks.MockSMSSender)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(c *redismocks.MockCache, s *utilmocks.MockSMSSender) {
+				var hash []byte
+				c.EXPECT().Exists(ctx, "otp_lockout:verify_phone:user").Return(false, nil)
+				c.EXPECT().Exists(ctx, "otp_sent:verify_phone:user").Return(false, nil)
+				c.EXPECT().Set(ctx, "otp:verify_phone:user", gomock.Any(), 10*time.Minute).
+					DoAndReturn(func(_ context.Context, _ string, value string, _ time.Duration) error {
+						var record codeRecord
+						assert.NoError(t, json.Unmarshal([]byte(value), &record))
+						hash = record.Hash
+						return nil
+					})
+				c.EXPECT().Set(ctx, "otp_sent:verify_phone:user", "1", time.Minute).Return(nil)
+				s.EXPECT().SendOTP("+15550100", gomock.Any()).
+					DoAndReturn(func(_ string, code string) error {
+						assert.Equal(t, hashCode(PurposeVerifyPhone, "user", code), hash)
+						return nil
+					})
+			},
+		},
+		{
+			name: "sent recently",
+			mockBehavior: func(c *redismocks.MockCache, s *utilmocks.MockSMSSender) {
+				c.EXPECT().Exists(ctx, "otp_lockout:verify_phone:user").Return(false, nil)
+				c.EXPECT().Exists(ctx, "otp_sent:verify_phone:user").Return(true, nil)
+			},
+			wantErr: svcErrs.ErrOTPThrottled,
+		},
+		{
+			name: "send error",
+			mockBehavior: func(c *redismocks.MockCache, s *utilmocks.MockSMSSender) {
+				c.EXPECT().Exists(ctx, gomock.Any()).Return(false, nil).Times(2)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+				s.EXPECT().SendOTP("+15550100", gomock.Any()).Return(errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrSendOTP,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			sender := utilmocks.NewMockSMSSender(ctrl)
+			tc.mockBehavior(cache, sender)
+
+			s := New(logger.New("local", "info"), cache, nil, sender, cfg)
+
+			err := s.SendSMS(ctx, PurposeVerifyPhone, "user", "+15550100")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_Verify(t *testing.T) {
+	ctx := context.Background()
+	key := "otp:step_up:user"
+	attemptsKey := "otp_attempts:step_up:user"
+
+	data, _ := json.Marshal(codeRecord{
+		Hash:      hashCode(PurposeStepUp, "user", "123456"),
+		ExpiresAt: time.Now().Add(5 * time.Minute),
+	})
+	record := string(data)
+
+	type MockBehavior func(c *redismocks.MockCache)
+
+	testCases := []struct {
+		name         string
+		code         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			code: "123456",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().Exists(ctx, "otp_lockout:step_up:user").Return(false, nil)
+				c.EXPECT().Get(ctx, key).Return(record, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).Return(int64(1), nil)
+				c.EXPECT().GetDel(ctx, key).Return(record, nil)
+				c.EXPECT().Delete(ctx, attemptsKey).Return(nil)
+			},
+		},
+		{
+			name: "OK on the last attempt",
+			code: "123456",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().Exists(ctx, "otp_lockout:step_up:user").Return(false, nil)
+				c.EXPECT().Get(ctx, key).Return(record, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).Return(int64(3), nil)
+				c.EXPECT().GetDel(ctx, key).Return(record, nil)
+				c.EXPECT().Delete(ctx, attemptsKey).Return(nil)
+			},
+		},
+		{
+			name: "wrong code",
+			code: "654321",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().Exists(ctx, "otp_lockout:step_up:user").Return(false, nil)
+				c.EXPECT().Get(ctx, key).Return(record, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, ttl time.Duration) (int64, error) {
+						assert.Equal(t, 10*time.Minute, ttl)
+						return 1, nil
+					})
+			},
+			wantErr: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "wrong code, attempts exceeded",
+			code: "654321",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().Exists(ctx, "otp_lockout:step_up:user").Return(false, nil)
+				c.EXPECT().Get(ctx, key).Return(record, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).Return(int64(3), nil)
+				c.EXPECT().Delete(ctx, key).Return(nil)
+				c.EXPECT().Set(ctx, "otp_lockout:step_up:user", "1", 15*time.Minute).Return(nil)
+			},
+			wantErr: svcErrs.ErrOTPLocked,
+		},
+		{
+			name: "right code after the attempts were used concurrently",
+			code: "123456",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().Exists(ctx, "otp_lockout:step_up:user").Return(false, nil)
+				c.EXPECT().Get(ctx, key).Return(record, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).Return(int64(4), nil)
+				c.EXPECT().Delete(ctx, key).Return(nil)
+				c.EXPECT().Set(ctx, "otp_lockout:step_up:user", "1", 15*time.Minute).Return(nil)
+			},
+			wantErr: svcErrs.ErrOTPLocked,
+		},
+		{
+			name: "code of another subject",
+			code: "123456",
+			mockBehavior: func(c *redismocks.MockCache) {
+				data, _ := json.Marshal(codeRecord{
+					Hash:      hashCode(PurposeStepUp, "other", "123456"),
+					ExpiresAt: time.Now().Add(5 * time.Minute),
+				})
+				c.EXPECT().Exists(ctx, "otp_lockout:step_up:user").Return(false, nil)
+				c.EXPECT().Get(ctx, key).Return(string(data), nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).Return(int64(1), nil)
+			},
+			wantErr: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "locked out",
+			code: "123456",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().Exists(ctx, "otp_lockout:step_up:user").Return(true, nil)
+			},
+			wantErr: svcErrs.ErrOTPLocked,
+		},
+		{
+			name: "no code sent",
+			code: "123456",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().Exists(ctx, "otp_lockout:step_up:user").Return(false, nil)
+				c.EXPECT().Get(ctx, key).Return("", errors.New("redis: nil"))
+			},
+			wantErr: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "cache error",
+			code: "123456",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().Exists(ctx, "otp_lockout:step_up:user").Return(false, nil)
+				c.EXPECT().Get(ctx, key).Return(record, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).Return(int64(0), errors.New("some error"))
+			},
+			wantErr: svcErrs.ErrAccessToCache,
+		},
+		{
+			name: "used concurrently",
+			code: "123456",
+			mockBehavior: func(c *redismocks.MockCache) {
+				c.EXPECT().Exists(ctx, "otp_lockout:step_up:user").Return(false, nil)
+				c.EXPECT().Get(ctx, key).Return(record, nil)
+				c.EXPECT().Incr(ctx, attemptsKey, gomock.Any()).Return(int64(1), nil)
+				c.EXPECT().GetDel(ctx, key).Return("", errors.New("redis: nil"))
+			},
+			wantErr: svcErrs.ErrInvalidOTP,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cache := redismocks.NewMockCache(ctrl)
+			tc.mockBehavior(cache)
+
+			s := New(logger.New("local", "info"), cache, nil, nil, cfg)
+
+			err := s.Verify(ctx, PurposeStepUp, "user", tc.code)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_Verify_AcrossCodes(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	entries := map[string]string{}
+	counters := map[string]int64{}
+	cache := redismocks.NewMockCache(ctrl)
+	cache.EXPECT().Exists(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, key string) (bool, error) {
+		_, ok := entries[key]
+		return ok, nil
+	}).AnyTimes()
+	cache.EXPECT().Get(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, key string) (string, error) {
+		v, ok := entries[key]
+		if !ok {
+			return "", errors.New("redis: nil")
+		}
+		return v, nil
+	}).AnyTimes()
+	cache.EXPECT().GetDel(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, key string) (string, error) {
+		v, ok := entries[key]
+		if !ok {
+			return "", errors.New("redis: nil")
+		}
+		delete(entries, key)
+		return v, nil
+	}).AnyTimes()
+	cache.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, key, value string, _ time.Duration) error {
+			entries[key] = value
+			return nil
+		}).AnyTimes()
+	cache.EXPECT().Delete(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, key string) error {
+		delete(entries, key)
+		delete(counters, key)
+		return nil
+	}).AnyTimes()
+	cache.EXPECT().Incr(ctx, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, key string, _ time.Duration) (int64, error) {
+			counters[key]++
+			return counters[key], nil
+		}).AnyTimes()
+
+	var code string
+	sender := utilmocks.NewMockSender(ctrl)
+	sender.EXPECT().SendOTPEmail("test@example.com", gomock.Any()).
+		DoAndReturn(func(_ string, c string) error {
+			code = c
+			return nil
+		}).AnyTimes()
+
+	s := New(logger.New("local", "info"), cache, sender, nil, cfg)
+
+	// send sends a new code once the resend interval has passed
+	send := func() {
+		delete(entries, "otp_sent:sign_in:test@example.com")
+		assert.NoError(t, s.SendEmail(ctx, PurposeSignIn, "test@example.com", "test@example.com"))
+	}
+
+	// a right code resets the attempts
+	send()
+	assert.ErrorIs(t, s.Verify(ctx, PurposeSignIn, "test@example.com", "wrong"), svcErrs.ErrInvalidOTP)
+	assert.NoError(t, s.Verify(ctx, PurposeSignIn, "test@example.com", code))
+
+	// a new code does not
+	for range cfg.MaxAttempts - 1 {
+		send()
+		assert.ErrorIs(t, s.Verify(ctx, PurposeSignIn, "test@example.com", "wrong"), svcErrs.ErrInvalidOTP)
+	}
+
+	send()
+	assert.ErrorIs(t, s.Verify(ctx, PurposeSignIn, "test@example.com", "wrong"), svcErrs.ErrOTPLocked)
+	assert.ErrorIs(t, s.SendEmail(ctx, PurposeSignIn, "test@example.com", "test@example.com"), svcErrs.ErrOTPLocked)
+}
+
+func TestService_generate(t *testing.T) {
+	s := New(logger.New("local", "info"), nil, nil, nil, Config{Digits: 8})
+
+	for range 100 {
+		code, err := s.generate()
+		assert.NoError(t, err)
+		assert.Regexp(t, "^[0-9]{8}$", code)
+	}
+}
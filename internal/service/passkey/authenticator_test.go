@@ -0,0 +1,216 @@
+package passkey
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/google/uuid"
+	"testing"
+)
+
+// Flags of the authenticator data.
+const (
+	flagUserPresent        = 0x01
+	flagUserVerified       = 0x04
+	flagAttestedCredential = 0x40
+)
+
+// softAuthenticator is a platform authenticator in memory, it answers the ceremonies
+// the way a browser passes them to a real device: ES256 key and "none" attestation.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialId []byte
+	userHandle   []byte
+	signCount    uint32
+	origin       string
+}
+
+func newSoftAuthenticator(t *testing.T, origin string) *softAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credentialId := make([]byte, 16)
+	if _, err = rand.Read(credentialId); err != nil {
+		t.Fatal(err)
+	}
+
+	return &softAuthenticator{key: key, credentialId: credentialId, origin: origin}
+}
+
+// credential is what the server stores once the authenticator was registered for the user.
+func (a *softAuthenticator) credential(t *testing.T, userId uuid.UUID) entity.WebAuthnCredential {
+	a.userHandle = userId[:]
+
+	return entity.WebAuthnCredential{
+		Id:              a.credentialId,
+		UserId:          userId,
+		PublicKey:       a.publicKey(t),
+		AttestationType: "none",
+		Transports:      []string{"internal"},
+		AAGUID:          make([]byte, 16),
+		SignCount:       a.signCount,
+	}
+}
+
+// create answers navigator.credentials.create.
+func (a *softAuthenticator) create(t *testing.T, options []byte) []byte {
+	t.Helper()
+
+	var creation struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+			RP        struct {
+				ID string `json:"id"`
+			} `json:"rp"`
+			User struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		} `json:"publicKey"`
+	}
+	if err := json.Unmarshal(options, &creation); err != nil {
+		t.Fatal(err)
+	}
+
+	userHandle, err := base64.RawURLEncoding.DecodeString(creation.PublicKey.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.userHandle = userHandle
+
+	clientData := a.clientData(t, "webauthn.create", creation.PublicKey.Challenge)
+
+	authData := a.authData(creation.PublicKey.RP.ID, flagUserPresent|flagUserVerified|flagAttestedCredential)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialId)))
+	authData = append(authData, a.credentialId...)
+	authData = append(authData, a.publicKey(t)...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.marshal(t, map[string]any{
+		"clientDataJSON":    b64(clientData),
+		"attestationObject": b64(attestationObject),
+		"transports":        []string{"internal"},
+	})
+}
+
+// get answers navigator.credentials.get, each assertion increments the signature counter.
+func (a *softAuthenticator) get(t *testing.T, options []byte) []byte {
+	t.Helper()
+
+	var request struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+			RPID      string `json:"rpId"`
+		} `json:"publicKey"`
+	}
+	if err := json.Unmarshal(options, &request); err != nil {
+		t.Fatal(err)
+	}
+
+	a.signCount++
+
+	clientData := a.clientData(t, "webauthn.get", request.PublicKey.Challenge)
+	authData := a.authData(request.PublicKey.RPID, flagUserPresent|flagUserVerified)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.marshal(t, map[string]any{
+		"clientDataJSON":    b64(clientData),
+		"authenticatorData": b64(authData),
+		"signature":         b64(signature),
+		"userHandle":        b64(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) publicKey(t *testing.T) []byte {
+	t.Helper()
+
+	point, err := a.key.PublicKey.ECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// uncompressed point: 0x04 | x | y
+	xy := point.Bytes()[1:]
+
+	key, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: xy[:32],
+		YCoord: xy[32:],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremonyType, challenge string) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremonyType,
+		"challenge": challenge,
+		"origin":    a.origin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func (a *softAuthenticator) authData(rpId string, flags byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(rpId))
+
+	data := append(rpIdHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+func (a *softAuthenticator) marshal(t *testing.T, response map[string]any) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{
+		"id":       b64(a.credentialId),
+		"rawId":    b64(a.credentialId),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
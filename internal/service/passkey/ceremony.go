@@ -0,0 +1,76 @@
+package passkey
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"time"
+)
+
+const (
+	ceremonyKeyTemplate = "webauthn_ceremony:%s"
+	// ceremonyTTL is used when the relying party does not enforce the timeout of the ceremonies.
+	ceremonyTTL = 5 * time.Minute
+)
+
+// Ceremony kinds, a challenge is only accepted by the step that follows the one that issued it.
+const (
+	ceremonyRegistration = "registration"
+	ceremonyLogin        = "login"
+	ceremonyAssertion    = "assertion"
+)
+
+// ceremony is the cached challenge of a started ceremony.
+type ceremony struct {
+	Kind    string               `json:"kind"`
+	Session webauthn.SessionData `json:"session"`
+}
+
+// newCeremony saves the challenge and returns the options for the browser with the id that refers to it.
+func (s *Service) newCeremony(ctx context.Context, kind string, options any, session *webauthn.SessionData) (Ceremony, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Ceremony{}, err
+	}
+	id := base64.RawURLEncoding.EncodeToString(b)
+
+	opts, err := json.Marshal(options)
+	if err != nil {
+		return Ceremony{}, err
+	}
+
+	data, err := json.Marshal(ceremony{Kind: kind, Session: *session})
+	if err != nil {
+		return Ceremony{}, err
+	}
+
+	ttl := ceremonyTTL
+	if !session.Expires.IsZero() {
+		ttl = time.Until(session.Expires)
+	}
+
+	if err = s.cache.Set(ctx, fmt.Sprintf(ceremonyKeyTemplate, id), string(data), ttl); err != nil {
+		return Ceremony{}, err
+	}
+
+	return Ceremony{SessionId: id, Options: opts}, nil
+}
+
+// claimCeremony takes the challenge out of the cache, each challenge is answered once.
+func (s *Service) claimCeremony(ctx context.Context, kind, id string) (webauthn.SessionData, error) {
+	data, err := s.cache.GetDel(ctx, fmt.Sprintf(ceremonyKeyTemplate, id))
+	if err != nil {
+		return webauthn.SessionData{}, svcErrs.ErrInvalidPasskeyCeremony
+	}
+
+	var c ceremony
+	if err = json.Unmarshal([]byte(data), &c); err != nil || c.Kind != kind {
+		return webauthn.SessionData{}, svcErrs.ErrInvalidPasskeyCeremony
+	}
+
+	return c.Session, nil
+}
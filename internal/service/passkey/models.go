@@ -0,0 +1,21 @@
+package passkey
+
+import "encoding/json"
+
+type (
+	// Ceremony starts navigator.credentials.create or navigator.credentials.get in the browser.
+	Ceremony struct {
+		// SessionId refers to the challenge kept by the server, it is sent back with the authenticator response.
+		SessionId string
+		// Options are the credential creation or request options, wrapped in "publicKey".
+		Options json.RawMessage
+	}
+
+	FinishRegistrationInput struct {
+		SessionId string
+		// Name helps the user to tell the passkeys apart.
+		Name string
+		// Response is the JSON encoded PublicKeyCredential returned by navigator.credentials.create.
+		Response []byte
+	}
+)
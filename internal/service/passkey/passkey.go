@@ -0,0 +1,325 @@
+package passkey
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/repo"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/bubalync/uni-auth/pkg/redis"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"log/slog"
+)
+
+// Service runs the WebAuthn ceremonies: registration of passkeys and security keys,
+// passwordless sign-in with discoverable credentials and assertions that answer a second factor challenge.
+type Service struct {
+	log      *slog.Logger
+	cache    redis.Cache
+	repo     repo.WebAuthnCredential
+	users    repo.User
+	webAuthn *webauthn.WebAuthn
+}
+
+// New -.
+func New(
+	log *slog.Logger,
+	cache redis.Cache,
+	repo repo.WebAuthnCredential,
+	users repo.User,
+	webAuthn *webauthn.WebAuthn,
+) *Service {
+	return &Service{
+		log:      log,
+		cache:    cache,
+		repo:     repo,
+		users:    users,
+		webAuthn: webAuthn,
+	}
+}
+
+// BeginRegistration returns the options to create a new credential, the registered ones are excluded.
+func (s *Service) BeginRegistration(ctx context.Context, userId uuid.UUID) (Ceremony, error) {
+	const op = "service.passkey.BeginRegistration"
+	log := s.log.With(slog.String("op", op))
+
+	user, err := s.webAuthnUser(ctx, log, userId)
+	if err != nil {
+		return Ceremony{}, err
+	}
+
+	options, session, err := s.webAuthn.BeginRegistration(user,
+		webauthn.WithExclusions(user.descriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		log.Error("failed to begin registration", sl.Err(err))
+		return Ceremony{}, svcErrs.ErrCannotUpdatePasskeys
+	}
+
+	c, err := s.newCeremony(ctx, ceremonyRegistration, options, session)
+	if err != nil {
+		log.Error("failed to save registration ceremony", sl.Err(err))
+		return Ceremony{}, svcErrs.ErrAccessToCache
+	}
+
+	return c, nil
+}
+
+// FinishRegistration verifies the attestation of the authenticator and saves the new credential.
+func (s *Service) FinishRegistration(ctx context.Context, userId uuid.UUID, input FinishRegistrationInput) (entity.WebAuthnCredential, error) {
+	const op = "service.passkey.FinishRegistration"
+	log := s.log.With(slog.String("op", op))
+
+	session, err := s.claimCeremony(ctx, ceremonyRegistration, input.SessionId)
+	if err != nil {
+		return entity.WebAuthnCredential{}, err
+	}
+
+	user, err := s.webAuthnUser(ctx, log, userId)
+	if err != nil {
+		return entity.WebAuthnCredential{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(input.Response))
+	if err != nil {
+		log.Warn("failed to parse attestation", sl.Err(err))
+		return entity.WebAuthnCredential{}, svcErrs.ErrInvalidPasskey
+	}
+
+	credential, err := s.webAuthn.CreateCredential(user, session, parsed)
+	if err != nil {
+		log.Warn("attestation was rejected", sl.Err(err), slog.String("user_id", userId.String()))
+		return entity.WebAuthnCredential{}, svcErrs.ErrInvalidPasskey
+	}
+
+	c := newCredentialEntity(user.user, input.Name, credential)
+	if err = s.repo.Create(ctx, c); err != nil {
+		if errors.Is(err, repoErrs.ErrAlreadyExists) {
+			return entity.WebAuthnCredential{}, svcErrs.ErrPasskeyAlreadyRegistered
+		}
+
+		log.Error("failed to save credential", sl.Err(err))
+		return entity.WebAuthnCredential{}, svcErrs.ErrCannotUpdatePasskeys
+	}
+
+	log.Info("passkey registered",
+		sl.SecurityEvent("passkey_registered"),
+		slog.String("user_id", userId.String()),
+	)
+
+	return c, nil
+}
+
+// BeginLogin returns the options of a passwordless sign-in, the authenticator picks the account
+// among its discoverable credentials.
+func (s *Service) BeginLogin(ctx context.Context) (Ceremony, error) {
+	const op = "service.passkey.BeginLogin"
+	log := s.log.With(slog.String("op", op))
+
+	// the passkey is the only factor, the authenticator has to verify the user
+	options, session, err := s.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		log.Error("failed to begin login", sl.Err(err))
+		return Ceremony{}, svcErrs.ErrCannotGetPasskeys
+	}
+
+	c, err := s.newCeremony(ctx, ceremonyLogin, options, session)
+	if err != nil {
+		log.Error("failed to save login ceremony", sl.Err(err))
+		return Ceremony{}, svcErrs.ErrAccessToCache
+	}
+
+	return c, nil
+}
+
+// FinishLogin verifies the assertion of a discoverable credential and returns its owner.
+func (s *Service) FinishLogin(ctx context.Context, sessionId string, response []byte) (entity.User, error) {
+	const op = "service.passkey.FinishLogin"
+	log := s.log.With(slog.String("op", op))
+
+	session, err := s.claimCeremony(ctx, ceremonyLogin, sessionId)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		log.Warn("failed to parse assertion", sl.Err(err))
+		return entity.User{}, svcErrs.ErrInvalidPasskey
+	}
+
+	var (
+		user      webAuthnUser
+		lookupErr error
+	)
+	credential, err := s.webAuthn.ValidateDiscoverableLogin(func(_, userHandle []byte) (webauthn.User, error) {
+		userId, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			lookupErr = svcErrs.ErrInvalidPasskey
+			return nil, err
+		}
+
+		user, lookupErr = s.webAuthnUser(ctx, log, userId)
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+
+		return user, nil
+	}, session, parsed)
+	if err != nil {
+		if lookupErr != nil && !errors.Is(lookupErr, svcErrs.ErrUserNotFound) {
+			return entity.User{}, lookupErr
+		}
+
+		log.Warn("assertion was rejected", sl.Err(err))
+		return entity.User{}, svcErrs.ErrInvalidPasskey
+	}
+
+	if err = s.recordUsage(ctx, log, user.user.Id, credential); err != nil {
+		return entity.User{}, err
+	}
+
+	return user.user, nil
+}
+
+// BeginAssertion returns the options to sign in with a registered credential of a known user,
+// it answers the second factor challenge.
+func (s *Service) BeginAssertion(ctx context.Context, userId uuid.UUID) (Ceremony, error) {
+	const op = "service.passkey.BeginAssertion"
+	log := s.log.With(slog.String("op", op))
+
+	user, err := s.webAuthnUser(ctx, log, userId)
+	if err != nil {
+		return Ceremony{}, err
+	}
+
+	if len(user.credentials) == 0 {
+		return Ceremony{}, svcErrs.ErrPasskeyNotFound
+	}
+
+	options, session, err := s.webAuthn.BeginLogin(user)
+	if err != nil {
+		log.Error("failed to begin assertion", sl.Err(err))
+		return Ceremony{}, svcErrs.ErrCannotGetPasskeys
+	}
+
+	c, err := s.newCeremony(ctx, ceremonyAssertion, options, session)
+	if err != nil {
+		log.Error("failed to save assertion ceremony", sl.Err(err))
+		return Ceremony{}, svcErrs.ErrAccessToCache
+	}
+
+	return c, nil
+}
+
+// FinishAssertion verifies the assertion started by BeginAssertion for the same user.
+func (s *Service) FinishAssertion(ctx context.Context, userId uuid.UUID, sessionId string, response []byte) error {
+	const op = "service.passkey.FinishAssertion"
+	log := s.log.With(slog.String("op", op))
+
+	session, err := s.claimCeremony(ctx, ceremonyAssertion, sessionId)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.webAuthnUser(ctx, log, userId)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		log.Warn("failed to parse assertion", sl.Err(err))
+		return svcErrs.ErrInvalidPasskey
+	}
+
+	// the library checks that the challenge was issued to this user
+	credential, err := s.webAuthn.ValidateLogin(user, session, parsed)
+	if err != nil {
+		log.Warn("assertion was rejected", sl.Err(err), slog.String("user_id", userId.String()))
+		return svcErrs.ErrInvalidPasskey
+	}
+
+	return s.recordUsage(ctx, log, userId, credential)
+}
+
+// Credentials lists the credentials the user registered.
+func (s *Service) Credentials(ctx context.Context, userId uuid.UUID) ([]entity.WebAuthnCredential, error) {
+	const op = "service.passkey.Credentials"
+	log := s.log.With(slog.String("op", op))
+
+	credentials, err := s.repo.CredentialsByUserId(ctx, userId)
+	if err != nil {
+		log.Error("failed to get credentials", sl.Err(err))
+		return nil, svcErrs.ErrCannotGetPasskeys
+	}
+
+	return credentials, nil
+}
+
+func (s *Service) Delete(ctx context.Context, userId uuid.UUID, id []byte) error {
+	const op = "service.passkey.Delete"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.repo.Delete(ctx, userId, id); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrPasskeyNotFound
+		}
+
+		log.Error("failed to delete credential", sl.Err(err))
+		return svcErrs.ErrCannotUpdatePasskeys
+	}
+
+	log.Info("passkey deleted",
+		sl.SecurityEvent("passkey_deleted"),
+		slog.String("user_id", userId.String()),
+	)
+
+	return nil
+}
+
+func (s *Service) webAuthnUser(ctx context.Context, log *slog.Logger, userId uuid.UUID) (webAuthnUser, error) {
+	user, err := s.users.UserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return webAuthnUser{}, svcErrs.ErrUserNotFound
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return webAuthnUser{}, svcErrs.ErrCannotGetUser
+	}
+
+	credentials, err := s.repo.CredentialsByUserId(ctx, userId)
+	if err != nil {
+		log.Error("failed to get credentials", sl.Err(err))
+		return webAuthnUser{}, svcErrs.ErrCannotGetPasskeys
+	}
+
+	return webAuthnUser{user: user, credentials: credentials}, nil
+}
+
+// recordUsage saves the signature counter of an accepted assertion. A counter that did not grow
+// means two authenticators hold the same key, the assertion is rejected.
+func (s *Service) recordUsage(ctx context.Context, log *slog.Logger, userId uuid.UUID, credential *webauthn.Credential) error {
+	if credential.Authenticator.CloneWarning {
+		log.Warn("signature counter of a passkey went back, the authenticator may be cloned",
+			sl.SecurityEvent("passkey_clone_detected"),
+			slog.String("user_id", userId.String()),
+		)
+		return svcErrs.ErrInvalidPasskey
+	}
+
+	err := s.repo.UpdateUsage(ctx, credential.ID, credential.Authenticator.SignCount, credential.Flags.BackupState)
+	if err != nil {
+		log.Error("failed to update credential", sl.Err(err))
+		return svcErrs.ErrCannotUpdatePasskeys
+	}
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package passkey
+
+import (
+	"context"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/mocks/redismocks"
+	"github.com/bubalync/uni-auth/internal/mocks/repomocks"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+const (
+	rpId     = "example.com"
+	rpOrigin = "https://example.com"
+)
+
+func newTestService(t *testing.T, ctrl *gomock.Controller) (*Service, *repomocks.MockWebAuthnCredential, *repomocks.MockUser) {
+	t.Helper()
+
+	relyingParty, err := webauthn.New(&webauthn.Config{
+		RPID:          rpId,
+		RPDisplayName: "uni-auth",
+		RPOrigins:     []string{rpOrigin},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: time.Minute},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: time.Minute},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credentials := repomocks.NewMockWebAuthnCredential(ctrl)
+	users := repomocks.NewMockUser(ctrl)
+
+	return New(logger.New("local", "info"), newCacheMock(ctrl), credentials, users, relyingParty), credentials, users
+}
+
+// newCacheMock keeps the ceremonies in memory.
+func newCacheMock(ctrl *gomock.Controller) *redismocks.MockCache {
+	data := make(map[string]string)
+
+	cache := redismocks.NewMockCache(ctrl)
+	cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, key, value string, _ time.Duration) error {
+			data[key] = value
+			return nil
+		}).AnyTimes()
+	cache.EXPECT().GetDel(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, key string) (string, error) {
+			value, ok := data[key]
+			if !ok {
+				return "", errors.New("redis: nil")
+			}
+			delete(data, key)
+			return value, nil
+		}).AnyTimes()
+
+	return cache
+}
+
+func TestPasskeyService_Registration(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	type MockBehavior func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		// tamper changes the ceremony before the authenticator response is sent back
+		tamper func(sessionId string, response []byte) (string, []byte)
+		err    error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator) {
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil).Times(2)
+				r.EXPECT().CredentialsByUserId(ctx, user.Id).Return(nil, nil).Times(2)
+				r.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, c entity.WebAuthnCredential) error {
+					want := a.credential(t, user.Id)
+					want.Name = "laptop"
+					assert.Equal(t, want, c)
+					return nil
+				})
+			},
+		},
+		{
+			name: "unknown ceremony",
+			mockBehavior: func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator) {
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+				r.EXPECT().CredentialsByUserId(ctx, user.Id).Return(nil, nil)
+			},
+			tamper: func(_ string, response []byte) (string, []byte) {
+				return "unknown", response
+			},
+			err: svcErrs.ErrInvalidPasskeyCeremony,
+		},
+		{
+			name: "invalid response",
+			mockBehavior: func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator) {
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil).Times(2)
+				r.EXPECT().CredentialsByUserId(ctx, user.Id).Return(nil, nil).Times(2)
+			},
+			tamper: func(sessionId string, _ []byte) (string, []byte) {
+				return sessionId, []byte(`{"id":"x"}`)
+			},
+			err: svcErrs.ErrInvalidPasskey,
+		},
+		{
+			name: "already registered",
+			mockBehavior: func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator) {
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil).Times(2)
+				r.EXPECT().CredentialsByUserId(ctx, user.Id).Return(nil, nil).Times(2)
+				r.EXPECT().Create(ctx, gomock.Any()).Return(repoErrs.ErrAlreadyExists)
+			},
+			err: svcErrs.ErrPasskeyAlreadyRegistered,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s, credentials, users := newTestService(t, ctrl)
+			authenticator := newSoftAuthenticator(t, rpOrigin)
+			tc.mockBehavior(credentials, users, authenticator)
+
+			c, err := s.BeginRegistration(ctx, user.Id)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			sessionId, response := c.SessionId, authenticator.create(t, c.Options)
+			if tc.tamper != nil {
+				sessionId, response = tc.tamper(sessionId, response)
+			}
+
+			got, err := s.FinishRegistration(ctx, user.Id, FinishRegistrationInput{
+				SessionId: sessionId,
+				Name:      "laptop",
+				Response:  response,
+			})
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, authenticator.credentialId, got.Id)
+			assert.Equal(t, []byte(user.Id[:]), authenticator.userHandle)
+		})
+	}
+}
+
+func TestPasskeyService_Login(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+
+	type MockBehavior func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator) {
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+				r.EXPECT().CredentialsByUserId(ctx, user.Id).Return([]entity.WebAuthnCredential{a.credential(t, user.Id)}, nil)
+				r.EXPECT().UpdateUsage(ctx, a.credentialId, uint32(1), false).Return(nil)
+			},
+		},
+		{
+			name: "unknown credential",
+			mockBehavior: func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator) {
+				a.credential(t, user.Id)
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+				r.EXPECT().CredentialsByUserId(ctx, user.Id).Return(nil, nil)
+			},
+			err: svcErrs.ErrInvalidPasskey,
+		},
+		{
+			name: "unknown user",
+			mockBehavior: func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator) {
+				a.credential(t, user.Id)
+				u.EXPECT().UserById(ctx, user.Id).Return(entity.User{}, repoErrs.ErrNotFound)
+			},
+			err: svcErrs.ErrInvalidPasskey,
+		},
+		{
+			name: "cloned authenticator",
+			mockBehavior: func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator) {
+				stored := a.credential(t, user.Id)
+				// the server has seen a higher counter than the one of the next assertion
+				stored.SignCount = 5
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+				r.EXPECT().CredentialsByUserId(ctx, user.Id).Return([]entity.WebAuthnCredential{stored}, nil)
+			},
+			err: svcErrs.ErrInvalidPasskey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s, credentials, users := newTestService(t, ctrl)
+			authenticator := newSoftAuthenticator(t, rpOrigin)
+			tc.mockBehavior(credentials, users, authenticator)
+
+			c, err := s.BeginLogin(ctx)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			got, err := s.FinishLogin(ctx, c.SessionId, authenticator.get(t, c.Options))
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, user, got)
+		})
+	}
+}
+
+func TestPasskeyService_Assertion(t *testing.T) {
+	ctx := context.Background()
+	user := entity.User{Id: uuid.New(), Email: "test@example.com"}
+	another := entity.User{Id: uuid.New(), Email: "another@example.com"}
+
+	type MockBehavior func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		// finishFor is the user who answers the challenge issued to user
+		finishFor uuid.UUID
+		beginErr  error
+		err       error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator) {
+				stored := []entity.WebAuthnCredential{a.credential(t, user.Id)}
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil).Times(2)
+				r.EXPECT().CredentialsByUserId(ctx, user.Id).Return(stored, nil).Times(2)
+				r.EXPECT().UpdateUsage(ctx, a.credentialId, uint32(1), false).Return(nil)
+			},
+			finishFor: user.Id,
+		},
+		{
+			name: "no passkeys",
+			mockBehavior: func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator) {
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+				r.EXPECT().CredentialsByUserId(ctx, user.Id).Return(nil, nil)
+			},
+			beginErr: svcErrs.ErrPasskeyNotFound,
+		},
+		{
+			name: "challenge of another user",
+			mockBehavior: func(r *repomocks.MockWebAuthnCredential, u *repomocks.MockUser, a *softAuthenticator) {
+				stored := []entity.WebAuthnCredential{a.credential(t, user.Id)}
+				u.EXPECT().UserById(ctx, user.Id).Return(user, nil)
+				r.EXPECT().CredentialsByUserId(ctx, user.Id).Return(stored, nil)
+				u.EXPECT().UserById(ctx, another.Id).Return(another, nil)
+				r.EXPECT().CredentialsByUserId(ctx, another.Id).Return(nil, nil)
+			},
+			finishFor: another.Id,
+			err:       svcErrs.ErrInvalidPasskey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s, credentials, users := newTestService(t, ctrl)
+			authenticator := newSoftAuthenticator(t, rpOrigin)
+			tc.mockBehavior(credentials, users, authenticator)
+
+			c, err := s.BeginAssertion(ctx, user.Id)
+			if tc.beginErr != nil {
+				assert.ErrorIs(t, err, tc.beginErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			err = s.FinishAssertion(ctx, tc.finishFor, c.SessionId, authenticator.get(t, c.Options))
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
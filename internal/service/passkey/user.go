@@ -0,0 +1,89 @@
+package passkey
+
+import (
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// webAuthnUser adapts the user and the registered credentials to the WebAuthn library.
+type webAuthnUser struct {
+	user        entity.User
+	credentials []entity.WebAuthnCredential
+}
+
+// WebAuthnID is the user handle, the user id keeps it stable and free of personal data.
+func (u webAuthnUser) WebAuthnID() []byte {
+	return u.user.Id[:]
+}
+
+func (u webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u webAuthnUser) WebAuthnDisplayName() string {
+	return u.user.Email
+}
+
+func (u webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, c := range u.credentials {
+		transports := make([]protocol.AuthenticatorTransport, 0, len(c.Transports))
+		for _, t := range c.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(t))
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              c.Id,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: c.SignCount,
+			},
+		})
+	}
+
+	return credentials
+}
+
+// descriptors lists the credentials of the user for the allow and exclude lists.
+func (u webAuthnUser) descriptors() []protocol.CredentialDescriptor {
+	credentials := u.WebAuthnCredentials()
+
+	descriptors := make([]protocol.CredentialDescriptor, 0, len(credentials))
+	for _, c := range credentials {
+		descriptors = append(descriptors, c.Descriptor())
+	}
+
+	return descriptors
+}
+
+func newCredentialEntity(user entity.User, name string, c *webauthn.Credential) entity.WebAuthnCredential {
+	transports := make([]string, 0, len(c.Transport))
+	for _, t := range c.Transport {
+		transports = append(transports, string(t))
+	}
+
+	return entity.WebAuthnCredential{
+		Id:              c.ID,
+		UserId:          user.Id,
+		Name:            name,
+		PublicKey:       c.PublicKey,
+		AttestationType: c.AttestationType,
+		Transports:      transports,
+		AAGUID:          c.Authenticator.AAGUID,
+		SignCount:       c.Authenticator.SignCount,
+		BackupEligible:  c.Flags.BackupEligible,
+		BackupState:     c.Flags.BackupState,
+	}
+}
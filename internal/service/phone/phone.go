@@ -0,0 +1,171 @@
+package phone
+
+import (
+	"context"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/repo"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/otp"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger/sl"
+	"github.com/google/uuid"
+	"log/slog"
+)
+
+// Codes texts one-time codes and checks them.
+type Codes interface {
+	SendSMS(ctx context.Context, purpose otp.Purpose, subject, to string) error
+	Verify(ctx context.Context, purpose otp.Purpose, subject, code string) error
+}
+
+// Service manages the phone of users. A verified phone receives the codes of the SMS second factor
+// and of the account recovery.
+type Service struct {
+	log   *slog.Logger
+	users repo.User
+	codes Codes
+}
+
+// New -.
+func New(log *slog.Logger, users repo.User, codes Codes) *Service {
+	return &Service{
+		log:   log,
+		users: users,
+		codes: codes,
+	}
+}
+
+// SetPhone replaces the phone of the user and texts a code that verifies it.
+// Setting the unverified phone again sends a new code.
+func (s *Service) SetPhone(ctx context.Context, userId uuid.UUID, phone string) error {
+	const op = "service.phone.SetPhone"
+	log := s.log.With(slog.String("op", op))
+
+	user, err := s.users.UserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrUserNotFound
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return svcErrs.ErrCannotGetUser
+	}
+
+	if user.IsPhoneVerified() && *user.Phone == phone {
+		return nil
+	}
+
+	if err = s.users.SetPhone(ctx, userId, &phone); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrUserNotFound
+		}
+
+		log.Error("failed to set phone", sl.Err(err))
+		return svcErrs.ErrCannotUpdateUser
+	}
+
+	return s.codes.SendSMS(ctx, otp.PurposeVerifyPhone, verificationSubject(userId, phone), phone)
+}
+
+// VerifyPhone marks the phone of the user as verified with the code sent by SetPhone.
+func (s *Service) VerifyPhone(ctx context.Context, userId uuid.UUID, code string) error {
+	const op = "service.phone.VerifyPhone"
+	log := s.log.With(slog.String("op", op))
+
+	user, err := s.users.UserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrUserNotFound
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		return svcErrs.ErrCannotGetUser
+	}
+
+	if user.Phone == nil {
+		return svcErrs.ErrPhoneNotFound
+	}
+
+	if user.IsPhoneVerified() {
+		return nil
+	}
+
+	phone := *user.Phone
+	if err = s.codes.Verify(ctx, otp.PurposeVerifyPhone, verificationSubject(userId, phone), code); err != nil {
+		return err
+	}
+
+	if err = s.users.VerifyPhone(ctx, userId, phone); err != nil {
+		switch {
+		case errors.Is(err, repoErrs.ErrNotFound):
+			// the phone was replaced after the code was checked
+			return svcErrs.ErrInvalidOTP
+		case errors.Is(err, repoErrs.ErrAlreadyExists):
+			return svcErrs.ErrPhoneAlreadyExists
+		}
+
+		log.Error("failed to verify phone", sl.Err(err))
+		return svcErrs.ErrCannotUpdateUser
+	}
+
+	log.Info("phone verified",
+		sl.SecurityEvent("phone_verified"),
+		slog.String("user_id", userId.String()),
+	)
+
+	return nil
+}
+
+// RemovePhone drops the phone of the user along with the SMS second factor.
+func (s *Service) RemovePhone(ctx context.Context, userId uuid.UUID) error {
+	const op = "service.phone.RemovePhone"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.users.SetPhone(ctx, userId, nil); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrUserNotFound
+		}
+
+		log.Error("failed to remove phone", sl.Err(err))
+		return svcErrs.ErrCannotUpdateUser
+	}
+
+	log.Info("phone removed",
+		sl.SecurityEvent("phone_removed"),
+		slog.String("user_id", userId.String()),
+	)
+
+	return nil
+}
+
+// SetSecondFactor turns the SMS second factor of the user on or off, it needs a verified phone.
+func (s *Service) SetSecondFactor(ctx context.Context, userId uuid.UUID, enabled bool) error {
+	const op = "service.phone.SetSecondFactor"
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.users.SetSMSSecondFactor(ctx, userId, enabled); err != nil {
+		if errors.Is(err, repoErrs.ErrNotFound) {
+			return svcErrs.ErrPhoneNotFound
+		}
+
+		log.Error("failed to update the sms second factor", sl.Err(err))
+		return svcErrs.ErrCannotUpdateUser
+	}
+
+	event := "sms_second_factor_disabled"
+	if enabled {
+		event = "sms_second_factor_enabled"
+	}
+
+	log.Info("sms second factor updated",
+		sl.SecurityEvent(event),
+		slog.String("user_id", userId.String()),
+	)
+
+	return nil
+}
+
+// verificationSubject binds a code to the phone it was sent to, so that it cannot verify a phone set later.
+func verificationSubject(userId uuid.UUID, phone string) string {
+	return userId.String() + ":" + phone
+}
@@ -0,0 +1,264 @@
+package phone
+
+import (
+	"context"
+	"errors"
+	"github.com/bubalync/uni-auth/internal/entity"
+	"github.com/bubalync/uni-auth/internal/mocks/phonemocks"
+	"github.com/bubalync/uni-auth/internal/mocks/repomocks"
+	"github.com/bubalync/uni-auth/internal/repo/repoErrs"
+	"github.com/bubalync/uni-auth/internal/service/otp"
+	"github.com/bubalync/uni-auth/internal/service/svcErrs"
+	"github.com/bubalync/uni-auth/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestPhoneService_SetPhone(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+	phone := "+15550100"
+	verifiedAt := time.Now()
+
+	type MockBehavior func(u *repomocks.MockUser, c *phonemocks.MockCodes)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(u *repomocks.MockUser, c *phonemocks.MockCodes) {
+				u.EXPECT().UserById(ctx, userId).Return(entity.User{Id: userId}, nil)
+				u.EXPECT().SetPhone(ctx, userId, &phone).Return(nil)
+				c.EXPECT().SendSMS(ctx, otp.PurposeVerifyPhone, userId.String()+":"+phone, phone).Return(nil)
+			},
+		},
+		{
+			name: "already verified",
+			mockBehavior: func(u *repomocks.MockUser, c *phonemocks.MockCodes) {
+				u.EXPECT().UserById(ctx, userId).Return(entity.User{Id: userId, Phone: &phone, PhoneVerifiedAt: &verifiedAt}, nil)
+			},
+		},
+		{
+			name: "sent recently",
+			mockBehavior: func(u *repomocks.MockUser, c *phonemocks.MockCodes) {
+				u.EXPECT().UserById(ctx, userId).Return(entity.User{Id: userId, Phone: &phone}, nil)
+				u.EXPECT().SetPhone(ctx, userId, &phone).Return(nil)
+				c.EXPECT().SendSMS(ctx, otp.PurposeVerifyPhone, userId.String()+":"+phone, phone).Return(svcErrs.ErrOTPThrottled)
+			},
+			err: svcErrs.ErrOTPThrottled,
+		},
+		{
+			name: "user not found",
+			mockBehavior: func(u *repomocks.MockUser, c *phonemocks.MockCodes) {
+				u.EXPECT().UserById(ctx, userId).Return(entity.User{}, repoErrs.ErrNotFound)
+			},
+			err: svcErrs.ErrUserNotFound,
+		},
+		{
+			name: "update error",
+			mockBehavior: func(u *repomocks.MockUser, c *phonemocks.MockCodes) {
+				u.EXPECT().UserById(ctx, userId).Return(entity.User{Id: userId}, nil)
+				u.EXPECT().SetPhone(ctx, userId, &phone).Return(errors.New("some error"))
+			},
+			err: svcErrs.ErrCannotUpdateUser,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			users := repomocks.NewMockUser(ctrl)
+			codes := phonemocks.NewMockCodes(ctrl)
+			tc.mockBehavior(users, codes)
+
+			err := New(logger.New("local", "info"), users, codes).SetPhone(ctx, userId, phone)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPhoneService_VerifyPhone(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+	phone := "+15550100"
+	verifiedAt := time.Now()
+	subject := userId.String() + ":" + phone
+
+	type MockBehavior func(u *repomocks.MockUser, c *phonemocks.MockCodes)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		err          error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(u *repomocks.MockUser, c *phonemocks.MockCodes) {
+				u.EXPECT().UserById(ctx, userId).Return(entity.User{Id: userId, Phone: &phone}, nil)
+				c.EXPECT().Verify(ctx, otp.PurposeVerifyPhone, subject, "123456").Return(nil)
+				u.EXPECT().VerifyPhone(ctx, userId, phone).Return(nil)
+			},
+		},
+		{
+			name: "no phone",
+			mockBehavior: func(u *repomocks.MockUser, c *phonemocks.MockCodes) {
+				u.EXPECT().UserById(ctx, userId).Return(entity.User{Id: userId}, nil)
+			},
+			err: svcErrs.ErrPhoneNotFound,
+		},
+		{
+			name: "already verified",
+			mockBehavior: func(u *repomocks.MockUser, c *phonemocks.MockCodes) {
+				u.EXPECT().UserById(ctx, userId).Return(entity.User{Id: userId, Phone: &phone, PhoneVerifiedAt: &verifiedAt}, nil)
+			},
+		},
+		{
+			name: "invalid code",
+			mockBehavior: func(u *repomocks.MockUser, c *phonemocks.MockCodes) {
+				u.EXPECT().UserById(ctx, userId).Return(entity.User{Id: userId, Phone: &phone}, nil)
+				c.EXPECT().Verify(ctx, otp.PurposeVerifyPhone, subject, "123456").Return(svcErrs.ErrInvalidOTP)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "phone replaced",
+			mockBehavior: func(u *repomocks.MockUser, c *phonemocks.MockCodes) {
+				u.EXPECT().UserById(ctx, userId).Return(entity.User{Id: userId, Phone: &phone}, nil)
+				c.EXPECT().Verify(ctx, otp.PurposeVerifyPhone, subject, "123456").Return(nil)
+				u.EXPECT().VerifyPhone(ctx, userId, phone).Return(repoErrs.ErrNotFound)
+			},
+			err: svcErrs.ErrInvalidOTP,
+		},
+		{
+			name: "verified by another user",
+			mockBehavior: func(u *repomocks.MockUser, c *phonemocks.MockCodes) {
+				u.EXPECT().UserById(ctx, userId).Return(entity.User{Id: userId, Phone: &phone}, nil)
+				c.EXPECT().Verify(ctx, otp.PurposeVerifyPhone, subject, "123456").Return(nil)
+				u.EXPECT().VerifyPhone(ctx, userId, phone).Return(repoErrs.ErrAlreadyExists)
+			},
+			err: svcErrs.ErrPhoneAlreadyExists,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			users := repomocks.NewMockUser(ctrl)
+			codes := phonemocks.NewMockCodes(ctrl)
+			tc.mockBehavior(users, codes)
+
+			err := New(logger.New("local", "info"), users, codes).VerifyPhone(ctx, userId, "123456")
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPhoneService_RemovePhone(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+
+	testCases := []struct {
+		name    string
+		repoErr error
+		err     error
+	}{
+		{
+			name: "OK",
+		},
+		{
+			name:    "user not found",
+			repoErr: repoErrs.ErrNotFound,
+			err:     svcErrs.ErrUserNotFound,
+		},
+		{
+			name:    "repo error",
+			repoErr: errors.New("some error"),
+			err:     svcErrs.ErrCannotUpdateUser,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			users := repomocks.NewMockUser(ctrl)
+			users.EXPECT().SetPhone(ctx, userId, (*string)(nil)).Return(tc.repoErr)
+
+			err := New(logger.New("local", "info"), users, nil).RemovePhone(ctx, userId)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPhoneService_SetSecondFactor(t *testing.T) {
+	ctx := context.Background()
+	userId := uuid.New()
+
+	testCases := []struct {
+		name    string
+		enabled bool
+		repoErr error
+		err     error
+	}{
+		{
+			name:    "enable",
+			enabled: true,
+		},
+		{
+			name:    "disable",
+			enabled: false,
+		},
+		{
+			name:    "no verified phone",
+			enabled: true,
+			repoErr: repoErrs.ErrNotFound,
+			err:     svcErrs.ErrPhoneNotFound,
+		},
+		{
+			name:    "repo error",
+			enabled: true,
+			repoErr: errors.New("some error"),
+			err:     svcErrs.ErrCannotUpdateUser,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			users := repomocks.NewMockUser(ctrl)
+			users.EXPECT().SetSMSSecondFactor(ctx, userId, tc.enabled).Return(tc.repoErr)
+
+			err := New(logger.New("local", "info"), users, nil).SetSecondFactor(ctx, userId, tc.enabled)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
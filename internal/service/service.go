@@ -5,11 +5,24 @@ import (
 	"github.com/bubalync/uni-auth/internal/entity"
 	"github.com/bubalync/uni-auth/internal/lib/email"
 	"github.com/bubalync/uni-auth/internal/lib/jwtgen"
+	"github.com/bubalync/uni-auth/internal/lib/sms"
 	"github.com/bubalync/uni-auth/internal/repo"
 	"github.com/bubalync/uni-auth/internal/service/auth"
+	"github.com/bubalync/uni-auth/internal/service/client"
+	"github.com/bubalync/uni-auth/internal/service/federation"
+	"github.com/bubalync/uni-auth/internal/service/keys"
+	"github.com/bubalync/uni-auth/internal/service/lockout"
+	"github.com/bubalync/uni-auth/internal/service/mfa"
+	"github.com/bubalync/uni-auth/internal/service/oauth"
+	"github.com/bubalync/uni-auth/internal/service/otp"
+	"github.com/bubalync/uni-auth/internal/service/passkey"
+	"github.com/bubalync/uni-auth/internal/service/phone"
 	"github.com/bubalync/uni-auth/internal/service/user"
+	"github.com/bubalync/uni-auth/pkg/breach"
+	"github.com/bubalync/uni-auth/pkg/cipher"
 	"github.com/bubalync/uni-auth/pkg/hasher"
 	"github.com/bubalync/uni-auth/pkg/redis"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/google/uuid"
 	"log/slog"
 	"time"
@@ -21,17 +34,96 @@ type (
 		GenerateToken(ctx context.Context, input auth.GenerateTokenInput) (auth.GenerateTokenOutput, error)
 		ResetPassword(ctx context.Context, input auth.ResetPasswordInput) error
 		RecoveryPassword(ctx context.Context, input auth.RecoveryPasswordInput) error
+		ResetPasswordBySMS(ctx context.Context, phone string) error
+		RecoveryPasswordBySMS(ctx context.Context, input auth.SMSRecoveryPasswordInput) error
+		VerifyEmail(ctx context.Context, token string) error
+		ResendVerification(ctx context.Context, email string) error
+		RequestMagicLink(ctx context.Context, input auth.MagicLinkInput) (string, error)
+		VerifyMagicLink(ctx context.Context, input auth.VerifyMagicLinkInput) (auth.GenerateTokenOutput, error)
+		SendSignInCode(ctx context.Context, email string) error
+		SignInWithCode(ctx context.Context, input auth.CodeSignInInput) (auth.GenerateTokenOutput, error)
+		SendVerificationCode(ctx context.Context, email string) error
+		VerifyEmailCode(ctx context.Context, email, code string) error
+		SendStepUpCode(ctx context.Context, userId uuid.UUID) error
+		StepUp(ctx context.Context, claims *jwtgen.Claims, code string) (auth.GenerateTokenOutput, error)
 		Refresh(ctx context.Context, token string) (auth.GenerateTokenOutput, error)
-		ParseToken(token string) (*jwtgen.Claims, error)
+		ParseToken(ctx context.Context, token string) (*jwtgen.Claims, error)
+		Logout(ctx context.Context, claims *jwtgen.Claims) error
+		VerifyMFA(ctx context.Context, input auth.VerifyMFAInput) (auth.GenerateTokenOutput, error)
+		SendMFACode(ctx context.Context, mfaToken string) error
+		BeginMFAPasskey(ctx context.Context, mfaToken string) (passkey.Ceremony, error)
+		SignInWithPasskey(ctx context.Context, input auth.PasskeySignInInput) (auth.GenerateTokenOutput, error)
+		JWKS() (jwtgen.JWKS, error)
+		Sessions(ctx context.Context, userId uuid.UUID) ([]entity.Session, error)
+		RevokeSession(ctx context.Context, userId, sessionId uuid.UUID) error
 	}
 
 	User interface {
 		Delete(ctx context.Context, u entity.User) error
-		Logout(ctx context.Context, u entity.User) error
 		Update(ctx context.Context, u entity.User) error
 		UserByEmail(ctx context.Context, email string) (entity.User, error)
 		UserById(ctx context.Context, id uuid.UUID) (entity.User, error)
 	}
+
+	MFA interface {
+		EnrollTOTP(ctx context.Context, userId uuid.UUID) (mfa.EnrollTOTPOutput, error)
+		ConfirmTOTP(ctx context.Context, userId uuid.UUID, code string) ([]string, error)
+		DisableTOTP(ctx context.Context, userId uuid.UUID, code string) error
+		RegenerateRecoveryCodes(ctx context.Context, userId uuid.UUID, code string) ([]string, error)
+	}
+
+	Phone interface {
+		SetPhone(ctx context.Context, userId uuid.UUID, phone string) error
+		VerifyPhone(ctx context.Context, userId uuid.UUID, code string) error
+		RemovePhone(ctx context.Context, userId uuid.UUID) error
+		SetSecondFactor(ctx context.Context, userId uuid.UUID, enabled bool) error
+	}
+
+	Passkey interface {
+		BeginRegistration(ctx context.Context, userId uuid.UUID) (passkey.Ceremony, error)
+		FinishRegistration(ctx context.Context, userId uuid.UUID, input passkey.FinishRegistrationInput) (entity.WebAuthnCredential, error)
+		BeginLogin(ctx context.Context) (passkey.Ceremony, error)
+		Credentials(ctx context.Context, userId uuid.UUID) ([]entity.WebAuthnCredential, error)
+		Delete(ctx context.Context, userId uuid.UUID, id []byte) error
+	}
+
+	Federation interface {
+		Providers() []string
+		Begin(ctx context.Context, input federation.BeginInput) (federation.BeginOutput, error)
+		BeginLink(ctx context.Context, userId uuid.UUID, provider string) (federation.BeginOutput, error)
+		Callback(ctx context.Context, input federation.CallbackInput) (federation.CallbackOutput, error)
+		Identities(ctx context.Context, userId uuid.UUID) ([]entity.Identity, error)
+		Unlink(ctx context.Context, userId uuid.UUID, provider, subject string) error
+	}
+
+	Lockout interface {
+		Unlock(ctx context.Context, email string) error
+	}
+
+	Keys interface {
+		Keys(ctx context.Context) ([]entity.SigningKey, error)
+		Rotate(ctx context.Context) (entity.SigningKey, error)
+		Revoke(ctx context.Context, id string) error
+		ApplySchedule(ctx context.Context) error
+		Run(ctx context.Context, interval time.Duration)
+	}
+
+	OAuth interface {
+		ValidateAuthorizationRequest(ctx context.Context, req oauth.AuthorizationRequest) error
+		Authorize(ctx context.Context, input oauth.AuthorizeInput) (string, error)
+		Token(ctx context.Context, req oauth.TokenRequest) (oauth.TokenResponse, error)
+		Introspect(ctx context.Context, req oauth.IntrospectionRequest) (auth.TokenInfo, error)
+		Revoke(ctx context.Context, req oauth.RevocationRequest) error
+	}
+
+	Client interface {
+		Create(ctx context.Context, input client.CreateClientInput) (client.CreateClientOutput, error)
+		Update(ctx context.Context, input client.UpdateClientInput) (entity.Client, error)
+		RotateSecret(ctx context.Context, id string) (string, error)
+		Delete(ctx context.Context, id string) error
+		Client(ctx context.Context, id string) (entity.Client, error)
+		Clients(ctx context.Context) ([]entity.Client, error)
+	}
 )
 
 type (
@@ -41,27 +133,164 @@ type (
 		Cache          redis.Cache
 		TokenGenerator jwtgen.TokenGenerator
 		EmailSender    email.Sender
+		SMSSender      sms.Sender
 
+		// SecretHasher hashes the secrets of clients, it is fast unlike Hasher.
+		// Recovery codes are short enough to brute force offline, they are hashed with Hasher.
+		SecretHasher hasher.PasswordHasher
+
+		AccessTokenTTL  time.Duration
 		RefreshTokenTTL time.Duration
+		IDTokenAudience string
+		OAuthCodeTTL    time.Duration
+
+		// MaxClientAccessTokenTTL and MaxClientRefreshTokenTTL cap the token lifetimes of OAuth clients.
+		MaxClientAccessTokenTTL  time.Duration
+		MaxClientRefreshTokenTTL time.Duration
+
+		EmailVerification auth.EmailVerificationConfig
+		Passwordless      auth.PasswordlessConfig
+		OTP               otp.Config
+		// Lockout is disabled when MaxAttempts is zero.
+		Lockout lockout.Config
+
+		// KeyRing is set when signing keys are stored in the database and rotated.
+		KeyRing     *jwtgen.KeyRing
+		Cipher      cipher.Cipher
+		KeySchedule keys.Schedule
+
+		// MFAIssuer is the account label shown in authenticator apps.
+		MFAIssuer string
+
+		// BreachedPasswords is nil when breached passwords are not screened.
+		BreachedPasswords *breach.Filter
+
+		// WebAuthn is the relying party of passkeys, it is nil when passkeys are disabled.
+		WebAuthn *webauthn.WebAuthn
+
+		// FederationProviders are the external OpenID providers by name, federation is disabled without them.
+		FederationProviders map[string]federation.Provider
+		FederationStateTTL  time.Duration
 	}
 
 	Services struct {
-		Auth Auth
-		User User
+		Auth  Auth
+		User  User
+		Phone Phone
+		// Keys is nil when the key ring is disabled.
+		Keys   Keys
+		OAuth  OAuth
+		Client Client
+		// MFA is nil when no encryption key is configured for the secrets.
+		MFA MFA
+		// Passkey is nil when no WebAuthn relying party is configured.
+		Passkey Passkey
+		// Federation is nil when no external provider is configured.
+		Federation Federation
+		// Lockout is nil when the lockout of failed sign-ins is disabled.
+		Lockout Lockout
 	}
 )
 
 func NewServices(log *slog.Logger, deps ServicesDependencies) *Services {
-	return &Services{
-		Auth: auth.New(
+	var (
+		mfaService     *mfa.Service
+		secondFactor   auth.SecondFactor
+		passkeyService *passkey.Service
+		passkeys       auth.Passkeys
+		lockoutService *lockout.Service
+		lockouts       auth.Lockout
+		breaches       auth.BreachedPasswords
+	)
+	if deps.Cipher != nil {
+		mfaService = mfa.New(
 			log,
-			deps.Cache,
+			deps.Repos.TOTP,
+			deps.Repos.RecoveryCode,
 			deps.Repos.User,
 			deps.Hasher,
-			deps.TokenGenerator,
-			deps.EmailSender,
-			deps.RefreshTokenTTL,
-		),
-		User: user.New(log, deps.Repos.User),
+			deps.Cipher,
+			deps.MFAIssuer,
+		)
+		secondFactor = mfaService
 	}
+
+	if deps.WebAuthn != nil {
+		passkeyService = passkey.New(log, deps.Cache, deps.Repos.WebAuthnCredential, deps.Repos.User, deps.WebAuthn)
+		passkeys = passkeyService
+	}
+
+	if deps.Lockout.MaxAttempts > 0 {
+		lockoutService = lockout.New(log, deps.Cache, deps.Repos.User, deps.EmailSender, deps.Lockout)
+		lockouts = lockoutService
+	}
+
+	if deps.BreachedPasswords != nil {
+		breaches = deps.BreachedPasswords
+	}
+
+	codes := otp.New(log, deps.Cache, deps.EmailSender, deps.SMSSender, deps.OTP)
+
+	authService := auth.New(
+		log,
+		deps.Cache,
+		deps.Repos.User,
+		deps.Hasher,
+		deps.TokenGenerator,
+		deps.EmailSender,
+		deps.RefreshTokenTTL,
+		deps.IDTokenAudience,
+		deps.EmailVerification,
+		deps.Passwordless,
+		codes,
+		secondFactor,
+		passkeys,
+		lockouts,
+		breaches,
+	)
+
+	services := &Services{
+		Auth:   authService,
+		User:   user.New(log, deps.Repos.User),
+		Phone:  phone.New(log, deps.Repos.User, codes),
+		OAuth:  oauth.New(log, deps.Cache, authService, deps.Repos.Client, deps.SecretHasher, deps.OAuthCodeTTL, deps.AccessTokenTTL),
+		Client: client.New(log, deps.Repos.Client, deps.SecretHasher, deps.MaxClientAccessTokenTTL, deps.MaxClientRefreshTokenTTL),
+	}
+
+	if deps.KeyRing != nil {
+		services.Keys = keys.New(log, deps.Repos.SigningKey, deps.Cipher, deps.KeyRing, deps.KeySchedule)
+	}
+
+	if mfaService != nil {
+		services.MFA = mfaService
+	}
+
+	if passkeyService != nil {
+		services.Passkey = passkeyService
+	}
+
+	if lockoutService != nil {
+		services.Lockout = lockoutService
+	}
+
+	if len(deps.FederationProviders) > 0 {
+		// passkeys are a way to sign in only when they are enabled
+		var credentials repo.WebAuthnCredential
+		if passkeyService != nil {
+			credentials = deps.Repos.WebAuthnCredential
+		}
+
+		services.Federation = federation.New(
+			log,
+			deps.Cache,
+			authService,
+			deps.Repos.User,
+			deps.Repos.Identity,
+			credentials,
+			deps.FederationProviders,
+			deps.FederationStateTTL,
+		)
+	}
+
+	return services
 }
@@ -1,18 +1,107 @@
 package svcErrs
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrInvalidCredentials     = errors.New("invalid credentials")
+	ErrAccountLocked          = errors.New("account is temporarily locked, try again later")
+	ErrTooManySignInAttempts  = errors.New("too many sign-in attempts, try again later")
 	ErrCannotParseToken       = errors.New("cannot parse token")
 	ErrTokenIsExpired         = errors.New("token is expired")
+	ErrTokenRevoked           = errors.New("token is revoked")
+	ErrRefreshTokenReused     = errors.New("refresh token reuse detected")
 	ErrCannotSignToken        = errors.New("cannot sign token")
 	ErrAccessToCache          = errors.New("error access to cache service")
 	ErrSendResetPasswordEmail = errors.New("error sending reset password email")
+	ErrSessionNotFound        = errors.New("session not found")
+
+	ErrEmailNotVerified           = errors.New("email is not verified")
+	ErrInvalidVerificationToken   = errors.New("email verification token is invalid or expired")
+	ErrVerificationEmailThrottled = errors.New("verification email was sent recently, try again later")
+	ErrSendVerificationEmail      = errors.New("error sending verification email")
+
+	ErrInvalidMagicLink   = errors.New("sign-in link is invalid or expired")
+	ErrSendMagicLinkEmail = errors.New("error sending sign-in link email")
+
+	ErrOTPLocked    = errors.New("too many wrong one-time codes, try again later")
+	ErrOTPThrottled = errors.New("one-time code was sent recently, try again later")
+	ErrSendOTP      = errors.New("error sending one-time code")
+
+	ErrPhoneNotFound      = errors.New("user has no verified phone")
+	ErrPhoneAlreadyExists = errors.New("phone is verified by another user")
+
+	ErrBreachedPassword = errors.New("password has appeared in a data breach, choose another one")
 
 	ErrCannotCreateUser  = errors.New("cannot create user")
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrCannotGetUser     = errors.New("cannot get user")
 	ErrUserNotFound      = errors.New("user not found")
 	ErrCannotUpdateUser  = errors.New("cannot update user")
+
+	ErrInvalidClient            = errors.New("unknown client")
+	ErrInvalidClientCredentials = errors.New("invalid client credentials")
+	ErrUnauthorizedClient       = errors.New("the client is not allowed to use this grant type")
+	ErrInvalidRedirectURI       = errors.New("redirect_uri is not registered for the client")
+	ErrUnsupportedResponseType  = errors.New("unsupported response_type")
+	ErrUnsupportedGrantType     = errors.New("unsupported grant_type")
+	ErrPKCERequired             = errors.New("code_challenge with code_challenge_method S256 is required")
+	ErrInvalidScope             = errors.New("invalid scope")
+	ErrInvalidGrant             = errors.New("authorization code is invalid or expired")
+	ErrTokenNotIssuedToClient   = errors.New("the token was not issued to the client")
+
+	ErrCannotCreateClient  = errors.New("cannot create client")
+	ErrClientAlreadyExists = errors.New("client already exists")
+	ErrCannotGetClient     = errors.New("cannot get client")
+	ErrClientNotFound      = errors.New("client not found")
+	ErrCannotUpdateClient  = errors.New("cannot update client")
+	ErrInvalidClientConfig = errors.New("invalid client settings")
+
+	ErrMFARequired       = errors.New("one-time code is required")
+	ErrInvalidOTP        = errors.New("invalid one-time code")
+	ErrInvalidMFAToken   = errors.New("mfa token is invalid or expired")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrCannotGetMFA      = errors.New("cannot get two-factor settings")
+	ErrCannotUpdateMFA   = errors.New("cannot update two-factor settings")
+
+	ErrInvalidPasskey           = errors.New("passkey verification failed")
+	ErrInvalidPasskeyCeremony   = errors.New("passkey ceremony is invalid or expired")
+	ErrPasskeyNotFound          = errors.New("passkey not found")
+	ErrPasskeyAlreadyRegistered = errors.New("passkey is already registered")
+	ErrCannotGetPasskeys        = errors.New("cannot get passkeys")
+	ErrCannotUpdatePasskeys     = errors.New("cannot update passkeys")
+
+	ErrUnknownProvider           = errors.New("unknown identity provider")
+	ErrInvalidFederationState    = errors.New("sign-in state is invalid or expired")
+	ErrFederatedSignInFailed     = errors.New("sign in at the identity provider failed")
+	ErrFederatedEmailNotVerified = errors.New("the identity provider did not verify the email")
+	// ErrFederatedAccountUnverified is returned instead of linking a provider to an account nobody proved to own.
+	ErrFederatedAccountUnverified = errors.New("an account with this email exists, verify its email or sign in to link the provider")
+	ErrIdentityNotFound           = errors.New("identity not found")
+	ErrIdentityAlreadyLinked      = errors.New("the identity is linked to another user")
+	ErrLastLoginMethod            = errors.New("cannot unlink the last sign-in method")
+	ErrCannotGetIdentities        = errors.New("cannot get identities")
+	ErrCannotUpdateIdentities     = errors.New("cannot update identities")
+
+	ErrCannotGetKeys    = errors.New("cannot get signing keys")
+	ErrCannotUpdateKeys = errors.New("cannot update signing keys")
+	ErrKeyNotFound      = errors.New("signing key not found")
 )
+
+// RetryAfterError is a rejection that ends after RetryAfter, e.g. a lockout.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
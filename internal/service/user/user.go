@@ -31,11 +31,6 @@ func (s *Service) Delete(ctx context.Context, u entity.User) error {
 	panic("implement me")
 }
 
-func (s *Service) Logout(ctx context.Context, u entity.User) error {
-	//TODO implement me
-	panic("implement me")
-}
-
 func (s *Service) Update(ctx context.Context, u entity.User) error {
 	//TODO implement me
 	panic("implement me")
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id VARCHAR(64) PRIMARY KEY,
+    algorithm VARCHAR(16) NOT NULL,
+    state VARCHAR(16) NOT NULL,
+    private_key bytea NOT NULL,
+    activates_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    retired_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- at most one key can sign and at most one can wait for promotion
+CREATE UNIQUE INDEX signing_keys_single_active ON signing_keys (state) WHERE state = 'active';
+CREATE UNIQUE INDEX signing_keys_single_next ON signing_keys (state) WHERE state = 'next';
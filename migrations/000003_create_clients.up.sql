@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS clients (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    secret_hash bytea,
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    grant_types TEXT[] NOT NULL DEFAULT '{}',
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    audience VARCHAR(255) NOT NULL DEFAULT '',
+    -- lifetimes in seconds, 0 falls back to the global settings
+    access_token_ttl INTEGER NOT NULL DEFAULT 0,
+    refresh_token_ttl INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    -- encrypted with ENCRYPTION_KEY, the user id is bound to the ciphertext
+    secret bytea NOT NULL,
+    -- two-factor authentication is enabled once the first code was confirmed
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    -- time step of the last accepted code, codes cannot be replayed
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS identities (
+    -- the name of the provider in the federation config
+    provider VARCHAR(50) NOT NULL,
+    -- the sub claim of the provider, stable unlike the email
+    subject VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX identities_user_id_idx ON identities (user_id);
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- accounts created before verification was introduced keep working
+UPDATE users SET email_verified_at = created_at;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS users_phone_verified_unique;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS sms_second_factor,
+    DROP COLUMN IF EXISTS phone_verified_at,
+    DROP COLUMN IF EXISTS phone;
\ No newline at end of file
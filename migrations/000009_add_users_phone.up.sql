@@ -0,0 +1,7 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS phone VARCHAR(16),
+    ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS sms_second_factor BOOLEAN NOT NULL DEFAULT false;
+
+-- a number belongs to one account once it is verified, until then several accounts may claim it
+CREATE UNIQUE INDEX IF NOT EXISTS users_phone_verified_unique ON users (phone) WHERE phone_verified_at IS NOT NULL;
\ No newline at end of file
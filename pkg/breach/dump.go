@@ -0,0 +1,49 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadDump calls fn with every hash of a dump in the format of Have I Been Pwned, a SHA-1 in hex
+// and the times it was seen per line, e.g. "7C4A8D09CA3762AF61E59520943DC26494F8941B:24230577".
+// Hashes seen fewer than minCount times are skipped, the count is optional and taken as 1.
+func ReadDump(r io.Reader, minCount int, fn func(hash [sha1.Size]byte)) error {
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		digest, countText, _ := strings.Cut(text, ":")
+
+		var hash [sha1.Size]byte
+		if len(digest) != hex.EncodedLen(sha1.Size) {
+			return fmt.Errorf("line %d: invalid sha-1 %q", line, digest)
+		}
+		if _, err := hex.Decode(hash[:], []byte(digest)); err != nil {
+			return fmt.Errorf("line %d: invalid sha-1 %q: %w", line, digest, err)
+		}
+
+		count := 1
+		if countText != "" {
+			var err error
+			if count, err = strconv.Atoi(countText); err != nil {
+				return fmt.Errorf("line %d: invalid count %q: %w", line, countText, err)
+			}
+		}
+
+		if count >= minCount {
+			fn(hash)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,75 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestReadDump(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dump     string
+		minCount int
+		want     []string
+		wantErr  string
+	}{
+		{
+			name: "OK",
+			dump: "7C4A8D09CA3762AF61E59520943DC26494F8941B:24230577\r\n" +
+				"\n" +
+				"  5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3645804  \n" +
+				"b1b3773a05c0ed0176787a4f1574ff0075f7521e\n",
+			minCount: 1,
+			want: []string{
+				"7c4a8d09ca3762af61e59520943dc26494f8941b",
+				"5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8",
+				"b1b3773a05c0ed0176787a4f1574ff0075f7521e",
+			},
+		},
+		{
+			name: "hashes seen fewer times are skipped",
+			dump: "7C4A8D09CA3762AF61E59520943DC26494F8941B:24230577\n" +
+				"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:2\n" +
+				"B1B3773A05C0ED0176787A4F1574FF0075F7521E\n",
+			minCount: 3,
+			want:     []string{"7c4a8d09ca3762af61e59520943dc26494f8941b"},
+		},
+		{
+			name:     "short hash",
+			dump:     "7C4A8D09CA3762AF61E59520943DC26494F8941B:1\n7C4A8D09:1\n",
+			minCount: 1,
+			wantErr:  "line 2: invalid sha-1",
+		},
+		{
+			name:     "not hex",
+			dump:     "ZC4A8D09CA3762AF61E59520943DC26494F8941B:1\n",
+			minCount: 1,
+			wantErr:  "line 1: invalid sha-1",
+		},
+		{
+			name:     "invalid count",
+			dump:     "7C4A8D09CA3762AF61E59520943DC26494F8941B:many\n",
+			minCount: 1,
+			wantErr:  "line 1: invalid count",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			err := ReadDump(strings.NewReader(tc.dump), tc.minCount, func(hash [sha1.Size]byte) {
+				got = append(got, hex.EncodeToString(hash[:]))
+			})
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	magic      = "UABF"
+	version    = 1
+	headerSize = 24
+
+	// maxBits bounds the filter read, 8 GiB is enough for every hash of Have I Been Pwned at 0.1%.
+	maxBits = 1 << 36
+	// maxHashes bounds the bits set for every hash, a filter at 0.1% sets 10.
+	maxHashes = 64
+)
+
+var ErrInvalidFilter = errors.New("invalid breached passwords filter")
+
+// Filter is a bloom filter of the SHA-1 hashes of breached passwords. It never misses a password
+// that was added, and reports a password that was not at about the false positive rate it was built with.
+type Filter struct {
+	bits []uint64
+	// m is the number of bits, k the number of bits set for every hash.
+	m uint64
+	k uint64
+}
+
+// NewFilter sizes a filter for n hashes at the false positive rate, e.g. 0.001.
+func NewFilter(n uint64, falsePositiveRate float64) *Filter {
+	n = max(n, 1)
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	m = max((m+63)/64*64, 64)
+	k := max(uint64(math.Round(float64(m)/float64(n)*math.Ln2)), 1)
+
+	return &Filter{bits: make([]uint64, m/64), m: m, k: k}
+}
+
+// Add adds the SHA-1 hash of a password.
+func (f *Filter) Add(hash [sha1.Size]byte) {
+	h1, h2 := split(hash)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Has reports whether the SHA-1 hash of a password was added.
+func (f *Filter) Has(hash [sha1.Size]byte) bool {
+	h1, h2 := split(hash)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Breached reports whether the password appeared in a breach.
+func (f *Filter) Breached(password string) bool {
+	return f.Has(sha1.Sum([]byte(password)))
+}
+
+// split derives the two hashes of the double hashing from the SHA-1, which is uniform already.
+func split(hash [sha1.Size]byte) (uint64, uint64) {
+	return binary.BigEndian.Uint64(hash[:8]), binary.BigEndian.Uint64(hash[8:16]) | 1
+}
+
+// WriteTo writes the filter: the magic, the version, m and k, then the bits.
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, magic...)
+	header = binary.BigEndian.AppendUint32(header, version)
+	header = binary.BigEndian.AppendUint64(header, f.m)
+	header = binary.BigEndian.AppendUint64(header, f.k)
+	if _, err := bw.Write(header); err != nil {
+		return 0, err
+	}
+
+	word := make([]byte, 8)
+	for _, b := range f.bits {
+		binary.BigEndian.PutUint64(word, b)
+		if _, err := bw.Write(word); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+
+	return int64(len(header) + 8*len(f.bits)), nil
+}
+
+// ReadFilter reads a filter written by WriteTo, size is the length of the input. The size of the filter
+// in the header is checked against it before the filter is allocated.
+func ReadFilter(r io.Reader, size int64) (*Filter, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+	}
+
+	if string(header[:4]) != magic || binary.BigEndian.Uint32(header[4:8]) != version {
+		return nil, ErrInvalidFilter
+	}
+
+	m, k := binary.BigEndian.Uint64(header[8:16]), binary.BigEndian.Uint64(header[16:24])
+	if m == 0 || m%64 != 0 || m > maxBits || k == 0 || k > maxHashes {
+		return nil, ErrInvalidFilter
+	}
+
+	if size-headerSize != int64(m/8) {
+		return nil, fmt.Errorf("%w: %d bits in %d bytes", ErrInvalidFilter, m, size-headerSize)
+	}
+
+	f := &Filter{bits: make([]uint64, m/64), m: m, k: k}
+	word := make([]byte, 8)
+	for i := range f.bits {
+		if _, err := io.ReadFull(br, word); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+		}
+		f.bits[i] = binary.BigEndian.Uint64(word)
+	}
+
+	return f, nil
+}
+
+// Load reads the filter file built by cmd/breachfilter.
+func Load(path string) (*Filter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadFilter(file, info.Size())
+}
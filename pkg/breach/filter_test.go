@@ -0,0 +1,154 @@
+package breach
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilter_Has(t *testing.T) {
+	f := NewFilter(1000, 0.001)
+
+	for i := range 1000 {
+		f.Add(sha1.Sum([]byte(fmt.Sprintf("breached-%d", i))))
+	}
+
+	for i := range 1000 {
+		assert.True(t, f.Has(sha1.Sum([]byte(fmt.Sprintf("breached-%d", i)))))
+	}
+
+	var falsePositives int
+	for i := range 10000 {
+		if f.Has(sha1.Sum([]byte(fmt.Sprintf("other-%d", i)))) {
+			falsePositives++
+		}
+	}
+	// 10 are expected at 0.1%
+	assert.Less(t, falsePositives, 50)
+
+	assert.True(t, f.Breached("breached-1"))
+	assert.False(t, NewFilter(1000, 0.001).Breached("breached-1"))
+}
+
+func TestFilter_WriteTo(t *testing.T) {
+	f := NewFilter(100, 0.01)
+	f.Add(sha1.Sum([]byte("password")))
+
+	var buf bytes.Buffer
+	n, err := f.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	got, err := ReadFilter(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, f, got)
+	assert.True(t, got.Breached("password"))
+
+	path := filepath.Join(t.TempDir(), "breached.bloom")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+	got, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, f, got)
+}
+
+func TestReadFilter(t *testing.T) {
+	header := func(m, k uint64) []byte {
+		b := []byte(magic)
+		b = binary.BigEndian.AppendUint32(b, version)
+		b = binary.BigEndian.AppendUint64(b, m)
+		return binary.BigEndian.AppendUint64(b, k)
+	}
+
+	testCases := []struct {
+		name    string
+		data    []byte
+		size    int64
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			data: append(header(128, 3), make([]byte, 16)...),
+			size: headerSize + 16,
+		},
+		{
+			name:    "short header",
+			data:    header(128, 3)[:20],
+			size:    20,
+			wantErr: true,
+		},
+		{
+			name:    "other magic",
+			data:    append(append([]byte("XXXX"), header(128, 3)[4:]...), make([]byte, 16)...),
+			size:    headerSize + 16,
+			wantErr: true,
+		},
+		{
+			name:    "zero bits",
+			data:    header(0, 3),
+			size:    headerSize,
+			wantErr: true,
+		},
+		{
+			name:    "bits not in words",
+			data:    append(header(100, 3), make([]byte, 16)...),
+			size:    headerSize + 16,
+			wantErr: true,
+		},
+		{
+			name:    "too many bits",
+			data:    header(1<<62, 3),
+			size:    headerSize + 1<<59,
+			wantErr: true,
+		},
+		{
+			name:    "zero hashes",
+			data:    append(header(128, 0), make([]byte, 16)...),
+			size:    headerSize + 16,
+			wantErr: true,
+		},
+		{
+			name:    "too many hashes",
+			data:    append(header(128, 1<<40), make([]byte, 16)...),
+			size:    headerSize + 16,
+			wantErr: true,
+		},
+		{
+			name:    "bits do not match the size",
+			data:    append(header(1<<30, 3), make([]byte, 16)...),
+			size:    headerSize + 16,
+			wantErr: true,
+		},
+		{
+			name:    "trailing data",
+			data:    append(header(128, 3), make([]byte, 24)...),
+			size:    headerSize + 24,
+			wantErr: true,
+		},
+		{
+			name:    "truncated",
+			data:    append(header(128, 3), make([]byte, 8)...),
+			size:    headerSize + 16,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ReadFilter(bytes.NewReader(tc.data), tc.size)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidFilter)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
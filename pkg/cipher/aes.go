@@ -0,0 +1,70 @@
+// Package cipher implements authenticated encryption of secrets stored at rest.
+package cipher
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
+type Cipher interface {
+	// Encrypt seals plaintext, additionalData is authenticated but not encrypted.
+	Encrypt(plaintext, additionalData []byte) ([]byte, error)
+	Decrypt(ciphertext, additionalData []byte) ([]byte, error)
+}
+
+// AESGCM is an AES-256-GCM Cipher. The random nonce is prepended to the ciphertext.
+type AESGCM struct {
+	aead cipher.AEAD
+}
+
+func NewAESGCM(key []byte) (*AESGCM, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("cipher - NewAESGCM: key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("cipher - NewAESGCM - aes.NewCipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("cipher - NewAESGCM - cipher.NewGCM: %w", err)
+	}
+
+	return &AESGCM{aead: aead}, nil
+}
+
+// NewAESGCMFromBase64 creates the cipher from a base64 (std encoding) key.
+func NewAESGCMFromBase64(key string) (*AESGCM, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("cipher - NewAESGCMFromBase64: %w", err)
+	}
+
+	return NewAESGCM(raw)
+}
+
+func (c *AESGCM) Encrypt(plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return c.aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func (c *AESGCM) Decrypt(ciphertext, additionalData []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, ErrCiphertextTooShort
+	}
+
+	return c.aead.Open(nil, ciphertext[:size], ciphertext[size:], additionalData)
+}
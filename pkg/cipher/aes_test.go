@@ -0,0 +1,209 @@
+package cipher
+
+import (
+	"bytes"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var (
+	testKey  = bytes.Repeat([]byte{1}, 32)
+	otherKey = bytes.Repeat([]byte{2}, 32)
+)
+
+func TestNewAESGCM(t *testing.T) {
+	testCases := []struct {
+		name    string
+		key     []byte
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			key:  testKey,
+		},
+		{
+			name:    "AES-128 key",
+			key:     bytes.Repeat([]byte{1}, 16),
+			wantErr: true,
+		},
+		{
+			name:    "empty key",
+			key:     nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewAESGCM(tc.key)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, c)
+		})
+	}
+}
+
+func TestNewAESGCMFromBase64(t *testing.T) {
+	testCases := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			key:  base64.StdEncoding.EncodeToString(testKey),
+		},
+		{
+			name:    "not base64",
+			key:     "not base64",
+			wantErr: true,
+		},
+		{
+			name:    "short key",
+			key:     base64.StdEncoding.EncodeToString(testKey[:16]),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewAESGCMFromBase64(tc.key)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, c)
+		})
+	}
+}
+
+func TestAESGCM_Decrypt(t *testing.T) {
+	c, err := NewAESGCM(testKey)
+	require.NoError(t, err)
+
+	other, err := NewAESGCM(otherKey)
+	require.NoError(t, err)
+
+	plaintext := []byte("JBSWY3DPEHPK3PXP")
+	additionalData := []byte("user-id")
+
+	ciphertext, err := c.Encrypt(plaintext, additionalData)
+	require.NoError(t, err)
+
+	// flip returns a copy of the ciphertext with the bit of the byte at i flipped
+	flip := func(i int) []byte {
+		tampered := bytes.Clone(ciphertext)
+		tampered[i] ^= 1
+		return tampered
+	}
+
+	testCases := []struct {
+		name           string
+		cipher         *AESGCM
+		ciphertext     []byte
+		additionalData []byte
+		want           []byte
+		wantErr        error
+		wantAnyErr     bool
+	}{
+		{
+			name:           "OK",
+			cipher:         c,
+			ciphertext:     ciphertext,
+			additionalData: additionalData,
+			want:           plaintext,
+		},
+		{
+			name:           "wrong key",
+			cipher:         other,
+			ciphertext:     ciphertext,
+			additionalData: additionalData,
+			wantAnyErr:     true,
+		},
+		{
+			name:           "tampered ciphertext",
+			cipher:         c,
+			ciphertext:     flip(len(ciphertext) - 1),
+			additionalData: additionalData,
+			wantAnyErr:     true,
+		},
+		{
+			name:           "tampered nonce",
+			cipher:         c,
+			ciphertext:     flip(0),
+			additionalData: additionalData,
+			wantAnyErr:     true,
+		},
+		{
+			name:           "other additional data",
+			cipher:         c,
+			ciphertext:     ciphertext,
+			additionalData: []byte("other-user-id"),
+			wantAnyErr:     true,
+		},
+		{
+			name:           "shorter than the nonce",
+			cipher:         c,
+			ciphertext:     ciphertext[:11],
+			additionalData: additionalData,
+			wantErr:        ErrCiphertextTooShort,
+		},
+		{
+			name:           "empty",
+			cipher:         c,
+			ciphertext:     nil,
+			additionalData: additionalData,
+			wantErr:        ErrCiphertextTooShort,
+		},
+		{
+			name:           "nonce only",
+			cipher:         c,
+			ciphertext:     ciphertext[:12],
+			additionalData: additionalData,
+			wantAnyErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.cipher.Decrypt(tc.ciphertext, tc.additionalData)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			if tc.wantAnyErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestAESGCM_Encrypt(t *testing.T) {
+	c, err := NewAESGCM(testKey)
+	require.NoError(t, err)
+
+	first, err := c.Encrypt([]byte("secret"), nil)
+	require.NoError(t, err)
+
+	second, err := c.Encrypt([]byte("secret"), nil)
+	require.NoError(t, err)
+
+	// the nonce is random, the same plaintext is sealed differently every time
+	assert.NotEqual(t, first, second)
+	// nonce, ciphertext and tag
+	assert.Len(t, first, 12+len("secret")+16)
+	assert.NotContains(t, string(first), "secret")
+}
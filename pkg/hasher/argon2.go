@@ -0,0 +1,128 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+const (
+	argon2idPrefix = "$argon2id$"
+
+	// the second recommended option of RFC 9106
+	defaultArgon2Memory      = 64 * 1024
+	defaultArgon2Time        = 3
+	defaultArgon2Parallelism = 4
+	argon2SaltLength         = 16
+	argon2KeyLength          = 32
+
+	// the bounds of the hashes that are compared, a hash out of them would panic or take unbounded memory or time
+	maxArgon2Memory           = 2 * 1024 * 1024 // the first recommended option of RFC 9106
+	maxArgon2Time             = 32
+	minArgon2SaltLength       = 8
+	minArgon2KeyLength        = 16
+	maxArgon2SaltAndKeyLength = 64
+)
+
+// Argon2idPasswordHasher hashes passwords with Argon2id, the hashes are PHC strings,
+// e.g. "$argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>" with the salt and the key in unpadded base64.
+type Argon2idPasswordHasher struct {
+	// memory is in KiB.
+	memory      uint32
+	time        uint32
+	parallelism uint8
+}
+
+func NewArgon2idHasher(opts ...Argon2Option) *Argon2idPasswordHasher {
+	h := &Argon2idPasswordHasher{
+		memory:      defaultArgon2Memory,
+		time:        defaultArgon2Time,
+		parallelism: defaultArgon2Parallelism,
+	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+func (h *Argon2idPasswordHasher) Hash(password string) ([]byte, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.time, h.memory, h.parallelism, argon2KeyLength)
+
+	return []byte(fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, h.memory, h.time, h.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)), nil
+}
+
+// Compare uses the parameters of the hash, hashes made before a change of the options still match.
+func (h *Argon2idPasswordHasher) Compare(hashedPassword, password []byte) error {
+	params, salt, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey(password, salt, params.time, params.memory, params.parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatchedHashAndPassword
+	}
+
+	return nil
+}
+
+// NeedsRehash reports whether the hash is not an Argon2id hash of the parameters of the hasher.
+func (h *Argon2idPasswordHasher) NeedsRehash(hashedPassword []byte) bool {
+	params, _, _, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	return params.memory != h.memory || params.time != h.time || params.parallelism != h.parallelism
+}
+
+func decodeArgon2id(hashedPassword []byte) (*Argon2idPasswordHasher, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=4", salt, key
+	parts := strings.Split(string(hashedPassword), "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	params := &Argon2idPasswordHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.parallelism); err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	// argon2 needs at least 8 KiB of memory per thread
+	if params.time < 1 || params.time > maxArgon2Time || params.parallelism < 1 ||
+		params.memory < 8*uint32(params.parallelism) || params.memory > maxArgon2Memory {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) < minArgon2SaltLength || len(salt) > maxArgon2SaltAndKeyLength {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) < minArgon2KeyLength || len(key) > maxArgon2SaltAndKeyLength {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	return params, salt, key, nil
+}
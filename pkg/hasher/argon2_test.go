@@ -0,0 +1,183 @@
+package hasher
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newTestArgon2idHasher(opts ...Argon2Option) *Argon2idPasswordHasher {
+	return NewArgon2idHasher(append([]Argon2Option{Memory(64), Time(1), Parallelism(1)}, opts...)...)
+}
+
+func TestArgon2idPasswordHasher_Compare(t *testing.T) {
+	h := newTestArgon2idHasher()
+
+	hash, err := h.Hash("Passw0rd!")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=64,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`, string(hash))
+
+	testCases := []struct {
+		name     string
+		hash     string
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "OK",
+			hash:     string(hash),
+			password: "Passw0rd!",
+		},
+		{
+			name:     "wrong password",
+			hash:     string(hash),
+			password: "passw0rd!",
+			wantErr:  ErrMismatchedHashAndPassword,
+		},
+		{
+			name:     "other parameters",
+			hash:     "$argon2id$v=19$m=32,t=2,p=2$c29tZXNhbHRzb21lc2FsdA$m7YeSi4yGMXPbPN5ljvB7w0ab9h2rOcwlsXyp+2rQr4",
+			password: "Passw0rd!",
+			wantErr:  ErrMismatchedHashAndPassword,
+		},
+		{
+			name:     "other algorithm",
+			hash:     "$argon2i$v=19$m=64,t=1,p=1$c29tZXNhbHRzb21lc2FsdA$m7YeSi4yGMXPbPN5ljvB7w0ab9h2rOcwlsXyp+2rQr4",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "other version",
+			hash:     "$argon2id$v=16$m=64,t=1,p=1$c29tZXNhbHRzb21lc2FsdA$m7YeSi4yGMXPbPN5ljvB7w0ab9h2rOcwlsXyp+2rQr4",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "missing part",
+			hash:     "$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHRzb21lc2FsdA",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "malformed parameters",
+			hash:     "$argon2id$v=19$t=1,m=64,p=1$c29tZXNhbHRzb21lc2FsdA$m7YeSi4yGMXPbPN5ljvB7w0ab9h2rOcwlsXyp+2rQr4",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "zero time",
+			hash:     "$argon2id$v=19$m=64,t=0,p=1$c29tZXNhbHRzb21lc2FsdA$m7YeSi4yGMXPbPN5ljvB7w0ab9h2rOcwlsXyp+2rQr4",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "too much time",
+			hash:     "$argon2id$v=19$m=64,t=4294967295,p=1$c29tZXNhbHRzb21lc2FsdA$m7YeSi4yGMXPbPN5ljvB7w0ab9h2rOcwlsXyp+2rQr4",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "zero parallelism",
+			hash:     "$argon2id$v=19$m=64,t=1,p=0$c29tZXNhbHRzb21lc2FsdA$m7YeSi4yGMXPbPN5ljvB7w0ab9h2rOcwlsXyp+2rQr4",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "parallelism out of range",
+			hash:     "$argon2id$v=19$m=65536,t=1,p=256$c29tZXNhbHRzb21lc2FsdA$m7YeSi4yGMXPbPN5ljvB7w0ab9h2rOcwlsXyp+2rQr4",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "too little memory per thread",
+			hash:     "$argon2id$v=19$m=8,t=1,p=2$c29tZXNhbHRzb21lc2FsdA$m7YeSi4yGMXPbPN5ljvB7w0ab9h2rOcwlsXyp+2rQr4",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "too much memory",
+			hash:     "$argon2id$v=19$m=4294967295,t=1,p=1$c29tZXNhbHRzb21lc2FsdA$m7YeSi4yGMXPbPN5ljvB7w0ab9h2rOcwlsXyp+2rQr4",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "short salt",
+			hash:     "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$m7YeSi4yGMXPbPN5ljvB7w0ab9h2rOcwlsXyp+2rQr4",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "short key",
+			hash:     "$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHRzb21lc2FsdA$a2V5",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "key is not base64",
+			hash:     "$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHRzb21lc2FsdA$not base64",
+			password: "Passw0rd!",
+			wantErr:  ErrInvalidHash,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := h.Compare([]byte(tc.hash), []byte(tc.password))
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestArgon2idPasswordHasher_NeedsRehash(t *testing.T) {
+	hash, err := newTestArgon2idHasher().Hash("Passw0rd!")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		hasher *Argon2idPasswordHasher
+		hash   []byte
+		want   bool
+	}{
+		{
+			name:   "same parameters",
+			hasher: newTestArgon2idHasher(),
+			hash:   hash,
+			want:   false,
+		},
+		{
+			name:   "other memory",
+			hasher: newTestArgon2idHasher(Memory(128)),
+			hash:   hash,
+			want:   true,
+		},
+		{
+			name:   "other time",
+			hasher: newTestArgon2idHasher(Time(2)),
+			hash:   hash,
+			want:   true,
+		},
+		{
+			name:   "other parallelism",
+			hasher: newTestArgon2idHasher(Parallelism(2)),
+			hash:   hash,
+			want:   true,
+		},
+		{
+			name:   "invalid hash",
+			hasher: newTestArgon2idHasher(),
+			hash:   []byte("$argon2id$v=19$m=64,t=0,p=1$c29tZXNhbHRzb21lc2FsdA$m7YeSi4yGMXPbPN5ljvB7w0ab9h2rOcwlsXyp+2rQr4"),
+			want:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.hasher.NeedsRehash(tc.hash))
+		})
+	}
+}
@@ -9,3 +9,27 @@ func Cost(cost int) Option {
 		s.cost = cost
 	}
 }
+
+// Argon2Option -.
+type Argon2Option func(hasher *Argon2idPasswordHasher)
+
+// Memory is in KiB.
+func Memory(memory uint32) Argon2Option {
+	return func(s *Argon2idPasswordHasher) {
+		s.memory = memory
+	}
+}
+
+// Time is the number of passes over the memory.
+func Time(time uint32) Argon2Option {
+	return func(s *Argon2idPasswordHasher) {
+		s.time = time
+	}
+}
+
+// Parallelism is the number of threads.
+func Parallelism(parallelism uint8) Argon2Option {
+	return func(s *Argon2idPasswordHasher) {
+		s.parallelism = parallelism
+	}
+}
@@ -1,16 +1,32 @@
 package hasher
 
 import (
+	"errors"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 )
 
 const (
 	defaultCost = bcrypt.DefaultCost
 )
 
+// Algorithms of the hashes.
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmSHA256   = "sha256"
+)
+
+var (
+	ErrMismatchedHashAndPassword = bcrypt.ErrMismatchedHashAndPassword
+	ErrInvalidHash               = errors.New("invalid password hash")
+)
+
 type PasswordHasher interface {
 	Hash(password string) ([]byte, error)
 	Compare(hashedPassword, password []byte) error
+	// NeedsRehash reports whether the hash is outdated, of another algorithm or cost, and should be replaced.
+	NeedsRehash(hashedPassword []byte) bool
 }
 
 type BcryptPasswordHasher struct {
@@ -37,3 +53,61 @@ func (h *BcryptPasswordHasher) Compare(hashedPassword, password []byte) error {
 func (h *BcryptPasswordHasher) Hash(password string) ([]byte, error) {
 	return bcrypt.GenerateFromPassword([]byte(password), h.cost)
 }
+
+func (h *BcryptPasswordHasher) NeedsRehash(hashedPassword []byte) bool {
+	cost, err := bcrypt.Cost(hashedPassword)
+	return err != nil || cost != h.cost
+}
+
+// Algorithm detects the algorithm of the hash, it is empty for an unknown one.
+func Algorithm(hashedPassword []byte) string {
+	switch hash := string(hashedPassword); {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return AlgorithmArgon2id
+	case strings.HasPrefix(hash, sha256Prefix):
+		return AlgorithmSHA256
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return AlgorithmBcrypt
+	default:
+		return ""
+	}
+}
+
+// DetectingPasswordHasher hashes with the preferred algorithm and compares every hash with
+// the hasher of its own algorithm, so that the hashes of an algorithm given up still match.
+type DetectingPasswordHasher struct {
+	preferred string
+	hashers   map[string]PasswordHasher
+}
+
+func NewDetectingHasher(preferred string, bcryptHasher *BcryptPasswordHasher, argon2idHasher *Argon2idPasswordHasher) *DetectingPasswordHasher {
+	return &DetectingPasswordHasher{
+		preferred: preferred,
+		hashers: map[string]PasswordHasher{
+			AlgorithmBcrypt:   bcryptHasher,
+			AlgorithmArgon2id: argon2idHasher,
+		},
+	}
+}
+
+func (h *DetectingPasswordHasher) Hash(password string) ([]byte, error) {
+	return h.hashers[h.preferred].Hash(password)
+}
+
+func (h *DetectingPasswordHasher) Compare(hashedPassword, password []byte) error {
+	hasher, ok := h.hashers[Algorithm(hashedPassword)]
+	if !ok {
+		return ErrInvalidHash
+	}
+
+	return hasher.Compare(hashedPassword, password)
+}
+
+// NeedsRehash reports the hashes of another algorithm than the preferred one, and of other parameters.
+func (h *DetectingPasswordHasher) NeedsRehash(hashedPassword []byte) bool {
+	if Algorithm(hashedPassword) != h.preferred {
+		return true
+	}
+
+	return h.hashers[h.preferred].NeedsRehash(hashedPassword)
+}
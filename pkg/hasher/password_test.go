@@ -0,0 +1,208 @@
+package hasher
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+)
+
+func TestAlgorithm(t *testing.T) {
+	testCases := []struct {
+		hash string
+		want string
+	}{
+		{hash: "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$a2V5", want: AlgorithmArgon2id},
+		{hash: "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", want: AlgorithmBcrypt},
+		{hash: "$2b$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", want: AlgorithmBcrypt},
+		{hash: "$2y$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", want: AlgorithmBcrypt},
+		{hash: "$sha256$c2FsdA$bWFj", want: AlgorithmSHA256},
+		{hash: "$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5", want: ""},
+		{hash: "plain", want: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.hash, func(t *testing.T) {
+			assert.Equal(t, tc.want, Algorithm([]byte(tc.hash)))
+		})
+	}
+}
+
+func TestDetectingPasswordHasher(t *testing.T) {
+	bcryptHasher := NewBcryptHasher(Cost(bcrypt.MinCost))
+	argon2idHasher := newTestArgon2idHasher()
+
+	bcryptHash, err := bcryptHasher.Hash("Passw0rd!")
+	require.NoError(t, err)
+	argon2idHash, err := argon2idHasher.Hash("Passw0rd!")
+	require.NoError(t, err)
+	otherCostHash, err := NewBcryptHasher(Cost(bcrypt.MinCost + 1)).Hash("Passw0rd!")
+	require.NoError(t, err)
+	otherParamsHash, err := newTestArgon2idHasher(Time(2)).Hash("Passw0rd!")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		preferred   string
+		hash        []byte
+		password    string
+		wantErr     error
+		wantRehash  bool
+		wantNewAlgo string
+	}{
+		{
+			name:        "argon2id",
+			preferred:   AlgorithmArgon2id,
+			hash:        argon2idHash,
+			password:    "Passw0rd!",
+			wantNewAlgo: AlgorithmArgon2id,
+		},
+		{
+			name:        "bcrypt fallback",
+			preferred:   AlgorithmArgon2id,
+			hash:        bcryptHash,
+			password:    "Passw0rd!",
+			wantRehash:  true,
+			wantNewAlgo: AlgorithmArgon2id,
+		},
+		{
+			name:        "argon2id of other parameters",
+			preferred:   AlgorithmArgon2id,
+			hash:        otherParamsHash,
+			password:    "Passw0rd!",
+			wantRehash:  true,
+			wantNewAlgo: AlgorithmArgon2id,
+		},
+		{
+			name:        "bcrypt",
+			preferred:   AlgorithmBcrypt,
+			hash:        bcryptHash,
+			password:    "Passw0rd!",
+			wantNewAlgo: AlgorithmBcrypt,
+		},
+		{
+			name:        "bcrypt of another cost",
+			preferred:   AlgorithmBcrypt,
+			hash:        otherCostHash,
+			password:    "Passw0rd!",
+			wantRehash:  true,
+			wantNewAlgo: AlgorithmBcrypt,
+		},
+		{
+			name:        "argon2id after going back to bcrypt",
+			preferred:   AlgorithmBcrypt,
+			hash:        argon2idHash,
+			password:    "Passw0rd!",
+			wantRehash:  true,
+			wantNewAlgo: AlgorithmBcrypt,
+		},
+		{
+			name:        "wrong password",
+			preferred:   AlgorithmArgon2id,
+			hash:        bcryptHash,
+			password:    "passw0rd!",
+			wantErr:     ErrMismatchedHashAndPassword,
+			wantRehash:  true,
+			wantNewAlgo: AlgorithmArgon2id,
+		},
+		{
+			name:        "unknown algorithm",
+			preferred:   AlgorithmArgon2id,
+			hash:        []byte("Passw0rd!"),
+			password:    "Passw0rd!",
+			wantErr:     ErrInvalidHash,
+			wantRehash:  true,
+			wantNewAlgo: AlgorithmArgon2id,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewDetectingHasher(tc.preferred, bcryptHasher, argon2idHasher)
+
+			err := h.Compare(tc.hash, []byte(tc.password))
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.wantRehash, h.NeedsRehash(tc.hash))
+
+			hash, err := h.Hash(tc.password)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantNewAlgo, Algorithm(hash))
+			assert.False(t, h.NeedsRehash(hash))
+		})
+	}
+}
+
+func TestSecretHasher(t *testing.T) {
+	legacy := NewDetectingHasher(AlgorithmArgon2id, NewBcryptHasher(Cost(bcrypt.MinCost)), newTestArgon2idHasher())
+	h := NewSecretHasher(legacy)
+
+	hash, err := h.Hash("secret")
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmSHA256, Algorithm(hash))
+	assert.False(t, h.NeedsRehash(hash))
+
+	legacyHash, err := legacy.Hash("secret")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		hash    []byte
+		secret  string
+		wantErr error
+	}{
+		{
+			name:   "OK",
+			hash:   hash,
+			secret: "secret",
+		},
+		{
+			name:    "wrong secret",
+			hash:    hash,
+			secret:  "Secret",
+			wantErr: ErrMismatchedHashAndPassword,
+		},
+		{
+			name:   "legacy hash",
+			hash:   legacyHash,
+			secret: "secret",
+		},
+		{
+			name:    "wrong secret of a legacy hash",
+			hash:    legacyHash,
+			secret:  "Secret",
+			wantErr: ErrMismatchedHashAndPassword,
+		},
+		{
+			name:    "short mac",
+			hash:    []byte("$sha256$c29tZXNhbHRzb21lc2FsdA$bWFj"),
+			secret:  "secret",
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name:    "missing part",
+			hash:    []byte("$sha256$c29tZXNhbHRzb21lc2FsdA"),
+			secret:  "secret",
+			wantErr: ErrInvalidHash,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := h.Compare(tc.hash, []byte(tc.secret))
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+
+	assert.True(t, h.NeedsRehash(legacyHash))
+	assert.ErrorIs(t, NewSecretHasher(nil).Compare(legacyHash, []byte("secret")), ErrInvalidHash)
+}
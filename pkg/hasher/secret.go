@@ -0,0 +1,83 @@
+package hasher
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const (
+	sha256Prefix     = "$sha256$"
+	sha256SaltLength = 16
+)
+
+// SecretHasher hashes generated secrets of at least 128 bits, e.g. the secrets of OAuth clients, with HMAC-SHA256
+// keyed by a random salt. Such secrets cannot be brute forced even from a leaked hash, so unlike passwords
+// they do not need a slow hash that lets every request with a wrong secret cost the server. Anything shorter,
+// e.g. recovery codes, must be hashed with a PasswordHasher. The hashes are "$sha256$<salt>$<mac>"
+// in unpadded base64, the hashes of other algorithms are compared by the legacy hasher.
+type SecretHasher struct {
+	legacy PasswordHasher
+}
+
+// NewSecretHasher -. legacy compares the hashes of the secrets made before, it may be nil.
+func NewSecretHasher(legacy PasswordHasher) *SecretHasher {
+	return &SecretHasher{legacy: legacy}
+}
+
+func (h *SecretHasher) Hash(secret string) ([]byte, error) {
+	salt := make([]byte, sha256SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("%s%s$%s", sha256Prefix,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(secretMAC(salt, []byte(secret))),
+	)), nil
+}
+
+func (h *SecretHasher) Compare(hashedSecret, secret []byte) error {
+	if Algorithm(hashedSecret) != AlgorithmSHA256 {
+		if h.legacy == nil {
+			return ErrInvalidHash
+		}
+		return h.legacy.Compare(hashedSecret, secret)
+	}
+
+	// "", "sha256", salt, mac
+	parts := strings.Split(string(hashedSecret), "$")
+	if len(parts) != 4 {
+		return ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil || len(salt) == 0 {
+		return ErrInvalidHash
+	}
+
+	mac, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(mac) != sha256.Size {
+		return ErrInvalidHash
+	}
+
+	if !hmac.Equal(mac, secretMAC(salt, secret)) {
+		return ErrMismatchedHashAndPassword
+	}
+
+	return nil
+}
+
+// NeedsRehash reports the hashes of the legacy hasher.
+func (h *SecretHasher) NeedsRehash(hashedSecret []byte) bool {
+	return Algorithm(hashedSecret) != AlgorithmSHA256
+}
+
+func secretMAC(salt, secret []byte) []byte {
+	m := hmac.New(sha256.New, salt)
+	m.Write(secret)
+	return m.Sum(nil)
+}
@@ -15,3 +15,11 @@ func ErrMap(errs map[string]string) slog.Attr {
 		Value: slog.AnyValue(errs),
 	}
 }
+
+// SecurityEvent marks a log record as a security relevant event.
+func SecurityEvent(name string) slog.Attr {
+	return slog.Attr{
+		Key:   "security_event",
+		Value: slog.StringValue(name),
+	}
+}
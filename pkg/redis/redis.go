@@ -2,14 +2,92 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/redis/go-redis/v9"
+	"strconv"
 	"time"
 )
 
 type Cache interface {
 	Set(ctx context.Context, key string, value string, ttl time.Duration) error
 	Get(ctx context.Context, key string) (string, error)
+	// GetDel returns the value and deletes the key atomically.
+	GetDel(ctx context.Context, key string) (string, error)
 	Delete(ctx context.Context, key string) error
+	// Exists reports whether the key is set, unlike Get a missing key is not an error.
+	Exists(ctx context.Context, key string) (bool, error)
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	// Incr increments the counter and returns its new value, the ttl is set when the counter is created.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Count returns the value of a counter set by Incr, it is zero for a missing key.
+	Count(ctx context.Context, key string) (int64, error)
+	// TTL returns the time left before the key expires, it is not positive for a missing key.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Hit counts a hit in the current counters of the windows if every window has room for it,
+	// the check and the increments are atomic. It returns the counts of the windows before the hit.
+	Hit(ctx context.Context, windows []Window) (counts []WindowCount, allowed bool, err error)
+
+	SAdd(ctx context.Context, key string, members ...string) error
+	SRem(ctx context.Context, key string, members ...string) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+}
+
+// incrScript increments a counter and starts its expiry with the first increment, atomically.
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// hitScript checks the windows given as pairs of the previous and the current counter keys, with the weight
+// of the previous counter, the limit and the ttl of the current counter as arguments, and increments
+// the current counters when every window has room for the hit. It returns whether the hit was counted
+// and the previous and current counts of every window.
+var hitScript = redis.NewScript(`
+local res = {1}
+for i = 1, #KEYS / 2 do
+	local previous = tonumber(redis.call("GET", KEYS[2 * i - 1]) or "0")
+	local current = tonumber(redis.call("GET", KEYS[2 * i]) or "0")
+	if previous * tonumber(ARGV[3 * i - 2]) + current + 1 > tonumber(ARGV[3 * i - 1]) then
+		res[1] = 0
+	end
+	res[2 * i] = previous
+	res[2 * i + 1] = current
+end
+if res[1] == 1 then
+	for i = 1, #KEYS / 2 do
+		if redis.call("INCR", KEYS[2 * i]) == 1 then
+			redis.call("PEXPIRE", KEYS[2 * i], ARGV[3 * i])
+		end
+	end
+end
+return res
+`)
+
+type (
+	// Window is a sliding window of two fixed window counters, the count of the previous one weighs Weight.
+	Window struct {
+		Previous string
+		Current  string
+		Weight   float64
+		Limit    int64
+		// TTL is set when the current counter is created.
+		TTL time.Duration
+	}
+
+	// WindowCount is the state of a window before a hit.
+	WindowCount struct {
+		Previous int64
+		Current  int64
+	}
+)
+
+// Allows reports whether the window has room for one more hit.
+func (w Window) Allows(count WindowCount) bool {
+	return float64(count.Previous)*w.Weight+float64(count.Current)+1 <= float64(w.Limit)
 }
 
 type Client struct {
@@ -49,10 +127,88 @@ func (r *Client) Get(ctx context.Context, key string) (string, error) {
 	return r.client.Get(ctx, key).Result()
 }
 
+func (r *Client) GetDel(ctx context.Context, key string) (string, error) {
+	return r.client.GetDel(ctx, key).Result()
+}
+
 func (r *Client) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+func (r *Client) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := r.client.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+func (r *Client) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return r.client.Expire(ctx, key, ttl).Err()
+}
+
+func (r *Client) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, r.client, []string{key}, ttl.Milliseconds()).Int64()
+}
+
+func (r *Client) Count(ctx context.Context, key string) (int64, error) {
+	value, err := r.client.Get(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func (r *Client) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return r.client.PTTL(ctx, key).Result()
+}
+
+func (r *Client) Hit(ctx context.Context, windows []Window) ([]WindowCount, bool, error) {
+	keys := make([]string, 0, 2*len(windows))
+	args := make([]any, 0, 3*len(windows))
+	for _, w := range windows {
+		keys = append(keys, w.Previous, w.Current)
+		args = append(args, strconv.FormatFloat(w.Weight, 'g', -1, 64), w.Limit, w.TTL.Milliseconds())
+	}
+
+	res, err := hitScript.Run(ctx, r.client, keys, args...).Int64Slice()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(res) != 1+2*len(windows) {
+		return nil, false, fmt.Errorf("unexpected reply of %d values for %d windows", len(res), len(windows))
+	}
+
+	counts := make([]WindowCount, len(windows))
+	for i := range counts {
+		counts[i] = WindowCount{Previous: res[1+2*i], Current: res[2+2*i]}
+	}
+
+	return counts, res[0] == 1, nil
+}
+
+func (r *Client) SAdd(ctx context.Context, key string, members ...string) error {
+	return r.client.SAdd(ctx, key, toAny(members)...).Err()
+}
+
+func (r *Client) SRem(ctx context.Context, key string, members ...string) error {
+	return r.client.SRem(ctx, key, toAny(members)...).Err()
+}
+
+func (r *Client) SMembers(ctx context.Context, key string) ([]string, error) {
+	return r.client.SMembers(ctx, key).Result()
+}
+
 func (r *Client) Close() error {
 	return r.client.Close()
 }
+
+func toAny(values []string) []any {
+	res := make([]any, len(values))
+	for i, v := range values {
+		res[i] = v
+	}
+	return res
+}
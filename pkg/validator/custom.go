@@ -4,33 +4,28 @@ import (
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"reflect"
-	"regexp"
-)
-
-const (
-	passwordMinLength = 8
-	passwordMaxLength = 32
-	passwordMinLower  = 1
-	passwordMinUpper  = 1
-	passwordMinDigit  = 1
-	passwordMinSymbol = 1
-)
-
-var (
-	lengthRegexp    = regexp.MustCompile(fmt.Sprintf(`^.{%d,%d}$`, passwordMinLength, passwordMaxLength))
-	lowerCaseRegexp = regexp.MustCompile(fmt.Sprintf(`[a-z]{%d,}`, passwordMinLower))
-	upperCaseRegexp = regexp.MustCompile(fmt.Sprintf(`[A-Z]{%d,}`, passwordMinUpper))
-	digitRegexp     = regexp.MustCompile(fmt.Sprintf(`[0-9]{%d,}`, passwordMinDigit))
-	symbolRegexp    = regexp.MustCompile(fmt.Sprintf(`[!@#$%%^&*]{%d,}`, passwordMinSymbol))
+	"strings"
 )
 
 type CustomValidator struct {
 	V *validator.Validate
+
+	policy PasswordPolicy
+	// breaches is nil when breached passwords are not screened.
+	breaches BreachChecker
 }
 
-func NewCustomValidator() *CustomValidator {
+func NewCustomValidator(opts ...Option) *CustomValidator {
 	v := validator.New(validator.WithRequiredStructEnabled())
-	cv := &CustomValidator{V: v}
+	cv := &CustomValidator{
+		V:      v,
+		policy: DefaultPasswordPolicy(),
+	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(cv)
+	}
 
 	err := v.RegisterValidation("password", cv.passwordValidate)
 	if err != nil {
@@ -40,16 +35,35 @@ func NewCustomValidator() *CustomValidator {
 	return cv
 }
 
+// PasswordPolicy returns the policy of the password tag.
+func (cv *CustomValidator) PasswordPolicy() PasswordPolicy {
+	return cv.policy
+}
+
+// CheckPassword checks the password against the policy and, when they are screened, the known data breaches.
+func (cv *CustomValidator) CheckPassword(password string, userInputs ...string) PasswordCheck {
+	res := cv.policy.Check(password, userInputs...)
+	if cv.breaches == nil {
+		return res
+	}
+
+	passed := !cv.breaches.Breached(password)
+	res.Rules = append(res.Rules, PasswordRule{Rule: RuleBreached, Passed: passed, Message: "Not found in known data breaches"})
+	res.Valid = res.Valid && passed
+
+	return res
+}
+
 func (cv *CustomValidator) ValidateStruct(data interface{}) map[string]string {
 	err := cv.V.Struct(data)
 	if err != nil {
-		return parseErrors(err)
+		return cv.parseErrors(data, err)
 	}
 
 	return nil
 }
 
-func parseErrors(err error) map[string]string {
+func (cv *CustomValidator) parseErrors(data interface{}, err error) map[string]string {
 	errors := make(map[string]string)
 
 	for _, err := range err.(validator.ValidationErrors) {
@@ -60,19 +74,17 @@ func parseErrors(err error) map[string]string {
 		case "email":
 			errors[field] = fmt.Sprintf("Invalid email format")
 		case "password":
-			errors[field] = fmt.Sprintf(
-				"%s must be between %d and %d in length"+
-					", contain at least %d lowercase"+
-					", %d uppercase"+
-					", %d digits"+
-					", and %d special characters (!@#$%%^&*)",
-				field,
-				passwordMinLength, passwordMaxLength,
-				passwordMinLower,
-				passwordMinUpper,
-				passwordMinDigit,
-				passwordMinSymbol,
-			)
+			password, _ := err.Value().(string)
+			// the same check as passwordValidate, the message is of the first rule that failed
+			res := cv.CheckPassword(password, userInputs(parentOf(data, err.StructNamespace()))...)
+			switch failedRule(res) {
+			case RuleStrength:
+				errors[field] = fmt.Sprintf("%s is too easy to guess", field)
+			case RuleBreached:
+				errors[field] = fmt.Sprintf("%s has appeared in a data breach, choose another one", field)
+			default:
+				errors[field] = cv.policy.message(field)
+			}
 		case "min":
 			errors[field] = fmt.Sprintf("Must be longer than %s", err.Param())
 		case "max":
@@ -90,19 +102,45 @@ func (cv *CustomValidator) passwordValidate(fl validator.FieldLevel) bool {
 		return false
 	}
 
-	fieldValue := fl.Field().String()
+	return cv.CheckPassword(fl.Field().String(), userInputs(fl.Parent())...).Valid
+}
 
-	if ok := lengthRegexp.MatchString(fieldValue); !ok {
-		return false
-	} else if ok = lowerCaseRegexp.MatchString(fieldValue); !ok {
-		return false
-	} else if ok = upperCaseRegexp.MatchString(fieldValue); !ok {
-		return false
-	} else if ok = digitRegexp.MatchString(fieldValue); !ok {
-		return false
-	} else if ok = symbolRegexp.MatchString(fieldValue); !ok {
-		return false
+// userInputs returns the email of the struct of a password, it weakens the password that contains it.
+func userInputs(parent reflect.Value) []string {
+	if parent.Kind() != reflect.Struct {
+		return nil
+	}
+
+	if email := parent.FieldByName("Email"); email.IsValid() && email.Kind() == reflect.String {
+		return []string{email.String()}
+	}
+
+	return nil
+}
+
+// parentOf returns the struct of the field in data by the namespace of a validation error,
+// e.g. "SignUpInput.Password". It is invalid when the field is not reachable through struct fields.
+func parentOf(data interface{}, namespace string) reflect.Value {
+	names := strings.Split(namespace, ".")
+	v := reflect.ValueOf(data)
+	for _, name := range names[1 : len(names)-1] {
+		v = reflect.Indirect(v)
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+		v = v.FieldByName(name)
+	}
+
+	return reflect.Indirect(v)
+}
+
+// failedRule returns the first rule of the check that did not pass.
+func failedRule(res PasswordCheck) string {
+	for _, r := range res.Rules {
+		if !r.Passed {
+			return r.Rule
+		}
 	}
 
-	return true
+	return ""
 }
@@ -0,0 +1,84 @@
+package validator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type breachList []string
+
+func (b breachList) Breached(password string) bool {
+	for _, p := range b {
+		if p == password {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCustomValidator_ValidateStruct_Password(t *testing.T) {
+	type input struct {
+		Email    string `validate:"required,email"`
+		Password string `validate:"required,password"`
+	}
+
+	policy := DefaultPasswordPolicy()
+	policy.MinScore = ScoreSomewhatGuessable
+	cv := NewCustomValidator(Policy(policy), Breaches(breachList{"kX9#vQ2!mZ7@"}))
+
+	testCases := []struct {
+		name     string
+		email    string
+		password string
+		want     map[string]string
+	}{
+		{
+			name:     "OK",
+			email:    "test@example.com",
+			password: "tR7#qLw9!zKp",
+		},
+		{
+			name:     "too short",
+			email:    "test@example.com",
+			password: "aB1!",
+			want:     map[string]string{"Password": policy.message("Password")},
+		},
+		{
+			name:     "common password",
+			email:    "test@example.com",
+			password: "Password1!",
+			want:     map[string]string{"Password": "Password is too easy to guess"},
+		},
+		{
+			name:     "keyboard pattern",
+			email:    "test@example.com",
+			password: "Qwerty123!",
+			want:     map[string]string{"Password": "Password is too easy to guess"},
+		},
+		{
+			name:     "sequence",
+			email:    "test@example.com",
+			password: "Abcdefgh1!",
+			want:     map[string]string{"Password": "Password is too easy to guess"},
+		},
+		{
+			name:     "contains the email",
+			email:    "jonathan.smithers@example.com",
+			password: "Jonathan.smithers1!",
+			want:     map[string]string{"Password": "Password is too easy to guess"},
+		},
+		{
+			name:     "breached",
+			email:    "test@example.com",
+			password: "kX9#vQ2!mZ7@",
+			want:     map[string]string{"Password": "Password has appeared in a data breach, choose another one"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := cv.ValidateStruct(&input{Email: tc.email, Password: tc.password})
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
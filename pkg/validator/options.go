@@ -0,0 +1,18 @@
+package validator
+
+// Option -.
+type Option func(cv *CustomValidator)
+
+// Policy -.
+func Policy(policy PasswordPolicy) Option {
+	return func(cv *CustomValidator) {
+		cv.policy = policy
+	}
+}
+
+// Breaches rejects the passwords that appeared in known data breaches.
+func Breaches(checker BreachChecker) Option {
+	return func(cv *CustomValidator) {
+		cv.breaches = checker
+	}
+}
@@ -0,0 +1,148 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password rules, the names are reported by PasswordPolicy.Check.
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleLowercase = "lowercase"
+	RuleUppercase = "uppercase"
+	RuleDigits    = "digits"
+	RuleSymbols   = "symbols"
+	RuleStrength  = "strength"
+	RuleBreached  = "not_breached"
+)
+
+// BreachChecker tells whether a password appeared in a known data breach.
+type BreachChecker interface {
+	Breached(password string) bool
+}
+
+// PasswordPolicy is what passwords must satisfy, a zero minimum turns its rule off.
+type PasswordPolicy struct {
+	// MinLength and MaxLength count characters.
+	MinLength int
+	MaxLength int
+	MinLower  int
+	MinUpper  int
+	MinDigit  int
+	MinSymbol int
+	// Symbols are the special characters counted by MinSymbol.
+	Symbols string
+	// MinScore is the least strength of EstimateStrength, from ScoreTooGuessable to ScoreVeryUnguessable.
+	MinScore int
+}
+
+// DefaultPasswordPolicy -.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength: 8,
+		MaxLength: 64,
+		MinLower:  1,
+		MinUpper:  1,
+		MinDigit:  1,
+		MinSymbol: 1,
+		Symbols:   "!@#$%^&*",
+	}
+}
+
+// PasswordRule is the result of a rule of the policy.
+type PasswordRule struct {
+	Rule    string
+	Passed  bool
+	Message string
+}
+
+// PasswordCheck is the result of checking a password against the policy.
+type PasswordCheck struct {
+	Valid    bool
+	Rules    []PasswordRule
+	Strength Strength
+}
+
+// Check checks the password against every rule of the policy, userInputs like the email weaken its strength.
+func (p PasswordPolicy) Check(password string, userInputs ...string) PasswordCheck {
+	var lower, upper, digits, symbols int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower++
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsDigit(r):
+			digits++
+		case strings.ContainsRune(p.Symbols, r):
+			symbols++
+		}
+	}
+	length := utf8.RuneCountInString(password)
+
+	res := PasswordCheck{Valid: true}
+	rule := func(name string, passed bool, message string) {
+		res.Rules = append(res.Rules, PasswordRule{Rule: name, Passed: passed, Message: message})
+		res.Valid = res.Valid && passed
+	}
+
+	rule(RuleMinLength, length >= p.MinLength, fmt.Sprintf("At least %d characters", p.MinLength))
+	if p.MaxLength > 0 {
+		rule(RuleMaxLength, length <= p.MaxLength, fmt.Sprintf("At most %d characters", p.MaxLength))
+	}
+	if p.MinLower > 0 {
+		rule(RuleLowercase, lower >= p.MinLower, fmt.Sprintf("At least %d lowercase letters", p.MinLower))
+	}
+	if p.MinUpper > 0 {
+		rule(RuleUppercase, upper >= p.MinUpper, fmt.Sprintf("At least %d uppercase letters", p.MinUpper))
+	}
+	if p.MinDigit > 0 {
+		rule(RuleDigits, digits >= p.MinDigit, fmt.Sprintf("At least %d digits", p.MinDigit))
+	}
+	if p.MinSymbol > 0 {
+		rule(RuleSymbols, symbols >= p.MinSymbol, fmt.Sprintf("At least %d special characters (%s)", p.MinSymbol, p.Symbols))
+	}
+
+	// a password over the limit is rejected anyway, it is not worth estimating
+	tooLong := p.MaxLength > 0 && length > p.MaxLength
+	if !tooLong {
+		res.Strength = EstimateStrength(password, userInputs...)
+	}
+	if p.MinScore > 0 {
+		rule(RuleStrength, !tooLong && res.Strength.Score >= p.MinScore, "Hard to guess")
+	}
+
+	return res
+}
+
+// message describes the rules of the policy, for the validation errors of the password field.
+func (p PasswordPolicy) message(field string) string {
+	msg := fmt.Sprintf("%s must be between %d and %d in length", field, p.MinLength, p.MaxLength)
+
+	var classes []string
+	if p.MinLower > 0 {
+		classes = append(classes, fmt.Sprintf("%d lowercase", p.MinLower))
+	}
+	if p.MinUpper > 0 {
+		classes = append(classes, fmt.Sprintf("%d uppercase", p.MinUpper))
+	}
+	if p.MinDigit > 0 {
+		classes = append(classes, fmt.Sprintf("%d digits", p.MinDigit))
+	}
+	if p.MinSymbol > 0 {
+		classes = append(classes, fmt.Sprintf("%d special characters (%s)", p.MinSymbol, p.Symbols))
+	}
+
+	switch len(classes) {
+	case 0:
+	case 1:
+		msg += ", contain at least " + classes[0]
+	default:
+		msg += ", contain at least " + strings.Join(classes[:len(classes)-1], ", ") + ", and " + classes[len(classes)-1]
+	}
+
+	return msg
+}
@@ -0,0 +1,346 @@
+package validator
+
+import (
+	_ "embed"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Strength scores, the thresholds of zxcvbn on the estimated number of guesses.
+const (
+	ScoreTooGuessable      = 0 // < 10^3 guesses
+	ScoreVeryGuessable     = 1 // < 10^6 guesses
+	ScoreSomewhatGuessable = 2 // < 10^8 guesses
+	ScoreSafelyGuessable   = 3 // < 10^10 guesses
+	ScoreVeryUnguessable   = 4
+)
+
+// Warnings of the strength estimate.
+const (
+	WarningUserInput = "Do not use your email or name in the password"
+	WarningCommon    = "Common words and passwords are easy to guess"
+	WarningRepeat    = "Repeated characters like aaa are easy to guess"
+	WarningSequence  = "Sequences like abc or 123 are easy to guess"
+	WarningKeyboard  = "Keyboard patterns like qwerty are easy to guess"
+	WarningYear      = "Years are easy to guess"
+)
+
+const (
+	minTokenLength = 3
+	// maxTokenLength bounds every match, the matches grow linearly with the length of the password.
+	maxTokenLength = 24
+	// minMatchGuesses keeps a single match from looking weaker than a few brute-forced characters.
+	minMatchGuesses = 50
+)
+
+//go:embed common.txt
+var commonPasswords string
+
+// commonRanks ranks the common passwords by frequency, 1 is the most common.
+var commonRanks = func() map[string]int {
+	ranks := make(map[string]int)
+	for i, word := range strings.Fields(commonPasswords) {
+		if _, ok := ranks[word]; !ok {
+			ranks[word] = i + 1
+		}
+	}
+	return ranks
+}()
+
+var keyboardRows = []string{
+	"1234567890-=",
+	"qwertyuiop[]",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+	"1qaz2wsx3edc4rfv5tgb6yhn7ujm8ik9ol0p",
+}
+
+var leet = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i', '!': 'i',
+	'0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+// Strength is the estimate of how hard a password is to guess.
+type Strength struct {
+	// Score is from ScoreTooGuessable to ScoreVeryUnguessable.
+	Score int
+	// Guesses is the log10 of the estimated number of guesses.
+	Guesses float64
+	// Warnings explain the patterns found in the password.
+	Warnings []string
+}
+
+// match is a guessable part of the password, runes [i, j).
+type match struct {
+	i, j    int
+	guesses float64
+	warning string
+}
+
+// EstimateStrength estimates the number of guesses an attacker needs, in the manner of zxcvbn: the password
+// is split into the cheapest sequence of patterns (common passwords, the user inputs, repeats, sequences,
+// keyboard rows, years) and brute-forced characters. userInputs, e.g. the email, make their parts cheap.
+func EstimateStrength(password string, userInputs ...string) Strength {
+	runes := []rune(password)
+	lower := []rune(strings.ToLower(password))
+	n := len(runes)
+
+	matches := make([][]match, n+1)
+	add := func(m match) {
+		m.guesses = max(m.guesses, minMatchGuesses)
+		matches[m.j] = append(matches[m.j], m)
+	}
+
+	inputRanks := userInputRanks(userInputs)
+	for i := 0; i < n; i++ {
+		for j := i + minTokenLength; j <= n && j-i <= maxTokenLength; j++ {
+			for _, m := range dictionaryMatches(runes[i:j], lower[i:j], inputRanks, commonRanks) {
+				m.i, m.j = i, j
+				add(m)
+			}
+		}
+	}
+
+	for _, m := range patternMatches(runes, lower) {
+		add(m)
+	}
+
+	// best[j] is the log10 of the guesses of the cheapest split of runes [0, j)
+	best := make([]float64, n+1)
+	prev := make([]*match, n+1)
+	for j := 1; j <= n; j++ {
+		best[j] = best[j-1] + math.Log10(cardinality(runes[j-1]))
+		prev[j] = nil
+
+		for k := range matches[j] {
+			m := &matches[j][k]
+			if g := best[m.i] + math.Log10(m.guesses); g < best[j] {
+				best[j] = g
+				prev[j] = m
+			}
+		}
+	}
+
+	var warnings []string
+	for j := n; j > 0; {
+		m := prev[j]
+		if m == nil {
+			j--
+			continue
+		}
+
+		if !slices.Contains(warnings, m.warning) {
+			warnings = append(warnings, m.warning)
+		}
+		j = m.i
+	}
+	slices.Reverse(warnings)
+
+	return Strength{
+		Score:    score(best[n]),
+		Guesses:  best[n],
+		Warnings: warnings,
+	}
+}
+
+func score(guesses float64) int {
+	switch {
+	case guesses < 3:
+		return ScoreTooGuessable
+	case guesses < 6:
+		return ScoreVeryGuessable
+	case guesses < 8:
+		return ScoreSomewhatGuessable
+	case guesses < 10:
+		return ScoreSafelyGuessable
+	default:
+		return ScoreVeryUnguessable
+	}
+}
+
+// userInputRanks ranks the user inputs and their parts, e.g. the local part and the domain of an email.
+func userInputRanks(inputs []string) map[string]int {
+	ranks := make(map[string]int)
+	rank := func(token string) {
+		if _, ok := ranks[token]; !ok && len([]rune(token)) >= minTokenLength {
+			ranks[token] = len(ranks) + 1
+		}
+	}
+
+	for _, input := range inputs {
+		input = strings.ToLower(input)
+		rank(input)
+
+		if local, _, ok := strings.Cut(input, "@"); ok {
+			rank(local)
+		}
+
+		for _, part := range strings.FieldsFunc(input, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			rank(part)
+		}
+	}
+
+	return ranks
+}
+
+// dictionaryMatches finds the token among the user inputs and the common passwords,
+// as it is, reversed and with l33t substitutions.
+func dictionaryMatches(token, lower []rune, inputs, common map[string]int) []match {
+	variations := uppercaseVariations(token)
+	word := string(lower)
+
+	reversed := slices.Clone(lower)
+	slices.Reverse(reversed)
+
+	unleeted, substitutions := unleet(lower)
+
+	type candidate struct {
+		word       string
+		multiplier float64
+	}
+
+	candidates := []candidate{{word, 1}, {string(reversed), 2}}
+	if substitutions > 0 {
+		candidates = append(candidates, candidate{unleeted, math.Pow(2, float64(substitutions))})
+	}
+
+	var res []match
+	for _, c := range candidates {
+		if rank, ok := inputs[c.word]; ok {
+			res = append(res, match{guesses: float64(rank) * variations * c.multiplier, warning: WarningUserInput})
+		}
+		if rank, ok := common[c.word]; ok {
+			res = append(res, match{guesses: float64(rank) * variations * c.multiplier, warning: WarningCommon})
+		}
+	}
+
+	return res
+}
+
+// uppercaseVariations is how many ways the letters of a word could have been capitalized.
+func uppercaseVariations(token []rune) float64 {
+	var upper, lower int
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+
+	switch {
+	case upper == 0:
+		return 1
+	case lower == 0, upper == 1 && unicode.IsUpper(token[0]):
+		return 2
+	default:
+		return math.Pow(2, float64(min(upper, lower)))
+	}
+}
+
+func unleet(lower []rune) (string, int) {
+	res := make([]rune, len(lower))
+	var substitutions int
+	for i, r := range lower {
+		if l, ok := leet[r]; ok {
+			res[i] = l
+			substitutions++
+			continue
+		}
+		res[i] = r
+	}
+
+	return string(res), substitutions
+}
+
+// patternMatches finds the repeats, sequences, keyboard rows and years.
+func patternMatches(runes, lower []rune) []match {
+	n := len(runes)
+
+	var res []match
+	for i := 0; i < n; i++ {
+		// repeats of a character
+		j := i + 1
+		for j < n && j-i < maxTokenLength && lower[j] == lower[i] {
+			j++
+		}
+		for k := i + minTokenLength; k <= j; k++ {
+			res = append(res, match{i: i, j: k, guesses: cardinality(runes[i]) * float64(k-i), warning: WarningRepeat})
+		}
+
+		// sequences like abc, 123 or zyx
+		for _, delta := range []rune{1, -1} {
+			j = i + 1
+			for j < n && j-i < maxTokenLength && lower[j]-lower[j-1] == delta && sameClass(lower[j], lower[i]) {
+				j++
+			}
+
+			start := 26.0
+			if unicode.IsDigit(lower[i]) {
+				start = 10
+			}
+			if strings.ContainsRune("a1z9", lower[i]) {
+				start = 4
+			}
+			if delta < 0 {
+				start *= 2
+			}
+
+			for k := i + minTokenLength; k <= j; k++ {
+				res = append(res, match{i: i, j: k, guesses: start * float64(k-i), warning: WarningSequence})
+			}
+		}
+
+		// keyboard rows like qwerty
+		for k := i + 4; k <= n && k-i <= maxTokenLength; k++ {
+			token := string(lower[i:k])
+			for _, row := range keyboardRows {
+				if strings.Contains(row, token) {
+					res = append(res, match{i: i, j: k, guesses: 40 * float64(k-i), warning: WarningKeyboard})
+					break
+				}
+			}
+		}
+
+		// years from 1900 to 2099
+		if i+4 <= n && isYear(lower[i:i+4]) {
+			res = append(res, match{i: i, j: i + 4, guesses: 200, warning: WarningYear})
+		}
+	}
+
+	return res
+}
+
+func sameClass(a, b rune) bool {
+	return unicode.IsDigit(a) == unicode.IsDigit(b) && unicode.IsLetter(a) == unicode.IsLetter(b)
+}
+
+func isYear(token []rune) bool {
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	century := string(token[:2])
+	return century == "19" || century == "20"
+}
+
+// cardinality is the size of the class of the character, the cost of guessing it by brute force.
+func cardinality(r rune) float64 {
+	switch {
+	case r >= '0' && r <= '9':
+		return 10
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		return 26
+	case r < unicode.MaxASCII:
+		return 33
+	default:
+		return 100
+	}
+}
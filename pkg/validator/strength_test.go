@@ -0,0 +1,64 @@
+package validator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestEstimateStrength(t *testing.T) {
+	testCases := []struct {
+		name         string
+		password     string
+		userInputs   []string
+		wantScore    int
+		wantWarnings []string
+	}{
+		{
+			name:         "common password",
+			password:     "password",
+			wantScore:    ScoreTooGuessable,
+			wantWarnings: []string{WarningCommon},
+		},
+		{
+			name:         "email",
+			password:     "Johnsmith!1",
+			userInputs:   []string{"johnsmith@example.com"},
+			wantScore:    ScoreVeryGuessable,
+			wantWarnings: []string{WarningUserInput},
+		},
+		{
+			name:      "random",
+			password:  "Hx7#kLp2$vQm",
+			wantScore: ScoreVeryUnguessable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := EstimateStrength(tc.password, tc.userInputs...)
+
+			assert.Equal(t, tc.wantScore, got.Score)
+			assert.Equal(t, tc.wantWarnings, got.Warnings)
+		})
+	}
+}
+
+func TestEstimateStrength_LongPassword(t *testing.T) {
+	// repeats and sequences are bounded by maxTokenLength, the matches grow linearly
+	for _, password := range []string{strings.Repeat("a", 4000), strings.Repeat("abcdefghijklmnopqrstuvwxyz", 150)} {
+		runes := []rune(password)
+		matches := patternMatches(runes, runes)
+		assert.LessOrEqual(t, len(matches), 3*maxTokenLength*len(runes))
+	}
+}
+
+func TestPasswordPolicy_Check_TooLong(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+	policy.MinScore = ScoreSomewhatGuessable
+
+	got := policy.Check(strings.Repeat("a", 16000))
+
+	assert.False(t, got.Valid)
+	assert.Zero(t, got.Strength)
+}